@@ -41,6 +41,7 @@ const (
 	DeleteOrderLimit // DELETE ... ORDER BY ... LIMIT ...
 	DeleteReturning
 	AlterColumnExists // ADD/DROP COLUMN IF NOT EXISTS/IF EXISTS
+	TransactionalDDL  // CREATE, ALTER, and DROP statements can be rolled back
 )
 
 type NotSupportError struct {
@@ -91,4 +92,5 @@ var flag2str = map[Feature]string{
 	DeleteOrderLimit:     "DeleteOrderLimit",
 	DeleteReturning:      "DeleteReturning",
 	AlterColumnExists:    "AlterColumnExists",
+	TransactionalDDL:     "TransactionalDDL",
 }
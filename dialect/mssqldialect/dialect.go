@@ -15,6 +15,7 @@ import (
 	"github.com/uptrace/bun/dialect"
 	"github.com/uptrace/bun/dialect/feature"
 	"github.com/uptrace/bun/dialect/sqltype"
+	"github.com/uptrace/bun/migrate/sqlschema"
 	"github.com/uptrace/bun/schema"
 )
 
@@ -48,7 +49,8 @@ func New(opts ...DialectOption) *Dialect {
 		feature.Output |
 		feature.OffsetFetch |
 		feature.UpdateFromTable |
-		feature.MSSavepoint
+		feature.MSSavepoint |
+		feature.TransactionalDDL
 
 	for _, opt := range opts {
 		opt(d)
@@ -170,3 +172,109 @@ func sqlType(field *schema.Field) string {
 	}
 	return field.DiscoveredSQLType
 }
+
+const (
+	// defaultDecimalPrecision is used for DECIMAL columns declared without the precision.
+	defaultDecimalPrecision = 18
+)
+
+var (
+	integer  = newAliases("INT", sqltype.Integer)
+	boolean  = newAliases(bitType, sqltype.Boolean)
+	double   = newAliases("FLOAT", sqltype.DoublePrecision)
+	decimal  = newAliases("DECIMAL", "NUMERIC", "DEC")
+	char     = newAliases("CHAR", "CHARACTER")
+	varchar  = newAliases(sqltype.VarChar, "CHARACTER VARYING")
+	nvarchar = newAliases("NVARCHAR", "NATIONAL CHARACTER VARYING", "NATIONAL CHAR VARYING")
+	datetime = newAliases(datetimeType, sqltype.Timestamp)
+
+	// Large object types do not have a declared length.
+	lengthless = newAliases("TEXT", "NTEXT", "IMAGE", "XML")
+)
+
+// CompareType returns true if col1 and col2 SQL types are equivalent.
+// NVARCHAR(MAX) and other (MAX) types only match columns of the same type that are also declared with (MAX),
+// while TEXT, NTEXT, IMAGE, and XML ignore the length altogether.
+func (d *Dialect) CompareType(col1, col2 sqlschema.Column) bool {
+	if col1.GetCollation() != col2.GetCollation() {
+		return false
+	}
+
+	typ1, typ2 := sqlschema.NormalizeType(col1.GetSQLType()), sqlschema.NormalizeType(col2.GetSQLType())
+
+	switch {
+	case typ1 == typ2 && lengthless.IsAlias(typ1):
+		return true
+	case integer.IsAlias(typ1) && integer.IsAlias(typ2):
+		return true
+	case decimal.IsAlias(typ1) && decimal.IsAlias(typ2):
+		return checkDecimalPrecision(col1, col2)
+	case typ1 == typ2,
+		char.IsAlias(typ1) && char.IsAlias(typ2),
+		varchar.IsAlias(typ1) && varchar.IsAlias(typ2),
+		nvarchar.IsAlias(typ1) && nvarchar.IsAlias(typ2):
+		return checkVarcharLen(col1, col2, d.DefaultVarcharLen()) &&
+			col1.GetNumericPrecision() == col2.GetNumericPrecision() && col1.GetNumericScale() == col2.GetNumericScale()
+	case boolean.IsAlias(typ1) && boolean.IsAlias(typ2),
+		double.IsAlias(typ1) && double.IsAlias(typ2),
+		datetime.IsAlias(typ1) && datetime.IsAlias(typ2):
+		return true
+	}
+	return false
+}
+
+// checkVarcharLen returns true if columns have the same VarcharLen, or,
+// if one specifies no VarcharLen and the other one has the default length for mssqldialect.
+// sqlschema.VarcharLenMax is only equal to itself.
+func checkVarcharLen(col1, col2 sqlschema.Column, defaultLen int) bool {
+	vl1, vl2 := col1.GetVarcharLen(), col2.GetVarcharLen()
+
+	if vl1 == vl2 {
+		return true
+	}
+
+	if (vl1 == 0 && vl2 == defaultLen) || (vl1 == defaultLen && vl2 == 0) {
+		return true
+	}
+	return false
+}
+
+// NormalizeDefault treats CURRENT_TIMESTAMP as GETDATE(), which is how SQL Server stores it.
+func (d *Dialect) NormalizeDefault(expr string) string {
+	expr = strings.TrimSpace(expr)
+	if expr == "current_timestamp" {
+		return "getdate()"
+	}
+	return expr
+}
+
+// checkDecimalPrecision returns true if DECIMAL columns have the same precision and scale.
+// DECIMAL is the same as DECIMAL(18, 0).
+func checkDecimalPrecision(col1, col2 sqlschema.Column) bool {
+	p1, p2 := col1.GetNumericPrecision(), col2.GetNumericPrecision()
+	if p1 == 0 {
+		p1 = defaultDecimalPrecision
+	}
+	if p2 == 0 {
+		p2 = defaultDecimalPrecision
+	}
+	return p1 == p2 && col1.GetNumericScale() == col2.GetNumericScale()
+}
+
+// typeAlias defines aliases for common data types. It is a lightweight string set implementation.
+type typeAlias map[string]struct{}
+
+// IsAlias checks if typ is one of the aliases of the data type.
+func (t typeAlias) IsAlias(typ string) bool {
+	_, ok := t[typ]
+	return ok
+}
+
+// newAliases creates a set of aliases.
+func newAliases(aliases ...string) typeAlias {
+	types := make(typeAlias)
+	for _, a := range aliases {
+		types[a] = struct{}{}
+	}
+	return types
+}
@@ -0,0 +1,598 @@
+package mssqldialect
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/internal/ordered"
+	"github.com/uptrace/bun/migrate/sqlschema"
+)
+
+// systemSchemas contain SQL Server catalog views. Schema names are compared case-insensitively.
+var systemSchemas = []string{"sys", "information_schema"}
+
+type (
+	Schema = sqlschema.BaseDatabase
+	Table  = sqlschema.BaseTable
+	Column = sqlschema.BaseColumn
+)
+
+func (d *Dialect) NewInspector(db *bun.DB, options ...sqlschema.InspectorOption) sqlschema.Inspector {
+	return newInspector(db, options...)
+}
+
+// Inspector reads the schema of an SQL Server database from the sys catalog views.
+// Table and column comments, which SQL Server stores as extended properties, are not inspected.
+type Inspector struct {
+	sqlschema.InspectorConfig
+	db *bun.DB
+}
+
+var _ sqlschema.Inspector = (*Inspector)(nil)
+
+func newInspector(db *bun.DB, options ...sqlschema.InspectorOption) *Inspector {
+	i := &Inspector{db: db}
+	i.SchemaName = db.Dialect().DefaultSchema()
+	sqlschema.ApplyInspectorOptions(&i.InspectorConfig, options...)
+	return i
+}
+
+func (in *Inspector) Inspect(ctx context.Context) (sqlschema.Database, error) {
+	dbSchema := Schema{
+		Tables:      ordered.NewMap[string, sqlschema.Table](),
+		ForeignKeys: make(map[sqlschema.ForeignKey]string),
+		Enums:       make(map[string][]string),
+	}
+
+	filter, err := sqlschema.NewTableFilter(in.InspectorConfig, systemSchemas...)
+	if err != nil {
+		return dbSchema, err
+	}
+	if filter.SchemaExcluded(in.SchemaName) {
+		return dbSchema, nil
+	}
+	exclude := in.ExcludeTables
+	if len(exclude) == 0 {
+		// Avoid getting NOT IN (NULL) if bun.In() is called with an empty slice.
+		exclude = []string{""}
+	}
+
+	var (
+		tables       []*SysTable
+		columns      []*SysColumn
+		indexColumns []*IndexColumn
+		fkColumns    []*ForeignKeyColumn
+		checks       []*CheckConstraint
+		views        []*SysView
+		triggers     []*SysTriggerEvent
+	)
+	if err := in.RunQueries(ctx, in.db,
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectTables, in.SchemaName, bun.In(exclude)).Scan(ctx, &tables)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectColumnsQuery, in.SchemaName, bun.In(exclude)).Scan(ctx, &columns)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectIndexes, in.SchemaName, bun.In(exclude)).Scan(ctx, &indexColumns)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectForeignKeys, in.SchemaName, bun.In(exclude), bun.In(exclude)).Scan(ctx, &fkColumns)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectCheckConstraints, in.SchemaName, bun.In(exclude)).Scan(ctx, &checks)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectViews, in.SchemaName, bun.In(exclude)).Scan(ctx, &views)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectTriggers, in.SchemaName, bun.In(exclude)).Scan(ctx, &triggers)
+		},
+	); err != nil {
+		return dbSchema, err
+	}
+
+	// Queries only exclude tables by their exact names, patterns and included tables are matched here.
+	tables = slices.DeleteFunc(tables, func(t *SysTable) bool {
+		return filter.Excluded(t.Schema, t.Name)
+	})
+
+	tablePKs := make(map[string]*sqlschema.PrimaryKey)
+	tableUniques := make(map[string][]sqlschema.Unique)
+	for _, idx := range groupIndexColumns(indexColumns) {
+		switch {
+		case idx.IsPrimaryKey:
+			tablePKs[idx.Table] = &sqlschema.PrimaryKey{
+				Name:    idx.Name,
+				Columns: sqlschema.NewColumns(idx.Columns...),
+			}
+		case idx.IsUniqueConstraint:
+			tableUniques[idx.Table] = append(tableUniques[idx.Table], sqlschema.Unique{
+				Name:    idx.Name,
+				Columns: sqlschema.NewColumns(idx.Columns...),
+			})
+		default:
+			if filter.Excluded(in.SchemaName, idx.Table) {
+				continue
+			}
+			dbSchema.Indexes = append(dbSchema.Indexes, sqlschema.Index{
+				Name:      idx.Name,
+				TableName: idx.Table,
+				Columns:   sqlschema.NewIndexColumns(idx.Columns...),
+				Unique:    idx.IsUnique,
+				Where:     stripBrackets(unwrapParens(idx.Filter)),
+				Include:   idx.Include,
+			})
+		}
+	}
+
+	for _, fk := range groupForeignKeyColumns(fkColumns) {
+		if filter.Excluded(in.SchemaName, fk.SourceTable) || filter.Excluded(in.SchemaName, fk.TargetTable) {
+			continue
+		}
+		dbSchema.ForeignKeys[sqlschema.ForeignKey{
+			From:     sqlschema.NewColumnReference(fk.SourceTable, fk.SourceColumns...),
+			To:       sqlschema.NewColumnReference(fk.TargetTable, fk.TargetColumns...),
+			OnDelete: referentialAction(fk.DeleteAction),
+			OnUpdate: referentialAction(fk.UpdateAction),
+		}.Canonical()] = fk.ConstraintName
+	}
+
+	tableChecks := make(map[string][]sqlschema.Check)
+	for _, c := range checks {
+		tableChecks[c.Table] = append(tableChecks[c.Table], sqlschema.Check{
+			Name:       c.ConstraintName,
+			Expression: stripBrackets(unwrapParens(c.Definition)),
+		})
+	}
+
+	tableColumns := make(map[string][]*SysColumn, len(tables))
+	for _, c := range columns {
+		tableColumns[c.Table] = append(tableColumns[c.Table], c)
+	}
+
+	for _, table := range tables {
+		if err := ctx.Err(); err != nil {
+			return dbSchema, err
+		}
+		colDefs := ordered.NewMap[string, sqlschema.Column]()
+		for _, c := range tableColumns[table.Name] {
+			col := &Column{
+				Name:            c.Name,
+				SQLType:         c.DataType,
+				VarcharLen:      c.varcharLen(),
+				DefaultValue:    defaultValue(c.Default),
+				IsNullable:      c.IsNullable,
+				IsIdentity:      c.IsIdentity,
+				Collation:       c.Collation,
+				GeneratedExpr:   stripBrackets(unwrapParens(c.GeneratedExpr)),
+				GeneratedStored: c.IsGeneratedStored,
+			}
+			if c.DataType == "decimal" || c.DataType == "numeric" {
+				col.NumericPrecision = c.NumericPrecision
+				col.NumericScale = c.NumericScale
+			}
+			colDefs.Store(c.Name, col)
+		}
+
+		dbSchema.Tables.Store(table.Name, &Table{
+			Schema:            table.Schema,
+			Name:              table.Name,
+			Columns:           colDefs,
+			PrimaryKey:        tablePKs[table.Name],
+			UniqueConstraints: tableUniques[table.Name],
+			Checks:            tableChecks[table.Name],
+		})
+	}
+
+	for _, v := range views {
+		if filter.Excluded(v.Schema, v.Name) {
+			continue
+		}
+		dbSchema.Views = append(dbSchema.Views, sqlschema.View{
+			Schema:     v.Schema,
+			Name:       v.Name,
+			Definition: viewQuery(v.Definition),
+		})
+	}
+
+	// Trigger events are listed one per row, grouped by trigger.
+	for _, t := range triggers {
+		if filter.Excluded(t.Schema, t.Table) {
+			continue
+		}
+		if n := len(dbSchema.Triggers); n > 0 && dbSchema.Triggers[n-1].TableName == t.Table && dbSchema.Triggers[n-1].Name == t.Name {
+			dbSchema.Triggers[n-1].Events = append(dbSchema.Triggers[n-1].Events, t.Event)
+			continue
+		}
+		timing := "AFTER"
+		if t.IsInsteadOf {
+			timing = "INSTEAD OF"
+		}
+		dbSchema.Triggers = append(dbSchema.Triggers, sqlschema.Trigger{
+			Name:      t.Name,
+			TableName: t.Table,
+			Timing:    timing,
+			Events:    []string{t.Event},
+			Body:      viewQuery(t.Definition),
+		})
+	}
+	return dbSchema, nil
+}
+
+// viewQuery extracts the query from the CREATE VIEW statement, which SQL Server stores verbatim.
+// The query follows the first AS keyword outside of quoted identifiers.
+func viewQuery(def string) string {
+	var quote byte
+	for i := 0; i < len(def); i++ {
+		c := def[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case (c == 'A' || c == 'a') && i > 0 && unicode.IsSpace(rune(def[i-1])) && i+2 < len(def) &&
+			(def[i+1] == 'S' || def[i+1] == 's') && unicode.IsSpace(rune(def[i+2])):
+			return strings.TrimSpace(def[i+2:])
+		}
+	}
+	return def
+}
+
+// SysView is a view together with its CREATE VIEW statement from sys.sql_modules.
+type SysView struct {
+	Schema     string `bun:"table_schema"`
+	Name       string `bun:"table_name"`
+	Definition string `bun:"definition"`
+}
+
+// SysTriggerEvent is an event that fires a DML trigger, together with the trigger's CREATE TRIGGER statement.
+// SQL Server triggers fire once per statement, and the body follows the first AS keyword in the statement.
+type SysTriggerEvent struct {
+	Schema      string `bun:"table_schema"`
+	Table       string `bun:"table_name"`
+	Name        string `bun:"trigger_name"`
+	IsInsteadOf bool   `bun:"is_instead_of_trigger"`
+	Event       string `bun:"event"`
+	Definition  string `bun:"definition"`
+}
+
+type SysTable struct {
+	Schema string `bun:"table_schema"`
+	Name   string `bun:"table_name"`
+}
+
+type SysColumn struct {
+	Table    string `bun:"table_name"`
+	Name     string `bun:"column_name"`
+	DataType string `bun:"data_type"`
+
+	// MaxLength is the length of the column in bytes, or -1 for (MAX) types.
+	MaxLength         int    `bun:"max_length"`
+	NumericPrecision  int    `bun:"numeric_precision"`
+	NumericScale      int    `bun:"numeric_scale"`
+	Default           string `bun:"default_value"`
+	IsNullable        bool   `bun:"is_nullable"`
+	IsIdentity        bool   `bun:"is_identity"`
+	Collation         string `bun:"collation"`
+	GeneratedExpr     string `bun:"generated_expr"`
+	IsGeneratedStored bool   `bun:"is_generated_stored"`
+}
+
+// varcharLen converts the length of character and binary columns from bytes to characters.
+// Other data types do not have a declared length.
+func (c *SysColumn) varcharLen() int {
+	if c.MaxLength == -1 {
+		return sqlschema.VarcharLenMax
+	}
+	switch c.DataType {
+	case "nchar", "nvarchar":
+		return c.MaxLength / 2
+	case "char", "varchar", "binary", "varbinary":
+		return c.MaxLength
+	}
+	return 0
+}
+
+// defaultValue normalizes the definition of the default constraint, e.g. ('blue') or ((1)),
+// so that it can be compared with the one declared in the model.
+func defaultValue(def string) string {
+	def = unwrapParens(def)
+	if strings.HasPrefix(def, "N'") {
+		def = def[1:]
+	}
+	if strings.HasPrefix(def, "'") && strings.HasSuffix(def, "'") {
+		return strings.Trim(def, "'")
+	}
+	return sqlschema.LowerExpr(def)
+}
+
+// unwrapParens removes the redundant parentheses that SQL Server adds around
+// the stored definitions of defaults, checks, and computed columns.
+func unwrapParens(s string) string {
+	s = strings.TrimSpace(s)
+	for strings.HasPrefix(s, "(") && closingParen(s) == len(s)-1 {
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	return s
+}
+
+// closingParen returns the position of the parenthesis that closes the one at s[0].
+func closingParen(s string) int {
+	var depth int
+	var inLiteral bool
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'':
+			inLiteral = !inLiteral
+		case inLiteral:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// stripBrackets removes the square brackets around identifiers, e.g. [page_count] > (0) becomes page_count > (0).
+func stripBrackets(s string) string {
+	var b strings.Builder
+	var inLiteral bool
+	for _, r := range s {
+		switch {
+		case r == '\'':
+			inLiteral = !inLiteral
+		case !inLiteral && (r == '[' || r == ']'):
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// IndexColumn is a row in sys.index_columns, which describes one column of the index.
+type IndexColumn struct {
+	Table              string `bun:"table_name"`
+	IndexName          string `bun:"index_name"`
+	IsPrimaryKey       bool   `bun:"is_primary_key"`
+	IsUniqueConstraint bool   `bun:"is_unique_constraint"`
+	IsUnique           bool   `bun:"is_unique"`
+	Filter             string `bun:"filter"`
+	Column             string `bun:"column_name"`
+	IsIncluded         bool   `bun:"is_included"`
+}
+
+// Index combines the columns of an index or a constraint backed by it.
+type Index struct {
+	Table              string
+	Name               string
+	IsPrimaryKey       bool
+	IsUniqueConstraint bool
+	IsUnique           bool
+	Filter             string
+	Columns            []string
+	Include            []string
+}
+
+// groupIndexColumns combines consecutive rows that belong to the same index.
+// Rows are expected to be sorted by table, index name, and the position of the column in the index.
+func groupIndexColumns(rows []*IndexColumn) []*Index {
+	var indexes []*Index
+	var last *Index
+	for _, row := range rows {
+		if last == nil || last.Table != row.Table || last.Name != row.IndexName {
+			last = &Index{
+				Table:              row.Table,
+				Name:               row.IndexName,
+				IsPrimaryKey:       row.IsPrimaryKey,
+				IsUniqueConstraint: row.IsUniqueConstraint,
+				IsUnique:           row.IsUnique,
+				Filter:             row.Filter,
+			}
+			indexes = append(indexes, last)
+		}
+		if row.IsIncluded {
+			last.Include = append(last.Include, row.Column)
+		} else {
+			last.Columns = append(last.Columns, row.Column)
+		}
+	}
+	return indexes
+}
+
+// ForeignKeyColumn is a row in sys.foreign_key_columns, which describes one column of the foreign key.
+type ForeignKeyColumn struct {
+	ConstraintName string `bun:"constraint_name"`
+	SourceTable    string `bun:"source_table"`
+	SourceColumn   string `bun:"source_column"`
+	TargetTable    string `bun:"target_table"`
+	TargetColumn   string `bun:"target_column"`
+	DeleteAction   string `bun:"delete_action"`
+	UpdateAction   string `bun:"update_action"`
+}
+
+// ForeignKey combines the columns of a multi-column foreign key.
+type ForeignKey struct {
+	ForeignKeyColumn
+	SourceColumns []string
+	TargetColumns []string
+}
+
+// groupForeignKeyColumns combines consecutive rows that belong to the same foreign key.
+// Rows are expected to be sorted by constraint name and column position.
+func groupForeignKeyColumns(rows []*ForeignKeyColumn) []*ForeignKey {
+	var fks []*ForeignKey
+	var last *ForeignKey
+	for _, row := range rows {
+		if last == nil || last.ConstraintName != row.ConstraintName {
+			last = &ForeignKey{ForeignKeyColumn: *row}
+			fks = append(fks, last)
+		}
+		last.SourceColumns = append(last.SourceColumns, row.SourceColumn)
+		last.TargetColumns = append(last.TargetColumns, row.TargetColumn)
+	}
+	return fks
+}
+
+// referentialAction converts the action description, e.g. SET_NULL, to sqlschema.ReferentialAction.
+func referentialAction(desc string) sqlschema.ReferentialAction {
+	return sqlschema.NewReferentialAction(strings.ReplaceAll(desc, "_", " "))
+}
+
+type CheckConstraint struct {
+	Table          string `bun:"table_name"`
+	ConstraintName string `bun:"constraint_name"`
+	Definition     string `bun:"definition"`
+}
+
+const (
+	// sqlInspectTables retrieves all user-defined tables in the selected schema.
+	// Pass bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
+	sqlInspectTables = `
+SELECT s.name AS table_schema, t.name AS table_name
+FROM sys.tables t
+	JOIN sys.schemas s ON s.schema_id = t.schema_id
+WHERE s.name = ?
+	AND t.is_ms_shipped = 0
+	AND t.name NOT IN (?)
+ORDER BY s.name, t.name
+`
+
+	// sqlInspectViews retrieves the definitions of user views in the schema.
+	sqlInspectViews = `
+SELECT s.name AS table_schema, v.name AS table_name, m.definition
+FROM sys.views v
+	JOIN sys.schemas s ON s.schema_id = v.schema_id
+	JOIN sys.sql_modules m ON m.object_id = v.object_id
+WHERE s.name = ?
+	AND v.is_ms_shipped = 0
+	AND v.name NOT IN (?)
+ORDER BY s.name, v.name
+`
+
+	// sqlInspectTriggers retrieves the events of DML triggers on user tables in the schema, ordered by trigger.
+	sqlInspectTriggers = `
+SELECT
+	s.name AS table_schema,
+	t.name AS table_name,
+	tr.name AS trigger_name,
+	tr.is_instead_of_trigger,
+	te.type_desc AS event,
+	m.definition
+FROM sys.triggers tr
+	JOIN sys.tables t ON t.object_id = tr.parent_id
+	JOIN sys.schemas s ON s.schema_id = t.schema_id
+	JOIN sys.trigger_events te ON te.object_id = tr.object_id
+	JOIN sys.sql_modules m ON m.object_id = tr.object_id
+WHERE s.name = ?
+	AND tr.is_ms_shipped = 0
+	AND t.name NOT IN (?)
+ORDER BY s.name, t.name, tr.name, te.type
+`
+
+	// sqlInspectColumnsQuery retrieves column definitions for all user tables in the schema, ordered by table and column_id.
+	// Pass schema name and bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
+	sqlInspectColumnsQuery = `
+SELECT
+	t.name AS table_name,
+	c.name AS column_name,
+	ty.name AS data_type,
+	c.max_length AS max_length,
+	c.precision AS numeric_precision,
+	c.scale AS numeric_scale,
+	COALESCE(dc.definition, '') AS default_value,
+	c.is_nullable AS is_nullable,
+	c.is_identity AS is_identity,
+	CASE
+		WHEN c.collation_name IS NULL THEN ''
+		WHEN c.collation_name = CAST(DATABASEPROPERTYEX(DB_NAME(), 'Collation') AS sysname) THEN ''
+		ELSE c.collation_name
+	END AS collation,
+	COALESCE(cc.definition, '') AS generated_expr,
+	COALESCE(cc.is_persisted, 0) AS is_generated_stored
+FROM sys.columns c
+	JOIN sys.tables t ON t.object_id = c.object_id
+	JOIN sys.schemas s ON s.schema_id = t.schema_id
+	JOIN sys.types ty ON ty.user_type_id = c.user_type_id
+	LEFT JOIN sys.default_constraints dc ON dc.object_id = c.default_object_id
+	LEFT JOIN sys.computed_columns cc ON cc.object_id = c.object_id AND cc.column_id = c.column_id
+WHERE s.name = ?
+	AND t.name NOT IN (?)
+ORDER BY t.name, c.column_id
+`
+
+	// sqlInspectIndexes retrieves indexes and the PRIMARY KEY and UNIQUE constraints they back, one row per column.
+	// Key columns are sorted by their position in the index and followed by the included columns.
+	// Pass schema name and bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
+	sqlInspectIndexes = `
+SELECT
+	t.name AS table_name,
+	i.name AS index_name,
+	i.is_primary_key AS is_primary_key,
+	i.is_unique_constraint AS is_unique_constraint,
+	i.is_unique AS is_unique,
+	COALESCE(i.filter_definition, '') AS filter,
+	c.name AS column_name,
+	ic.is_included_column AS is_included
+FROM sys.indexes i
+	JOIN sys.tables t ON t.object_id = i.object_id
+	JOIN sys.schemas s ON s.schema_id = t.schema_id
+	JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+	JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+WHERE s.name = ?
+	AND i.type > 0
+	AND i.is_hypothetical = 0
+	AND t.name NOT IN (?)
+ORDER BY t.name, i.name, ic.is_included_column, ic.key_ordinal, ic.index_column_id
+`
+
+	// sqlInspectForeignKeys retrieves foreign keys defined on user tables, one row per column.
+	// Pass schema name and bun.In([]string{...}) twice to exclude tables on either side of the reference.
+	sqlInspectForeignKeys = `
+SELECT
+	fk.name AS constraint_name,
+	st.name AS source_table,
+	sc.name AS source_column,
+	tt.name AS target_table,
+	tc.name AS target_column,
+	fk.delete_referential_action_desc AS delete_action,
+	fk.update_referential_action_desc AS update_action
+FROM sys.foreign_keys fk
+	JOIN sys.foreign_key_columns fkc ON fkc.constraint_object_id = fk.object_id
+	JOIN sys.tables st ON st.object_id = fk.parent_object_id
+	JOIN sys.schemas s ON s.schema_id = st.schema_id
+	JOIN sys.columns sc ON sc.object_id = fkc.parent_object_id AND sc.column_id = fkc.parent_column_id
+	JOIN sys.tables tt ON tt.object_id = fk.referenced_object_id
+	JOIN sys.columns tc ON tc.object_id = fkc.referenced_object_id AND tc.column_id = fkc.referenced_column_id
+WHERE s.name = ?
+	AND st.name NOT IN (?)
+	AND tt.name NOT IN (?)
+ORDER BY fk.name, fkc.constraint_column_id
+`
+
+	// sqlInspectCheckConstraints retrieves CHECK constraints defined on user tables.
+	// Pass schema name and bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
+	sqlInspectCheckConstraints = `
+SELECT
+	t.name AS table_name,
+	cc.name AS constraint_name,
+	cc.definition AS definition
+FROM sys.check_constraints cc
+	JOIN sys.tables t ON t.object_id = cc.parent_object_id
+	JOIN sys.schemas s ON s.schema_id = t.schema_id
+WHERE s.name = ?
+	AND t.name NOT IN (?)
+ORDER BY t.name, cc.name
+`
+)
@@ -15,6 +15,7 @@ import (
 	"github.com/uptrace/bun/dialect"
 	"github.com/uptrace/bun/dialect/feature"
 	"github.com/uptrace/bun/dialect/sqltype"
+	"github.com/uptrace/bun/migrate/sqlschema"
 	"github.com/uptrace/bun/schema"
 )
 
@@ -217,6 +218,15 @@ func (d *Dialect) AppendSequence(b []byte, _ *schema.Table, _ *schema.Field) []b
 	return append(b, " AUTO_INCREMENT"...)
 }
 
+// AppendColumnSequence appends the type of an auto-increment column followed by AUTO_INCREMENT.
+func (d *Dialect) AppendColumnSequence(fmter schema.Formatter, b []byte, col sqlschema.Column) ([]byte, error) {
+	b, err := col.AppendQuery(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, " AUTO_INCREMENT"...), nil
+}
+
 func (d *Dialect) DefaultSchema() string {
 	return "mydb"
 }
@@ -227,3 +237,125 @@ func sqlType(field *schema.Field) string {
 	}
 	return field.DiscoveredSQLType
 }
+
+const (
+	// defaultDecimalPrecision is used for DECIMAL columns declared without the precision.
+	defaultDecimalPrecision = 10
+)
+
+var (
+	integer  = newAliases("INT", sqltype.Integer)
+	double   = newAliases("DOUBLE", sqltype.DoublePrecision, sqltype.Real)
+	boolean  = newAliases("BOOL", sqltype.Boolean)
+	decimal  = newAliases("DECIMAL", "NUMERIC", "DEC", "FIXED")
+	char     = newAliases("CHAR", "CHARACTER")
+	varchar  = newAliases(sqltype.VarChar, "CHARACTER VARYING")
+	datetime = newAliases(datetimeType, sqltype.Timestamp)
+
+	// MariaDB stores JSON columns as LONGTEXT with a JSON_VALID check.
+	json = newAliases(sqltype.JSON, "LONGTEXT")
+
+	integerTypes = newAliases("TINYINT", sqltype.SmallInt, "MEDIUMINT", "INT", sqltype.Integer, sqltype.BigInt)
+)
+
+// CompareType returns true if col1 and col2 SQL types are equivalent.
+// Display widths of integer types, e.g. INT(11), are ignored, as they do not affect the range of values
+// and MySQL 8 no longer reports them. The exception is TINYINT(1), which is how MySQL stores BOOL columns.
+func (d *Dialect) CompareType(col1, col2 sqlschema.Column) bool {
+	if col1.GetCollation() != col2.GetCollation() {
+		return false
+	}
+
+	typ1, typ2 := sqlschema.NormalizeType(col1.GetSQLType()), sqlschema.NormalizeType(col2.GetSQLType())
+
+	switch {
+	case isBoolean(typ1, col1) || isBoolean(typ2, col2):
+		return isBoolean(typ1, col1) && isBoolean(typ2, col2)
+	case integer.IsAlias(typ1) && integer.IsAlias(typ2):
+		return true
+	case typ1 == typ2 && integerTypes.IsAlias(typ1):
+		return true
+	case decimal.IsAlias(typ1) && decimal.IsAlias(typ2):
+		return checkDecimalPrecision(col1, col2)
+	case typ1 == typ2,
+		char.IsAlias(typ1) && char.IsAlias(typ2),
+		varchar.IsAlias(typ1) && varchar.IsAlias(typ2):
+		return checkVarcharLen(col1, col2, d.DefaultVarcharLen()) &&
+			col1.GetNumericPrecision() == col2.GetNumericPrecision() && col1.GetNumericScale() == col2.GetNumericScale()
+	case double.IsAlias(typ1) && double.IsAlias(typ2),
+		datetime.IsAlias(typ1) && datetime.IsAlias(typ2),
+		json.IsAlias(typ1) && json.IsAlias(typ2):
+		return true
+	}
+	return false
+}
+
+// isBoolean returns true for BOOL columns and TINYINT(1), which MySQL uses to store them.
+// The display width is kept in VarcharLen, as that is where both inspectors put the length modifier.
+func isBoolean(typ string, col sqlschema.Column) bool {
+	return boolean.IsAlias(typ) || (typ == "TINYINT" && col.GetVarcharLen() == 1)
+}
+
+// checkVarcharLen returns true if columns have the same VarcharLen, or,
+// if one specifies no VarcharLen and the other one has the default length for mysqldialect.
+func checkVarcharLen(col1, col2 sqlschema.Column, defaultLen int) bool {
+	vl1, vl2 := col1.GetVarcharLen(), col2.GetVarcharLen()
+
+	if vl1 == vl2 {
+		return true
+	}
+
+	if (vl1 == 0 && vl2 == defaultLen) || (vl1 == defaultLen && vl2 == 0) {
+		return true
+	}
+	return false
+}
+
+// NormalizeDefault treats NOW(), LOCALTIME and LOCALTIMESTAMP as synonyms of CURRENT_TIMESTAMP,
+// which is how MySQL reports them, keeping the fractional seconds precision, e.g. NOW(3) ~ CURRENT_TIMESTAMP(3).
+func (d *Dialect) NormalizeDefault(expr string) string {
+	expr = strings.TrimSpace(expr)
+	name, args := expr, "()"
+	if i := strings.IndexByte(expr, '('); i != -1 {
+		name, args = strings.TrimSpace(expr[:i]), expr[i:]
+	}
+	switch name {
+	case "now", "current_timestamp", "localtime", "localtimestamp":
+		if args == "()" {
+			return "current_timestamp"
+		}
+		return "current_timestamp" + args
+	}
+	return expr
+}
+
+// checkDecimalPrecision returns true if DECIMAL columns have the same precision and scale.
+// DECIMAL is the same as DECIMAL(10, 0).
+func checkDecimalPrecision(col1, col2 sqlschema.Column) bool {
+	p1, p2 := col1.GetNumericPrecision(), col2.GetNumericPrecision()
+	if p1 == 0 {
+		p1 = defaultDecimalPrecision
+	}
+	if p2 == 0 {
+		p2 = defaultDecimalPrecision
+	}
+	return p1 == p2 && col1.GetNumericScale() == col2.GetNumericScale()
+}
+
+// typeAlias defines aliases for common data types. It is a lightweight string set implementation.
+type typeAlias map[string]struct{}
+
+// IsAlias checks if typ is one of the aliases of the data type.
+func (t typeAlias) IsAlias(typ string) bool {
+	_, ok := t[typ]
+	return ok
+}
+
+// newAliases creates a set of aliases.
+func newAliases(aliases ...string) typeAlias {
+	types := make(typeAlias)
+	for _, a := range aliases {
+		types[a] = struct{}{}
+	}
+	return types
+}
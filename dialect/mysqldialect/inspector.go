@@ -0,0 +1,488 @@
+package mysqldialect
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/internal/ordered"
+	"github.com/uptrace/bun/migrate/sqlschema"
+	"github.com/uptrace/bun/schema"
+)
+
+// systemSchemas are created by the server for its own metadata and are never inspected.
+var systemSchemas = []string{"information_schema", "mysql", "performance_schema", "sys"}
+
+type (
+	Schema = sqlschema.BaseDatabase
+	Table  = sqlschema.BaseTable
+	Column = sqlschema.BaseColumn
+)
+
+func (d *Dialect) NewInspector(db *bun.DB, options ...sqlschema.InspectorOption) sqlschema.Inspector {
+	return newInspector(db, options...)
+}
+
+// Inspector reads the schema of a MySQL or MariaDB database from information_schema.
+//
+// In MySQL a schema is a synonym for a database. Because the name of the database is not known
+// in advance, the dialect's DefaultSchema is treated as an alias for the current database.
+//
+// Unique indexes and UNIQUE constraints are the same thing in MySQL, so both are reported as unique constraints.
+type Inspector struct {
+	sqlschema.InspectorConfig
+	db *bun.DB
+}
+
+var _ sqlschema.Inspector = (*Inspector)(nil)
+
+func newInspector(db *bun.DB, options ...sqlschema.InspectorOption) *Inspector {
+	i := &Inspector{db: db}
+	i.SchemaName = db.Dialect().DefaultSchema()
+	sqlschema.ApplyInspectorOptions(&i.InspectorConfig, options...)
+	return i
+}
+
+func (in *Inspector) Inspect(ctx context.Context) (sqlschema.Database, error) {
+	dbSchema := Schema{
+		Tables:      ordered.NewMap[string, sqlschema.Table](),
+		ForeignKeys: make(map[sqlschema.ForeignKey]string),
+		Enums:       make(map[string][]string),
+	}
+
+	filter, err := sqlschema.NewTableFilter(in.InspectorConfig, systemSchemas...)
+	if err != nil {
+		return dbSchema, err
+	}
+	if filter.SchemaExcluded(in.SchemaName) {
+		return dbSchema, nil
+	}
+	exclude := in.ExcludeTables
+	if len(exclude) == 0 {
+		// Avoid getting NOT IN (NULL) if bun.In() is called with an empty slice.
+		exclude = []string{""}
+	}
+
+	schemaName := in.schemaName()
+
+	var (
+		tables       []*InformationSchemaTable
+		columns      []*InformationSchemaColumn
+		keys         []*KeyColumn
+		indexColumns []*IndexColumn
+		views        []*View
+		triggers     []*Trigger
+	)
+	if err := in.RunQueries(ctx, in.db,
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectTables, schemaName, bun.In(exclude)).Scan(ctx, &tables)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectColumnsQuery, schemaName, bun.In(exclude)).Scan(ctx, &columns)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectConstraints, schemaName, bun.In(exclude)).Scan(ctx, &keys)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectIndexes, schemaName, bun.In(exclude)).Scan(ctx, &indexColumns)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectViews, schemaName, bun.In(exclude)).Scan(ctx, &views)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectTriggers, schemaName, bun.In(exclude)).Scan(ctx, &triggers)
+		},
+	); err != nil {
+		return dbSchema, err
+	}
+
+	// Queries only exclude tables by their exact names, patterns and included tables are matched here.
+	tables = slices.DeleteFunc(tables, func(t *InformationSchemaTable) bool {
+		return filter.Excluded(in.SchemaName, t.Name)
+	})
+
+	// Indexes which back PRIMARY KEY, UNIQUE and FOREIGN KEY constraints are part of the constraint definition.
+	constraintIndexes := make(map[string]bool)
+	tablePKs := make(map[string]*sqlschema.PrimaryKey)
+	tableUniques := make(map[string][]sqlschema.Unique)
+	for _, c := range groupKeyColumns(keys) {
+		constraintIndexes[c.Table+"."+c.ConstraintName] = true
+
+		switch c.ConstraintType {
+		case constraintPrimaryKey:
+			tablePKs[c.Table] = &sqlschema.PrimaryKey{
+				Name:    c.ConstraintName,
+				Columns: sqlschema.NewColumns(c.Columns...),
+			}
+		case constraintUnique:
+			tableUniques[c.Table] = append(tableUniques[c.Table], sqlschema.Unique{
+				Name:    c.ConstraintName,
+				Columns: sqlschema.NewColumns(c.Columns...),
+			})
+		case constraintForeignKey:
+			if filter.Excluded(in.SchemaName, c.Table) || filter.Excluded(in.SchemaName, c.TargetTable) {
+				continue
+			}
+			dbSchema.ForeignKeys[sqlschema.ForeignKey{
+				From:     sqlschema.NewColumnReference(c.Table, c.Columns...),
+				To:       sqlschema.NewColumnReference(c.TargetTable, c.TargetColumns...),
+				OnDelete: sqlschema.NewReferentialAction(c.DeleteRule),
+				OnUpdate: sqlschema.NewReferentialAction(c.UpdateRule),
+				Match:    matchType(c.MatchOption),
+			}.Canonical()] = c.ConstraintName
+		}
+	}
+
+IndexLoop:
+	for _, idx := range groupIndexColumns(indexColumns) {
+		if constraintIndexes[idx.TableName+"."+idx.Name] || filter.Excluded(in.SchemaName, idx.TableName) {
+			continue
+		}
+		// Expressions of functional indexes cannot be read consistently across MySQL and MariaDB versions.
+		for _, col := range idx.Columns {
+			if col.Name == "" {
+				continue IndexLoop
+			}
+		}
+		dbSchema.Indexes = append(dbSchema.Indexes, idx)
+	}
+
+	tableColumns := make(map[string][]*InformationSchemaColumn, len(tables))
+	for _, c := range columns {
+		tableColumns[c.Table] = append(tableColumns[c.Table], c)
+	}
+
+	for _, table := range tables {
+		if err := ctx.Err(); err != nil {
+			return dbSchema, err
+		}
+		colDefs := ordered.NewMap[string, sqlschema.Column]()
+		for _, c := range tableColumns[table.Name] {
+			collation := c.Collation
+			if collation == table.Collation {
+				collation = ""
+			}
+
+			col := &Column{
+				Name:            c.Name,
+				SQLType:         c.DataType,
+				DefaultValue:    c.defaultValue(),
+				IsNullable:      c.IsNullable,
+				IsAutoIncrement: c.IsAutoIncrement,
+				Comment:         c.Comment,
+				Collation:       collation,
+				GeneratedExpr:   c.GeneratedExpr,
+				GeneratedStored: c.IsGeneratedStored,
+			}
+			switch c.DataType {
+			case "char", "varchar":
+				col.VarcharLen = c.CharMaxLen
+			case "decimal":
+				col.NumericPrecision = c.NumericPrecision
+				col.NumericScale = c.NumericScale
+			case "tinyint":
+				// BOOL columns are stored as TINYINT(1); keep the display width
+				// to tell them apart from other TINYINT columns.
+				if c.ColumnType == "tinyint(1)" {
+					col.VarcharLen = 1
+				}
+			}
+			colDefs.Store(c.Name, col)
+		}
+
+		dbSchema.Tables.Store(table.Name, &Table{
+			Schema:            in.SchemaName,
+			Name:              table.Name,
+			Columns:           colDefs,
+			PrimaryKey:        tablePKs[table.Name],
+			UniqueConstraints: tableUniques[table.Name],
+			Comment:           table.Comment,
+		})
+	}
+
+	for _, v := range views {
+		if filter.Excluded(in.SchemaName, v.Name) {
+			continue
+		}
+		dbSchema.Views = append(dbSchema.Views, sqlschema.View{
+			Schema:     in.SchemaName,
+			Name:       v.Name,
+			Definition: v.Definition,
+		})
+	}
+
+	for _, t := range triggers {
+		if filter.Excluded(in.SchemaName, t.Table) {
+			continue
+		}
+		dbSchema.Triggers = append(dbSchema.Triggers, sqlschema.Trigger{
+			Name:       t.Name,
+			TableName:  t.Table,
+			Timing:     t.Timing,
+			Events:     []string{t.Event},
+			ForEachRow: t.Orientation == "ROW",
+			Body:       t.Body,
+		})
+	}
+	return dbSchema, nil
+}
+
+// schemaName returns the name of the inspected database as a query argument.
+func (in *Inspector) schemaName() schema.QueryAppender {
+	if in.SchemaName == "" || in.SchemaName == in.db.Dialect().DefaultSchema() {
+		return bun.Safe("DATABASE()")
+	}
+	return bun.SafeQuery("?", in.SchemaName)
+}
+
+// View is a row from information_schema.views. MySQL stores the query with fully qualified
+// and quoted identifiers, e.g. select `db`.`users`.`id` AS `id` from `db`.`users`.
+type View struct {
+	Name       string `bun:"table_name"`
+	Definition string `bun:"view_definition"`
+}
+
+// Trigger is a row from information_schema.triggers. MySQL triggers fire on a single event for each row.
+type Trigger struct {
+	Name        string `bun:"trigger_name"`
+	Table       string `bun:"table_name"`
+	Timing      string `bun:"action_timing"`
+	Event       string `bun:"event_manipulation"`
+	Orientation string `bun:"action_orientation"`
+	Body        string `bun:"action_statement"`
+}
+
+type InformationSchemaTable struct {
+	Schema    string `bun:"table_schema"`
+	Name      string `bun:"table_name"`
+	Collation string `bun:"table_collation"`
+	Comment   string `bun:"table_comment"`
+}
+
+type InformationSchemaColumn struct {
+	Table             string `bun:"table_name"`
+	Name              string `bun:"column_name"`
+	DataType          string `bun:"data_type"`
+	ColumnType        string `bun:"column_type"`
+	CharMaxLen        int    `bun:"varchar_len"`
+	NumericPrecision  int    `bun:"numeric_precision"`
+	NumericScale      int    `bun:"numeric_scale"`
+	Default           string `bun:"default_value"`
+	HasDefault        bool   `bun:"has_default"`
+	IsDefaultExpr     bool   `bun:"is_default_expr"`
+	IsNullable        bool   `bun:"is_nullable"`
+	IsAutoIncrement   bool   `bun:"is_auto_increment"`
+	Comment           string `bun:"column_comment"`
+	Collation         string `bun:"collation"`
+	GeneratedExpr     string `bun:"generated_expr"`
+	IsGeneratedStored bool   `bun:"is_generated_stored"`
+}
+
+// defaultValue normalizes the column's default so that it can be compared with the one declared in the model.
+// MySQL reports literal defaults without quotes and marks expressions as DEFAULT_GENERATED
+// (except for CURRENT_TIMESTAMP in older versions), while MariaDB quotes string literals and reports the absence of a default as "NULL".
+func (c *InformationSchemaColumn) defaultValue() string {
+	def := c.Default
+	switch {
+	case !c.HasDefault || def == "NULL":
+		return ""
+	case strings.HasPrefix(def, "'") && strings.HasSuffix(def, "'"):
+		return strings.Trim(def, "'")
+	case c.IsDefaultExpr, strings.HasPrefix(strings.ToUpper(def), "CURRENT_TIMESTAMP"):
+		return sqlschema.LowerExpr(def)
+	}
+	return def
+}
+
+const (
+	constraintPrimaryKey = "PRIMARY KEY"
+	constraintUnique     = "UNIQUE"
+	constraintForeignKey = "FOREIGN KEY"
+)
+
+// KeyColumn describes one column of a PRIMARY KEY, UNIQUE, or FOREIGN KEY constraint.
+type KeyColumn struct {
+	Table          string `bun:"table_name"`
+	ConstraintName string `bun:"constraint_name"`
+	ConstraintType string `bun:"constraint_type"`
+	Column         string `bun:"column_name"`
+	TargetTable    string `bun:"target_table"`
+	TargetColumn   string `bun:"target_column"`
+	UpdateRule     string `bun:"update_rule"`
+	DeleteRule     string `bun:"delete_rule"`
+	MatchOption    string `bun:"match_option"`
+}
+
+// Constraint combines the columns of a multi-column constraint.
+type Constraint struct {
+	KeyColumn
+	Columns       []string
+	TargetColumns []string
+}
+
+// groupKeyColumns combines consecutive rows that belong to the same constraint.
+// Rows are expected to be sorted by table, constraint name, and column position.
+func groupKeyColumns(rows []*KeyColumn) []*Constraint {
+	var constraints []*Constraint
+	var last *Constraint
+	for _, row := range rows {
+		if last == nil || last.Table != row.Table || last.ConstraintName != row.ConstraintName {
+			last = &Constraint{KeyColumn: *row}
+			constraints = append(constraints, last)
+		}
+		last.Columns = append(last.Columns, row.Column)
+		last.TargetColumns = append(last.TargetColumns, row.TargetColumn)
+	}
+	return constraints
+}
+
+// IndexColumn is a row in information_schema.statistics, which describes one column of the index.
+type IndexColumn struct {
+	Table     string `bun:"table_name"`
+	IndexName string `bun:"index_name"`
+	IsUnique  bool   `bun:"is_unique"`
+	Column    string `bun:"column_name"`
+	Method    string `bun:"method"`
+}
+
+// groupIndexColumns combines consecutive rows that belong to the same index.
+// Rows are expected to be sorted by table, index name, and column position.
+func groupIndexColumns(rows []*IndexColumn) []sqlschema.Index {
+	var indexes []sqlschema.Index
+	for _, row := range rows {
+		last := len(indexes) - 1
+		if last < 0 || indexes[last].TableName != row.Table || indexes[last].Name != row.IndexName {
+			indexes = append(indexes, sqlschema.Index{
+				Name:      row.IndexName,
+				TableName: row.Table,
+				Unique:    row.IsUnique,
+				Method:    strings.ToLower(row.Method),
+			})
+			last++
+		}
+		indexes[last].Columns = append(indexes[last].Columns, sqlschema.IndexColumn{Name: row.Column})
+	}
+	return indexes
+}
+
+// matchType converts the match option reported by MySQL to sqlschema.MatchType.
+// MySQL parses, but does not enforce MATCH clauses, and reports "NONE" when the clause is omitted.
+func matchType(s string) sqlschema.MatchType {
+	if strings.EqualFold(s, "NONE") {
+		return sqlschema.MatchSimple
+	}
+	return sqlschema.NewMatchType(s)
+}
+
+const (
+	// sqlInspectTables retrieves all user-defined tables in the selected schema.
+	// Pass schema name and bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
+	sqlInspectTables = `
+SELECT
+	t.table_schema AS table_schema,
+	t.table_name AS table_name,
+	t.table_collation AS table_collation,
+	t.table_comment AS table_comment
+FROM information_schema.tables t
+WHERE t.table_schema = ?
+	AND t.table_type = 'BASE TABLE'
+	AND t.table_name NOT IN (?)
+ORDER BY t.table_schema, t.table_name
+`
+
+	// sqlInspectViews retrieves the queries of all views in the schema.
+	sqlInspectViews = `
+SELECT v.table_name AS table_name, v.view_definition AS view_definition
+FROM information_schema.views v
+WHERE v.table_schema = ?
+	AND v.table_name NOT IN (?)
+ORDER BY v.table_name
+`
+
+	// sqlInspectTriggers retrieves the triggers on all tables in the schema.
+	sqlInspectTriggers = `
+SELECT
+	tg.trigger_name AS trigger_name,
+	tg.event_object_table AS table_name,
+	tg.action_timing AS action_timing,
+	tg.event_manipulation AS event_manipulation,
+	tg.action_orientation AS action_orientation,
+	tg.action_statement AS action_statement
+FROM information_schema.triggers tg
+WHERE tg.event_object_schema = ?
+	AND tg.event_object_table NOT IN (?)
+ORDER BY tg.event_object_table, tg.trigger_name
+`
+
+	// sqlInspectColumnsQuery retrieves column definitions for all tables in the schema, ordered by table and position.
+	// Information schema columns are aliased explicitly, because MySQL 8 reports their names in upper case.
+	// Pass schema name and bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
+	sqlInspectColumnsQuery = `
+SELECT
+	c.table_name AS table_name,
+	c.column_name AS column_name,
+	LOWER(c.data_type) AS data_type,
+	LOWER(c.column_type) AS column_type,
+	COALESCE(c.character_maximum_length, 0) AS varchar_len,
+	COALESCE(c.numeric_precision, 0) AS numeric_precision,
+	COALESCE(c.numeric_scale, 0) AS numeric_scale,
+	COALESCE(c.column_default, '') AS default_value,
+	c.column_default IS NOT NULL AS has_default,
+	c.extra LIKE '%DEFAULT_GENERATED%' AS is_default_expr,
+	c.is_nullable = 'YES' AS is_nullable,
+	c.extra LIKE '%auto_increment%' AS is_auto_increment,
+	c.column_comment AS column_comment,
+	COALESCE(c.collation_name, '') AS collation,
+	COALESCE(c.generation_expression, '') AS generated_expr,
+	c.extra LIKE '%STORED GENERATED%' AS is_generated_stored
+FROM information_schema.columns c
+WHERE c.table_schema = ?
+	AND c.table_name NOT IN (?)
+ORDER BY c.table_name, c.ordinal_position
+`
+
+	// sqlInspectConstraints retrieves PRIMARY KEY, UNIQUE, and FOREIGN KEY constraints, one row per column.
+	// Pass schema name and bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
+	sqlInspectConstraints = `
+SELECT
+	tc.table_name AS table_name,
+	tc.constraint_name AS constraint_name,
+	tc.constraint_type AS constraint_type,
+	k.column_name AS column_name,
+	COALESCE(k.referenced_table_name, '') AS target_table,
+	COALESCE(k.referenced_column_name, '') AS target_column,
+	COALESCE(rc.update_rule, '') AS update_rule,
+	COALESCE(rc.delete_rule, '') AS delete_rule,
+	COALESCE(rc.match_option, '') AS match_option
+FROM information_schema.table_constraints tc
+	JOIN information_schema.key_column_usage k
+		ON k.constraint_schema = tc.constraint_schema
+		AND k.constraint_name = tc.constraint_name
+		AND k.table_name = tc.table_name
+	LEFT JOIN information_schema.referential_constraints rc
+		ON rc.constraint_schema = tc.constraint_schema
+		AND rc.constraint_name = tc.constraint_name
+		AND rc.table_name = tc.table_name
+WHERE tc.table_schema = ?
+	AND tc.constraint_type IN ('PRIMARY KEY', 'UNIQUE', 'FOREIGN KEY')
+	AND tc.table_name NOT IN (?)
+ORDER BY tc.table_name, tc.constraint_name, k.ordinal_position
+`
+
+	// sqlInspectIndexes retrieves indexes defined on user tables, one row per indexed column.
+	// Parts of functional indexes have no column_name.
+	// Pass schema name and bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
+	sqlInspectIndexes = `
+SELECT
+	s.table_name AS table_name,
+	s.index_name AS index_name,
+	s.non_unique = 0 AS is_unique,
+	COALESCE(s.column_name, '') AS column_name,
+	s.index_type AS method
+FROM information_schema.statistics s
+WHERE s.table_schema = ?
+	AND s.table_name NOT IN (?)
+ORDER BY s.table_name, s.index_name, s.seq_in_index
+`
+)
@@ -0,0 +1,77 @@
+package mysqldialect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun/migrate/sqlschema"
+)
+
+func TestInspectorDialect_CompareType(t *testing.T) {
+	d := New()
+
+	t.Run("boolean", func(t *testing.T) {
+		for _, tt := range []struct {
+			name       string
+			col1, col2 sqlschema.BaseColumn
+			want       bool
+		}{
+			{
+				name: "bool is stored as tinyint(1)",
+				col1: sqlschema.BaseColumn{SQLType: "boolean"},
+				col2: sqlschema.BaseColumn{SQLType: "tinyint", VarcharLen: 1},
+				want: true,
+			},
+			{
+				name: "bool alias",
+				col1: sqlschema.BaseColumn{SQLType: "bool"},
+				col2: sqlschema.BaseColumn{SQLType: "tinyint", VarcharLen: 1},
+				want: true,
+			},
+			{
+				name: "tinyint(4) is not bool",
+				col1: sqlschema.BaseColumn{SQLType: "boolean"},
+				col2: sqlschema.BaseColumn{SQLType: "tinyint", VarcharLen: 4},
+				want: false,
+			},
+			{
+				name: "tinyint without display width is not bool",
+				col1: sqlschema.BaseColumn{SQLType: "tinyint"},
+				col2: sqlschema.BaseColumn{SQLType: "boolean"},
+				want: false,
+			},
+			{
+				name: "display width of other integer types is ignored",
+				col1: sqlschema.BaseColumn{SQLType: "int", VarcharLen: 11},
+				col2: sqlschema.BaseColumn{SQLType: "integer"},
+				want: true,
+			},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				got := d.CompareType(&tt.col1, &tt.col2)
+				require.Equal(t, tt.want, got)
+			})
+		}
+	})
+}
+
+func TestDialect_NormalizeDefault(t *testing.T) {
+	d := New()
+
+	for _, tt := range []struct {
+		expr, want string
+	}{
+		{"current_timestamp", "current_timestamp"},
+		{"current_timestamp()", "current_timestamp"},
+		{"now()", "current_timestamp"},
+		{"localtimestamp", "current_timestamp"},
+		{"now(3)", "current_timestamp(3)"},
+		{"current_timestamp(3)", "current_timestamp(3)"},
+		{"uuid()", "uuid()"},
+		{"NOW", "NOW"}, // string literal
+	} {
+		t.Run(tt.expr, func(t *testing.T) {
+			require.Equal(t, tt.want, d.NormalizeDefault(tt.expr))
+		})
+	}
+}
@@ -1,8 +1,13 @@
 package pgdialect
 
 import (
+	"bytes"
+	"cmp"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/migrate"
@@ -14,6 +19,14 @@ func (d *Dialect) NewMigrator(db *bun.DB, schemaName string) sqlschema.Migrator
 	return &migrator{db: db, schemaName: schemaName, BaseMigrator: sqlschema.NewBaseMigrator(db)}
 }
 
+var _ sqlschema.SchemaCreator = (*Dialect)(nil)
+
+// AppendCreateSchema creates the schema unless it already exists, e.g. "public" in a new database.
+func (d *Dialect) AppendCreateSchema(fmter schema.Formatter, b []byte, schemaName string) []byte {
+	b = append(b, "CREATE SCHEMA IF NOT EXISTS "...)
+	return fmter.AppendIdent(b, schemaName)
+}
+
 type migrator struct {
 	*sqlschema.BaseMigrator
 
@@ -35,9 +48,24 @@ func (m *migrator) AppendSQL(b []byte, operation interface{}) (_ []byte, err err
 
 	switch change := operation.(type) {
 	case *migrate.CreateTableOp:
-		return m.AppendCreateTable(b, change.Model)
+		var opts []sqlschema.CreateTableOption
+		if change.IfNotExists {
+			opts = append(opts, sqlschema.WithIfNotExists())
+		}
+		if change.Model == nil {
+			schemaName, _ := m.splitFQN(change.TableName)
+			b, err = m.AppendCreateTableDefinition(b, schemaName, change.Table, opts...)
+		} else {
+			b, err = m.AppendCreateTable(b, change.Model, opts...)
+		}
+		if err == nil && change.Table != nil && change.Table.GetTablespace() != "" {
+			b = append(b, " TABLESPACE "...)
+			b = fmter.AppendName(b, change.Table.GetTablespace())
+		}
+		return b, err
 	case *migrate.DropTableOp:
-		return m.AppendDropTable(b, m.schemaName, change.TableName)
+		schemaName, tableName := m.splitFQN(change.TableName)
+		return m.AppendDropTable(b, schemaName, tableName)
 	case *migrate.RenameTableOp:
 		b, err = m.renameTable(fmter, appendAlterTable(b, change.TableName), change)
 	case *migrate.RenameColumnOp:
@@ -56,12 +84,157 @@ func (m *migrator) AppendSQL(b []byte, operation interface{}) (_ []byte, err err
 		b, err = m.addUnique(fmter, appendAlterTable(b, change.TableName), change)
 	case *migrate.DropUniqueConstraintOp:
 		b, err = m.dropConstraint(fmter, appendAlterTable(b, change.TableName), change.Unique.Name)
+	case *migrate.ChangeColumnDefaultOp:
+		b, err = m.changeDefault(fmter, appendAlterTable(b, change.TableName), change)
+	case *migrate.ChangeColumnNullabilityOp:
+		if change.Backfill != "" && !change.Nullable {
+			b = m.backfillNulls(fmter, b, change)
+		}
+		b, err = m.changeNullability(fmter, appendAlterTable(b, change.TableName), change)
+	case *migrate.ChangeColumnCommentOp:
+		return m.commentColumn(fmter, b, change)
+	case *migrate.ChangeTableCommentOp:
+		return m.commentTable(fmter, b, change)
+	case *migrate.ChangeColumnStorageOp:
+		b, err = m.changeStorage(fmter, appendAlterTable(b, change.TableName), change)
+	case *migrate.AddCheckConstraintOp:
+		b, err = m.addCheck(fmter, appendAlterTable(b, change.TableName), change)
+	case *migrate.DropCheckConstraintOp:
+		b, err = m.dropConstraint(fmter, appendAlterTable(b, change.TableName), change.Check.Name)
+	case *migrate.AddExclusionConstraintOp:
+		b, err = m.addExclusion(fmter, appendAlterTable(b, change.TableName), change)
+	case *migrate.DropExclusionConstraintOp:
+		b, err = m.dropConstraint(fmter, appendAlterTable(b, change.TableName), change.Exclusion.Name)
 	case *migrate.ChangeColumnTypeOp:
-		b, err = m.changeColumnType(fmter, appendAlterTable(b, change.TableName), change)
+		return m.changeColumn(fmter, b, appendAlterTable(nil, change.TableName), change)
 	case *migrate.AddForeignKeyOp:
-		b, err = m.addForeignKey(fmter, appendAlterTable(b, change.TableName()), change)
+		if !change.IfNotExists {
+			b, err = m.addForeignKey(fmter, appendAlterTable(b, change.TableName()), change)
+			break
+		}
+		var stmt []byte
+		stmt, err = m.addForeignKey(fmter, appendAlterTable(nil, change.TableName()), change)
+		b = appendIgnoreDuplicate(b, stmt)
 	case *migrate.DropForeignKeyOp:
 		b, err = m.dropConstraint(fmter, appendAlterTable(b, change.TableName()), change.ConstraintName)
+	case *migrate.CreateEnumOp:
+		if !change.IfNotExists {
+			return m.createEnum(fmter, b, change)
+		}
+		stmt, err := m.createEnum(fmter, nil, change)
+		return appendIgnoreDuplicate(b, stmt), err
+	case *migrate.DropEnumOp:
+		return m.dropEnum(fmter, b, change)
+	case *migrate.AddEnumValueOp:
+		return m.addEnumValue(fmter, b, change)
+	case *migrate.CreateViewOp:
+		return m.createView(fmter, b, change.View)
+	case *migrate.DropViewOp:
+		return m.dropView(fmter, b, change.View)
+	case *migrate.RefreshViewOp:
+		b = append(b, "REFRESH MATERIALIZED VIEW "...)
+		return m.appendViewName(fmter, b, change.View), nil
+	case *migrate.CreateSequenceOp:
+		b = append(b, "CREATE SEQUENCE "...)
+		if change.IfNotExists {
+			b = append(b, "IF NOT EXISTS "...)
+		}
+		b = m.appendSequenceName(fmter, b, change.Sequence)
+		return m.appendSequenceOptions(fmter, b, sqlschema.Sequence{}, change.Sequence), nil
+	case *migrate.AlterSequenceOp:
+		b = append(b, "ALTER SEQUENCE "...)
+		b = m.appendSequenceName(fmter, b, change.To)
+		return m.appendSequenceOptions(fmter, b, change.From, change.To), nil
+	case *migrate.DropSequenceOp:
+		b = append(b, "DROP SEQUENCE "...)
+		return m.appendSequenceName(fmter, b, change.Sequence), nil
+	case *migrate.CreateDomainOp:
+		if !change.IfNotExists {
+			return m.createDomain(fmter, b, change.Domain)
+		}
+		stmt, err := m.createDomain(fmter, nil, change.Domain)
+		return appendIgnoreDuplicate(b, stmt), err
+	case *migrate.DropDomainOp:
+		b = append(b, "DROP DOMAIN "...)
+		return m.appendDomainName(fmter, b, change.Domain), nil
+	case *migrate.CreateCompositeTypeOp:
+		if !change.IfNotExists {
+			return m.createCompositeType(fmter, b, change.Type)
+		}
+		stmt, err := m.createCompositeType(fmter, nil, change.Type)
+		return appendIgnoreDuplicate(b, stmt), err
+	case *migrate.DropCompositeTypeOp:
+		b = append(b, "DROP TYPE "...)
+		return m.appendCompositeTypeName(fmter, b, change.Type), nil
+	case *migrate.AddCompositeAttributeOp:
+		b = append(b, "ALTER TYPE "...)
+		b = m.appendCompositeTypeName(fmter, b, change.Type)
+		b = append(b, " ADD ATTRIBUTE "...)
+		b = fmter.AppendName(b, change.Attribute.Name)
+		b = append(b, " "...)
+		return append(b, change.Attribute.DataType...), nil
+	case *migrate.DropCompositeAttributeOp:
+		b = append(b, "ALTER TYPE "...)
+		b = m.appendCompositeTypeName(fmter, b, change.Type)
+		b = append(b, " DROP ATTRIBUTE "...)
+		return fmter.AppendName(b, change.Attribute.Name), nil
+	case *migrate.CreateExtensionOp:
+		b = append(b, "CREATE EXTENSION IF NOT EXISTS "...)
+		b = fmter.AppendName(b, change.Extension.Name)
+		if change.Extension.Schema != "" {
+			b = append(b, " WITH SCHEMA "...)
+			b = fmter.AppendName(b, change.Extension.Schema)
+		}
+		if change.Extension.Version != "" {
+			b = append(b, " VERSION "...)
+			b = fmter.AppendQuery(b, "?", change.Extension.Version)
+		}
+		return b, nil
+	case *migrate.CreateTriggerOp:
+		return m.createTrigger(fmter, b, change.Trigger)
+	case *migrate.DropTriggerOp:
+		b = append(b, "DROP TRIGGER "...)
+		b = fmter.AppendName(b, change.Trigger.Name)
+		b = append(b, " ON "...)
+		return m.appendFQN(fmter, b, change.Trigger.TableName), nil
+	case *migrate.CreatePolicyOp:
+		return m.createPolicy(fmter, b, change.Policy), nil
+	case *migrate.AlterPolicyOp:
+		return m.alterPolicy(fmter, b, change), nil
+	case *migrate.DropPolicyOp:
+		b = append(b, "DROP POLICY "...)
+		b = fmter.AppendName(b, change.Policy.Name)
+		b = append(b, " ON "...)
+		return m.appendFQN(fmter, b, change.Policy.TableName), nil
+	case *migrate.ChangeRowSecurityOp:
+		b = m.changeRowSecurity(appendAlterTable(b, change.TableName), change)
+	case *migrate.ChangeTablespaceOp:
+		b = appendAlterTable(b, change.TableName)
+		b = appendSetTablespace(fmter, b, change.To)
+	case *migrate.ChangeIndexTablespaceOp:
+		b = append(b, "ALTER INDEX "...)
+		schemaName, _ := m.splitFQN(change.Index.TableName)
+		b = fmter.AppendQuery(b, "?.? ", bun.Ident(schemaName), bun.Ident(change.Index.Name))
+		b = appendSetTablespace(fmter, b, change.To)
+	case *migrate.GrantOp:
+		b = append(b, "GRANT "...)
+		b = m.appendGrant(fmter, b, change.Grant)
+		b = append(b, " TO "...)
+		return appendGrantee(fmter, b, change.Grant.Grantee), nil
+	case *migrate.RevokeOp:
+		b = append(b, "REVOKE "...)
+		b = m.appendGrant(fmter, b, change.Grant)
+		b = append(b, " FROM "...)
+		return appendGrantee(fmter, b, change.Grant.Grantee), nil
+	case *migrate.CreateIndexOp:
+		return m.createIndex(fmter, b, change)
+	case *migrate.DropIndexOp:
+		b = append(b, "DROP INDEX "...)
+		if change.Concurrently {
+			b = append(b, "CONCURRENTLY "...)
+		}
+		schemaName, _ := m.splitFQN(change.Index.TableName)
+		return fmter.AppendQuery(b, "?.?", bun.Ident(schemaName), bun.Ident(change.Index.Name)), nil
 	default:
 		return nil, fmt.Errorf("append sql: unknown operation %T", change)
 	}
@@ -72,12 +245,25 @@ func (m *migrator) AppendSQL(b []byte, operation interface{}) (_ []byte, err err
 }
 
 func (m *migrator) appendFQN(fmter schema.Formatter, b []byte, tableName string) []byte {
-	return fmter.AppendQuery(b, "?.?", bun.Ident(m.schemaName), bun.Ident(tableName))
+	schemaName, tableName := m.splitFQN(tableName)
+	return fmter.AppendQuery(b, "?.?", bun.Ident(schemaName), bun.Ident(tableName))
+}
+
+// splitFQN resolves the schema of a table, which is qualified with its schema name
+// if it is not in the migrator's default schema (see sqlschema.InspectorConfig.TableKey).
+func (m *migrator) splitFQN(tableName string) (string, string) {
+	schemaName, tableName := sqlschema.SplitTableKey(tableName)
+	if schemaName == "" {
+		schemaName = m.schemaName
+	}
+	return schemaName, tableName
 }
 
 func (m *migrator) renameTable(fmter schema.Formatter, b []byte, rename *migrate.RenameTableOp) (_ []byte, err error) {
+	// Tables cannot be moved to another schema with RENAME TO.
+	_, newName := m.splitFQN(rename.NewName)
 	b = append(b, "RENAME TO "...)
-	b = fmter.AppendName(b, rename.NewName)
+	b = fmter.AppendName(b, newName)
 	return b, nil
 }
 
@@ -100,17 +286,511 @@ func (m *migrator) addColumn(fmter schema.Formatter, b []byte, add *migrate.AddC
 	if err != nil {
 		return nil, err
 	}
+	b = appendCollation(fmter, b, add.Column)
+
+	if add.Column.GetGeneratedExpr() != "" {
+		return appendGeneratedAs(b, add.Column), nil
+	}
+
+	if add.Column.GetIsIdentity() {
+		return appendGeneratedAsIdentity(b, add.Column.GetIdentityOptions()), nil
+	}
+
+	if add.RequiresBackfill() {
+		if add.Backfill == "" {
+			return nil, fmt.Errorf("append sql: cannot add NOT NULL column %q to %s without a default: "+
+				"set a default, a \"backfill\" value, or make the column nullable", add.ColumnName, add.TableName)
+		}
+		// Existing rows are populated through a temporary default, which is dropped in the same statement.
+		b = append(b, " NOT NULL DEFAULT "...)
+		b = append(b, add.Backfill...)
+		b = append(b, ", ALTER COLUMN "...)
+		b = fmter.AppendName(b, add.ColumnName)
+		return append(b, " DROP DEFAULT"...), nil
+	}
+
+	if !add.Column.GetIsNullable() {
+		b = append(b, " NOT NULL"...)
+	}
 
 	if add.Column.GetDefaultValue() != "" {
 		b = append(b, " DEFAULT "...)
-		b = append(b, add.Column.GetDefaultValue()...)
+		b = sqlschema.AppendDefault(b, add.Column.GetDefaultValue())
 		b = append(b, " "...)
 	}
 
-	if add.Column.GetIsIdentity() {
-		b = appendGeneratedAsIdentity(b)
+	return b, nil
+}
+
+func (m *migrator) changeDefault(fmter schema.Formatter, b []byte, change *migrate.ChangeColumnDefaultOp) (_ []byte, err error) {
+	b = append(b, "ALTER COLUMN "...)
+	b = fmter.AppendName(b, change.Column)
+	if change.To == "" {
+		return append(b, " DROP DEFAULT"...), nil
+	}
+	b = append(b, " SET DEFAULT "...)
+	return sqlschema.AppendDefault(b, change.To), nil
+}
+
+func (m *migrator) changeNullability(fmter schema.Formatter, b []byte, change *migrate.ChangeColumnNullabilityOp) (_ []byte, err error) {
+	b = append(b, "ALTER COLUMN "...)
+	b = fmter.AppendName(b, change.Column)
+	if change.Nullable {
+		return append(b, " DROP NOT NULL"...), nil
+	}
+	return append(b, " SET NOT NULL"...), nil
+}
+
+// backfillNulls appends the UPDATE statement which replaces NULL values with the backfill value,
+// so that the column can be made NOT NULL in the statement that follows.
+func (m *migrator) backfillNulls(fmter schema.Formatter, b []byte, change *migrate.ChangeColumnNullabilityOp) []byte {
+	b = append(b, "UPDATE "...)
+	b = m.appendFQN(fmter, b, change.TableName)
+	b = append(b, " SET "...)
+	b = fmter.AppendName(b, change.Column)
+	b = append(b, " = "...)
+	b = append(b, change.Backfill...)
+	b = append(b, " WHERE "...)
+	b = fmter.AppendName(b, change.Column)
+	return append(b, " IS NULL;\n"...)
+}
+
+func (m *migrator) commentColumn(fmter schema.Formatter, b []byte, change *migrate.ChangeColumnCommentOp) (_ []byte, err error) {
+	b = append(b, "COMMENT ON COLUMN "...)
+	b = m.appendFQN(fmter, b, change.TableName)
+	b = append(b, "."...)
+	b = fmter.AppendName(b, change.Column)
+
+	b = append(b, " IS "...)
+	if change.To == "" {
+		return append(b, "NULL"...), nil
+	}
+	return fmter.AppendQuery(b, "?", change.To), nil
+}
+
+func (m *migrator) commentTable(fmter schema.Formatter, b []byte, change *migrate.ChangeTableCommentOp) (_ []byte, err error) {
+	b = append(b, "COMMENT ON TABLE "...)
+	b = m.appendFQN(fmter, b, change.TableName)
+
+	b = append(b, " IS "...)
+	if change.To == "" {
+		return append(b, "NULL"...), nil
+	}
+	return fmter.AppendQuery(b, "?", change.To), nil
+}
+
+func (m *migrator) createEnum(fmter schema.Formatter, b []byte, create *migrate.CreateEnumOp) (_ []byte, err error) {
+	b = append(b, "CREATE TYPE "...)
+	b = m.appendFQN(fmter, b, create.TypeName)
+	b = append(b, " AS ENUM ("...)
+	for i, value := range create.Values {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = fmter.AppendQuery(b, "?", value)
+	}
+	b = append(b, ")"...)
+	return b, nil
+}
+
+func (m *migrator) dropEnum(fmter schema.Formatter, b []byte, drop *migrate.DropEnumOp) (_ []byte, err error) {
+	b = append(b, "DROP TYPE "...)
+	b = m.appendFQN(fmter, b, drop.TypeName)
+	return b, nil
+}
+
+// createDomain appends CREATE DOMAIN statement with the domain's default and constraints.
+func (m *migrator) createDomain(fmter schema.Formatter, b []byte, dom sqlschema.Domain) (_ []byte, err error) {
+	b = append(b, "CREATE DOMAIN "...)
+	b = m.appendDomainName(fmter, b, dom)
+	b = append(b, " AS "...)
+	b = append(b, dom.DataType...)
+	if dom.Default != "" {
+		b = append(b, " DEFAULT "...)
+		b = append(b, dom.Default...)
+	}
+	if dom.NotNull {
+		b = append(b, " NOT NULL"...)
+	}
+	for _, check := range dom.Checks {
+		if check.Name != "" {
+			b = append(b, " CONSTRAINT "...)
+			b = fmter.AppendName(b, check.Name)
+		}
+		b = append(b, " CHECK ("...)
+		b = append(b, check.Expression...)
+		b = append(b, ")"...)
 	}
+	return b, nil
+}
+
+func (m *migrator) appendDomainName(fmter schema.Formatter, b []byte, dom sqlschema.Domain) []byte {
+	schemaName := dom.Schema
+	if schemaName == "" {
+		schemaName = m.schemaName
+	}
+	return fmter.AppendQuery(b, "?.?", bun.Ident(schemaName), bun.Ident(dom.Name))
+}
+
+func (m *migrator) createCompositeType(fmter schema.Formatter, b []byte, ct sqlschema.CompositeType) (_ []byte, err error) {
+	b = append(b, "CREATE TYPE "...)
+	b = m.appendCompositeTypeName(fmter, b, ct)
+	b = append(b, " AS ("...)
+	for i, attr := range ct.Attributes {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = fmter.AppendName(b, attr.Name)
+		b = append(b, " "...)
+		b = append(b, attr.DataType...)
+	}
+	b = append(b, ")"...)
+	return b, nil
+}
+
+func (m *migrator) appendCompositeTypeName(fmter schema.Formatter, b []byte, ct sqlschema.CompositeType) []byte {
+	schemaName := ct.Schema
+	if schemaName == "" {
+		schemaName = m.schemaName
+	}
+	return fmter.AppendQuery(b, "?.?", bun.Ident(schemaName), bun.Ident(ct.Name))
+}
 
+// createView appends CREATE [MATERIALIZED] VIEW statement. The definition is appended as is, so it must be a valid query.
+// Materialized views are only populated on creation if they are expected to be.
+func (m *migrator) createView(fmter schema.Formatter, b []byte, view sqlschema.View) (_ []byte, err error) {
+	b = append(b, "CREATE "...)
+	if view.Materialized {
+		b = append(b, "MATERIALIZED "...)
+	}
+	b = append(b, "VIEW "...)
+	b = m.appendViewName(fmter, b, view)
+	b = append(b, " AS "...)
+	b = append(b, strings.TrimSuffix(strings.TrimSpace(view.Definition), ";")...)
+	if view.Materialized && !view.Populated {
+		b = append(b, " WITH NO DATA"...)
+	}
+	return b, nil
+}
+
+func (m *migrator) dropView(fmter schema.Formatter, b []byte, view sqlschema.View) (_ []byte, err error) {
+	b = append(b, "DROP "...)
+	if view.Materialized {
+		b = append(b, "MATERIALIZED "...)
+	}
+	b = append(b, "VIEW "...)
+	b = m.appendViewName(fmter, b, view)
+	return b, nil
+}
+
+// appendViewName appends the view's name qualified with its schema, defaulting to the migrator's schema.
+func (m *migrator) appendViewName(fmter schema.Formatter, b []byte, view sqlschema.View) []byte {
+	schemaName := view.Schema
+	if schemaName == "" {
+		schemaName = m.schemaName
+	}
+	return fmter.AppendQuery(b, "?.?", bun.Ident(schemaName), bun.Ident(view.Name))
+}
+
+// createTrigger appends CREATE TRIGGER statement. The body is appended as is,
+// so it must be a valid EXECUTE FUNCTION clause.
+func (m *migrator) createTrigger(fmter schema.Formatter, b []byte, trigger sqlschema.Trigger) (_ []byte, err error) {
+	b = append(b, "CREATE TRIGGER "...)
+	b = fmter.AppendName(b, trigger.Name)
+	b = append(b, " "...)
+	b = append(b, strings.ToUpper(trigger.Timing)...)
+	b = append(b, " "...)
+	for i, event := range trigger.Events {
+		if i > 0 {
+			b = append(b, " OR "...)
+		}
+		b = append(b, strings.ToUpper(event)...)
+	}
+	b = append(b, " ON "...)
+	b = m.appendFQN(fmter, b, trigger.TableName)
+	if trigger.ForEachRow {
+		b = append(b, " FOR EACH ROW "...)
+	} else {
+		b = append(b, " FOR EACH STATEMENT "...)
+	}
+	b = append(b, strings.TrimSpace(trigger.Body)...)
+	return b, nil
+}
+
+// createPolicy appends CREATE POLICY statement. The expressions are appended as is.
+func (m *migrator) createPolicy(fmter schema.Formatter, b []byte, policy sqlschema.Policy) []byte {
+	b = append(b, "CREATE POLICY "...)
+	b = fmter.AppendName(b, policy.Name)
+	b = append(b, " ON "...)
+	b = m.appendFQN(fmter, b, policy.TableName)
+	if policy.Restrictive {
+		b = append(b, " AS RESTRICTIVE"...)
+	}
+	if cmd := policy.NormalizedCommand(); cmd != "ALL" {
+		b = append(b, " FOR "...)
+		b = append(b, cmd...)
+	}
+	if roles := policy.NormalizedRoles(); len(roles) > 0 {
+		b = appendPolicyRoles(fmter, append(b, " TO "...), roles)
+	}
+	if policy.Using != "" {
+		b = append(b, " USING ("...)
+		b = append(b, policy.Using...)
+		b = append(b, ")"...)
+	}
+	if policy.WithCheck != "" {
+		b = append(b, " WITH CHECK ("...)
+		b = append(b, policy.WithCheck...)
+		b = append(b, ")"...)
+	}
+	return b
+}
+
+// alterPolicy appends ALTER POLICY statement which sets the new roles and expressions of the policy.
+func (m *migrator) alterPolicy(fmter schema.Formatter, b []byte, alter *migrate.AlterPolicyOp) []byte {
+	b = append(b, "ALTER POLICY "...)
+	b = fmter.AppendName(b, alter.To.Name)
+	b = append(b, " ON "...)
+	b = m.appendFQN(fmter, b, alter.To.TableName)
+	if !slices.Equal(alter.From.NormalizedRoles(), alter.To.NormalizedRoles()) {
+		if roles := alter.To.NormalizedRoles(); len(roles) > 0 {
+			b = appendPolicyRoles(fmter, append(b, " TO "...), roles)
+		} else {
+			b = append(b, " TO PUBLIC"...)
+		}
+	}
+	if alter.To.Using != "" {
+		b = append(b, " USING ("...)
+		b = append(b, alter.To.Using...)
+		b = append(b, ")"...)
+	}
+	if alter.To.WithCheck != "" {
+		b = append(b, " WITH CHECK ("...)
+		b = append(b, alter.To.WithCheck...)
+		b = append(b, ")"...)
+	}
+	return b
+}
+
+func appendPolicyRoles(fmter schema.Formatter, b []byte, roles []string) []byte {
+	for i, role := range roles {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = fmter.AppendName(b, role)
+	}
+	return b
+}
+
+// appendGrant appends the privilege and the table it is granted on, e.g. UPDATE ("title") ON TABLE "public"."documents".
+func (m *migrator) appendGrant(fmter schema.Formatter, b []byte, grant sqlschema.Grant) []byte {
+	b = append(b, strings.ToUpper(grant.Privilege)...)
+	if grant.Column != "" {
+		b = append(b, " ("...)
+		b = fmter.AppendName(b, grant.Column)
+		b = append(b, ")"...)
+	}
+	b = append(b, " ON TABLE "...)
+	return m.appendFQN(fmter, b, grant.TableName)
+}
+
+// appendSetTablespace appends SET TABLESPACE clause. An empty tablespace moves the object to pg_default.
+func appendSetTablespace(fmter schema.Formatter, b []byte, tablespace string) []byte {
+	b = append(b, "SET TABLESPACE "...)
+	if tablespace == "" {
+		return append(b, "pg_default"...)
+	}
+	return fmter.AppendName(b, tablespace)
+}
+
+func appendGrantee(fmter schema.Formatter, b []byte, grantee string) []byte {
+	if strings.EqualFold(grantee, "public") {
+		return append(b, "PUBLIC"...)
+	}
+	return fmter.AppendName(b, grantee)
+}
+
+// changeStorage appends ALTER COLUMN ... SET STORAGE clause. An empty storage mode is set as DEFAULT,
+// which requires PostgreSQL 16 or later.
+func (m *migrator) changeStorage(fmter schema.Formatter, b []byte, change *migrate.ChangeColumnStorageOp) (_ []byte, err error) {
+	storage := strings.ToUpper(change.To)
+	switch storage {
+	case "":
+		storage = "DEFAULT"
+	case "PLAIN", "EXTERNAL", "EXTENDED", "MAIN":
+	default:
+		return nil, fmt.Errorf("unknown storage mode %q for column %s", change.To, change.Column)
+	}
+	b = append(b, "ALTER COLUMN "...)
+	b = fmter.AppendName(b, change.Column)
+	b = append(b, " SET STORAGE "...)
+	return append(b, storage...), nil
+}
+
+// changeRowSecurity appends the ENABLE or DISABLE ROW LEVEL SECURITY clause to the ALTER TABLE statement,
+// followed by FORCE or NO FORCE if that setting has changed too.
+func (m *migrator) changeRowSecurity(b []byte, change *migrate.ChangeRowSecurityOp) []byte {
+	if change.From.Enabled != change.To.Enabled {
+		if change.To.Enabled {
+			b = append(b, "ENABLE ROW LEVEL SECURITY"...)
+		} else {
+			b = append(b, "DISABLE ROW LEVEL SECURITY"...)
+		}
+	}
+	if change.From.Forced != change.To.Forced {
+		if change.From.Enabled != change.To.Enabled {
+			b = append(b, ", "...)
+		}
+		if change.To.Forced {
+			b = append(b, "FORCE ROW LEVEL SECURITY"...)
+		} else {
+			b = append(b, "NO FORCE ROW LEVEL SECURITY"...)
+		}
+	}
+	return b
+}
+
+// createIndex appends CREATE INDEX statement. The index is created in the schema of its table,
+// so its name cannot be qualified. Expressions are enclosed in parentheses, as Postgres requires.
+// Operator classes are only set for key parts that do not use the default one and may be qualified with their schema.
+// Storage parameter values are quoted, which Postgres accepts for numeric and boolean parameters alike.
+func (m *migrator) createIndex(fmter schema.Formatter, b []byte, create *migrate.CreateIndexOp) (_ []byte, err error) {
+	idx := create.Index
+
+	b = append(b, "CREATE "...)
+	if idx.Unique {
+		b = append(b, "UNIQUE "...)
+	}
+	b = append(b, "INDEX "...)
+	if create.Concurrently {
+		b = append(b, "CONCURRENTLY "...)
+	}
+	if create.IfNotExists {
+		b = append(b, "IF NOT EXISTS "...)
+	}
+	b = fmter.AppendName(b, idx.Name)
+	b = append(b, " ON "...)
+	b = m.appendFQN(fmter, b, idx.TableName)
+	if method := idx.GetMethod(); method != sqlschema.DefaultIndexMethod {
+		b = append(b, " USING "...)
+		b = append(b, method...)
+	}
+
+	b = append(b, " ("...)
+	for i, col := range idx.Columns {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		if col.Expression != "" {
+			b = append(b, "("...)
+			b = append(b, col.Expression...)
+			b = append(b, ")"...)
+		} else {
+			b = fmter.AppendName(b, col.Name)
+		}
+		if col.OpClass != "" {
+			b = append(b, " "...)
+			b = fmter.AppendIdent(b, col.OpClass)
+		}
+	}
+	b = append(b, ")"...)
+
+	if len(idx.Include) > 0 {
+		b = append(b, " INCLUDE ("...)
+		for i, name := range idx.Include {
+			if i > 0 {
+				b = append(b, ", "...)
+			}
+			b = fmter.AppendName(b, name)
+		}
+		b = append(b, ")"...)
+	}
+	if params := idx.NormalizedStorageParameters(); len(params) > 0 {
+		b = append(b, " WITH ("...)
+		for i, param := range params {
+			if i > 0 {
+				b = append(b, ", "...)
+			}
+			name, value, _ := strings.Cut(param, "=")
+			b = append(b, name...)
+			b = fmter.AppendQuery(b, " = ?", value)
+		}
+		b = append(b, ")"...)
+	}
+	if idx.Tablespace != "" {
+		b = append(b, " TABLESPACE "...)
+		b = fmter.AppendName(b, idx.Tablespace)
+	}
+	if idx.Where != "" {
+		b = append(b, " WHERE "...)
+		b = append(b, idx.Where...)
+	}
+	return b, nil
+}
+
+// appendIgnoreDuplicate appends the statement in a DO block which ignores the duplicate_object error,
+// because Postgres cannot create types and constraints with IF NOT EXISTS. The block is dollar-quoted
+// with a tag that the statement does not contain, e.g. in a domain's CHECK expression.
+func appendIgnoreDuplicate(b, stmt []byte) []byte {
+	tag := "$$"
+	for i := 1; bytes.Contains(stmt, []byte(tag)); i++ {
+		tag = "$do" + strconv.Itoa(i) + "$"
+	}
+	b = append(b, "DO "+tag+" BEGIN "...)
+	b = append(b, stmt...)
+	return append(b, "; EXCEPTION WHEN duplicate_object THEN NULL; END "+tag...)
+}
+
+// appendSequenceName appends the sequence's name qualified with its schema, defaulting to the migrator's schema.
+func (m *migrator) appendSequenceName(fmter schema.Formatter, b []byte, seq sqlschema.Sequence) []byte {
+	schemaName := seq.Schema
+	if schemaName == "" {
+		schemaName = m.schemaName
+	}
+	return fmter.AppendQuery(b, "?.?", bun.Ident(schemaName), bun.Ident(seq.Name))
+}
+
+// appendSequenceOptions appends the parameters of the sequence that differ from the previous ones.
+// Parameters set to zero are left to the database. A sequence that no longer belongs to a column is OWNED BY NONE.
+func (m *migrator) appendSequenceOptions(fmter schema.Formatter, b []byte, from, to sqlschema.Sequence) []byte {
+	appendOption := func(option string, value, prev int64) {
+		if value != 0 && value != prev {
+			b = append(b, fmt.Sprintf(" %s %d", option, value)...)
+		}
+	}
+	appendOption("INCREMENT BY", to.Increment, from.Increment)
+	appendOption("MINVALUE", to.MinValue, from.MinValue)
+	appendOption("MAXVALUE", to.MaxValue, from.MaxValue)
+	appendOption("START WITH", to.Start, from.Start)
+
+	if to.OwnedBy != from.OwnedBy {
+		b = append(b, " OWNED BY "...)
+		if !to.IsOwned() {
+			return append(b, "NONE"...)
+		}
+		b = m.appendFQN(fmter, b, to.OwnedBy.TableName)
+		b = append(b, "."...)
+		b = fmter.AppendName(b, string(to.OwnedBy.Column))
+	}
+	return b
+}
+
+// addEnumValue appends ALTER TYPE ... ADD VALUE statement.
+// Note, that the new value cannot be used in the same transaction in which it was added.
+func (m *migrator) addEnumValue(fmter schema.Formatter, b []byte, add *migrate.AddEnumValueOp) (_ []byte, err error) {
+	b = append(b, "ALTER TYPE "...)
+	b = m.appendFQN(fmter, b, add.TypeName)
+	b = append(b, " ADD VALUE "...)
+	b = fmter.AppendQuery(b, "?", add.Value)
+
+	switch {
+	case add.Before != "":
+		b = append(b, " BEFORE "...)
+		b = fmter.AppendQuery(b, "?", add.Before)
+	case add.After != "":
+		b = append(b, " AFTER "...)
+		b = fmter.AppendQuery(b, "?", add.After)
+	}
 	return b, nil
 }
 
@@ -142,7 +822,8 @@ func (m *migrator) addUnique(fmter schema.Formatter, b []byte, change *migrate.A
 		b = fmter.AppendName(b, change.Unique.Name)
 	} else {
 		// Default naming scheme for unique constraints in Postgres is <table>_<column>_key
-		b = fmter.AppendName(b, fmt.Sprintf("%s_%s_key", change.TableName, change.Unique.Columns))
+		_, tableName := m.splitFQN(change.TableName)
+		b = fmter.AppendName(b, fmt.Sprintf("%s_%s_key", tableName, change.Unique.Columns))
 	}
 	b = append(b, " UNIQUE ("...)
 	b, _ = change.Unique.Columns.AppendQuery(fmter, b)
@@ -151,6 +832,67 @@ func (m *migrator) addUnique(fmter schema.Formatter, b []byte, change *migrate.A
 	return b, nil
 }
 
+func (m *migrator) addCheck(fmter schema.Formatter, b []byte, change *migrate.AddCheckConstraintOp) (_ []byte, err error) {
+	b = append(b, "ADD "...)
+	// Postgres will name the constraint <table>_<column>_check if it's not set explicitly.
+	if change.Check.Name != "" {
+		b = append(b, "CONSTRAINT "...)
+		b = fmter.AppendName(b, change.Check.Name)
+		b = append(b, " "...)
+	}
+	b = append(b, "CHECK ("...)
+	b = append(b, change.Check.Expression...)
+	b = append(b, ")"...)
+
+	return b, nil
+}
+
+// addExclusion adds an EXCLUDE constraint. Plain column names are quoted unless they are quoted already,
+// and other elements are put in parentheses, as Postgres requires for expressions.
+func (m *migrator) addExclusion(fmter schema.Formatter, b []byte, change *migrate.AddExclusionConstraintOp) (_ []byte, err error) {
+	excl := change.Exclusion
+	if len(excl.Elements) == 0 {
+		return nil, fmt.Errorf("exclusion constraint %q has no elements", excl.Name)
+	}
+
+	b = append(b, "ADD "...)
+	if excl.Name != "" {
+		b = append(b, "CONSTRAINT "...)
+		b = fmter.AppendName(b, excl.Name)
+		b = append(b, " "...)
+	}
+	b = append(b, "EXCLUDE USING "...)
+	b = append(b, excl.NormalizedMethod()...)
+	b = append(b, " ("...)
+	for i, el := range excl.Elements {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		if el.Operator == "" {
+			return nil, fmt.Errorf("exclusion constraint %q: element %q has no operator", excl.Name, el.Expression)
+		}
+		switch {
+		case strings.HasPrefix(el.Expression, `"`) && isSimpleIdent(el.Expression):
+			b = append(b, el.Expression...)
+		case isSimpleIdent(el.Expression):
+			b = fmter.AppendName(b, el.Expression)
+		default:
+			b = append(b, "("...)
+			b = append(b, el.Expression...)
+			b = append(b, ")"...)
+		}
+		b = append(b, " WITH "...)
+		b = append(b, el.Operator...)
+	}
+	b = append(b, ")"...)
+	if excl.Where != "" {
+		b = append(b, " WHERE ("...)
+		b = append(b, excl.Where...)
+		b = append(b, ")"...)
+	}
+	return b, nil
+}
+
 func (m *migrator) dropConstraint(fmter schema.Formatter, b []byte, name string) (_ []byte, err error) {
 	b = append(b, "DROP CONSTRAINT "...)
 	b = fmter.AppendName(b, name)
@@ -165,7 +907,8 @@ func (m *migrator) addForeignKey(fmter schema.Formatter, b []byte, add *migrate.
 	if name == "" {
 		colRef := add.ForeignKey.From
 		columns := strings.Join(colRef.Column.Split(), "_")
-		name = fmt.Sprintf("%s_%s_fkey", colRef.TableName, columns)
+		_, tableName := m.splitFQN(colRef.TableName)
+		name = fmt.Sprintf("%s_%s_fkey", tableName, columns)
 	}
 	b = fmter.AppendName(b, name)
 
@@ -184,6 +927,102 @@ func (m *migrator) addForeignKey(fmter schema.Formatter, b []byte, add *migrate.
 	}
 	b = append(b, ")"...)
 
+	if add.ForeignKey.Match != sqlschema.MatchSimple {
+		b = append(b, " MATCH "...)
+		b = append(b, add.ForeignKey.Match.String()...)
+	}
+	if add.ForeignKey.OnDelete != sqlschema.NoAction {
+		b = append(b, " ON DELETE "...)
+		b = append(b, add.ForeignKey.OnDelete.String()...)
+	}
+	if add.ForeignKey.OnUpdate != sqlschema.NoAction {
+		b = append(b, " ON UPDATE "...)
+		b = append(b, add.ForeignKey.OnUpdate.String()...)
+	}
+	if add.ForeignKey.Deferrable {
+		b = append(b, " DEFERRABLE"...)
+		if add.ForeignKey.InitiallyDeferred {
+			b = append(b, " INITIALLY DEFERRED"...)
+		}
+	}
+
+	return b, nil
+}
+
+// needsExplicitCast checks if the values cannot be converted to the new type with an assignment cast,
+// e.g. TEXT to INTEGER, in which case Postgres requires a USING clause.
+func needsExplicitCast(from, to sqlschema.Column) bool {
+	typ1, typ2 := sqlschema.NormalizeType(from.GetSQLType()), sqlschema.NormalizeType(to.GetSQLType())
+	fam1, fam2 := sqlschema.GetTypeFamily(typ1), sqlschema.GetTypeFamily(typ2)
+	isNumber := func(fam sqlschema.TypeFamily) bool {
+		return fam == sqlschema.FamilyInteger || fam == sqlschema.FamilyDecimal || fam == sqlschema.FamilyFloat
+	}
+	isDateTime := func(fam sqlschema.TypeFamily) bool {
+		return fam == sqlschema.FamilyDate || fam == sqlschema.FamilyTimestamp
+	}
+
+	switch {
+	case from.GetArrayDims() != to.GetArrayDims():
+		return true
+	case fam2 == sqlschema.FamilyString,
+		fam1 != sqlschema.FamilyUnknown && fam1 == fam2,
+		isNumber(fam1) && isNumber(fam2),
+		isDateTime(fam1) && isDateTime(fam2):
+		return false
+	}
+	return typ1 != typ2
+}
+
+// changeColumn alters the column and converts it to or from a serial column. Unlike identity columns,
+// serial columns have no attribute to alter, they take their values from a default that calls nextval()
+// of a sequence the column owns. So the sequence and the default are created or dropped in statements of their own,
+// and the sequence is set past the existing values when the column changes to or from an identity column.
+func (m *migrator) changeColumn(fmter schema.Formatter, b, alterTable []byte, change *migrate.ChangeColumnTypeOp) (_ []byte, err error) {
+	got, want := change.From, change.To
+	dropSerial := got.GetIsAutoIncrement() && !want.GetIsAutoIncrement()
+	addSerial := want.GetIsAutoIncrement() && !got.GetIsAutoIncrement()
+
+	schemaName, tableName := m.splitFQN(change.TableName)
+	seq := sqlschema.Sequence{Schema: schemaName, Name: tableName + "_" + change.Column + "_seq"}
+
+	var stmts [][]byte
+	if dropSerial {
+		// The sequence is dropped first, because a new identity column would create a sequence with the same name.
+		alter := fmter.AppendQuery(slices.Clone(alterTable), "ALTER COLUMN ? DROP DEFAULT", bun.Ident(change.Column))
+		drop := m.appendSequenceName(fmter, []byte("DROP SEQUENCE IF EXISTS "), sqlschema.Sequence{
+			Schema: schemaName,
+			Name:   cmp.Or(got.GetSequence(), seq.Name),
+		})
+		stmts = append(stmts, alter, drop)
+	}
+
+	alter, err := m.changeColumnType(fmter, slices.Clone(alterTable), change)
+	if err != nil {
+		return b, err
+	}
+	if len(alter) > len(alterTable) || !dropSerial && !addSerial {
+		stmts = append(stmts, alter)
+	}
+
+	if addSerial {
+		create := m.appendSequenceName(fmter, []byte("CREATE SEQUENCE "), seq)
+		create = fmter.AppendQuery(create, " OWNED BY ?.?.?", bun.Ident(schemaName), bun.Ident(tableName), bun.Ident(change.Column))
+		setDefault := fmter.AppendQuery(slices.Clone(alterTable), "ALTER COLUMN ? SET DEFAULT nextval(?)",
+			bun.Ident(change.Column), string(m.appendSequenceName(fmter, nil, seq)))
+		stmts = append(stmts, create, setDefault)
+	}
+	if addSerial || dropSerial && want.GetIsIdentity() {
+		fqn := m.appendFQN(fmter, nil, change.TableName)
+		stmts = append(stmts, fmter.AppendQuery(nil, "SELECT setval(pg_get_serial_sequence(?, ?), COALESCE(max(?), 0) + 1, false) FROM ?",
+			string(fqn), change.Column, bun.Ident(change.Column), bun.Safe(fqn)))
+	}
+
+	for i, stmt := range stmts {
+		if i > 0 {
+			b = append(b, ";\n"...)
+		}
+		b = append(b, stmt...)
+	}
 	return b, nil
 }
 
@@ -201,13 +1040,49 @@ func (m *migrator) changeColumnType(fmter schema.Formatter, b []byte, colDef *mi
 
 	got, want := colDef.From, colDef.To
 
+	// Postgres cannot change the generation expression in place, so the column is dropped and re-added.
+	if want.GetGeneratedExpr() != got.GetGeneratedExpr() || want.GetGeneratedStored() != got.GetGeneratedStored() {
+		b, _ = m.dropColumn(fmter, b, &migrate.DropColumnOp{ColumnName: colDef.Column})
+		b = append(b, ", "...)
+		return m.addColumn(fmter, b, &migrate.AddColumnOp{ColumnName: colDef.Column, Column: want})
+	}
+
+	// The current default may not be cast to the new type like the values are, so it is dropped
+	// before the type is changed and the new one is set afterwards, as the PostgreSQL docs suggest.
 	inspector := m.db.Dialect().(sqlschema.InspectorDialect)
-	if !inspector.CompareType(want, got) {
+	typeChanged := !inspector.CompareType(want, got)
+	resetDefault := typeChanged && got.GetDefaultValue() != "" && (colDef.Using != "" || needsExplicitCast(got, want))
+	if resetDefault {
+		appendAlterColumn()
+		b = append(b, " DROP DEFAULT"...)
+	}
+
+	if typeChanged {
+		// Serial types only exist in CREATE TABLE, so the column is changed to the integer type. See changeColumn.
+		wantType := want
+		if integer := serialInteger(want.GetSQLType()); integer != want.GetSQLType() {
+			wantType = &sqlschema.BaseColumn{SQLType: integer}
+		}
+
 		appendAlterColumn()
 		b = append(b, " SET DATA TYPE "...)
-		if b, err = want.AppendQuery(fmter, b); err != nil {
+		if b, err = wantType.AppendQuery(fmter, b); err != nil {
 			return b, err
 		}
+		b = appendCollation(fmter, b, want)
+
+		switch {
+		case colDef.Using != "":
+			b = append(b, " USING "...)
+			b = append(b, colDef.Using...)
+		case needsExplicitCast(got, want):
+			b = append(b, " USING "...)
+			b = fmter.AppendName(b, colDef.Column)
+			b = append(b, "::"...)
+			if b, err = wantType.AppendQuery(fmter, b); err != nil {
+				return b, err
+			}
+		}
 	}
 
 	// Column must be declared NOT NULL before identity can be added.
@@ -227,19 +1102,75 @@ func (m *migrator) changeColumnType(fmter schema.Formatter, b []byte, colDef *mi
 			b = append(b, " DROP IDENTITY"...)
 		} else {
 			b = append(b, " ADD"...)
-			b = appendGeneratedAsIdentity(b)
+			b = appendGeneratedAsIdentity(b, want.GetIdentityOptions())
+		}
+	} else if want.GetIsIdentity() {
+		from, to := got.GetIdentityOptions(), want.GetIdentityOptions()
+		if from.GetGeneration() != to.GetGeneration() {
+			appendAlterColumn()
+			b = append(b, " SET GENERATED "...)
+			b = append(b, to.GetGeneration()...)
+		}
+		if to.Start != 0 && to.Start != from.Start {
+			appendAlterColumn()
+			b = append(b, " SET START WITH "...)
+			b = strconv.AppendInt(b, to.Start, 10)
+		}
+		if to.Increment != 0 && to.Increment != from.Increment {
+			appendAlterColumn()
+			b = append(b, " SET INCREMENT BY "...)
+			b = strconv.AppendInt(b, to.Increment, 10)
 		}
 	}
 
-	if want.GetDefaultValue() != got.GetDefaultValue() {
+	if resetDefault {
+		if want.GetDefaultValue() != "" {
+			appendAlterColumn()
+			b = append(b, " SET DEFAULT "...)
+			b = sqlschema.AppendDefault(b, want.GetDefaultValue())
+		}
+	} else if want.GetDefaultValue() != got.GetDefaultValue() {
 		appendAlterColumn()
 		if want.GetDefaultValue() == "" {
 			b = append(b, " DROP DEFAULT"...)
 		} else {
 			b = append(b, " SET DEFAULT "...)
-			b = append(b, want.GetDefaultValue()...)
+			b = sqlschema.AppendDefault(b, want.GetDefaultValue())
 		}
 	}
 
 	return b, nil
 }
+
+// appendCollation appends COLLATE clause if the column does not use the default collation.
+func appendCollation(fmter schema.Formatter, b []byte, col sqlschema.Column) []byte {
+	if col.GetCollation() == "" {
+		return b
+	}
+	b = append(b, " COLLATE "...)
+	return fmter.AppendIdent(b, col.GetCollation())
+}
+
+// appendGeneratedAs appends GENERATED ALWAYS AS (expr) STORED to the column definition.
+// Postgres only supports stored generated columns.
+func appendGeneratedAs(b []byte, col sqlschema.Column) []byte {
+	b = append(b, " GENERATED ALWAYS AS ("...)
+	b = append(b, col.GetGeneratedExpr()...)
+	return append(b, ") STORED"...)
+}
+
+// isSimpleIdent reports whether s is a column name, either quoted or consisting of letters, digits, and underscores.
+func isSimpleIdent(s string) bool {
+	if len(s) > 1 && s[0] == '"' && s[len(s)-1] == '"' {
+		return !strings.Contains(s[1:len(s)-1], `"`)
+	}
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		return false
+	}
+	for _, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -3,6 +3,7 @@ package pgdialect
 import (
 	"database/sql"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/uptrace/bun"
@@ -32,6 +33,7 @@ type Dialect struct {
 var _ schema.Dialect = (*Dialect)(nil)
 var _ sqlschema.InspectorDialect = (*Dialect)(nil)
 var _ sqlschema.MigratorDialect = (*Dialect)(nil)
+var _ sqlschema.DefaultNormalizer = (*Dialect)(nil)
 
 func New(opts ...DialectOption) *Dialect {
 	d := new(Dialect)
@@ -54,7 +56,8 @@ func New(opts ...DialectOption) *Dialect {
 		feature.GeneratedIdentity |
 		feature.CompositeIn |
 		feature.DeleteReturning |
-		feature.AlterColumnExists
+		feature.AlterColumnExists |
+		feature.TransactionalDDL
 
 	for _, opt := range opts {
 		opt(d)
@@ -128,11 +131,55 @@ func (d *Dialect) IdentQuote() byte {
 	return '"'
 }
 
-func (d *Dialect) AppendSequence(b []byte, _ *schema.Table, _ *schema.Field) []byte {
-	return appendGeneratedAsIdentity(b)
+func (d *Dialect) AppendSequence(b []byte, _ *schema.Table, field *schema.Field) []byte {
+	opts := sqlschema.IdentityOptions{Start: field.IdentityStart, Increment: field.IdentityIncrement}
+	if field.IdentityAlways {
+		opts.Generation = sqlschema.IdentityAlways
+	}
+	return appendGeneratedAsIdentity(b, opts)
+}
+
+// AppendColumnSequence appends the type of an identity column followed by GENERATED AS IDENTITY clause.
+// Auto-increment columns are created as serial columns of the matching size.
+func (d *Dialect) AppendColumnSequence(fmter schema.Formatter, b []byte, col sqlschema.Column) ([]byte, error) {
+	if !col.GetIsIdentity() {
+		switch strings.ToUpper(col.GetSQLType()) {
+		case sqltype.SmallInt:
+			return append(b, pgTypeSmallSerial...), nil
+		case sqltype.Integer:
+			return append(b, pgTypeSerial...), nil
+		case sqltype.BigInt:
+			return append(b, pgTypeBigSerial...), nil
+		}
+	}
+	b, err := col.AppendQuery(fmter, b)
+	if err != nil || !col.GetIsIdentity() {
+		return b, err
+	}
+	return appendGeneratedAsIdentity(b, col.GetIdentityOptions()), nil
 }
 
-// appendGeneratedAsIdentity appends GENERATED BY DEFAULT AS IDENTITY to the column definition.
-func appendGeneratedAsIdentity(b []byte) []byte {
-	return append(b, " GENERATED BY DEFAULT AS IDENTITY"...)
+// appendGeneratedAsIdentity appends GENERATED ALWAYS|BY DEFAULT AS IDENTITY to the column definition,
+// followed by the sequence options that differ from the defaults.
+func appendGeneratedAsIdentity(b []byte, opts sqlschema.IdentityOptions) []byte {
+	b = append(b, " GENERATED "...)
+	b = append(b, opts.GetGeneration()...)
+	b = append(b, " AS IDENTITY"...)
+
+	if opts.Start == 0 && opts.Increment == 0 {
+		return b
+	}
+	b = append(b, " ("...)
+	if opts.Start != 0 {
+		b = append(b, "START WITH "...)
+		b = strconv.AppendInt(b, opts.Start, 10)
+	}
+	if opts.Increment != 0 {
+		if opts.Start != 0 {
+			b = append(b, ' ')
+		}
+		b = append(b, "INCREMENT BY "...)
+		b = strconv.AppendInt(b, opts.Increment, 10)
+	}
+	return append(b, ')')
 }
@@ -0,0 +1,1740 @@
+package pgdialect
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/uptrace/bun/internal/ordered"
+	"github.com/uptrace/bun/migrate/sqlschema"
+)
+
+// DumpInspector reads the schema state from a file created with `pg_dump --schema-only`, without connecting
+// to the database, e.g. to adopt migrations for an existing database. It understands CREATE TABLE, CREATE INDEX,
+// CREATE TYPE ... AS ENUM, CREATE [MATERIALIZED] VIEW, COMMENT ON and the ALTER TABLE statements which add
+// constraints, defaults and identity to the tables. Other statements, such as SET or CREATE FUNCTION, are skipped.
+// CREATE FOREIGN TABLE is only read if sqlschema.WithIncludeForeignTables is set, see Inspector,
+// and so is GRANT if sqlschema.WithIncludeGrants is set.
+//
+// Errors report the line and the beginning of the statement that could not be parsed.
+type DumpInspector struct {
+	sqlschema.InspectorConfig
+	fsys fs.FS
+	name string
+}
+
+var _ sqlschema.Inspector = (*DumpInspector)(nil)
+
+// NewDumpInspector creates an inspector which reads the dump from the named file in fsys.
+// Tables are placed in the "public" schema unless another SchemaName is configured.
+func NewDumpInspector(fsys fs.FS, name string, options ...sqlschema.InspectorOption) *DumpInspector {
+	di := &DumpInspector{fsys: fsys, name: name}
+	sqlschema.ApplyInspectorOptions(&di.InspectorConfig, options...)
+	if di.SchemaName == "" {
+		di.SchemaName = "public"
+	}
+	return di
+}
+
+func (di *DumpInspector) Inspect(ctx context.Context) (sqlschema.Database, error) {
+	f, err := di.fsys.Open(di.name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", di.name, err)
+	}
+	return di.load(ctx, string(b))
+}
+
+// dumpForeignKey is a foreign key waiting for all tables to be parsed, so that its target can be checked.
+type dumpForeignKey struct {
+	stmt *dumpStatement
+	fk   sqlschema.ForeignKey
+	name string
+}
+
+// dumpState collects the schema objects while the statements are parsed.
+type dumpState struct {
+	*DumpInspector
+	filter *sqlschema.TableFilter
+	schema Schema
+	fks    []dumpForeignKey
+
+	// foreign maps the keys of the foreign tables to their indexes in schema.ForeignTables,
+	// or to -1 if foreign tables are not included, so that the comments on their columns can be skipped.
+	foreign map[string]int
+
+	// tablespace is the default tablespace set with SET default_tablespace,
+	// which pg_dump uses instead of the TABLESPACE clause. Empty for the database's default.
+	tablespace string
+
+	// owners maps the keys of the tables to their owners, whose privileges are not part of the schema state.
+	owners map[string]string
+
+	// sequenceOwners maps the qualified names of the sequences to the columns that own them, see OWNED BY.
+	sequenceOwners map[string]sequenceOwner
+}
+
+type sequenceOwner struct {
+	table, column string
+}
+
+func (di *DumpInspector) load(ctx context.Context, src string) (sqlschema.Database, error) {
+	filter, err := sqlschema.NewTableFilter(di.InspectorConfig, systemSchemas...)
+	if err != nil {
+		return nil, err
+	}
+	state := &dumpState{
+		DumpInspector: di,
+		filter:        filter,
+		foreign:       make(map[string]int),
+		owners:        make(map[string]string),
+
+		sequenceOwners: make(map[string]sequenceOwner),
+		schema: Schema{
+			Tables:      ordered.NewMap[string, sqlschema.Table](),
+			ForeignKeys: make(map[sqlschema.ForeignKey]string),
+			Enums:       make(map[string][]string),
+		},
+	}
+
+	statements, err := splitDump(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", di.name, err)
+	}
+	for _, stmt := range statements {
+		// Large dumps take a while to parse, so the context is checked between the statements.
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		stmt.file = di.name
+		if err := state.statement(stmt); err != nil {
+			return nil, err
+		}
+	}
+
+	state.resolveSerials()
+
+	for _, fk := range state.fks {
+		if _, ok := state.schema.Tables.Load(fk.fk.To.TableName); !ok {
+			if schemaName, tableName := sqlschema.SplitTableKey(fk.fk.To.TableName); state.excluded(schemaName, tableName) {
+				continue
+			}
+			return nil, fk.stmt.errorf("foreign key %s references unknown table %q", fk.name, fk.fk.To.TableName)
+		}
+		state.schema.ForeignKeys[fk.fk.Canonical()] = fk.name
+	}
+	return state.schema, nil
+}
+
+// excluded reports whether the table is outside of the inspected schemas or is excluded by the filter.
+func (s *dumpState) excluded(schemaName, tableName string) bool {
+	if schemaName == "" {
+		schemaName = s.SchemaName
+	}
+	return !slices.Contains(s.InspectedSchemas(), schemaName) || s.filter.Excluded(schemaName, tableName)
+}
+
+func (s *dumpState) statement(stmt *dumpStatement) error {
+	switch {
+	case stmt.acceptKeyword("CREATE", "TABLE"),
+		stmt.acceptKeyword("CREATE", "UNLOGGED", "TABLE"):
+		return s.createTable(stmt)
+	case stmt.acceptKeyword("CREATE", "FOREIGN", "TABLE"):
+		return s.createForeignTable(stmt)
+	case stmt.acceptKeyword("CREATE", "INDEX"):
+		return s.createIndex(stmt, false)
+	case stmt.acceptKeyword("CREATE", "UNIQUE", "INDEX"):
+		return s.createIndex(stmt, true)
+	case stmt.acceptKeyword("CREATE", "TYPE"):
+		return s.createType(stmt)
+	case stmt.acceptKeyword("CREATE", "EXTENSION"):
+		return s.createExtension(stmt)
+	case stmt.acceptKeyword("CREATE", "VIEW"),
+		stmt.acceptKeyword("CREATE", "OR", "REPLACE", "VIEW"):
+		return s.createView(stmt, false)
+	case stmt.acceptKeyword("CREATE", "MATERIALIZED", "VIEW"):
+		return s.createView(stmt, true)
+	case stmt.acceptKeyword("REFRESH", "MATERIALIZED", "VIEW"):
+		return s.refreshView(stmt)
+	case stmt.acceptKeyword("CREATE", "POLICY"):
+		return s.createPolicy(stmt)
+	case stmt.acceptKeyword("ALTER", "TABLE"):
+		return s.alterTable(stmt)
+	case stmt.acceptKeyword("CREATE", "SEQUENCE"), stmt.acceptKeyword("ALTER", "SEQUENCE"):
+		return s.sequence(stmt)
+	case stmt.acceptKeyword("GRANT"):
+		if s.IncludeGrants {
+			return s.grant(stmt)
+		}
+	case stmt.acceptKeyword("COMMENT", "ON"):
+		return s.comment(stmt)
+	case stmt.acceptKeyword("SET", "default_tablespace"):
+		if !stmt.acceptKeyword("TO") {
+			stmt.next() // =
+		}
+		switch tok := stmt.next(); {
+		case tok.kind == tokIdent && tok.text == "default":
+			s.tablespace = ""
+		case tok.kind == tokString || tok.isIdent():
+			s.tablespace = tok.text
+		}
+	}
+	return nil
+}
+
+// tableName reads a possibly qualified table name and returns its key in the state,
+// or an empty key if the table is excluded from inspection.
+func (s *dumpState) tableName(stmt *dumpStatement) (key, schemaName, tableName string, err error) {
+	schemaName, tableName, err = stmt.qualifiedName()
+	if err != nil {
+		return "", "", "", err
+	}
+	if schemaName == "" {
+		schemaName = s.SchemaName
+	}
+	if s.excluded(schemaName, tableName) {
+		return "", schemaName, tableName, nil
+	}
+	return s.TableKey(schemaName, tableName), schemaName, tableName, nil
+}
+
+// table reads the name of a table that must have been created by a preceding statement.
+func (s *dumpState) table(stmt *dumpStatement) (*Table, error) {
+	key, _, tableName, err := s.tableName(stmt)
+	if err != nil || key == "" {
+		return nil, err
+	}
+	table, ok := s.schema.Tables.Load(key)
+	if !ok {
+		return nil, stmt.errorf("unknown table %q", tableName)
+	}
+	return table.(*Table), nil
+}
+
+func (s *dumpState) createTable(stmt *dumpStatement) error {
+	stmt.acceptKeyword("IF", "NOT", "EXISTS")
+	key, schemaName, tableName, err := s.tableName(stmt)
+	if err != nil || key == "" {
+		return err
+	}
+	if _, ok := s.schema.Tables.Load(key); ok {
+		return stmt.errorf("duplicate table %q", tableName)
+	}
+	table := &Table{
+		Schema:     schemaName,
+		Name:       tableName,
+		Columns:    ordered.NewMap[string, sqlschema.Column](),
+		Tablespace: s.tablespace,
+	}
+
+	if stmt.acceptKeyword("PARTITION", "OF") {
+		parent, _, _, err := s.tableName(stmt)
+		if err != nil {
+			return err
+		}
+		table.PartitionOf = parent
+		stmt.skip()
+		s.schema.Tables.Store(key, table)
+		return nil
+	}
+
+	if err := stmt.expect("("); err != nil {
+		return err
+	}
+	for !stmt.accept(")") {
+		if err := s.tableElement(stmt, key, table); err != nil {
+			return err
+		}
+		if !stmt.accept(",") && !stmt.peekPunct(")") {
+			return stmt.errorf("expected \",\" or \")\", got %q", stmt.peek().text)
+		}
+	}
+
+	for !stmt.done() {
+		switch {
+		case stmt.acceptKeyword("PARTITION", "BY"):
+			strategy := strings.ToUpper(stmt.next().text)
+			key, err := stmt.parens()
+			if err != nil {
+				return err
+			}
+			table.Partitioning = &sqlschema.Partitioning{Strategy: strategy, Key: key}
+		case stmt.acceptKeyword("INHERITS"):
+			if err := stmt.expect("("); err != nil {
+				return err
+			}
+			for !stmt.accept(")") {
+				parent, _, _, err := s.tableName(stmt)
+				if err != nil {
+					return err
+				}
+				if parent != "" {
+					table.Inherits = append(table.Inherits, parent)
+				}
+				stmt.accept(",")
+			}
+		case stmt.acceptKeyword("TABLESPACE"):
+			if table.Tablespace, err = stmt.ident(); err != nil {
+				return err
+			}
+		default:
+			// Storage parameters are not part of the schema state.
+			stmt.next()
+		}
+	}
+	s.schema.Tables.Store(key, table)
+	return nil
+}
+
+// createForeignTable reads the columns, the server, and the options of a foreign table.
+// Constraints of foreign tables are not enforced by PostgreSQL and are skipped.
+func (s *dumpState) createForeignTable(stmt *dumpStatement) error {
+	stmt.acceptKeyword("IF", "NOT", "EXISTS")
+	key, schemaName, tableName, err := s.tableName(stmt)
+	if err != nil || key == "" {
+		return err
+	}
+	if _, ok := s.foreign[key]; ok {
+		return stmt.errorf("duplicate foreign table %q", tableName)
+	}
+	s.foreign[key] = -1
+	if !s.IncludeForeignTables {
+		return nil
+	}
+	table := &Table{
+		Schema:  schemaName,
+		Name:    tableName,
+		Columns: ordered.NewMap[string, sqlschema.Column](),
+	}
+
+	if err := stmt.expect("("); err != nil {
+		return err
+	}
+	for !stmt.accept(")") {
+		if stmt.peekKeyword("CONSTRAINT") || stmt.peekKeyword("CHECK") {
+			stmt.raw()
+		} else if err := s.column(stmt, key, table); err != nil {
+			return err
+		}
+		if !stmt.accept(",") && !stmt.peekPunct(")") {
+			return stmt.errorf("expected \",\" or \")\", got %q", stmt.peek().text)
+		}
+	}
+
+	ft := sqlschema.ForeignTable{Schema: schemaName, Name: tableName}
+	for _, col := range table.Columns.Values() {
+		ft.Columns = append(ft.Columns, col.(*Column))
+	}
+	for !stmt.done() {
+		switch {
+		case stmt.acceptKeyword("SERVER"):
+			if ft.Server, err = stmt.ident(); err != nil {
+				return err
+			}
+		case stmt.acceptKeyword("OPTIONS"):
+			if ft.Options, err = foreignOptions(stmt); err != nil {
+				return err
+			}
+		default:
+			stmt.next()
+		}
+	}
+	s.foreign[key] = len(s.schema.ForeignTables)
+	s.schema.ForeignTables = append(s.schema.ForeignTables, ft)
+	return nil
+}
+
+// foreignOptions reads the OPTIONS list of a foreign table or column, e.g. (schema_name 'public', table_name 'orders'),
+// and returns the options in the "name=value" form of pg_foreign_table.ftoptions.
+func foreignOptions(stmt *dumpStatement) ([]string, error) {
+	if err := stmt.expect("("); err != nil {
+		return nil, err
+	}
+	var options []string
+	for !stmt.accept(")") {
+		name, err := stmt.ident()
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, name+"="+stmt.next().text)
+		if !stmt.accept(",") && !stmt.peekPunct(")") {
+			return nil, stmt.errorf("expected \",\" or \")\", got %q", stmt.peek().text)
+		}
+	}
+	return options, nil
+}
+
+// storageParameters reads the WITH list of an index, e.g. (pages_per_range='32', autosummarize),
+// and returns the parameters in the "name=value" form of pg_class.reloptions. Parameters without a value are true.
+func storageParameters(stmt *dumpStatement) ([]string, error) {
+	if err := stmt.expect("("); err != nil {
+		return nil, err
+	}
+	var params []string
+	for !stmt.accept(")") {
+		name, err := stmt.ident()
+		if err != nil {
+			return nil, err
+		}
+		value := "true"
+		if tok := stmt.peek(); tok.kind == tokOperator && tok.text == "=" {
+			stmt.next()
+			value = stmt.number()
+		}
+		params = append(params, name+"="+value)
+		if !stmt.accept(",") && !stmt.peekPunct(")") {
+			return nil, stmt.errorf("expected \",\" or \")\", got %q", stmt.peek().text)
+		}
+	}
+	return params, nil
+}
+
+func (s *dumpState) tableElement(stmt *dumpStatement, key string, table *Table) error {
+	switch {
+	case stmt.acceptKeyword("CONSTRAINT"):
+		name, err := stmt.ident()
+		if err != nil {
+			return err
+		}
+		return s.tableConstraint(stmt, key, table, name)
+	case stmt.peekKeyword("PRIMARY"), stmt.peekKeyword("UNIQUE"), stmt.peekKeyword("CHECK"),
+		stmt.peekKeyword("FOREIGN"), stmt.peekKeyword("EXCLUDE"):
+		return s.tableConstraint(stmt, key, table, "")
+	case stmt.peekKeyword("LIKE"):
+		return stmt.errorf("LIKE clause is not supported")
+	}
+	return s.column(stmt, key, table)
+}
+
+// columnConstraints start the column constraints which follow the column type.
+var columnConstraints = []string{
+	"CONSTRAINT", "NOT", "NULL", "DEFAULT", "PRIMARY", "UNIQUE", "CHECK",
+	"REFERENCES", "COLLATE", "GENERATED", "OPTIONS",
+}
+
+func (s *dumpState) column(stmt *dumpStatement, key string, table *Table) error {
+	name, err := stmt.ident()
+	if err != nil {
+		return err
+	}
+	if _, ok := table.Columns.Load(name); ok {
+		return stmt.errorf("duplicate column %q in table %q", name, table.Name)
+	}
+	typ := stmt.raw(columnConstraints...)
+	if typ == "" {
+		return stmt.errorf("column %q in table %q must have a type", name, table.Name)
+	}
+	col, err := columnType(typ)
+	if err != nil {
+		return stmt.errorf("column %q in table %q: %v", name, table.Name, err)
+	}
+	col.Name = name
+	col.IsNullable = true
+
+	var constraintName string
+	for {
+		switch {
+		case stmt.acceptKeyword("CONSTRAINT"):
+			if constraintName, err = stmt.ident(); err != nil {
+				return err
+			}
+			continue
+		case stmt.acceptKeyword("NOT", "NULL"):
+			col.IsNullable = false
+		case stmt.acceptKeyword("NULL"):
+		case stmt.acceptKeyword("DEFAULT"):
+			setDefault(col, stmt.raw(columnConstraints...))
+		case stmt.acceptKeyword("PRIMARY", "KEY"):
+			col.IsNullable = false
+			table.PrimaryKey = &sqlschema.PrimaryKey{Name: constraintName, Columns: sqlschema.NewColumns(name)}
+		case stmt.acceptKeyword("UNIQUE"):
+			table.UniqueConstraints = append(table.UniqueConstraints, sqlschema.Unique{
+				Name:    constraintName,
+				Columns: sqlschema.NewColumns(name),
+			})
+		case stmt.acceptKeyword("CHECK"):
+			expr, err := stmt.parens()
+			if err != nil {
+				return err
+			}
+			table.Checks = append(table.Checks, sqlschema.Check{Name: constraintName, Expression: parseCheckDefinition(expr)})
+		case stmt.acceptKeyword("REFERENCES"):
+			if err := s.references(stmt, key, table, []string{name}, constraintName); err != nil {
+				return err
+			}
+		case stmt.acceptKeyword("COLLATE"):
+			collation, err := stmt.ident()
+			if err != nil {
+				return err
+			}
+			col.Collation = collation
+		case stmt.acceptKeyword("GENERATED"):
+			if err := generated(stmt, col); err != nil {
+				return err
+			}
+		case stmt.acceptKeyword("OPTIONS"):
+			// Options of foreign table columns, e.g. OPTIONS (column_name 'id'), are not part of the schema state.
+			if _, err := foreignOptions(stmt); err != nil {
+				return err
+			}
+		default:
+			table.Columns.Store(name, col)
+			return nil
+		}
+		constraintName = ""
+	}
+}
+
+// generated reads the generation clause of a generated or identity column after the GENERATED keyword.
+func generated(stmt *dumpStatement, col *Column) error {
+	switch {
+	case stmt.acceptKeyword("ALWAYS", "AS", "IDENTITY"):
+		col.IdentityOptions.Generation = sqlschema.IdentityAlways
+	case stmt.acceptKeyword("BY", "DEFAULT", "AS", "IDENTITY"):
+		col.IdentityOptions.Generation = sqlschema.IdentityByDefault
+	case stmt.acceptKeyword("ALWAYS", "AS"):
+		expr, err := stmt.parens()
+		if err != nil {
+			return err
+		}
+		col.GeneratedExpr = expr
+		col.GeneratedStored = stmt.acceptKeyword("STORED")
+		return nil
+	default:
+		return stmt.errorf("expected IDENTITY or generation expression, got %q", stmt.peek().text)
+	}
+
+	col.IsIdentity = true
+	if !stmt.accept("(") {
+		return nil
+	}
+	for depth := 1; depth > 0 && !stmt.done(); {
+		switch {
+		case stmt.acceptKeyword("START", "WITH"), stmt.acceptKeyword("START"):
+			col.IdentityOptions.Start, _ = strconv.ParseInt(stmt.number(), 10, 64)
+		case stmt.acceptKeyword("INCREMENT", "BY"), stmt.acceptKeyword("INCREMENT"):
+			col.IdentityOptions.Increment, _ = strconv.ParseInt(stmt.number(), 10, 64)
+		case stmt.accept("("):
+			depth++
+		case stmt.accept(")"):
+			depth--
+		default:
+			stmt.next()
+		}
+	}
+	return nil
+}
+
+func (s *dumpState) tableConstraint(stmt *dumpStatement, key string, table *Table, name string) error {
+	switch {
+	case stmt.acceptKeyword("PRIMARY", "KEY"):
+		columns, err := s.columns(stmt, table)
+		if err != nil {
+			return err
+		}
+		for _, c := range columns {
+			if col, ok := table.Columns.Load(c); ok {
+				col.(*Column).IsNullable = false
+			}
+		}
+		table.PrimaryKey = &sqlschema.PrimaryKey{Name: name, Columns: sqlschema.NewColumns(columns...)}
+	case stmt.acceptKeyword("UNIQUE"):
+		stmt.acceptKeyword("NULLS", "NOT", "DISTINCT")
+		stmt.acceptKeyword("NULLS", "DISTINCT")
+		columns, err := s.columns(stmt, table)
+		if err != nil {
+			return err
+		}
+		table.UniqueConstraints = append(table.UniqueConstraints, sqlschema.Unique{
+			Name:    name,
+			Columns: sqlschema.NewColumns(columns...),
+		})
+	case stmt.acceptKeyword("CHECK"):
+		expr, err := stmt.parens()
+		if err != nil {
+			return err
+		}
+		stmt.acceptKeyword("NOT", "VALID")
+		table.Checks = append(table.Checks, sqlschema.Check{Name: name, Expression: parseCheckDefinition(expr)})
+	case stmt.acceptKeyword("FOREIGN", "KEY"):
+		columns, err := s.columns(stmt, table)
+		if err != nil {
+			return err
+		}
+		if err := stmt.expectKeyword("REFERENCES"); err != nil {
+			return err
+		}
+		return s.references(stmt, key, table, columns, name)
+	case stmt.acceptKeyword("EXCLUDE"):
+		excl, err := exclusion(stmt)
+		if err != nil {
+			return err
+		}
+		excl.Name = name
+		table.Exclusions = append(table.Exclusions, excl)
+	default:
+		return stmt.errorf("unsupported constraint %q in table %q", stmt.peek().text, table.Name)
+	}
+	return nil
+}
+
+// exclusion reads the definition of an EXCLUDE constraint, e.g. "USING gist (room WITH =, during WITH &&)".
+// Operator classes of the elements are dropped, as are the INCLUDE, WITH, and USING INDEX TABLESPACE clauses.
+func exclusion(stmt *dumpStatement) (sqlschema.ExclusionConstraint, error) {
+	excl := sqlschema.ExclusionConstraint{Method: sqlschema.DefaultIndexMethod}
+	if stmt.acceptKeyword("USING") {
+		excl.Method = strings.ToLower(stmt.next().text)
+	}
+	if err := stmt.expect("("); err != nil {
+		return excl, err
+	}
+	for !stmt.accept(")") {
+		elem := stmt.raw("WITH")
+		if err := stmt.expectKeyword("WITH"); err != nil {
+			return excl, err
+		}
+		op := stmt.raw()
+		if elem == "" || op == "" {
+			return excl, stmt.errorf("exclusion constraint elements must have the form \"element WITH operator\"")
+		}
+		col := indexColumn(elem)
+		excl.Elements = append(excl.Elements, sqlschema.ExclusionElement{
+			Expression: cmp.Or(col.Name, col.Expression),
+			Operator:   op,
+		})
+		stmt.accept(",")
+	}
+
+	for !stmt.done() && !stmt.peekPunct(",") && !stmt.peekPunct(")") {
+		switch {
+		case stmt.acceptKeyword("INCLUDE"), stmt.acceptKeyword("WITH"):
+			if _, err := stmt.parens(); err != nil {
+				return excl, err
+			}
+		case stmt.acceptKeyword("WHERE"):
+			where, err := stmt.parens()
+			if err != nil {
+				return excl, err
+			}
+			excl.Where = where
+		default:
+			stmt.next()
+		}
+	}
+	return excl, nil
+}
+
+// columns reads a parenthesized list of columns, which must exist in the table.
+func (s *dumpState) columns(stmt *dumpStatement, table *Table) ([]string, error) {
+	columns, err := stmt.identList()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range columns {
+		if _, ok := table.Columns.Load(c); !ok && !s.inherited(table, c) {
+			return nil, stmt.errorf("unknown column %q in table %q", c, table.Name)
+		}
+	}
+	return columns, nil
+}
+
+// inherited reports whether the column is defined by one of the tables the table inherits from.
+func (s *dumpState) inherited(table *Table, column string) bool {
+	for _, key := range table.Inherits {
+		parent, ok := s.schema.Tables.Load(key)
+		if !ok {
+			continue
+		}
+		if _, ok := parent.GetColumns().Load(column); ok || s.inherited(parent.(*Table), column) {
+			return true
+		}
+	}
+	return false
+}
+
+// references reads the target of a foreign key after the REFERENCES keyword. The referenced columns
+// default to the same columns as the referencing ones, which is the case for primary keys named alike.
+func (s *dumpState) references(stmt *dumpStatement, key string, table *Table, columns []string, name string) error {
+	target, _, _, err := s.tableName(stmt)
+	if err != nil {
+		return err
+	}
+	refColumns := columns
+	if stmt.peekPunct("(") {
+		if refColumns, err = stmt.identList(); err != nil {
+			return err
+		}
+	}
+	if len(refColumns) != len(columns) {
+		return stmt.errorf("foreign key in table %q references %d columns, want %d", table.Name, len(refColumns), len(columns))
+	}
+
+	fk := sqlschema.ForeignKey{
+		From: sqlschema.NewColumnReference(key, columns...),
+		To:   sqlschema.NewColumnReference(target, refColumns...),
+	}
+	for {
+		switch {
+		case stmt.acceptKeyword("MATCH"):
+			fk.Match = sqlschema.NewMatchType(stmt.next().text)
+		case stmt.acceptKeyword("ON", "DELETE"):
+			fk.OnDelete = referentialActionName(stmt)
+		case stmt.acceptKeyword("ON", "UPDATE"):
+			fk.OnUpdate = referentialActionName(stmt)
+		case stmt.acceptKeyword("NOT", "DEFERRABLE"):
+		case stmt.acceptKeyword("DEFERRABLE"):
+			fk.Deferrable = true
+		case stmt.acceptKeyword("INITIALLY", "DEFERRED"):
+			fk.InitiallyDeferred = true
+		case stmt.acceptKeyword("INITIALLY", "IMMEDIATE"):
+		case stmt.acceptKeyword("NOT", "VALID"):
+		default:
+			if target == "" {
+				// Foreign keys to excluded tables are skipped, like the database inspector does.
+				return nil
+			}
+			if name == "" {
+				name = table.Name + "_" + strings.Join(columns, "_") + "_fkey"
+			}
+			s.fks = append(s.fks, dumpForeignKey{stmt: stmt, fk: fk, name: name})
+			return nil
+		}
+	}
+}
+
+func referentialActionName(stmt *dumpStatement) sqlschema.ReferentialAction {
+	for _, action := range []string{"NO ACTION", "SET NULL", "SET DEFAULT"} {
+		if stmt.acceptKeyword(strings.Fields(action)...) {
+			return sqlschema.NewReferentialAction(action)
+		}
+	}
+	return sqlschema.NewReferentialAction(stmt.next().text)
+}
+
+func (s *dumpState) alterTable(stmt *dumpStatement) error {
+	stmt.acceptKeyword("IF", "EXISTS")
+	stmt.acceptKeyword("ONLY")
+	key, _, _, err := s.tableName(stmt)
+	if err != nil || key == "" {
+		return err
+	}
+	table, ok := s.schema.Tables.Load(key)
+	if !ok {
+		// pg_dump also changes the owner of sequences and views with ALTER TABLE.
+		if stmt.peekKeyword("ADD") || stmt.peekKeyword("ALTER") {
+			return stmt.errorf("unknown table %q", key)
+		}
+		return nil
+	}
+
+	for !stmt.done() {
+		if err := s.alterTableAction(stmt, key, table.(*Table)); err != nil {
+			return err
+		}
+		if !stmt.accept(",") && !stmt.done() {
+			return stmt.errorf("expected \",\", got %q", stmt.peek().text)
+		}
+	}
+	return nil
+}
+
+func (s *dumpState) alterTableAction(stmt *dumpStatement, key string, table *Table) error {
+	switch {
+	case stmt.acceptKeyword("ADD", "CONSTRAINT"):
+		name, err := stmt.ident()
+		if err != nil {
+			return err
+		}
+		return s.tableConstraint(stmt, key, table, name)
+	case stmt.peekKeyword("ADD", "PRIMARY"), stmt.peekKeyword("ADD", "UNIQUE"),
+		stmt.peekKeyword("ADD", "CHECK"), stmt.peekKeyword("ADD", "FOREIGN"), stmt.peekKeyword("ADD", "EXCLUDE"):
+		stmt.next()
+		return s.tableConstraint(stmt, key, table, "")
+	case stmt.acceptKeyword("ALTER", "COLUMN"), stmt.acceptKeyword("ALTER"):
+		name, err := stmt.ident()
+		if err != nil {
+			return err
+		}
+		c, ok := table.Columns.Load(name)
+		if !ok && s.inherited(table, name) {
+			// Inherited columns are defined by the parent table.
+			stmt.raw()
+			return nil
+		}
+		if !ok {
+			return stmt.errorf("unknown column %q in table %q", name, table.Name)
+		}
+		col := c.(*Column)
+		switch {
+		case stmt.acceptKeyword("SET", "DEFAULT"):
+			setDefault(col, stmt.raw())
+		case stmt.acceptKeyword("SET", "NOT", "NULL"):
+			col.IsNullable = false
+		case stmt.acceptKeyword("SET", "STORAGE"):
+			// pg_dump only sets the storage mode if it differs from the type's default.
+			col.Storage = strings.ToUpper(stmt.next().text)
+		case stmt.acceptKeyword("ADD", "GENERATED"):
+			return generated(stmt, col)
+		default:
+			stmt.raw()
+		}
+	case stmt.acceptKeyword("ATTACH", "PARTITION"):
+		partition, _, _, err := s.tableName(stmt)
+		if err != nil {
+			return err
+		}
+		if p, ok := s.schema.Tables.Load(partition); ok {
+			p.(*Table).PartitionOf = key
+		}
+		stmt.raw()
+	case stmt.acceptKeyword("OWNER", "TO"):
+		s.owners[key] = stmt.raw()
+	case stmt.acceptKeyword("ENABLE", "ROW", "LEVEL", "SECURITY"):
+		table.RowSecurity.Enabled = true
+	case stmt.acceptKeyword("DISABLE", "ROW", "LEVEL", "SECURITY"):
+		table.RowSecurity.Enabled = false
+	case stmt.acceptKeyword("FORCE", "ROW", "LEVEL", "SECURITY"):
+		table.RowSecurity.Forced = true
+	case stmt.acceptKeyword("NO", "FORCE", "ROW", "LEVEL", "SECURITY"):
+		table.RowSecurity.Forced = false
+	default:
+		// Ownership, storage and replication settings are not part of the schema state.
+		stmt.raw()
+	}
+	return nil
+}
+
+// createPolicy reads CREATE POLICY statement as pg_dump writes it, e.g.:
+//
+//	CREATE POLICY docs_owner ON public.docs AS RESTRICTIVE FOR SELECT TO app USING ((owner = CURRENT_USER));
+//
+// sequence records the column which owns the sequence, e.g. ALTER SEQUENCE public.users_id_seq OWNED BY public.users.id.
+// Sequences are not part of the tables, so the rest of the statement is skipped.
+func (s *dumpState) sequence(stmt *dumpStatement) error {
+	stmt.acceptKeyword("IF", "NOT", "EXISTS")
+	stmt.acceptKeyword("IF", "EXISTS")
+	schemaName, name, err := stmt.qualifiedName()
+	if err != nil {
+		return err
+	}
+	seq := cmp.Or(schemaName, s.SchemaName) + "." + name
+	for !stmt.done() {
+		if !stmt.acceptKeyword("OWNED", "BY") {
+			stmt.next()
+			continue
+		}
+		if stmt.acceptKeyword("NONE") {
+			delete(s.sequenceOwners, seq)
+			continue
+		}
+		parts, err := stmt.nameParts()
+		if err != nil {
+			return err
+		}
+		if len(parts) < 2 {
+			return stmt.errorf("sequence %q must be owned by a column", name)
+		}
+		tableSchema := s.SchemaName
+		if len(parts) > 2 {
+			tableSchema = parts[len(parts)-3]
+		}
+		s.sequenceOwners[seq] = sequenceOwner{
+			table:  s.TableKey(tableSchema, parts[len(parts)-2]),
+			column: parts[len(parts)-1],
+		}
+	}
+	return nil
+}
+
+// resolveSerials marks the columns whose default takes the next value of a sequence they own as serial columns,
+// like the database inspector does. Sequences which are not owned by the column are regular defaults.
+func (s *dumpState) resolveSerials() {
+	for _, pair := range s.schema.Tables.Pairs() {
+		table := pair.Value.(*Table)
+		for _, c := range table.Columns.Values() {
+			col := c.(*Column)
+			seq, ok := strings.CutPrefix(col.DefaultValue, "nextval(")
+			if !ok || col.IsIdentity {
+				continue
+			}
+			seq = strings.TrimSuffix(seq, ")")
+			seq = strings.TrimSuffix(seq, "::regclass")
+			seq = strings.Trim(seq, "'")
+			if !strings.Contains(seq, ".") {
+				seq = table.Schema + "." + seq
+			}
+			if s.sequenceOwners[seq] == (sequenceOwner{table: pair.Key, column: col.Name}) {
+				col.IsAutoIncrement = true
+				col.DefaultValue = ""
+			}
+		}
+	}
+}
+
+func (s *dumpState) createPolicy(stmt *dumpStatement) error {
+	name, err := stmt.ident()
+	if err != nil {
+		return err
+	}
+	if err := stmt.expectKeyword("ON"); err != nil {
+		return err
+	}
+	key, _, tableName, err := s.tableName(stmt)
+	if err != nil || key == "" {
+		return err
+	}
+	if _, ok := s.schema.Tables.Load(key); !ok {
+		return stmt.errorf("unknown table %q", tableName)
+	}
+
+	policy := sqlschema.Policy{Name: name, TableName: key}
+	if stmt.acceptKeyword("AS") {
+		policy.Restrictive = stmt.acceptKeyword("RESTRICTIVE")
+		stmt.acceptKeyword("PERMISSIVE")
+	}
+	if stmt.acceptKeyword("FOR") {
+		policy.Command = strings.ToUpper(stmt.next().text)
+	}
+	if stmt.acceptKeyword("TO") {
+		for {
+			role, err := stmt.ident()
+			if err != nil {
+				return err
+			}
+			policy.Roles = append(policy.Roles, role)
+			if !stmt.accept(",") {
+				break
+			}
+		}
+	}
+	if stmt.acceptKeyword("USING") {
+		if policy.Using, err = stmt.parens(); err != nil {
+			return err
+		}
+		policy.Using = parsePolicyExpr(policy.Using)
+	}
+	if stmt.acceptKeyword("WITH", "CHECK") {
+		if policy.WithCheck, err = stmt.parens(); err != nil {
+			return err
+		}
+		policy.WithCheck = parsePolicyExpr(policy.WithCheck)
+	}
+	s.schema.Policies = append(s.schema.Policies, policy)
+	return nil
+}
+
+// grant reads GRANT statement on a table as pg_dump writes it, e.g.:
+//
+//	GRANT SELECT(title),UPDATE(title) ON TABLE public.documents TO editor;
+//
+// Grants on other objects, such as sequences and schemas, and grants to the table owner are skipped.
+func (s *dumpState) grant(stmt *dumpStatement) error {
+	type privilege struct {
+		name    string
+		columns []string
+	}
+	var privileges []privilege
+	for {
+		p := privilege{name: strings.ToUpper(stmt.next().text)}
+		if p.name == "ALL" {
+			stmt.acceptKeyword("PRIVILEGES")
+		}
+		if stmt.peekPunct("(") {
+			columns, err := stmt.identList()
+			if err != nil {
+				return err
+			}
+			p.columns = columns
+		}
+		privileges = append(privileges, p)
+		if !stmt.accept(",") {
+			break
+		}
+	}
+	if err := stmt.expectKeyword("ON"); err != nil {
+		return err
+	}
+	if !stmt.acceptKeyword("TABLE") {
+		return nil
+	}
+	key, _, _, err := s.tableName(stmt)
+	if err != nil || key == "" {
+		return err
+	}
+	if _, ok := s.schema.Tables.Load(key); !ok {
+		// Grants on views and sequences also use ON TABLE.
+		return nil
+	}
+	if err := stmt.expectKeyword("TO"); err != nil {
+		return err
+	}
+	for {
+		grantee, err := stmt.ident()
+		if err != nil {
+			return err
+		}
+		for _, p := range privileges {
+			if grantee == s.owners[key] {
+				break
+			}
+			columns := p.columns
+			if len(columns) == 0 {
+				columns = []string{""}
+			}
+			for _, col := range columns {
+				grant := sqlschema.Grant{TableName: key, Column: col, Grantee: grantee, Privilege: p.name}
+				s.schema.Grants = append(s.schema.Grants, grant.Expand()...)
+			}
+		}
+		if !stmt.accept(",") {
+			return nil
+		}
+	}
+}
+
+func (s *dumpState) createIndex(stmt *dumpStatement, unique bool) error {
+	stmt.acceptKeyword("CONCURRENTLY")
+	stmt.acceptKeyword("IF", "NOT", "EXISTS")
+	name, err := stmt.ident()
+	if err != nil {
+		return err
+	}
+	if err := stmt.expectKeyword("ON"); err != nil {
+		return err
+	}
+	stmt.acceptKeyword("ONLY")
+	key, _, _, err := s.tableName(stmt)
+	if err != nil || key == "" {
+		return err
+	}
+	if _, ok := s.schema.Tables.Load(key); !ok {
+		return stmt.errorf("index %q is created on unknown table %q", name, key)
+	}
+
+	idx := sqlschema.Index{Name: name, TableName: key, Unique: unique, Method: sqlschema.DefaultIndexMethod, Tablespace: s.tablespace}
+	if stmt.acceptKeyword("USING") {
+		idx.Method = strings.ToLower(stmt.next().text)
+	}
+	if err := stmt.expect("("); err != nil {
+		return err
+	}
+	for !stmt.accept(")") {
+		elem := stmt.raw()
+		if elem == "" {
+			return stmt.errorf("index %q must have columns", name)
+		}
+		idx.Columns = append(idx.Columns, indexColumn(elem))
+		stmt.accept(",")
+	}
+
+	for !stmt.done() {
+		switch {
+		case stmt.acceptKeyword("INCLUDE"):
+			if idx.Include, err = stmt.identList(); err != nil {
+				return err
+			}
+		case stmt.acceptKeyword("WITH"):
+			if idx.StorageParameters, err = storageParameters(stmt); err != nil {
+				return err
+			}
+		case stmt.acceptKeyword("TABLESPACE"):
+			if idx.Tablespace, err = stmt.ident(); err != nil {
+				return err
+			}
+		case stmt.acceptKeyword("WHERE"):
+			idx.Where = stmt.rest()
+		default:
+			stmt.next()
+		}
+	}
+	s.schema.Indexes = append(s.schema.Indexes, idx)
+	return nil
+}
+
+// indexColumn creates an index key part from its definition, which has the form
+// "column_or_expression [COLLATE collation] [opclass] [ASC | DESC] [NULLS { FIRST | LAST }]".
+// The operator class is kept without its schema, as pg_dump only prints the non-default ones,
+// while the collation and sort order are dropped.
+func indexColumn(elem string) sqlschema.IndexColumn {
+	toks, _ := tokenizeDump(elem)
+	isKeyword := func(i int, words ...string) bool {
+		return i >= 0 && toks[i].kind == tokIdent && slices.Contains(words, toks[i].text)
+	}
+	isPunct := func(i int, p string) bool {
+		return i >= 0 && toks[i].kind == tokPunct && toks[i].text == p
+	}
+
+	n := len(toks)
+	if n > 2 && isKeyword(n-2, "nulls") && isKeyword(n-1, "first", "last") {
+		n -= 2
+	}
+	if n > 1 && isKeyword(n-1, "asc", "desc") {
+		n--
+	}
+	var opClass string
+	if n > 1 && toks[n-1].isIdent() {
+		// The operator class follows a column name or a closing parenthesis, possibly qualified with its schema.
+		start := n - 1
+		if start > 2 && isPunct(start-1, ".") && toks[start-2].isIdent() {
+			start -= 2
+		}
+		if prev := start - 1; (toks[prev].isIdent() || isPunct(prev, ")")) && !isKeyword(prev, "collate") {
+			opClass = toks[n-1].text
+			n = start
+		}
+	}
+	if n > 2 && isKeyword(n-2, "collate") {
+		n -= 2
+	}
+	if n == 0 {
+		return sqlschema.IndexColumn{Expression: elem}
+	}
+
+	col := sqlschema.IndexColumn{OpClass: opClass}
+	switch expr := strings.TrimSpace(elem[:toks[n-1].end]); {
+	case strings.HasPrefix(expr, "(") && isEnclosed(expr):
+		col.Expression = expr[1 : len(expr)-1]
+	case n == 1 && toks[0].isIdent():
+		col.Name = toks[0].text
+	default:
+		col.Expression = expr
+	}
+	return col
+}
+
+func (s *dumpState) createType(stmt *dumpStatement) error {
+	_, name, err := stmt.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if !stmt.acceptKeyword("AS", "ENUM") {
+		// Domains, composite and range types are not read from the dump.
+		return nil
+	}
+	if err := stmt.expect("("); err != nil {
+		return err
+	}
+	var values []string
+	for !stmt.accept(")") {
+		tok := stmt.next()
+		if tok.kind != tokString {
+			return stmt.errorf("enum %q: expected a value, got %q", name, tok.text)
+		}
+		values = append(values, tok.text)
+		stmt.accept(",")
+	}
+	s.schema.Enums[name] = values
+	return nil
+}
+
+func (s *dumpState) createExtension(stmt *dumpStatement) error {
+	stmt.acceptKeyword("IF", "NOT", "EXISTS")
+	name, err := stmt.ident()
+	if err != nil {
+		return err
+	}
+	ext := sqlschema.Extension{Name: name}
+	stmt.acceptKeyword("WITH")
+	for !stmt.done() {
+		switch {
+		case stmt.acceptKeyword("SCHEMA"):
+			if ext.Schema, err = stmt.ident(); err != nil {
+				return err
+			}
+		case stmt.acceptKeyword("VERSION"):
+			ext.Version = stmt.next().text
+		default:
+			stmt.next() // CASCADE
+		}
+	}
+	s.schema.Extensions = append(s.schema.Extensions, ext)
+	return nil
+}
+
+func (s *dumpState) createView(stmt *dumpStatement, materialized bool) error {
+	stmt.acceptKeyword("IF", "NOT", "EXISTS")
+	schemaName, name, err := stmt.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if schemaName == "" {
+		schemaName = s.SchemaName
+	}
+	if s.excluded(schemaName, name) {
+		return nil
+	}
+	if err := stmt.expectKeyword("AS"); err != nil {
+		return err
+	}
+	def := stmt.rest()
+	populated := true
+	if materialized {
+		for _, suffix := range []string{"WITH NO DATA", "WITH DATA"} {
+			if strings.HasSuffix(strings.ToUpper(def), suffix) {
+				populated = suffix == "WITH DATA"
+				def = strings.TrimSpace(def[:len(def)-len(suffix)])
+			}
+		}
+	}
+	s.schema.Views = append(s.schema.Views, sqlschema.View{
+		Schema:       schemaName,
+		Name:         name,
+		Definition:   def,
+		Materialized: materialized,
+		Populated:    !materialized || populated,
+	})
+	return nil
+}
+
+// refreshView marks a materialized view, which pg_dump creates WITH NO DATA, as populated.
+func (s *dumpState) refreshView(stmt *dumpStatement) error {
+	stmt.acceptKeyword("CONCURRENTLY")
+	schemaName, name, err := stmt.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if schemaName == "" {
+		schemaName = s.SchemaName
+	}
+	for i, v := range s.schema.Views {
+		if v.Schema == schemaName && v.Name == name {
+			s.schema.Views[i].Populated = true
+		}
+	}
+	return nil
+}
+
+func (s *dumpState) comment(stmt *dumpStatement) error {
+	switch {
+	case stmt.acceptKeyword("FOREIGN", "TABLE"):
+		key, _, _, err := s.tableName(stmt)
+		if err != nil || key == "" {
+			return err
+		}
+		i, ok := s.foreign[key]
+		if !ok || i < 0 {
+			return nil
+		}
+		if err := stmt.expectKeyword("IS"); err != nil {
+			return err
+		}
+		s.schema.ForeignTables[i].Comment = stmt.next().text
+	case stmt.acceptKeyword("TABLE"):
+		table, err := s.table(stmt)
+		if err != nil || table == nil {
+			return err
+		}
+		if err := stmt.expectKeyword("IS"); err != nil {
+			return err
+		}
+		table.Comment = stmt.next().text
+	case stmt.acceptKeyword("COLUMN"):
+		// The column name is the last part of the qualified name, e.g. public.users.email.
+		parts, err := stmt.nameParts()
+		if err != nil {
+			return err
+		}
+		if len(parts) < 2 {
+			return stmt.errorf("expected a qualified column name")
+		}
+		schemaName, tableName := s.SchemaName, parts[len(parts)-2]
+		if len(parts) > 2 {
+			schemaName = parts[len(parts)-3]
+		}
+		if s.excluded(schemaName, tableName) {
+			return nil
+		}
+		key := s.TableKey(schemaName, tableName)
+		var columns *ordered.Map[string, sqlschema.Column]
+		if i, ok := s.foreign[key]; ok {
+			if i < 0 {
+				return nil
+			}
+			columns = s.schema.ForeignTables[i].Table().Columns
+		} else if table, ok := s.schema.Tables.Load(key); ok {
+			columns = table.GetColumns()
+		} else {
+			return stmt.errorf("unknown table %q", tableName)
+		}
+		col, ok := columns.Load(parts[len(parts)-1])
+		if !ok {
+			return stmt.errorf("unknown column %q in table %q", parts[len(parts)-1], tableName)
+		}
+		if err := stmt.expectKeyword("IS"); err != nil {
+			return err
+		}
+		col.(*Column).Comment = stmt.next().text
+	}
+	return nil
+}
+
+// columnType parses the column type from the dump. User-defined types are reported without their schema,
+// like the database inspector does, and serial types are replaced with the integer types they stand for.
+func columnType(typ string) (*Column, error) {
+	toks, err := tokenizeDump(typ)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) > 2 && toks[0].isIdent() && toks[1].text == "." {
+		toks = toks[2:]
+	}
+	name := typ[toks[0].start:toks[0].end]
+	if toks[0].kind == tokQuotedIdent {
+		name = toks[0].text
+	}
+	typ = name + typ[toks[0].end:]
+
+	var serial bool
+	if integer, ok := serialTypes[strings.ToLower(typ)]; ok {
+		typ, serial = integer, true
+	}
+	col, err := sqlschema.ParseSQLType(typ)
+	if err != nil {
+		return nil, err
+	}
+	col.IsAutoIncrement = serial
+	return &col, nil
+}
+
+// setDefault sets the column's default in the form reported by the database inspector,
+// i.e. literals are stripped of their quotes and casts. Serial columns are resolved once the whole dump is read.
+func setDefault(col *Column, expr string) {
+	toks, err := tokenizeDump(expr)
+	switch {
+	case err != nil || len(toks) == 0:
+		col.DefaultValue = sqlschema.LowerExpr(expr)
+	case toks[0].kind == tokString && (len(toks) == 1 || isCast(toks[1:])):
+		col.DefaultValue = toks[0].text
+	case len(toks) == 1 && toks[0].kind == tokNumber:
+		col.DefaultValue = toks[0].text
+	default:
+		col.DefaultValue = sqlschema.LowerExpr(expr)
+	}
+}
+
+// isCast reports whether the tokens are a type cast, e.g. ::character varying(10) or ::text[].
+func isCast(toks []dumpToken) bool {
+	if toks[0].text != "::" {
+		return false
+	}
+	for _, tok := range toks[1:] {
+		switch {
+		case tok.kind == tokString:
+			return false
+		case tok.kind == tokOperator && !slices.Contains([]string{"::", "[", "]"}, tok.text):
+			return false
+		}
+	}
+	return true
+}
+
+// ---------------------------------------------------------------------------
+
+type dumpTokenKind int
+
+const (
+	tokEOF dumpTokenKind = iota
+	tokIdent
+	tokQuotedIdent
+	tokString
+	tokNumber
+	tokPunct
+	tokOperator
+)
+
+// dumpToken is a lexical token of the dump. The text of unquoted identifiers is folded to lower case,
+// like Postgres does, and the text of quoted identifiers and strings is unquoted.
+// Start and end are byte offsets of the token in the source, used to copy expressions verbatim.
+type dumpToken struct {
+	kind       dumpTokenKind
+	text       string
+	start, end int
+}
+
+func (t dumpToken) isIdent() bool {
+	return t.kind == tokIdent || t.kind == tokQuotedIdent
+}
+
+// dumpStatement is a single statement of the dump with a cursor for parsing its tokens.
+type dumpStatement struct {
+	file string
+	src  string
+	line int
+	toks []dumpToken
+	pos  int
+}
+
+// splitDump splits the dump into statements at the semicolons, skipping comments and empty statements.
+func splitDump(src string) ([]*dumpStatement, error) {
+	toks, err := tokenizeDump(src)
+	if err != nil {
+		return nil, err
+	}
+	var statements []*dumpStatement
+	var start int
+	for i, tok := range toks {
+		if tok.kind != tokPunct || tok.text != ";" {
+			continue
+		}
+		if i > start {
+			statements = append(statements, newDumpStatement(src, toks[start:i]))
+		}
+		start = i + 1
+	}
+	if start < len(toks) {
+		statements = append(statements, newDumpStatement(src, toks[start:]))
+	}
+	return statements, nil
+}
+
+func newDumpStatement(src string, toks []dumpToken) *dumpStatement {
+	return &dumpStatement{
+		src:  src,
+		line: strings.Count(src[:toks[0].start], "\n") + 1,
+		toks: toks,
+	}
+}
+
+// tokenizeDump splits the SQL into tokens. The error reports the line of an unterminated string or comment.
+func tokenizeDump(src string) ([]dumpToken, error) {
+	var toks []dumpToken
+	errorf := func(pos int, format string, args ...interface{}) error {
+		return fmt.Errorf("%d: %s", strings.Count(src[:pos], "\n")+1, fmt.Sprintf(format, args...))
+	}
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "--"):
+			if j := strings.IndexByte(src[i:], '\n'); j != -1 {
+				i += j + 1
+			} else {
+				i = len(src)
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			j := strings.Index(src[i+2:], "*/")
+			if j == -1 {
+				return nil, errorf(i, "unterminated comment")
+			}
+			i += j + 4
+		case c == '\'' || c == '"':
+			text, end, ok := readQuoted(src, i, c)
+			if !ok {
+				return nil, errorf(i, "unterminated %s", map[byte]string{'\'': "string", '"': "identifier"}[c])
+			}
+			kind := tokString
+			if c == '"' {
+				kind = tokQuotedIdent
+			}
+			toks = append(toks, dumpToken{kind: kind, text: text, start: i, end: end})
+			i = end
+		case (c == 'E' || c == 'e') && i+1 < len(src) && src[i+1] == '\'':
+			text, end, ok := readQuoted(src, i+1, '\'')
+			if !ok {
+				return nil, errorf(i, "unterminated string")
+			}
+			toks = append(toks, dumpToken{kind: tokString, text: text, start: i, end: end})
+			i = end
+		case c == '$':
+			tag := dollarTag(src[i:])
+			if tag == "" {
+				toks = append(toks, dumpToken{kind: tokOperator, text: "$", start: i, end: i + 1})
+				i++
+				break
+			}
+			j := strings.Index(src[i+len(tag):], tag)
+			if j == -1 {
+				return nil, errorf(i, "unterminated dollar-quoted string")
+			}
+			end := i + len(tag) + j + len(tag)
+			toks = append(toks, dumpToken{kind: tokString, text: src[i+len(tag) : end-len(tag)], start: i, end: end})
+			i = end
+		case c == '_' || unicode.IsLetter(rune(c)) || c >= 0x80:
+			j := i + 1
+			for j < len(src) && (src[j] == '_' || src[j] == '$' || unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j])) || src[j] >= 0x80) {
+				j++
+			}
+			toks = append(toks, dumpToken{kind: tokIdent, text: strings.ToLower(src[i:j]), start: i, end: j})
+			i = j
+		case c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+				j++
+			}
+			toks = append(toks, dumpToken{kind: tokNumber, text: src[i:j], start: i, end: j})
+			i = j
+		case strings.ContainsRune("(),.;", rune(c)):
+			toks = append(toks, dumpToken{kind: tokPunct, text: string(c), start: i, end: i + 1})
+			i++
+		case strings.HasPrefix(src[i:], "::"):
+			toks = append(toks, dumpToken{kind: tokOperator, text: "::", start: i, end: i + 2})
+			i += 2
+		default:
+			toks = append(toks, dumpToken{kind: tokOperator, text: string(c), start: i, end: i + 1})
+			i++
+		}
+	}
+	return toks, nil
+}
+
+// readQuoted reads the string or identifier which starts with the quote at src[i].
+// Doubled quotes inside are unescaped. It returns the unquoted text and the offset after the closing quote.
+func readQuoted(src string, i int, quote byte) (string, int, bool) {
+	var b strings.Builder
+	for j := i + 1; j < len(src); j++ {
+		if src[j] != quote {
+			b.WriteByte(src[j])
+			continue
+		}
+		if j+1 < len(src) && src[j+1] == quote {
+			b.WriteByte(quote)
+			j++
+			continue
+		}
+		return b.String(), j + 1, true
+	}
+	return "", 0, false
+}
+
+// dollarTag returns the opening tag of a dollar-quoted string, e.g. "$$" or "$body$", if there is one.
+func dollarTag(s string) string {
+	for j := 1; j < len(s); j++ {
+		switch c := s[j]; {
+		case c == '$':
+			return s[:j+1]
+		case c == '_' || unicode.IsLetter(rune(c)) || j > 1 && unicode.IsDigit(rune(c)):
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+func (stmt *dumpStatement) errorf(format string, args ...interface{}) error {
+	snippet := stmt.src[stmt.toks[0].start:stmt.toks[len(stmt.toks)-1].end]
+	if i := strings.IndexByte(snippet, '\n'); i != -1 {
+		snippet = snippet[:i]
+	}
+	if len(snippet) > 60 {
+		snippet = snippet[:60] + "..."
+	}
+	return fmt.Errorf("%s:%d: %s: %s", stmt.file, stmt.line, fmt.Sprintf(format, args...), snippet)
+}
+
+func (stmt *dumpStatement) done() bool {
+	return stmt.pos >= len(stmt.toks)
+}
+
+func (stmt *dumpStatement) peek() dumpToken {
+	if stmt.done() {
+		return dumpToken{kind: tokEOF, text: "end of statement"}
+	}
+	return stmt.toks[stmt.pos]
+}
+
+func (stmt *dumpStatement) next() dumpToken {
+	tok := stmt.peek()
+	if !stmt.done() {
+		stmt.pos++
+	}
+	return tok
+}
+
+// peekKeyword reports whether the next tokens are the unquoted keywords, compared case-insensitively.
+func (stmt *dumpStatement) peekKeyword(words ...string) bool {
+	if stmt.pos+len(words) > len(stmt.toks) {
+		return false
+	}
+	for i, word := range words {
+		tok := stmt.toks[stmt.pos+i]
+		if tok.kind != tokIdent || tok.text != strings.ToLower(word) {
+			return false
+		}
+	}
+	return true
+}
+
+func (stmt *dumpStatement) acceptKeyword(words ...string) bool {
+	if !stmt.peekKeyword(words...) {
+		return false
+	}
+	stmt.pos += len(words)
+	return true
+}
+
+func (stmt *dumpStatement) expectKeyword(words ...string) error {
+	if !stmt.acceptKeyword(words...) {
+		return stmt.errorf("expected %s, got %q", strings.Join(words, " "), stmt.peek().text)
+	}
+	return nil
+}
+
+func (stmt *dumpStatement) peekPunct(p string) bool {
+	tok := stmt.peek()
+	return tok.kind == tokPunct && tok.text == p
+}
+
+func (stmt *dumpStatement) accept(p string) bool {
+	if !stmt.peekPunct(p) {
+		return false
+	}
+	stmt.pos++
+	return true
+}
+
+func (stmt *dumpStatement) expect(p string) error {
+	if !stmt.accept(p) {
+		return stmt.errorf("expected %q, got %q", p, stmt.peek().text)
+	}
+	return nil
+}
+
+func (stmt *dumpStatement) ident() (string, error) {
+	tok := stmt.next()
+	if !tok.isIdent() {
+		return "", stmt.errorf("expected a name, got %q", tok.text)
+	}
+	return tok.text, nil
+}
+
+func (stmt *dumpStatement) number() string {
+	tok := stmt.peek()
+	if tok.kind == tokOperator && tok.text == "-" {
+		stmt.next()
+		return "-" + stmt.next().text
+	}
+	return stmt.next().text
+}
+
+// nameParts reads a dotted name, e.g. public.users.email.
+func (stmt *dumpStatement) nameParts() ([]string, error) {
+	var parts []string
+	for {
+		part, err := stmt.ident()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+		if !stmt.accept(".") {
+			return parts, nil
+		}
+	}
+}
+
+// qualifiedName reads an optionally schema-qualified name, e.g. public.users.
+func (stmt *dumpStatement) qualifiedName() (schemaName, name string, err error) {
+	parts, err := stmt.nameParts()
+	if err != nil {
+		return "", "", err
+	}
+	switch len(parts) {
+	case 1:
+		return "", parts[0], nil
+	case 2:
+		return parts[0], parts[1], nil
+	}
+	return "", "", stmt.errorf("unexpected name %q", strings.Join(parts, "."))
+}
+
+// identList reads a parenthesized list of names, e.g. (id, "userId").
+func (stmt *dumpStatement) identList() ([]string, error) {
+	if err := stmt.expect("("); err != nil {
+		return nil, err
+	}
+	var names []string
+	for {
+		name, err := stmt.ident()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if stmt.accept(")") {
+			return names, nil
+		}
+		if err := stmt.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parens reads the expression in parentheses and returns it verbatim, without the outermost parentheses.
+func (stmt *dumpStatement) parens() (string, error) {
+	if !stmt.peekPunct("(") {
+		return "", stmt.errorf("expected \"(\", got %q", stmt.peek().text)
+	}
+	start := stmt.pos
+	for depth := 0; ; {
+		tok := stmt.next()
+		switch {
+		case tok.kind == tokEOF:
+			return "", stmt.errorf("unbalanced parentheses")
+		case tok.kind == tokPunct && tok.text == "(":
+			depth++
+		case tok.kind == tokPunct && tok.text == ")":
+			if depth--; depth == 0 {
+				return stmt.src[stmt.toks[start].end:tok.start], nil
+			}
+		}
+	}
+}
+
+// raw copies the source of the tokens verbatim until a comma or an unmatched closing parenthesis,
+// or until one of the stop keywords outside of parentheses.
+func (stmt *dumpStatement) raw(stop ...string) string {
+	start := stmt.pos
+	depth := 0
+loop:
+	for !stmt.done() {
+		tok := stmt.peek()
+		switch {
+		case tok.kind == tokPunct && tok.text == "(":
+			depth++
+		case tok.kind == tokPunct && tok.text == ")":
+			if depth == 0 {
+				break loop
+			}
+			depth--
+		case depth > 0:
+		case tok.kind == tokPunct && tok.text == ",":
+			break loop
+		case tok.kind == tokIdent && stmt.pos > start:
+			for _, word := range stop {
+				if stmt.peekKeyword(word) {
+					break loop
+				}
+			}
+		}
+		stmt.pos++
+	}
+	if stmt.pos == start {
+		return ""
+	}
+	return stmt.src[stmt.toks[start].start:stmt.toks[stmt.pos-1].end]
+}
+
+// rest copies the source of the remaining tokens verbatim.
+func (stmt *dumpStatement) rest() string {
+	if stmt.done() {
+		return ""
+	}
+	start := stmt.toks[stmt.pos].start
+	stmt.pos = len(stmt.toks)
+	return stmt.src[start:stmt.toks[len(stmt.toks)-1].end]
+}
+
+// skip moves the cursor to the end of the statement.
+func (stmt *dumpStatement) skip() {
+	stmt.pos = len(stmt.toks)
+}
@@ -0,0 +1,302 @@
+package pgdialect
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun/migrate/sqlschema"
+)
+
+const testDump = `
+--
+-- PostgreSQL database dump
+--
+
+SET statement_timeout = 0;
+SELECT pg_catalog.set_config('search_path', '', false);
+
+CREATE TYPE public.mood AS ENUM (
+    'happy',
+    'sad'
+);
+
+CREATE FUNCTION public.touch() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$BEGIN NEW.updated_at := now(); RETURN NEW; END;$$;
+
+CREATE TABLE public.authors (
+    id bigint NOT NULL,
+    email character varying(320) NOT NULL,
+    mood public.mood DEFAULT 'happy'::public.mood,
+    rating numeric(3,1) DEFAULT 0,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+COMMENT ON TABLE public.authors IS 'People who write';
+COMMENT ON COLUMN public.authors.email IS 'Login';
+
+CREATE SEQUENCE public.authors_id_seq START WITH 1 INCREMENT BY 1;
+ALTER SEQUENCE public.authors_id_seq OWNED BY public.authors.id;
+
+CREATE TABLE public.books (
+    id bigint NOT NULL,
+    author_id bigint,
+    title text DEFAULT 'untitled'::text,
+    tags text[],
+    CONSTRAINT books_title_check CHECK ((length(title) > 0))
+);
+
+ALTER TABLE public.books ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.books_id_seq
+    START WITH 10
+    INCREMENT BY 1
+    NO MINVALUE
+    CACHE 1
+);
+
+CREATE VIEW public.prolific AS
+ SELECT author_id
+   FROM public.books
+  GROUP BY author_id;
+
+ALTER TABLE public.authors OWNER TO bun;
+ALTER TABLE ONLY public.authors ALTER COLUMN id SET DEFAULT nextval('public.authors_id_seq'::regclass);
+ALTER TABLE ONLY public.authors
+    ADD CONSTRAINT authors_pkey PRIMARY KEY (id);
+ALTER TABLE ONLY public.authors
+    ADD CONSTRAINT authors_email_key UNIQUE (email);
+ALTER TABLE ONLY public.books
+    ADD CONSTRAINT books_pkey PRIMARY KEY (id);
+
+CREATE INDEX books_lower_title_idx ON public.books USING btree (lower(title)) WHERE (author_id IS NOT NULL);
+CREATE UNIQUE INDEX books_author_title_idx ON public.books USING btree (author_id, title DESC) INCLUDE (tags);
+
+ALTER TABLE ONLY public.books
+    ADD CONSTRAINT books_author_id_fkey FOREIGN KEY (author_id) REFERENCES public.authors(id) ON DELETE CASCADE;
+`
+
+func TestDumpInspector(t *testing.T) {
+	inspect := func(t *testing.T, dump string, options ...sqlschema.InspectorOption) (sqlschema.Database, error) {
+		t.Helper()
+		fsys := fstest.MapFS{"schema.sql": {Data: []byte(dump)}}
+		return NewDumpInspector(fsys, "schema.sql", options...).Inspect(context.Background())
+	}
+
+	t.Run("reads pg_dump output", func(t *testing.T) {
+		state, err := inspect(t, testDump)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"authors", "books"}, state.GetTables().Keys())
+		require.Equal(t, map[string][]string{"mood": {"happy", "sad"}}, state.GetEnums())
+
+		authors := state.GetTables().Value("authors").(*Table)
+		require.Equal(t, "public", authors.Schema)
+		require.Equal(t, "People who write", authors.Comment)
+		require.Equal(t, []string{"id", "email", "mood", "rating", "created_at"}, authors.Columns.Keys())
+		require.Equal(t, &Column{Name: "id", SQLType: "bigint", IsAutoIncrement: true}, authors.Columns.Value("id"))
+		require.Equal(t, &Column{Name: "email", SQLType: "character varying", VarcharLen: 320, Comment: "Login"},
+			authors.Columns.Value("email"))
+		require.Equal(t, &Column{Name: "mood", SQLType: "mood", DefaultValue: "happy", IsNullable: true},
+			authors.Columns.Value("mood"))
+		require.Equal(t, &Column{Name: "rating", SQLType: "numeric", NumericPrecision: 3, NumericScale: 1, DefaultValue: "0", IsNullable: true},
+			authors.Columns.Value("rating"))
+		require.Equal(t, &Column{Name: "created_at", SQLType: "timestamp with time zone", DefaultValue: "now()"},
+			authors.Columns.Value("created_at"))
+		require.Equal(t, &sqlschema.PrimaryKey{Name: "authors_pkey", Columns: sqlschema.NewColumns("id")}, authors.PrimaryKey)
+		require.Equal(t, []sqlschema.Unique{{Name: "authors_email_key", Columns: sqlschema.NewColumns("email")}}, authors.UniqueConstraints)
+
+		books := state.GetTables().Value("books").(*Table)
+		require.Equal(t, &Column{
+			Name: "id", SQLType: "bigint", IsIdentity: true,
+			IdentityOptions: sqlschema.IdentityOptions{Generation: sqlschema.IdentityAlways, Start: 10, Increment: 1},
+		}, books.Columns.Value("id"))
+		require.Equal(t, &Column{Name: "tags", SQLType: "text", ArrayDims: 1, IsNullable: true}, books.Columns.Value("tags"))
+		require.Equal(t, "untitled", books.Columns.Value("title").GetDefaultValue())
+		require.Equal(t, []sqlschema.Check{{Name: "books_title_check", Expression: "length(title) > 0"}}, books.Checks)
+
+		require.Equal(t, map[sqlschema.ForeignKey]string{
+			{
+				From:     sqlschema.NewColumnReference("books", "author_id"),
+				To:       sqlschema.NewColumnReference("authors", "id"),
+				OnDelete: sqlschema.Cascade,
+			}: "books_author_id_fkey",
+		}, state.GetForeignKeys())
+
+		require.Equal(t, []sqlschema.Index{
+			{
+				Name:      "books_lower_title_idx",
+				TableName: "books",
+				Columns:   []sqlschema.IndexColumn{{Expression: "lower(title)"}},
+				Where:     "(author_id IS NOT NULL)",
+				Method:    "btree",
+			},
+			{
+				Name:      "books_author_title_idx",
+				TableName: "books",
+				Columns:   sqlschema.NewIndexColumns("author_id", "title"),
+				Unique:    true,
+				Include:   []string{"tags"},
+				Method:    "btree",
+			},
+		}, state.(Schema).Indexes)
+
+		require.Len(t, state.(Schema).Views, 1)
+		require.Equal(t, "prolific", state.(Schema).Views[0].Name)
+		require.Contains(t, state.(Schema).Views[0].Definition, "GROUP BY author_id")
+	})
+
+	t.Run("reads inline constraints", func(t *testing.T) {
+		state, err := inspect(t, `
+CREATE TABLE "authors" ("id" BIGSERIAL NOT NULL, PRIMARY KEY ("id"));
+CREATE TABLE "posts" (
+	"id" BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	"author_id" BIGINT CONSTRAINT "posts_author_fk" REFERENCES "authors" ("id") ON UPDATE SET NULL,
+	"slug" VARCHAR(50) UNIQUE COLLATE "C",
+	"title_lower" TEXT GENERATED ALWAYS AS (lower(slug)) STORED
+);`)
+		require.NoError(t, err)
+
+		authors := state.GetTables().Value("authors").(*Table)
+		require.Equal(t, &Column{Name: "id", SQLType: "bigint", IsAutoIncrement: true}, authors.Columns.Value("id"))
+
+		posts := state.GetTables().Value("posts").(*Table)
+		require.Equal(t, &sqlschema.PrimaryKey{Columns: sqlschema.NewColumns("id")}, posts.PrimaryKey)
+		require.True(t, posts.Columns.Value("id").GetIsIdentity())
+		require.Equal(t, "C", posts.Columns.Value("slug").GetCollation())
+		require.Equal(t, 50, posts.Columns.Value("slug").GetVarcharLen())
+		require.Equal(t, []sqlschema.Unique{{Columns: sqlschema.NewColumns("slug")}}, posts.UniqueConstraints)
+		require.Equal(t, "lower(slug)", posts.Columns.Value("title_lower").GetGeneratedExpr())
+		require.True(t, posts.Columns.Value("title_lower").GetGeneratedStored())
+		require.Equal(t, map[sqlschema.ForeignKey]string{
+			{
+				From:     sqlschema.NewColumnReference("posts", "author_id"),
+				To:       sqlschema.NewColumnReference("authors", "id"),
+				OnUpdate: sqlschema.SetNull,
+			}: "posts_author_fk",
+		}, state.GetForeignKeys())
+	})
+
+	t.Run("tells serial columns from identity columns", func(t *testing.T) {
+		state, err := inspect(t, `
+CREATE TABLE public.tickets (
+    id bigint NOT NULL,
+    seat bigint NOT NULL,
+    number bigint NOT NULL,
+    legacy bigint NOT NULL
+);
+ALTER TABLE public.tickets ALTER COLUMN seat ADD GENERATED BY DEFAULT AS IDENTITY (
+    SEQUENCE NAME public.tickets_seat_seq
+    START WITH 100
+    INCREMENT BY 1
+);
+CREATE SEQUENCE public.tickets_id_seq START WITH 1 INCREMENT BY 1;
+ALTER SEQUENCE public.tickets_id_seq OWNED BY public.tickets.id;
+CREATE SEQUENCE public.ticket_numbers START WITH 1 INCREMENT BY 1;
+CREATE SEQUENCE public.tickets_legacy_seq START WITH 1 INCREMENT BY 1;
+ALTER TABLE ONLY public.tickets ALTER COLUMN id SET DEFAULT nextval('public.tickets_id_seq'::regclass);
+ALTER TABLE ONLY public.tickets ALTER COLUMN number SET DEFAULT nextval('public.ticket_numbers'::regclass);
+ALTER TABLE ONLY public.tickets ALTER COLUMN legacy SET DEFAULT nextval('public.tickets_legacy_seq'::regclass);
+`)
+		require.NoError(t, err)
+
+		tickets := state.GetTables().Value("tickets").(*Table)
+		require.Equal(t, &Column{Name: "id", SQLType: "bigint", IsAutoIncrement: true}, tickets.Columns.Value("id"))
+		require.Equal(t, &Column{
+			Name: "seat", SQLType: "bigint", IsIdentity: true,
+			IdentityOptions: sqlschema.IdentityOptions{Generation: sqlschema.IdentityByDefault, Start: 100, Increment: 1},
+		}, tickets.Columns.Value("seat"))
+		// Sequences which the columns do not own are regular defaults, even if they are named like a serial sequence.
+		require.Equal(t, "nextval('public.ticket_numbers'::regclass)", tickets.Columns.Value("number").GetDefaultValue())
+		require.False(t, tickets.Columns.Value("legacy").GetIsAutoIncrement())
+		require.Equal(t, "nextval('public.tickets_legacy_seq'::regclass)", tickets.Columns.Value("legacy").GetDefaultValue())
+	})
+
+	t.Run("reads foreign tables if included", func(t *testing.T) {
+		const dump = `
+CREATE TABLE public.orders (id bigint NOT NULL);
+
+CREATE FOREIGN TABLE public.remote_orders (
+    id bigint OPTIONS (column_name 'order_id') NOT NULL,
+    note text
+)
+SERVER billing
+OPTIONS (
+    schema_name 'public',
+    table_name 'orders'
+);
+ALTER FOREIGN TABLE public.remote_orders OWNER TO bun;
+
+COMMENT ON FOREIGN TABLE public.remote_orders IS 'Orders in billing';
+COMMENT ON COLUMN public.remote_orders.note IS 'Free text';
+`
+		state, err := inspect(t, dump)
+		require.NoError(t, err)
+		require.Equal(t, []string{"orders"}, state.GetTables().Keys())
+		require.Empty(t, state.GetForeignTables())
+
+		state, err = inspect(t, dump, sqlschema.WithIncludeForeignTables())
+		require.NoError(t, err)
+		require.Equal(t, []string{"orders"}, state.GetTables().Keys())
+		require.Equal(t, []sqlschema.ForeignTable{{
+			Schema:  "public",
+			Name:    "remote_orders",
+			Server:  "billing",
+			Options: []string{"schema_name=public", "table_name=orders"},
+			Columns: []*sqlschema.BaseColumn{
+				{Name: "id", SQLType: "bigint"},
+				{Name: "note", SQLType: "text", IsNullable: true, Comment: "Free text"},
+			},
+			Comment: "Orders in billing",
+		}}, state.GetForeignTables())
+	})
+
+	t.Run("stops when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		fsys := fstest.MapFS{"schema.sql": {Data: []byte(testDump)}}
+		_, err := NewDumpInspector(fsys, "schema.sql").Inspect(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("skips excluded tables", func(t *testing.T) {
+		state, err := inspect(t, testDump, sqlschema.WithExcludeTables("authors"))
+		require.NoError(t, err)
+		require.Equal(t, []string{"books"}, state.GetTables().Keys())
+		require.Empty(t, state.GetForeignKeys())
+	})
+
+	t.Run("errors point at the statement", func(t *testing.T) {
+		for _, tt := range []struct {
+			name, dump, wantErr string
+		}{
+			{
+				name:    "unknown column",
+				dump:    "CREATE TABLE t (id bigint);\n\nALTER TABLE ONLY t ADD CONSTRAINT t_pkey PRIMARY KEY (uid);",
+				wantErr: `schema.sql:3: unknown column "uid" in table "t": ALTER TABLE ONLY t ADD CONSTRAINT t_pkey PRIMARY KEY (uid)`,
+			},
+			{
+				name:    "missing type",
+				dump:    "CREATE TABLE t (\n  id,\n  name text\n);",
+				wantErr: `schema.sql:1: column "id" in table "t" must have a type: CREATE TABLE t (`,
+			},
+			{
+				name:    "unknown foreign key target",
+				dump:    "CREATE TABLE t (owner_id bigint REFERENCES owners (id));",
+				wantErr: `schema.sql:1: foreign key t_owner_id_fkey references unknown table "owners": CREATE TABLE t (owner_id bigint REFERENCES owners (id))`,
+			},
+			{
+				name:    "unterminated string",
+				dump:    "CREATE TABLE t (id bigint);\nCOMMENT ON TABLE t IS 'oops;",
+				wantErr: `schema.sql:2: unterminated string`,
+			},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := inspect(t, tt.dump)
+				require.EqualError(t, err, tt.wantErr)
+			})
+		}
+	})
+}
@@ -2,6 +2,7 @@ package pgdialect
 
 import (
 	"context"
+	"slices"
 	"strings"
 
 	"github.com/uptrace/bun"
@@ -9,6 +10,9 @@ import (
 	"github.com/uptrace/bun/migrate/sqlschema"
 )
 
+// systemSchemas hold the catalog and are never inspected.
+var systemSchemas = []string{"pg_catalog", "information_schema", "pg_toast"}
+
 type (
 	Schema = sqlschema.BaseDatabase
 	Table  = sqlschema.BaseTable
@@ -19,16 +23,38 @@ func (d *Dialect) NewInspector(db *bun.DB, options ...sqlschema.InspectorOption)
 	return newInspector(db, options...)
 }
 
+// Inspector reads the schema state from the PostgreSQL catalog.
+//
+// The inspected schemas are always named explicitly: SchemaName, which defaults to "public",
+// and the ones added with sqlschema.WithSchemas. The connection's search_path is never consulted,
+// so the same database produces the same state on every connection. Names which Postgres
+// qualifies only when their schema is not in the search_path, like the sequences in nextval()
+// defaults and the types of array columns, are reported without the qualifier of their own schema.
+//
+// Tables in SchemaName are stored under their plain name and the tables in other schemas under
+// the qualified one, see sqlschema.InspectorConfig.TableKey. A table name that exists in several
+// inspected schemas is therefore not ambiguous: "orders" is the table in SchemaName and
+// "sales.orders" the one in "sales". Foreign keys and indexes refer to the tables by the same keys.
+//
+// Foreign tables, e.g. the ones imported with postgres_fdw, are never stored with the other tables.
+// They are listed separately if sqlschema.WithIncludeForeignTables is set.
+// Privileges on the tables are only inspected if sqlschema.WithIncludeGrants is set.
 type Inspector struct {
 	sqlschema.InspectorConfig
 	db *bun.DB
 }
 
-var _ sqlschema.Inspector = (*Inspector)(nil)
+var (
+	_ sqlschema.Inspector     = (*Inspector)(nil)
+	_ sqlschema.Fingerprinter = (*Inspector)(nil)
+)
 
 func newInspector(db *bun.DB, options ...sqlschema.InspectorOption) *Inspector {
 	i := &Inspector{db: db}
 	sqlschema.ApplyInspectorOptions(&i.InspectorConfig, options...)
+	if i.SchemaName == "" {
+		i.SchemaName = db.Dialect().DefaultSchema()
+	}
 	return i
 }
 
@@ -36,63 +62,308 @@ func (in *Inspector) Inspect(ctx context.Context) (sqlschema.Database, error) {
 	dbSchema := Schema{
 		Tables:      ordered.NewMap[string, sqlschema.Table](),
 		ForeignKeys: make(map[sqlschema.ForeignKey]string),
+		Enums:       make(map[string][]string),
 	}
 
+	filter, err := sqlschema.NewTableFilter(in.InspectorConfig, systemSchemas...)
+	if err != nil {
+		return dbSchema, err
+	}
+	if filter.SchemaExcluded(in.SchemaName) {
+		return dbSchema, nil
+	}
 	exclude := in.ExcludeTables
 	if len(exclude) == 0 {
 		// Avoid getting NOT IN (NULL) if bun.In() is called with an empty slice.
 		exclude = []string{""}
 	}
+	schemas := bun.In(in.InspectedSchemas())
 
-	var tables []*InformationSchemaTable
-	if err := in.db.NewRaw(sqlInspectTables, in.SchemaName, bun.In(exclude)).Scan(ctx, &tables); err != nil {
-		return dbSchema, err
+	var (
+		tables  []*InformationSchemaTable
+		columns []*InformationSchemaColumn
+		fks     []*ForeignKey
+		uniques []*UniqueConstraint
+		indexes []*Index
+		enums   []*EnumType
+		checks  []*CheckConstraint
+		excls   []*ExclusionConstraint
+		views   []*View
+		seqs    []*Sequence
+		trigs   []*Trigger
+		domains []*Domain
+		types   []*CompositeType
+		exts    []*Extension
+		foreign []*ForeignTable
+		pols    []*Policy
+		grants  []*Grant
+	)
+	queries := []func(context.Context) error{
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectTables, schemas, bun.In(exclude)).Scan(ctx, &tables)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectColumnsQuery, schemas, bun.In(exclude)).Scan(ctx, &columns)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectForeignKeys, schemas, bun.In(exclude), bun.In(exclude)).Scan(ctx, &fks)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectUniqueConstraints, schemas, bun.In(exclude)).Scan(ctx, &uniques)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectIndexes, schemas, bun.In(exclude)).Scan(ctx, &indexes)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectEnums, in.SchemaName).Scan(ctx, &enums)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectCheckConstraints, schemas, bun.In(exclude)).Scan(ctx, &checks)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectExclusionConstraints, schemas, bun.In(exclude)).Scan(ctx, &excls)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectViews, schemas, bun.In(exclude), schemas, bun.In(exclude)).Scan(ctx, &views)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectSequences, schemas).Scan(ctx, &seqs)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectTriggers, schemas, bun.In(exclude)).Scan(ctx, &trigs)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectDomains, schemas).Scan(ctx, &domains)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectCompositeTypes, schemas).Scan(ctx, &types)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectExtensions).Scan(ctx, &exts)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectPolicies, schemas, bun.In(exclude)).Scan(ctx, &pols)
+		},
 	}
-
-	var fks []*ForeignKey
-	if err := in.db.NewRaw(sqlInspectForeignKeys, in.SchemaName, bun.In(exclude), bun.In(exclude)).Scan(ctx, &fks); err != nil {
+	if in.IncludeForeignTables {
+		queries = append(queries, func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectForeignTables, schemas, bun.In(exclude)).Scan(ctx, &foreign)
+		})
+	}
+	if in.IncludeGrants {
+		queries = append(queries, func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectGrants, schemas, bun.In(exclude), schemas, bun.In(exclude)).Scan(ctx, &grants)
+		})
+	}
+	if err := in.RunQueries(ctx, in.db, queries...); err != nil {
 		return dbSchema, err
 	}
+
+	// Queries only exclude tables by their exact names, patterns and included tables are matched here.
+	tables = slices.DeleteFunc(tables, func(t *InformationSchemaTable) bool {
+		return filter.Excluded(t.Schema, t.Name)
+	})
+
 	dbSchema.ForeignKeys = make(map[sqlschema.ForeignKey]string, len(fks))
 
+	tableUniques := make(map[string][]sqlschema.Unique)
+	for _, u := range uniques {
+		key := in.TableKey(u.Schema, u.Table)
+		tableUniques[key] = append(tableUniques[key], sqlschema.Unique{
+			Name:    u.ConstraintName,
+			Columns: sqlschema.NewColumns(u.Columns...),
+		})
+	}
+
+	for _, idx := range indexes {
+		if filter.Excluded(idx.Schema, idx.Table) {
+			continue
+		}
+		columns := make([]sqlschema.IndexColumn, len(idx.Columns))
+		for i := range idx.Columns {
+			columns[i] = sqlschema.IndexColumn{Name: idx.Columns[i], Expression: idx.Expressions[i]}
+			if i < len(idx.OpClasses) {
+				columns[i].OpClass = idx.OpClasses[i]
+			}
+		}
+		dbSchema.Indexes = append(dbSchema.Indexes, sqlschema.Index{
+			Name:              idx.Name,
+			TableName:         in.TableKey(idx.Schema, idx.Table),
+			Columns:           columns,
+			Unique:            idx.IsUnique,
+			Where:             idx.Where,
+			Include:           idx.Include,
+			Method:            idx.Method,
+			Tablespace:        idx.Tablespace,
+			StorageParameters: idx.Options,
+		})
+	}
+
+	for _, e := range enums {
+		dbSchema.Enums[e.Name] = e.Values
+	}
+
+	for _, v := range views {
+		if filter.Excluded(v.Schema, v.Name) {
+			continue
+		}
+		dbSchema.Views = append(dbSchema.Views, sqlschema.View{
+			Schema:       v.Schema,
+			Name:         v.Name,
+			Definition:   strings.TrimSpace(v.Definition),
+			Materialized: v.Materialized,
+			Populated:    v.Populated,
+		})
+	}
+
+	for _, t := range trigs {
+		if filter.Excluded(t.Schema, t.Table) {
+			continue
+		}
+		dbSchema.Triggers = append(dbSchema.Triggers, sqlschema.Trigger{
+			Name:       t.Name,
+			TableName:  in.TableKey(t.Schema, t.Table),
+			Timing:     t.Timing,
+			Events:     t.Events,
+			ForEachRow: t.ForEachRow,
+			Body:       t.Body,
+		})
+	}
+
+	for _, p := range pols {
+		if filter.Excluded(p.Schema, p.Table) {
+			continue
+		}
+		dbSchema.Policies = append(dbSchema.Policies, sqlschema.Policy{
+			Name:        p.Name,
+			TableName:   in.TableKey(p.Schema, p.Table),
+			Restrictive: p.Restrictive,
+			Command:     p.Command,
+			Roles:       p.Roles,
+			Using:       parsePolicyExpr(p.Using),
+			WithCheck:   parsePolicyExpr(p.WithCheck),
+		})
+	}
+
+	for _, g := range grants {
+		if filter.Excluded(g.Schema, g.Table) {
+			continue
+		}
+		dbSchema.Grants = append(dbSchema.Grants, sqlschema.Grant{
+			TableName: in.TableKey(g.Schema, g.Table),
+			Column:    g.Column,
+			Grantee:   g.Grantee,
+			Privilege: g.Privilege,
+		})
+	}
+
+	for _, dom := range domains {
+		checks := make([]sqlschema.Check, len(dom.CheckNames))
+		for i := range dom.CheckNames {
+			checks[i] = sqlschema.Check{Name: dom.CheckNames[i], Expression: parseCheckDefinition(dom.CheckDefinitions[i])}
+		}
+		dbSchema.Domains = append(dbSchema.Domains, sqlschema.Domain{
+			Schema:   dom.Schema,
+			Name:     dom.Name,
+			DataType: dom.DataType,
+			NotNull:  dom.NotNull,
+			Default:  dom.Default,
+			Checks:   checks,
+		})
+	}
+
+	for _, ext := range exts {
+		dbSchema.Extensions = append(dbSchema.Extensions, sqlschema.Extension{
+			Name:    ext.Name,
+			Schema:  ext.Schema,
+			Version: ext.Version,
+		})
+	}
+
+	for _, ct := range types {
+		attrs := make([]sqlschema.CompositeAttribute, len(ct.AttributeNames))
+		for i := range ct.AttributeNames {
+			attrs[i] = sqlschema.CompositeAttribute{Name: ct.AttributeNames[i], DataType: ct.AttributeTypes[i]}
+		}
+		dbSchema.CompositeTypes = append(dbSchema.CompositeTypes, sqlschema.CompositeType{
+			Schema:     ct.Schema,
+			Name:       ct.Name,
+			Attributes: attrs,
+		})
+	}
+
+	// columnSequences maps the serial and identity columns to the sequences they own.
+	type columnKey struct{ table, column string }
+	columnSequences := make(map[columnKey]string)
+	for _, s := range seqs {
+		seq := sqlschema.Sequence{
+			Schema:    s.Schema,
+			Name:      s.Name,
+			Start:     s.Start,
+			Increment: s.Increment,
+			MinValue:  s.MinValue,
+			MaxValue:  s.MaxValue,
+		}
+		if s.OwnerTable != "" {
+			if filter.Excluded(s.OwnerSchema, s.OwnerTable) {
+				continue
+			}
+			key := in.TableKey(s.OwnerSchema, s.OwnerTable)
+			seq.OwnedBy = sqlschema.NewColumnReference(key, s.OwnerColumn)
+			columnSequences[columnKey{key, s.OwnerColumn}] = s.Name
+		}
+		dbSchema.Sequences = append(dbSchema.Sequences, seq)
+	}
+
+	tableChecks := make(map[string][]sqlschema.Check)
+	for _, c := range checks {
+		key := in.TableKey(c.Schema, c.Table)
+		tableChecks[key] = append(tableChecks[key], sqlschema.Check{
+			Name:       c.ConstraintName,
+			Expression: parseCheckDefinition(c.Definition),
+		})
+	}
+
+	tableExclusions := make(map[string][]sqlschema.ExclusionConstraint)
+	for _, c := range excls {
+		key := in.TableKey(c.Schema, c.Table)
+		excl := sqlschema.ExclusionConstraint{
+			Name:   c.ConstraintName,
+			Method: c.Method,
+			Where:  c.Predicate,
+		}
+		for i, expr := range c.Elements {
+			excl.Elements = append(excl.Elements, sqlschema.ExclusionElement{Expression: expr, Operator: c.Operators[i]})
+		}
+		tableExclusions[key] = append(tableExclusions[key], excl)
+	}
+
+	tableColumns := make(map[string][]*InformationSchemaColumn, len(tables))
+	for _, c := range columns {
+		key := in.TableKey(c.Schema, c.Table)
+		tableColumns[key] = append(tableColumns[key], c)
+	}
+
 	for _, table := range tables {
-		var columns []*InformationSchemaColumn
-		if err := in.db.NewRaw(sqlInspectColumnsQuery, table.Schema, table.Name).Scan(ctx, &columns); err != nil {
+		if err := ctx.Err(); err != nil {
 			return dbSchema, err
 		}
-
+		key := in.TableKey(table.Schema, table.Name)
 		colDefs := ordered.NewMap[string, sqlschema.Column]()
-		uniqueGroups := make(map[string][]string)
-
-		for _, c := range columns {
-			def := c.Default
-			if c.IsSerial || c.IsIdentity {
-				def = ""
-			} else if !c.IsDefaultLiteral {
-				def = strings.ToLower(def)
-			}
 
-			colDefs.Store(c.Name, &Column{
-				Name:            c.Name,
-				SQLType:         c.DataType,
-				VarcharLen:      c.VarcharLen,
-				DefaultValue:    def,
-				IsNullable:      c.IsNullable,
-				IsAutoIncrement: c.IsSerial,
-				IsIdentity:      c.IsIdentity,
-			})
-
-			for _, group := range c.UniqueGroups {
-				uniqueGroups[group] = append(uniqueGroups[group], c.Name)
-			}
+		var parents []string
+		for i := range table.InheritsTables {
+			parents = append(parents, in.TableKey(table.InheritsSchemas[i], table.InheritsTables[i]))
 		}
 
-		var unique []sqlschema.Unique
-		for name, columns := range uniqueGroups {
-			unique = append(unique, sqlschema.Unique{
-				Name:    name,
-				Columns: sqlschema.NewColumns(columns...),
-			})
+		for _, c := range tableColumns[key] {
+			// Inherited columns are managed through the parent table, like its checks.
+			if len(parents) > 0 && !c.IsLocal {
+				continue
+			}
+			col := c.column()
+			col.Sequence = columnSequences[columnKey{key, c.Name}]
+			colDefs.Store(c.Name, col)
 		}
 
 		var pk *sqlschema.PrimaryKey
@@ -103,47 +374,188 @@ func (in *Inspector) Inspect(ctx context.Context) (sqlschema.Database, error) {
 			}
 		}
 
-		dbSchema.Tables.Store(table.Name, &Table{
+		var partitioning *sqlschema.Partitioning
+		if table.PartitionStrategy != "" {
+			partitioning = &sqlschema.Partitioning{
+				Strategy: table.PartitionStrategy,
+				Key:      parsePartitionKey(table.PartitionStrategy, table.PartitionKey),
+			}
+		}
+		var parent string
+		if table.ParentTable != "" {
+			parent = in.TableKey(table.ParentSchema, table.ParentTable)
+		}
+
+		dbSchema.Tables.Store(key, &Table{
 			Schema:            table.Schema,
 			Name:              table.Name,
 			Columns:           colDefs,
 			PrimaryKey:        pk,
-			UniqueConstraints: unique,
+			UniqueConstraints: tableUniques[key],
+			Checks:            tableChecks[key],
+			Exclusions:        tableExclusions[key],
+			Partitioning:      partitioning,
+			PartitionOf:       parent,
+			Inherits:          parents,
+			RowSecurity: sqlschema.RowSecurity{
+				Enabled: table.RowSecurity,
+				Forced:  table.ForceRowSecurity,
+			},
+			Tablespace: table.Tablespace,
+			Comment:    table.Comment,
+		})
+	}
+
+	for _, ft := range foreign {
+		if filter.Excluded(ft.Schema, ft.Name) {
+			continue
+		}
+		var columns []*Column
+		for _, c := range tableColumns[in.TableKey(ft.Schema, ft.Name)] {
+			columns = append(columns, c.column())
+		}
+		dbSchema.ForeignTables = append(dbSchema.ForeignTables, sqlschema.ForeignTable{
+			Schema:  ft.Schema,
+			Name:    ft.Name,
+			Server:  ft.Server,
+			Options: ft.Options,
+			Columns: columns,
+			Comment: ft.Comment,
 		})
 	}
 
 	for _, fk := range fks {
+		if filter.Excluded(fk.SourceSchema, fk.SourceTable) || filter.Excluded(fk.TargetSchema, fk.TargetTable) {
+			continue
+		}
+		// Source and target columns are aggregated in the order of conkey and confkey respectively,
+		// so that i-th source column references the i-th target column. The target may be in another schema.
 		dbSchema.ForeignKeys[sqlschema.ForeignKey{
-			From: sqlschema.NewColumnReference(fk.SourceTable, fk.SourceColumns...),
-			To:   sqlschema.NewColumnReference(fk.TargetTable, fk.TargetColumns...),
-		}] = fk.ConstraintName
+			From:     sqlschema.NewColumnReference(in.TableKey(fk.SourceSchema, fk.SourceTable), fk.SourceColumns...),
+			To:       sqlschema.NewColumnReference(in.TableKey(fk.TargetSchema, fk.TargetTable), fk.TargetColumns...),
+			OnDelete: referentialAction(fk.DeleteAction),
+			OnUpdate: referentialAction(fk.UpdateAction),
+
+			Match:             matchType(fk.MatchType),
+			Deferrable:        fk.Deferrable,
+			InitiallyDeferred: fk.InitiallyDeferred,
+		}.Canonical()] = fk.ConstraintName
 	}
 	return dbSchema, nil
 }
 
+// Fingerprint hashes the transaction IDs of the catalog rows describing the inspected schemas.
+// Any DDL statement rewrites at least one of these rows, which changes their xmin.
+func (in *Inspector) Fingerprint(ctx context.Context) (string, error) {
+	var fingerprint string
+	if err := in.db.NewRaw(sqlSchemaFingerprint, bun.In(in.InspectedSchemas())).Scan(ctx, &fingerprint); err != nil {
+		return "", err
+	}
+	return fingerprint, nil
+}
+
 type InformationSchemaTable struct {
 	Schema     string     `bun:"table_schema,pk"`
 	Name       string     `bun:"table_name,pk"`
 	PrimaryKey PrimaryKey `bun:"embed:primary_key_"`
 
+	PartitionStrategy string   `bun:"partition_strategy"`
+	PartitionKey      string   `bun:"partition_key"`
+	ParentSchema      string   `bun:"parent_schema"`
+	ParentTable       string   `bun:"parent_table"`
+	InheritsSchemas   []string `bun:"inherits_schemas,array"`
+	InheritsTables    []string `bun:"inherits_tables,array"`
+	RowSecurity       bool     `bun:"row_security"`
+	ForceRowSecurity  bool     `bun:"force_row_security"`
+	Tablespace        string   `bun:"tablespace"`
+	Comment           string   `bun:"comment"`
+
 	Columns []*InformationSchemaColumn `bun:"rel:has-many,join:table_schema=table_schema,join:table_name=table_name"`
 }
 
 type InformationSchemaColumn struct {
-	Schema           string   `bun:"table_schema"`
-	Table            string   `bun:"table_name"`
-	Name             string   `bun:"column_name"`
-	DataType         string   `bun:"data_type"`
-	VarcharLen       int      `bun:"varchar_len"`
-	IsArray          bool     `bun:"is_array"`
-	ArrayDims        int      `bun:"array_dims"`
-	Default          string   `bun:"default"`
-	IsDefaultLiteral bool     `bun:"default_is_literal_expr"`
-	IsIdentity       bool     `bun:"is_identity"`
-	IndentityType    string   `bun:"identity_type"`
-	IsSerial         bool     `bun:"is_serial"`
-	IsNullable       bool     `bun:"is_nullable"`
-	UniqueGroups     []string `bun:"unique_groups,array"`
+	Schema            string `bun:"table_schema"`
+	Table             string `bun:"table_name"`
+	Name              string `bun:"column_name"`
+	DataType          string `bun:"data_type"`
+	VarcharLen        int    `bun:"varchar_len"`
+	NumericPrecision  int    `bun:"numeric_precision"`
+	NumericScale      int    `bun:"numeric_scale"`
+	IsArray           bool   `bun:"is_array"`
+	ArrayDims         int    `bun:"array_dims"`
+	Default           string `bun:"default"`
+	IsDefaultLiteral  bool   `bun:"default_is_literal_expr"`
+	IsIdentity        bool   `bun:"is_identity"`
+	IndentityType     string `bun:"identity_type"`
+	IdentityStart     int64  `bun:"identity_start"`
+	IdentityIncrement int64  `bun:"identity_increment"`
+	IsSerial          bool   `bun:"is_serial"`
+	IsNullable        bool   `bun:"is_nullable"`
+	Comment           string `bun:"comment"`
+	Collation         string `bun:"collation"`
+	GeneratedExpr     string `bun:"generated_expr"`
+	IsGeneratedStored bool   `bun:"is_generated_stored"`
+	DomainName        string `bun:"domain_name"`
+
+	// IsLocal is false for the columns a table inherits from its parents and does not define itself.
+	IsLocal bool `bun:"is_local"`
+
+	// Storage is only reported if it differs from the default storage of the column's type.
+	Storage string `bun:"storage"`
+}
+
+// identityOptions decodes pg_attribute.attidentity: 'a' for GENERATED ALWAYS and 'd' for GENERATED BY DEFAULT.
+func (c *InformationSchemaColumn) identityOptions() sqlschema.IdentityOptions {
+	if !c.IsIdentity {
+		return sqlschema.IdentityOptions{}
+	}
+	opts := sqlschema.IdentityOptions{
+		Generation: sqlschema.IdentityByDefault,
+		Start:      c.IdentityStart,
+		Increment:  c.IdentityIncrement,
+	}
+	if c.IndentityType == "a" {
+		opts.Generation = sqlschema.IdentityAlways
+	}
+	return opts
+}
+
+// column converts the inspected column into its definition in the schema state.
+func (c *InformationSchemaColumn) column() *Column {
+	def := c.Default
+	if c.IsSerial || c.IsIdentity {
+		def = ""
+	} else if !c.IsDefaultLiteral {
+		def = sqlschema.LowerExpr(unqualifyRegclass(def, c.Schema))
+	}
+	if c.ArrayDims > 0 {
+		c.DataType = unqualifyType(c.DataType)
+	}
+
+	// Columns of a domain type report the modifiers of the domain's base type, which belong to the domain.
+	if c.DomainName != "" {
+		c.DataType = c.DomainName
+		c.VarcharLen, c.NumericPrecision, c.NumericScale = 0, 0, 0
+	}
+
+	return &Column{
+		Name:             c.Name,
+		SQLType:          c.DataType,
+		VarcharLen:       c.VarcharLen,
+		ArrayDims:        c.ArrayDims,
+		NumericPrecision: c.NumericPrecision,
+		NumericScale:     c.NumericScale,
+		DefaultValue:     def,
+		IsNullable:       c.IsNullable,
+		IsAutoIncrement:  c.IsSerial,
+		IsIdentity:       c.IsIdentity,
+		IdentityOptions:  c.identityOptions(),
+		Comment:          c.Comment,
+		Collation:        c.Collation,
+		GeneratedExpr:    c.GeneratedExpr,
+		GeneratedStored:  c.IsGeneratedStored,
+		Storage:          c.Storage,
+	}
 }
 
 type ForeignKey struct {
@@ -154,6 +566,38 @@ type ForeignKey struct {
 	TargetSchema   string   `bun:"target_schema"`
 	TargetTable    string   `bun:"target_table"`
 	TargetColumns  []string `bun:"target_columns,array"`
+	DeleteAction   string   `bun:"delete_action"`
+	UpdateAction   string   `bun:"update_action"`
+
+	MatchType         string `bun:"match_type"`
+	Deferrable        bool   `bun:"is_deferrable"`
+	InitiallyDeferred bool   `bun:"is_initially_deferred"`
+}
+
+// matchType converts match type codes stored in pg_constraint.confmatchtype.
+func matchType(code string) sqlschema.MatchType {
+	switch code {
+	case "f":
+		return sqlschema.MatchFull
+	case "p":
+		return sqlschema.MatchPartial
+	}
+	return sqlschema.MatchSimple
+}
+
+// referentialAction converts action codes stored in pg_constraint.confdeltype and confupdtype.
+func referentialAction(code string) sqlschema.ReferentialAction {
+	switch code {
+	case "r":
+		return sqlschema.Restrict
+	case "c":
+		return sqlschema.Cascade
+	case "n":
+		return sqlschema.SetNull
+	case "d":
+		return sqlschema.SetDefault
+	}
+	return sqlschema.NoAction
 }
 
 type PrimaryKey struct {
@@ -161,79 +605,337 @@ type PrimaryKey struct {
 	Columns        []string `bun:"columns,array"`
 }
 
+type UniqueConstraint struct {
+	Schema         string   `bun:"table_schema"`
+	Table          string   `bun:"table_name"`
+	ConstraintName string   `bun:"constraint_name"`
+	Columns        []string `bun:"columns,array"`
+}
+
+type Index struct {
+	Schema      string   `bun:"table_schema"`
+	Table       string   `bun:"table_name"`
+	Name        string   `bun:"index_name"`
+	Columns     []string `bun:"columns,array"`
+	Expressions []string `bun:"expressions,array"`
+	OpClasses   []string `bun:"opclasses,array"`
+	Include     []string `bun:"include,array"`
+	IsUnique    bool     `bun:"is_unique"`
+	Where       string   `bun:"where"`
+	Method      string   `bun:"method"`
+	Tablespace  string   `bun:"tablespace"`
+	Options     []string `bun:"options,array"`
+}
+
+type EnumType struct {
+	Name   string   `bun:"type_name"`
+	Values []string `bun:"values,array"`
+}
+
+type View struct {
+	Schema       string `bun:"schemaname"`
+	Name         string `bun:"viewname"`
+	Definition   string `bun:"definition"`
+	Materialized bool   `bun:"materialized"`
+	Populated    bool   `bun:"ispopulated"`
+}
+
+type Sequence struct {
+	Schema      string `bun:"schemaname"`
+	Name        string `bun:"sequencename"`
+	Start       int64  `bun:"start_value"`
+	Increment   int64  `bun:"increment_by"`
+	MinValue    int64  `bun:"min_value"`
+	MaxValue    int64  `bun:"max_value"`
+	OwnerSchema string `bun:"owner_schema"`
+	OwnerTable  string `bun:"owner_table"`
+	OwnerColumn string `bun:"owner_column"`
+}
+
+type Domain struct {
+	Schema           string   `bun:"domain_schema"`
+	Name             string   `bun:"domain_name"`
+	DataType         string   `bun:"data_type"`
+	NotNull          bool     `bun:"not_null"`
+	Default          string   `bun:"default"`
+	CheckNames       []string `bun:"check_names,array"`
+	CheckDefinitions []string `bun:"check_definitions,array"`
+}
+
+type Extension struct {
+	Name    string `bun:"extname"`
+	Schema  string `bun:"schema_name"`
+	Version string `bun:"extversion"`
+}
+
+type CompositeType struct {
+	Schema         string   `bun:"type_schema"`
+	Name           string   `bun:"type_name"`
+	AttributeNames []string `bun:"attribute_names,array"`
+	AttributeTypes []string `bun:"attribute_types,array"`
+}
+
+type Trigger struct {
+	Schema     string   `bun:"table_schema"`
+	Table      string   `bun:"table_name"`
+	Name       string   `bun:"trigger_name"`
+	Timing     string   `bun:"timing"`
+	Events     []string `bun:"events,array"`
+	ForEachRow bool     `bun:"for_each_row"`
+	Body       string   `bun:"body"`
+}
+
+type ForeignTable struct {
+	Schema  string   `bun:"table_schema"`
+	Name    string   `bun:"table_name"`
+	Server  string   `bun:"server_name"`
+	Options []string `bun:"options,array"`
+	Comment string   `bun:"comment"`
+}
+
+type Policy struct {
+	Schema      string   `bun:"table_schema"`
+	Table       string   `bun:"table_name"`
+	Name        string   `bun:"policy_name"`
+	Restrictive bool     `bun:"restrictive"`
+	Command     string   `bun:"command"`
+	Roles       []string `bun:"roles,array"`
+	Using       string   `bun:"using_expr"`
+	WithCheck   string   `bun:"with_check_expr"`
+}
+
+type Grant struct {
+	Schema    string `bun:"table_schema"`
+	Table     string `bun:"table_name"`
+	Column    string `bun:"column_name"`
+	Grantee   string `bun:"grantee"`
+	Privilege string `bun:"privilege_type"`
+}
+
+type CheckConstraint struct {
+	Schema         string `bun:"table_schema"`
+	Table          string `bun:"table_name"`
+	ConstraintName string `bun:"constraint_name"`
+	Definition     string `bun:"definition"`
+}
+
+// ExclusionConstraint lists the elements of the constraint's index with the operators they are compared with.
+type ExclusionConstraint struct {
+	Schema         string   `bun:"table_schema"`
+	Table          string   `bun:"table_name"`
+	ConstraintName string   `bun:"constraint_name"`
+	Method         string   `bun:"method"`
+	Elements       []string `bun:"elements,array"`
+	Operators      []string `bun:"operators,array"`
+	Predicate      string   `bun:"predicate"`
+}
+
+// parseCheckDefinition extracts the check expression from the output of pg_get_constraintdef,
+// which has the form "CHECK (expr)", optionally followed by "NOT VALID".
+func parseCheckDefinition(def string) string {
+	def = strings.TrimSuffix(def, " NOT VALID")
+	def = strings.TrimPrefix(def, "CHECK ")
+	for strings.HasPrefix(def, "(") && strings.HasSuffix(def, ")") && isEnclosed(def) {
+		def = def[1 : len(def)-1]
+	}
+	return def
+}
+
+// parsePolicyExpr removes the parentheses which pg_policies adds around the USING and WITH CHECK expressions.
+func parsePolicyExpr(expr string) string {
+	for strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") && isEnclosed(expr) {
+		expr = expr[1 : len(expr)-1]
+	}
+	return expr
+}
+
+// parsePartitionKey extracts the key columns from the output of pg_get_partkeydef, e.g. "RANGE (created_at)".
+func parsePartitionKey(strategy, def string) string {
+	def = strings.TrimSpace(strings.TrimPrefix(def, strategy))
+	if strings.HasPrefix(def, "(") && strings.HasSuffix(def, ")") && isEnclosed(def) {
+		def = def[1 : len(def)-1]
+	}
+	return def
+}
+
+// isEnclosed checks that the opening parenthesis at the start of the expression
+// is matched by the closing one at the end, i.e. the expression is not "(a) AND (b)".
+func isEnclosed(expr string) bool {
+	var depth int
+	var inLiteral bool
+	for i, r := range expr {
+		switch {
+		case r == '\'':
+			inLiteral = !inLiteral
+		case inLiteral:
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth == 0 && i < len(expr)-1 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
 const (
-	// sqlInspectTables retrieves all user-defined tables in the selected schema.
+	// sqlInspectTables retrieves all user-defined tables in the selected schemas.
+	// Partitioned tables report their partitioning strategy and key, e.g. "RANGE (created_at)",
+	// and partitions report the table they are attached to. Tables created with INHERITS list their parents in order.
+	// The tablespace is empty for the tables stored in the database's default tablespace.
 	// Pass bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
 	sqlInspectTables = `
 SELECT
 	"t".table_schema,
 	"t".table_name,
 	pk.name AS primary_key_name,
-	pk.columns AS primary_key_columns
+	pk.columns AS primary_key_columns,
+	CASE pt.partstrat WHEN 'r' THEN 'RANGE' WHEN 'l' THEN 'LIST' WHEN 'h' THEN 'HASH' ELSE '' END AS partition_strategy,
+	COALESCE(pg_get_partkeydef(pt.partrelid), '') AS partition_key,
+	COALESCE(parent_ns.nspname, '') AS parent_schema,
+	COALESCE(parent.relname, '') AS parent_table,
+	ARRAY(
+		SELECT ns.nspname
+		FROM pg_inherits i
+			JOIN pg_class p ON p.oid = i.inhparent
+			JOIN pg_namespace ns ON ns.oid = p.relnamespace
+		WHERE i.inhrelid = c.oid AND NOT c.relispartition
+		ORDER BY i.inhseqno
+	) AS inherits_schemas,
+	ARRAY(
+		SELECT p.relname
+		FROM pg_inherits i
+			JOIN pg_class p ON p.oid = i.inhparent
+		WHERE i.inhrelid = c.oid AND NOT c.relispartition
+		ORDER BY i.inhseqno
+	) AS inherits_tables,
+	c.relrowsecurity AS row_security,
+	c.relforcerowsecurity AS force_row_security,
+	COALESCE(ts.spcname, '') AS "tablespace",
+	COALESCE(obj_description(c.oid, 'pg_class'), '') AS "comment"
 FROM information_schema.tables "t"
+	JOIN pg_class c ON c.oid = format('%I.%I', "t".table_schema, "t".table_name)::regclass
+	LEFT JOIN pg_partitioned_table pt ON pt.partrelid = c.oid
+	LEFT JOIN pg_tablespace ts ON ts.oid = c.reltablespace
+	LEFT JOIN pg_inherits inh ON inh.inhrelid = c.oid AND c.relispartition
+	LEFT JOIN pg_class parent ON parent.oid = inh.inhparent
+	LEFT JOIN pg_namespace parent_ns ON parent_ns.oid = parent.relnamespace
 	LEFT JOIN (
-		SELECT i.indrelid, "idx".relname AS "name", ARRAY_AGG("a".attname) AS "columns"
-		FROM pg_index i
-			JOIN pg_attribute "a"
-				ON "a".attrelid = i.indrelid
-				AND "a".attnum = ANY("i".indkey)
-				AND i.indisprimary
-			JOIN pg_class "idx" ON i.indexrelid = "idx".oid
+		SELECT co.conrelid, co.conname AS "name", ARRAY_AGG("a".attname ORDER BY k.pos) AS "columns"
+		FROM pg_constraint co
+			CROSS JOIN UNNEST(co.conkey) WITH ORDINALITY AS k(attnum, pos)
+			JOIN pg_attribute "a" ON "a".attrelid = co.conrelid AND "a".attnum = k.attnum
+		WHERE co.contype = 'p'
 		GROUP BY 1, 2
 	) pk
-	ON ("t".table_schema || '.' || "t".table_name)::regclass = pk.indrelid
+	ON pk.conrelid = c.oid
 WHERE table_type = 'BASE TABLE'
-	AND "t".table_schema = ?
+	AND "t".table_schema IN (?)
 	AND "t".table_schema NOT LIKE 'pg_%'
 	AND "table_name" NOT IN (?)
 ORDER BY "t".table_schema, "t".table_name
 `
 
-	// sqlInspectColumnsQuery retrieves column definitions for the specified table.
-	// Unlike sqlInspectTables and sqlInspectSchema, it should be passed to bun.NewRaw
-	// with additional args for table_schema and table_name.
+	// sqlInspectColumnsQuery retrieves column definitions for all tables in the selected schemas in their physical order,
+	// so that the columns of every table are fetched in a single round trip.
+	// Serial columns take their default from the sequence they own, which pg_get_serial_sequence also reports
+	// for identity columns, so the identity columns are excluded explicitly. A nextval() default of any other
+	// sequence is a regular default.
+	// Pass bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
 	sqlInspectColumnsQuery = `
 SELECT
 	"c".table_schema,
 	"c".table_name,
 	"c".column_name,
-	"c".data_type,
-	"c".character_maximum_length::integer AS varchar_len,
+	CASE
+		WHEN "c".data_type = 'ARRAY' THEN format_type("c".element_type, NULL)
+		WHEN "c".data_type = 'USER-DEFINED' THEN "c".udt_name
+		ELSE "c".data_type
+	END AS data_type,
+	CASE
+		WHEN "c".data_type = 'ARRAY' THEN information_schema._pg_char_max_length("c".element_type, "c".atttypmod)
+		WHEN "c".data_type LIKE 'time%' THEN "c".datetime_precision
+		ELSE "c".character_maximum_length
+	END::integer AS varchar_len,
+	CASE
+		WHEN "c".data_type = 'numeric' THEN COALESCE("c".numeric_precision, 0)
+		WHEN "c".element_type = 'numeric'::regtype THEN COALESCE(information_schema._pg_numeric_precision("c".element_type, "c".atttypmod), 0)
+		ELSE 0
+	END AS numeric_precision,
+	CASE
+		WHEN "c".data_type = 'numeric' THEN COALESCE("c".numeric_scale, 0)
+		WHEN "c".element_type = 'numeric'::regtype THEN COALESCE(information_schema._pg_numeric_scale("c".element_type, "c".atttypmod), 0)
+		ELSE 0
+	END AS numeric_scale,
 	"c".data_type = 'ARRAY' AS is_array,
-	COALESCE("c".array_dims, 0) AS array_dims,
+	CASE WHEN "c".data_type = 'ARRAY' THEN GREATEST("c".attndims, 1) ELSE 0 END AS array_dims,
 	CASE
 		WHEN "c".column_default ~ '^''.*''::.*$' THEN substring("c".column_default FROM '^''(.*)''::.*$')
 		ELSE "c".column_default
 	END AS "default",
 	"c".column_default ~ '^''.*''::.*$' OR "c".column_default ~ '^[0-9\.]+$' AS default_is_literal_expr,
 	"c".is_identity = 'YES' AS is_identity,
-	"c".column_default = format('nextval(''%s_%s_seq''::regclass)', "c".table_name, "c".column_name) AS is_serial,
+	"c".is_identity <> 'YES' AND COALESCE(
+		substring("c".column_default FROM '^nextval\(''(.*)''::regclass\)$')::regclass
+			= pg_get_serial_sequence(format('%I.%I', "c".table_schema, "c".table_name), "c".column_name)::regclass,
+		false
+	) AS is_serial,
 	COALESCE("c".identity_type, '') AS identity_type,
+	COALESCE("c".identity_start::bigint, 0) AS identity_start,
+	COALESCE("c".identity_increment::bigint, 0) AS identity_increment,
 	"c".is_nullable = 'YES' AS is_nullable,
-	"c"."unique_groups" AS unique_groups
+	COALESCE("c".collation_name, '') AS "collation",
+	COALESCE("c".generation_expression, '') AS generated_expr,
+	"c".is_generated = 'ALWAYS' AND "c".attgenerated = 's' AS is_generated_stored,
+	COALESCE(col_description(format('%I.%I', "c".table_schema, "c".table_name)::regclass, "c".ordinal_position), '') AS "comment",
+	COALESCE("c".domain_name, '') AS domain_name,
+	COALESCE("c".attislocal, true) AS is_local,
+	CASE WHEN "c".attstorage = "c".typstorage THEN ''
+		ELSE CASE "c".attstorage WHEN 'p' THEN 'PLAIN' WHEN 'e' THEN 'EXTERNAL' WHEN 'x' THEN 'EXTENDED' WHEN 'm' THEN 'MAIN' ELSE '' END
+	END AS "storage"
 FROM (
 	SELECT
 		"table_schema",
 		"table_name",
 		"column_name",
+		"c".ordinal_position,
 		"c".data_type,
+		"c".udt_name,
 		"c".character_maximum_length,
+		"c".datetime_precision,
+		"c".numeric_precision,
+		"c".numeric_scale,
 		"c".column_default,
+		"c".domain_name,
 		"c".is_identity,
+		"c".identity_start,
+		"c".identity_increment,
 		"c".is_nullable,
-		att.array_dims,
+		"c".collation_name,
+		"c".is_generated,
+		"c".generation_expression,
+		"a".attgenerated,
+		"a".attislocal,
+		"a".attstorage,
+		"typ".typstorage,
+		"a".attndims,
+		"a".atttypmod,
+		NULLIF("typ".typelem, 0) AS element_type,
 		att.identity_type,
-		att."unique_groups",
 		att."constraint_type"
 	FROM information_schema.columns "c"
+		LEFT JOIN pg_attribute "a"
+			ON "a".attrelid = format('%I.%I', "c".table_schema, "c".table_name)::regclass
+			AND "a".attnum = "c".ordinal_position
+		LEFT JOIN pg_type "typ" ON "typ".oid = "a".atttypid
 		LEFT JOIN (
 			SELECT
 				s.nspname AS "table_schema",
 				"t".relname AS "table_name",
 				"c".attname AS "column_name",
-				"c".attndims AS array_dims,
 				"c".attidentity AS identity_type,
-				ARRAY_AGG(con.conname) FILTER (WHERE con.contype = 'u') AS "unique_groups",
 				ARRAY_AGG(con.contype) AS "constraint_type"
 			FROM (
 				SELECT
@@ -248,11 +950,435 @@ FROM (
 				LEFT JOIN pg_attribute "c" USING (attrelid, attnum)
 				LEFT JOIN pg_namespace s ON s.oid = con.connamespace
 				LEFT JOIN pg_class "t" ON "t".oid = con.conrelid
-			GROUP BY 1, 2, 3, 4, 5
+			GROUP BY 1, 2, 3, 4
 		) att USING ("table_schema", "table_name", "column_name")
 	) "c"
-WHERE "table_schema" = ? AND "table_name" = ?
-ORDER BY "table_schema", "table_name", "column_name"
+WHERE "table_schema" IN (?) AND "table_name" NOT IN (?)
+ORDER BY "table_schema", "table_name", "ordinal_position"
+`
+
+	// sqlInspectUniqueConstraints retrieves UNIQUE constraints defined on user tables, listing their columns in key order.
+	// Unique indexes created with CREATE UNIQUE INDEX are not constraints and are not included.
+	// Pass bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
+	sqlInspectUniqueConstraints = `
+SELECT
+	s.nspname AS table_schema,
+	"t".relname AS table_name,
+	co.conname AS constraint_name,
+	ARRAY(
+		SELECT "a".attname
+		FROM UNNEST(co.conkey) WITH ORDINALITY AS k(attnum, pos)
+			JOIN pg_attribute "a" ON "a".attrelid = co.conrelid AND "a".attnum = k.attnum
+		ORDER BY k.pos
+	) AS "columns"
+FROM pg_constraint co
+	JOIN pg_class "t" ON "t".oid = co.conrelid
+	JOIN pg_namespace s ON s.oid = "t".relnamespace
+WHERE co.contype = 'u'
+	AND s.nspname IN (?)
+	AND "t".relname NOT IN (?)
+ORDER BY table_schema, table_name, constraint_name
+`
+
+	// sqlInspectIndexes retrieves secondary indexes defined on user tables, listing key columns in their order.
+	// For each key part, either its column name or its expression is set, and the other one is an empty string.
+	// Operator classes are read from indclass, which only covers key parts, and are empty for the default ones.
+	// Indexes that back PRIMARY KEY, UNIQUE, and EXCLUDE constraints are excluded, because they are created and dropped
+	// together with the constraint. Note, that FOREIGN KEY constraints also reference the unique index on the target table
+	// via conindid, but they do not own it.
+	// Pass bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
+	sqlInspectIndexes = `
+SELECT
+	s.nspname AS table_schema,
+	"t".relname AS table_name,
+	"idx".relname AS index_name,
+	i.indisunique AS is_unique,
+	COALESCE(pg_get_expr(i.indpred, i.indrelid), '') AS "where",
+	am.amname AS "method",
+	COALESCE(ts.spcname, '') AS "tablespace",
+	COALESCE("idx".reloptions, '{}') AS "options",
+	ARRAY(
+		SELECT COALESCE("a".attname, '')
+		FROM UNNEST(i.indkey::int2[]) WITH ORDINALITY AS k(attnum, pos)
+			LEFT JOIN pg_attribute "a" ON "a".attrelid = i.indrelid AND "a".attnum = k.attnum
+		WHERE k.pos <= i.indnkeyatts
+		ORDER BY k.pos
+	) AS "columns",
+	ARRAY(
+		SELECT CASE WHEN k.attnum = 0 THEN pg_get_indexdef(i.indexrelid, k.pos::integer, true) ELSE '' END
+		FROM UNNEST(i.indkey::int2[]) WITH ORDINALITY AS k(attnum, pos)
+		WHERE k.pos <= i.indnkeyatts
+		ORDER BY k.pos
+	) AS expressions,
+	ARRAY(
+		SELECT CASE WHEN opc.opcdefault THEN '' ELSE opc.opcname END
+		FROM UNNEST(i.indclass::oid[]) WITH ORDINALITY AS c(oid, pos)
+			JOIN pg_opclass opc ON opc.oid = c.oid
+		ORDER BY c.pos
+	) AS opclasses,
+	ARRAY(
+		SELECT "a".attname
+		FROM UNNEST(i.indkey::int2[]) WITH ORDINALITY AS k(attnum, pos)
+			JOIN pg_attribute "a" ON "a".attrelid = i.indrelid AND "a".attnum = k.attnum
+		WHERE k.pos > i.indnkeyatts
+		ORDER BY k.pos
+	) AS "include"
+FROM pg_index i
+	JOIN pg_class "idx" ON "idx".oid = i.indexrelid
+	JOIN pg_am am ON am.oid = "idx".relam
+	LEFT JOIN pg_tablespace ts ON ts.oid = "idx".reltablespace
+	JOIN pg_class "t" ON "t".oid = i.indrelid
+	JOIN pg_namespace s ON s.oid = "t".relnamespace
+WHERE NOT i.indisprimary
+	AND NOT EXISTS (
+		SELECT 1 FROM pg_constraint co
+		WHERE co.conindid = i.indexrelid
+			AND co.conrelid = i.indrelid
+			AND co.contype IN ('p', 'u', 'x')
+	)
+	AND "t".relkind IN ('r', 'm')
+	AND s.nspname IN (?)
+	AND "t".relname NOT IN (?)
+ORDER BY table_schema, table_name, index_name
+`
+
+	// sqlInspectEnums retrieves user-defined enumerated types in the selected schema together with their values in the sort order.
+	sqlInspectEnums = `
+SELECT
+	"t".typname AS type_name,
+	ARRAY_AGG(e.enumlabel ORDER BY e.enumsortorder) AS "values"
+FROM pg_type "t"
+	JOIN pg_enum e ON e.enumtypid = "t".oid
+	JOIN pg_namespace s ON s.oid = "t".typnamespace
+WHERE s.nspname = ?
+GROUP BY "t".typname
+ORDER BY "t".typname
+`
+
+	// sqlInspectViews retrieves view and materialized view definitions in the selected schemas, as reconstructed by pg_get_viewdef.
+	// Pass schemas and bun.In([]string{...}) for both parts of the query to exclude views from this inspection
+	// or bun.In([]string{''}) to include all results.
+	sqlInspectViews = `
+SELECT schemaname, viewname, definition, FALSE AS materialized, FALSE AS ispopulated
+FROM pg_views
+WHERE schemaname IN (?)
+	AND viewname NOT IN (?)
+UNION ALL
+SELECT schemaname, matviewname, definition, TRUE, ispopulated
+FROM pg_matviews
+WHERE schemaname IN (?)
+	AND matviewname NOT IN (?)
+ORDER BY schemaname, viewname
+`
+
+	// sqlInspectSequences retrieves sequences in the selected schemas together with the columns that own them.
+	// Serial columns own their sequence via an automatic dependency ('a') and identity columns via an internal one ('i').
+	sqlInspectSequences = `
+SELECT
+	s.schemaname,
+	s.sequencename,
+	s.start_value,
+	s.increment_by,
+	s.min_value,
+	s.max_value,
+	COALESCE(ts.nspname, '') AS owner_schema,
+	COALESCE("t".relname, '') AS owner_table,
+	COALESCE("a".attname, '') AS owner_column
+FROM pg_sequences s
+	JOIN pg_namespace n ON n.nspname = s.schemaname
+	JOIN pg_class c ON c.relnamespace = n.oid AND c.relname = s.sequencename
+	LEFT JOIN pg_depend d
+		ON d.objid = c.oid
+		AND d.classid = 'pg_class'::regclass
+		AND d.refclassid = 'pg_class'::regclass
+		AND d.deptype IN ('a', 'i')
+	LEFT JOIN pg_class "t" ON "t".oid = d.refobjid
+	LEFT JOIN pg_namespace ts ON ts.oid = "t".relnamespace
+	LEFT JOIN pg_attribute "a" ON "a".attrelid = d.refobjid AND "a".attnum = d.refobjsubid
+WHERE s.schemaname IN (?)
+ORDER BY s.schemaname, s.sequencename
+`
+
+	// sqlInspectTriggers retrieves user-defined triggers on the tables in the selected schemas.
+	// Timing, events, and row level are decoded from the tgtype bitmask, and the body is the EXECUTE clause
+	// of the trigger's definition. Internal triggers, which enforce foreign key constraints, are skipped,
+	// and so are the triggers on foreign tables, so that migrations never drop them.
+	// Pass bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
+	sqlInspectTriggers = `
+SELECT
+	s.nspname AS table_schema,
+	"t".relname AS table_name,
+	tg.tgname AS trigger_name,
+	CASE
+		WHEN tg.tgtype & 2 = 2 THEN 'BEFORE'
+		WHEN tg.tgtype & 64 = 64 THEN 'INSTEAD OF'
+		ELSE 'AFTER'
+	END AS timing,
+	ARRAY_REMOVE(ARRAY[
+		CASE WHEN tg.tgtype & 4 = 4 THEN 'INSERT' END,
+		CASE WHEN tg.tgtype & 16 = 16 THEN 'UPDATE' END,
+		CASE WHEN tg.tgtype & 8 = 8 THEN 'DELETE' END,
+		CASE WHEN tg.tgtype & 32 = 32 THEN 'TRUNCATE' END
+	], NULL) AS events,
+	tg.tgtype & 1 = 1 AS for_each_row,
+	COALESCE(substring(pg_get_triggerdef(tg.oid) FROM 'EXECUTE (\?:FUNCTION|PROCEDURE) .*$'), '') AS body
+FROM pg_trigger tg
+	JOIN pg_class "t" ON "t".oid = tg.tgrelid
+	JOIN pg_namespace s ON s.oid = "t".relnamespace
+WHERE NOT tg.tgisinternal
+	AND "t".relkind <> 'f'
+	AND s.nspname IN (?)
+	AND "t".relname NOT IN (?)
+ORDER BY table_schema, table_name, trigger_name
+`
+
+	// sqlInspectPolicies retrieves the row-level security policies on the tables in the selected schemas.
+	// The roles of the policies that apply to everyone are reported as {public}.
+	// Pass bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
+	sqlInspectPolicies = `
+SELECT
+	p.schemaname AS table_schema,
+	p.tablename AS table_name,
+	p.policyname AS policy_name,
+	p.permissive = 'RESTRICTIVE' AS restrictive,
+	p.cmd AS command,
+	p.roles::text[] AS roles,
+	COALESCE(p.qual, '') AS using_expr,
+	COALESCE(p.with_check, '') AS with_check_expr
+FROM pg_policies p
+WHERE p.schemaname IN (?)
+	AND p.tablename NOT IN (?)
+ORDER BY table_schema, table_name, policy_name
+`
+
+	// sqlInspectGrants retrieves the privileges on the tables in the selected schemas, followed by the privileges
+	// on their columns. Column privileges implied by the same privilege on the table are not listed,
+	// and neither are the grants to the table owner and to the predefined pg_* roles.
+	// Pass bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
+	sqlInspectGrants = `
+SELECT g.table_schema, g.table_name, '' AS column_name, g.grantee, g.privilege_type
+FROM information_schema.role_table_grants g
+	JOIN pg_class c ON c.oid = format('%I.%I', g.table_schema, g.table_name)::regclass
+WHERE c.relkind IN ('r', 'p')
+	AND g.grantee <> pg_get_userbyid(c.relowner)
+	AND g.grantee NOT LIKE 'pg\_%'
+	AND g.table_schema IN (?)
+	AND g.table_name NOT IN (?)
+UNION ALL
+SELECT g.table_schema, g.table_name, g.column_name, g.grantee, g.privilege_type
+FROM information_schema.column_privileges g
+	JOIN pg_class c ON c.oid = format('%I.%I', g.table_schema, g.table_name)::regclass
+WHERE c.relkind IN ('r', 'p')
+	AND g.grantee <> pg_get_userbyid(c.relowner)
+	AND g.grantee NOT LIKE 'pg\_%'
+	AND g.table_schema IN (?)
+	AND g.table_name NOT IN (?)
+	AND NOT EXISTS (
+		SELECT 1
+		FROM information_schema.role_table_grants tg
+		WHERE tg.table_schema = g.table_schema
+			AND tg.table_name = g.table_name
+			AND tg.grantee = g.grantee
+			AND tg.privilege_type = g.privilege_type
+	)
+ORDER BY table_schema, table_name, column_name, grantee, privilege_type
+`
+
+	// sqlInspectForeignTables retrieves the foreign tables in the selected schemas with their servers and options.
+	// Their columns are listed by sqlInspectColumnsQuery together with the columns of the other tables.
+	// Pass bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
+	sqlInspectForeignTables = `
+SELECT
+	s.nspname AS table_schema,
+	"t".relname AS table_name,
+	srv.srvname AS server_name,
+	COALESCE(ft.ftoptions, '{}') AS "options",
+	COALESCE(obj_description("t".oid, 'pg_class'), '') AS "comment"
+FROM pg_foreign_table ft
+	JOIN pg_class "t" ON "t".oid = ft.ftrelid
+	JOIN pg_namespace s ON s.oid = "t".relnamespace
+	JOIN pg_foreign_server srv ON srv.oid = ft.ftserver
+WHERE s.nspname IN (?)
+	AND "t".relname NOT IN (?)
+ORDER BY table_schema, table_name
+`
+
+	// sqlInspectDomains retrieves domain types in the selected schemas with their CHECK constraints.
+	// The base type is formatted with its modifiers, e.g. character varying(255).
+	sqlInspectDomains = `
+SELECT
+	n.nspname AS domain_schema,
+	t.typname AS domain_name,
+	format_type(t.typbasetype, t.typtypmod) AS data_type,
+	t.typnotnull AS not_null,
+	COALESCE(t.typdefault, '') AS "default",
+	ARRAY(
+		SELECT co.conname FROM pg_constraint co
+		WHERE co.contypid = t.oid AND co.contype = 'c'
+		ORDER BY co.conname
+	) AS check_names,
+	ARRAY(
+		SELECT pg_get_constraintdef(co.oid) FROM pg_constraint co
+		WHERE co.contypid = t.oid AND co.contype = 'c'
+		ORDER BY co.conname
+	) AS check_definitions
+FROM pg_type t
+	JOIN pg_namespace n ON n.oid = t.typnamespace
+WHERE t.typtype = 'd'
+	AND n.nspname IN (?)
+ORDER BY domain_schema, domain_name
+`
+
+	// sqlInspectExtensions retrieves all extensions installed in the database, regardless of their schema.
+	sqlInspectExtensions = `
+SELECT e.extname, n.nspname AS schema_name, e.extversion
+FROM pg_extension e
+	JOIN pg_namespace n ON n.oid = e.extnamespace
+ORDER BY e.extname
+`
+
+	// sqlInspectCompositeTypes retrieves standalone composite types in the selected schemas with their attributes.
+	// Every table has a composite row type too, so only the types backed by a 'c' relation are selected.
+	sqlInspectCompositeTypes = `
+SELECT
+	n.nspname AS type_schema,
+	t.typname AS type_name,
+	ARRAY(
+		SELECT "a".attname FROM pg_attribute "a"
+		WHERE "a".attrelid = t.typrelid AND "a".attnum > 0 AND NOT "a".attisdropped
+		ORDER BY "a".attnum
+	) AS attribute_names,
+	ARRAY(
+		SELECT format_type("a".atttypid, "a".atttypmod) FROM pg_attribute "a"
+		WHERE "a".attrelid = t.typrelid AND "a".attnum > 0 AND NOT "a".attisdropped
+		ORDER BY "a".attnum
+	) AS attribute_types
+FROM pg_type t
+	JOIN pg_namespace n ON n.oid = t.typnamespace
+	JOIN pg_class c ON c.oid = t.typrelid
+WHERE t.typtype = 'c'
+	AND c.relkind = 'c'
+	AND n.nspname IN (?)
+ORDER BY type_schema, type_name
+`
+
+	// sqlInspectCheckConstraints retrieves CHECK constraints defined on user tables. Constraints inherited
+	// from the parent tables are skipped, unless the table is a partition, see sqlInspectTables.
+	// Pass bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
+	sqlInspectCheckConstraints = `
+SELECT
+	s.nspname AS table_schema,
+	"t".relname AS table_name,
+	co.conname AS constraint_name,
+	pg_get_constraintdef(co.oid) AS "definition"
+FROM pg_constraint co
+	JOIN pg_class "t" ON "t".oid = co.conrelid
+	JOIN pg_namespace s ON s.oid = "t".relnamespace
+WHERE co.contype = 'c'
+	AND (co.conislocal OR "t".relispartition)
+	AND s.nspname IN (?)
+	AND "t".relname NOT IN (?)
+ORDER BY table_schema, table_name, constraint_name
+`
+
+	// sqlInspectExclusionConstraints retrieves EXCLUDE constraints defined on user tables.
+	// Elements are read from the constraint's index, in the order of the operators in conexclop.
+	// Pass bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
+	sqlInspectExclusionConstraints = `
+SELECT
+	s.nspname AS table_schema,
+	"t".relname AS table_name,
+	co.conname AS constraint_name,
+	am.amname AS "method",
+	ARRAY(
+		SELECT pg_get_indexdef(co.conindid, k.n::int, true)
+		FROM generate_subscripts(co.conexclop, 1) k(n)
+		ORDER BY k.n
+	) AS elements,
+	ARRAY(
+		SELECT op.oprname
+		FROM unnest(co.conexclop) WITH ORDINALITY x(oid, n)
+			JOIN pg_operator op ON op.oid = x.oid
+		ORDER BY x.n
+	) AS operators,
+	COALESCE(pg_get_expr(i.indpred, i.indrelid, true), '') AS predicate
+FROM pg_constraint co
+	JOIN pg_class "t" ON "t".oid = co.conrelid
+	JOIN pg_namespace s ON s.oid = "t".relnamespace
+	JOIN pg_index i ON i.indexrelid = co.conindid
+	JOIN pg_class ic ON ic.oid = co.conindid
+	JOIN pg_am am ON am.oid = ic.relam
+WHERE co.contype = 'x'
+	AND s.nspname IN (?)
+	AND "t".relname NOT IN (?)
+ORDER BY table_schema, table_name, constraint_name
+`
+
+	// sqlSchemaFingerprint computes the hash of catalog row versions for relations (tables, indexes, sequences),
+	// columns, defaults, constraints, column comments, types, view queries, sequence parameters, sequence owners,
+	// and triggers in the selected schemas, as well as the installed extensions.
+	// Rows are identified by their OIDs, so that dropping an object changes the hash too.
+	sqlSchemaFingerprint = `
+WITH ns AS (
+	SELECT oid FROM pg_namespace WHERE nspname IN (?)
+)
+SELECT md5(COALESCE(string_agg(fp, ',' ORDER BY fp), '')) AS fingerprint
+FROM (
+	SELECT 'c' || c.oid || ':' || c.xmin AS fp
+	FROM pg_class c
+	WHERE c.relnamespace IN (SELECT oid FROM ns)
+	UNION ALL
+	SELECT 'a' || a.attrelid || '.' || a.attnum || ':' || a.xmin
+	FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+	WHERE c.relnamespace IN (SELECT oid FROM ns)
+	UNION ALL
+	SELECT 'd' || d.oid || ':' || d.xmin
+	FROM pg_attrdef d
+		JOIN pg_class c ON c.oid = d.adrelid
+	WHERE c.relnamespace IN (SELECT oid FROM ns)
+	UNION ALL
+	SELECT 'k' || co.oid || ':' || co.xmin
+	FROM pg_constraint co
+	WHERE co.connamespace IN (SELECT oid FROM ns)
+	UNION ALL
+	SELECT 'm' || d.objoid || '.' || d.objsubid || ':' || d.xmin
+	FROM pg_description d
+		JOIN pg_class c ON c.oid = d.objoid AND d.classoid = 'pg_class'::regclass
+	WHERE c.relnamespace IN (SELECT oid FROM ns)
+	UNION ALL
+	SELECT 't' || t.oid || ':' || t.xmin
+	FROM pg_type t
+	WHERE t.typnamespace IN (SELECT oid FROM ns)
+	UNION ALL
+	SELECT 'e' || e.oid || ':' || e.xmin
+	FROM pg_enum e
+		JOIN pg_type t ON t.oid = e.enumtypid
+	WHERE t.typnamespace IN (SELECT oid FROM ns)
+	UNION ALL
+	SELECT 'r' || r.oid || ':' || r.xmin
+	FROM pg_rewrite r
+		JOIN pg_class c ON c.oid = r.ev_class
+	WHERE c.relnamespace IN (SELECT oid FROM ns)
+	UNION ALL
+	SELECT 'q' || sq.seqrelid || ':' || sq.xmin
+	FROM pg_sequence sq
+		JOIN pg_class c ON c.oid = sq.seqrelid
+	WHERE c.relnamespace IN (SELECT oid FROM ns)
+	UNION ALL
+	SELECT 'o' || d.objid || '.' || d.refobjid || '.' || d.refobjsubid || ':' || d.xmin
+	FROM pg_depend d
+		JOIN pg_class c ON c.oid = d.objid AND d.classid = 'pg_class'::regclass
+	WHERE c.relkind = 'S' AND c.relnamespace IN (SELECT oid FROM ns)
+	UNION ALL
+	SELECT 'g' || tg.oid || ':' || tg.xmin
+	FROM pg_trigger tg
+		JOIN pg_class c ON c.oid = tg.tgrelid
+	WHERE c.relnamespace IN (SELECT oid FROM ns)
+	UNION ALL
+	SELECT 'x' || x.oid || ':' || x.xmin
+	FROM pg_extension x
+) fingerprints
 `
 
 	// sqlInspectForeignKeys get FK definitions for user-defined tables.
@@ -276,10 +1402,15 @@ SELECT DISTINCT
 	co.conname AS "constraint_name",
 	ss.nspname AS schema_name,
 	s.relname AS "table_name",
-	ARRAY_AGG(sc.attname) AS "columns",
+	ARRAY_AGG(sc.attname ORDER BY ARRAY_POSITION(co.conkey, sc.attnum)) AS "columns",
 	ts.nspname AS target_schema,
 	"t".relname AS target_table,
-	ARRAY_AGG(tc.attname) AS target_columns
+	ARRAY_AGG(tc.attname ORDER BY ARRAY_POSITION(co.confkey, tc.attnum)) AS target_columns,
+	co.confdeltype AS delete_action,
+	co.confupdtype AS update_action,
+	co.confmatchtype AS match_type,
+	co.condeferrable AS is_deferrable,
+	co.condeferred AS is_initially_deferred
 FROM pg_constraint co
 	LEFT JOIN "tables" s ON s.oid = co.conrelid
 	LEFT JOIN "schemas" ss ON ss.oid = s.relnamespace
@@ -290,8 +1421,31 @@ FROM pg_constraint co
 WHERE co.contype = 'f'
 	AND co.conrelid IN (SELECT oid FROM pg_class WHERE relkind = 'r')
 	AND ARRAY_POSITION(co.conkey, sc.attnum) = ARRAY_POSITION(co.confkey, tc.attnum)
-	AND ss.nspname = ?
+	AND ss.nspname IN (?)
 	AND s.relname NOT IN (?) AND "t".relname NOT IN (?)
-GROUP BY "constraint_name", "schema_name", "table_name", target_schema, target_table
+GROUP BY "constraint_name", "schema_name", "table_name", target_schema, target_table,
+	delete_action, update_action, match_type, is_deferrable, is_initially_deferred
 `
 )
+
+// unqualifyRegclass removes the schema from the relations referenced in the expression, e.g. nextval('sales.seq'::regclass),
+// if it is the schema of the table. Postgres only qualifies them when the schema is not in the search_path.
+func unqualifyRegclass(expr, schemaName string) string {
+	if !strings.Contains(expr, "::regclass") {
+		return expr
+	}
+	for _, prefix := range []string{schemaName + ".", `"` + strings.ReplaceAll(schemaName, `"`, `""`) + `".`} {
+		expr = strings.ReplaceAll(expr, "'"+prefix, "'")
+	}
+	return expr
+}
+
+// unqualifyType removes the schema from the element type of an array column, e.g. sales.mood,
+// which format_type only qualifies when the schema is not in the search_path.
+// User-defined types of other columns are reported without the schema by information_schema.
+func unqualifyType(typ string) string {
+	if i := strings.LastIndex(typ, "."); i != -1 && !strings.Contains(typ[:i], "(") {
+		return strings.Trim(typ[i+1:], `"`)
+	}
+	return typ
+}
@@ -0,0 +1,119 @@
+package pgdialect
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+func TestInspector_SearchPath(t *testing.T) {
+	t.Run("regclass defaults", func(t *testing.T) {
+		for _, tt := range []struct {
+			expr, schemaName, want string
+		}{
+			{"nextval('seq'::regclass)", "sales", "nextval('seq'::regclass)"},
+			{"nextval('sales.seq'::regclass)", "sales", "nextval('seq'::regclass)"},
+			{`nextval('"Sales".seq'::regclass)`, "Sales", "nextval('seq'::regclass)"},
+			{"nextval('audit.seq'::regclass)", "sales", "nextval('audit.seq'::regclass)"},
+			{"'sales.x'::text", "sales", "'sales.x'::text"},
+		} {
+			require.Equal(t, tt.want, unqualifyRegclass(tt.expr, tt.schemaName), tt.expr)
+		}
+	})
+
+	t.Run("array element types", func(t *testing.T) {
+		for _, tt := range []struct {
+			typ, want string
+		}{
+			{"text", "text"},
+			{"sales.mood", "mood"},
+			{`"Sales"."Mood"`, "Mood"},
+			{"numeric(10,2)", "numeric(10,2)"},
+		} {
+			require.Equal(t, tt.want, unqualifyType(tt.typ), tt.typ)
+		}
+	})
+
+	t.Run("schema defaults to public", func(t *testing.T) {
+		require.Equal(t, "public", newInspector(bun.NewDB(nil, New())).SchemaName)
+	})
+}
+
+func TestInspector_QueryPlaceholders(t *testing.T) {
+	const schemaName, excluded = "zz_schema", "zz_excluded"
+	schemas, exclude := bun.In([]string{schemaName}), bun.In([]string{excluded})
+
+	for _, tt := range []struct {
+		name  string
+		query string
+		args  []interface{}
+	}{
+		{"tables", sqlInspectTables, []interface{}{schemas, exclude}},
+		{"columns", sqlInspectColumnsQuery, []interface{}{schemas, exclude}},
+		{"foreign keys", sqlInspectForeignKeys, []interface{}{schemas, exclude, exclude}},
+		{"unique constraints", sqlInspectUniqueConstraints, []interface{}{schemas, exclude}},
+		{"indexes", sqlInspectIndexes, []interface{}{schemas, exclude}},
+		{"enums", sqlInspectEnums, []interface{}{schemaName}},
+		{"check constraints", sqlInspectCheckConstraints, []interface{}{schemas, exclude}},
+		{"exclusion constraints", sqlInspectExclusionConstraints, []interface{}{schemas, exclude}},
+		{"views", sqlInspectViews, []interface{}{schemas, exclude, schemas, exclude}},
+		{"sequences", sqlInspectSequences, []interface{}{schemas}},
+		{"triggers", sqlInspectTriggers, []interface{}{schemas, exclude}},
+		{"domains", sqlInspectDomains, []interface{}{schemas}},
+		{"composite types", sqlInspectCompositeTypes, []interface{}{schemas}},
+		{"extensions", sqlInspectExtensions, nil},
+		{"policies", sqlInspectPolicies, []interface{}{schemas, exclude}},
+		{"foreign tables", sqlInspectForeignTables, []interface{}{schemas, exclude}},
+		{"grants", sqlInspectGrants, []interface{}{schemas, exclude, schemas, exclude}},
+		{"fingerprint", sqlSchemaFingerprint, []interface{}{schemas}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			query := schema.NewFormatter(New()).FormatQuery(tt.query, tt.args...)
+			code, literals := splitLiterals(query)
+			require.NotContains(t, code, "?", "unformatted placeholder in %s", query)
+
+			// Every literal of the query must survive formatting unchanged,
+			// i.e. no argument was substituted inside of one.
+			_, want := splitLiterals(strings.ReplaceAll(tt.query, `\?`, "?"))
+			got := slices.DeleteFunc(literals, func(lit string) bool {
+				return lit == schemaName || lit == excluded
+			})
+			require.Equal(t, want, got)
+		})
+	}
+}
+
+// splitLiterals separates the single-quoted string literals of the query from the rest of it.
+func splitLiterals(query string) (code string, literals []string) {
+	var b strings.Builder
+	literals = []string{}
+	for {
+		start := strings.IndexByte(query, '\'')
+		if start == -1 {
+			b.WriteString(query)
+			return b.String(), literals
+		}
+		b.WriteString(query[:start])
+
+		var lit strings.Builder
+		i := start + 1
+		for ; i < len(query); i++ {
+			if query[i] != '\'' {
+				lit.WriteByte(query[i])
+				continue
+			}
+			if i+1 < len(query) && query[i+1] == '\'' {
+				lit.WriteByte('\'')
+				i++
+				continue
+			}
+			break
+		}
+		literals = append(literals, lit.String())
+		query = query[min(i+1, len(query)):]
+	}
+}
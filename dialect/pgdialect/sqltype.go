@@ -14,13 +14,16 @@ import (
 
 const (
 	// Date / Time
-	pgTypeTimestamp       = "TIMESTAMP"                // Timestamp
-	pgTypeTimestampWithTz = "TIMESTAMP WITH TIME ZONE" // Timestamp with a time zone
-	pgTypeTimestampTz     = "TIMESTAMPTZ"              // Timestamp with a time zone (alias)
-	pgTypeDate            = "DATE"                     // Date
-	pgTypeTime            = "TIME"                     // Time without a time zone
-	pgTypeTimeTz          = "TIME WITH TIME ZONE"      // Time with a time zone
-	pgTypeInterval        = "INTERVAL"                 // Time interval
+	pgTypeTimestamp          = "TIMESTAMP"                   // Timestamp
+	pgTypeTimestampWithoutTz = "TIMESTAMP WITHOUT TIME ZONE" // Timestamp (alias)
+	pgTypeTimestampWithTz    = "TIMESTAMP WITH TIME ZONE"    // Timestamp with a time zone
+	pgTypeTimestampTz        = "TIMESTAMPTZ"                 // Timestamp with a time zone (alias)
+	pgTypeDate               = "DATE"                        // Date
+	pgTypeTime               = "TIME"                        // Time without a time zone
+	pgTypeTimeWithoutTz      = "TIME WITHOUT TIME ZONE"      // Time without a time zone (alias)
+	pgTypeTimeTz             = "TIME WITH TIME ZONE"         // Time with a time zone
+	pgTypeTimeTzAlias        = "TIMETZ"                      // Time with a time zone (alias)
+	pgTypeInterval           = "INTERVAL"                    // Time interval
 
 	// Network Addresses
 	pgTypeInet    = "INET"    // IPv4 or IPv6 hosts and networks
@@ -39,6 +42,10 @@ const (
 	pgTypeVarchar          = "VARCHAR"           // variable length string with optional limit
 	pgTypeCharacterVarying = "CHARACTER VARYING" // alias for VARCHAR
 
+	// Numeric Types
+	pgTypeNumeric = "NUMERIC" // exact number with selectable precision
+	pgTypeDecimal = "DECIMAL" // alias for NUMERIC
+
 	// Binary Data Types
 	pgTypeBytea = "BYTEA" // binary string
 )
@@ -126,11 +133,81 @@ func sqlType(typ reflect.Type) string {
 var (
 	char        = newAliases(pgTypeChar, pgTypeCharacter)
 	varchar     = newAliases(pgTypeVarchar, pgTypeCharacterVarying)
-	timestampTz = newAliases(sqltype.Timestamp, pgTypeTimestampTz, pgTypeTimestampWithTz)
+	timestampTz = newAliases(pgTypeTimestampTz, pgTypeTimestampWithTz)
+	timestamp   = newAliases(pgTypeTimestamp, pgTypeTimestampWithoutTz)
+	timeOfDayTz = newAliases(pgTypeTimeTz, pgTypeTimeTzAlias)
+	timeOfDay   = newAliases(pgTypeTime, pgTypeTimeWithoutTz)
+	numeric     = newAliases(pgTypeNumeric, pgTypeDecimal)
 )
 
+// serialTypes are the pseudo-types which create an integer column with a sequence.
+var serialTypes = map[string]string{
+	"smallserial": "smallint",
+	"serial2":     "smallint",
+	"serial":      "integer",
+	"serial4":     "integer",
+	"bigserial":   "bigint",
+	"serial8":     "bigint",
+}
+
+// serialInteger returns the integer type of a serial pseudo-type and any other type as is.
+// Serial types only exist in CREATE TABLE, they are stored as integers that take their default from a sequence.
+func serialInteger(typ string) string {
+	if integer, ok := serialTypes[strings.ToLower(typ)]; ok {
+		return integer
+	}
+	return typ
+}
+
+// defaultTimePrecision is the number of fractional digits in time and timestamp values
+// declared without explicit precision. Note that TIMESTAMP(0) cannot be told apart from TIMESTAMP,
+// because zero VarcharLen means no precision was specified.
+const defaultTimePrecision = 6
+
+// typeExtensions maps the types which are not built into Postgres to the extensions that provide them.
+var typeExtensions = map[string]string{
+	"citext": "citext",
+	"hstore": "hstore",
+	"ltree":  "ltree",
+	"cube":   "cube",
+}
+
+var _ sqlschema.TypeExtensions = (*Dialect)(nil)
+
+// TypeExtension returns the name of the extension which provides the type, e.g. "citext".
+// Case-insensitive citext is a distinct type, which is never equivalent to text, see CompareType.
+func (d *Dialect) TypeExtension(sqlType string) string {
+	return typeExtensions[strings.ToLower(sqlType)]
+}
+
 func (d *Dialect) CompareType(col1, col2 sqlschema.Column) bool {
-	typ1, typ2 := strings.ToUpper(col1.GetSQLType()), strings.ToUpper(col2.GetSQLType())
+	// Columns that only differ in collation still need to be altered.
+	if col1.GetCollation() != col2.GetCollation() {
+		return false
+	}
+
+	// An array is never equivalent to a scalar of the same type nor to an array of different dimensions.
+	if col1.GetArrayDims() != col2.GetArrayDims() {
+		return false
+	}
+
+	// Numeric types with different precision or scale store different ranges of values.
+	if col1.GetNumericPrecision() != col2.GetNumericPrecision() || col1.GetNumericScale() != col2.GetNumericScale() {
+		return false
+	}
+
+	// Whether the column is serial is compared with IsAutoIncrement.
+	typ1 := sqlschema.NormalizeType(serialInteger(col1.GetSQLType()))
+	typ2 := sqlschema.NormalizeType(serialInteger(col2.GetSQLType()))
+
+	// Fractional seconds precision is stored in VarcharLen, like the length of character types.
+	switch {
+	case timestampTz.IsAlias(typ1) && timestampTz.IsAlias(typ2),
+		timestamp.IsAlias(typ1) && timestamp.IsAlias(typ2),
+		timeOfDayTz.IsAlias(typ1) && timeOfDayTz.IsAlias(typ2),
+		timeOfDay.IsAlias(typ1) && timeOfDay.IsAlias(typ2):
+		return checkVarcharLen(col1, col2, defaultTimePrecision)
+	}
 
 	if typ1 == typ2 {
 		return checkVarcharLen(col1, col2, d.DefaultVarcharLen())
@@ -141,7 +218,7 @@ func (d *Dialect) CompareType(col1, col2 sqlschema.Column) bool {
 		return checkVarcharLen(col1, col2, d.DefaultVarcharLen())
 	case varchar.IsAlias(typ1) && varchar.IsAlias(typ2):
 		return checkVarcharLen(col1, col2, d.DefaultVarcharLen())
-	case timestampTz.IsAlias(typ1) && timestampTz.IsAlias(typ2):
+	case numeric.IsAlias(typ1) && numeric.IsAlias(typ2):
 		return true
 	}
 	return false
@@ -164,6 +241,28 @@ func checkVarcharLen(col1, col2 sqlschema.Column, defaultLen int) bool {
 	return false
 }
 
+// NormalizeDefault strips the type casts PostgreSQL adds to stored defaults, e.g. 'draft'::character varying,
+// and treats CURRENT_TIMESTAMP and transaction_timestamp() as now(), which all return the start time of the transaction.
+func (d *Dialect) NormalizeDefault(expr string) string {
+	expr = strings.TrimSpace(sqlschema.StripTypeCasts(expr))
+	if isStringLiteral(expr) {
+		return expr[1 : len(expr)-1]
+	}
+	switch expr {
+	case "current_timestamp", "transaction_timestamp()":
+		return "now()"
+	}
+	return expr
+}
+
+// isStringLiteral checks that s is a single quoted string, e.g. 'it”s', and not an expression like 'a' || 'b'.
+func isStringLiteral(s string) bool {
+	if len(s) < 2 || s[0] != '\'' || s[len(s)-1] != '\'' {
+		return false
+	}
+	return !strings.Contains(strings.ReplaceAll(s[1:len(s)-1], "''", ""), "'")
+}
+
 // typeAlias defines aliases for common data types. It is a lightweight string set implementation.
 type typeAlias map[string]struct{}
 
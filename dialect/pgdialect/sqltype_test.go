@@ -29,12 +29,22 @@ func TestInspectorDialect_CompareType(t *testing.T) {
 			{pgTypeChar, pgTypeText, false},
 			{pgTypeVarchar, pgTypeText, false},
 
-			// SQL standards require that TIMESTAMP be default alias for "TIMESTAMP WITH TIME ZONE"
-			{sqltype.Timestamp, pgTypeTimestampTz, true},
-			{sqltype.Timestamp, pgTypeTimestampWithTz, true},
-			{sqltype.Timestamp, pgTypeTimestamp, true}, // Still, TIMESTAMP == TIMESTAMP
+			// In Postgres, TIMESTAMP is "TIMESTAMP WITHOUT TIME ZONE". Models get TIMESTAMPTZ for time.Time by default.
+			{sqltype.Timestamp, pgTypeTimestampTz, false},
+			{sqltype.Timestamp, pgTypeTimestampWithTz, false},
+			{sqltype.Timestamp, pgTypeTimestamp, true},
 			{sqltype.Timestamp, pgTypeTimeTz, false},
 			{pgTypeTimestampTz, pgTypeTimestampWithTz, true},
+			{pgTypeTimestamp, pgTypeTimestampWithoutTz, true},
+			{pgTypeTimestampWithoutTz, pgTypeTimestampWithTz, false},
+			{pgTypeTime, pgTypeTimeWithoutTz, true},
+			{pgTypeTimeTz, pgTypeTimeTzAlias, true},
+			{pgTypeTime, pgTypeTimeTzAlias, false},
+
+			// citext is case-insensitive, changing it to or from text changes the semantics of the column
+			{"citext", "CITEXT", true},
+			{"citext", pgTypeText, false},
+			{"citext", pgTypeVarchar, false},
 		} {
 			eq := " ~ "
 			if !tt.want {
@@ -82,4 +92,202 @@ func TestInspectorDialect_CompareType(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("numeric precision and scale", func(t *testing.T) {
+		for _, tt := range []struct {
+			name       string
+			col1, col2 sqlschema.BaseColumn
+			want       bool
+		}{
+			{
+				name: "decimal is an alias for numeric",
+				col1: sqlschema.BaseColumn{SQLType: "decimal", NumericPrecision: 10, NumericScale: 2},
+				col2: sqlschema.BaseColumn{SQLType: "numeric", NumericPrecision: 10, NumericScale: 2},
+				want: true,
+			},
+			{
+				name: "different scale",
+				col1: sqlschema.BaseColumn{SQLType: "numeric", NumericPrecision: 10, NumericScale: 2},
+				col2: sqlschema.BaseColumn{SQLType: "numeric", NumericPrecision: 10, NumericScale: 4},
+				want: false,
+			},
+			{
+				name: "different precision",
+				col1: sqlschema.BaseColumn{SQLType: "numeric", NumericPrecision: 10},
+				col2: sqlschema.BaseColumn{SQLType: "numeric", NumericPrecision: 12},
+				want: false,
+			},
+			{
+				name: "unconstrained and constrained numeric",
+				col1: sqlschema.BaseColumn{SQLType: "numeric"},
+				col2: sqlschema.BaseColumn{SQLType: "numeric", NumericPrecision: 10, NumericScale: 2},
+				want: false,
+			},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				got := d.CompareType(&tt.col1, &tt.col2)
+				require.Equal(t, tt.want, got)
+			})
+		}
+	})
+
+	t.Run("fractional seconds precision", func(t *testing.T) {
+		for _, tt := range []struct {
+			name       string
+			col1, col2 sqlschema.BaseColumn
+			want       bool
+		}{
+			{
+				name: "same precision",
+				col1: sqlschema.BaseColumn{SQLType: "timestamptz", VarcharLen: 3},
+				col2: sqlschema.BaseColumn{SQLType: "timestamp with time zone", VarcharLen: 3},
+				want: true,
+			},
+			{
+				name: "different precision",
+				col1: sqlschema.BaseColumn{SQLType: "timestamp", VarcharLen: 3},
+				col2: sqlschema.BaseColumn{SQLType: "timestamp without time zone", VarcharLen: 6},
+				want: false,
+			},
+			{
+				name: "no explicit precision is equivalent to default precision",
+				col1: sqlschema.BaseColumn{SQLType: "timetz"},
+				col2: sqlschema.BaseColumn{SQLType: "time with time zone", VarcharLen: 6},
+				want: true,
+			},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				got := d.CompareType(&tt.col1, &tt.col2)
+				require.Equal(t, tt.want, got)
+			})
+		}
+	})
+
+	t.Run("array types", func(t *testing.T) {
+		for _, tt := range []struct {
+			name       string
+			col1, col2 sqlschema.BaseColumn
+			want       bool
+		}{
+			{
+				name: "arrays of the same type",
+				col1: sqlschema.BaseColumn{SQLType: "integer", ArrayDims: 1},
+				col2: sqlschema.BaseColumn{SQLType: "INTEGER", ArrayDims: 1},
+				want: true,
+			},
+			{
+				name: "array and scalar",
+				col1: sqlschema.BaseColumn{SQLType: "integer", ArrayDims: 1},
+				col2: sqlschema.BaseColumn{SQLType: "integer"},
+				want: false,
+			},
+			{
+				name: "arrays of different dimensions",
+				col1: sqlschema.BaseColumn{SQLType: "text", ArrayDims: 1},
+				col2: sqlschema.BaseColumn{SQLType: "text", ArrayDims: 2},
+				want: false,
+			},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				got := d.CompareType(&tt.col1, &tt.col2)
+				require.Equal(t, tt.want, got)
+			})
+		}
+	})
+
+	t.Run("collation", func(t *testing.T) {
+		for _, tt := range []struct {
+			name       string
+			col1, col2 sqlschema.BaseColumn
+			want       bool
+		}{
+			{
+				name: "default collation",
+				col1: sqlschema.BaseColumn{SQLType: "text"},
+				col2: sqlschema.BaseColumn{SQLType: "text"},
+				want: true,
+			},
+			{
+				name: "same collation",
+				col1: sqlschema.BaseColumn{SQLType: "varchar", Collation: "en_US"},
+				col2: sqlschema.BaseColumn{SQLType: "character varying", Collation: "en_US"},
+				want: true,
+			},
+			{
+				name: "custom and default collation",
+				col1: sqlschema.BaseColumn{SQLType: "varchar", VarcharLen: 255, Collation: "en_US"},
+				col2: sqlschema.BaseColumn{SQLType: "varchar", VarcharLen: 255},
+				want: false,
+			},
+			{
+				name: "different collation",
+				col1: sqlschema.BaseColumn{SQLType: "text", Collation: "en_US"},
+				col2: sqlschema.BaseColumn{SQLType: "text", Collation: "C"},
+				want: false,
+			},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				got := d.CompareType(&tt.col1, &tt.col2)
+				require.Equal(t, tt.want, got)
+			})
+		}
+	})
+
+	t.Run("multi-word types", func(t *testing.T) {
+		for _, tt := range []struct {
+			typ1, typ2 string
+			want       bool
+		}{
+			{"double precision", "DOUBLE PRECISION", true},
+			{"double  precision", "double precision", true},
+			{"character\tvarying", pgTypeVarchar, true},
+			{"timestamp   with time zone", pgTypeTimestampTz, true},
+			{"timestamp without  time zone", pgTypeTimestamp, true},
+			{"time with time  zone", pgTypeTimeTzAlias, true},
+			{"time without time zone", pgTypeTime, true},
+			{"bit  varying", "bit varying", true},
+			{"timestamp with time zone", "timestamp without time zone", false},
+			{"timestamp", pgTypeTimestampTz, false},
+			{"timestamp", "timestamp with time zone", false},
+
+			// FLOAT8 is only equivalent to DOUBLE PRECISION if configured with migrate.TypeAlias.
+			{"double precision", "float8", false},
+		} {
+			eq := " ~ "
+			if !tt.want {
+				eq = " !~ "
+			}
+			t.Run(tt.typ1+eq+tt.typ2, func(t *testing.T) {
+				got := d.CompareType(
+					&sqlschema.BaseColumn{SQLType: tt.typ1},
+					&sqlschema.BaseColumn{SQLType: tt.typ2},
+				)
+				require.Equal(t, tt.want, got)
+			})
+		}
+	})
+}
+
+func TestDialect_NormalizeDefault(t *testing.T) {
+	d := New()
+
+	for _, tt := range []struct {
+		expr, want string
+	}{
+		{"now()", "now()"},
+		{"current_timestamp", "now()"},
+		{"transaction_timestamp()", "now()"},
+		{"clock_timestamp()", "clock_timestamp()"},
+		{"'draft'::character varying", "draft"},
+		{"'It''s'::text", "It''s"},
+		{"Draft", "Draft"},
+		{"'a'::text || 'B'::text", "'a' || 'B'"},
+		{"nextval('Users_Seq'::regclass)", "nextval('Users_Seq')"},
+		{"(0)::numeric(10,2)", "(0)"},
+		{"'{}'::text[]", "{}"},
+	} {
+		t.Run(tt.expr, func(t *testing.T) {
+			require.Equal(t, tt.want, d.NormalizeDefault(tt.expr))
+		})
+	}
 }
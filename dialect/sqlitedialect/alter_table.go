@@ -0,0 +1,487 @@
+package sqlitedialect
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/internal/ordered"
+	"github.com/uptrace/bun/migrate"
+	"github.com/uptrace/bun/migrate/sqlschema"
+	"github.com/uptrace/bun/schema"
+)
+
+func (d *Dialect) NewMigrator(db *bun.DB, schemaName string) sqlschema.Migrator {
+	return &migrator{db: db, schemaName: schemaName, BaseMigrator: sqlschema.NewBaseMigrator(db)}
+}
+
+// InlineForeignKeys reports that foreign keys must be declared in CREATE TABLE,
+// as SQLite cannot add them to an existing table.
+func (d *Dialect) InlineForeignKeys() bool {
+	return true
+}
+
+type migrator struct {
+	*sqlschema.BaseMigrator
+
+	db         *bun.DB
+	schemaName string
+}
+
+var _ sqlschema.Migrator = (*migrator)(nil)
+
+// AppendSQL supports the subset of ALTER TABLE that SQLite implements: renaming tables and columns,
+// adding and dropping columns. SQLite cannot alter column definitions or constraints of an existing table,
+// which requires re-creating the table instead. Column definitions are changed by rebuilding the table,
+// other operations are reported as errors rather than producing invalid SQL.
+func (m *migrator) AppendSQL(b []byte, operation interface{}) (_ []byte, err error) {
+	fmter := m.db.Formatter()
+
+	// Append ALTER TABLE statement to the enclosed query bytes []byte.
+	appendAlterTable := func(query []byte, tableName string) []byte {
+		query = append(query, "ALTER TABLE "...)
+		query = m.appendFQN(fmter, query, tableName)
+		return append(query, " "...)
+	}
+
+	switch change := operation.(type) {
+	case *migrate.CreateTableOp:
+		if change.Table != nil && len(change.Table.GetInherits()) > 0 {
+			return nil, fmt.Errorf("append sql: sqlite does not support table inheritance")
+		}
+		if change.Table != nil && change.Table.GetTablespace() != "" {
+			return nil, fmt.Errorf("append sql: sqlite does not support tablespaces")
+		}
+		var opts []sqlschema.CreateTableOption
+		if change.IfNotExists {
+			opts = append(opts, sqlschema.WithIfNotExists())
+		}
+		if change.Model == nil {
+			return m.AppendCreateTableDefinition(b, m.schemaName, change.Table, opts...)
+		}
+		return m.AppendCreateTable(b, change.Model, opts...)
+	case *migrate.DropTableOp:
+		return m.AppendDropTable(b, m.schemaName, change.TableName)
+	case *migrate.RenameTableOp:
+		b, err = m.renameTable(fmter, appendAlterTable(b, change.TableName), change)
+	case *migrate.RenameColumnOp:
+		b, err = m.renameColumn(fmter, appendAlterTable(b, change.TableName), change)
+	case *migrate.AddColumnOp:
+		b, err = m.addColumn(fmter, appendAlterTable(b, change.TableName), change)
+	case *migrate.DropColumnOp:
+		b, err = m.dropColumn(fmter, appendAlterTable(b, change.TableName), change)
+	case *migrate.ChangeColumnTypeOp:
+		// Without the current definition of the table it cannot be rebuilt.
+		if change.Table == nil {
+			return nil, fmt.Errorf("append sql: sqlite cannot apply %T to an existing table, the table must be recreated", change)
+		}
+		b, err = m.rebuildTable(fmter, b, change)
+	case *migrate.ChangeColumnDefaultOp:
+		if change.Table == nil {
+			return nil, fmt.Errorf("append sql: sqlite cannot apply %T to an existing table, the table must be recreated", change)
+		}
+		b, err = m.changeDefault(fmter, b, change)
+	case *migrate.ChangeColumnNullabilityOp:
+		if change.Table == nil {
+			return nil, fmt.Errorf("append sql: sqlite cannot apply %T to an existing table, the table must be recreated", change)
+		}
+		b, err = m.changeNullability(fmter, b, change)
+	case *migrate.AddPrimaryKeyOp, *migrate.ChangePrimaryKeyOp, *migrate.DropPrimaryKeyOp,
+		*migrate.AddUniqueConstraintOp, *migrate.DropUniqueConstraintOp,
+		*migrate.AddCheckConstraintOp, *migrate.DropCheckConstraintOp,
+		*migrate.AddForeignKeyOp, *migrate.DropForeignKeyOp:
+		return nil, fmt.Errorf("append sql: sqlite cannot apply %T to an existing table, the table must be recreated", change)
+	case *migrate.ChangeColumnStorageOp:
+		return nil, fmt.Errorf("append sql: sqlite does not support column storage modes")
+	case *migrate.AddExclusionConstraintOp, *migrate.DropExclusionConstraintOp:
+		return nil, fmt.Errorf("append sql: sqlite does not support exclusion constraints")
+	case *migrate.ChangeColumnCommentOp:
+		return nil, fmt.Errorf("append sql: sqlite does not support column comments")
+	case *migrate.ChangeTableCommentOp:
+		return nil, fmt.Errorf("append sql: sqlite does not support table comments")
+	case *migrate.CreateEnumOp, *migrate.DropEnumOp, *migrate.AddEnumValueOp:
+		return nil, fmt.Errorf("append sql: sqlite does not support enumerated types")
+	case *migrate.CreateViewOp:
+		if change.View.Materialized {
+			return nil, fmt.Errorf("append sql: sqlite does not support materialized views")
+		}
+		return m.createView(fmter, b, change.View)
+	case *migrate.DropViewOp:
+		return m.dropView(fmter, b, change.View)
+	case *migrate.RefreshViewOp:
+		return nil, fmt.Errorf("append sql: sqlite does not support materialized views")
+	case *migrate.CreateSequenceOp, *migrate.AlterSequenceOp, *migrate.DropSequenceOp:
+		return nil, fmt.Errorf("append sql: sqlite does not support sequences")
+	case *migrate.CreateDomainOp, *migrate.DropDomainOp:
+		return nil, fmt.Errorf("append sql: sqlite does not support domains")
+	case *migrate.CreateCompositeTypeOp, *migrate.DropCompositeTypeOp,
+		*migrate.AddCompositeAttributeOp, *migrate.DropCompositeAttributeOp:
+		return nil, fmt.Errorf("append sql: sqlite does not support composite types")
+	case *migrate.CreateExtensionOp:
+		return nil, fmt.Errorf("append sql: sqlite does not support extensions")
+	case *migrate.CreatePolicyOp, *migrate.AlterPolicyOp, *migrate.DropPolicyOp, *migrate.ChangeRowSecurityOp:
+		return nil, fmt.Errorf("append sql: sqlite does not support row-level security")
+	case *migrate.ChangeTablespaceOp, *migrate.ChangeIndexTablespaceOp:
+		return nil, fmt.Errorf("append sql: sqlite does not support tablespaces")
+	case *migrate.GrantOp, *migrate.RevokeOp:
+		return nil, fmt.Errorf("append sql: sqlite does not support privileges")
+	case *migrate.CreateTriggerOp:
+		b, err = m.createTrigger(fmter, b, change.Trigger)
+	case *migrate.DropTriggerOp:
+		b = append(b, "DROP TRIGGER "...)
+		return fmter.AppendQuery(b, "?.?", bun.Ident(m.schemaName), bun.Ident(change.Trigger.Name)), nil
+	case *migrate.CreateIndexOp:
+		b, err = m.createIndex(fmter, b, change)
+	case *migrate.DropIndexOp:
+		b = append(b, "DROP INDEX "...)
+		return fmter.AppendQuery(b, "?.?", bun.Ident(m.schemaName), bun.Ident(change.Index.Name)), nil
+	default:
+		return nil, fmt.Errorf("append sql: unknown operation %T", change)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("append sql: %w", err)
+	}
+	return b, nil
+}
+
+func (m *migrator) appendFQN(fmter schema.Formatter, b []byte, tableName string) []byte {
+	return fmter.AppendQuery(b, "?.?", bun.Ident(m.schemaName), bun.Ident(tableName))
+}
+
+// createView appends CREATE VIEW statement. The definition is appended as is, so it must be a valid query.
+func (m *migrator) createView(fmter schema.Formatter, b []byte, view sqlschema.View) (_ []byte, err error) {
+	b = append(b, "CREATE VIEW "...)
+	b = m.appendViewName(fmter, b, view)
+	b = append(b, " AS "...)
+	b = append(b, strings.TrimSuffix(strings.TrimSpace(view.Definition), ";")...)
+	return b, nil
+}
+
+func (m *migrator) dropView(fmter schema.Formatter, b []byte, view sqlschema.View) (_ []byte, err error) {
+	b = append(b, "DROP VIEW "...)
+	b = m.appendViewName(fmter, b, view)
+	return b, nil
+}
+
+// appendViewName appends the view's name qualified with the database it is attached to.
+func (m *migrator) appendViewName(fmter schema.Formatter, b []byte, view sqlschema.View) []byte {
+	schemaName := view.Schema
+	if schemaName == "" {
+		schemaName = m.schemaName
+	}
+	return fmter.AppendQuery(b, "?.?", bun.Ident(schemaName), bun.Ident(view.Name))
+}
+
+// createTrigger appends CREATE TRIGGER statement. The trigger is created in the schema of its table,
+// which must not be qualified in the ON clause. SQLite triggers fire on a single event for each row.
+func (m *migrator) createTrigger(fmter schema.Formatter, b []byte, trigger sqlschema.Trigger) (_ []byte, err error) {
+	if len(trigger.Events) != 1 {
+		return nil, fmt.Errorf("sqlite trigger %q must fire on exactly one event, got %d", trigger.Name, len(trigger.Events))
+	}
+	if !trigger.ForEachRow {
+		return nil, fmt.Errorf("sqlite does not support FOR EACH STATEMENT triggers")
+	}
+	b = append(b, "CREATE TRIGGER "...)
+	b = fmter.AppendQuery(b, "?.?", bun.Ident(m.schemaName), bun.Ident(trigger.Name))
+	b = append(b, " "...)
+	b = append(b, strings.ToUpper(trigger.Timing)...)
+	b = append(b, " "...)
+	b = append(b, strings.ToUpper(trigger.Events[0])...)
+	b = append(b, " ON "...)
+	b = fmter.AppendName(b, trigger.TableName)
+	b = append(b, " FOR EACH ROW "...)
+	b = append(b, strings.TrimSpace(trigger.Body)...)
+	return b, nil
+}
+
+// changeDefault rebuilds the table, since SQLite cannot alter the default value of an existing column.
+func (m *migrator) changeDefault(fmter schema.Formatter, b []byte, change *migrate.ChangeColumnDefaultOp) (_ []byte, err error) {
+	col, ok := change.Table.GetColumns().Load(change.Column)
+	if !ok {
+		return nil, fmt.Errorf("column %q does not exist in table %s", change.Column, change.TableName)
+	}
+	to := copyColumn(col)
+	to.DefaultValue = change.To
+	return m.rebuildTable(fmter, b, &migrate.ChangeColumnTypeOp{
+		TableName:    change.TableName,
+		Column:       change.Column,
+		From:         col,
+		To:           to,
+		Table:        change.Table,
+		ForeignKeys:  change.ForeignKeys,
+		Indexes:      change.Indexes,
+		Triggers:     change.Triggers,
+		ReferencedBy: change.ReferencedBy,
+	})
+}
+
+// changeNullability rebuilds the table with the column made nullable or NOT NULL.
+// The NULL values are replaced with the backfill value when the rows are copied.
+func (m *migrator) changeNullability(fmter schema.Formatter, b []byte, change *migrate.ChangeColumnNullabilityOp) (_ []byte, err error) {
+	col, ok := change.Table.GetColumns().Load(change.Column)
+	if !ok {
+		return nil, fmt.Errorf("column %q does not exist in table %s", change.Column, change.TableName)
+	}
+	to := copyColumn(col)
+	to.IsNullable = change.Nullable
+
+	var using string
+	if change.Backfill != "" && !change.Nullable {
+		using = string(fmter.AppendName([]byte("COALESCE("), change.Column)) + ", " + change.Backfill + ")"
+	}
+	return m.rebuildTable(fmter, b, &migrate.ChangeColumnTypeOp{
+		TableName:    change.TableName,
+		Column:       change.Column,
+		From:         col,
+		To:           to,
+		Using:        using,
+		Table:        change.Table,
+		ForeignKeys:  change.ForeignKeys,
+		Indexes:      change.Indexes,
+		Triggers:     change.Triggers,
+		ReferencedBy: change.ReferencedBy,
+	})
+}
+
+func copyColumn(col sqlschema.Column) *sqlschema.BaseColumn {
+	return &sqlschema.BaseColumn{
+		Name:             col.GetName(),
+		SQLType:          col.GetSQLType(),
+		VarcharLen:       col.GetVarcharLen(),
+		NumericPrecision: col.GetNumericPrecision(),
+		NumericScale:     col.GetNumericScale(),
+		ArrayDims:        col.GetArrayDims(),
+		DefaultValue:     col.GetDefaultValue(),
+		IsNullable:       col.GetIsNullable(),
+		IsAutoIncrement:  col.GetIsAutoIncrement(),
+		IsIdentity:       col.GetIsIdentity(),
+		IdentityOptions:  col.GetIdentityOptions(),
+		Collation:        col.GetCollation(),
+		GeneratedExpr:    col.GetGeneratedExpr(),
+		GeneratedStored:  col.GetGeneratedStored(),
+	}
+}
+
+// rebuildTable changes the column definition by moving the rows aside, re-creating the table with the altered column,
+// and copying the rows back. The values are converted with the Using expression or a CAST. Dropping the original
+// table drops its indexes and triggers, so they are created again afterwards.
+//
+// The statements run in a savepoint, which starts a transaction if there is none, so the table is never left half-built.
+// Foreign key enforcement cannot be disabled in a transaction, so the foreign keys which reference the table
+// are only checked when the transaction commits, see PRAGMA defer_foreign_keys. Still, dropping the table deletes its rows,
+// which applies the ON DELETE actions of the referencing foreign keys, so such tables cannot be rebuilt.
+func (m *migrator) rebuildTable(fmter schema.Formatter, b []byte, change *migrate.ChangeColumnTypeOp) (_ []byte, err error) {
+	current := change.Table
+	if _, ok := current.GetColumns().Load(change.Column); !ok {
+		return nil, fmt.Errorf("column %q does not exist in table %s", change.Column, change.TableName)
+	}
+	for _, fk := range sqlschema.SortedForeignKeys(change.ReferencedBy) {
+		// Rows that reference the same table are deleted with it anyway, unless the deletion is restricted.
+		self := fk.From.TableName == fk.To.TableName
+		if fk.OnDelete == sqlschema.Restrict || !self && fk.OnDelete != sqlschema.NoAction {
+			return nil, fmt.Errorf("cannot rebuild table %s: foreign key %s would apply to its rows when the table is dropped",
+				change.TableName, fk)
+		}
+	}
+
+	inspector := m.db.Dialect().(sqlschema.InspectorDialect)
+	columns := ordered.NewMap[string, sqlschema.Column]()
+	var names, values []byte
+	for _, pair := range current.GetColumns().Pairs() {
+		col := pair.Value
+		if pair.Key == change.Column {
+			col = change.To
+		}
+		columns.Store(pair.Key, col)
+
+		// Generated columns are computed again from the copied values.
+		if col.GetGeneratedExpr() != "" {
+			continue
+		}
+		if len(names) > 0 {
+			names = append(names, ", "...)
+			values = append(values, ", "...)
+		}
+		names = fmter.AppendName(names, pair.Key)
+		switch {
+		case pair.Key != change.Column:
+			values = fmter.AppendName(values, pair.Key)
+		case change.Using != "":
+			values = append(values, change.Using...)
+		case !inspector.CompareType(change.From, change.To):
+			values = append(values, "CAST("...)
+			values = fmter.AppendName(values, pair.Key)
+			values = append(values, " AS "...)
+			if values, err = change.To.AppendQuery(fmter, values); err != nil {
+				return nil, err
+			}
+			values = append(values, ")"...)
+		default:
+			values = fmter.AppendName(values, pair.Key)
+		}
+	}
+
+	oldName := change.TableName + "_old"
+	savepoint := change.TableName + "_rebuild"
+	b = append(b, "SAVEPOINT "...)
+	b = fmter.AppendName(b, savepoint)
+	b = append(b, ";\nPRAGMA defer_foreign_keys = ON;\nCREATE TABLE "...)
+	b = m.appendFQN(fmter, b, oldName)
+	b = append(b, " AS SELECT * FROM "...)
+	b = m.appendFQN(fmter, b, change.TableName)
+
+	b = append(b, ";\nDROP TABLE "...)
+	b = m.appendFQN(fmter, b, change.TableName)
+
+	b = append(b, ";\n"...)
+	b, err = m.AppendCreateTableWithForeignKeys(b, m.schemaName, &sqlschema.BaseTable{
+		Name:              change.TableName,
+		Columns:           columns,
+		PrimaryKey:        current.GetPrimaryKey(),
+		UniqueConstraints: current.GetUniqueConstraints(),
+		Checks:            current.GetChecks(),
+	}, change.ForeignKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	b = append(b, ";\nINSERT INTO "...)
+	b = m.appendFQN(fmter, b, change.TableName)
+	b = append(b, " ("...)
+	b = append(b, names...)
+	b = append(b, ") SELECT "...)
+	b = append(b, values...)
+	b = append(b, " FROM "...)
+	b = m.appendFQN(fmter, b, oldName)
+
+	b = append(b, ";\nDROP TABLE "...)
+	b = m.appendFQN(fmter, b, oldName)
+
+	for _, idx := range change.Indexes {
+		b = append(b, ";\n"...)
+		if b, err = m.createIndex(fmter, b, &migrate.CreateIndexOp{Index: idx}); err != nil {
+			return nil, err
+		}
+	}
+	for _, trigger := range change.Triggers {
+		b = append(b, ";\n"...)
+		if b, err = m.createTrigger(fmter, b, trigger); err != nil {
+			return nil, err
+		}
+	}
+
+	b = append(b, ";\nRELEASE "...)
+	b = fmter.AppendName(b, savepoint)
+
+	// The operations that alter other columns of the table share its definition
+	// and rebuild it after this one, so they must keep the altered column.
+	current.GetColumns().Store(change.Column, change.To)
+	return b, nil
+}
+
+// createIndex appends CREATE INDEX statement. Like triggers, the index is created in the schema of its table,
+// which must not be qualified in the ON clause. SQLite only implements B-tree indexes without INCLUDE columns
+// or storage parameters, has no operator classes, and it cannot build the indexes concurrently, so that option is ignored.
+func (m *migrator) createIndex(fmter schema.Formatter, b []byte, create *migrate.CreateIndexOp) (_ []byte, err error) {
+	idx := create.Index
+	if idx.GetMethod() != sqlschema.DefaultIndexMethod {
+		return nil, fmt.Errorf("sqlite does not support %s indexes", idx.Method)
+	}
+	if len(idx.Include) > 0 {
+		return nil, fmt.Errorf("sqlite does not support INCLUDE columns in indexes")
+	}
+	if len(idx.StorageParameters) > 0 {
+		return nil, fmt.Errorf("sqlite does not support index storage parameters")
+	}
+	for _, col := range idx.Columns {
+		if col.OpClass != "" {
+			return nil, fmt.Errorf("sqlite does not support operator classes")
+		}
+	}
+	if idx.Tablespace != "" {
+		return nil, fmt.Errorf("sqlite does not support tablespaces")
+	}
+
+	b = append(b, "CREATE "...)
+	if idx.Unique {
+		b = append(b, "UNIQUE "...)
+	}
+	b = append(b, "INDEX "...)
+	if create.IfNotExists {
+		b = append(b, "IF NOT EXISTS "...)
+	}
+	b = fmter.AppendQuery(b, "?.?", bun.Ident(m.schemaName), bun.Ident(idx.Name))
+	b = append(b, " ON "...)
+	b = fmter.AppendName(b, idx.TableName)
+	b = append(b, " ("...)
+	for i, col := range idx.Columns {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		if col.Expression != "" {
+			b = append(b, col.Expression...)
+			continue
+		}
+		b = fmter.AppendName(b, col.Name)
+	}
+	b = append(b, ")"...)
+	if idx.Where != "" {
+		b = append(b, " WHERE "...)
+		b = append(b, idx.Where...)
+	}
+	return b, nil
+}
+
+func (m *migrator) renameTable(fmter schema.Formatter, b []byte, rename *migrate.RenameTableOp) (_ []byte, err error) {
+	// The new name cannot be qualified, as SQLite does not allow moving tables between databases.
+	b = append(b, "RENAME TO "...)
+	b = fmter.AppendName(b, rename.NewName)
+	return b, nil
+}
+
+func (m *migrator) renameColumn(fmter schema.Formatter, b []byte, rename *migrate.RenameColumnOp) (_ []byte, err error) {
+	b = append(b, "RENAME COLUMN "...)
+	b = fmter.AppendName(b, rename.OldName)
+
+	b = append(b, " TO "...)
+	b = fmter.AppendName(b, rename.NewName)
+
+	return b, nil
+}
+
+// addColumn appends ADD COLUMN clause. SQLite does not allow adding PRIMARY KEY or UNIQUE columns,
+// and NOT NULL columns must have a non-NULL default value. Since a default cannot be dropped later,
+// such columns are not backfilled and must declare a default.
+func (m *migrator) addColumn(fmter schema.Formatter, b []byte, add *migrate.AddColumnOp) (_ []byte, err error) {
+	if add.RequiresBackfill() {
+		return nil, fmt.Errorf("append sql: sqlite cannot add NOT NULL column %q to %s without a default: "+
+			"set a default or make the column nullable", add.ColumnName, add.TableName)
+	}
+
+	b = append(b, "ADD COLUMN "...)
+	b = fmter.AppendName(b, add.ColumnName)
+	b = append(b, " "...)
+
+	b, err = add.Column.AppendQuery(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if !add.Column.GetIsNullable() {
+		b = append(b, " NOT NULL"...)
+	}
+
+	if add.Column.GetDefaultValue() != "" {
+		b = append(b, " DEFAULT "...)
+		b = append(b, add.Column.GetDefaultValue()...)
+	}
+
+	return b, nil
+}
+
+func (m *migrator) dropColumn(fmter schema.Formatter, b []byte, drop *migrate.DropColumnOp) (_ []byte, err error) {
+	b = append(b, "DROP COLUMN "...)
+	b = fmter.AppendName(b, drop.ColumnName)
+
+	return b, nil
+}
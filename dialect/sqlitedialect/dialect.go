@@ -4,11 +4,13 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"fmt"
+	"strings"
 
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect"
 	"github.com/uptrace/bun/dialect/feature"
 	"github.com/uptrace/bun/dialect/sqltype"
+	"github.com/uptrace/bun/migrate/sqlschema"
 	"github.com/uptrace/bun/schema"
 )
 
@@ -41,7 +43,8 @@ func New(opts ...DialectOption) *Dialect {
 		feature.SelectExists |
 		feature.AutoIncrement |
 		feature.CompositeIn |
-		feature.DeleteReturning
+		feature.DeleteReturning |
+		feature.TransactionalDDL
 
 	for _, opt := range opts {
 		opt(d)
@@ -129,6 +132,20 @@ func (d *Dialect) AppendSequence(b []byte, table *schema.Table, field *schema.Fi
 	return b
 }
 
+// AppendColumnSequence declares an auto-increment column as INTEGER PRIMARY KEY AUTOINCREMENT when a table
+// is created from its definition. Like with AppendSequence, the column becomes the table's primary key,
+// so the PRIMARY KEY constraint is not declared again. Other columns are created as plain columns of their type.
+func (d *Dialect) AppendColumnSequence(fmter schema.Formatter, b []byte, col sqlschema.Column) ([]byte, error) {
+	b, err := col.AppendQuery(fmter, b)
+	if err != nil {
+		return nil, err
+	}
+	if col.GetIsAutoIncrement() && strings.EqualFold(col.GetSQLType(), sqltype.Integer) {
+		b = append(b, " PRIMARY KEY AUTOINCREMENT"...)
+	}
+	return b, nil
+}
+
 // DefaultSchemaName is the "schema-name" of the main database.
 // The details might differ from other dialects, but for all means and purposes
 // "main" is the default schema in an SQLite database.
@@ -136,6 +153,41 @@ func (d *Dialect) DefaultSchema() string {
 	return "main"
 }
 
+// CompareType returns true if the columns have the same type affinity.
+// SQLite uses dynamic typing and only derives an affinity from the declared type of the column,
+// so that INT ~ INTEGER ~ BIGINT and VARCHAR(255) ~ TEXT. See [type affinity] rules.
+//
+// [type affinity]: https://www.sqlite.org/datatype3.html#determination_of_column_affinity
+func (d *Dialect) CompareType(col1, col2 sqlschema.Column) bool {
+	return typeAffinity(col1.GetSQLType()) == typeAffinity(col2.GetSQLType())
+}
+
+const (
+	affinityInteger = "INTEGER"
+	affinityText    = "TEXT"
+	affinityBlob    = "BLOB"
+	affinityReal    = "REAL"
+	affinityNumeric = "NUMERIC"
+)
+
+// typeAffinity determines the affinity of a declared column type.
+// The rules are applied in order, so that e.g. "CHARINT" has INTEGER affinity.
+func typeAffinity(typ string) string {
+	typ = strings.ToUpper(typ)
+	switch {
+	case strings.Contains(typ, "INT"):
+		return affinityInteger
+	case strings.Contains(typ, "CHAR"), strings.Contains(typ, "CLOB"), strings.Contains(typ, "TEXT"):
+		return affinityText
+	case strings.Contains(typ, "BLOB"), typ == "":
+		return affinityBlob
+	case strings.Contains(typ, "REAL"), strings.Contains(typ, "FLOA"), strings.Contains(typ, "DOUB"):
+		return affinityReal
+	default:
+		return affinityNumeric
+	}
+}
+
 func fieldSQLType(field *schema.Field) string {
 	switch field.DiscoveredSQLType {
 	case sqltype.SmallInt, sqltype.BigInt:
@@ -0,0 +1,691 @@
+package sqlitedialect
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/internal/ordered"
+	"github.com/uptrace/bun/migrate/sqlschema"
+)
+
+type (
+	Schema = sqlschema.BaseDatabase
+	Table  = sqlschema.BaseTable
+	Column = sqlschema.BaseColumn
+)
+
+func (d *Dialect) NewInspector(db *bun.DB, options ...sqlschema.InspectorOption) sqlschema.Inspector {
+	return newInspector(db, options...)
+}
+
+// Inspector reads the schema of an SQLite database from the sqlite_master table
+// and the table_info, index_list, index_info, and foreign_key_list pragmas.
+// Pragmas are joined with sqlite_master as table-valued functions, so that the number
+// of queries does not depend on the number of tables.
+//
+// SQLite does not store constraint names for foreign keys, nor does it report whether
+// they are deferrable, so foreign keys are always given the name "table_column_fkey".
+type Inspector struct {
+	sqlschema.InspectorConfig
+	db *bun.DB
+}
+
+var (
+	_ sqlschema.Inspector     = (*Inspector)(nil)
+	_ sqlschema.Fingerprinter = (*Inspector)(nil)
+)
+
+func newInspector(db *bun.DB, options ...sqlschema.InspectorOption) *Inspector {
+	i := &Inspector{db: db}
+	i.SchemaName = db.Dialect().DefaultSchema()
+	sqlschema.ApplyInspectorOptions(&i.InspectorConfig, options...)
+	return i
+}
+
+func (in *Inspector) Inspect(ctx context.Context) (sqlschema.Database, error) {
+	dbSchema := Schema{
+		Tables:      ordered.NewMap[string, sqlschema.Table](),
+		ForeignKeys: make(map[sqlschema.ForeignKey]string),
+		Enums:       make(map[string][]string),
+	}
+
+	filter, err := sqlschema.NewTableFilter(in.InspectorConfig)
+	if err != nil {
+		return dbSchema, err
+	}
+	if filter.SchemaExcluded(in.SchemaName) {
+		return dbSchema, nil
+	}
+	exclude := in.ExcludeTables
+	if len(exclude) == 0 {
+		// Avoid getting NOT IN (NULL) if bun.In() is called with an empty slice.
+		exclude = []string{""}
+	}
+
+	var (
+		tables     []*MasterTable
+		views      []*MasterTable
+		triggers   []*MasterTable
+		indexDefs  []*MasterTable
+		columns    []*TableColumn
+		indexParts []*IndexInfo
+		fks        []*ForeignKey
+	)
+	if err := in.RunQueries(ctx, in.db,
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectTables, bun.Ident(in.SchemaName), bun.In(exclude)).Scan(ctx, &tables)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectViews, bun.Ident(in.SchemaName), bun.In(exclude)).Scan(ctx, &views)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectTriggers, bun.Ident(in.SchemaName), bun.In(exclude)).Scan(ctx, &triggers)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectIndexDefinitions, bun.Ident(in.SchemaName)).Scan(ctx, &indexDefs)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectColumns, bun.Ident(in.SchemaName), in.SchemaName, bun.In(exclude)).Scan(ctx, &columns)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectIndexes, bun.Ident(in.SchemaName), in.SchemaName, in.SchemaName, bun.In(exclude)).Scan(ctx, &indexParts)
+		},
+		func(ctx context.Context) error {
+			return in.db.NewRaw(sqlInspectForeignKeys, bun.Ident(in.SchemaName), in.SchemaName, bun.In(exclude)).Scan(ctx, &fks)
+		},
+	); err != nil {
+		return dbSchema, err
+	}
+
+	// The query only excludes tables by their exact names, patterns and included tables are matched here.
+	tables = slices.DeleteFunc(tables, func(t *MasterTable) bool {
+		return filter.Excluded(in.SchemaName, t.Name)
+	})
+
+	indexSQL := make(map[string]string, len(indexDefs))
+	for _, def := range indexDefs {
+		indexSQL[def.Name] = def.SQL
+	}
+
+	tableColumns := make(map[string][]*TableColumn, len(tables))
+	for _, c := range columns {
+		tableColumns[c.Table] = append(tableColumns[c.Table], c)
+	}
+
+	// Rows are sorted by table, index, and column position, so parts of the same index are consecutive.
+	tableIndexes := make(map[string][]*IndexListEntry, len(tables))
+	var lastIndex *IndexListEntry
+	for _, part := range indexParts {
+		if lastIndex == nil || lastIndex.Table != part.Table || lastIndex.Name != part.IndexName {
+			lastIndex = &IndexListEntry{
+				Table:   part.Table,
+				Name:    part.IndexName,
+				Unique:  part.Unique,
+				Origin:  part.Origin,
+				Partial: part.Partial,
+			}
+			tableIndexes[part.Table] = append(tableIndexes[part.Table], lastIndex)
+		}
+		lastIndex.Parts = append(lastIndex.Parts, part)
+	}
+
+	primaryKeys := make(map[string][]string, len(tables))
+	for _, table := range tables {
+		if err := ctx.Err(); err != nil {
+			return dbSchema, err
+		}
+		columns := tableColumns[table.Name]
+
+		var pkColumns []string
+		for _, c := range columns {
+			if c.PrimaryKey > 0 {
+				pkColumns = append(pkColumns, c.Name)
+			}
+		}
+		isAutoIncrement := len(pkColumns) == 1 && strings.Contains(strings.ToUpper(table.SQL), "AUTOINCREMENT")
+
+		colDefs := ordered.NewMap[string, sqlschema.Column]()
+		for _, c := range columns {
+			sqlType, length, precision, scale := parseType(c.Type)
+			colDefs.Store(c.Name, &Column{
+				Name:             c.Name,
+				SQLType:          sqlType,
+				VarcharLen:       length,
+				NumericPrecision: precision,
+				NumericScale:     scale,
+				DefaultValue:     exprOrLiteral(c.Default),
+				IsNullable:       !c.NotNull,
+				IsAutoIncrement:  isAutoIncrement && c.PrimaryKey > 0,
+			})
+		}
+
+		// Columns are reported in the order of their declaration in the table.
+		// Primary key columns must be ordered by their position in the constraint.
+		var pk *sqlschema.PrimaryKey
+		if len(pkColumns) > 0 {
+			keyColumns := make([]string, len(pkColumns))
+			for _, c := range columns {
+				if c.PrimaryKey > 0 {
+					keyColumns[c.PrimaryKey-1] = c.Name
+				}
+			}
+			primaryKeys[table.Name] = keyColumns
+			pk = &sqlschema.PrimaryKey{Columns: sqlschema.NewColumns(keyColumns...)}
+		}
+
+		var uniques []sqlschema.Unique
+		for _, entry := range tableIndexes[table.Name] {
+			if entry.Origin == originPrimaryKey {
+				continue
+			}
+			parts := entry.Parts
+
+			if entry.Origin == originUnique {
+				var columns []string
+				for _, p := range parts {
+					columns = append(columns, p.Name)
+				}
+				uniques = append(uniques, sqlschema.Unique{
+					Name:    entry.Name,
+					Columns: sqlschema.NewColumns(columns...),
+				})
+				continue
+			}
+
+			exprs, where := parseIndexDefinition(indexSQL[entry.Name])
+			columns := make([]sqlschema.IndexColumn, len(parts))
+			for i, p := range parts {
+				if p.ColumnID == exprColumnID && i < len(exprs) {
+					columns[i] = sqlschema.IndexColumn{Expression: exprs[i]}
+					continue
+				}
+				columns[i] = sqlschema.IndexColumn{Name: p.Name}
+			}
+			idx := sqlschema.Index{
+				Name:      entry.Name,
+				TableName: table.Name,
+				Columns:   columns,
+				Unique:    entry.Unique,
+			}
+			if entry.Partial {
+				idx.Where = where
+			}
+			dbSchema.Indexes = append(dbSchema.Indexes, idx)
+		}
+
+		dbSchema.Tables.Store(table.Name, &Table{
+			Schema:            in.SchemaName,
+			Name:              table.Name,
+			Columns:           colDefs,
+			PrimaryKey:        pk,
+			UniqueConstraints: uniques,
+			Checks:            parseChecks(table.SQL),
+		})
+	}
+
+	for _, fk := range groupForeignKeys(fks) {
+		if filter.Excluded(in.SchemaName, fk.SourceTable) || filter.Excluded(in.SchemaName, fk.TargetTable) {
+			continue
+		}
+		targetColumns := fk.TargetColumns
+
+		// REFERENCES clause may omit the column list, in which case the foreign key references the primary key.
+		if len(targetColumns) == 0 || targetColumns[0] == "" {
+			targetColumns = primaryKeys[fk.TargetTable]
+		}
+
+		dbSchema.ForeignKeys[sqlschema.ForeignKey{
+			From:     sqlschema.NewColumnReference(fk.SourceTable, fk.SourceColumns...),
+			To:       sqlschema.NewColumnReference(fk.TargetTable, targetColumns...),
+			OnDelete: sqlschema.NewReferentialAction(fk.OnDelete),
+			OnUpdate: sqlschema.NewReferentialAction(fk.OnUpdate),
+			Match:    matchType(fk.Match),
+		}.Canonical()] = fk.SourceTable + "_" + strings.Join(fk.SourceColumns, "_") + "_fkey"
+	}
+
+	for _, v := range views {
+		if filter.Excluded(in.SchemaName, v.Name) {
+			continue
+		}
+		dbSchema.Views = append(dbSchema.Views, sqlschema.View{
+			Schema:     in.SchemaName,
+			Name:       v.Name,
+			Definition: viewDefinition(v.SQL),
+		})
+	}
+
+	for _, t := range triggers {
+		if filter.Excluded(in.SchemaName, t.Table) {
+			continue
+		}
+		trigger := parseTriggerDefinition(t.SQL)
+		trigger.Name = t.Name
+		trigger.TableName = t.Table
+		dbSchema.Triggers = append(dbSchema.Triggers, trigger)
+	}
+	return dbSchema, nil
+}
+
+// Fingerprint returns the schema_version of the inspected schema, which SQLite increments on every schema change.
+func (in *Inspector) Fingerprint(ctx context.Context) (string, error) {
+	var version int64
+	if err := in.db.NewRaw("PRAGMA ?.schema_version", bun.Ident(in.SchemaName)).Scan(ctx, &version); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(version, 10), nil
+}
+
+// MasterTable is a table or an index definition stored in sqlite_master.
+type MasterTable struct {
+	Name  string `bun:"name"`
+	Table string `bun:"tbl_name"`
+	SQL   string `bun:"sql"`
+}
+
+// TableColumn is a row returned by the table_info pragma.
+type TableColumn struct {
+	Table   string `bun:"table_name"`
+	Name    string `bun:"name"`
+	Type    string `bun:"type"`
+	NotNull bool   `bun:"not_null"`
+	Default string `bun:"dflt_value"`
+
+	// PrimaryKey is the 1-based position of the column in the primary key, or 0 for other columns.
+	PrimaryKey int `bun:"pk_position"`
+}
+
+const (
+	originPrimaryKey = "pk"
+	originUnique     = "u"
+
+	// exprColumnID is reported by the index_info pragma for index parts which are expressions.
+	exprColumnID = -2
+)
+
+// IndexListEntry is an index returned by the index_list pragma.
+type IndexListEntry struct {
+	Table  string
+	Name   string
+	Unique bool
+
+	// Origin is "c" for indexes created with CREATE INDEX, "u" for UNIQUE constraints
+	// and "pk" for PRIMARY KEY constraints.
+	Origin  string
+	Partial bool
+
+	// Parts are the columns of the index in their order in the index.
+	Parts []*IndexInfo
+}
+
+// IndexInfo is a row returned by the index_info pragma, together with the index_list entry of its index.
+type IndexInfo struct {
+	Table     string `bun:"table_name"`
+	IndexName string `bun:"index_name"`
+	Unique    bool   `bun:"unique"`
+	Origin    string `bun:"origin"`
+	Partial   bool   `bun:"partial"`
+	ColumnID  int    `bun:"cid"`
+	Name      string `bun:"name"`
+}
+
+// ForeignKey is a row returned by the foreign_key_list pragma, which describes one column of the foreign key.
+// groupForeignKeys combines the rows of multi-column foreign keys into a single ForeignKey.
+type ForeignKey struct {
+	ID            int      `bun:"id"`
+	SourceTable   string   `bun:"table_name"`
+	SourceColumns []string `bun:"-"`
+	SourceColumn  string   `bun:"from"`
+	TargetTable   string   `bun:"table"`
+	TargetColumns []string `bun:"-"`
+	TargetColumn  string   `bun:"to"`
+	OnUpdate      string   `bun:"on_update"`
+	OnDelete      string   `bun:"on_delete"`
+	Match         string   `bun:"match"`
+}
+
+// groupForeignKeys combines consecutive rows that belong to the same foreign key.
+// Rows are expected to be sorted by table, id, and seq.
+func groupForeignKeys(rows []*ForeignKey) []*ForeignKey {
+	var fks []*ForeignKey
+	var last *ForeignKey
+	for _, row := range rows {
+		if last == nil || last.SourceTable != row.SourceTable || last.ID != row.ID {
+			last = row
+			fks = append(fks, last)
+		}
+		last.SourceColumns = append(last.SourceColumns, row.SourceColumn)
+		last.TargetColumns = append(last.TargetColumns, row.TargetColumn)
+	}
+	return fks
+}
+
+// matchType converts the MATCH clause reported by SQLite to sqlschema.MatchType.
+// SQLite parses, but does not enforce MATCH clauses, and reports "NONE" when the clause is omitted.
+func matchType(s string) sqlschema.MatchType {
+	if strings.EqualFold(s, "NONE") {
+		return sqlschema.MatchSimple
+	}
+	return sqlschema.NewMatchType(s)
+}
+
+// parseType splits the declared column type, e.g. VARCHAR(255) or DECIMAL(10,2),
+// into the type name and its length or precision and scale.
+func parseType(typ string) (sqlType string, length, precision, scale int) {
+	sqlType = strings.ToLower(strings.TrimSpace(typ))
+	paren := strings.IndexByte(sqlType, '(')
+	if paren == -1 || !strings.HasSuffix(sqlType, ")") {
+		return sqlType, 0, 0, 0
+	}
+
+	args := strings.Split(sqlType[paren+1:len(sqlType)-1], ",")
+	sqlType = strings.TrimSpace(sqlType[:paren])
+
+	n, err := strconv.Atoi(strings.TrimSpace(args[0]))
+	if err != nil {
+		return sqlType, 0, 0, 0
+	}
+	if !isNumericType(sqlType) {
+		return sqlType, n, 0, 0
+	}
+	if len(args) > 1 {
+		scale, _ = strconv.Atoi(strings.TrimSpace(args[1]))
+	}
+	return sqlType, 0, n, scale
+}
+
+func isNumericType(typ string) bool {
+	return typ == "numeric" || typ == "decimal"
+}
+
+// exprOrLiteral trims the quotes around a string literal and lowercases any other expression
+// outside of the literals it contains, so that default values can be compared to the ones declared in bun models.
+func exprOrLiteral(s string) string {
+	if strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") {
+		return strings.Trim(s, "'")
+	}
+	return sqlschema.LowerExpr(s)
+}
+
+// viewDefinition extracts the query from the CREATE VIEW statement, which SQLite stores verbatim.
+// The query follows the first AS keyword outside of quoted identifiers.
+func viewDefinition(sql string) string {
+	if i := keywordIndex(sql, "AS"); i != -1 {
+		return strings.TrimSpace(sql[i+len("AS"):])
+	}
+	return sql
+}
+
+// parseTriggerDefinition reads the timing, the event, and the body of the trigger from its CREATE TRIGGER statement.
+// The body includes the WHEN condition, if any. SQLite only supports FOR EACH ROW triggers, which fire BEFORE
+// the event by default.
+func parseTriggerDefinition(sql string) sqlschema.Trigger {
+	trigger := sqlschema.Trigger{Timing: "BEFORE", ForEachRow: true}
+	on := keywordIndex(sql, "ON")
+	if on == -1 {
+		return trigger
+	}
+
+	// Skip the trigger name, so that a trigger called "after_insert" is not mistaken for an AFTER trigger.
+	words := strings.Fields(strings.ToUpper(sql[:on]))
+	start := slices.Index(words, "TRIGGER") + 2
+	if slices.Contains(words, "EXISTS") {
+		start += 3
+	}
+	for _, w := range words[min(start, len(words)):] {
+		switch w {
+		case "AFTER":
+			trigger.Timing = "AFTER"
+		case "INSTEAD":
+			trigger.Timing = "INSTEAD OF"
+		case "INSERT", "UPDATE", "DELETE":
+			if len(trigger.Events) == 0 {
+				trigger.Events = []string{w}
+			}
+		}
+	}
+
+	rest := sql[on+len("ON"):]
+	body := keywordIndex(rest, "BEGIN")
+	if when := keywordIndex(rest, "WHEN"); when != -1 && (body == -1 || when < body) {
+		body = when
+	}
+	if body != -1 {
+		trigger.Body = strings.TrimSpace(rest[body:])
+	}
+	return trigger
+}
+
+// keywordIndex returns the index of the first occurrence of the keyword outside of quoted identifiers and literals,
+// or -1 if the statement does not contain it. The keyword must be preceded and followed by whitespace.
+func keywordIndex(sql, keyword string) int {
+	var quote byte
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`' || c == '\'':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case i > 0 && unicode.IsSpace(rune(sql[i-1])) && i+len(keyword) < len(sql) &&
+			strings.EqualFold(sql[i:i+len(keyword)], keyword) && unicode.IsSpace(rune(sql[i+len(keyword)])):
+			return i
+		}
+	}
+	return -1
+}
+
+// parseIndexDefinition extracts the indexed expressions and the WHERE predicate of a partial
+// index from its CREATE INDEX statement, as SQLite does not report either of them in the pragmas.
+func parseIndexDefinition(sql string) (exprs []string, where string) {
+	start := strings.IndexByte(sql, '(')
+	if start == -1 {
+		return nil, ""
+	}
+	end := closingParen(sql, start)
+	if end == -1 {
+		return nil, ""
+	}
+
+	for _, part := range splitTopLevel(sql[start+1 : end]) {
+		exprs = append(exprs, trimSortOrder(part))
+	}
+
+	rest := sql[end+1:]
+	if i := strings.Index(strings.ToUpper(rest), "WHERE"); i >= 0 {
+		where = strings.TrimSpace(rest[i+len("WHERE"):])
+	}
+	return exprs, where
+}
+
+// parseChecks extracts CHECK constraints from the CREATE TABLE statement.
+func parseChecks(sql string) []sqlschema.Check {
+	var checks []sqlschema.Check
+	upper := strings.ToUpper(sql)
+	for i := 0; i < len(sql); {
+		at := strings.Index(upper[i:], "CHECK")
+		if at == -1 {
+			break
+		}
+		at += i
+
+		open := strings.IndexByte(sql[at:], '(')
+		if open == -1 || strings.TrimSpace(sql[at+len("CHECK"):at+open]) != "" {
+			i = at + len("CHECK")
+			continue
+		}
+		open += at
+
+		end := closingParen(sql, open)
+		if end == -1 {
+			break
+		}
+
+		check := sqlschema.Check{Expression: strings.TrimSpace(sql[open+1 : end])}
+		if name := constraintName(sql[:at]); name != "" {
+			check.Name = name
+		}
+		checks = append(checks, check)
+		i = end + 1
+	}
+	return checks
+}
+
+// constraintName returns the name in "CONSTRAINT name" clause immediately preceding the constraint definition.
+func constraintName(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) < 2 || !strings.EqualFold(fields[len(fields)-2], "CONSTRAINT") {
+		return ""
+	}
+	return strings.Trim(fields[len(fields)-1], "\"`[]")
+}
+
+// closingParen returns the position of the parenthesis that closes the one at s[open],
+// skipping over string literals and quoted identifiers.
+func closingParen(s string, open int) int {
+	var depth int
+	var quote byte
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitTopLevel splits a comma-separated list, ignoring commas inside parentheses and quotes.
+func splitTopLevel(s string) []string {
+	var parts []string
+	var depth, start int
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// trimSortOrder removes the trailing ASC or DESC keyword from an indexed expression.
+func trimSortOrder(s string) string {
+	upper := strings.ToUpper(s)
+	for _, suffix := range []string{" ASC", " DESC"} {
+		if strings.HasSuffix(upper, suffix) {
+			return strings.TrimSpace(s[:len(s)-len(suffix)])
+		}
+	}
+	return s
+}
+
+const (
+	// sqlInspectTables retrieves all user-defined tables in the selected schema.
+	// Pass bun.Ident(schema), bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
+	sqlInspectTables = `
+SELECT name, sql
+FROM ?.sqlite_master
+WHERE type = 'table'
+	AND name NOT LIKE 'sqlite_%'
+	AND name NOT IN (?)
+ORDER BY name
+`
+
+	// sqlInspectViews retrieves CREATE VIEW statements for all views in the selected schema.
+	// Pass bun.Ident(schema) and bun.In([]string{...}) to exclude views from this inspection.
+	sqlInspectViews = `
+SELECT name, sql
+FROM ?.sqlite_master
+WHERE type = 'view'
+	AND name NOT IN (?)
+ORDER BY name
+`
+
+	// sqlInspectTriggers retrieves CREATE TRIGGER statements for the triggers on the tables in the selected schema.
+	// Pass bun.Ident(schema) and bun.In([]string{...}) to exclude tables from this inspection.
+	sqlInspectTriggers = `
+SELECT name, tbl_name, sql
+FROM ?.sqlite_master
+WHERE type = 'trigger'
+	AND tbl_name NOT IN (?)
+ORDER BY name
+`
+
+	// sqlInspectIndexDefinitions retrieves CREATE INDEX statements for all indexes in the selected schema.
+	// Indexes created implicitly for PRIMARY KEY and UNIQUE constraints do not have one and are not included.
+	sqlInspectIndexDefinitions = `
+SELECT name, sql
+FROM ?.sqlite_master
+WHERE type = 'index'
+	AND sql IS NOT NULL
+`
+
+	// sqlInspectColumns retrieves column definitions for all user-defined tables in the selected schema, ordered by table and cid.
+	// Pass bun.Ident(schema), schema name, and bun.In([]string{...}) to exclude tables from this inspection or bun.In([]string{''}) to include all results.
+	sqlInspectColumns = `
+SELECT m.name AS table_name, p.name, p.type, p."notnull" AS not_null, p.dflt_value, p.pk AS pk_position
+FROM ?.sqlite_master m
+	JOIN pragma_table_info(m.name, ?) p
+WHERE m.type = 'table'
+	AND m.name NOT LIKE 'sqlite_%'
+	AND m.name NOT IN (?)
+ORDER BY m.name, p.cid
+`
+
+	// sqlInspectIndexes retrieves indexes defined on user-defined tables, including the ones created for constraints,
+	// one row per index column in their order in the index.
+	// Pass bun.Ident(schema), schema name twice, and bun.In([]string{...}) to exclude tables from this inspection.
+	sqlInspectIndexes = `
+SELECT m.name AS table_name, il.name AS index_name, il."unique", il.origin, il.partial, ii.cid, ii.name
+FROM ?.sqlite_master m
+	JOIN pragma_index_list(m.name, ?) il
+	JOIN pragma_index_info(il.name, ?) ii
+WHERE m.type = 'table'
+	AND m.name NOT LIKE 'sqlite_%'
+	AND m.name NOT IN (?)
+ORDER BY m.name, il.name, ii.seqno
+`
+
+	// sqlInspectForeignKeys retrieves foreign keys defined on user-defined tables, one row per column.
+	// Pass bun.Ident(schema), schema name, and bun.In([]string{...}) to exclude tables from this inspection.
+	sqlInspectForeignKeys = `
+SELECT m.name AS table_name, fk.id, fk."from", fk."table", fk."to", fk.on_update, fk.on_delete, fk."match"
+FROM ?.sqlite_master m
+	JOIN pragma_foreign_key_list(m.name, ?) fk
+WHERE m.type = 'table'
+	AND m.name NOT LIKE 'sqlite_%'
+	AND m.name NOT IN (?)
+ORDER BY m.name, fk.id, fk.seq
+`
+)
@@ -1,6 +1,9 @@
 package dbtest_test
 
 import (
+	"context"
+	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -8,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/migrate/sqlschema"
 )
 
 type Bench struct {
@@ -70,6 +74,65 @@ func benchmarkSelectError(b *testing.B, db *bun.DB) {
 	})
 }
 
+// BenchmarkDatabaseInspector_Inspect reports the number of queries per inspection,
+// which should not grow with the number of tables in the schema.
+func BenchmarkDatabaseInspector_Inspect(b *testing.B) {
+	for _, numTables := range []int{10, 500} {
+		for name, newDB := range allDBs {
+			b.Run(fmt.Sprintf("%s/tables=%d", name, numTables), func(b *testing.B) {
+				db := newDB(b)
+				inspector, err := sqlschema.NewInspector(db)
+				if err != nil {
+					b.Skip(err)
+				}
+				mustCreateInspectBenchTables(b, db, numTables)
+
+				var queries atomic.Int64
+				db.AddQueryHook(&queryHook{
+					beforeQuery: func(ctx context.Context, _ *bun.QueryEvent) context.Context {
+						queries.Add(1)
+						return ctx
+					},
+				})
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := inspector.Inspect(ctx); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(queries.Load())/float64(b.N), "queries/op")
+			})
+		}
+	}
+}
+
+// mustCreateInspectBenchTables creates a chain of tables, each referencing the previous one.
+func mustCreateInspectBenchTables(b *testing.B, db *bun.DB, n int) {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("bench_inspect_%03d", i)
+	}
+
+	b.Cleanup(func() {
+		for i := len(names) - 1; i >= 0; i-- {
+			_, err := db.NewDropTable().IfExists().TableExpr("?", bun.Ident(names[i])).Exec(ctx)
+			require.NoError(b, err, "must drop table %q", names[i])
+		}
+	})
+
+	for i, name := range names {
+		query := "CREATE TABLE ? (id BIGINT NOT NULL PRIMARY KEY, name VARCHAR(100), parent_id BIGINT)"
+		args := []interface{}{bun.Ident(name)}
+		if i > 0 {
+			query = "CREATE TABLE ? (id BIGINT NOT NULL PRIMARY KEY, name VARCHAR(100), parent_id BIGINT REFERENCES ? (id))"
+			args = append(args, bun.Ident(names[i-1]))
+		}
+		_, err := db.NewRaw(query, args...).Exec(ctx)
+		require.NoError(b, err, "must create table %q", name)
+	}
+}
+
 func benchEachDB(b *testing.B, f func(b *testing.B, db *bun.DB)) {
 	for name, newDB := range allDBs {
 		b.Run(name, func(b *testing.B) {
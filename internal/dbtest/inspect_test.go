@@ -4,11 +4,15 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/dialect/pgdialect"
 	"github.com/uptrace/bun/dialect/sqltype"
 	"github.com/uptrace/bun/internal/ordered"
 	"github.com/uptrace/bun/migrate/sqlschema"
@@ -20,7 +24,7 @@ type Article struct {
 	Editor      string `bun:",notnull,unique:title_author,default:'john doe'"`
 	Title       string `bun:",notnull,unique:title_author"`
 	Locale      string `bun:",type:varchar(5),default:'en-GB'"`
-	Pages       int8   `bun:"page_count,notnull,default:1"`
+	Pages       int8   `bun:"page_count,notnull,default:1,check:page_count > 0"`
 	Count       int32  `bun:"book_count,autoincrement"`
 	PublisherID string `bun:"publisher_id,notnull"`
 	AuthorID    int    `bun:"author_id,notnull"`
@@ -72,6 +76,26 @@ type Journalist struct {
 	Articles []*Article `bun:"rel:has-many,join:author_id=author_id"`
 }
 
+// Subscriber declares a partial index.
+type Subscriber struct {
+	ID        int64 `bun:",pk"`
+	Email     string
+	DeletedAt time.Time
+}
+
+func (*Subscriber) Indexes() []sqlschema.Index {
+	return []sqlschema.Index{
+		{Columns: sqlschema.NewIndexColumns("email"), Unique: true, Where: "deleted_at IS NULL"},
+		{Columns: []sqlschema.IndexColumn{{Expression: "lower(email)"}, {Name: "id"}}},
+		{Name: "subscribers_covering_idx", Columns: sqlschema.NewIndexColumns("id"), Include: []string{"email", "deleted_at"}},
+	}
+}
+
+// Mood is an enumerated type.
+type Mood string
+
+func (Mood) EnumValues() []string { return []string{"sad", "ok", "happy"} }
+
 func TestDatabaseInspector_Inspect(t *testing.T) {
 	testEachDB(t, func(t *testing.T, dbName string, db *bun.DB) {
 		defaultSchema := db.Dialect().DefaultSchema()
@@ -172,6 +196,9 @@ func TestDatabaseInspector_Inspect(t *testing.T) {
 							UniqueConstraints: []sqlschema.Unique{
 								{Columns: sqlschema.NewColumns("editor", "title")},
 							},
+							Checks: []sqlschema.Check{
+								{Expression: "page_count > 0"},
+							},
 						},
 					},
 					ordered.Pair[string, sqlschema.Table]{
@@ -326,8 +353,8 @@ func TestDatabaseInspector_Inspect(t *testing.T) {
 				),
 				wantFKs: []sqlschema.ForeignKey{
 					{
-						From: sqlschema.NewColumnReference("offices", "publisher_name", "publisher_id"),
-						To:   sqlschema.NewColumnReference("publishers", "publisher_name", "publisher_id"),
+						From: sqlschema.NewColumnReference("offices", "publisher_id", "publisher_name"),
+						To:   sqlschema.NewColumnReference("publishers", "publisher_id", "publisher_name"),
 					},
 				},
 			},
@@ -366,8 +393,301 @@ func TestDatabaseInspector_Inspect(t *testing.T) {
 					fks = append(fks, fk)
 				}
 				require.ElementsMatch(t, tt.wantFKs, fks, "foreign keys differ: expected=listA, got=listB")
+
+				// Snapshot of the database schema should be lossless.
+				b, err := sqlschema.MarshalDatabase(got)
+				require.NoError(t, err)
+				restored, err := sqlschema.UnmarshalDatabase(b)
+				require.NoError(t, err)
+				require.Equal(t, got, restored)
+			})
+		}
+	})
+}
+
+func TestDatabaseInspector_FilterTables(t *testing.T) {
+	type TempUpload struct {
+		bun.BaseModel `bun:"table:temp_uploads"`
+		ID            int64 `bun:",pk"`
+	}
+	type DjangoSession struct {
+		bun.BaseModel `bun:"table:django_session"`
+		ID            int64 `bun:",pk"`
+	}
+	type AuditLog struct {
+		bun.BaseModel `bun:"table:audit_log"`
+		ID            int64 `bun:",pk"`
+	}
+	type Document struct {
+		bun.BaseModel `bun:"table:documents"`
+		ID            int64       `bun:",pk"`
+		UploadID      int64       `bun:"upload_id"`
+		Upload        *TempUpload `bun:"rel:belongs-to,join:upload_id=id"`
+	}
+
+	testEachDB(t, func(t *testing.T, dbName string, db *bun.DB) {
+		ctx := context.Background()
+		defaultSchema := db.Dialect().DefaultSchema()
+		mustCreateTableWithFKs(t, ctx, db,
+			(*TempUpload)(nil),
+			(*DjangoSession)(nil),
+			(*AuditLog)(nil),
+			(*Document)(nil), // references TempUpload
+		)
+
+		t.Run("matches globs, regexps and qualified names", func(t *testing.T) {
+			dbInspector, err := sqlschema.NewInspector(db,
+				sqlschema.WithSchemaName(defaultSchema),
+				sqlschema.WithExcludeTables(migrationsTable, migrationLocksTable),
+				sqlschema.WithExcludeTables("temp_*", "re:^django_", defaultSchema+".audit_*"),
+			)
+			if err != nil {
+				t.Skip(err)
+			}
+
+			got, err := dbInspector.Inspect(ctx)
+			require.NoError(t, err)
+			require.Equal(t, []string{"documents"}, got.GetTables().Keys())
+			require.Empty(t, got.GetForeignKeys(), "foreign keys to excluded tables must be skipped")
+		})
+
+		t.Run("inspects included tables only", func(t *testing.T) {
+			dbInspector, err := sqlschema.NewInspector(db,
+				sqlschema.WithSchemaName(defaultSchema),
+				sqlschema.WithIncludeTables("documents", "re:^(temp|audit)_"),
+				sqlschema.WithExcludeTables("audit_log"),
+			)
+			if err != nil {
+				t.Skip(err)
+			}
+
+			got, err := dbInspector.Inspect(ctx)
+			require.NoError(t, err)
+			require.ElementsMatch(t, []string{"documents", "temp_uploads"}, got.GetTables().Keys(), "exclude should win over include")
+			require.Len(t, got.GetForeignKeys(), 1)
+		})
+
+		t.Run("skips excluded schemas", func(t *testing.T) {
+			schemas := []string{strings.ToUpper(defaultSchema)}
+			if dbName == pgName {
+				schemas = append(schemas, "information_schema")
+			}
+			for _, schemaName := range schemas {
+				dbInspector, err := sqlschema.NewInspector(db,
+					sqlschema.WithSchemaName(schemaName),
+					sqlschema.WithExcludeSchemas(defaultSchema), // system schemas are excluded by default
+				)
+				if err != nil {
+					t.Skip(err)
+				}
+
+				got, err := dbInspector.Inspect(ctx)
+				require.NoError(t, err)
+				require.Equal(t, 0, got.GetTables().Len(), "inspected tables in %q", schemaName)
+			}
+		})
+
+		t.Run("reports invalid patterns", func(t *testing.T) {
+			dbInspector, err := sqlschema.NewInspector(db,
+				sqlschema.WithSchemaName(defaultSchema),
+				sqlschema.WithExcludeTables("re:(temp"),
+			)
+			if err != nil {
+				t.Skip(err)
+			}
+
+			_, err = dbInspector.Inspect(ctx)
+			require.ErrorContains(t, err, `invalid pattern "re:(temp"`)
+		})
+	})
+}
+
+func TestDatabaseInspector_Concurrency(t *testing.T) {
+	type Shelf struct {
+		bun.BaseModel `bun:"table:shelves"`
+		ID            int64  `bun:",pk"`
+		Label         string `bun:",unique"`
+	}
+	type Volume struct {
+		bun.BaseModel `bun:"table:volumes"`
+		ID            int64 `bun:",pk"`
+		Title         string
+		ShelfID       int64  `bun:"shelf_id"`
+		Shelf         *Shelf `bun:"rel:belongs-to,join:shelf_id=id"`
+	}
+
+	testEachDB(t, func(t *testing.T, dbName string, db *bun.DB) {
+		ctx := context.Background()
+		mustCreateTableWithFKs(t, ctx, db, (*Shelf)(nil), (*Volume)(nil))
+		_, err := db.NewCreateIndex().Model((*Volume)(nil)).Index("volumes_title_idx").Column("title").Exec(ctx)
+		require.NoError(t, err)
+
+		inspect := func(t *testing.T, ctx context.Context, concurrency int) (sqlschema.Database, error) {
+			dbInspector, err := sqlschema.NewInspector(db,
+				sqlschema.WithSchemaName(db.Dialect().DefaultSchema()),
+				sqlschema.WithExcludeTables(migrationsTable, migrationLocksTable),
+				sqlschema.WithConcurrency(concurrency),
+			)
+			if err != nil {
+				t.Skip(err)
+			}
+			return dbInspector.Inspect(ctx)
+		}
+
+		t.Run("produces the same state as sequential inspection", func(t *testing.T) {
+			want, err := inspect(t, ctx, 0)
+			require.NoError(t, err)
+
+			for i := 0; i < 5; i++ {
+				got, err := inspect(t, ctx, 4)
+				require.NoError(t, err)
+				require.Equal(t, want, got)
+			}
+		})
+
+		t.Run("stops when the context is cancelled", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(ctx)
+			cancel()
+
+			_, err := inspect(t, ctx, 4)
+			require.ErrorIs(t, err, context.Canceled)
+		})
+
+		t.Run("stops between sequential queries", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			var queries int
+			hooked := bun.NewDB(db.DB, db.Dialect())
+			hooked.AddQueryHook(&queryHook{
+				beforeQuery: func(ctx context.Context, _ *bun.QueryEvent) context.Context {
+					queries++
+					return ctx
+				},
+				afterQuery: func(context.Context, *bun.QueryEvent) {
+					cancel() // as if the timeout expired during the first query
+				},
 			})
+			dbInspector, err := sqlschema.NewInspector(hooked,
+				sqlschema.WithSchemaName(db.Dialect().DefaultSchema()),
+				sqlschema.WithExcludeTables(migrationsTable, migrationLocksTable),
+			)
+			if err != nil {
+				t.Skip(err)
+			}
+
+			start := time.Now()
+			_, err = dbInspector.Inspect(ctx)
+			require.ErrorIs(t, err, context.Canceled)
+			require.Equal(t, 1, queries, "no queries must be started after the context is cancelled")
+			require.Less(t, time.Since(start), 5*time.Second)
+		})
+	})
+}
+
+func TestDatabaseInspector_SerialAndIdentity(t *testing.T) {
+	db := pg(t)
+	t.Cleanup(func() { db.Close() })
+
+	for _, query := range []string{
+		`DROP TABLE IF EXISTS tickets`,
+		`DROP SEQUENCE IF EXISTS ticket_numbers`,
+		`CREATE SEQUENCE ticket_numbers`,
+		`CREATE TABLE tickets (
+			id bigserial PRIMARY KEY,
+			seat bigint GENERATED BY DEFAULT AS IDENTITY (START WITH 100),
+			number bigint NOT NULL DEFAULT nextval('ticket_numbers')
+		)`,
+	} {
+		_, err := db.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+	t.Cleanup(func() {
+		_, _ = db.ExecContext(ctx, `DROP TABLE IF EXISTS tickets`)
+		_, _ = db.ExecContext(ctx, `DROP SEQUENCE IF EXISTS ticket_numbers`)
+	})
+
+	dbInspector, err := sqlschema.NewInspector(db,
+		sqlschema.WithSchemaName(db.Dialect().DefaultSchema()),
+		sqlschema.WithIncludeTables("tickets"),
+	)
+	require.NoError(t, err)
+	got, err := dbInspector.Inspect(ctx)
+	require.NoError(t, err)
+
+	columns := got.GetTables().Value("tickets").GetColumns()
+	id := columns.Value("id")
+	require.True(t, id.GetIsAutoIncrement(), "serial column")
+	require.False(t, id.GetIsIdentity())
+	require.Empty(t, id.GetDefaultValue(), "the sequence of a serial column is not a default")
+	require.Equal(t, "tickets_id_seq", id.GetSequence())
+
+	seat := columns.Value("seat")
+	require.True(t, seat.GetIsIdentity(), "identity column")
+	require.False(t, seat.GetIsAutoIncrement())
+	require.Equal(t, int64(100), seat.GetIdentityOptions().Start)
+	require.Equal(t, "tickets_seat_seq", seat.GetSequence())
+
+	// The column does not own the sequence, so nextval() is a regular default.
+	number := columns.Value("number")
+	require.False(t, number.GetIsAutoIncrement())
+	require.False(t, number.GetIsIdentity())
+	require.Equal(t, "nextval('ticket_numbers'::regclass)", number.GetDefaultValue())
+}
+
+func TestCachingInspector(t *testing.T) {
+	type Gadget struct {
+		bun.BaseModel `bun:"table:gadgets"`
+		ID            int64 `bun:",pk"`
+		Name          string
+	}
+
+	testEachDB(t, func(t *testing.T, dbName string, db *bun.DB) {
+		ctx := context.Background()
+		mustCreateTableWithFKs(t, ctx, db, (*Gadget)(nil))
+
+		dbInspector, err := sqlschema.NewInspector(db,
+			sqlschema.WithSchemaName(db.Dialect().DefaultSchema()),
+			sqlschema.WithExcludeTables(migrationsTable, migrationLocksTable),
+		)
+		if err != nil {
+			t.Skip(err)
 		}
+		cached, err := sqlschema.NewCachingInspector(dbInspector)
+		if err != nil {
+			t.Skip(err)
+		}
+
+		var queries atomic.Int64
+		db.AddQueryHook(&queryHook{
+			beforeQuery: func(ctx context.Context, _ *bun.QueryEvent) context.Context {
+				queries.Add(1)
+				return ctx
+			},
+		})
+
+		want, err := cached.Inspect(ctx)
+		require.NoError(t, err)
+
+		queries.Store(0)
+		got, err := cached.Inspect(ctx)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+		require.EqualValues(t, 1, queries.Load(), "only the fingerprint must be queried for an unchanged schema")
+
+		// Modifying the returned state must not affect the cache.
+		got.GetTables().Delete("gadgets")
+		got, err = cached.Inspect(ctx)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+
+		_, err = db.NewAddColumn().Model((*Gadget)(nil)).ColumnExpr("price INTEGER").Exec(ctx)
+		require.NoError(t, err)
+
+		got, err = cached.Inspect(ctx)
+		require.NoError(t, err)
+		_, ok := got.GetTables().Value("gadgets").GetColumns().Load("price")
+		require.True(t, ok, "schema change must invalidate the cache")
 	})
 }
 
@@ -383,6 +703,9 @@ func mustCreateTableWithFKs(tb testing.TB, ctx context.Context, db *bun.DB, mode
 
 func mustCreateSchema(tb testing.TB, ctx context.Context, db *bun.DB, schema string) {
 	tb.Helper()
+	if db.Dialect().Name() == dialect.SQLite {
+		tb.Skip("sqlite does not support CREATE SCHEMA")
+	}
 	_, err := db.NewRaw("CREATE SCHEMA IF NOT EXISTS ?", bun.Ident(schema)).Exec(ctx)
 	require.NoError(tb, err, "create schema %q:", schema)
 
@@ -393,6 +716,27 @@ func mustCreateSchema(tb testing.TB, ctx context.Context, db *bun.DB, schema str
 
 // cmpTables compares table schemas using dialect-specific equivalence checks for column types
 // and reports the differences as t.Error().
+func TestCheck_Equals(t *testing.T) {
+	check := func(expr string) sqlschema.Check { return sqlschema.Check{Expression: expr} }
+
+	t.Run("redundant parentheses are ignored", func(t *testing.T) {
+		require.True(t, check("(a > 0 OR b > 0) AND c > 0").Equals(check("(((a > 0) OR (b > 0)) AND (c > 0))")))
+		require.True(t, check("lower(email) <> ''").Equals(check("(lower((email)) <> '')")))
+	})
+
+	t.Run("regrouped expressions are different", func(t *testing.T) {
+		require.False(t, check("(a OR b) AND c").Equals(check("a OR (b AND c)")))
+		require.False(t, check("(a + b) * c").Equals(check("a + b * c")))
+		require.False(t, check("NOT (a AND b)").Equals(check("NOT a AND b")))
+	})
+
+	t.Run("whitespace is collapsed", func(t *testing.T) {
+		require.True(t, check("a>0  AND\n\tb > 0").Equals(check("((a > 0) AND (b > 0))")))
+		require.False(t, check("col a").Equals(check("cola")))
+		require.False(t, check("status = 'a  b'").Equals(check("status = 'a b'")))
+	})
+}
+
 func cmpTables(
 	tb testing.TB,
 	d sqlschema.InspectorDialect,
@@ -454,6 +798,19 @@ func cmpColumns(
 		if wantCol.IsIdentity != gotCol.IsIdentity {
 			errorf("IsIdentity:\n\t(+want)\t%t\n\t(-got)\t%t", wantCol.IsIdentity, gotCol.IsIdentity)
 		}
+
+		if wantCol.Collation != gotCol.Collation {
+			errorf("collations differ:\n\t(+want)\t%s\n\t(-got)\t%s", wantCol.Collation, gotCol.Collation)
+		}
+
+		if wantCol.GeneratedExpr != gotCol.GeneratedExpr || wantCol.GeneratedStored != gotCol.GeneratedStored {
+			errorf("generated expressions differ:\n\t(+want)\t%s (stored=%t)\n\t(-got)\t%s (stored=%t)",
+				wantCol.GeneratedExpr, wantCol.GeneratedStored, gotCol.GeneratedExpr, gotCol.GeneratedStored)
+		}
+
+		if wantCol.Comment != gotCol.Comment {
+			errorf("comments differ:\n\t(+want)\t%s\n\t(-got)\t%s", wantCol.Comment, gotCol.Comment)
+		}
 	}
 
 	if len(missing) > 0 {
@@ -495,6 +852,17 @@ func cmpConstraints(tb testing.TB, want, got *sqlschema.BaseTable) {
 		return
 	}
 	require.ElementsMatch(tb, stripNames(want.UniqueConstraints), stripNames(got.UniqueConstraints), "table %q does not have expected unique constraints (listA=want, listB=got)", want.Name)
+
+	require.Lenf(tb, got.Checks, len(want.Checks), "table %q has wrong number of check constraints", want.Name)
+Checks:
+	for _, wantCheck := range want.Checks {
+		for _, gotCheck := range got.Checks {
+			if wantCheck.Equals(gotCheck) {
+				continue Checks
+			}
+		}
+		tb.Errorf("table %q missing check constraint (%s)", want.Name, wantCheck.Expression)
+	}
 }
 
 func tableNames(tables *ordered.Map[string, sqlschema.Table]) []string {
@@ -502,6 +870,9 @@ func tableNames(tables *ordered.Map[string, sqlschema.Table]) []string {
 }
 
 func formatType(c sqlschema.Column) string {
+	if c.GetNumericPrecision() != 0 {
+		return fmt.Sprintf("%s(%d,%d)", c.GetSQLType(), c.GetNumericPrecision(), c.GetNumericScale())
+	}
 	if c.GetVarcharLen() == 0 {
 		return c.GetSQLType()
 	}
@@ -557,6 +928,7 @@ func TestBunModelInspector_Inspect(t *testing.T) {
 				ID        string `bun:",notnull,type:text"`
 				FirstName string `bun:",notnull,type:character varying(60)"`
 				LastName  string `bun:",notnull,type:varchar(100)"`
+				Bio       string `bun:",notnull,type:nvarchar(max)"`
 			}
 
 			tables := schema.NewTables(dialect)
@@ -584,6 +956,13 @@ func TestBunModelInspector_Inspect(t *testing.T) {
 						VarcharLen: 100,
 					},
 				},
+				ordered.Pair[string, sqlschema.Column]{
+					Key: "bio",
+					Value: &sqlschema.BaseColumn{
+						SQLType:    "nvarchar",
+						VarcharLen: sqlschema.VarcharLenMax,
+					},
+				},
 			)
 
 			got, err := inspector.Inspect(context.Background())
@@ -596,46 +975,147 @@ func TestBunModelInspector_Inspect(t *testing.T) {
 			}
 		})
 
-		t.Run("inspect unique constraints", func(t *testing.T) {
+		t.Run("parses fractional seconds precision", func(t *testing.T) {
 			type Model struct {
-				ID        string `bun:",unique"`
-				FirstName string `bun:"first_name,unique:full_name"`
-				LastName  string `bun:"last_name,unique:full_name"`
+				CreatedAt time.Time `bun:",notnull,type:timestamptz(3)"`
+				UpdatedAt time.Time `bun:",notnull,type:timestamp(1) with time zone"`
 			}
 
 			tables := schema.NewTables(dialect)
 			tables.Register((*Model)(nil))
 			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
 
-			want := &sqlschema.BaseTable{
-				Name: "models",
-				UniqueConstraints: []sqlschema.Unique{
-					{Columns: sqlschema.NewColumns("id")},
-					{Name: "full_name", Columns: sqlschema.NewColumns("first_name", "last_name")},
+			want := ordered.NewMap[string, sqlschema.Column](
+				ordered.Pair[string, sqlschema.Column]{
+					Key: "created_at",
+					Value: &sqlschema.BaseColumn{
+						SQLType:    "timestamptz",
+						VarcharLen: 3,
+					},
+				},
+				ordered.Pair[string, sqlschema.Column]{
+					Key: "updated_at",
+					Value: &sqlschema.BaseColumn{
+						SQLType:    "timestamp with time zone",
+						VarcharLen: 1,
+					},
 				},
+			)
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			gotTables := got.GetTables()
+			require.Equal(t, 1, gotTables.Len())
+			for _, table := range gotTables.Values() {
+				cmpColumns(t, dialect.(sqlschema.InspectorDialect), "model", want, table.GetColumns())
+			}
+		})
+
+		t.Run("reads column comments", func(t *testing.T) {
+			type Model struct {
+				ID   string `bun:",comment:Unique identifier"`
+				Name string `bun:",comment:\"Display name, as shown to users\""`
 			}
 
+			tables := schema.NewTables(dialect)
+			tables.Register((*Model)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			want := ordered.NewMap[string, sqlschema.Column](
+				ordered.Pair[string, sqlschema.Column]{
+					Key: "id",
+					Value: &sqlschema.BaseColumn{
+						SQLType:    "varchar",
+						IsNullable: true,
+						Comment:    "Unique identifier",
+					},
+				},
+				ordered.Pair[string, sqlschema.Column]{
+					Key: "name",
+					Value: &sqlschema.BaseColumn{
+						SQLType:    "varchar",
+						IsNullable: true,
+						Comment:    "Display name, as shown to users",
+					},
+				},
+			)
+
 			got, err := inspector.Inspect(context.Background())
 			require.NoError(t, err)
 
 			gotTables := got.GetTables()
 			require.Equal(t, 1, gotTables.Len())
 			for _, table := range gotTables.Values() {
-				cmpConstraints(t, want, &table.(*sqlschema.BunTable).BaseTable)
-				return
+				cmpColumns(t, dialect.(sqlschema.InspectorDialect), "model", want, table.GetColumns())
 			}
 		})
-		t.Run("collects primary keys", func(t *testing.T) {
+
+		t.Run("reads table comment", func(t *testing.T) {
 			type Model struct {
-				ID       string    `bun:",pk"`
-				Email    string    `bun:",pk"`
-				Birthday time.Time `bun:",notnull"`
+				bun.BaseModel `bun:"table:models,comment:\"Models, as registered by users\""`
+				ID            string
 			}
 
 			tables := schema.NewTables(dialect)
 			tables.Register((*Model)(nil))
 			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
-			want := sqlschema.NewColumns("id", "email")
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, "Models, as registered by users", got.GetTables().Value("models").GetComment())
+		})
+
+		t.Run("reads identity options", func(t *testing.T) {
+			type Model struct {
+				ID      int64 `bun:",pk,identity:always,identity_start:100,identity_increment:2"`
+				Counter int64 `bun:",identity"`
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*Model)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+			columns := got.GetTables().Value("models").GetColumns()
+			require.Equal(t, sqlschema.IdentityOptions{
+				Generation: sqlschema.IdentityAlways,
+				Start:      100,
+				Increment:  2,
+			}, columns.Value("id").GetIdentityOptions())
+			require.Equal(t, sqlschema.IdentityOptions{
+				Generation: sqlschema.IdentityByDefault,
+			}, columns.Value("counter").GetIdentityOptions())
+		})
+
+		t.Run("reads column collation", func(t *testing.T) {
+			type Model struct {
+				Name     string `bun:",collate:en_US"`
+				Nickname string `bun:",collate:default"`
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*Model)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			want := ordered.NewMap[string, sqlschema.Column](
+				ordered.Pair[string, sqlschema.Column]{
+					Key: "name",
+					Value: &sqlschema.BaseColumn{
+						SQLType:    "varchar",
+						IsNullable: true,
+						Collation:  "en_US",
+					},
+				},
+				ordered.Pair[string, sqlschema.Column]{
+					Key: "nickname",
+					Value: &sqlschema.BaseColumn{
+						SQLType:    "varchar",
+						IsNullable: true,
+					},
+				},
+			)
 
 			got, err := inspector.Inspect(context.Background())
 			require.NoError(t, err)
@@ -643,21 +1123,20 @@ func TestBunModelInspector_Inspect(t *testing.T) {
 			gotTables := got.GetTables()
 			require.Equal(t, 1, gotTables.Len())
 			for _, table := range gotTables.Values() {
-				pk := table.GetPrimaryKey()
-				require.NotNilf(t, pk, "did not register primary key, want (%s)", want)
-				require.Equal(t, want, pk.Columns, "wrong primary key columns")
-				return
+				cmpColumns(t, dialect.(sqlschema.InspectorDialect), "model", want, table.GetColumns())
 			}
 		})
 
-		t.Run("separates schema and table name", func(t *testing.T) {
+		t.Run("parses numeric precision and scale", func(t *testing.T) {
 			type Model struct {
-				bun.BaseModel `bun:"table:custom_schema.model"`
+				Price    float64 `bun:",type:decimal(10,2)"`
+				Quantity int64   `bun:",type:numeric(12)"`
+				Ratio    float64 `bun:",type:numeric"`
 			}
 
 			tables := schema.NewTables(dialect)
 			tables.Register((*Model)(nil))
-			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName("custom_schema"))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
 
 			got, err := inspector.Inspect(context.Background())
 			require.NoError(t, err)
@@ -665,24 +1144,39 @@ func TestBunModelInspector_Inspect(t *testing.T) {
 			gotTables := got.GetTables()
 			require.Equal(t, 1, gotTables.Len())
 			for _, table := range gotTables.Values() {
-				require.Equal(t, "custom_schema", table.GetSchema(), "wrong schema name")
-				require.Equal(t, "model", table.GetName(), "wrong table name")
-				return
+				columns := table.GetColumns()
+
+				price := columns.Value("price")
+				require.Equal(t, "decimal", price.GetSQLType())
+				require.Equal(t, 10, price.GetNumericPrecision())
+				require.Equal(t, 2, price.GetNumericScale())
+
+				quantity := columns.Value("quantity")
+				require.Equal(t, 12, quantity.GetNumericPrecision())
+				require.Equal(t, 0, quantity.GetNumericScale())
+
+				ratio := columns.Value("ratio")
+				require.Equal(t, 0, ratio.GetNumericPrecision())
+				require.Equal(t, 0, ratio.GetVarcharLen())
 			}
 		})
 
-		t.Run("filters tables by schema", func(t *testing.T) {
-			type KeepMe struct {
-				bun.BaseModel `bun:"table:want.keep_me"`
+		t.Run("parses array types", func(t *testing.T) {
+			if dialectName != pgName {
+				t.Skip(dialectName + " does not support arrays")
 			}
 
-			type LoseMe struct {
-				bun.BaseModel `bun:"table:lose_me"`
+			type Model struct {
+				Tags    []string  `bun:",array"`
+				Matrix  [][]int64 `bun:",type:bigint[][]"`
+				Labels  []string  `bun:",type:varchar(20)[]"`
+				Vector  []float64 `bun:",type:numeric(5, 2)[3]"`
+				Comment string
 			}
 
 			tables := schema.NewTables(dialect)
-			tables.Register((*KeepMe)(nil), (*LoseMe)(nil))
-			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName("want"))
+			tables.Register((*Model)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
 
 			got, err := inspector.Inspect(context.Background())
 			require.NoError(t, err)
@@ -690,10 +1184,1180 @@ func TestBunModelInspector_Inspect(t *testing.T) {
 			gotTables := got.GetTables()
 			require.Equal(t, 1, gotTables.Len())
 			for _, table := range gotTables.Values() {
-				require.Equal(t, "want", table.GetSchema(), "wrong schema name")
-				require.Equal(t, "keep_me", table.GetName(), "wrong table name")
-				return
+				columns := table.GetColumns()
+
+				require.Equal(t, "varchar", columns.Value("tags").GetSQLType())
+				require.Equal(t, 1, columns.Value("tags").GetArrayDims())
+
+				require.Equal(t, "bigint", columns.Value("matrix").GetSQLType())
+				require.Equal(t, 2, columns.Value("matrix").GetArrayDims())
+
+				require.Equal(t, 20, columns.Value("labels").GetVarcharLen())
+				require.Equal(t, 1, columns.Value("labels").GetArrayDims())
+
+				require.Equal(t, 5, columns.Value("vector").GetNumericPrecision())
+				require.Equal(t, 2, columns.Value("vector").GetNumericScale())
+				require.Equal(t, 1, columns.Value("vector").GetArrayDims())
+
+				require.Equal(t, 0, columns.Value("comment").GetArrayDims())
 			}
 		})
+
+		t.Run("reads referential actions", func(t *testing.T) {
+			type Author struct {
+				ID int64 `bun:",pk"`
+			}
+			type Book struct {
+				ID       int64   `bun:",pk"`
+				AuthorID int64   `bun:"author_id"`
+				EditorID int64   `bun:"editor_id"`
+				Author   *Author `bun:"rel:belongs-to,join:author_id=id,on_delete:cascade,on_update:set null"`
+				Editor   *Author `bun:"rel:belongs-to,join:editor_id=id"`
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*Author)(nil), (*Book)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			require.Contains(t, got.GetForeignKeys(), sqlschema.ForeignKey{
+				From:     sqlschema.NewColumnReference("books", "author_id"),
+				To:       sqlschema.NewColumnReference("authors", "id"),
+				OnDelete: sqlschema.Cascade,
+				OnUpdate: sqlschema.SetNull,
+			})
+			require.Contains(t, got.GetForeignKeys(), sqlschema.ForeignKey{
+				From: sqlschema.NewColumnReference("books", "editor_id"),
+				To:   sqlschema.NewColumnReference("authors", "id"),
+			}, "NO ACTION is the default")
+		})
+
+		t.Run("reads deferrable foreign keys", func(t *testing.T) {
+			type Node struct {
+				ID       int64 `bun:",pk"`
+				ParentID int64 `bun:"parent_id"`
+				NextID   int64 `bun:"next_id"`
+				Parent   *Node `bun:"rel:belongs-to,join:parent_id=id,deferrable"`
+				Next     *Node `bun:"rel:belongs-to,join:next_id=id,initially_deferred"`
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*Node)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			require.Contains(t, got.GetForeignKeys(), sqlschema.ForeignKey{
+				From:       sqlschema.NewColumnReference("nodes", "parent_id"),
+				To:         sqlschema.NewColumnReference("nodes", "id"),
+				Deferrable: true,
+			})
+			require.Contains(t, got.GetForeignKeys(), sqlschema.ForeignKey{
+				From:              sqlschema.NewColumnReference("nodes", "next_id"),
+				To:                sqlschema.NewColumnReference("nodes", "id"),
+				Deferrable:        true,
+				InitiallyDeferred: true,
+			}, "initially deferred constraints are always deferrable")
+		})
+
+		t.Run("reads foreign key match type", func(t *testing.T) {
+			type Office struct {
+				Building string `bun:",pk"`
+				Room     string `bun:",pk"`
+			}
+			type Employee struct {
+				ID       int64   `bun:",pk"`
+				Building string  `bun:"building"`
+				Room     string  `bun:"room"`
+				Office   *Office `bun:"rel:belongs-to,join:building=building,join:room=room,match:full"`
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*Office)(nil), (*Employee)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			require.Contains(t, got.GetForeignKeys(), sqlschema.ForeignKey{
+				From:  sqlschema.NewColumnReference("employees", "building", "room"),
+				To:    sqlschema.NewColumnReference("offices", "building", "room"),
+				Match: sqlschema.MatchFull,
+			})
+		})
+
+		t.Run("aligns composite foreign key columns", func(t *testing.T) {
+			type Office struct {
+				Building string `bun:",pk"`
+				Room     string `bun:",pk"`
+			}
+			type Employee struct {
+				ID       int64   `bun:",pk"`
+				Building string  `bun:"office_building"`
+				Room     string  `bun:"office_room"`
+				Office   *Office `bun:"rel:belongs-to,join:office_room=room,join:office_building=building"`
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*Office)(nil), (*Employee)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			reordered := sqlschema.ForeignKey{
+				From: sqlschema.NewColumnReference("employees", "office_room", "office_building"),
+				To:   sqlschema.NewColumnReference("offices", "room", "building"),
+			}
+			require.Contains(t, got.GetForeignKeys(), reordered.Canonical())
+			require.Contains(t, got.GetForeignKeys(), sqlschema.ForeignKey{
+				From: sqlschema.NewColumnReference("employees", "office_building", "office_room"),
+				To:   sqlschema.NewColumnReference("offices", "building", "room"),
+			})
+		})
+
+		t.Run("names foreign keys like the dialect", func(t *testing.T) {
+			type Office struct {
+				Building string `bun:",pk"`
+				Room     string `bun:",pk"`
+			}
+			type Employee struct {
+				ID       int64   `bun:",pk"`
+				Building string  `bun:"building"`
+				Room     string  `bun:"room"`
+				Office   *Office `bun:"rel:belongs-to,join:building=building,join:room=room"`
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*Office)(nil), (*Employee)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			fk := sqlschema.ForeignKey{
+				From: sqlschema.NewColumnReference("employees", "building", "room"),
+				To:   sqlschema.NewColumnReference("offices", "building", "room"),
+			}
+			require.Contains(t, got.GetForeignKeys(), fk)
+			require.Equal(t, "employees_building_room_fkey", got.GetForeignKeys()[fk])
+		})
+
+		t.Run("collects indexes", func(t *testing.T) {
+			type Model struct {
+				ID        string `bun:",pk"`
+				Email     string `bun:",unique_index"`
+				LastName  string `bun:"last_name,index:full_name"`
+				FirstName string `bun:"first_name,index:full_name"`
+				CreatedAt string `bun:"created_at,index"`
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*Model)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			require.Equal(t, []sqlschema.Index{
+				{Name: "models_email_idx", TableName: "models", Columns: sqlschema.NewIndexColumns("email"), Unique: true},
+				{Name: "full_name", TableName: "models", Columns: sqlschema.NewIndexColumns("last_name", "first_name")},
+				{Name: "models_created_at_idx", TableName: "models", Columns: sqlschema.NewIndexColumns("created_at")},
+			}, got.GetIndexes())
+		})
+
+		t.Run("collects partial indexes", func(t *testing.T) {
+			tables := schema.NewTables(dialect)
+			tables.Register((*Subscriber)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			want := sqlschema.Index{
+				Name:      "subscribers_email_idx",
+				TableName: "subscribers",
+				Columns:   sqlschema.NewIndexColumns("email"),
+				Unique:    true,
+				Where:     "deleted_at IS NULL",
+			}
+			require.Equal(t, want, got.GetIndexes()[0])
+
+			// Predicates are compared regardless of how the database formatted them.
+			inspected := want
+			inspected.Where = "(deleted_at is null)"
+			require.True(t, want.Equals(inspected), "same predicate")
+
+			inspected.Where = "deleted_at IS NOT NULL"
+			require.False(t, want.Equals(inspected), "different predicate")
+		})
+
+		t.Run("collects expression indexes", func(t *testing.T) {
+			tables := schema.NewTables(dialect)
+			tables.Register((*Subscriber)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			indexes := got.GetIndexes()
+			require.Len(t, indexes, 3)
+
+			want := sqlschema.Index{
+				Name:      "subscribers_expr_id_idx",
+				TableName: "subscribers",
+				Columns:   []sqlschema.IndexColumn{{Expression: "lower(email)"}, {Name: "id"}},
+			}
+			require.Equal(t, want, indexes[1])
+
+			// Databases may store expressions with additional casts and parentheses.
+			inspected := want
+			inspected.Columns = []sqlschema.IndexColumn{{Expression: "lower((email)::text)"}, {Name: "id"}}
+			require.True(t, want.Equals(inspected), "same expression")
+
+			inspected.Columns = []sqlschema.IndexColumn{{Expression: "upper((email)::text)"}, {Name: "id"}}
+			require.False(t, want.Equals(inspected), "different expression")
+		})
+
+		t.Run("separates unique indexes from unique constraints", func(t *testing.T) {
+			type Model struct {
+				Email    string `bun:",unique"`
+				Nickname string `bun:",unique_index"`
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*Model)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			require.Equal(t, []sqlschema.Index{
+				{Name: "models_nickname_idx", TableName: "models", Columns: sqlschema.NewIndexColumns("nickname"), Unique: true},
+			}, got.GetIndexes())
+
+			for _, table := range got.GetTables().Values() {
+				require.Equal(t, []sqlschema.Unique{{Columns: sqlschema.NewColumns("email")}}, table.GetUniqueConstraints())
+			}
+		})
+
+		t.Run("collects covering indexes", func(t *testing.T) {
+			tables := schema.NewTables(dialect)
+			tables.Register((*Subscriber)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			indexes := got.GetIndexes()
+			require.Len(t, indexes, 3)
+
+			want := sqlschema.Index{
+				Name:      "subscribers_covering_idx",
+				TableName: "subscribers",
+				Columns:   sqlschema.NewIndexColumns("id"),
+				Include:   []string{"email", "deleted_at"},
+			}
+			require.Equal(t, want, indexes[2])
+
+			inspected := want
+			inspected.Include = []string{"email"}
+			require.False(t, want.Equals(inspected), "different included columns")
+		})
+
+		t.Run("compares index methods", func(t *testing.T) {
+			btree := sqlschema.Index{TableName: "models", Columns: sqlschema.NewIndexColumns("tags")}
+
+			explicit := btree
+			explicit.Method = "BTREE"
+			require.True(t, btree.Equals(explicit), "btree is the default method")
+
+			gin := btree
+			gin.Method = "gin"
+			require.False(t, btree.Equals(gin), "different methods")
+		})
+
+		t.Run("reads enum types", func(t *testing.T) {
+			type Model struct {
+				Mood      Mood   `bun:",type:mood"`
+				PastMoods []Mood `bun:",type:mood[]"`
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*Model)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			require.Equal(t, map[string][]string{"mood": {"sad", "ok", "happy"}}, got.GetEnums())
+		})
+
+		t.Run("reads generated columns", func(t *testing.T) {
+			type Model struct {
+				Price    int `bun:",notnull"`
+				Quantity int `bun:",notnull"`
+				Total    int `bun:",generated:price * quantity,stored"`
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*Model)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			want := ordered.NewMap[string, sqlschema.Column](
+				ordered.Pair[string, sqlschema.Column]{
+					Key:   "price",
+					Value: &sqlschema.BaseColumn{SQLType: "bigint"},
+				},
+				ordered.Pair[string, sqlschema.Column]{
+					Key:   "quantity",
+					Value: &sqlschema.BaseColumn{SQLType: "bigint"},
+				},
+				ordered.Pair[string, sqlschema.Column]{
+					Key: "total",
+					Value: &sqlschema.BaseColumn{
+						SQLType:         "bigint",
+						IsNullable:      true,
+						GeneratedExpr:   "price * quantity",
+						GeneratedStored: true,
+					},
+				},
+			)
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			gotTables := got.GetTables()
+			require.Equal(t, 1, gotTables.Len())
+			for _, table := range gotTables.Values() {
+				cmpColumns(t, dialect.(sqlschema.InspectorDialect), "model", want, table.GetColumns())
+			}
+		})
+
+		t.Run("preserves column order", func(t *testing.T) {
+			type Model struct {
+				Name      string
+				ID        int64 `bun:",pk"`
+				CreatedAt time.Time
+				Age       int
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*Model)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			gotTables := got.GetTables()
+			require.Equal(t, 1, gotTables.Len())
+			for _, table := range gotTables.Values() {
+				// Columns should appear in the same order as they are declared in the CREATE TABLE statement.
+				require.Equal(t, []string{"name", "id", "created_at", "age"}, table.GetColumns().Keys())
+			}
+		})
+
+		t.Run("inspect unique constraints", func(t *testing.T) {
+			type Model struct {
+				ID        string `bun:",unique"`
+				FirstName string `bun:"first_name,unique:full_name"`
+				LastName  string `bun:"last_name,unique:full_name"`
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*Model)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			want := &sqlschema.BaseTable{
+				Name: "models",
+				UniqueConstraints: []sqlschema.Unique{
+					{Columns: sqlschema.NewColumns("id")},
+					{Name: "full_name", Columns: sqlschema.NewColumns("first_name", "last_name")},
+				},
+			}
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			gotTables := got.GetTables()
+			require.Equal(t, 1, gotTables.Len())
+			for _, table := range gotTables.Values() {
+				cmpConstraints(t, want, &table.(*sqlschema.BunTable).BaseTable)
+				return
+			}
+		})
+		t.Run("preserves unique column order", func(t *testing.T) {
+			type Model struct {
+				LastName  string `bun:"last_name,unique:full_name"`
+				FirstName string `bun:"first_name,unique:full_name"`
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*Model)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			gotTables := got.GetTables()
+			require.Equal(t, 1, gotTables.Len())
+			for _, table := range gotTables.Values() {
+				unique := table.GetUniqueConstraints()
+				require.Len(t, unique, 1)
+				require.Equal(t, sqlschema.NewColumns("last_name", "first_name"), unique[0].Columns)
+			}
+		})
+
+		t.Run("inspect check constraints", func(t *testing.T) {
+			type Model struct {
+				Age     int       `bun:",check:age >= 18"`
+				StartAt time.Time `bun:",check:start_at < end_at"`
+				EndAt   time.Time
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*Model)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			want := &sqlschema.BaseTable{
+				Name: "models",
+				Checks: []sqlschema.Check{
+					{Expression: "age >= 18"},
+					{Expression: "(START_AT < end_at)"},
+				},
+			}
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			gotTables := got.GetTables()
+			require.Equal(t, 1, gotTables.Len())
+			for _, table := range gotTables.Values() {
+				cmpConstraints(t, want, &table.(*sqlschema.BunTable).BaseTable)
+				return
+			}
+		})
+
+		t.Run("collects primary keys", func(t *testing.T) {
+			type Model struct {
+				ID       string    `bun:",pk"`
+				Email    string    `bun:",pk"`
+				Birthday time.Time `bun:",notnull"`
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*Model)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+			want := sqlschema.NewColumns("id", "email")
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			gotTables := got.GetTables()
+			require.Equal(t, 1, gotTables.Len())
+			for _, table := range gotTables.Values() {
+				pk := table.GetPrimaryKey()
+				require.NotNilf(t, pk, "did not register primary key, want (%s)", want)
+				require.Equal(t, want, pk.Columns, "wrong primary key columns")
+				return
+			}
+		})
+
+		t.Run("separates schema and table name", func(t *testing.T) {
+			type Model struct {
+				bun.BaseModel `bun:"table:custom_schema.model"`
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*Model)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName("custom_schema"))
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			gotTables := got.GetTables()
+			require.Equal(t, 1, gotTables.Len())
+			for _, table := range gotTables.Values() {
+				require.Equal(t, "custom_schema", table.GetSchema(), "wrong schema name")
+				require.Equal(t, "model", table.GetName(), "wrong table name")
+				return
+			}
+		})
+
+		t.Run("filters tables by schema", func(t *testing.T) {
+			type KeepMe struct {
+				bun.BaseModel `bun:"table:want.keep_me"`
+			}
+
+			type LoseMe struct {
+				bun.BaseModel `bun:"table:lose_me"`
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*KeepMe)(nil), (*LoseMe)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName("want"))
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			gotTables := got.GetTables()
+			require.Equal(t, 1, gotTables.Len())
+			for _, table := range gotTables.Values() {
+				require.Equal(t, "want", table.GetSchema(), "wrong schema name")
+				require.Equal(t, "keep_me", table.GetName(), "wrong table name")
+				return
+			}
+		})
+
+		t.Run("inspects multiple schemas", func(t *testing.T) {
+			type User struct {
+				bun.BaseModel `bun:"table:shared.users"`
+				ID            int64 `bun:",pk"`
+			}
+
+			type Order struct {
+				bun.BaseModel `bun:"table:app.orders"`
+				ID            int64 `bun:",pk"`
+				UserID        int64 `bun:",unique_index"`
+				User          *User `bun:"rel:belongs-to,join:user_id=id"`
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*User)(nil), (*Order)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables,
+				sqlschema.WithSchemaName("app"),
+				sqlschema.WithSchemas("shared"),
+			)
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			gotTables := got.GetTables()
+			require.Equal(t, []string{"shared.users", "app.orders"}, []string{
+				gotTables.Value("shared.users").GetSchema() + "." + gotTables.Value("shared.users").GetName(),
+				gotTables.Value("orders").GetSchema() + "." + gotTables.Value("orders").GetName(),
+			}, "tables outside of the default schema must be keyed by their qualified name")
+
+			require.Equal(t, map[sqlschema.ForeignKey]string{
+				{
+					From: sqlschema.NewColumnReference("orders", "user_id"),
+					To:   sqlschema.NewColumnReference("shared.users", "id"),
+				}: "orders_user_id_fkey",
+			}, got.GetForeignKeys())
+			require.Equal(t, []sqlschema.Index{{
+				Name:      "orders_user_id_idx",
+				TableName: "orders",
+				Columns:   sqlschema.NewIndexColumns("user_id"),
+				Unique:    true,
+			}}, got.GetIndexes())
+		})
+
+		t.Run("registers views", func(t *testing.T) {
+			inspector := sqlschema.NewBunModelInspector(schema.NewTables(dialect),
+				sqlschema.WithSchemaName("app"),
+				sqlschema.WithSchemas("shared"),
+				sqlschema.WithViews(
+					sqlschema.View{Name: "active_users", Definition: "SELECT id FROM users"},
+					sqlschema.View{Schema: "shared", Name: "countries", Definition: "SELECT 'NL' AS code"},
+					sqlschema.View{Schema: "other", Name: "skipped", Definition: "SELECT 1"},
+				),
+			)
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, []sqlschema.View{
+				{Schema: "app", Name: "active_users", Definition: "SELECT id FROM users"},
+				{Schema: "shared", Name: "countries", Definition: "SELECT 'NL' AS code"},
+			}, got.GetViews(), "views outside of the inspected schemas must be skipped")
+		})
+
+		t.Run("registers sequences", func(t *testing.T) {
+			inspector := sqlschema.NewBunModelInspector(schema.NewTables(dialect),
+				sqlschema.WithSchemaName("app"),
+				sqlschema.WithSequences(
+					sqlschema.Sequence{Name: "order_no", Start: 1000},
+					sqlschema.Sequence{Schema: "other", Name: "skipped"},
+				),
+			)
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, []sqlschema.Sequence{
+				{Schema: "app", Name: "order_no", Start: 1000},
+			}, got.GetSequences(), "sequences outside of the inspected schemas must be skipped")
+		})
+
+		t.Run("registers domains", func(t *testing.T) {
+			inspector := sqlschema.NewBunModelInspector(schema.NewTables(dialect),
+				sqlschema.WithSchemaName("app"),
+				sqlschema.WithDomains(
+					sqlschema.Domain{Name: "email", DataType: "text"},
+					sqlschema.Domain{Schema: "other", Name: "skipped", DataType: "text"},
+				),
+			)
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, []sqlschema.Domain{
+				{Schema: "app", Name: "email", DataType: "text"},
+			}, got.GetDomains(), "domains outside of the inspected schemas must be skipped")
+		})
+
+		t.Run("round-trips JSON snapshots", func(t *testing.T) {
+			type Author struct {
+				ID    int64  `bun:",pk"`
+				Email string `bun:",unique,notnull"`
+			}
+			type Book struct {
+				ID       int64   `bun:",pk"`
+				Title    string  `bun:",type:varchar(100),default:'untitled'"`
+				AuthorID int64   `bun:"author_id"`
+				EditorID int64   `bun:"editor_id"`
+				Author   *Author `bun:"rel:belongs-to,join:author_id=id,on_delete:cascade"`
+				Editor   *Author `bun:"rel:belongs-to,join:editor_id=id"`
+			}
+
+			tables := schema.NewTables(dialect)
+			tables.Register((*Author)(nil), (*Book)(nil))
+			inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(dialect.DefaultSchema()))
+
+			got, err := inspector.Inspect(context.Background())
+			require.NoError(t, err)
+
+			b, err := sqlschema.MarshalDatabase(got)
+			require.NoError(t, err)
+			restored, err := sqlschema.UnmarshalDatabase(b)
+			require.NoError(t, err)
+
+			again, err := sqlschema.MarshalDatabase(restored)
+			require.NoError(t, err)
+			require.Equal(t, string(b), string(again), "snapshot is not reproducible")
+
+			require.Equal(t, got.GetForeignKeys(), restored.GetForeignKeys())
+			require.Equal(t, got.GetTables().Keys(), restored.GetTables().Keys())
+			for _, table := range restored.GetTables().Values() {
+				bunTable, ok := table.(*sqlschema.BunTable)
+				require.True(t, ok, "expected %T, got %T", bunTable, table)
+				require.Nil(t, bunTable.Model)
+
+				want, _ := got.GetTables().Load(table.GetName())
+				require.Equal(t, want.(*sqlschema.BunTable).ModelName, bunTable.ModelName)
+				require.Equal(t, want.(*sqlschema.BunTable).BaseTable, bunTable.BaseTable)
+			}
+		})
+	})
+}
+
+func TestYAMLInspector_Inspect(t *testing.T) {
+	t.Run("loads tables and foreign keys", func(t *testing.T) {
+		fsys := fstest.MapFS{"schema.yaml": {Data: []byte(`
+tables:
+  - name: books
+    columns:
+      - {name: id, type: bigint, nullable: false}
+      - {name: author_id, type: bigint}
+      - {name: title, type: VARCHAR(100), default: "'untitled'"}
+      - {name: price, type: "numeric(10,2)"}
+    primary_key: [id]
+    unique: [[title, author_id]]
+    checks: ["price > 0"]
+    foreign_keys:
+      - columns: [author_id]
+        references: {table: authors, columns: [id]}
+        on_delete: cascade
+  - name: authors
+    columns:
+      - {name: id, type: bigint, nullable: false}
+    primary_key: [id]
+`)}}
+
+		got, err := sqlschema.NewYAMLInspector(fsys, "schema.yaml", sqlschema.WithSchemaName("public")).Inspect(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"books", "authors"}, got.GetTables().Keys())
+		books := got.GetTables().Value("books").(*sqlschema.BaseTable)
+		require.Equal(t, "public", books.Schema)
+		require.Equal(t, []string{"id", "author_id", "title", "price"}, books.Columns.Keys())
+		require.Equal(t, &sqlschema.BaseColumn{Name: "id", SQLType: "bigint"}, books.Columns.Value("id"))
+		require.Equal(t, &sqlschema.BaseColumn{
+			Name: "title", SQLType: "varchar", VarcharLen: 100, IsNullable: true, DefaultValue: "untitled",
+		}, books.Columns.Value("title"))
+		require.Equal(t, &sqlschema.BaseColumn{
+			Name: "price", SQLType: "numeric", NumericPrecision: 10, NumericScale: 2, IsNullable: true,
+		}, books.Columns.Value("price"))
+		require.Equal(t, &sqlschema.PrimaryKey{Columns: sqlschema.NewColumns("id")}, books.PrimaryKey)
+		require.Equal(t, []sqlschema.Unique{{Columns: sqlschema.NewColumns("title", "author_id")}}, books.UniqueConstraints)
+		require.Equal(t, []sqlschema.Check{{Expression: "price > 0"}}, books.Checks)
+
+		require.Equal(t, map[sqlschema.ForeignKey]string{
+			{
+				From:     sqlschema.NewColumnReference("books", "author_id"),
+				To:       sqlschema.NewColumnReference("authors", "id"),
+				OnDelete: sqlschema.Cascade,
+			}: "books_author_id_fkey",
+		}, got.GetForeignKeys())
+	})
+
+	t.Run("parses type modifiers", func(t *testing.T) {
+		for _, tt := range []struct {
+			typ     string
+			want    sqlschema.BaseColumn
+			wantErr string
+		}{
+			{typ: "numeric(10,2)", want: sqlschema.BaseColumn{SQLType: "numeric", NumericPrecision: 10, NumericScale: 2}},
+			{typ: "DECIMAL(8)", want: sqlschema.BaseColumn{SQLType: "decimal", NumericPrecision: 8}},
+			{typ: "varchar(255)", want: sqlschema.BaseColumn{SQLType: "varchar", VarcharLen: 255}},
+			{typ: "nvarchar(max)", want: sqlschema.BaseColumn{SQLType: "nvarchar", VarcharLen: sqlschema.VarcharLenMax}},
+			{typ: "bit varying(8)", want: sqlschema.BaseColumn{SQLType: "bit varying", VarcharLen: 8}},
+			{typ: "timestamp(3) with time zone", want: sqlschema.BaseColumn{SQLType: "timestamp with time zone", VarcharLen: 3}},
+			{typ: "char", want: sqlschema.BaseColumn{SQLType: "char"}},
+			{typ: "double precision", want: sqlschema.BaseColumn{SQLType: "double precision"}},
+			{typ: "DOUBLE   PRECISION", want: sqlschema.BaseColumn{SQLType: "double precision"}},
+			{typ: "character varying(64)", want: sqlschema.BaseColumn{SQLType: "character varying", VarcharLen: 64}},
+			{typ: "character  varying (64)", want: sqlschema.BaseColumn{SQLType: "character varying", VarcharLen: 64}},
+			{typ: "timestamp with time zone", want: sqlschema.BaseColumn{SQLType: "timestamp with time zone"}},
+			{typ: "timestamp(3)  with\ttime zone", want: sqlschema.BaseColumn{SQLType: "timestamp with time zone", VarcharLen: 3}},
+			{typ: "time without time zone", want: sqlschema.BaseColumn{SQLType: "time without time zone"}},
+			{typ: "double(10,2)", want: sqlschema.BaseColumn{SQLType: "double(10,2)"}},
+			{typ: "numeric(10,2)[]", want: sqlschema.BaseColumn{SQLType: "numeric", NumericPrecision: 10, NumericScale: 2, ArrayDims: 1}},
+			{typ: "varchar(", wantErr: "unbalanced parentheses"},
+			{typ: "varchar)10(", wantErr: "unbalanced parentheses"},
+			{typ: "varchar(abc)", wantErr: `invalid type modifier "(abc)"`},
+			{typ: "numeric(10,)", wantErr: `invalid type modifier "(10,)"`},
+			{typ: "numeric(10,2,1)", wantErr: "numeric types only accept precision and scale"},
+		} {
+			t.Run(tt.typ, func(t *testing.T) {
+				fsys := fstest.MapFS{"schema.yaml": {Data: []byte(fmt.Sprintf(`
+tables:
+  - name: things
+    columns:
+      - {name: value, type: %q, nullable: false}
+`, tt.typ))}}
+
+				got, err := sqlschema.NewYAMLInspector(fsys, "schema.yaml").Inspect(context.Background())
+				if tt.wantErr != "" {
+					require.ErrorContains(t, err, tt.wantErr)
+					return
+				}
+				require.NoError(t, err)
+
+				tt.want.Name = "value"
+				require.Equal(t, &tt.want, got.GetTables().Value("things").GetColumns().Value("value"))
+			})
+		}
+	})
+
+	t.Run("reports invalid definitions", func(t *testing.T) {
+		for _, tt := range []struct {
+			name    string
+			yaml    string
+			wantErr string
+		}{
+			{
+				name: "duplicate column",
+				yaml: `
+tables:
+  - name: users
+    columns:
+      - {name: id, type: bigint}
+      - {name: id, type: text}
+`,
+				wantErr: `schema.yaml:6: duplicate column "id" in table "users"`,
+			},
+			{
+				name: "duplicate table",
+				yaml: `
+tables:
+  - name: users
+  - name: users
+`,
+				wantErr: `schema.yaml:4: duplicate table "users"`,
+			},
+			{
+				name: "missing type",
+				yaml: `
+tables:
+  - name: users
+    columns:
+      - name: id
+`,
+				wantErr: `schema.yaml:5: column "id" in table "users" must have a type`,
+			},
+			{
+				name: "unknown primary key column",
+				yaml: `
+tables:
+  - name: users
+    columns:
+      - {name: id, type: bigint}
+    primary_key: [user_id]
+`,
+				wantErr: `schema.yaml:3: unknown column "user_id" in table "users"`,
+			},
+			{
+				name: "unknown referenced table",
+				yaml: `
+tables:
+  - name: books
+    columns:
+      - {name: author_id, type: bigint}
+    foreign_keys:
+      - columns: [author_id]
+        references: {table: authors, columns: [id]}
+`,
+				wantErr: `schema.yaml:8: foreign key in table "books" references unknown table "authors"`,
+			},
+			{
+				name: "unknown referenced column",
+				yaml: `
+tables:
+  - name: authors
+    columns:
+      - {name: id, type: bigint}
+  - name: books
+    columns:
+      - {name: author_id, type: bigint}
+    foreign_keys:
+      - columns: [author_id]
+        references:
+          table: authors
+          columns: [author_id]
+`,
+				wantErr: `schema.yaml:12: unknown column "author_id" in table "authors"`,
+			},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				fsys := fstest.MapFS{"schema.yaml": {Data: []byte(tt.yaml)}}
+
+				_, err := sqlschema.NewYAMLInspector(fsys, "schema.yaml").Inspect(context.Background())
+				require.EqualError(t, err, tt.wantErr)
+			})
+		}
+	})
+}
+
+func TestStateBuilder(t *testing.T) {
+	t.Run("builds the same state as YAMLInspector", func(t *testing.T) {
+		fsys := fstest.MapFS{"schema.yaml": {Data: []byte(`
+tables:
+  - name: books
+    columns:
+      - {name: id, type: bigint, nullable: false}
+      - {name: author_id, type: bigint, nullable: false}
+      - {name: title, type: VARCHAR(100), default: "'untitled'"}
+    primary_key: [id]
+    unique: [[title, author_id]]
+    checks: ["length(title) > 0"]
+    foreign_keys:
+      - columns: [author_id]
+        references: {table: authors, columns: [id]}
+  - name: authors
+    columns:
+      - {name: id, type: bigint, nullable: false}
+    primary_key: [id]
+`)}}
+		want, err := sqlschema.NewYAMLInspector(fsys, "schema.yaml", sqlschema.WithSchemaName("public")).Inspect(context.Background())
+		require.NoError(t, err)
+
+		got, err := sqlschema.NewState(sqlschema.WithSchemaName("public")).
+			AddTable("books").
+			Column("id", "bigint").PK().
+			Column("author_id", "bigint").NotNull().References("authors", "id").
+			Column("title", "VARCHAR(100)").Default("'untitled'").
+			UniqueConstraint("title", "author_id").
+			Check("length(title) > 0").
+			AddTable("authors").
+			Column("id", "bigint").PK().
+			Build()
+		require.NoError(t, err)
+
+		require.Equal(t, want.GetTables(), got.GetTables())
+		require.Equal(t, want.GetForeignKeys(), got.GetForeignKeys())
+	})
+
+	t.Run("composite primary key", func(t *testing.T) {
+		got, err := sqlschema.NewState().
+			AddTable("memberships").
+			Column("user_id", "bigint").PK().
+			Column("group_id", "bigint").PK().
+			Build()
+		require.NoError(t, err)
+
+		table := got.GetTables().Value("memberships")
+		require.Equal(t, &sqlschema.PrimaryKey{Columns: sqlschema.NewColumns("user_id", "group_id")}, table.GetPrimaryKey())
+		require.False(t, table.GetColumns().Value("group_id").GetIsNullable())
+	})
+
+	t.Run("reports invalid references", func(t *testing.T) {
+		_, err := sqlschema.NewState().
+			AddTable("posts").
+			Column("id", "bigint").PK().
+			Column("id", "text").
+			Column("author_id", "bigint").References("authors", "id").
+			Column("editor_id", "bigint").References("posts", "editor").
+			Build()
+
+		require.EqualError(t, err, "build state: duplicate column \"id\" in table \"posts\"\n"+
+			"foreign key posts_author_id_fkey references unknown table \"authors\"\n"+
+			"foreign key posts_editor_id_fkey: unknown column \"editor\" in table \"posts\"")
+	})
+}
+
+func TestDatabase_Table(t *testing.T) {
+	type Event struct {
+		bun.BaseModel `bun:"table:audit.events"`
+		ID            int64 `bun:",pk"`
+	}
+
+	check := func(t *testing.T, state sqlschema.Database, defaultSchema string) {
+		for _, tt := range []struct {
+			schemaName, name string
+			want             bool
+		}{
+			{"", "articles", true},
+			{defaultSchema, "articles", true},
+			{"audit", "articles", false},
+			{"audit", "events", true},
+			{"", "events", false},
+			{defaultSchema, "missing", false},
+		} {
+			table, ok := state.Table(tt.schemaName, tt.name)
+			require.Equal(t, tt.want, ok, "%s.%s", tt.schemaName, tt.name)
+			if ok {
+				require.Equal(t, tt.name, table.GetName())
+			}
+		}
+	}
+
+	testEachDialect(t, func(t *testing.T, dialectName string, dialect schema.Dialect) {
+		if _, ok := dialect.(sqlschema.InspectorDialect); !ok {
+			t.Skip(dialectName + " is not sqlschema.InspectorDialect")
+		}
+		tables := schema.NewTables(dialect)
+		tables.Register((*Article)(nil), (*Event)(nil))
+		state, err := sqlschema.NewBunModelInspector(tables,
+			sqlschema.WithSchemaName(dialect.DefaultSchema()),
+			sqlschema.WithSchemas("audit"),
+		).Inspect(context.Background())
+		require.NoError(t, err)
+		check(t, state, dialect.DefaultSchema())
+	})
+
+	t.Run("state builder", func(t *testing.T) {
+		state, err := sqlschema.NewState(sqlschema.WithSchemaName("public")).
+			AddTable("articles").Column("id", "bigint").
+			Build()
+		require.NoError(t, err)
+
+		_, ok := state.Table("public", "articles")
+		require.True(t, ok)
+		_, ok = state.Table("", "articles")
+		require.True(t, ok)
+		_, ok = state.Table("audit", "articles")
+		require.False(t, ok)
+	})
+}
+
+func TestColumn_Equal(t *testing.T) {
+	base := sqlschema.BaseColumn{
+		Name:         "email",
+		SQLType:      "varchar",
+		VarcharLen:   320,
+		DefaultValue: "lower('X')",
+		Comment:      "Login",
+		Collation:    "C",
+	}
+	with := func(f func(*sqlschema.BaseColumn)) *sqlschema.BaseColumn {
+		col := base
+		f(&col)
+		return &col
+	}
+
+	t.Run("all attributes are compared by default", func(t *testing.T) {
+		require.True(t, base.Equal(with(func(c *sqlschema.BaseColumn) { c.Name = "login"; c.SQLType = "VARCHAR" })))
+		require.True(t, sqlschema.EqualColumns(&base, with(func(c *sqlschema.BaseColumn) { c.Name = "login" })))
+		for name, other := range map[string]*sqlschema.BaseColumn{
+			"type":       with(func(c *sqlschema.BaseColumn) { c.SQLType = "text" }),
+			"length":     with(func(c *sqlschema.BaseColumn) { c.VarcharLen = 100 }),
+			"nullable":   with(func(c *sqlschema.BaseColumn) { c.IsNullable = true }),
+			"default":    with(func(c *sqlschema.BaseColumn) { c.DefaultValue = "LOWER('X')" }),
+			"identity":   with(func(c *sqlschema.BaseColumn) { c.IsIdentity = true }),
+			"comment":    with(func(c *sqlschema.BaseColumn) { c.Comment = "" }),
+			"collation":  with(func(c *sqlschema.BaseColumn) { c.Collation = "en_US" }),
+			"generated":  with(func(c *sqlschema.BaseColumn) { c.GeneratedExpr = "lower(name)" }),
+			"array dims": with(func(c *sqlschema.BaseColumn) { c.ArrayDims = 1 }),
+		} {
+			require.False(t, base.Equal(other), name)
+		}
+	})
+
+	t.Run("WithTypeComparison", func(t *testing.T) {
+		other := with(func(c *sqlschema.BaseColumn) { c.SQLType = "character varying" })
+		require.False(t, base.Equal(other))
+		require.True(t, base.Equal(other, sqlschema.WithTypeComparison(pgdialect.New().CompareType)))
+	})
+
+	t.Run("WithDefaultNormalization", func(t *testing.T) {
+		other := with(func(c *sqlschema.BaseColumn) { c.DefaultValue = "LOWER('X')" })
+		require.False(t, base.Equal(other))
+		require.True(t, base.Equal(other, sqlschema.WithDefaultNormalization(sqlschema.NormalizeExpr)))
+	})
+
+	t.Run("IgnoreDefaults", func(t *testing.T) {
+		other := with(func(c *sqlschema.BaseColumn) { c.DefaultValue = "" })
+		require.False(t, base.Equal(other))
+		require.True(t, base.Equal(other, sqlschema.IgnoreDefaults()))
+	})
+
+	t.Run("comments ignore whitespace", func(t *testing.T) {
+		require.True(t, base.Equal(with(func(c *sqlschema.BaseColumn) { c.Comment = "  Login\n" })))
+		require.True(t, sqlschema.EqualComments("Used  to\tlog in", "Used to log in"))
+		require.False(t, sqlschema.EqualComments("Used to log in", "Used to login"))
+	})
+
+	t.Run("IgnoreComments", func(t *testing.T) {
+		other := with(func(c *sqlschema.BaseColumn) { c.Comment = "Used to log in" })
+		require.False(t, base.Equal(other))
+		require.True(t, base.Equal(other, sqlschema.IgnoreComments()))
+	})
+
+	t.Run("IgnoreCollation", func(t *testing.T) {
+		other := with(func(c *sqlschema.BaseColumn) { c.Collation = "en_US" })
+		require.False(t, base.Equal(other))
+		require.True(t, base.Equal(other, sqlschema.IgnoreCollation()))
+	})
+}
+
+func TestForeignKey_String(t *testing.T) {
+	for _, tt := range []struct {
+		fk   sqlschema.ForeignKey
+		want string
+	}{
+		{
+			fk: sqlschema.ForeignKey{
+				From: sqlschema.NewColumnReference("orders", "user_id"),
+				To:   sqlschema.NewColumnReference("users", "id"),
+			},
+			want: "orders(user_id) -> users(id)",
+		},
+		{
+			fk: sqlschema.ForeignKey{
+				From:     sqlschema.NewColumnReference("sales.orders", "user_id", "account_id"),
+				To:       sqlschema.NewColumnReference("users", "id", "account_id"),
+				OnDelete: sqlschema.Cascade,
+				OnUpdate: sqlschema.SetNull,
+				Match:    sqlschema.MatchFull,
+
+				Deferrable:        true,
+				InitiallyDeferred: true,
+			},
+			want: "sales.orders(user_id, account_id) -> users(id, account_id) ON DELETE CASCADE ON UPDATE SET NULL MATCH FULL DEFERRABLE INITIALLY DEFERRED",
+		},
+	} {
+		require.Equal(t, tt.want, tt.fk.String())
+		require.Equal(t, tt.want, fmt.Sprint(tt.fk))
+	}
+	require.Equal(t, "users(id)", fmt.Sprint(sqlschema.NewColumnReference("users", "id")))
+}
+
+func TestDatabase_String(t *testing.T) {
+	build := func() sqlschema.BaseDatabase {
+		state, err := sqlschema.NewState().
+			AddTable("books").
+			Column("id", "bigint").PK().
+			Column("author_id", "bigint").NotNull().References("authors", "id").
+			Column("title", "varchar(100)").Default("'untitled'").
+			Check("length(title) > 0").
+			AddTable("authors").
+			Column("id", "bigint").PK().
+			Column("email", "varchar(320)").NotNull().Unique().
+			Build()
+		require.NoError(t, err)
+		state.Enums["mood"] = []string{"happy", "sad"}
+		state.Indexes = []sqlschema.Index{
+			{Name: "books_title_idx", TableName: "books", Columns: []sqlschema.IndexColumn{{Expression: "lower(title)"}}, Method: "gin"},
+			{Name: "authors_email_idx", TableName: "authors", Columns: sqlschema.NewIndexColumns("email"), Unique: true, Where: "email <> ''"},
+		}
+		return state
+	}
+
+	want := `table authors
+  column id bigint not null
+  column email varchar(320) not null
+  primary key (id)
+  unique (email)
+table books
+  column id bigint not null
+  column author_id bigint not null
+  column title varchar(100) default untitled
+  primary key (id)
+  check (length(title) > 0)
+foreign key books_author_id_fkey: books(author_id) -> authors(id)
+unique index authors_email_idx on authors (email) where email <> ''
+index books_title_idx on books (lower(title)) using gin
+enum mood (happy, sad)
+`
+	require.Equal(t, want, build().String())
+	for i := 0; i < 10; i++ {
+		require.Equal(t, want, build().String(), "output is not deterministic")
+	}
+}
+
+func TestCreationOrder(t *testing.T) {
+	fk := func(from, fromColumn, to string) sqlschema.ForeignKey {
+		return sqlschema.ForeignKey{
+			From: sqlschema.NewColumnReference(from, fromColumn),
+			To:   sqlschema.NewColumnReference(to, "id"),
+		}
+	}
+	state := func(names []string, fks ...sqlschema.ForeignKey) sqlschema.Database {
+		db := sqlschema.BaseDatabase{
+			Tables:      ordered.NewMap[string, sqlschema.Table](),
+			ForeignKeys: make(map[sqlschema.ForeignKey]string),
+		}
+		for _, name := range names {
+			db.Tables.Store(name, &sqlschema.BaseTable{Name: name})
+		}
+		for _, fk := range fks {
+			db.ForeignKeys[fk] = ""
+		}
+		return db
+	}
+
+	t.Run("referenced tables first", func(t *testing.T) {
+		tables, deferred := sqlschema.CreationOrder(state(
+			[]string{"authors", "comments", "posts"},
+			fk("comments", "post_id", "posts"),
+			fk("posts", "author_id", "authors"),
+			fk("comments", "author_id", "authors"),
+		))
+		require.Equal(t, []string{"authors", "posts", "comments"}, tables)
+		require.Empty(t, deferred)
+	})
+
+	t.Run("circular reference", func(t *testing.T) {
+		employees := fk("employees", "department_id", "departments")
+		departments := fk("departments", "manager_id", "employees")
+		tables, deferred := sqlschema.CreationOrder(state(
+			[]string{"assignments", "employees", "departments"},
+			employees, departments,
+			fk("assignments", "employee_id", "employees"),
+		))
+		require.Equal(t, []string{"departments", "employees", "assignments"}, tables)
+		require.Equal(t, []sqlschema.ForeignKey{departments, employees}, deferred)
+	})
+
+	t.Run("self reference and unknown tables", func(t *testing.T) {
+		tables, deferred := sqlschema.CreationOrder(state(
+			[]string{"categories", "books"},
+			fk("categories", "parent_id", "categories"),
+			fk("books", "category_id", "categories"),
+			fk("books", "publisher_id", "publishers"),
+		))
+		require.Equal(t, []string{"categories", "books"}, tables)
+		require.Empty(t, deferred)
 	})
 }
@@ -1,20 +1,35 @@
 package dbtest_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/dialect/feature"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
 	"github.com/uptrace/bun/dialect/sqltype"
 	"github.com/uptrace/bun/internal/ordered"
 	"github.com/uptrace/bun/migrate"
 	"github.com/uptrace/bun/migrate/sqlschema"
+	"github.com/uptrace/bun/migrate/sqlschema/sqlschematest"
+	"github.com/uptrace/bun/schema"
 )
 
 const (
@@ -55,6 +70,7 @@ func TestMigrate(t *testing.T) {
 	tests := []Test{
 		{run: testMigrateUpAndDown},
 		{run: testMigrateUpError},
+		{run: testMigrateReplaced},
 	}
 
 	testEachDB(t, func(t *testing.T, dbName string, db *bun.DB) {
@@ -180,6 +196,134 @@ func testMigrateUpError(t *testing.T, db *bun.DB) {
 	require.Equal(t, []string{"down2", "down1"}, history)
 }
 
+func testMigrateReplaced(t *testing.T, db *bun.DB) {
+	ctx := context.Background()
+
+	var history []string
+	migration := func(name string, replaces ...string) migrate.Migration {
+		return migrate.Migration{
+			Name:     name,
+			Replaces: replaces,
+			Up: func(ctx context.Context, db *bun.DB) error {
+				history = append(history, "up "+name)
+				return nil
+			},
+		}
+	}
+	first := migration("20060102150405")
+	second := migration("20060102160405")
+	baseline := migration("20060103150405", first.Name, second.Name)
+
+	migrateFrom := func(t *testing.T, applied []migrate.Migration, available ...migrate.Migration) (*migrate.MigrationGroup, error) {
+		t.Helper()
+		migrations := migrate.NewMigrations()
+		for _, m := range applied {
+			migrations.Add(m)
+		}
+		m := migrate.NewMigrator(db, migrations,
+			migrate.WithTableName(migrationsTable),
+			migrate.WithLocksTableName(migrationLocksTable),
+		)
+		require.NoError(t, m.Reset(ctx))
+		if len(applied) > 0 {
+			_, err := m.Migrate(ctx)
+			require.NoError(t, err)
+		}
+
+		history = nil
+		migrations = migrate.NewMigrations()
+		for _, m := range available {
+			migrations.Add(m)
+		}
+		m = migrate.NewMigrator(db, migrations,
+			migrate.WithTableName(migrationsTable),
+			migrate.WithLocksTableName(migrationLocksTable),
+		)
+		return m.Migrate(ctx)
+	}
+	names := func(group *migrate.MigrationGroup) []string {
+		var names []string
+		for _, m := range group.Migrations {
+			names = append(names, m.Name)
+		}
+		return names
+	}
+
+	t.Run("fresh database runs the baseline", func(t *testing.T) {
+		group, err := migrateFrom(t, nil, first, second, baseline)
+		require.NoError(t, err)
+		require.Equal(t, []string{"up " + baseline.Name}, history)
+		require.Equal(t, []string{baseline.Name}, names(group))
+	})
+
+	t.Run("database past the squashed point only marks the baseline", func(t *testing.T) {
+		group, err := migrateFrom(t, []migrate.Migration{first, second}, baseline)
+		require.NoError(t, err)
+		require.Empty(t, history)
+		require.Equal(t, []string{baseline.Name}, names(group))
+	})
+
+	t.Run("database in the middle applies the remaining migrations", func(t *testing.T) {
+		group, err := migrateFrom(t, []migrate.Migration{first}, second, baseline)
+		require.NoError(t, err)
+		require.Equal(t, []string{"up " + second.Name}, history)
+		require.Equal(t, []string{second.Name, baseline.Name}, names(group))
+	})
+
+	t.Run("remaining migrations must exist", func(t *testing.T) {
+		_, err := migrateFrom(t, []migrate.Migration{first}, baseline)
+		require.ErrorContains(t, err, "but "+second.Name+" is missing")
+		require.Empty(t, history)
+	})
+}
+
+func TestMigrator_Squash(t *testing.T) {
+	type Author struct {
+		bun.BaseModel `bun:"table:authors"`
+		ID            int64 `bun:",pk,autoincrement"`
+	}
+
+	type Book struct {
+		bun.BaseModel `bun:"table:books"`
+		ID            int64 `bun:",pk,autoincrement"`
+		AuthorID      int64
+		Author        *Author `bun:"rel:belongs-to,join:author_id=id"`
+	}
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	dir := t.TempDir()
+	migrations := migrate.NewMigrations(migrate.WithMigrationsDirectory(dir))
+	migrations.Add(migrate.Migration{Name: "20060102150405", Replaces: []string{"20050102150405"}})
+	migrations.Add(migrate.Migration{Name: "20060102160405"})
+
+	tables := schema.NewTables(db.Dialect())
+	tables.Register((*Book)(nil), (*Author)(nil))
+	state, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(db.Dialect().DefaultSchema())).Inspect(ctx)
+	require.NoError(t, err)
+
+	files, err := migrate.NewMigrator(db, migrations).Squash(ctx, "baseline", state)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	up, down := files[0].Content, files[1].Content
+	require.True(t, strings.HasPrefix(up, "--bun:replaces 20050102150405 20060102150405 20060102160405\n"),
+		"replaced migrations must include the ones replaced by an earlier baseline: %s", up)
+	require.Contains(t, up, `CREATE TABLE "authors"`)
+	require.Contains(t, up, `ADD CONSTRAINT "books_author_id_fkey"`)
+	require.Equal(t, []string{
+		`ALTER TABLE "public"."books" DROP CONSTRAINT "books_author_id_fkey"`,
+		`DROP TABLE "public"."books"`,
+		`DROP TABLE "public"."authors"`,
+		"",
+	}, strings.Split(strings.ReplaceAll(down, ";\n", "\n"), "\n"))
+
+	discovered := migrate.NewMigrations()
+	require.NoError(t, discovered.Discover(os.DirFS(dir)))
+	sorted := discovered.Sorted()
+	require.Len(t, sorted, 1)
+	require.Equal(t, []string{"20050102150405", "20060102150405", "20060102160405"}, sorted[0].Replaces)
+}
+
 // newAutoMigratorOrSkip creates an AutoMigrator configured to use test migratins/locks
 // tables and dedicated migrations directory. If an AutoMigrator cannob be created because
 // the dialect doesn't support either schema inspections or migrations, the test will be *skipped*
@@ -324,6 +468,16 @@ func runMigrations(t *testing.T, m *migrate.AutoMigrator) {
 	checkMigrationFilesExist(t)
 }
 
+func TestAutoMigrator_TransactionalDDL(t *testing.T) {
+	// Does not connect to the database, since Apply fails before inspecting it.
+	db := bun.NewDB(nil, pgdialect.New(pgdialect.WithoutFeature(feature.TransactionalDDL)))
+	m, err := migrate.NewAutoMigrator(db)
+	require.NoError(t, err)
+
+	_, err = m.Apply(ctx)
+	require.ErrorContains(t, err, "implicitly commits DDL statements")
+}
+
 func TestAutoMigrator_Migrate(t *testing.T) {
 
 	tests := []struct {
@@ -334,12 +488,33 @@ func TestAutoMigrator_Migrate(t *testing.T) {
 		{testCreateDropTable},
 		{testAlterForeignKeys},
 		{testChangeColumnType_AutoCast},
+		{testChangeColumnType_Cast},
 		{testIdentity},
 		{testAddDropColumn},
 		{testUnique},
 		{testUniqueRenamedTable},
 		{testUpdatePrimaryKeys},
+		{testChangeColumnDefault},
+		{testChangeColumnNullability},
+		{testRenamedColumnDefault},
 		{testNothingToMigrate},
+		{testNothingToMigrateWithConstraints},
+		{testTypeEquivalence},
+		{testNothingToMigrateBoolean},
+		{testNothingToMigrateTimestamps},
+		{testRenameDetectionDisabled},
+		{testTargetInspectorYAML},
+		{testDryRun},
+		{testPreview},
+		{testApply},
+		{testOperationHooks},
+		{testApplyWithoutTransaction},
+		{testSafeMode},
+		{testRevertDropTable},
+		{testViews},
+		{testTriggers},
+		{testIndexes},
+		{testRebuildTable},
 	}
 
 	testEachDB(t, func(t *testing.T, dbName string, db *bun.DB) {
@@ -386,6 +561,10 @@ func testRenameTable(t *testing.T, db *bun.DB) {
 }
 
 func testCreateDropTable(t *testing.T, db *bun.DB) {
+	if db.Dialect().Name() == dialect.SQLite {
+		t.Skip("sqlite does not support gen_random_uuid() and identity columns")
+	}
+
 	type DropMe struct {
 		bun.BaseModel `bun:"table:dropme"`
 		Foo           int `bun:"foo,identity"`
@@ -416,6 +595,10 @@ func testCreateDropTable(t *testing.T, db *bun.DB) {
 }
 
 func testAlterForeignKeys(t *testing.T, db *bun.DB) {
+	if db.Dialect().Name() == dialect.SQLite {
+		t.Skip("sqlite cannot add or drop foreign keys in an existing table")
+	}
+
 	// Initial state -- each thing has one owner
 	type OwnerExclusive struct {
 		bun.BaseModel `bun:"owners"`
@@ -555,6 +738,10 @@ func testRenamedColumns(t *testing.T, db *bun.DB) {
 // testChangeColumnType_AutoCast checks type changes which can be type-casted automatically,
 // i.e. do not require supplying a USING clause (pgdialect).
 func testChangeColumnType_AutoCast(t *testing.T, db *bun.DB) {
+	if db.Dialect().Name() == dialect.SQLite {
+		t.Skip("sqlite cannot change column types in an existing table")
+	}
+
 	type TableBefore struct {
 		bun.BaseModel `bun:"table:change_me_own_type"`
 
@@ -657,7 +844,53 @@ func testChangeColumnType_AutoCast(t *testing.T, db *bun.DB) {
 	cmpTables(t, db.Dialect().(sqlschema.InspectorDialect), wantTables, state.GetTables())
 }
 
+// testChangeColumnType_Cast checks that existing values are converted to a type
+// which they cannot be assigned to implicitly. SQLite rebuilds the table to do that.
+func testChangeColumnType_Cast(t *testing.T, db *bun.DB) {
+	type TableBefore struct {
+		bun.BaseModel `bun:"table:cast_me"`
+
+		ID     int64  `bun:"id,pk"`
+		Amount string `bun:"amount,notnull"`
+		Note   string `bun:"note"`
+	}
+
+	type TableAfter struct {
+		bun.BaseModel `bun:"table:cast_me"`
+
+		ID     int64  `bun:"id,pk"`
+		Amount int64  `bun:"amount,notnull"`
+		Note   string `bun:"note"`
+	}
+
+	ctx := context.Background()
+	inspect := inspectDbOrSkip(t, db)
+	mustResetModel(t, ctx, db, (*TableBefore)(nil))
+	_, err := db.NewInsert().Model(&TableBefore{ID: 1, Amount: "42", Note: "kept"}).Exec(ctx)
+	require.NoError(t, err)
+	m := newAutoMigratorOrSkip(t, db, migrate.WithModel((*TableAfter)(nil)))
+
+	// Act
+	runMigrations(t, m)
+
+	// Assert
+	var got TableAfter
+	require.NoError(t, db.NewSelect().Model(&got).Where("id = 1").Scan(ctx))
+	require.Equal(t, TableAfter{ID: 1, Amount: 42, Note: "kept"}, got)
+
+	state := inspect(ctx)
+	table, ok := state.GetTables().Load("cast_me")
+	require.True(t, ok, "table must keep its name")
+	d := db.Dialect().(sqlschema.InspectorDialect)
+	require.True(t, d.CompareType(&sqlschema.BaseColumn{SQLType: sqltype.BigInt}, table.GetColumns().Value("amount")),
+		"amount must be converted to bigint")
+}
+
 func testIdentity(t *testing.T, db *bun.DB) {
+	if db.Dialect().Name() == dialect.SQLite {
+		t.Skip("sqlite does not support identity columns")
+	}
+
 	type TableBefore struct {
 		bun.BaseModel `bun:"table:bourne_identity"`
 		A             int64 `bun:",notnull,identity"`
@@ -762,6 +995,10 @@ func testAddDropColumn(t *testing.T, db *bun.DB) {
 }
 
 func testUnique(t *testing.T, db *bun.DB) {
+	if db.Dialect().Name() == dialect.SQLite {
+		t.Skip("sqlite cannot add or drop unique constraints in an existing table")
+	}
+
 	type TableBefore struct {
 		bun.BaseModel `bun:"table:uniqlo_stores"`
 		FirstName     string `bun:"first_name,unique:full_name"`
@@ -957,6 +1194,10 @@ func testUniqueRenamedTable(t *testing.T, db *bun.DB) {
 }
 
 func testUpdatePrimaryKeys(t *testing.T, db *bun.DB) {
+	if db.Dialect().Name() == dialect.SQLite {
+		t.Skip("sqlite cannot change primary keys in an existing table")
+	}
+
 	// Has a composite primary key.
 	type DropPKBefore struct {
 		bun.BaseModel `bun:"table:drop_your_pks"`
@@ -1106,6 +1347,138 @@ func testUpdatePrimaryKeys(t *testing.T, db *bun.DB) {
 	cmpTables(t, db.Dialect().(sqlschema.InspectorDialect), wantTables, state.Tables)
 }
 
+func testChangeColumnDefault(t *testing.T, db *bun.DB) {
+	type PostBefore struct {
+		bun.BaseModel `bun:"table:posts_with_defaults"`
+		ID            int64  `bun:",pk"`
+		Status        string `bun:",notnull,default:'draft'"`
+		Rank          int    `bun:",notnull,default:0"`
+		Note          string
+	}
+
+	type PostAfter struct {
+		bun.BaseModel `bun:"table:posts_with_defaults"`
+		ID            int64  `bun:",pk"`
+		Status        string `bun:",notnull,default:'published'"`
+		Rank          int    `bun:",notnull"`
+		Note          string `bun:",default:'n/a'"`
+	}
+
+	ctx := context.Background()
+	inspect := inspectDbOrSkip(t, db)
+	mustResetModel(t, ctx, db, (*PostBefore)(nil))
+	_, err := db.NewInsert().Model(&PostBefore{ID: 1, Status: "draft"}).Exec(ctx)
+	require.NoError(t, err)
+
+	m := newAutoMigratorOrSkip(t, db, migrate.WithModel((*PostAfter)(nil)))
+	statements, err := m.DryRun(ctx, io.Discard)
+	require.NoError(t, err)
+	require.Len(t, statements, 3)
+	for _, stmt := range statements {
+		require.IsType(t, (*migrate.ChangeColumnDefaultOp)(nil), stmt.Operation)
+	}
+
+	// Act
+	runMigrations(t, m)
+
+	// Assert
+	columns := inspect(ctx).Tables.Value("posts_with_defaults").GetColumns()
+	require.Equal(t, "published", columns.Value("status").GetDefaultValue())
+	require.Equal(t, "", columns.Value("rank").GetDefaultValue())
+	require.Equal(t, "n/a", columns.Value("note").GetDefaultValue())
+
+	var post PostAfter
+	require.NoError(t, db.NewSelect().Model(&post).Where("id = 1").Scan(ctx))
+	require.Equal(t, "draft", post.Status, "existing rows must keep their values")
+}
+
+func testChangeColumnNullability(t *testing.T, db *bun.DB) {
+	type TaskBefore struct {
+		bun.BaseModel `bun:"table:tasks_nullability"`
+		ID            int64  `bun:",pk"`
+		Owner         string `bun:",nullzero"`
+		Title         string `bun:",notnull"`
+	}
+
+	type TaskAfter struct {
+		bun.BaseModel `bun:"table:tasks_nullability"`
+		ID            int64  `bun:",pk"`
+		Owner         string `bun:",notnull,backfill:'nobody'"`
+		Title         string
+	}
+
+	ctx := context.Background()
+	inspect := inspectDbOrSkip(t, db)
+	mustResetModel(t, ctx, db, (*TaskBefore)(nil))
+	_, err := db.NewInsert().Model(&[]TaskBefore{{ID: 1, Title: "a"}, {ID: 2, Owner: "bob", Title: "b"}}).Exec(ctx)
+	require.NoError(t, err)
+
+	m := newAutoMigratorOrSkip(t, db, migrate.WithModel((*TaskAfter)(nil)))
+	statements, err := m.DryRun(ctx, io.Discard)
+	require.NoError(t, err)
+	require.Len(t, statements, 2)
+	for _, stmt := range statements {
+		require.IsType(t, (*migrate.ChangeColumnNullabilityOp)(nil), stmt.Operation)
+	}
+
+	// Act
+	runMigrations(t, m)
+
+	// Assert
+	columns := inspect(ctx).Tables.Value("tasks_nullability").GetColumns()
+	require.False(t, columns.Value("owner").GetIsNullable())
+	require.True(t, columns.Value("title").GetIsNullable())
+
+	var owners []string
+	require.NoError(t, db.NewSelect().Model((*TaskAfter)(nil)).Column("owner").Order("id").Scan(ctx, &owners))
+	require.Equal(t, []string{"nobody", "bob"}, owners)
+}
+
+func testRenamedColumnDefault(t *testing.T, db *bun.DB) {
+	type PostBefore struct {
+		bun.BaseModel `bun:"table:posts_renamed_defaults"`
+		ID            int64  `bun:",pk"`
+		Status        string `bun:",notnull,default:'draft'"`
+		Note          string
+	}
+
+	type PostAfter struct {
+		bun.BaseModel `bun:"table:posts_renamed_defaults"`
+		ID            int64  `bun:",pk"`
+		State         string `bun:",notnull,default:'published',rename_from:status"`
+		Note          string
+	}
+
+	ctx := context.Background()
+	inspect := inspectDbOrSkip(t, db)
+	mustResetModel(t, ctx, db, (*PostBefore)(nil))
+	_, err := db.NewInsert().Model(&PostBefore{ID: 1, Status: "draft"}).Exec(ctx)
+	require.NoError(t, err)
+
+	m := newAutoMigratorOrSkip(t, db, migrate.WithModel((*PostAfter)(nil)))
+	statements, err := m.DryRun(ctx, io.Discard)
+	require.NoError(t, err)
+	require.Len(t, statements, 2)
+	require.IsType(t, (*migrate.RenameColumnOp)(nil), statements[0].Operation)
+	require.IsType(t, (*migrate.ChangeColumnDefaultOp)(nil), statements[1].Operation)
+
+	// Act
+	runMigrations(t, m)
+
+	// Assert
+	columns := inspect(ctx).Tables.Value("posts_renamed_defaults").GetColumns()
+	require.Equal(t, []string{"id", "state", "note"}, columns.Keys())
+	require.Equal(t, "published", columns.Value("state").GetDefaultValue())
+
+	var post PostAfter
+	require.NoError(t, db.NewSelect().Model(&post).Where("id = 1").Scan(ctx))
+	require.Equal(t, "draft", post.State, "existing rows must keep their values")
+
+	statements, err = m.DryRun(ctx, io.Discard)
+	require.NoError(t, err)
+	require.Empty(t, statements, "nothing to migrate after the rename")
+}
+
 func testNothingToMigrate(t *testing.T, db *bun.DB) {
 	type BoringThing struct {
 		AlwaysBlue string `bun:"colour,default:'blue'"`
@@ -1126,3 +1499,4154 @@ func testNothingToMigrate(t *testing.T, db *bun.DB) {
 	require.NoError(t, err, "fetch applied migrations")
 	require.Empty(t, applied, "nothing to migrate, AppliedMigrations not empty")
 }
+
+func testNothingToMigrateWithConstraints(t *testing.T, db *bun.DB) {
+	type Author struct {
+		bun.BaseModel `bun:"table:constrained_authors"`
+		ID            int64  `bun:",pk,autoincrement"`
+		Email         string `bun:",notnull,unique"`
+	}
+
+	type Book struct {
+		bun.BaseModel `bun:"table:constrained_books"`
+		ISBN          string  `bun:",pk,type:varchar(13)"`
+		Title         string  `bun:",notnull,unique:title_author"`
+		AuthorID      int64   `bun:",notnull,unique:title_author"`
+		Pages         int32   `bun:",notnull,default:1,check:pages > 0"`
+		Author        *Author `bun:"rel:belongs-to,join:author_id=id,on_delete:cascade"`
+	}
+
+	ctx := context.Background()
+	db.RegisterModel((*Author)(nil), (*Book)(nil))
+	mustCreateTableWithFKs(t, ctx, db, (*Author)(nil), (*Book)(nil))
+	m := newAutoMigratorOrSkip(t, db,
+		migrate.WithModel((*Author)(nil), (*Book)(nil)),
+	)
+
+	// Act
+	_, err := m.Migrate(ctx) // do not use runMigrations because we do not expect any files to be created
+	require.NoError(t, err, "auto migration failed")
+
+	migrator := migrate.NewMigrator(db, migrate.NewMigrations(), migrate.WithTableName(migrationsTable))
+	applied, err := migrator.AppliedMigrations(ctx)
+	require.NoError(t, err, "fetch applied migrations")
+	require.Empty(t, applied, "nothing to migrate, AppliedMigrations not empty")
+}
+
+func testTypeEquivalence(t *testing.T, db *bun.DB) {
+	type TokenBefore struct {
+		bun.BaseModel `bun:"table:tokens"`
+		Token         string `bun:"token,type:text"`
+	}
+
+	type TokenAfter struct {
+		bun.BaseModel `bun:"table:tokens"`
+		Token         string `bun:"token,type:uuid"`
+	}
+
+	ctx := context.Background()
+	mustResetModel(t, ctx, db, (*TokenBefore)(nil))
+	m := newAutoMigratorOrSkip(t, db,
+		migrate.WithModel((*TokenAfter)(nil)),
+		migrate.WithTypeEquivalence(migrate.TypeAlias("uuid", "text")),
+	)
+
+	// Act
+	_, err := m.Migrate(ctx) // do not use runMigrations because we do not expect any files to be created
+	require.NoError(t, err, "auto migration failed")
+
+	migrator := migrate.NewMigrator(db, migrate.NewMigrations(), migrate.WithTableName(migrationsTable))
+	applied, err := migrator.AppliedMigrations(ctx)
+	require.NoError(t, err, "fetch applied migrations")
+	require.Empty(t, applied, "registered type alias, AppliedMigrations not empty")
+}
+
+func testNothingToMigrateBoolean(t *testing.T, db *bun.DB) {
+	type Flag struct {
+		bun.BaseModel `bun:"table:flags"`
+		Name          string `bun:",pk,type:varchar(50)"`
+		Enabled       bool   `bun:",notnull"`
+		Archived      bool
+	}
+
+	ctx := context.Background()
+	mustResetModel(t, ctx, db, (*Flag)(nil))
+	m := newAutoMigratorOrSkip(t, db,
+		migrate.WithModel((*Flag)(nil)),
+	)
+
+	// Act
+	_, err := m.Migrate(ctx) // do not use runMigrations because we do not expect any files to be created
+	require.NoError(t, err, "auto migration failed")
+
+	migrator := migrate.NewMigrator(db, migrate.NewMigrations(), migrate.WithTableName(migrationsTable))
+	applied, err := migrator.AppliedMigrations(ctx)
+	require.NoError(t, err, "fetch applied migrations")
+	require.Empty(t, applied, "boolean columns did not change, AppliedMigrations not empty")
+}
+
+func testNothingToMigrateTimestamps(t *testing.T, db *bun.DB) {
+	if db.Dialect().Name() != dialect.PG {
+		t.Skip("time zone-aware types are specific to Postgres")
+	}
+
+	type Event struct {
+		bun.BaseModel `bun:"table:events"`
+		ID            int64     `bun:",pk,autoincrement"`
+		StartsAt      time.Time `bun:",notnull,type:timestamptz"`
+		EndsAt        time.Time `bun:",notnull,type:timestamp with time zone"`
+		LoggedAt      time.Time `bun:",notnull,type:timestamp(3)"`
+		Deadline      time.Time `bun:",notnull,type:timestamp without time zone"`
+		OpensAt       time.Time `bun:",notnull,type:timetz"`
+		ClosesAt      time.Time `bun:",notnull,type:time"`
+	}
+
+	ctx := context.Background()
+	mustResetModel(t, ctx, db, (*Event)(nil))
+	m := newAutoMigratorOrSkip(t, db,
+		migrate.WithModel((*Event)(nil)),
+	)
+
+	// Act
+	_, err := m.Migrate(ctx) // do not use runMigrations because we do not expect any files to be created
+	require.NoError(t, err, "auto migration failed")
+
+	migrator := migrate.NewMigrator(db, migrate.NewMigrations(), migrate.WithTableName(migrationsTable))
+	applied, err := migrator.AppliedMigrations(ctx)
+	require.NoError(t, err, "fetch applied migrations")
+	require.Empty(t, applied, "timestamp columns did not change, AppliedMigrations not empty")
+}
+
+func TestDiff(t *testing.T) {
+	type ThingBefore struct {
+		bun.BaseModel `bun:"table:things"`
+		ID            int64   `bun:",pk"`
+		Score         string  `bun:",type:integer"`
+		Price         float64 `bun:",type:decimal(10,2)"`
+		Legacy        int32
+	}
+
+	type ThingAfter struct {
+		bun.BaseModel `bun:"table:things"`
+		ID            int64   `bun:",pk"`
+		Score         string  `bun:",type:text"`
+		Price         float64 `bun:",type:numeric(10,2)"`
+		Email         string  `bun:",type:varchar(100)"`
+	}
+
+	type Owner struct {
+		bun.BaseModel `bun:"table:owners"`
+		ID            int64 `bun:",pk"`
+	}
+
+	inspect := func(t *testing.T, d schema.Dialect, models ...interface{}) sqlschema.Database {
+		t.Helper()
+		tables := schema.NewTables(d)
+		tables.Register(models...)
+		state, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+		require.NoError(t, err)
+		return state
+	}
+
+	testEachDialect(t, func(t *testing.T, dialectName string, d schema.Dialect) {
+		inspectorDialect, ok := d.(sqlschema.InspectorDialect)
+		if !ok {
+			t.Skip(dialectName + " is not sqlschema.InspectorDialect")
+		}
+
+		current := inspect(t, d, (*ThingBefore)(nil))
+		target := inspect(t, d, (*ThingAfter)(nil), (*Owner)(nil))
+
+		changes, err := migrate.Diff(inspectorDialect, current, target)
+		require.NoError(t, err)
+
+		var got []string
+		for _, op := range changes.Operations {
+			switch op := op.(type) {
+			case *migrate.CreateTableOp:
+				got = append(got, "create table "+op.TableName)
+			case *migrate.AddColumnOp:
+				got = append(got, "add column "+op.ColumnName)
+			case *migrate.DropColumnOp:
+				got = append(got, "drop column "+op.ColumnName)
+			case *migrate.ChangeColumnTypeOp:
+				got = append(got, "change column type "+op.Column)
+			default:
+				t.Errorf("unexpected operation %T", op)
+			}
+		}
+		require.ElementsMatch(t, []string{
+			"create table owners",
+			"add column email",
+			"drop column legacy",
+			"change column type score",
+		}, got)
+	})
+}
+
+func TestDiff_TypeEquivalence(t *testing.T) {
+	type MeasurementBefore struct {
+		bun.BaseModel `bun:"table:measurements"`
+		ID            int64   `bun:",pk"`
+		Value         float64 `bun:",type:float8"`
+	}
+
+	type MeasurementAfter struct {
+		bun.BaseModel `bun:"table:measurements"`
+		ID            int64   `bun:",pk"`
+		Value         float64 `bun:",type:double  precision"`
+	}
+
+	d := pgdialect.New()
+	inspect := func(t *testing.T, model interface{}) sqlschema.Database {
+		t.Helper()
+		tables := schema.NewTables(d)
+		tables.Register(model)
+		state, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+		require.NoError(t, err)
+		return state
+	}
+
+	t.Run("types differ by default", func(t *testing.T) {
+		changes, err := migrate.Diff(d, inspect(t, (*MeasurementBefore)(nil)), inspect(t, (*MeasurementAfter)(nil)))
+		require.NoError(t, err)
+		require.Len(t, changes.Operations, 1)
+		require.IsType(t, (*migrate.ChangeColumnTypeOp)(nil), changes.Operations[0])
+	})
+
+	t.Run("registered alias ignores whitespace", func(t *testing.T) {
+		changes, err := migrate.Diff(d, inspect(t, (*MeasurementBefore)(nil)), inspect(t, (*MeasurementAfter)(nil)),
+			migrate.TypeAlias("DOUBLE PRECISION", "float8"))
+		require.NoError(t, err)
+		require.Empty(t, changes.Operations)
+	})
+}
+
+type externalID [16]byte
+
+func TestDiff_TypeOverrides(t *testing.T) {
+	type AccountBefore struct {
+		bun.BaseModel `bun:"table:accounts"`
+		ID            externalID `bun:",pk,type:uuid"`
+		Email         string     `bun:",type:varchar(320)"`
+	}
+
+	type Account struct {
+		bun.BaseModel `bun:"table:accounts"`
+		ID            externalID `bun:",pk"`
+		Email         string
+	}
+
+	d := pgdialect.New()
+	inspect := func(t *testing.T, model interface{}, opts ...sqlschema.InspectorOption) sqlschema.Database {
+		t.Helper()
+		tables := schema.NewTables(d)
+		tables.Register(model)
+		opts = append([]sqlschema.InspectorOption{sqlschema.WithSchemaName(d.DefaultSchema())}, opts...)
+		state, err := sqlschema.NewBunModelInspector(tables, opts...).Inspect(ctx)
+		require.NoError(t, err)
+		return state
+	}
+	overrides := sqlschema.WithTypeOverrides(
+		sqlschema.TypeOverride{GoType: reflect.TypeOf(externalID{}), SQLType: "uuid"},
+		sqlschema.TypeOverride{Table: "accounts", Column: "email", SQLType: "varchar(320)"},
+	)
+
+	t.Run("types differ by default", func(t *testing.T) {
+		changes, err := migrate.Diff(d, inspect(t, (*AccountBefore)(nil)), inspect(t, (*Account)(nil)))
+		require.NoError(t, err)
+		require.Len(t, changes.Operations, 2)
+		for _, op := range changes.Operations {
+			require.IsType(t, (*migrate.ChangeColumnTypeOp)(nil), op)
+		}
+	})
+
+	t.Run("overridden types are equal", func(t *testing.T) {
+		target := inspect(t, (*Account)(nil), overrides)
+		table, _ := target.GetTables().Load("accounts")
+		email, _ := table.GetColumns().Load("email")
+		require.Equal(t, "varchar", email.GetSQLType())
+		require.Equal(t, 320, email.GetVarcharLen())
+
+		changes, err := migrate.Diff(d, inspect(t, (*AccountBefore)(nil)), target)
+		require.NoError(t, err)
+		require.Empty(t, changes.Operations)
+	})
+
+	t.Run("table is created with overridden types", func(t *testing.T) {
+		db := pg(t) // only generates SQL, does not connect to the database
+		m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+		require.NoError(t, err)
+
+		changes, err := migrate.Diff(d, sqlschema.BaseDatabase{Tables: ordered.NewMap[string, sqlschema.Table]()},
+			inspect(t, (*Account)(nil), overrides))
+		require.NoError(t, err)
+		statements, err := changes.Statements(m)
+		require.NoError(t, err)
+		require.Len(t, statements, 1)
+		require.Contains(t, statements[0].SQL, `"id" uuid NOT NULL`)
+		require.Contains(t, statements[0].SQL, `"email" varchar(320)`)
+	})
+}
+
+func TestSQLSchemaTest_Golden(t *testing.T) {
+	state := sqlschema.BaseDatabase{
+		Tables: ordered.NewMap(ordered.Pair[string, sqlschema.Table]{
+			Key: "posts",
+			Value: &sqlschema.BaseTable{
+				Name: "posts",
+				Columns: ordered.NewMap(
+					ordered.Pair[string, sqlschema.Column]{
+						Key:   "id",
+						Value: &sqlschema.BaseColumn{Name: "id", SQLType: sqltype.BigInt},
+					},
+					ordered.Pair[string, sqlschema.Column]{
+						Key:   "title",
+						Value: &sqlschema.BaseColumn{Name: "title", SQLType: sqltype.VarChar, VarcharLen: 100, IsNullable: true},
+					},
+				),
+				PrimaryKey: &sqlschema.PrimaryKey{Columns: sqlschema.NewColumns("id")},
+			},
+		}),
+	}
+	changes := &migrate.Changeset{Operations: []migrate.Operation{
+		&migrate.AddColumnOp{
+			TableName:  "posts",
+			ColumnName: "body",
+			Column:     &sqlschema.BaseColumn{Name: "body", SQLType: "text", IsNullable: true},
+		},
+		&migrate.CreateIndexOp{Index: sqlschema.Index{
+			Name:      "posts_title_idx",
+			TableName: "posts",
+			Columns:   sqlschema.NewIndexColumns("title"),
+		}},
+	}}
+
+	for _, db := range []*bun.DB{
+		pg(t), // only generates SQL, does not connect to the database
+		bun.NewDB(nil, sqlitedialect.New()),
+	} {
+		t.Run(db.Dialect().Name().String(), func(t *testing.T) {
+			sqlschematest.AssertState(t, db, state, "golden/create_posts")
+			sqlschematest.AssertChangeset(t, db, changes, "golden/alter_posts")
+		})
+	}
+
+	t.Run("whitespace is insignificant", func(t *testing.T) {
+		require.Equal(t,
+			sqlschematest.Normalize(`CREATE TABLE "posts" ("id" BIGINT, "title" VARCHAR(100));`),
+			sqlschematest.Normalize("-- posts\nCREATE TABLE \"posts\" (\n\t\"id\" BIGINT,\n\t\"title\" VARCHAR(100)\n);\n"),
+		)
+		require.NotEqual(t, sqlschematest.Normalize(`DEFAULT 'a  b'`), sqlschematest.Normalize(`DEFAULT 'a b'`))
+	})
+}
+
+func TestExport(t *testing.T) {
+	type Author struct {
+		bun.BaseModel `bun:"table:authors"`
+		ID            int64 `bun:",pk,autoincrement"`
+		Name          string
+	}
+
+	type Book struct {
+		bun.BaseModel `bun:"table:books"`
+		ID            int64 `bun:",pk,autoincrement"`
+		AuthorID      int64
+		Author        *Author `bun:"rel:belongs-to,join:author_id=id"`
+	}
+
+	type Event struct {
+		bun.BaseModel `bun:"table:audit.events"`
+		ID            int64 `bun:",pk"`
+	}
+
+	for _, tt := range []struct {
+		db     *bun.DB
+		models []interface{}
+	}{
+		{
+			db:     pg(t), // only generates SQL, does not connect to the database
+			models: []interface{}{(*Book)(nil), (*Author)(nil), (*indexedAccount)(nil), (*Event)(nil)},
+		},
+		{
+			db:     bun.NewDB(nil, sqlitedialect.New()),
+			models: []interface{}{(*Book)(nil), (*Author)(nil), (*indexedAccount)(nil)},
+		},
+	} {
+		t.Run(tt.db.Dialect().Name().String(), func(t *testing.T) {
+			d := tt.db.Dialect().(sqlschema.InspectorDialect)
+			export := func() []string {
+				tables := schema.NewTables(d)
+				tables.Register(tt.models...)
+				state, err := sqlschema.NewBunModelInspector(tables,
+					sqlschema.WithSchemaName(d.DefaultSchema()),
+					sqlschema.WithSchemas("audit"),
+				).Inspect(ctx)
+				require.NoError(t, err)
+
+				script, err := migrate.Export(tt.db, d.DefaultSchema(), state)
+				require.NoError(t, err)
+				return script
+			}
+
+			script := export()
+			sqlschematest.AssertGolden(t, "golden/export."+tt.db.Dialect().Name().String(), script...)
+			require.Equal(t, script, export(), "script is not deterministic")
+		})
+	}
+}
+
+func TestExport_IfNotExists(t *testing.T) {
+	t.Run("pg", func(t *testing.T) {
+		const dump = `
+CREATE TYPE public.mood AS ENUM ('happy', 'sad');
+
+CREATE TABLE public.authors (
+    id bigint NOT NULL,
+    mood public.mood,
+    CONSTRAINT authors_pkey PRIMARY KEY (id)
+);
+CREATE TABLE public.books (
+    id bigint NOT NULL,
+    author_id bigint,
+    CONSTRAINT books_pkey PRIMARY KEY (id)
+);
+ALTER TABLE ONLY public.books
+    ADD CONSTRAINT books_author_id_fkey FOREIGN KEY (author_id) REFERENCES public.authors(id);
+CREATE INDEX books_author_id_idx ON public.books USING btree (author_id);
+`
+		db := pg(t) // only generates SQL, does not connect to the database
+		export := func(opts ...migrate.ExportOption) []string {
+			fsys := fstest.MapFS{"schema.sql": {Data: []byte(dump)}}
+			state, err := pgdialect.NewDumpInspector(fsys, "schema.sql").Inspect(ctx)
+			require.NoError(t, err)
+			script, err := migrate.Export(db, db.Dialect().DefaultSchema(), state, opts...)
+			require.NoError(t, err)
+			return script
+		}
+
+		require.ElementsMatch(t, []string{
+			`DO $$ BEGIN CREATE TYPE "public"."mood" AS ENUM ('happy', 'sad'); EXCEPTION WHEN duplicate_object THEN NULL; END $$`,
+			`CREATE TABLE IF NOT EXISTS "public"."authors" ("id" bigint NOT NULL, "mood" mood, CONSTRAINT "authors_pkey" PRIMARY KEY ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "public"."books" ("id" bigint NOT NULL, "author_id" bigint, CONSTRAINT "books_pkey" PRIMARY KEY ("id"))`,
+			`DO $$ BEGIN ALTER TABLE "public"."books" ADD CONSTRAINT "books_author_id_fkey" FOREIGN KEY ("author_id") REFERENCES "public"."authors" ("id"); EXCEPTION WHEN duplicate_object THEN NULL; END $$`,
+			`CREATE INDEX IF NOT EXISTS "books_author_id_idx" ON "public"."books" ("author_id")`,
+		}, export(migrate.WithIfNotExists()))
+
+		// The default script keeps failing on existing objects, so that it does not hide them.
+		for _, stmt := range export() {
+			require.NotContains(t, stmt, "IF NOT EXISTS")
+			require.NotContains(t, stmt, "duplicate_object")
+		}
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		db := bun.NewDB(nil, sqlitedialect.New())
+		d := db.Dialect().(sqlschema.InspectorDialect)
+		tables := schema.NewTables(d)
+		tables.Register((*indexedAccount)(nil))
+		state, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+		require.NoError(t, err)
+
+		script, err := migrate.Export(db, d.DefaultSchema(), state, migrate.WithIfNotExists())
+		require.NoError(t, err)
+		require.NotEmpty(t, script)
+		for _, stmt := range script {
+			require.Regexp(t, `^CREATE (TABLE|(UNIQUE )?INDEX) IF NOT EXISTS `, stmt)
+		}
+	})
+}
+
+func TestExport_CircularReferences(t *testing.T) {
+	type Employee struct {
+		bun.BaseModel `bun:"table:employees"`
+		ID            int64 `bun:",pk"`
+		DepartmentID  int64
+	}
+
+	type Department struct {
+		bun.BaseModel `bun:"table:departments"`
+		ID            int64 `bun:",pk"`
+		ManagerID     int64
+		Manager       *Employee `bun:"rel:belongs-to,join:manager_id=id"`
+	}
+
+	type EmployeeInDepartment struct {
+		bun.BaseModel `bun:"table:employees"`
+		ID            int64 `bun:",pk"`
+		DepartmentID  int64
+		Department    *Department `bun:"rel:belongs-to,join:department_id=id"`
+	}
+
+	type Assignment struct {
+		bun.BaseModel `bun:"table:assignments"`
+		ID            int64 `bun:",pk"`
+		EmployeeID    int64
+		Employee      *EmployeeInDepartment `bun:"rel:belongs-to,join:employee_id=id"`
+	}
+
+	export := func(t *testing.T, db *bun.DB) []string {
+		t.Helper()
+		d := db.Dialect().(sqlschema.InspectorDialect)
+		tables := schema.NewTables(d)
+		tables.Register((*Assignment)(nil), (*EmployeeInDepartment)(nil), (*Department)(nil))
+		state, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+		require.NoError(t, err)
+		require.Len(t, state.GetForeignKeys(), 3)
+
+		script, err := migrate.Export(db, d.DefaultSchema(), state)
+		require.NoError(t, err)
+		return script
+	}
+	tableOf := func(stmt string) string {
+		head, _, _ := strings.Cut(stmt, " (")
+		for _, table := range []string{"departments", "employees", "assignments"} {
+			if strings.HasSuffix(head, `"`+table+`"`) {
+				return table
+			}
+		}
+		return ""
+	}
+
+	t.Run("pg", func(t *testing.T) {
+		script := export(t, pg(t)) // only generates SQL, does not connect to the database
+		require.Len(t, script, 6)
+		for i, table := range []string{"departments", "employees", "assignments"} {
+			require.True(t, strings.HasPrefix(script[i], "CREATE TABLE"), script[i])
+			require.Equal(t, table, tableOf(script[i]), "tables must be created after the tables they reference")
+			require.NotContains(t, script[i], "FOREIGN KEY")
+		}
+		for _, stmt := range script[3:] {
+			require.Contains(t, stmt, "ADD CONSTRAINT", "foreign keys must be added once all tables exist")
+		}
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		script := export(t, bun.NewDB(nil, sqlitedialect.New()))
+		require.Len(t, script, 3)
+		for i, table := range []string{"departments", "employees", "assignments"} {
+			require.Equal(t, table, tableOf(script[i]))
+			require.Contains(t, script[i], "FOREIGN KEY", "foreign keys must be declared inline")
+		}
+	})
+}
+
+func TestDiff_DefaultNormalization(t *testing.T) {
+	type EventBefore struct {
+		bun.BaseModel `bun:"table:events"`
+		ID            int64     `bun:",pk"`
+		Status        string    `bun:",default:'Draft'::text"`
+		Slug          string    `bun:",default:nextval('Event_Slugs'::regclass)"`
+		CreatedAt     time.Time `bun:",default:now()"`
+	}
+
+	type EventAfter struct {
+		bun.BaseModel `bun:"table:events"`
+		ID            int64     `bun:",pk"`
+		Status        string    `bun:",default:'Draft'"`
+		Slug          string    `bun:",default:NEXTVAL('Event_Slugs')"`
+		CreatedAt     time.Time `bun:",default:CURRENT_TIMESTAMP"`
+	}
+
+	type EventRenamedStatus struct {
+		bun.BaseModel `bun:"table:events"`
+		ID            int64     `bun:",pk"`
+		Status        string    `bun:",default:'draft'"`
+		Slug          string    `bun:",default:NEXTVAL('Event_Slugs')"`
+		CreatedAt     time.Time `bun:",default:CURRENT_TIMESTAMP"`
+	}
+
+	d := pgdialect.New()
+	inspect := func(t *testing.T, model interface{}) sqlschema.Database {
+		t.Helper()
+		tables := schema.NewTables(d)
+		tables.Register(model)
+		state, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+		require.NoError(t, err)
+		return state
+	}
+
+	t.Run("string literals keep their case", func(t *testing.T) {
+		col := inspect(t, (*EventAfter)(nil)).GetTables().Value("events").GetColumns().Value("slug")
+		require.Equal(t, "nextval('Event_Slugs')", col.GetDefaultValue())
+	})
+
+	t.Run("equivalent defaults", func(t *testing.T) {
+		changes, err := migrate.Diff(d, inspect(t, (*EventBefore)(nil)), inspect(t, (*EventAfter)(nil)))
+		require.NoError(t, err)
+		require.Empty(t, changes.Operations)
+	})
+
+	t.Run("literals are case-sensitive", func(t *testing.T) {
+		changes, err := migrate.Diff(d, inspect(t, (*EventBefore)(nil)), inspect(t, (*EventRenamedStatus)(nil)))
+		require.NoError(t, err)
+		require.Len(t, changes.Operations, 1)
+		op := changes.Operations[0].(*migrate.ChangeColumnDefaultOp)
+		require.Equal(t, "status", op.Column)
+		require.Equal(t, "draft", op.To)
+	})
+
+	t.Run("default changes are rendered separately", func(t *testing.T) {
+		type EventNoDefaults struct {
+			bun.BaseModel `bun:"table:events"`
+			ID            int64 `bun:",pk"`
+			Status        string
+			Slug          string    `bun:",default:NEXTVAL('Event_Slugs')"`
+			CreatedAt     time.Time `bun:",default:CURRENT_TIMESTAMP"`
+		}
+
+		changes, err := migrate.Diff(d, inspect(t, (*EventNoDefaults)(nil)), inspect(t, (*EventRenamedStatus)(nil)))
+		require.NoError(t, err)
+		m, err := sqlschema.NewMigrator(pg(t), d.DefaultSchema())
+		require.NoError(t, err)
+
+		statements, err := changes.Statements(m)
+		require.NoError(t, err)
+		require.Len(t, statements, 1)
+		require.Equal(t, `ALTER TABLE "public"."events" ALTER COLUMN "status" SET DEFAULT 'draft'`, statements[0].SQL)
+
+		statements, err = (&migrate.Changeset{Operations: []migrate.Operation{changes.Operations[0].GetReverse()}}).Statements(m)
+		require.NoError(t, err)
+		require.Equal(t, `ALTER TABLE "public"."events" ALTER COLUMN "status" DROP DEFAULT`, statements[0].SQL)
+	})
+}
+
+func TestDiff_TypeExtensions(t *testing.T) {
+	type User struct {
+		bun.BaseModel `bun:"table:users"`
+		ID            int64  `bun:",pk"`
+		Email         string `bun:",type:citext,notnull"`
+		Name          string `bun:",type:citext"`
+		Bio           string
+	}
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+
+	tables := schema.NewTables(d)
+	tables.Register((*User)(nil))
+	target, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []sqlschema.Extension{{Name: "citext"}}, target.GetExtensions(),
+		"citext columns must require the extension once")
+
+	script, err := migrate.Export(db, d.DefaultSchema(), target)
+	require.NoError(t, err)
+	require.Equal(t, `CREATE EXTENSION IF NOT EXISTS "citext"`, script[0])
+
+	fsys := fstest.MapFS{"schema.sql": {Data: []byte(strings.Join(script, ";\n") + ";")}}
+	current, err := pgdialect.NewDumpInspector(fsys, "schema.sql").Inspect(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []sqlschema.Extension{{Name: "citext"}}, current.GetExtensions())
+	require.Equal(t, "citext", current.GetTables().Value("users").GetColumns().Value("email").GetSQLType())
+
+	changes, err := migrate.Diff(d, current, target)
+	require.NoError(t, err)
+	require.Empty(t, changes.Operations, "citext columns must not be changed to text")
+}
+
+func TestDiff_Nullability(t *testing.T) {
+	type Task struct {
+		bun.BaseModel `bun:"table:tasks"`
+		ID            int64 `bun:",pk"`
+		Owner         string
+	}
+
+	type TaskNotNull struct {
+		bun.BaseModel `bun:"table:tasks"`
+		ID            int64  `bun:",pk"`
+		Owner         string `bun:",notnull"`
+	}
+
+	type TaskBackfill struct {
+		bun.BaseModel `bun:"table:tasks"`
+		ID            int64  `bun:",pk"`
+		Owner         string `bun:",notnull,backfill:'nobody'"`
+	}
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+	diff := func(t *testing.T, current, target interface{}) []migrate.Statement {
+		t.Helper()
+		inspect := func(model interface{}) sqlschema.Database {
+			tables := schema.NewTables(d)
+			tables.Register(model)
+			state, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+			require.NoError(t, err)
+			return state
+		}
+		changes, err := migrate.Diff(d, inspect(current), inspect(target))
+		require.NoError(t, err)
+		m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+		require.NoError(t, err)
+		statements, err := changes.Statements(m)
+		require.NoError(t, err)
+		require.Len(t, statements, 1)
+		require.IsType(t, (*migrate.ChangeColumnNullabilityOp)(nil), statements[0].Operation)
+		return statements
+	}
+
+	t.Run("set not null", func(t *testing.T) {
+		statements := diff(t, (*Task)(nil), (*TaskNotNull)(nil))
+		require.Equal(t, `ALTER TABLE "public"."tasks" ALTER COLUMN "owner" SET NOT NULL`, statements[0].SQL)
+	})
+
+	t.Run("set not null with backfill", func(t *testing.T) {
+		statements := diff(t, (*Task)(nil), (*TaskBackfill)(nil))
+		require.Equal(t, `UPDATE "public"."tasks" SET "owner" = 'nobody' WHERE "owner" IS NULL;`+"\n"+
+			`ALTER TABLE "public"."tasks" ALTER COLUMN "owner" SET NOT NULL`, statements[0].SQL)
+	})
+
+	t.Run("drop not null", func(t *testing.T) {
+		statements := diff(t, (*TaskNotNull)(nil), (*Task)(nil))
+		require.Equal(t, `ALTER TABLE "public"."tasks" ALTER COLUMN "owner" DROP NOT NULL`, statements[0].SQL)
+	})
+}
+
+func TestDiff_Inheritance(t *testing.T) {
+	type petFields struct {
+		ID   int64  `bun:",pk"`
+		Name string `bun:",notnull"`
+	}
+	type Pet struct {
+		bun.BaseModel `bun:"table:pets"`
+		petFields
+	}
+	type Cat struct {
+		bun.BaseModel `bun:"table:cats,inherits:pets"`
+		petFields
+		Lives int64 `bun:",notnull,default:9"`
+	}
+
+	type ownedPetFields struct {
+		ID    int64  `bun:",pk"`
+		Name  string `bun:",notnull"`
+		Owner string
+	}
+	type OwnedPet struct {
+		bun.BaseModel `bun:"table:pets"`
+		ownedPetFields
+	}
+	type OwnedCat struct {
+		bun.BaseModel `bun:"table:cats,inherits:pets"`
+		ownedPetFields
+		Lives int64 `bun:",notnull,default:9"`
+	}
+
+	const dump = `
+CREATE TABLE public.pets (
+    id bigint NOT NULL,
+    name character varying NOT NULL
+);
+CREATE TABLE public.cats (
+    lives bigint DEFAULT 9 NOT NULL
+)
+INHERITS (public.pets);
+ALTER TABLE ONLY public.pets ADD CONSTRAINT pets_pkey PRIMARY KEY (id);
+ALTER TABLE ONLY public.cats ADD CONSTRAINT cats_pkey PRIMARY KEY (id);
+`
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+	inspect := func(t *testing.T, models ...interface{}) sqlschema.Database {
+		t.Helper()
+		tables := schema.NewTables(d)
+		tables.Register(models...)
+		state, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+		require.NoError(t, err)
+		return state
+	}
+	inspectDump := func(t *testing.T) sqlschema.Database {
+		t.Helper()
+		fsys := fstest.MapFS{"schema.sql": {Data: []byte(dump)}}
+		state, err := pgdialect.NewDumpInspector(fsys, "schema.sql").Inspect(ctx)
+		require.NoError(t, err)
+		return state
+	}
+	statements := func(t *testing.T, current, target sqlschema.Database) []string {
+		t.Helper()
+		changes, err := migrate.Diff(d, current, target)
+		require.NoError(t, err)
+		m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+		require.NoError(t, err)
+		stmts, err := changes.Statements(m)
+		require.NoError(t, err)
+		var got []string
+		for _, stmt := range stmts {
+			got = append(got, stmt.SQL)
+		}
+		return got
+	}
+
+	t.Run("child tables only define their own columns", func(t *testing.T) {
+		for _, state := range []sqlschema.Database{inspect(t, (*Pet)(nil), (*Cat)(nil)), inspectDump(t)} {
+			cats := state.GetTables().Value("cats")
+			require.Equal(t, []string{"pets"}, cats.GetInherits())
+			require.Equal(t, []string{"lives"}, cats.GetColumns().Keys())
+			require.Equal(t, []string{"id", "name"}, state.GetTables().Value("pets").GetColumns().Keys())
+		}
+	})
+
+	t.Run("child table is created after its parent", func(t *testing.T) {
+		got := statements(t, inspect(t), inspect(t, (*Cat)(nil), (*Pet)(nil)))
+		require.Len(t, got, 2)
+		require.Contains(t, got[0], `CREATE TABLE "pets"`)
+		require.Equal(t, `CREATE TABLE "public"."cats" ("lives" bigint NOT NULL DEFAULT 9, PRIMARY KEY ("id")) INHERITS ("public"."pets")`, got[1])
+	})
+
+	t.Run("inherited columns are not diffed", func(t *testing.T) {
+		require.Empty(t, statements(t, inspectDump(t), inspect(t, (*Pet)(nil), (*Cat)(nil))))
+	})
+
+	t.Run("columns are added to the parent only", func(t *testing.T) {
+		got := statements(t, inspectDump(t), inspect(t, (*OwnedPet)(nil), (*OwnedCat)(nil)))
+		require.Equal(t, []string{`ALTER TABLE "public"."pets" ADD COLUMN "owner" varchar`}, got)
+	})
+}
+
+func TestDiff_CompositePrimaryKey(t *testing.T) {
+	type Membership struct {
+		bun.BaseModel `bun:"table:memberships"`
+		GroupID       int64 `bun:",pk"`
+		UserID        int64 `bun:",pk"`
+		Role          string
+	}
+
+	type MembershipByUser struct {
+		bun.BaseModel `bun:"table:memberships"`
+		UserID        int64 `bun:",pk"`
+		GroupID       int64 `bun:",pk"`
+		Role          string
+	}
+
+	type MembershipByRole struct {
+		bun.BaseModel `bun:"table:memberships"`
+		GroupID       int64  `bun:",pk"`
+		UserID        int64  `bun:",pk"`
+		Role          string `bun:",pk"`
+	}
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+	fsys := fstest.MapFS{"schema.sql": {Data: []byte(`
+CREATE TABLE public.memberships (
+    group_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    role character varying,
+    CONSTRAINT memberships_pkey PRIMARY KEY (group_id, user_id)
+);`)}}
+	diff := func(t *testing.T, model interface{}) []migrate.Statement {
+		t.Helper()
+		current, err := pgdialect.NewDumpInspector(fsys, "schema.sql").Inspect(ctx)
+		require.NoError(t, err)
+		require.Equal(t, &sqlschema.PrimaryKey{Name: "memberships_pkey", Columns: sqlschema.NewColumns("group_id", "user_id")},
+			current.GetTables().Value("memberships").GetPrimaryKey())
+
+		tables := schema.NewTables(d)
+		tables.Register(model)
+		target, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+		require.NoError(t, err)
+
+		changes, err := migrate.Diff(d, current, target)
+		require.NoError(t, err)
+		m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+		require.NoError(t, err)
+		statements, err := changes.Statements(m)
+		require.NoError(t, err)
+		return statements
+	}
+
+	t.Run("same columns in the same order", func(t *testing.T) {
+		require.Empty(t, diff(t, (*Membership)(nil)))
+	})
+
+	t.Run("columns in a different order", func(t *testing.T) {
+		statements := diff(t, (*MembershipByUser)(nil))
+		require.Len(t, statements, 1)
+		require.Equal(t, &migrate.ChangePrimaryKeyOp{
+			TableName: "memberships",
+			Old:       sqlschema.PrimaryKey{Name: "memberships_pkey", Columns: sqlschema.NewColumns("group_id", "user_id")},
+			New:       sqlschema.PrimaryKey{Columns: sqlschema.NewColumns("user_id", "group_id")},
+		}, statements[0].Operation)
+		require.Equal(t, `ALTER TABLE "public"."memberships" DROP CONSTRAINT "memberships_pkey", ADD PRIMARY KEY ("user_id", "group_id")`,
+			statements[0].SQL)
+	})
+
+	t.Run("additional column", func(t *testing.T) {
+		statements := diff(t, (*MembershipByRole)(nil))
+		require.Len(t, statements, 2)
+		require.Equal(t, `ALTER TABLE "public"."memberships" ALTER COLUMN "role" SET NOT NULL`, statements[0].SQL)
+		require.Equal(t, `ALTER TABLE "public"."memberships" DROP CONSTRAINT "memberships_pkey", ADD PRIMARY KEY ("group_id", "user_id", "role")`,
+			statements[1].SQL)
+	})
+}
+
+func TestDiff_PrimaryKeyChanges(t *testing.T) {
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+	diff := func(t *testing.T, current, target string) ([]migrate.Statement, error) {
+		t.Helper()
+		fsys := fstest.MapFS{"current.sql": {Data: []byte(current)}, "target.sql": {Data: []byte(target)}}
+		got, err := pgdialect.NewDumpInspector(fsys, "current.sql").Inspect(ctx)
+		require.NoError(t, err)
+		want, err := pgdialect.NewDumpInspector(fsys, "target.sql").Inspect(ctx)
+		require.NoError(t, err)
+
+		changes, err := migrate.Diff(d, got, want)
+		if err != nil {
+			return nil, err
+		}
+		m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+		require.NoError(t, err)
+		return changes.Statements(m)
+	}
+	sql := func(statements []migrate.Statement) []string {
+		var s []string
+		for _, stmt := range statements {
+			s = append(s, stmt.SQL)
+		}
+		return s
+	}
+
+	t.Run("adds primary key to an existing table", func(t *testing.T) {
+		statements, err := diff(t,
+			`CREATE TABLE public.tags (name text NOT NULL);`,
+			`CREATE TABLE public.tags (name text NOT NULL, PRIMARY KEY (name));`)
+		require.NoError(t, err)
+		require.Equal(t, []string{`ALTER TABLE "public"."tags" ADD PRIMARY KEY ("name")`}, sql(statements))
+	})
+
+	t.Run("adds primary key after the new column", func(t *testing.T) {
+		statements, err := diff(t,
+			`CREATE TABLE public.tags (name text NOT NULL);`,
+			`CREATE TABLE public.tags (id bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY, name text NOT NULL);`)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			`ALTER TABLE "public"."tags" ADD COLUMN "id" bigint GENERATED BY DEFAULT AS IDENTITY`,
+			`ALTER TABLE "public"."tags" ADD PRIMARY KEY ("id")`,
+		}, sql(statements))
+	})
+
+	t.Run("changes primary key columns after the new column", func(t *testing.T) {
+		statements, err := diff(t,
+			`CREATE TABLE public.tags (name text NOT NULL, CONSTRAINT tags_pkey PRIMARY KEY (name));`,
+			`CREATE TABLE public.tags (name text NOT NULL, version integer DEFAULT 1 NOT NULL, CONSTRAINT tags_pkey PRIMARY KEY (name, version));`)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			`ALTER TABLE "public"."tags" ADD COLUMN "version" integer NOT NULL DEFAULT 1 `,
+			`ALTER TABLE "public"."tags" DROP CONSTRAINT "tags_pkey", ADD PRIMARY KEY ("name", "version")`,
+		}, sql(statements))
+	})
+
+	t.Run("drops primary key before making its column nullable", func(t *testing.T) {
+		statements, err := diff(t,
+			`CREATE TABLE public.tags (name text NOT NULL, CONSTRAINT tags_pkey PRIMARY KEY (name));`,
+			`CREATE TABLE public.tags (name text);`)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			`ALTER TABLE "public"."tags" DROP CONSTRAINT "tags_pkey"`,
+			`ALTER TABLE "public"."tags" ALTER COLUMN "name" DROP NOT NULL`,
+		}, sql(statements))
+	})
+
+	t.Run("recreates foreign keys that reference the primary key", func(t *testing.T) {
+		statements, err := diff(t, `
+CREATE TABLE public.authors (id bigint NOT NULL, tenant_id bigint NOT NULL, CONSTRAINT authors_pkey PRIMARY KEY (id));
+CREATE TABLE public.books (author_id bigint CONSTRAINT books_author_id_fkey REFERENCES public.authors (id));`, `
+CREATE TABLE public.authors (
+	id bigint NOT NULL, tenant_id bigint NOT NULL,
+	CONSTRAINT authors_pkey PRIMARY KEY (tenant_id, id),
+	CONSTRAINT authors_id_key UNIQUE (id)
+);
+CREATE TABLE public.books (author_id bigint CONSTRAINT books_author_id_fkey REFERENCES public.authors (id));`)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			`ALTER TABLE "public"."authors" ADD CONSTRAINT "authors_id_key" UNIQUE ("id")`,
+			`ALTER TABLE "public"."books" DROP CONSTRAINT "books_author_id_fkey"`,
+			`ALTER TABLE "public"."authors" DROP CONSTRAINT "authors_pkey", ADD PRIMARY KEY ("tenant_id", "id")`,
+			`ALTER TABLE "public"."books" ADD CONSTRAINT "books_author_id_fkey" FOREIGN KEY ("author_id") REFERENCES "public"."authors" ("id")`,
+		}, sql(statements))
+	})
+
+	t.Run("refuses to drop primary key that backs a foreign key", func(t *testing.T) {
+		_, err := diff(t, `
+CREATE TABLE public.authors (id bigint NOT NULL PRIMARY KEY);
+CREATE TABLE public.books (author_id bigint CONSTRAINT books_author_id_fkey REFERENCES public.authors (id));`, `
+CREATE TABLE public.authors (id bigint NOT NULL);
+CREATE TABLE public.books (author_id bigint CONSTRAINT books_author_id_fkey REFERENCES public.authors (id));`)
+		require.ErrorContains(t, err, `cannot drop primary key (id) of table "authors": foreign key books(author_id) -> authors(id) references it`)
+	})
+
+	t.Run("drops foreign key before the primary key it references", func(t *testing.T) {
+		statements, err := diff(t, `
+CREATE TABLE public.authors (id bigint NOT NULL, CONSTRAINT authors_pkey PRIMARY KEY (id));
+CREATE TABLE public.books (author_id bigint CONSTRAINT books_author_id_fkey REFERENCES public.authors (id));`, `
+CREATE TABLE public.authors (id bigint NOT NULL);
+CREATE TABLE public.books (author_id bigint);`)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			`ALTER TABLE "public"."books" DROP CONSTRAINT "books_author_id_fkey"`,
+			`ALTER TABLE "public"."authors" DROP CONSTRAINT "authors_pkey"`,
+		}, sql(statements))
+	})
+}
+
+func TestDiff_QuotedIdentifiers(t *testing.T) {
+	type Account struct {
+		bun.BaseModel `bun:"table:accounts"`
+		UserID        int64  `bun:"userID,pk"`
+		DisplayName   string `bun:"displayName"`
+		Email         string `bun:"email"`
+	}
+
+	type AccountLowercase struct {
+		bun.BaseModel `bun:"table:accounts"`
+		UserID        int64  `bun:"userid,pk"`
+		DisplayName   string `bun:"displayName"`
+		Email         string `bun:"email"`
+	}
+
+	d := pgdialect.New()
+	fsys := fstest.MapFS{"schema.sql": {Data: []byte(`
+CREATE TABLE public.accounts (
+    "userID" bigint NOT NULL,
+    "displayName" character varying,
+    email character varying,
+    CONSTRAINT accounts_pkey PRIMARY KEY ("userID")
+);`)}}
+	inspectDump := func(t *testing.T) sqlschema.Database {
+		t.Helper()
+		state, err := pgdialect.NewDumpInspector(fsys, "schema.sql").Inspect(ctx)
+		require.NoError(t, err)
+		return state
+	}
+	diff := func(t *testing.T, model interface{}) []migrate.Operation {
+		t.Helper()
+		tables := schema.NewTables(d)
+		tables.Register(model)
+		target, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+		require.NoError(t, err)
+		changes, err := migrate.Diff(d, inspectDump(t), target)
+		require.NoError(t, err)
+		return changes.Operations
+	}
+
+	t.Run("inspector keeps the case", func(t *testing.T) {
+		columns := inspectDump(t).GetTables().Value("accounts").GetColumns()
+		require.Equal(t, []string{"userID", "displayName", "email"}, columns.Keys())
+	})
+
+	t.Run("camelCase columns converge", func(t *testing.T) {
+		require.Empty(t, diff(t, (*Account)(nil)))
+	})
+
+	t.Run("column names are case-sensitive", func(t *testing.T) {
+		require.NotEmpty(t, diff(t, (*AccountLowercase)(nil)))
+	})
+
+	t.Run("expressions keep the case of quoted identifiers", func(t *testing.T) {
+		require.Equal(t, `"userID" > 0`, sqlschema.LowerExpr(`"userID" > 0`))
+		require.NotEqual(t, sqlschema.NormalizeExpr(`"userID" > 0`), sqlschema.NormalizeExpr(`userID > 0`))
+		require.Equal(t, sqlschema.NormalizeExpr(`"email" <> ''`), sqlschema.NormalizeExpr(`EMAIL <> ''::text`))
+		require.Equal(t, `"a""B"`, sqlschema.NormalizeExpr(`"a""B"`))
+	})
+}
+
+func TestDiff_Views(t *testing.T) {
+	type Report struct {
+		bun.BaseModel `bun:"table:reports"`
+		ID            int64 `bun:",pk"`
+	}
+
+	type Summary struct {
+		bun.BaseModel `bun:"table:summaries"`
+		ID            int64 `bun:",pk"`
+		Total         int64
+	}
+
+	d := pgdialect.New()
+	inspect := func(t *testing.T, model interface{}, views ...sqlschema.View) sqlschema.Database {
+		t.Helper()
+		tables := schema.NewTables(d)
+		tables.Register(model)
+		state, err := sqlschema.NewBunModelInspector(tables,
+			sqlschema.WithSchemaName(d.DefaultSchema()),
+			sqlschema.WithViews(views...),
+		).Inspect(ctx)
+		require.NoError(t, err)
+		return state
+	}
+
+	current := inspect(t, (*Report)(nil),
+		sqlschema.View{Name: "report_ids", Definition: "SELECT id FROM reports"},
+		sqlschema.View{Name: "totals", Definition: "SELECT count(*) FROM reports"},
+		sqlschema.View{Name: "unchanged", Definition: "SELECT 1"},
+	)
+	target := inspect(t, (*Summary)(nil),
+		sqlschema.View{Name: "totals", Definition: "SELECT count(*) FROM summaries"},
+		sqlschema.View{Name: "unchanged", Definition: "select  1;"},
+		sqlschema.View{Schema: "reporting", Name: "ignored", Definition: "SELECT 1"},
+	)
+
+	changes, err := migrate.Diff(d, current, target)
+	require.NoError(t, err)
+
+	var got []string
+	for _, op := range changes.Operations {
+		switch op := op.(type) {
+		case *migrate.CreateTableOp:
+			got = append(got, "create table "+op.TableName)
+		case *migrate.DropTableOp:
+			got = append(got, "drop table "+op.TableName)
+		case *migrate.CreateViewOp:
+			got = append(got, "create view "+op.View.Name)
+		case *migrate.DropViewOp:
+			got = append(got, "drop view "+op.View.Name)
+		default:
+			t.Errorf("unexpected operation %T", op)
+		}
+	}
+
+	// Views are dropped before and created after the tables, which they may select from, are changed.
+	require.Len(t, got, 5)
+	require.ElementsMatch(t, []string{"drop view totals", "drop view report_ids"}, got[:2])
+	require.ElementsMatch(t, []string{"create table summaries", "drop table reports"}, got[2:4])
+	require.Equal(t, "create view totals", got[4])
+}
+
+func TestDiff_MaterializedViews(t *testing.T) {
+	db := pg(t) // only generates SQL, does not connect to the database
+	m, err := sqlschema.NewMigrator(db, db.Dialect().DefaultSchema())
+	require.NoError(t, err)
+
+	state := func(views ...sqlschema.View) sqlschema.Database {
+		return sqlschema.BaseDatabase{Tables: ordered.NewMap[string, sqlschema.Table](), Views: views}
+	}
+	current := state(
+		sqlschema.View{Schema: "public", Name: "daily", Definition: "SELECT 1", Materialized: true},
+		sqlschema.View{Schema: "public", Name: "weekly", Definition: "SELECT 2", Materialized: true, Populated: true},
+	)
+	target := state(
+		sqlschema.View{Schema: "public", Name: "daily", Definition: "SELECT 1", Materialized: true, Populated: true},
+		sqlschema.View{Schema: "public", Name: "weekly", Definition: "SELECT 2"},
+		sqlschema.View{Schema: "public", Name: "monthly", Definition: "SELECT 3", Materialized: true},
+	)
+
+	changes, err := migrate.Diff(db.Dialect().(sqlschema.InspectorDialect), current, target)
+	require.NoError(t, err)
+	statements, err := changes.Statements(m)
+	require.NoError(t, err)
+
+	var got []string
+	for _, stmt := range statements {
+		got = append(got, stmt.SQL)
+	}
+	require.Equal(t, []string{
+		`DROP MATERIALIZED VIEW "public"."weekly"`,
+		`CREATE VIEW "public"."weekly" AS SELECT 2`,
+		`CREATE MATERIALIZED VIEW "public"."monthly" AS SELECT 3 WITH NO DATA`,
+		`REFRESH MATERIALIZED VIEW "public"."daily"`,
+	}, got)
+}
+
+func TestDiff_Sequences(t *testing.T) {
+	type Order struct {
+		bun.BaseModel `bun:"table:orders"`
+		ID            int64 `bun:",pk,autoincrement"`
+	}
+
+	type Invoice struct {
+		bun.BaseModel `bun:"table:invoices"`
+		Number        int64
+	}
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+	inspect := func(t *testing.T, models []interface{}, sequences ...sqlschema.Sequence) sqlschema.Database {
+		t.Helper()
+		tables := schema.NewTables(d)
+		tables.Register(models...)
+		state, err := sqlschema.NewBunModelInspector(tables,
+			sqlschema.WithSchemaName(d.DefaultSchema()),
+			sqlschema.WithSequences(sequences...),
+		).Inspect(ctx)
+		require.NoError(t, err)
+		return state
+	}
+
+	current := inspect(t, []interface{}{(*Order)(nil)},
+		sqlschema.Sequence{Name: "order_no", Start: 1, Increment: 1, MinValue: 1, MaxValue: math.MaxInt64},
+		sqlschema.Sequence{Name: "legacy_no", Start: 1, Increment: 1, MinValue: 1, MaxValue: math.MaxInt64},
+		sqlschema.Sequence{Name: "orders_id_seq", OwnedBy: sqlschema.NewColumnReference("orders", "id")},
+	)
+	target := inspect(t, []interface{}{(*Order)(nil), (*Invoice)(nil)},
+		sqlschema.Sequence{Name: "order_no", Increment: 10},
+		sqlschema.Sequence{Name: "invoice_no", Start: 1000, OwnedBy: sqlschema.NewColumnReference("invoices", "number")},
+	)
+
+	changes, err := migrate.Diff(d, current, target)
+	require.NoError(t, err)
+
+	m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+	require.NoError(t, err)
+
+	var got []string
+	for _, op := range changes.Operations {
+		if create, ok := op.(*migrate.CreateTableOp); ok {
+			got = append(got, "create table "+create.TableName)
+			continue
+		}
+		b, err := m.AppendSQL(nil, op)
+		require.NoError(t, err)
+		got = append(got, string(b))
+	}
+
+	// Sequences of serial columns are not dropped, and sequences owned by a new table are created after it.
+	createSequence := `CREATE SEQUENCE "public"."invoice_no" START WITH 1000 OWNED BY "public"."invoices"."number"`
+	require.ElementsMatch(t, []string{
+		`ALTER SEQUENCE "public"."order_no" INCREMENT BY 10`,
+		`DROP SEQUENCE "public"."legacy_no"`,
+		"create table invoices",
+		createSequence,
+	}, got)
+	require.Less(t, slices.Index(got, "create table invoices"), slices.Index(got, createSequence))
+}
+
+func TestDiff_Domains(t *testing.T) {
+	type Subscriber struct {
+		bun.BaseModel `bun:"table:subscribers"`
+		Email         string `bun:",type:email"`
+	}
+
+	type Account struct {
+		bun.BaseModel `bun:"table:accounts"`
+		Login         string `bun:",type:login"`
+	}
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+	inspect := func(t *testing.T, models []interface{}, domains ...sqlschema.Domain) sqlschema.Database {
+		t.Helper()
+		tables := schema.NewTables(d)
+		tables.Register(models...)
+		state, err := sqlschema.NewBunModelInspector(tables,
+			sqlschema.WithSchemaName(d.DefaultSchema()),
+			sqlschema.WithDomains(domains...),
+		).Inspect(ctx)
+		require.NoError(t, err)
+		return state
+	}
+
+	email := sqlschema.Domain{
+		Name:     "email",
+		DataType: "text",
+		NotNull:  true,
+		Checks:   []sqlschema.Check{{Name: "email_check", Expression: "VALUE ~ '^.+@.+$'"}},
+	}
+	current := inspect(t, []interface{}{(*Account)(nil)},
+		sqlschema.Domain{Name: "login", DataType: "varchar(32)"},
+		sqlschema.Domain{Name: "legacy", DataType: "text"},
+	)
+	target := inspect(t, []interface{}{(*Account)(nil), (*Subscriber)(nil)},
+		email,
+		sqlschema.Domain{Name: "login", DataType: "varchar(64)"},
+	)
+
+	changes, err := migrate.Diff(d, current, target)
+	require.NoError(t, err)
+
+	m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+	require.NoError(t, err)
+
+	statements, err := changes.Statements(m)
+	require.NoError(t, err)
+
+	var got []string
+	for _, stmt := range statements {
+		if _, ok := stmt.Operation.(*migrate.CreateTableOp); ok {
+			got = append(got, "create table subscribers")
+			continue
+		}
+		got = append(got, stmt.SQL)
+	}
+
+	// Domain "login" is still used by the accounts table, so it is not dropped, and its change is only reported.
+	createDomain := `CREATE DOMAIN "public"."email" AS text NOT NULL CONSTRAINT "email_check" CHECK (VALUE ~ '^.+@.+$')`
+	require.ElementsMatch(t, []string{
+		createDomain,
+		`DROP DOMAIN "public"."legacy"`,
+		"create table subscribers",
+	}, got)
+	require.Less(t, slices.Index(got, createDomain), slices.Index(got, "create table subscribers"))
+
+	t.Run("resolves domains to their base types", func(t *testing.T) {
+		eq := migrate.DomainBaseType(email, sqlschema.Domain{Name: "login", DataType: "varchar(32)"})
+		require.True(t, eq(&sqlschema.BaseColumn{SQLType: "email"}, &sqlschema.BaseColumn{SQLType: "TEXT"}))
+		require.True(t, eq(&sqlschema.BaseColumn{SQLType: "varchar", VarcharLen: 32}, &sqlschema.BaseColumn{SQLType: "login"}))
+		require.False(t, eq(&sqlschema.BaseColumn{SQLType: "varchar", VarcharLen: 64}, &sqlschema.BaseColumn{SQLType: "login"}))
+		require.False(t, eq(&sqlschema.BaseColumn{SQLType: "text"}, &sqlschema.BaseColumn{SQLType: "text"}),
+			"columns which do not use the domains are left to other rules")
+	})
+}
+
+func TestDiff_CompositeTypes(t *testing.T) {
+	type Customer struct {
+		bun.BaseModel `bun:"table:customers"`
+		Address       string `bun:",type:address"`
+	}
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+	inspect := func(t *testing.T, models []interface{}, types ...sqlschema.CompositeType) sqlschema.Database {
+		t.Helper()
+		tables := schema.NewTables(d)
+		tables.Register(models...)
+		state, err := sqlschema.NewBunModelInspector(tables,
+			sqlschema.WithSchemaName(d.DefaultSchema()),
+			sqlschema.WithCompositeTypes(types...),
+		).Inspect(ctx)
+		require.NoError(t, err)
+		return state
+	}
+
+	current := inspect(t, nil,
+		sqlschema.CompositeType{Name: "money_amount", Attributes: []sqlschema.CompositeAttribute{
+			{Name: "amount", DataType: "numeric"},
+			{Name: "currency", DataType: "text"},
+			{Name: "rate", DataType: "numeric"},
+		}},
+		sqlschema.CompositeType{Name: "legacy", Attributes: []sqlschema.CompositeAttribute{{Name: "id", DataType: "integer"}}},
+	)
+	target := inspect(t, []interface{}{(*Customer)(nil)},
+		sqlschema.CompositeType{Name: "money_amount", Attributes: []sqlschema.CompositeAttribute{
+			{Name: "amount", DataType: "numeric"},
+			{Name: "currency", DataType: "TEXT"},
+			{Name: "precision", DataType: "integer"},
+		}},
+		sqlschema.CompositeType{Name: "address", Attributes: []sqlschema.CompositeAttribute{
+			{Name: "street", DataType: "text"},
+			{Name: "city", DataType: "text"},
+		}},
+	)
+
+	changes, err := migrate.Diff(d, current, target)
+	require.NoError(t, err)
+
+	m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+	require.NoError(t, err)
+
+	statements, err := changes.Statements(m)
+	require.NoError(t, err)
+
+	var got []string
+	for _, stmt := range statements {
+		if _, ok := stmt.Operation.(*migrate.CreateTableOp); ok {
+			got = append(got, "create table customers")
+			continue
+		}
+		got = append(got, stmt.SQL)
+	}
+
+	createType := `CREATE TYPE "public"."address" AS ("street" text, "city" text)`
+	require.ElementsMatch(t, []string{
+		createType,
+		`ALTER TYPE "public"."money_amount" ADD ATTRIBUTE "precision" integer`,
+		`ALTER TYPE "public"."money_amount" DROP ATTRIBUTE "rate"`,
+		`DROP TYPE "public"."legacy"`,
+		"create table customers",
+	}, got)
+	require.Less(t, slices.Index(got, createType), slices.Index(got, "create table customers"))
+}
+
+func TestDiff_Extensions(t *testing.T) {
+	type User struct {
+		bun.BaseModel `bun:"table:users"`
+		ID            string `bun:",pk,type:uuid,default:gen_random_uuid()"`
+		Email         string `bun:",type:citext"`
+	}
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+
+	tables := schema.NewTables(d)
+	tables.Register((*User)(nil))
+	target, err := sqlschema.NewBunModelInspector(tables,
+		sqlschema.WithSchemaName(d.DefaultSchema()),
+		sqlschema.WithExtensions(
+			sqlschema.Extension{Name: "citext"},
+			sqlschema.Extension{Name: "pgcrypto", Schema: "extensions", Version: "1.3"},
+		),
+	).Inspect(ctx)
+	require.NoError(t, err)
+
+	current := sqlschema.BaseDatabase{
+		Tables: ordered.NewMap[string, sqlschema.Table](),
+		Extensions: []sqlschema.Extension{
+			{Name: "plpgsql", Schema: "pg_catalog", Version: "1.0"},
+			{Name: "citext", Schema: "public", Version: "1.6"},
+		},
+	}
+
+	changes, err := migrate.Diff(d, current, target)
+	require.NoError(t, err)
+
+	m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+	require.NoError(t, err)
+
+	statements, err := changes.Statements(m)
+	require.NoError(t, err)
+	require.Len(t, statements, 2, "installed extensions must not be created or dropped")
+	require.Equal(t, `CREATE EXTENSION IF NOT EXISTS "pgcrypto" WITH SCHEMA "extensions" VERSION '1.3'`, statements[0].SQL)
+	require.IsType(t, (*migrate.CreateTableOp)(nil), statements[1].Operation)
+}
+
+func TestDiff_Triggers(t *testing.T) {
+	type Account struct {
+		bun.BaseModel `bun:"table:accounts"`
+		ID            int64 `bun:",pk"`
+	}
+
+	type Payment struct {
+		bun.BaseModel `bun:"table:payments"`
+		ID            int64 `bun:",pk"`
+	}
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+	inspect := func(t *testing.T, models []interface{}, triggers ...sqlschema.Trigger) sqlschema.Database {
+		t.Helper()
+		tables := schema.NewTables(d)
+		tables.Register(models...)
+		state, err := sqlschema.NewBunModelInspector(tables,
+			sqlschema.WithSchemaName(d.DefaultSchema()),
+			sqlschema.WithTriggers(triggers...),
+		).Inspect(ctx)
+		require.NoError(t, err)
+		return state
+	}
+	trigger := func(name, table, timing string, events ...string) sqlschema.Trigger {
+		return sqlschema.Trigger{
+			Name: name, TableName: table, Timing: timing, Events: events, ForEachRow: true,
+			Body: "EXECUTE FUNCTION audit()",
+		}
+	}
+
+	changed := trigger("accounts_audit", "accounts", "AFTER", "INSERT")
+	changed.ForEachRow = false
+	current := inspect(t, []interface{}{(*Account)(nil)},
+		trigger("accounts_touch", "accounts", "BEFORE", "UPDATE", "INSERT"),
+		changed,
+		trigger("accounts_stale", "accounts", "AFTER", "DELETE"),
+	)
+	target := inspect(t, []interface{}{(*Account)(nil), (*Payment)(nil)},
+		trigger("accounts_touch", "accounts", "before", "insert", "update"),
+		trigger("accounts_audit", "accounts", "AFTER", "INSERT"),
+		trigger("payments_audit", "payments", "AFTER", "INSERT", "UPDATE"),
+	)
+
+	changes, err := migrate.Diff(d, current, target)
+	require.NoError(t, err)
+
+	m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+	require.NoError(t, err)
+
+	var got []string
+	for _, op := range changes.Operations {
+		if create, ok := op.(*migrate.CreateTableOp); ok {
+			got = append(got, "create table "+create.TableName)
+			continue
+		}
+		b, err := m.AppendSQL(nil, op)
+		require.NoError(t, err)
+		got = append(got, string(b))
+	}
+
+	// Triggers are compared regardless of the order and case of their events, changed triggers are replaced,
+	// and triggers on new tables are created after the table.
+	dropChanged := `DROP TRIGGER "accounts_audit" ON "public"."accounts"`
+	createChanged := `CREATE TRIGGER "accounts_audit" AFTER INSERT ON "public"."accounts" FOR EACH ROW EXECUTE FUNCTION audit()`
+	createNew := `CREATE TRIGGER "payments_audit" AFTER INSERT OR UPDATE ON "public"."payments" FOR EACH ROW EXECUTE FUNCTION audit()`
+	require.ElementsMatch(t, []string{
+		dropChanged,
+		createChanged,
+		`DROP TRIGGER "accounts_stale" ON "public"."accounts"`,
+		"create table payments",
+		createNew,
+	}, got)
+	require.Less(t, slices.Index(got, dropChanged), slices.Index(got, createChanged))
+	require.Less(t, slices.Index(got, "create table payments"), slices.Index(got, createNew))
+}
+
+func TestDiff_Policies(t *testing.T) {
+	type Document struct {
+		bun.BaseModel `bun:"table:documents,rls"`
+		ID            int64 `bun:",pk"`
+		OwnerID       int64
+	}
+
+	type Note struct {
+		bun.BaseModel `bun:"table:notes,rls:force"`
+		ID            int64 `bun:",pk"`
+		OwnerID       int64
+	}
+
+	const dump = `
+CREATE TABLE public.documents (
+    id bigint NOT NULL,
+    owner_id bigint
+);
+ALTER TABLE public.documents ENABLE ROW LEVEL SECURITY;
+ALTER TABLE ONLY public.documents
+    ADD CONSTRAINT documents_pkey PRIMARY KEY (id);
+
+CREATE POLICY documents_owner ON public.documents USING ((owner_id = (current_setting('app.user_id'::text))::bigint));
+CREATE POLICY documents_read ON public.documents FOR SELECT TO reader USING (true);
+CREATE POLICY documents_insert ON public.documents FOR INSERT WITH CHECK ((owner_id IS NOT NULL));
+CREATE POLICY documents_stale ON public.documents FOR DELETE USING (false);
+`
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+
+	fsys := fstest.MapFS{"schema.sql": {Data: []byte(dump)}}
+	current, err := pgdialect.NewDumpInspector(fsys, "schema.sql").Inspect(ctx)
+	require.NoError(t, err)
+	require.Equal(t, sqlschema.RowSecurity{Enabled: true}, current.GetTables().Value("documents").GetRowSecurity())
+	require.Len(t, current.GetPolicies(), 4)
+
+	tables := schema.NewTables(d)
+	tables.Register((*Document)(nil), (*Note)(nil))
+	target, err := sqlschema.NewBunModelInspector(tables,
+		sqlschema.WithSchemaName(d.DefaultSchema()),
+		sqlschema.WithPolicies(
+			sqlschema.Policy{
+				Name: "documents_owner", TableName: "documents",
+				Using: "owner_id = current_setting('app.user_id')::bigint",
+			},
+			sqlschema.Policy{
+				Name: "documents_read", TableName: "documents", Command: "select",
+				Roles: []string{"reader", "auditor"}, Using: "true",
+			},
+			sqlschema.Policy{
+				Name: "documents_insert", TableName: "documents", Command: "INSERT", Restrictive: true,
+				WithCheck: "owner_id IS NOT NULL",
+			},
+			sqlschema.Policy{
+				Name: "notes_owner", TableName: "notes",
+				Using: "owner_id = current_setting('app.user_id')::bigint",
+			},
+		),
+	).Inspect(ctx)
+	require.NoError(t, err)
+
+	changes, err := migrate.Diff(d, current, target)
+	require.NoError(t, err)
+
+	m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+	require.NoError(t, err)
+
+	var got []string
+	for _, op := range changes.Operations {
+		if create, ok := op.(*migrate.CreateTableOp); ok {
+			got = append(got, "create table "+create.TableName)
+			continue
+		}
+		b, err := m.AppendSQL(nil, op)
+		require.NoError(t, err)
+		got = append(got, string(b))
+	}
+
+	// Expressions are compared without the parentheses and casts Postgres adds. Policies whose roles changed
+	// are altered, while the ones with a different kind are replaced.
+	dropChanged := `DROP POLICY "documents_insert" ON "public"."documents"`
+	createChanged := `CREATE POLICY "documents_insert" ON "public"."documents" AS RESTRICTIVE FOR INSERT WITH CHECK (owner_id IS NOT NULL)`
+	enableNew := `ALTER TABLE "public"."notes" ENABLE ROW LEVEL SECURITY, FORCE ROW LEVEL SECURITY`
+	createNew := `CREATE POLICY "notes_owner" ON "public"."notes" USING (owner_id = current_setting('app.user_id')::bigint)`
+	require.ElementsMatch(t, []string{
+		`ALTER POLICY "documents_read" ON "public"."documents" TO "auditor", "reader" USING (true)`,
+		dropChanged,
+		createChanged,
+		`DROP POLICY "documents_stale" ON "public"."documents"`,
+		"create table notes",
+		enableNew,
+		createNew,
+	}, got)
+	require.Less(t, slices.Index(got, dropChanged), slices.Index(got, createChanged))
+	require.Less(t, slices.Index(got, "create table notes"), slices.Index(got, enableNew))
+	require.Less(t, slices.Index(got, "create table notes"), slices.Index(got, createNew))
+
+	t.Run("row level security is kept if no policies are defined", func(t *testing.T) {
+		tables := schema.NewTables(d)
+		tables.Register((*struct {
+			bun.BaseModel `bun:"table:documents"`
+			ID            int64 `bun:",pk"`
+			OwnerID       int64
+		})(nil))
+		target, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+		require.NoError(t, err)
+
+		changes, err := migrate.Diff(d, current, target)
+		require.NoError(t, err)
+		require.Empty(t, changes.Operations)
+	})
+}
+
+func TestDiff_Grants(t *testing.T) {
+	type Document struct {
+		bun.BaseModel `bun:"table:documents"`
+		ID            int64 `bun:",pk"`
+		Title         string
+	}
+
+	type Note struct {
+		bun.BaseModel `bun:"table:notes"`
+		ID            int64 `bun:",pk"`
+	}
+
+	const dump = `
+CREATE TABLE public.documents (
+    id bigint NOT NULL,
+    title character varying
+);
+ALTER TABLE public.documents OWNER TO bun;
+ALTER TABLE ONLY public.documents
+    ADD CONSTRAINT documents_pkey PRIMARY KEY (id);
+
+GRANT ALL ON TABLE public.documents TO bun;
+GRANT SELECT ON TABLE public.documents TO reader;
+GRANT SELECT,DELETE ON TABLE public.documents TO editor;
+GRANT UPDATE(title) ON TABLE public.documents TO editor;
+`
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+
+	inspectDump := func(t *testing.T, options ...sqlschema.InspectorOption) sqlschema.Database {
+		t.Helper()
+		fsys := fstest.MapFS{"schema.sql": {Data: []byte(dump)}}
+		state, err := pgdialect.NewDumpInspector(fsys, "schema.sql", options...).Inspect(ctx)
+		require.NoError(t, err)
+		return state
+	}
+	require.Empty(t, inspectDump(t).GetGrants())
+
+	current := inspectDump(t, sqlschema.WithIncludeGrants())
+	require.Equal(t, []sqlschema.Grant{
+		{TableName: "documents", Grantee: "reader", Privilege: "SELECT"},
+		{TableName: "documents", Grantee: "editor", Privilege: "SELECT"},
+		{TableName: "documents", Grantee: "editor", Privilege: "DELETE"},
+		{TableName: "documents", Column: "title", Grantee: "editor", Privilege: "UPDATE"},
+	}, current.GetGrants(), "grants to the owner are skipped")
+
+	tables := schema.NewTables(d)
+	tables.Register((*Document)(nil), (*Note)(nil))
+	target, err := sqlschema.NewBunModelInspector(tables,
+		sqlschema.WithSchemaName(d.DefaultSchema()),
+		sqlschema.WithGrants(
+			sqlschema.Grant{TableName: "documents", Grantee: "reader", Privilege: "select"},
+			sqlschema.Grant{TableName: "documents", Grantee: "editor", Privilege: "SELECT"},
+			sqlschema.Grant{TableName: "documents", Column: "title", Grantee: "editor", Privilege: "UPDATE"},
+			sqlschema.Grant{TableName: "documents", Column: "title", Grantee: "editor", Privilege: "INSERT"},
+			sqlschema.Grant{TableName: "notes", Grantee: "public", Privilege: "SELECT"},
+		),
+	).Inspect(ctx)
+	require.NoError(t, err)
+
+	changes, err := migrate.Diff(d, current, target)
+	require.NoError(t, err)
+
+	m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+	require.NoError(t, err)
+
+	var got []string
+	for _, op := range changes.Operations {
+		if create, ok := op.(*migrate.CreateTableOp); ok {
+			got = append(got, "create table "+create.TableName)
+			continue
+		}
+		b, err := m.AppendSQL(nil, op)
+		require.NoError(t, err)
+		got = append(got, string(b))
+	}
+
+	grantNew := `GRANT SELECT ON TABLE "public"."notes" TO PUBLIC`
+	require.ElementsMatch(t, []string{
+		`REVOKE DELETE ON TABLE "public"."documents" FROM "editor"`,
+		`GRANT INSERT ("title") ON TABLE "public"."documents" TO "editor"`,
+		"create table notes",
+		grantNew,
+	}, got)
+	require.Less(t, slices.Index(got, "create table notes"), slices.Index(got, grantNew))
+
+	t.Run("grants are kept if none are defined", func(t *testing.T) {
+		tables := schema.NewTables(d)
+		tables.Register((*Document)(nil))
+		target, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+		require.NoError(t, err)
+
+		changes, err := migrate.Diff(d, current, target)
+		require.NoError(t, err)
+		require.Empty(t, changes.Operations)
+	})
+}
+
+type tablespaceEvent struct {
+	bun.BaseModel `bun:"table:events,tablespace:archive"`
+	ID            int64 `bun:",pk"`
+	Kind          string
+}
+
+func (*tablespaceEvent) Indexes() []sqlschema.Index {
+	return []sqlschema.Index{
+		{Name: "events_kind_idx", Columns: sqlschema.NewIndexColumns("kind"), Tablespace: "slow"},
+	}
+}
+
+func TestDiff_Tablespaces(t *testing.T) {
+	type Log struct {
+		bun.BaseModel `bun:"table:logs"`
+		ID            int64 `bun:",pk"`
+	}
+
+	type Metric struct {
+		bun.BaseModel `bun:"table:metrics,tablespace:archive"`
+		ID            int64 `bun:",pk"`
+	}
+
+	const dump = `
+SET default_tablespace = '';
+
+CREATE TABLE public.events (
+    id bigint NOT NULL,
+    kind character varying
+);
+ALTER TABLE ONLY public.events
+    ADD CONSTRAINT events_pkey PRIMARY KEY (id);
+
+CREATE TABLE public.logs (
+    id bigint NOT NULL
+) TABLESPACE archive;
+ALTER TABLE ONLY public.logs
+    ADD CONSTRAINT logs_pkey PRIMARY KEY (id);
+
+SET default_tablespace = fast;
+
+CREATE INDEX events_kind_idx ON public.events USING btree (kind);
+`
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+
+	fsys := fstest.MapFS{"schema.sql": {Data: []byte(dump)}}
+	current, err := pgdialect.NewDumpInspector(fsys, "schema.sql").Inspect(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "", current.GetTables().Value("events").GetTablespace())
+	require.Equal(t, "archive", current.GetTables().Value("logs").GetTablespace())
+	require.Equal(t, "fast", current.GetIndexes()[0].Tablespace)
+
+	tables := schema.NewTables(d)
+	tables.Register((*tablespaceEvent)(nil), (*Log)(nil), (*Metric)(nil))
+	target, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+	require.NoError(t, err)
+
+	changes, err := migrate.Diff(d, current, target)
+	require.NoError(t, err)
+
+	m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+	require.NoError(t, err)
+
+	var got []string
+	for _, op := range changes.Operations {
+		b, err := m.AppendSQL(nil, op)
+		require.NoError(t, err)
+		got = append(got, string(b))
+	}
+
+	// Tables and indexes without a tablespace in the model stay where they are.
+	require.ElementsMatch(t, []string{
+		`ALTER TABLE "public"."events" SET TABLESPACE "archive"`,
+		`ALTER INDEX "public"."events_kind_idx" SET TABLESPACE "slow"`,
+		`CREATE TABLE "metrics" ("id" BIGINT NOT NULL, PRIMARY KEY ("id")) TABLESPACE "archive"`,
+	}, got)
+}
+
+func TestDiff_ColumnStorage(t *testing.T) {
+	type Attachment struct {
+		bun.BaseModel `bun:"table:attachments"`
+		ID            int64  `bun:",pk"`
+		Body          string `bun:"type:text,storage:external"`
+		Preview       string `bun:"type:text"`
+		Checksum      []byte `bun:"type:bytea,storage:main"`
+		Note          string `bun:"type:varchar(100),storage:plain"`
+	}
+
+	const dump = `
+CREATE TABLE public.attachments (
+    id bigint NOT NULL,
+    body text,
+    preview text,
+    checksum bytea,
+    note text
+);
+ALTER TABLE ONLY public.attachments ALTER COLUMN preview SET STORAGE EXTERNAL;
+ALTER TABLE ONLY public.attachments ALTER COLUMN checksum SET STORAGE MAIN;
+ALTER TABLE ONLY public.attachments ALTER COLUMN note SET STORAGE PLAIN;
+ALTER TABLE ONLY public.attachments
+    ADD CONSTRAINT attachments_pkey PRIMARY KEY (id);
+`
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+
+	fsys := fstest.MapFS{"schema.sql": {Data: []byte(dump)}}
+	current, err := pgdialect.NewDumpInspector(fsys, "schema.sql").Inspect(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "", current.GetTables().Value("attachments").GetColumns().Value("body").GetStorage())
+	require.Equal(t, "EXTERNAL", current.GetTables().Value("attachments").GetColumns().Value("preview").GetStorage())
+
+	var warnings bytes.Buffer
+	bun.SetLogger(log.New(&warnings, "", 0))
+	defer bun.SetLogger(nil)
+
+	tables := schema.NewTables(d)
+	tables.Register((*Attachment)(nil))
+	require.Empty(t, warnings.String(), "storage is a known tag option")
+	target, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+	require.NoError(t, err)
+
+	changes, err := migrate.Diff(d, current, target)
+	require.NoError(t, err)
+
+	m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+	require.NoError(t, err)
+
+	var got []string
+	for _, op := range changes.Operations {
+		b, err := m.AppendSQL(nil, op)
+		require.NoError(t, err)
+		got = append(got, string(b))
+	}
+
+	// Columns without a storage mode in the model keep theirs. Changing the type resets the storage mode,
+	// so it is set again after the type change.
+	setNote := `ALTER TABLE "public"."attachments" ALTER COLUMN "note" SET STORAGE PLAIN`
+	changeNote := `ALTER TABLE "public"."attachments" ALTER COLUMN "note" SET DATA TYPE varchar(100)`
+	require.ElementsMatch(t, []string{
+		`ALTER TABLE "public"."attachments" ALTER COLUMN "body" SET STORAGE EXTERNAL`,
+		changeNote,
+		setNote,
+	}, got)
+	require.Less(t, slices.Index(got, changeNote), slices.Index(got, setNote))
+}
+
+type exclusionBooking struct {
+	bun.BaseModel `bun:"table:bookings"`
+	ID            int64 `bun:",pk"`
+	Room          int64
+	During        string `bun:"type:tstzrange"`
+	CanceledAt    time.Time
+}
+
+func (*exclusionBooking) Exclusions() []sqlschema.ExclusionConstraint {
+	return []sqlschema.ExclusionConstraint{
+		{
+			Method:   "gist",
+			Elements: []sqlschema.ExclusionElement{{Expression: "during", Operator: "&&"}, {Expression: "room", Operator: "="}},
+		},
+		{
+			Name:     "bookings_active_excl",
+			Method:   "gist",
+			Elements: []sqlschema.ExclusionElement{{Expression: "room", Operator: "="}, {Expression: "tstzrange(lower(during), upper(during))", Operator: "&&"}},
+			Where:    "canceled_at IS NULL",
+		},
+	}
+}
+
+type exclusionDesk struct {
+	bun.BaseModel `bun:"table:desks"`
+	ID            int64 `bun:",pk"`
+	Floor         int64
+}
+
+func (*exclusionDesk) Exclusions() []sqlschema.ExclusionConstraint {
+	return []sqlschema.ExclusionConstraint{{Elements: []sqlschema.ExclusionElement{{Expression: "floor", Operator: "="}}}}
+}
+
+func TestDiff_Exclusions(t *testing.T) {
+	const dump = `
+CREATE TABLE public.bookings (
+    id bigint NOT NULL,
+    room bigint,
+    during tstzrange,
+    canceled_at timestamp with time zone
+);
+ALTER TABLE ONLY public.bookings
+    ADD CONSTRAINT bookings_pkey PRIMARY KEY (id);
+ALTER TABLE ONLY public.bookings
+    ADD CONSTRAINT bookings_room_during_excl EXCLUDE USING gist (room WITH OPERATOR(pg_catalog.=), during WITH OPERATOR(pg_catalog.&&));
+ALTER TABLE ONLY public.bookings
+    ADD CONSTRAINT bookings_id_excl EXCLUDE USING btree (id WITH =) WHERE ((canceled_at IS NULL));
+`
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+
+	fsys := fstest.MapFS{"schema.sql": {Data: []byte(dump)}}
+	current, err := pgdialect.NewDumpInspector(fsys, "schema.sql").Inspect(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []sqlschema.ExclusionConstraint{
+		{
+			Name:   "bookings_room_during_excl",
+			Method: "gist",
+			Elements: []sqlschema.ExclusionElement{
+				{Expression: "room", Operator: "OPERATOR(pg_catalog.=)"},
+				{Expression: "during", Operator: "OPERATOR(pg_catalog.&&)"},
+			},
+		},
+		{
+			Name:     "bookings_id_excl",
+			Method:   "btree",
+			Elements: []sqlschema.ExclusionElement{{Expression: "id", Operator: "="}},
+			Where:    "(canceled_at IS NULL)",
+		},
+	}, current.GetTables().Value("bookings").GetExclusions())
+
+	tables := schema.NewTables(d)
+	tables.Register((*exclusionBooking)(nil), (*exclusionDesk)(nil))
+	target, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "bookings_during_room_excl", target.GetTables().Value("bookings").GetExclusions()[0].Name)
+
+	changes, err := migrate.Diff(d, current, target)
+	require.NoError(t, err)
+
+	m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+	require.NoError(t, err)
+
+	var got []string
+	for _, op := range changes.Operations {
+		b, err := m.AppendSQL(nil, op)
+		require.NoError(t, err)
+		got = append(got, string(b))
+	}
+
+	// The elements of an exclusion constraint are compared regardless of their order and operator syntax.
+	createDesks := `CREATE TABLE "desks" ("id" BIGINT NOT NULL, "floor" BIGINT, PRIMARY KEY ("id"))`
+	addDesks := `ALTER TABLE "public"."desks" ADD CONSTRAINT "desks_floor_excl" EXCLUDE USING btree ("floor" WITH =)`
+	require.ElementsMatch(t, []string{
+		`ALTER TABLE "public"."bookings" DROP CONSTRAINT "bookings_id_excl"`,
+		`ALTER TABLE "public"."bookings" ADD CONSTRAINT "bookings_active_excl" EXCLUDE USING gist ("room" WITH =, (tstzrange(lower(during), upper(during))) WITH &&) WHERE (canceled_at IS NULL)`,
+		createDesks,
+		addDesks,
+	}, got)
+	require.Less(t, slices.Index(got, createDesks), slices.Index(got, addDesks))
+}
+
+func TestDiff_SerialAndIdentity(t *testing.T) {
+	type Unchanged struct {
+		bun.BaseModel `bun:"table:tickets"`
+		ID            int64 `bun:",pk,autoincrement"`
+		Seat          int64 `bun:",notnull,identity"`
+	}
+	type Ticket struct {
+		bun.BaseModel `bun:"table:tickets"`
+		ID            int64 `bun:",pk,identity"`
+		Seat          int64 `bun:",notnull,autoincrement"`
+	}
+
+	const dump = `
+CREATE TABLE public.tickets (
+    id bigint NOT NULL,
+    seat bigint NOT NULL
+);
+ALTER TABLE public.tickets ALTER COLUMN seat ADD GENERATED BY DEFAULT AS IDENTITY (
+    SEQUENCE NAME public.tickets_seat_seq
+);
+CREATE SEQUENCE public.tickets_id_seq;
+ALTER SEQUENCE public.tickets_id_seq OWNED BY public.tickets.id;
+ALTER TABLE ONLY public.tickets ALTER COLUMN id SET DEFAULT nextval('public.tickets_id_seq'::regclass);
+ALTER TABLE ONLY public.tickets
+    ADD CONSTRAINT tickets_pkey PRIMARY KEY (id);
+`
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+
+	fsys := fstest.MapFS{"schema.sql": {Data: []byte(dump)}}
+	current, err := pgdialect.NewDumpInspector(fsys, "schema.sql").Inspect(ctx)
+	require.NoError(t, err)
+
+	inspectModel := func(model interface{}) sqlschema.Database {
+		tables := schema.NewTables(d)
+		tables.Register(model)
+		target, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+		require.NoError(t, err)
+		return target
+	}
+
+	// The serial type of the model is the integer type of the column.
+	changes, err := migrate.Diff(d, current, inspectModel((*Unchanged)(nil)))
+	require.NoError(t, err)
+	require.Empty(t, changes.Operations)
+
+	changes, err = migrate.Diff(d, current, inspectModel((*Ticket)(nil)))
+	require.NoError(t, err)
+
+	m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+	require.NoError(t, err)
+
+	var got []string
+	for _, op := range changes.Operations {
+		b, err := m.AppendSQL(nil, op)
+		require.NoError(t, err)
+		got = append(got, string(b))
+	}
+
+	// Both columns keep generating values past the existing ones.
+	require.ElementsMatch(t, []string{
+		`ALTER TABLE "public"."tickets" ALTER COLUMN "id" DROP DEFAULT;
+DROP SEQUENCE IF EXISTS "public"."tickets_id_seq";
+ALTER TABLE "public"."tickets" ALTER COLUMN "id" ADD GENERATED BY DEFAULT AS IDENTITY;
+SELECT setval(pg_get_serial_sequence('"public"."tickets"', 'id'), COALESCE(max("id"), 0) + 1, false) FROM "public"."tickets"`,
+		`ALTER TABLE "public"."tickets" ALTER COLUMN "seat" DROP IDENTITY;
+CREATE SEQUENCE "public"."tickets_seat_seq" OWNED BY "public"."tickets"."seat";
+ALTER TABLE "public"."tickets" ALTER COLUMN "seat" SET DEFAULT nextval('"public"."tickets_seat_seq"');
+SELECT setval(pg_get_serial_sequence('"public"."tickets"', 'seat'), COALESCE(max("seat"), 0) + 1, false) FROM "public"."tickets"`,
+	}, got)
+}
+
+func TestDiff_Indexes(t *testing.T) {
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+	m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+	require.NoError(t, err)
+
+	state := func(tableNames []string, indexes ...sqlschema.Index) sqlschema.Database {
+		tables := ordered.NewMap[string, sqlschema.Table]()
+		for _, name := range tableNames {
+			tables.Store(name, &sqlschema.BaseTable{
+				Name: name,
+				Columns: ordered.NewMap(ordered.Pair[string, sqlschema.Column]{
+					Key:   "id",
+					Value: &sqlschema.BaseColumn{Name: "id", SQLType: sqltype.BigInt},
+				}),
+			})
+		}
+		return sqlschema.BaseDatabase{Tables: tables, Indexes: indexes}
+	}
+
+	byEmail := sqlschema.Index{Name: "users_email_idx", TableName: "users", Columns: sqlschema.NewIndexColumns("email")}
+	changed := byEmail
+	changed.Unique = true
+	current := state([]string{"users"},
+		byEmail,
+		sqlschema.Index{Name: "users_stale_idx", TableName: "users", Columns: sqlschema.NewIndexColumns("stale")},
+		sqlschema.Index{Name: "users_kept_idx", TableName: "users", Columns: sqlschema.NewIndexColumns("name")},
+	)
+	target := state([]string{"users", "posts"},
+		changed,
+		sqlschema.Index{Name: "users_name_idx", TableName: "users", Columns: sqlschema.NewIndexColumns("name")},
+		sqlschema.Index{
+			Name: "users_active_idx", TableName: "users",
+			Columns: []sqlschema.IndexColumn{{Expression: "lower(email)"}},
+			Include: []string{"name"}, Where: "deleted_at IS NULL",
+		},
+		sqlschema.Index{Name: "posts_tags_idx", TableName: "posts", Columns: sqlschema.NewIndexColumns("tags"), Method: "gin"},
+	)
+
+	changes, err := migrate.Diff(d, current, target)
+	require.NoError(t, err)
+
+	var got []string
+	for _, op := range changes.Operations {
+		if create, ok := op.(*migrate.CreateTableOp); ok {
+			got = append(got, "create table "+create.TableName)
+			continue
+		}
+		b, err := m.AppendSQL(nil, op)
+		require.NoError(t, err)
+		got = append(got, string(b))
+	}
+
+	// Indexes are matched by definition, so users_kept_idx is not re-created under the new name.
+	// Changed indexes are replaced, and indexes on new tables are created after the table.
+	dropChanged := `DROP INDEX "public"."users_email_idx"`
+	createChanged := `CREATE UNIQUE INDEX "users_email_idx" ON "public"."users" ("email")`
+	createNew := `CREATE INDEX "posts_tags_idx" ON "public"."posts" USING gin ("tags")`
+	require.ElementsMatch(t, []string{
+		dropChanged,
+		createChanged,
+		`DROP INDEX "public"."users_stale_idx"`,
+		`CREATE INDEX "users_active_idx" ON "public"."users" ((lower(email))) INCLUDE ("name") WHERE deleted_at IS NULL`,
+		"create table posts",
+		createNew,
+	}, got)
+	require.Less(t, slices.Index(got, dropChanged), slices.Index(got, createChanged))
+	require.Less(t, slices.Index(got, "create table posts"), slices.Index(got, createNew))
+
+	t.Run("concurrently", func(t *testing.T) {
+		b, err := m.AppendSQL(nil, &migrate.CreateIndexOp{Index: byEmail, Concurrently: true})
+		require.NoError(t, err)
+		require.Equal(t, `CREATE INDEX CONCURRENTLY "users_email_idx" ON "public"."users" ("email")`, string(b))
+
+		b, err = m.AppendSQL(nil, &migrate.DropIndexOp{Index: byEmail, Concurrently: true})
+		require.NoError(t, err)
+		require.Equal(t, `DROP INDEX CONCURRENTLY "public"."users_email_idx"`, string(b))
+	})
+
+	t.Run("indexes are not managed without target indexes", func(t *testing.T) {
+		changes, err := migrate.Diff(d, current, state([]string{"users"}))
+		require.NoError(t, err)
+		require.Empty(t, changes.Operations)
+	})
+}
+
+type brinReading struct {
+	bun.BaseModel `bun:"table:readings"`
+	ID            int64 `bun:",pk"`
+	SensorID      int64
+	TakenAt       time.Time
+}
+
+func (*brinReading) Indexes() []sqlschema.Index {
+	return []sqlschema.Index{
+		{Name: "readings_taken_at_idx", Columns: sqlschema.NewIndexColumns("taken_at"), Method: "brin", StorageParameters: []string{"pages_per_range=64"}},
+		{Name: "readings_sensor_id_idx", Columns: sqlschema.NewIndexColumns("sensor_id"), Method: "BRIN", StorageParameters: []string{"Pages_Per_Range = 32"}},
+	}
+}
+
+func TestDiff_BrinIndexes(t *testing.T) {
+	const dump = `
+CREATE TABLE public.readings (
+    id bigint NOT NULL,
+    sensor_id bigint,
+    taken_at timestamp with time zone
+);
+ALTER TABLE ONLY public.readings
+    ADD CONSTRAINT readings_pkey PRIMARY KEY (id);
+
+CREATE INDEX readings_sensor_id_idx ON public.readings USING brin (sensor_id) WITH (pages_per_range='32');
+CREATE INDEX readings_taken_at_idx ON public.readings USING brin (taken_at) WITH (pages_per_range='128', autosummarize='on');
+`
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+
+	fsys := fstest.MapFS{"schema.sql": {Data: []byte(dump)}}
+	current, err := pgdialect.NewDumpInspector(fsys, "schema.sql").Inspect(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "brin", current.GetIndexes()[0].Method)
+	require.Equal(t, []string{"pages_per_range=32"}, current.GetIndexes()[0].StorageParameters)
+
+	tables := schema.NewTables(d)
+	tables.Register((*brinReading)(nil))
+	target, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+	require.NoError(t, err)
+
+	changes, err := migrate.Diff(d, current, target)
+	require.NoError(t, err)
+
+	m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+	require.NoError(t, err)
+
+	var got []string
+	for _, op := range changes.Operations {
+		b, err := m.AppendSQL(nil, op)
+		require.NoError(t, err)
+		got = append(got, string(b))
+	}
+
+	// readings_sensor_id_idx only differs in how the parameter is spelled, while
+	// readings_taken_at_idx summarizes a different number of pages and must be rebuilt.
+	require.Equal(t, []string{
+		`DROP INDEX "public"."readings_taken_at_idx"`,
+		`CREATE INDEX "readings_taken_at_idx" ON "public"."readings" USING brin ("taken_at") WITH (pages_per_range = '64')`,
+	}, got)
+}
+
+type opClassDocument struct {
+	bun.BaseModel `bun:"table:documents"`
+	ID            int64 `bun:",pk"`
+	Title         string
+	Settings      map[string]string `bun:"type:jsonb"`
+	Payload       map[string]string `bun:"type:jsonb"`
+}
+
+func (*opClassDocument) Indexes() []sqlschema.Index {
+	return []sqlschema.Index{
+		{Name: "documents_settings_idx", Columns: []sqlschema.IndexColumn{{Name: "settings", OpClass: "jsonb_path_ops"}}, Method: "gin"},
+		{Name: "documents_title_trgm_idx", Columns: []sqlschema.IndexColumn{{Name: "title", OpClass: "GIN_TRGM_OPS"}}, Method: "gin"},
+		{Name: "documents_title_lower_idx", Columns: []sqlschema.IndexColumn{{Expression: "lower(title)", OpClass: "text_pattern_ops"}}},
+		{Name: "documents_payload_idx", Columns: []sqlschema.IndexColumn{{Name: "payload", OpClass: "jsonb_path_ops"}}, Method: "gin"},
+	}
+}
+
+func TestDiff_OperatorClasses(t *testing.T) {
+	const dump = `
+CREATE TABLE public.documents (
+    id bigint NOT NULL,
+    title character varying,
+    settings jsonb,
+    payload jsonb
+);
+ALTER TABLE ONLY public.documents
+    ADD CONSTRAINT documents_pkey PRIMARY KEY (id);
+
+CREATE INDEX documents_payload_idx ON public.documents USING gin (payload);
+CREATE INDEX documents_settings_idx ON public.documents USING gin (settings jsonb_path_ops);
+CREATE INDEX documents_title_lower_idx ON public.documents USING btree (lower((title)::text) text_pattern_ops DESC);
+CREATE INDEX documents_title_trgm_idx ON public.documents USING gin (title public.gin_trgm_ops);
+`
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+
+	fsys := fstest.MapFS{"schema.sql": {Data: []byte(dump)}}
+	current, err := pgdialect.NewDumpInspector(fsys, "schema.sql").Inspect(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []sqlschema.IndexColumn{{Name: "payload"}}, current.GetIndexes()[0].Columns)
+	require.Equal(t, []sqlschema.IndexColumn{{Name: "settings", OpClass: "jsonb_path_ops"}}, current.GetIndexes()[1].Columns)
+	require.Equal(t, []sqlschema.IndexColumn{{Expression: "lower((title)::text)", OpClass: "text_pattern_ops"}}, current.GetIndexes()[2].Columns)
+	require.Equal(t, []sqlschema.IndexColumn{{Name: "title", OpClass: "gin_trgm_ops"}}, current.GetIndexes()[3].Columns)
+
+	tables := schema.NewTables(d)
+	tables.Register((*opClassDocument)(nil))
+	target, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+	require.NoError(t, err)
+
+	changes, err := migrate.Diff(d, current, target)
+	require.NoError(t, err)
+
+	m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+	require.NoError(t, err)
+
+	var got []string
+	for _, op := range changes.Operations {
+		b, err := m.AppendSQL(nil, op)
+		require.NoError(t, err)
+		got = append(got, string(b))
+	}
+
+	// Only the payload index, which uses the default operator class, is rebuilt with a different one.
+	require.Equal(t, []string{
+		`DROP INDEX "public"."documents_payload_idx"`,
+		`CREATE INDEX "documents_payload_idx" ON "public"."documents" USING gin ("payload" "jsonb_path_ops")`,
+	}, got)
+}
+
+type reservedUser struct {
+	bun.BaseModel `bun:"table:user"`
+	ID            int64 `bun:",pk"`
+}
+
+type reservedOrderBefore struct {
+	bun.BaseModel `bun:"table:order"`
+	ID            int64  `bun:",pk"`
+	Select        int32  `bun:"select"`
+	Group         string `bun:"group,notnull"`
+}
+
+type reservedOrderAfter struct {
+	bun.BaseModel `bun:"table:order"`
+	ID            int64  `bun:",pk"`
+	Select        string `bun:"select,type:text,default:'all'"`
+	Group         string `bun:"group"`
+	From          int64  `bun:"from"`
+}
+
+func (*reservedOrderAfter) Indexes() []sqlschema.Index {
+	return []sqlschema.Index{{Name: "order_select_idx", Columns: sqlschema.NewIndexColumns("select", "from")}}
+}
+
+func TestDiff_ReservedWords(t *testing.T) {
+	// unquoted finds the names which are reserved words outside of quoted identifiers and string literals.
+	unquoted := func(quote byte, query string) []string {
+		q := regexp.QuoteMeta(string(quote))
+		quoted := regexp.MustCompile(q + "[^" + q + "]*" + q + "|'[^']*'")
+		return regexp.MustCompile(`\b(order|select|group|from|user)\b`).FindAllString(quoted.ReplaceAllString(query, ""), -1)
+	}
+
+	for _, newDB := range []func(testing.TB) *bun.DB{pg, sqlite} {
+		db := newDB(t) // only generates SQL, does not connect to the database
+		d := db.Dialect().(sqlschema.InspectorDialect)
+
+		t.Run(d.Name().String(), func(t *testing.T) {
+			inspect := func(models ...interface{}) sqlschema.Database {
+				tables := schema.NewTables(d)
+				tables.Register(models...)
+				state, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+				require.NoError(t, err)
+				return state
+			}
+			changes, err := migrate.Diff(d, inspect((*reservedOrderBefore)(nil)), inspect((*reservedOrderAfter)(nil), (*reservedUser)(nil)))
+			require.NoError(t, err)
+			require.NotEmpty(t, changes.Operations)
+
+			m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+			require.NoError(t, err)
+			for _, op := range changes.Operations {
+				b, err := m.AppendSQL(nil, op)
+				require.NoError(t, err)
+				require.Empty(t, unquoted(d.IdentQuote(), string(b)), string(b))
+			}
+		})
+	}
+
+	t.Run("constraints", func(t *testing.T) {
+		db := pg(t) // only generates SQL, does not connect to the database
+		m, err := sqlschema.NewMigrator(db, db.Dialect().DefaultSchema())
+		require.NoError(t, err)
+
+		for _, tt := range []struct {
+			op   interface{}
+			want string
+		}{
+			{
+				op:   &migrate.AddUniqueConstraintOp{TableName: "order", Unique: sqlschema.Unique{Name: "order_key", Columns: sqlschema.NewColumns("group", "select")}},
+				want: `ALTER TABLE "public"."order" ADD CONSTRAINT "order_key" UNIQUE ("group", "select")`,
+			},
+			{
+				op:   &migrate.AddPrimaryKeyOp{TableName: "order", PrimaryKey: sqlschema.PrimaryKey{Columns: sqlschema.NewColumns("select")}},
+				want: `ALTER TABLE "public"."order" ADD PRIMARY KEY ("select")`,
+			},
+			{
+				op: &migrate.AddForeignKeyOp{
+					ConstraintName: "order_from_fkey",
+					ForeignKey: sqlschema.ForeignKey{
+						From: sqlschema.NewColumnReference("order", "from"),
+						To:   sqlschema.NewColumnReference("user", "select"),
+					},
+				},
+				want: `ALTER TABLE "public"."order" ADD CONSTRAINT "order_from_fkey" FOREIGN KEY ("from") REFERENCES "public"."user" ("select")`,
+			},
+			{
+				op: &migrate.AddExclusionConstraintOp{TableName: "order", Exclusion: sqlschema.ExclusionConstraint{
+					Name: "order_excl", Elements: []sqlschema.ExclusionElement{{Expression: "select", Operator: "="}},
+				}},
+				want: `ALTER TABLE "public"."order" ADD CONSTRAINT "order_excl" EXCLUDE USING btree ("select" WITH =)`,
+			},
+		} {
+			b, err := m.AppendSQL(nil, tt.op)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(b))
+		}
+	})
+
+	t.Run("column lists", func(t *testing.T) {
+		testEachDialect(t, func(t *testing.T, dialectName string, d schema.Dialect) {
+			columns := sqlschema.NewColumns("select", "order")
+			b, err := columns.AppendQuery(schema.NewFormatter(d), nil)
+			require.NoError(t, err)
+
+			q := string(d.IdentQuote())
+			require.Equal(t, q+"select"+q+", "+q+"order"+q, string(b))
+		})
+	})
+}
+
+func TestDiff_Partitions(t *testing.T) {
+	type User struct {
+		bun.BaseModel `bun:"table:users"`
+		ID            int64 `bun:",pk"`
+	}
+
+	type Event struct {
+		bun.BaseModel `bun:"table:events"`
+		ID            int64 `bun:",pk"`
+		UserID        int64
+		User          *User `bun:"rel:belongs-to,join:user_id=id"`
+	}
+
+	d := pgdialect.New()
+	audit := sqlschema.Trigger{Name: "audit", TableName: "events", Timing: "AFTER", Events: []string{"INSERT"}, ForEachRow: true, Body: "EXECUTE FUNCTION audit()"}
+	inspect := func() sqlschema.Database {
+		tables := schema.NewTables(d)
+		tables.Register((*User)(nil), (*Event)(nil))
+		state, err := sqlschema.NewBunModelInspector(tables,
+			sqlschema.WithSchemaName(d.DefaultSchema()),
+			sqlschema.WithTriggers(audit),
+		).Inspect(ctx)
+		require.NoError(t, err)
+		return state
+	}
+
+	// The partition has a copy of the parent's columns, foreign keys, and triggers.
+	target := inspect()
+	current := sqlschema.BaseDatabase{
+		Tables:      ordered.NewMap[string, sqlschema.Table](),
+		ForeignKeys: target.GetForeignKeys(),
+		Triggers:    target.GetTriggers(),
+	}
+	for _, pair := range target.GetTables().Pairs() {
+		current.Tables.Store(pair.Key, pair.Value)
+	}
+	target = inspect()
+	events := current.Tables.Value("events")
+	current.Tables.Store("events_2024", &sqlschema.BaseTable{
+		Name:        "events_2024",
+		Columns:     events.GetColumns(),
+		PrimaryKey:  events.GetPrimaryKey(),
+		PartitionOf: "events",
+	})
+	current.ForeignKeys[sqlschema.ForeignKey{
+		From: sqlschema.NewColumnReference("events_2024", "user_id"),
+		To:   sqlschema.NewColumnReference("users", "id"),
+	}] = "events_2024_user_id_fkey"
+	partitionAudit := audit
+	partitionAudit.TableName = "events_2024"
+	current.Triggers = append(current.Triggers, partitionAudit)
+
+	changes, err := migrate.Diff(d, current, target)
+	require.NoError(t, err)
+	require.Empty(t, changes.Operations, "partitions must not be dropped")
+}
+
+func TestDiff_TableComments(t *testing.T) {
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+	m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+	require.NoError(t, err)
+
+	state := func(comments map[string]string) sqlschema.Database {
+		tables := ordered.NewMap[string, sqlschema.Table]()
+		for _, name := range []string{"articles", "authors", "tags"} {
+			if comment, ok := comments[name]; ok {
+				tables.Store(name, &sqlschema.BaseTable{Name: name, Columns: ordered.NewMap[string, sqlschema.Column](), Comment: comment})
+			}
+		}
+		return sqlschema.BaseDatabase{Tables: tables}
+	}
+	current := state(map[string]string{"articles": "", "authors": "Article authors", "tags": "Labels"})
+	target := state(map[string]string{"articles": "Published articles", "authors": "", "tags": "  Labels "})
+
+	changes, err := migrate.Diff(d, current, target)
+	require.NoError(t, err)
+	statements, err := changes.Statements(m)
+	require.NoError(t, err)
+
+	var got []string
+	for _, stmt := range statements {
+		got = append(got, stmt.SQL)
+	}
+	require.ElementsMatch(t, []string{
+		`COMMENT ON TABLE "public"."articles" IS 'Published articles'`,
+		`COMMENT ON TABLE "public"."authors" IS NULL`,
+	}, got, "comments that only differ in whitespace must not be changed")
+}
+
+func TestDiff_IdentityOptions(t *testing.T) {
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+	m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+	require.NoError(t, err)
+
+	state := func(opts sqlschema.IdentityOptions) sqlschema.Database {
+		return sqlschema.BaseDatabase{
+			Tables: ordered.NewMap(ordered.Pair[string, sqlschema.Table]{
+				Key: "events",
+				Value: &sqlschema.BaseTable{
+					Name: "events",
+					Columns: ordered.NewMap(ordered.Pair[string, sqlschema.Column]{
+						Key:   "id",
+						Value: &sqlschema.BaseColumn{Name: "id", SQLType: sqltype.BigInt, IsIdentity: true, IdentityOptions: opts},
+					}),
+				},
+			}),
+		}
+	}
+
+	for _, tt := range []struct {
+		name          string
+		current, want sqlschema.IdentityOptions
+		wantSQL       []string
+	}{
+		{
+			name:    "generation type changed",
+			current: sqlschema.IdentityOptions{Generation: sqlschema.IdentityByDefault, Start: 1, Increment: 1},
+			want:    sqlschema.IdentityOptions{Generation: sqlschema.IdentityAlways},
+			wantSQL: []string{`ALTER TABLE "public"."events" ALTER COLUMN "id" SET GENERATED ALWAYS`},
+		},
+		{
+			name:    "sequence options changed",
+			current: sqlschema.IdentityOptions{Generation: sqlschema.IdentityAlways, Start: 1, Increment: 1},
+			want:    sqlschema.IdentityOptions{Generation: sqlschema.IdentityAlways, Start: 100, Increment: 2},
+			wantSQL: []string{`ALTER TABLE "public"."events" ALTER COLUMN "id" SET START WITH 100, ALTER COLUMN "id" SET INCREMENT BY 2`},
+		},
+		{
+			name:    "default options are not compared",
+			current: sqlschema.IdentityOptions{Generation: sqlschema.IdentityByDefault, Start: 1, Increment: 1},
+			want:    sqlschema.IdentityOptions{},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := migrate.Diff(d, state(tt.current), state(tt.want))
+			require.NoError(t, err)
+			statements, err := changes.Statements(m)
+			require.NoError(t, err)
+
+			var got []string
+			for _, stmt := range statements {
+				got = append(got, stmt.SQL)
+			}
+			require.Equal(t, tt.wantSQL, got)
+		})
+	}
+}
+
+func TestDiff_AddNotNullColumn(t *testing.T) {
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+	m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+	require.NoError(t, err)
+
+	id := ordered.Pair[string, sqlschema.Column]{
+		Key:   "id",
+		Value: &sqlschema.BaseColumn{Name: "id", SQLType: sqltype.BigInt},
+	}
+	current := sqlschema.BaseDatabase{
+		Tables: ordered.NewMap(ordered.Pair[string, sqlschema.Table]{
+			Key:   "users",
+			Value: &sqlschema.BaseTable{Name: "users", Columns: ordered.NewMap(id)},
+		}),
+	}
+	target := func(col *sqlschema.BaseColumn, backfills map[string]string) sqlschema.Database {
+		return sqlschema.BaseDatabase{
+			Tables: ordered.NewMap(ordered.Pair[string, sqlschema.Table]{
+				Key: "users",
+				Value: &sqlschema.BunTable{
+					BaseTable: sqlschema.BaseTable{
+						Name:    "users",
+						Columns: ordered.NewMap(id, ordered.Pair[string, sqlschema.Column]{Key: col.Name, Value: col}),
+					},
+					Backfills: backfills,
+				},
+			}),
+		}
+	}
+
+	for _, tt := range []struct {
+		name      string
+		column    *sqlschema.BaseColumn
+		backfills map[string]string
+		wantSQL   []string
+		wantErr   string
+	}{
+		{
+			name:    "nullable column",
+			column:  &sqlschema.BaseColumn{Name: "status", SQLType: sqltype.VarChar, VarcharLen: 20, IsNullable: true},
+			wantSQL: []string{`ALTER TABLE "public"."users" ADD COLUMN "status" VARCHAR(20)`},
+		},
+		{
+			name:    "not null column with default",
+			column:  &sqlschema.BaseColumn{Name: "status", SQLType: sqltype.VarChar, VarcharLen: 20, DefaultValue: "'active'"},
+			wantSQL: []string{`ALTER TABLE "public"."users" ADD COLUMN "status" VARCHAR(20) NOT NULL DEFAULT 'active' `},
+		},
+		{
+			name:      "not null column with backfill",
+			column:    &sqlschema.BaseColumn{Name: "status", SQLType: sqltype.VarChar, VarcharLen: 20},
+			backfills: map[string]string{"status": "'active'"},
+			wantSQL: []string{
+				`ALTER TABLE "public"."users" ADD COLUMN "status" VARCHAR(20) NOT NULL DEFAULT 'active', ALTER COLUMN "status" DROP DEFAULT`,
+			},
+		},
+		{
+			name:    "not null column without default",
+			column:  &sqlschema.BaseColumn{Name: "status", SQLType: sqltype.VarChar, VarcharLen: 20},
+			wantErr: `cannot add NOT NULL column "status" to users without a default`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := migrate.Diff(d, current, target(tt.column, tt.backfills))
+			require.NoError(t, err)
+			statements, err := changes.Statements(m)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var got []string
+			for _, stmt := range statements {
+				got = append(got, stmt.SQL)
+			}
+			require.Equal(t, tt.wantSQL, got)
+		})
+	}
+
+	t.Run("sqlite requires a default", func(t *testing.T) {
+		db := bun.NewDB(nil, sqlitedialect.New())
+		m, err := sqlschema.NewMigrator(db, "main")
+		require.NoError(t, err)
+
+		column := &sqlschema.BaseColumn{Name: "status", SQLType: sqltype.VarChar, VarcharLen: 20}
+		changes, err := migrate.Diff(d, current, target(column, map[string]string{"status": "'active'"}))
+		require.NoError(t, err)
+		_, err = changes.Statements(m)
+		require.ErrorContains(t, err, `sqlite cannot add NOT NULL column "status" to users without a default`)
+	})
+}
+
+func TestDiff_ChangeColumnType(t *testing.T) {
+	state := func(col *sqlschema.BaseColumn, usingExprs map[string]string) sqlschema.Database {
+		return sqlschema.BaseDatabase{
+			Tables: ordered.NewMap(ordered.Pair[string, sqlschema.Table]{
+				Key: "orders",
+				Value: &sqlschema.BunTable{
+					BaseTable: sqlschema.BaseTable{
+						Name: "orders",
+						Columns: ordered.NewMap(
+							ordered.Pair[string, sqlschema.Column]{
+								Key:   "id",
+								Value: &sqlschema.BaseColumn{Name: "id", SQLType: sqltype.BigInt},
+							},
+							ordered.Pair[string, sqlschema.Column]{Key: col.Name, Value: col},
+						),
+						PrimaryKey: &sqlschema.PrimaryKey{Columns: sqlschema.NewColumns("id")},
+					},
+					UsingExprs: usingExprs,
+				},
+			}),
+		}
+	}
+
+	t.Run("pg", func(t *testing.T) {
+		db := pg(t) // only generates SQL, does not connect to the database
+		d := db.Dialect().(sqlschema.InspectorDialect)
+		m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+		require.NoError(t, err)
+
+		for _, tt := range []struct {
+			name            string
+			from, to        *sqlschema.BaseColumn
+			usingExprs      map[string]string
+			wantSQL         string
+			wantDestructive bool
+		}{
+			{
+				name:    "implicit conversion",
+				from:    &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.Integer},
+				to:      &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.BigInt},
+				wantSQL: `ALTER TABLE "public"."orders" ALTER COLUMN "amount" SET DATA TYPE BIGINT`,
+			},
+			{
+				name:            "default cast",
+				from:            &sqlschema.BaseColumn{Name: "amount", SQLType: "text"},
+				to:              &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.BigInt},
+				wantSQL:         `ALTER TABLE "public"."orders" ALTER COLUMN "amount" SET DATA TYPE BIGINT USING "amount"::BIGINT`,
+				wantDestructive: true,
+			},
+			{
+				name:            "custom using expression",
+				from:            &sqlschema.BaseColumn{Name: "amount", SQLType: "numeric", NumericPrecision: 10, NumericScale: 2},
+				to:              &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.Integer},
+				usingExprs:      map[string]string{"amount": "round(amount)::integer"},
+				wantSQL:         `ALTER TABLE "public"."orders" ALTER COLUMN "amount" SET DATA TYPE INTEGER USING round(amount)::integer`,
+				wantDestructive: true,
+			},
+			{
+				name:            "narrowing conversion",
+				from:            &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.BigInt},
+				to:              &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.SmallInt},
+				wantSQL:         `ALTER TABLE "public"."orders" ALTER COLUMN "amount" SET DATA TYPE SMALLINT`,
+				wantDestructive: true,
+			},
+			{
+				name:            "shorter varchar",
+				from:            &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.VarChar, VarcharLen: 100},
+				to:              &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.VarChar, VarcharLen: 10},
+				wantSQL:         `ALTER TABLE "public"."orders" ALTER COLUMN "amount" SET DATA TYPE VARCHAR(10)`,
+				wantDestructive: true,
+			},
+			{
+				name: "default is reset around the cast",
+				from: &sqlschema.BaseColumn{Name: "amount", SQLType: "text", DefaultValue: "0"},
+				to:   &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.BigInt, DefaultValue: "0"},
+				wantSQL: `ALTER TABLE "public"."orders" ALTER COLUMN "amount" DROP DEFAULT, ` +
+					`ALTER COLUMN "amount" SET DATA TYPE BIGINT USING "amount"::BIGINT, ALTER COLUMN "amount" SET DEFAULT 0`,
+				wantDestructive: true,
+			},
+			{
+				name:    "longer varchar",
+				from:    &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.VarChar, VarcharLen: 100},
+				to:      &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.VarChar, VarcharLen: 200},
+				wantSQL: `ALTER TABLE "public"."orders" ALTER COLUMN "amount" SET DATA TYPE VARCHAR(200)`,
+			},
+			{
+				name:    "varchar to text",
+				from:    &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.VarChar, VarcharLen: 100},
+				to:      &sqlschema.BaseColumn{Name: "amount", SQLType: "text"},
+				wantSQL: `ALTER TABLE "public"."orders" ALTER COLUMN "amount" SET DATA TYPE text`,
+			},
+			{
+				name:    "integer to text",
+				from:    &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.Integer},
+				to:      &sqlschema.BaseColumn{Name: "amount", SQLType: "text"},
+				wantSQL: `ALTER TABLE "public"."orders" ALTER COLUMN "amount" SET DATA TYPE text`,
+			},
+			{
+				name:    "wider numeric",
+				from:    &sqlschema.BaseColumn{Name: "amount", SQLType: "numeric", NumericPrecision: 10, NumericScale: 2},
+				to:      &sqlschema.BaseColumn{Name: "amount", SQLType: "numeric", NumericPrecision: 12, NumericScale: 4},
+				wantSQL: `ALTER TABLE "public"."orders" ALTER COLUMN "amount" SET DATA TYPE numeric(12,4)`,
+			},
+			{
+				name:            "smaller numeric scale",
+				from:            &sqlschema.BaseColumn{Name: "amount", SQLType: "numeric", NumericPrecision: 10, NumericScale: 4},
+				to:              &sqlschema.BaseColumn{Name: "amount", SQLType: "numeric", NumericPrecision: 10, NumericScale: 2},
+				wantSQL:         `ALTER TABLE "public"."orders" ALTER COLUMN "amount" SET DATA TYPE numeric(10,2)`,
+				wantDestructive: true,
+			},
+			{
+				name:    "integer to double precision",
+				from:    &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.Integer},
+				to:      &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.DoublePrecision},
+				wantSQL: `ALTER TABLE "public"."orders" ALTER COLUMN "amount" SET DATA TYPE DOUBLE PRECISION`,
+			},
+			{
+				name: "type alias",
+				from: &sqlschema.BaseColumn{Name: "amount", SQLType: "decimal", NumericPrecision: 10, NumericScale: 2},
+				to:   &sqlschema.BaseColumn{Name: "amount", SQLType: "numeric", NumericPrecision: 10, NumericScale: 2},
+			},
+			{
+				name: "varchar with the default length",
+				from: &sqlschema.BaseColumn{Name: "amount", SQLType: "character varying"},
+				to:   &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.VarChar, VarcharLen: pgdialect.New().DefaultVarcharLen()},
+			},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				changes, err := migrate.Diff(d, state(tt.from, nil), state(tt.to, tt.usingExprs))
+				require.NoError(t, err)
+				statements, err := changes.Statements(m)
+				require.NoError(t, err)
+
+				if tt.wantSQL == "" {
+					require.Empty(t, statements, "equivalent types must not be changed")
+					return
+				}
+				require.Len(t, statements, 1)
+				require.IsType(t, (*migrate.ChangeColumnTypeOp)(nil), statements[0].Operation)
+				require.Equal(t, tt.wantSQL, statements[0].SQL)
+				require.Equal(t, tt.wantDestructive, statements[0].Destructive, "destructive")
+			})
+		}
+	})
+
+	t.Run("sqlite ignores length-only changes", func(t *testing.T) {
+		db := bun.NewDB(nil, sqlitedialect.New())
+		d := db.Dialect().(sqlschema.InspectorDialect)
+
+		from := &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.VarChar, VarcharLen: 100}
+		to := &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.VarChar, VarcharLen: 200}
+		changes, err := migrate.Diff(d, state(from, nil), state(to, nil))
+		require.NoError(t, err)
+		require.Empty(t, changes.Operations, "sqlite does not enforce the length of VARCHAR columns")
+	})
+
+	t.Run("sqlite rebuilds the table", func(t *testing.T) {
+		db := bun.NewDB(nil, sqlitedialect.New())
+		d := db.Dialect().(sqlschema.InspectorDialect)
+		m, err := sqlschema.NewMigrator(db, "main")
+		require.NoError(t, err)
+
+		from := &sqlschema.BaseColumn{Name: "amount", SQLType: "TEXT", IsNullable: true}
+		to := &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.Integer, IsNullable: true}
+		changes, err := migrate.Diff(d, state(from, nil), state(to, nil))
+		require.NoError(t, err)
+		statements, err := changes.Statements(m)
+		require.NoError(t, err)
+
+		require.Len(t, statements, 1)
+		require.Equal(t, `SAVEPOINT "orders_rebuild";
+PRAGMA defer_foreign_keys = ON;
+CREATE TABLE "main"."orders_old" AS SELECT * FROM "main"."orders";
+DROP TABLE "main"."orders";
+CREATE TABLE "main"."orders" ("id" BIGINT NOT NULL, "amount" INTEGER, PRIMARY KEY ("id"));
+INSERT INTO "main"."orders" ("id", "amount") SELECT "id", CAST("amount" AS INTEGER) FROM "main"."orders_old";
+DROP TABLE "main"."orders_old";
+RELEASE "orders_rebuild"`, statements[0].SQL)
+	})
+
+	t.Run("sqlite cannot rebuild a table referenced with ON DELETE actions", func(t *testing.T) {
+		db := bun.NewDB(nil, sqlitedialect.New())
+		d := db.Dialect().(sqlschema.InspectorDialect)
+		m, err := sqlschema.NewMigrator(db, "main")
+		require.NoError(t, err)
+
+		withReference := func(col *sqlschema.BaseColumn) sqlschema.Database {
+			s := state(col, nil).(sqlschema.BaseDatabase)
+			s.ForeignKeys = map[sqlschema.ForeignKey]string{{
+				From:     sqlschema.NewColumnReference("items", "order_id"),
+				To:       sqlschema.NewColumnReference("orders", "id"),
+				OnDelete: sqlschema.Cascade,
+			}: "items_order_id_fkey"}
+			return s
+		}
+		from := &sqlschema.BaseColumn{Name: "amount", SQLType: "TEXT", IsNullable: true}
+		to := &sqlschema.BaseColumn{Name: "amount", SQLType: sqltype.Integer, IsNullable: true}
+		changes, err := migrate.Diff(d, withReference(from), withReference(to))
+		require.NoError(t, err)
+		_, err = changes.Statements(m)
+		require.ErrorContains(t, err, "cannot rebuild table orders: foreign key items(order_id) -> orders(id) ON DELETE CASCADE")
+	})
+}
+
+func TestCreateTableStatements(t *testing.T) {
+	state := func(schemaName string) sqlschema.Database {
+		return sqlschema.BaseDatabase{
+			Tables: ordered.NewMap(ordered.Pair[string, sqlschema.Table]{
+				Key: "books",
+				Value: &sqlschema.BaseTable{
+					Schema: schemaName,
+					Name:   "books",
+					Columns: ordered.NewMap(
+						ordered.Pair[string, sqlschema.Column]{Key: "id", Value: &sqlschema.BaseColumn{
+							Name: "id", SQLType: sqltype.BigInt, IsIdentity: true,
+							IdentityOptions: sqlschema.IdentityOptions{Generation: sqlschema.IdentityAlways, Start: 100},
+						}},
+						ordered.Pair[string, sqlschema.Column]{Key: "title", Value: &sqlschema.BaseColumn{
+							Name: "title", SQLType: sqltype.VarChar, VarcharLen: 100, DefaultValue: "'untitled'",
+						}},
+						ordered.Pair[string, sqlschema.Column]{Key: "price", Value: &sqlschema.BaseColumn{
+							Name: "price", SQLType: "numeric", NumericPrecision: 10, NumericScale: 2, IsNullable: true,
+						}},
+						ordered.Pair[string, sqlschema.Column]{Key: "author_id", Value: &sqlschema.BaseColumn{
+							Name: "author_id", SQLType: sqltype.BigInt,
+						}},
+					),
+					PrimaryKey: &sqlschema.PrimaryKey{Columns: sqlschema.NewColumns("id")},
+					UniqueConstraints: []sqlschema.Unique{
+						{Name: "books_title_key", Columns: sqlschema.NewColumns("title")},
+						{Name: "books_author_title_key", Columns: sqlschema.NewColumns("author_id", "title")},
+					},
+				},
+			}),
+			ForeignKeys: map[sqlschema.ForeignKey]string{
+				{
+					From:     sqlschema.NewColumnReference("books", "author_id"),
+					To:       sqlschema.NewColumnReference("authors", "id"),
+					OnDelete: sqlschema.Cascade,
+				}: "books_author_id_fkey",
+				{
+					From: sqlschema.NewColumnReference("reviews", "book_id"),
+					To:   sqlschema.NewColumnReference("books", "id"),
+				}: "reviews_book_id_fkey",
+			},
+		}
+	}
+
+	generate := func(t *testing.T, db *bun.DB, schemaName string) []string {
+		t.Helper()
+		statements, err := migrate.CreateTableStatements(db, schemaName, state(schemaName), "books")
+		require.NoError(t, err)
+
+		var got []string
+		for _, stmt := range statements {
+			got = append(got, stmt.SQL)
+		}
+		return got
+	}
+
+	t.Run("pg", func(t *testing.T) {
+		db := pg(t) // only generates SQL, does not connect to the database
+
+		got := generate(t, db, "public")
+		require.Equal(t, []string{
+			`CREATE TABLE "public"."books" (` +
+				`"id" BIGINT GENERATED ALWAYS AS IDENTITY (START WITH 100) NOT NULL, ` +
+				`"title" VARCHAR(100) NOT NULL DEFAULT 'untitled', ` +
+				`"price" numeric(10,2), ` +
+				`"author_id" BIGINT NOT NULL, ` +
+				`PRIMARY KEY ("id"), ` +
+				`CONSTRAINT "books_author_title_key" UNIQUE ("author_id", "title"), ` +
+				`CONSTRAINT "books_title_key" UNIQUE ("title"))`,
+			`ALTER TABLE "public"."books" ADD CONSTRAINT "books_author_id_fkey" FOREIGN KEY ("author_id") ` +
+				`REFERENCES "public"."authors" ("id") ON DELETE CASCADE`,
+		}, got, "foreign keys of other tables must not be included")
+		require.Equal(t, got, generate(t, db, "public"), "statements must be the same on every run")
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		db := bun.NewDB(nil, sqlitedialect.New())
+
+		require.Equal(t, []string{
+			`CREATE TABLE "main"."books" (` +
+				`"id" BIGINT NOT NULL, ` +
+				`"title" VARCHAR(100) NOT NULL DEFAULT 'untitled', ` +
+				`"price" numeric(10,2), ` +
+				`"author_id" BIGINT NOT NULL, ` +
+				`PRIMARY KEY ("id"), ` +
+				`CONSTRAINT "books_author_title_key" UNIQUE ("author_id", "title"), ` +
+				`CONSTRAINT "books_title_key" UNIQUE ("title"), ` +
+				`CONSTRAINT "books_author_id_fkey" FOREIGN KEY ("author_id") REFERENCES "main"."authors" ("id") ON DELETE CASCADE)`,
+		}, generate(t, db, "main"), "sqlite must declare foreign keys in CREATE TABLE")
+	})
+}
+
+func TestDropTableStatements(t *testing.T) {
+	db := pg(t) // only generates SQL, does not connect to the database
+
+	state := func(names []string, fks map[sqlschema.ForeignKey]string) sqlschema.Database {
+		tables := ordered.NewMap[string, sqlschema.Table]()
+		for _, name := range names {
+			tables.Store(name, &sqlschema.BaseTable{Name: name, Columns: ordered.NewMap[string, sqlschema.Column]()})
+		}
+		return sqlschema.BaseDatabase{Tables: tables, ForeignKeys: fks}
+	}
+	fk := func(from, to string) sqlschema.ForeignKey {
+		return sqlschema.ForeignKey{
+			From: sqlschema.NewColumnReference(from, to+"_id"),
+			To:   sqlschema.NewColumnReference(to, "id"),
+		}
+	}
+	sql := func(t *testing.T, statements []migrate.Statement) []string {
+		t.Helper()
+		var got []string
+		for _, stmt := range statements {
+			got = append(got, stmt.SQL)
+		}
+		return got
+	}
+
+	t.Run("referencing tables are dropped first", func(t *testing.T) {
+		statements, err := migrate.DropTableStatements(db, "public", state(
+			[]string{"authors", "books", "reviews", "categories"},
+			map[sqlschema.ForeignKey]string{
+				fk("books", "authors"):   "books_authors_id_fkey",
+				fk("reviews", "books"):   "reviews_books_id_fkey",
+				fk("authors", "authors"): "authors_authors_id_fkey",
+			},
+		))
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			`DROP TABLE "public"."reviews"`,
+			`DROP TABLE "public"."categories"`,
+			`DROP TABLE "public"."books"`,
+			`DROP TABLE "public"."authors"`,
+		}, sql(t, statements), "self-references must not prevent the table from being dropped")
+	})
+
+	cyclic := state(
+		[]string{"employees", "departments", "offices"},
+		map[sqlschema.ForeignKey]string{
+			fk("employees", "departments"): "employees_departments_id_fkey",
+			fk("departments", "employees"): "departments_employees_id_fkey",
+			fk("offices", "departments"):   "offices_departments_id_fkey",
+		},
+	)
+
+	t.Run("cyclic references are dropped first", func(t *testing.T) {
+		statements, err := migrate.DropTableStatements(db, "public", cyclic)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			`DROP TABLE "public"."offices"`,
+			`ALTER TABLE "public"."departments" DROP CONSTRAINT "departments_employees_id_fkey"`,
+			`DROP TABLE "public"."employees"`,
+			`DROP TABLE "public"."departments"`,
+		}, sql(t, statements))
+	})
+
+	t.Run("cascade", func(t *testing.T) {
+		statements, err := migrate.DropTableStatements(db, "public", cyclic, migrate.WithDropCascade())
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			`DROP TABLE "public"."offices" CASCADE`,
+			`DROP TABLE "public"."employees" CASCADE`,
+			`DROP TABLE "public"."departments" CASCADE`,
+		}, sql(t, statements))
+	})
+}
+
+func TestChangeset_Statements(t *testing.T) {
+	type AccountBefore struct {
+		bun.BaseModel `bun:"table:accounts"`
+		ID            int64 `bun:",pk"`
+		Nickname      string
+	}
+
+	type AccountAfter struct {
+		bun.BaseModel `bun:"table:accounts"`
+		ID            int64 `bun:",pk"`
+		Balance       int64 `bun:",notnull,default:0"`
+	}
+
+	testEachDB(t, func(t *testing.T, dbName string, db *bun.DB) {
+		d, ok := db.Dialect().(sqlschema.InspectorDialect)
+		if !ok {
+			t.Skip(dbName + " is not sqlschema.InspectorDialect")
+		}
+		m, err := sqlschema.NewMigrator(db, db.Dialect().DefaultSchema())
+		if err != nil {
+			t.Skip(err)
+		}
+
+		inspect := func(model interface{}) sqlschema.Database {
+			tables := schema.NewTables(db.Dialect())
+			tables.Register(model)
+			state, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(db.Dialect().DefaultSchema())).Inspect(ctx)
+			require.NoError(t, err)
+			return state
+		}
+
+		changes, err := migrate.Diff(d, inspect((*AccountBefore)(nil)), inspect((*AccountAfter)(nil)))
+		require.NoError(t, err)
+
+		statements, err := changes.Statements(m)
+		require.NoError(t, err)
+		require.Len(t, statements, 2)
+
+		for _, stmt := range statements {
+			switch stmt.Operation.(type) {
+			case *migrate.AddColumnOp:
+				require.Contains(t, stmt.SQL, "ADD")
+				require.False(t, stmt.Destructive, "adding a column is not destructive")
+			case *migrate.DropColumnOp:
+				require.Contains(t, stmt.SQL, "DROP")
+				require.True(t, stmt.Destructive, "dropping a column is destructive")
+			default:
+				t.Errorf("unexpected operation %T", stmt.Operation)
+			}
+		}
+	})
+}
+
+func testRenameDetectionDisabled(t *testing.T, db *bun.DB) {
+	type UserBefore struct {
+		bun.BaseModel `bun:"table:users"`
+		ID            int64  `bun:",pk"`
+		Login         string `bun:",type:varchar(50)"`
+	}
+
+	type UserAfter struct {
+		bun.BaseModel `bun:"table:users"`
+		ID            int64  `bun:",pk"`
+		Email         string `bun:",type:varchar(50)"`
+	}
+
+	ctx := context.Background()
+	mustResetModel(t, ctx, db, (*UserBefore)(nil))
+	_, err := db.NewInsert().Model(&UserBefore{ID: 1, Login: "admin"}).Exec(ctx)
+	require.NoError(t, err)
+
+	m := newAutoMigratorOrSkip(t, db,
+		migrate.WithModel((*UserAfter)(nil)),
+		migrate.WithRenameDetection(false),
+	)
+
+	// Act
+	runMigrations(t, m)
+
+	// Assert: the column was dropped and added again, rather than renamed.
+	var user UserAfter
+	require.NoError(t, db.NewSelect().Model(&user).Where("id = 1").Scan(ctx))
+	require.Empty(t, user.Email, "column was renamed")
+}
+
+func testTargetInspectorYAML(t *testing.T, db *bun.DB) {
+	type Author struct {
+		bun.BaseModel `bun:"table:yaml_authors"`
+		ID            int64 `bun:",pk"`
+		Name          string
+	}
+
+	fsys := fstest.MapFS{"schema.yaml": {Data: []byte(`
+tables:
+  - name: yaml_authors
+    columns:
+      - {name: id, type: bigint, nullable: false}
+      - {name: name, type: varchar(100)}
+    primary_key: [id]
+`)}}
+
+	ctx := context.Background()
+	mustDropTableOnCleanup(t, ctx, db, (*Author)(nil))
+	newMigrator := func() *migrate.AutoMigrator {
+		return newAutoMigratorOrSkip(t, db, migrate.WithTargetInspector(
+			sqlschema.NewYAMLInspector(fsys, "schema.yaml", sqlschema.WithSchemaName(db.Dialect().DefaultSchema())),
+		))
+	}
+
+	// Act
+	runMigrations(t, newMigrator())
+
+	// Assert: the table was created from its YAML definition and is up to date.
+	_, err := db.NewInsert().Model(&Author{ID: 1, Name: "Jane"}).Exec(ctx)
+	require.NoError(t, err)
+
+	_, err = newMigrator().Migrate(ctx)
+	require.NoError(t, err, "auto migration failed")
+
+	migrator := migrate.NewMigrator(db, migrate.NewMigrations(), migrate.WithTableName(migrationsTable))
+	applied, err := migrator.AppliedMigrations(ctx)
+	require.NoError(t, err, "fetch applied migrations")
+	require.Len(t, applied, 1, "nothing to migrate after the table was created")
+}
+
+func testDryRun(t *testing.T, db *bun.DB) {
+	type Obsolete struct {
+		bun.BaseModel `bun:"table:obsolete"`
+		ID            int64 `bun:",pk"`
+	}
+
+	type Fresh struct {
+		bun.BaseModel `bun:"table:fresh"`
+		ID            int64 `bun:",pk"`
+		Name          string
+	}
+
+	ctx := context.Background()
+	inspect := inspectDbOrSkip(t, db)
+	mustResetModel(t, ctx, db, (*Obsolete)(nil))
+	mustDropTableOnCleanup(t, ctx, db, (*Fresh)(nil))
+	m := newAutoMigratorOrSkip(t, db, migrate.WithModel((*Fresh)(nil)))
+
+	// Act
+	var buf bytes.Buffer
+	statements, err := m.DryRun(ctx, &buf)
+	require.NoError(t, err)
+
+	// Assert: SQL is reported, but not executed.
+	require.Len(t, statements, 2)
+	require.Contains(t, statements[0].SQL, "CREATE TABLE")
+	require.False(t, statements[0].Destructive)
+	require.Contains(t, statements[1].SQL, "DROP TABLE")
+	require.True(t, statements[1].Destructive)
+	require.Equal(t, statements[0].SQL+";\n-- DESTRUCTIVE\n"+statements[1].SQL+";\n", buf.String())
+
+	tables := inspect(ctx).Tables
+	require.Equal(t, []string{"obsolete"}, tables.Keys(), "dry run changed the database")
+
+	var again bytes.Buffer
+	_, err = m.DryRun(ctx, &again)
+	require.NoError(t, err)
+	require.Equal(t, buf.String(), again.String(), "output is not stable")
+}
+
+func testPreview(t *testing.T, db *bun.DB) {
+	type LegacyLog struct {
+		bun.BaseModel `bun:"table:legacy_logs"`
+		ID            int64 `bun:",pk"`
+		Message       string
+	}
+
+	type UserBefore struct {
+		bun.BaseModel `bun:"table:users"`
+		ID            int64 `bun:",pk"`
+	}
+
+	type User struct {
+		bun.BaseModel `bun:"table:users"`
+		ID            int64 `bun:",pk"`
+		Age           int   `bun:",notnull,default:0"`
+	}
+
+	ctx := context.Background()
+	inspect := inspectDbOrSkip(t, db)
+	mustResetModel(t, ctx, db, (*LegacyLog)(nil), (*UserBefore)(nil))
+	m := newAutoMigratorOrSkip(t, db, migrate.WithModel((*User)(nil)))
+
+	// Act
+	lines, err := m.Preview(ctx)
+	require.NoError(t, err)
+	statements, err := m.DryRun(ctx, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	// Assert: changes are described in the order they are executed.
+	require.Len(t, statements, 2)
+	require.True(t, statements[1].Destructive)
+	require.Equal(t, []string{
+		"users:",
+		"  add column age " + strings.ToLower(statements[0].Operation.(*migrate.AddColumnOp).Column.GetSQLType()) + " not null default 0",
+		"legacy_logs:",
+		"  drop table [destructive]",
+	}, lines)
+	require.ElementsMatch(t, []string{"legacy_logs", "users"}, inspect(ctx).Tables.Keys(), "preview changed the database")
+}
+
+func testApply(t *testing.T, db *bun.DB) {
+	type Obsolete struct {
+		bun.BaseModel `bun:"table:obsolete"`
+		ID            int64 `bun:",pk"`
+	}
+
+	type Fresh struct {
+		bun.BaseModel `bun:"table:fresh"`
+		ID            int64 `bun:",pk"`
+		Name          string
+	}
+
+	ctx := context.Background()
+	inspect := inspectDbOrSkip(t, db)
+	mustResetModel(t, ctx, db, (*Obsolete)(nil))
+	mustDropTableOnCleanup(t, ctx, db, (*Fresh)(nil))
+	m := newAutoMigratorOrSkip(t, db, migrate.WithModel((*Fresh)(nil)))
+
+	// Act
+	statements, err := m.Apply(ctx)
+	require.NoError(t, err)
+
+	// Assert: the executed statements are reported and the migration is recorded.
+	require.Len(t, statements, 2)
+	require.Contains(t, statements[0].SQL, "CREATE TABLE")
+	require.Contains(t, statements[1].SQL, "DROP TABLE")
+	require.Equal(t, []string{"fresh"}, inspect(ctx).Tables.Keys())
+
+	applied, err := db.NewSelect().Table(migrationsTable).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, applied, "migration must be recorded")
+
+	statements, err = m.Apply(ctx)
+	require.NoError(t, err)
+	require.Empty(t, statements, "nothing to migrate")
+
+	applied, err = db.NewSelect().Table(migrationsTable).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, applied, "empty migration must not be recorded")
+}
+
+func testApplyWithoutTransaction(t *testing.T, db *bun.DB) {
+	type Obsolete struct {
+		bun.BaseModel `bun:"table:obsolete"`
+		ID            int64 `bun:",pk"`
+	}
+
+	type Fresh struct {
+		bun.BaseModel `bun:"table:fresh"`
+		ID            int64 `bun:",pk"`
+		Name          string
+	}
+
+	ctx := context.Background()
+	inspect := inspectDbOrSkip(t, db)
+	mustResetModel(t, ctx, db, (*Obsolete)(nil))
+	mustDropTableOnCleanup(t, ctx, db, (*Fresh)(nil))
+
+	// Act: the second statement fails.
+	m := newAutoMigratorOrSkip(t, db, migrate.WithModel((*Fresh)(nil)), migrate.WithTransaction(false),
+		migrate.BeforeOperation(func(ctx context.Context, tx bun.Tx, op *migrate.DropTableOp) error {
+			return errors.New("consumer is still running")
+		}),
+	)
+	statements, err := m.Apply(ctx)
+
+	// Assert: the statements executed before the failure are kept and reported.
+	require.ErrorContains(t, err, "consumer is still running")
+	require.ErrorContains(t, err, "applied 1 of 2 statements")
+	require.Len(t, statements, 1)
+	require.IsType(t, (*migrate.CreateTableOp)(nil), statements[0].Operation)
+	require.ElementsMatch(t, []string{"fresh", "obsolete"}, inspect(ctx).Tables.Keys())
+
+	// Act: applying again resumes the migration.
+	m = newAutoMigratorOrSkip(t, db, migrate.WithModel((*Fresh)(nil)), migrate.WithTransaction(false))
+	statements, err = m.Apply(ctx)
+	require.NoError(t, err)
+
+	// Assert
+	require.Len(t, statements, 1)
+	require.IsType(t, (*migrate.DropTableOp)(nil), statements[0].Operation)
+	require.Equal(t, []string{"fresh"}, inspect(ctx).Tables.Keys())
+}
+
+func testOperationHooks(t *testing.T, db *bun.DB) {
+	type Obsolete struct {
+		bun.BaseModel `bun:"table:obsolete"`
+		ID            int64 `bun:",pk"`
+	}
+
+	type AccountBefore struct {
+		bun.BaseModel `bun:"table:accounts"`
+		ID            int64 `bun:",pk"`
+	}
+
+	type Account struct {
+		bun.BaseModel `bun:"table:accounts"`
+		ID            int64  `bun:",pk"`
+		Status        string `bun:",nullzero"`
+	}
+
+	ctx := context.Background()
+	inspect := inspectDbOrSkip(t, db)
+	mustResetModel(t, ctx, db, (*Obsolete)(nil), (*AccountBefore)(nil))
+	_, err := db.NewInsert().Model(&[]AccountBefore{{ID: 1}, {ID: 2}}).Exec(ctx)
+	require.NoError(t, err)
+
+	backfill := migrate.AfterOperation(func(ctx context.Context, tx bun.Tx, op *migrate.AddColumnOp) error {
+		_, err := tx.NewUpdate().Table(op.TableName).Set("? = 'active'", bun.Ident(op.ColumnName)).Where("TRUE").Exec(ctx)
+		return err
+	})
+
+	// Act: a failing hook aborts the migration.
+	m := newAutoMigratorOrSkip(t, db, migrate.WithModel((*Account)(nil)), backfill,
+		migrate.BeforeOperation(func(ctx context.Context, tx bun.Tx, op *migrate.DropTableOp) error {
+			return errors.New("consumer is still running")
+		}),
+	)
+	_, err = m.Apply(ctx)
+
+	// Assert: all changes are rolled back.
+	require.ErrorContains(t, err, "consumer is still running")
+	state := inspect(ctx)
+	require.ElementsMatch(t, []string{"accounts", "obsolete"}, state.Tables.Keys())
+	accounts, _ := state.Tables.Load("accounts")
+	require.Equal(t, []string{"id"}, accounts.GetColumns().Keys(), "added column must be rolled back")
+
+	// Act: hooks are called around each statement.
+	var events []string
+	m = newAutoMigratorOrSkip(t, db, migrate.WithModel((*Account)(nil)), backfill,
+		migrate.BeforeOperation(func(ctx context.Context, tx bun.Tx, op migrate.Operation) error {
+			events = append(events, fmt.Sprintf("before %T", op))
+			return nil
+		}),
+		migrate.AfterOperation(func(ctx context.Context, tx bun.Tx, op migrate.Operation) error {
+			events = append(events, fmt.Sprintf("after %T", op))
+			return nil
+		}),
+	)
+	statements, err := m.Apply(ctx)
+	require.NoError(t, err)
+
+	// Assert
+	var want []string
+	for _, stmt := range statements {
+		want = append(want, fmt.Sprintf("before %T", stmt.Operation), fmt.Sprintf("after %T", stmt.Operation))
+	}
+	require.Len(t, statements, 2)
+	require.Equal(t, want, events)
+
+	var statuses []string
+	require.NoError(t, db.NewSelect().Table("accounts").Column("status").Scan(ctx, &statuses))
+	require.Equal(t, []string{"active", "active"}, statuses, "new column must be backfilled")
+}
+
+func testSafeMode(t *testing.T, db *bun.DB) {
+	type Obsolete struct {
+		bun.BaseModel `bun:"table:obsolete"`
+		ID            int64 `bun:",pk"`
+	}
+
+	type Fresh struct {
+		bun.BaseModel `bun:"table:fresh"`
+		ID            int64  `bun:",pk"`
+		Note          string `bun:",nullzero"`
+	}
+
+	ctx := context.Background()
+	inspect := inspectDbOrSkip(t, db)
+	mustResetModel(t, ctx, db, (*Obsolete)(nil))
+	mustDropTableOnCleanup(t, ctx, db, (*Fresh)(nil))
+
+	// Act: dropping a table is refused.
+	m := newAutoMigratorOrSkip(t, db, migrate.WithModel((*Fresh)(nil)), migrate.WithSafeMode())
+	_, err := m.Migrate(ctx)
+
+	// Assert
+	var destructive *migrate.DestructiveChangesError
+	require.ErrorAs(t, err, &destructive)
+	require.Len(t, destructive.Statements, 1)
+	require.IsType(t, (*migrate.DropTableOp)(nil), destructive.Statements[0].Operation)
+	require.Contains(t, err.Error(), "DROP TABLE")
+	require.Equal(t, []string{"obsolete"}, inspect(ctx).Tables.Keys(), "no changes must be applied")
+
+	// Act: additive changes are applied.
+	m = newAutoMigratorOrSkip(t, db, migrate.WithModel((*Obsolete)(nil), (*Fresh)(nil)), migrate.WithSafeMode())
+	runMigrations(t, m)
+
+	// Assert
+	require.ElementsMatch(t, []string{"obsolete", "fresh"}, inspect(ctx).Tables.Keys())
+}
+
+func testRevertDropTable(t *testing.T, db *bun.DB) {
+	type Doomed struct {
+		bun.BaseModel `bun:"table:doomed"`
+		ID            int64  `bun:",pk"`
+		Name          string `bun:",notnull"`
+	}
+
+	ctx := context.Background()
+	inspect := inspectDbOrSkip(t, db)
+	mustResetModel(t, ctx, db, (*Doomed)(nil))
+	_, err := db.NewInsert().Model(&Doomed{ID: 1, Name: "doomed"}).Exec(ctx)
+	require.NoError(t, err)
+	before := inspect(ctx).Tables
+
+	m := newAutoMigratorOrSkip(t, db)
+	files, err := m.CreateSQLMigrations(ctx)
+	require.NoError(t, err)
+	require.Len(t, files, 2, "expected up/down migration pair")
+
+	up, down := files[0], files[1]
+	require.Contains(t, up.Content, "DROP TABLE")
+	require.Contains(t, down.Content, "CREATE TABLE")
+	require.Contains(t, down.Content, `WARNING: rows dropped with table "doomed" cannot be restored`)
+
+	// Act: apply the migration from its files and revert it.
+	fsys := fstest.MapFS{
+		up.Name:   {Data: []byte(up.Content)},
+		down.Name: {Data: []byte(down.Content)},
+	}
+	migrations := migrate.NewMigrations()
+	require.NoError(t, migrations.Discover(fsys))
+
+	migrator := migrate.NewMigrator(db, migrations,
+		migrate.WithTableName(migrationsTable),
+		migrate.WithLocksTableName(migrationLocksTable),
+	)
+	require.NoError(t, migrator.Init(ctx))
+	_, err = migrator.Migrate(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 0, inspect(ctx).Tables.Len(), "table was not dropped")
+	_, err = migrator.Rollback(ctx)
+	require.NoError(t, err)
+
+	// Assert: the table is back, but the data is lost.
+	after := inspect(ctx).Tables
+	cmpTables(t, db.Dialect().(sqlschema.InspectorDialect), before, after)
+
+	count, err := db.NewSelect().Model((*Doomed)(nil)).Count(ctx)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
+
+func testViews(t *testing.T, db *bun.DB) {
+	type Account struct {
+		bun.BaseModel `bun:"table:accounts"`
+		ID            int64 `bun:",pk"`
+		Active        bool  `bun:",notnull"`
+	}
+
+	ctx := context.Background()
+	inspect := inspectDbOrSkip(t, db)
+	mustResetModel(t, ctx, db, (*Account)(nil))
+	t.Cleanup(func() {
+		for _, name := range []string{"stale_accounts", "active_accounts", "account_ids"} {
+			_, err := db.ExecContext(ctx, "DROP VIEW IF EXISTS ?", bun.Ident(name))
+			require.NoError(t, err, "drop view %q", name)
+		}
+	})
+	for _, query := range []string{
+		"CREATE VIEW stale_accounts AS SELECT id FROM accounts",
+		"CREATE VIEW active_accounts AS SELECT id FROM accounts",
+	} {
+		_, err := db.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+
+	m := newAutoMigratorOrSkip(t, db,
+		migrate.WithModel((*Account)(nil)),
+		migrate.WithViews(
+			sqlschema.View{Name: "active_accounts", Definition: "SELECT id, active FROM accounts WHERE active"},
+			sqlschema.View{Name: "account_ids", Definition: "SELECT id FROM accounts"},
+		),
+	)
+
+	// Act
+	runMigrations(t, m)
+
+	// Assert: the stale view is dropped, the changed one is replaced, and the new one is created.
+	got := make(map[string]string)
+	for _, v := range inspect(ctx).Views {
+		got[v.Name] = strings.ToLower(v.Definition)
+	}
+	require.Len(t, got, 2, "views after migration: %v", got)
+	require.Contains(t, got, "account_ids")
+	require.Contains(t, got["active_accounts"], "where")
+}
+
+func testTriggers(t *testing.T, db *bun.DB) {
+	type Account struct {
+		bun.BaseModel `bun:"table:accounts"`
+		ID            int64 `bun:",pk"`
+		Touched       int64 `bun:",notnull,default:0"`
+	}
+
+	ctx := context.Background()
+	want := sqlschema.Trigger{Name: "accounts_touch", TableName: "accounts", Timing: "AFTER", Events: []string{"UPDATE"}, ForEachRow: true}
+	var setup, teardown []string
+	switch db.Dialect().Name() {
+	case dialect.SQLite:
+		want.Body = "BEGIN UPDATE accounts SET touched = touched + 1 WHERE id = NEW.id; END"
+		setup = []string{"CREATE TRIGGER stale_touch AFTER INSERT ON accounts BEGIN UPDATE accounts SET touched = 1 WHERE id = NEW.id; END"}
+	case dialect.PG:
+		// PostgreSQL triggers call a function, which is not managed by the migrator.
+		want.Body = "EXECUTE FUNCTION touch_account()"
+		setup = []string{
+			"CREATE OR REPLACE FUNCTION touch_account() RETURNS trigger AS $$ BEGIN NEW.touched := NEW.touched + 1; RETURN NEW; END $$ LANGUAGE plpgsql",
+			"CREATE TRIGGER stale_touch BEFORE INSERT ON accounts FOR EACH ROW EXECUTE FUNCTION touch_account()",
+		}
+		teardown = []string{"DROP FUNCTION IF EXISTS touch_account()"}
+	default:
+		t.Skip("trigger bodies are dialect-specific")
+	}
+
+	inspect := inspectDbOrSkip(t, db)
+	mustResetModel(t, ctx, db, (*Account)(nil))
+	t.Cleanup(func() {
+		for _, query := range teardown {
+			_, err := db.ExecContext(ctx, query)
+			require.NoError(t, err)
+		}
+	})
+	for _, query := range setup {
+		_, err := db.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+
+	m := newAutoMigratorOrSkip(t, db,
+		migrate.WithModel((*Account)(nil)),
+		migrate.WithTriggers(want),
+	)
+
+	// Act
+	runMigrations(t, m)
+
+	// Assert: the stale trigger is dropped and the registered one is created as defined.
+	got := inspect(ctx).Triggers
+	require.Len(t, got, 1, "triggers after migration: %+v", got)
+	require.Equal(t, want.Name, got[0].Name)
+	require.True(t, want.Equals(got[0]), "inspected trigger: %+v", got[0])
+}
+
+type indexedAccount struct {
+	bun.BaseModel `bun:"table:indexed_accounts"`
+	ID            int64     `bun:",pk"`
+	Email         string    `bun:",unique_index"`
+	Name          string    `bun:",index"`
+	DeletedAt     time.Time `bun:",nullzero"`
+}
+
+var _ sqlschema.IndexDefiner = (*indexedAccount)(nil)
+
+func (*indexedAccount) Indexes() []sqlschema.Index {
+	return []sqlschema.Index{
+		{Name: "indexed_accounts_active_idx", Columns: sqlschema.NewIndexColumns("name"), Where: "deleted_at IS NULL"},
+	}
+}
+
+func testIndexes(t *testing.T, db *bun.DB) {
+	ctx := context.Background()
+	inspect := inspectDbOrSkip(t, db)
+	mustResetModel(t, ctx, db, (*indexedAccount)(nil))
+	_, err := db.ExecContext(ctx, "CREATE INDEX indexed_accounts_stale_idx ON indexed_accounts (deleted_at)")
+	require.NoError(t, err)
+
+	m := newAutoMigratorOrSkip(t, db, migrate.WithModel((*indexedAccount)(nil)))
+
+	// Act
+	runMigrations(t, m)
+
+	// Assert: the stale index is dropped and the model's indexes are created as defined.
+	var got []string
+	for _, idx := range inspect(ctx).Indexes {
+		got = append(got, idx.Name)
+	}
+	require.ElementsMatch(t, []string{
+		"indexed_accounts_email_idx",
+		"indexed_accounts_name_idx",
+		"indexed_accounts_active_idx",
+	}, got)
+}
+
+func testRebuildTable(t *testing.T, db *bun.DB) {
+	type OrderBefore struct {
+		bun.BaseModel `bun:"table:rebuilt_orders"`
+		ID            int64  `bun:",pk,autoincrement"`
+		Status        string `bun:",notnull,default:'new',index"`
+		Amount        string
+	}
+	type ItemBefore struct {
+		bun.BaseModel `bun:"table:rebuilt_order_items"`
+		ID            int64        `bun:",pk"`
+		OrderID       int64        `bun:",notnull"`
+		Order         *OrderBefore `bun:"rel:belongs-to,join:order_id=id"`
+	}
+
+	type OrderAfter struct {
+		bun.BaseModel `bun:"table:rebuilt_orders"`
+		ID            int64  `bun:",pk,autoincrement"`
+		Status        string `bun:",notnull,default:'open',index"`
+		Amount        int64
+	}
+	type ItemAfter struct {
+		bun.BaseModel `bun:"table:rebuilt_order_items"`
+		ID            int64       `bun:",pk"`
+		OrderID       int64       `bun:",notnull"`
+		Order         *OrderAfter `bun:"rel:belongs-to,join:order_id=id"`
+	}
+
+	if db.Dialect().Name() != dialect.SQLite {
+		t.Skip("only sqlite rebuilds the table to alter its columns")
+	}
+
+	// Foreign keys are enforced per connection.
+	ctx := context.Background()
+	db.SetMaxOpenConns(1)
+	_, err := db.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.ExecContext(ctx, "PRAGMA foreign_keys = OFF")
+		db.SetMaxOpenConns(0)
+	})
+
+	inspect := inspectDbOrSkip(t, db)
+	db.RegisterModel((*OrderBefore)(nil), (*ItemBefore)(nil))
+	mustCreateTableWithFKs(t, ctx, db, (*OrderBefore)(nil), (*ItemBefore)(nil))
+	for _, query := range []string{
+		"CREATE INDEX rebuilt_orders_status_idx ON rebuilt_orders (status)",
+		"CREATE TRIGGER rebuilt_orders_touch AFTER UPDATE ON rebuilt_orders BEGIN UPDATE rebuilt_orders SET status = 'touched' WHERE id = NEW.id AND NEW.status = 'new'; END",
+	} {
+		_, err := db.ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+	_, err = db.NewInsert().Model(&OrderBefore{ID: 1, Status: "new", Amount: "42"}).Exec(ctx)
+	require.NoError(t, err)
+	_, err = db.NewInsert().Model(&ItemBefore{ID: 1, OrderID: 1}).Exec(ctx)
+	require.NoError(t, err)
+
+	db.RegisterModel((*OrderAfter)(nil), (*ItemAfter)(nil))
+	m := newAutoMigratorOrSkip(t, db, migrate.WithModel((*OrderAfter)(nil), (*ItemAfter)(nil)))
+
+	// Act
+	statements, err := m.Apply(ctx)
+	require.NoError(t, err)
+	require.Len(t, statements, 2, "the type and the default of the columns are changed")
+
+	// Assert: the table keeps its rows, index, trigger, and AUTOINCREMENT primary key.
+	state := inspect(ctx)
+	columns := state.Tables.Value("rebuilt_orders").GetColumns()
+	require.Equal(t, "open", columns.Value("status").GetDefaultValue())
+	require.Equal(t, sqltype.Integer, strings.ToUpper(columns.Value("amount").GetSQLType()))
+	require.True(t, columns.Value("id").GetIsAutoIncrement(), "id must keep AUTOINCREMENT")
+	require.Len(t, state.Indexes, 1)
+	require.Equal(t, "rebuilt_orders_status_idx", state.Indexes[0].Name)
+	require.Len(t, state.Triggers, 1)
+	require.Equal(t, "rebuilt_orders_touch", state.Triggers[0].Name)
+
+	var order OrderAfter
+	require.NoError(t, db.NewSelect().Model(&order).Where("id = 1").Scan(ctx))
+	require.Equal(t, OrderAfter{ID: 1, Status: "new", Amount: 42}, order)
+	items, err := db.NewSelect().Model((*ItemAfter)(nil)).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, items, "referencing rows must be kept")
+
+	statements, err = m.Apply(ctx)
+	require.NoError(t, err)
+	require.Empty(t, statements, "nothing to migrate after the table is rebuilt")
+}
+
+func TestDiff_RenamedColumns(t *testing.T) {
+	type Change struct{ Op, Column string }
+
+	for _, tt := range []struct {
+		name          string
+		before, after interface{}
+		want          []Change
+	}{
+		{
+			name: "single compatible column is renamed",
+			before: (*struct {
+				bun.BaseModel `bun:"table:users"`
+				ID            int64  `bun:",pk"`
+				Login         string `bun:",type:varchar(50)"`
+			})(nil),
+			after: (*struct {
+				bun.BaseModel `bun:"table:users"`
+				ID            int64  `bun:",pk"`
+				Email         string `bun:",type:varchar(50)"`
+			})(nil),
+			want: []Change{{"rename", "login->email"}},
+		},
+		{
+			name: "ambiguous columns are dropped and added",
+			before: (*struct {
+				bun.BaseModel `bun:"table:users"`
+				ID            int64 `bun:",pk"`
+				Width         int32
+				Height        int32
+			})(nil),
+			after: (*struct {
+				bun.BaseModel `bun:"table:users"`
+				ID            int64 `bun:",pk"`
+				Depth         int32
+				Length        int32
+			})(nil),
+			want: []Change{{"drop", "width"}, {"drop", "height"}, {"add", "depth"}, {"add", "length"}},
+		},
+		{
+			name: "rename_from resolves ambiguity",
+			before: (*struct {
+				bun.BaseModel `bun:"table:users"`
+				ID            int64 `bun:",pk"`
+				Width         int32
+				Height        int32
+			})(nil),
+			after: (*struct {
+				bun.BaseModel `bun:"table:users"`
+				ID            int64 `bun:",pk"`
+				Depth         int32 `bun:",rename_from:height"`
+				Length        int32 `bun:",rename_from:width"`
+			})(nil),
+			want: []Change{{"rename", "height->depth"}, {"rename", "width->length"}},
+		},
+		{
+			name: "rename_from with a changed definition",
+			before: (*struct {
+				bun.BaseModel `bun:"table:users"`
+				ID            int64 `bun:",pk"`
+				Width         int32
+				Height        int32
+				Status        string `bun:",notnull"`
+			})(nil),
+			after: (*struct {
+				bun.BaseModel `bun:"table:users"`
+				ID            int64  `bun:",pk"`
+				Depth         string `bun:",rename_from:height"`
+				Length        int32  `bun:",rename_from:width,default:1"`
+				State         string `bun:",rename_from:status"`
+			})(nil),
+			want: []Change{
+				{"rename", "height->depth"}, {"type", "depth"},
+				{"rename", "width->length"}, {"default", "length"},
+				{"rename", "status->state"}, {"nullability", "state"},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			testEachDialect(t, func(t *testing.T, dialectName string, d schema.Dialect) {
+				inspectorDialect, ok := d.(sqlschema.InspectorDialect)
+				if !ok {
+					t.Skip(dialectName + " is not sqlschema.InspectorDialect")
+				}
+
+				inspect := func(model interface{}) sqlschema.Database {
+					tables := schema.NewTables(d)
+					tables.Register(model)
+					state, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+					require.NoError(t, err)
+					return state
+				}
+
+				changes, err := migrate.Diff(inspectorDialect, inspect(tt.before), inspect(tt.after))
+				require.NoError(t, err)
+
+				var got []Change
+				for _, op := range changes.Operations {
+					switch op := op.(type) {
+					case *migrate.RenameColumnOp:
+						got = append(got, Change{"rename", op.OldName + "->" + op.NewName})
+					case *migrate.AddColumnOp:
+						got = append(got, Change{"add", op.ColumnName})
+					case *migrate.DropColumnOp:
+						got = append(got, Change{"drop", op.ColumnName})
+					case *migrate.ChangeColumnTypeOp:
+						got = append(got, Change{"type", op.Column})
+					case *migrate.ChangeColumnDefaultOp:
+						got = append(got, Change{"default", op.Column})
+					case *migrate.ChangeColumnNullabilityOp:
+						got = append(got, Change{"nullability", op.Column})
+					default:
+						t.Errorf("unexpected operation %T", op)
+					}
+				}
+				require.ElementsMatch(t, tt.want, got)
+			})
+		})
+	}
+}
+
+func TestDiff_RenamedTables(t *testing.T) {
+	type Client struct {
+		bun.BaseModel `bun:"table:clients"`
+		ID            int64  `bun:",pk"`
+		Name          string `bun:",type:varchar(50)"`
+	}
+
+	type Customer struct {
+		bun.BaseModel `bun:"table:customers"`
+		ID            int64  `bun:",pk"`
+		Name          string `bun:",type:varchar(50)"`
+	}
+
+	type Supplier struct {
+		bun.BaseModel `bun:"table:suppliers"`
+		ID            int64  `bun:",pk"`
+		Name          string `bun:",type:varchar(50)"`
+	}
+
+	type Vendor struct {
+		bun.BaseModel `bun:"table:vendors"`
+		ID            int64  `bun:",pk"`
+		Name          string `bun:",type:varchar(50)"`
+	}
+
+	type UniqueClient struct {
+		bun.BaseModel `bun:"table:clients"`
+		ID            int64  `bun:",pk"`
+		Name          string `bun:",type:varchar(50),unique"`
+	}
+
+	type RenamedCustomer struct {
+		bun.BaseModel `bun:"table:customers,rename_from:clients"`
+		ID            int64  `bun:",pk"`
+		Name          string `bun:",type:varchar(50)"`
+		Email         string `bun:",type:varchar(100)"`
+	}
+
+	for _, tt := range []struct {
+		name          string
+		before, after []interface{}
+		want          []string
+	}{
+		{
+			name:   "table with the same signature is renamed",
+			before: []interface{}{(*Client)(nil)},
+			after:  []interface{}{(*Customer)(nil)},
+			want:   []string{"rename table clients->customers"},
+		},
+		{
+			name:   "ambiguous tables are dropped and created",
+			before: []interface{}{(*Client)(nil), (*Supplier)(nil)},
+			after:  []interface{}{(*Customer)(nil), (*Vendor)(nil)},
+			want: []string{
+				"drop table clients", "drop table suppliers",
+				"create table customers", "create table vendors",
+			},
+		},
+		{
+			name:   "tables with different constraints are not renamed",
+			before: []interface{}{(*UniqueClient)(nil)},
+			after:  []interface{}{(*Customer)(nil)},
+			want:   []string{"drop table clients", "create table customers"},
+		},
+		{
+			name:   "rename_from allows changing columns",
+			before: []interface{}{(*Client)(nil), (*Supplier)(nil)},
+			after:  []interface{}{(*RenamedCustomer)(nil), (*Supplier)(nil)},
+			want:   []string{"rename table clients->customers", "add column customers.email"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			testEachDialect(t, func(t *testing.T, dialectName string, d schema.Dialect) {
+				inspectorDialect, ok := d.(sqlschema.InspectorDialect)
+				if !ok {
+					t.Skip(dialectName + " is not sqlschema.InspectorDialect")
+				}
+
+				inspect := func(models ...interface{}) sqlschema.Database {
+					tables := schema.NewTables(d)
+					tables.Register(models...)
+					state, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+					require.NoError(t, err)
+					return state
+				}
+
+				changes, err := migrate.Diff(inspectorDialect, inspect(tt.before...), inspect(tt.after...))
+				require.NoError(t, err)
+
+				var got []string
+				for _, op := range changes.Operations {
+					switch op := op.(type) {
+					case *migrate.RenameTableOp:
+						got = append(got, "rename table "+op.TableName+"->"+op.NewName)
+					case *migrate.CreateTableOp:
+						got = append(got, "create table "+op.TableName)
+					case *migrate.DropTableOp:
+						got = append(got, "drop table "+op.TableName)
+					case *migrate.AddColumnOp:
+						got = append(got, "add column "+op.TableName+"."+op.ColumnName)
+					default:
+						t.Errorf("unexpected operation %T", op)
+					}
+				}
+				require.ElementsMatch(t, tt.want, got)
+			})
+		})
+	}
+
+	t.Run("rename_from in other schemas", func(t *testing.T) {
+		type SalesClient struct {
+			bun.BaseModel `bun:"table:sales.clients"`
+			ID            int64 `bun:",pk"`
+		}
+		type SalesCustomer struct {
+			bun.BaseModel `bun:"table:sales.customers,rename_from:sales.accounts"`
+			ID            int64 `bun:",pk"`
+		}
+
+		d := pgdialect.New()
+		tables := schema.NewTables(d)
+		tables.Register((*SalesClient)(nil), (*SalesCustomer)(nil))
+		inspector := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema()), sqlschema.WithSchemas("sales"))
+		state, err := inspector.Inspect(ctx)
+		require.NoError(t, err)
+
+		client := state.GetTables().Value("sales.clients").(*sqlschema.BunTable)
+		require.Empty(t, client.RenamedFrom)
+		customer := state.GetTables().Value("sales.customers").(*sqlschema.BunTable)
+		require.Equal(t, "sales.accounts", customer.RenamedFrom)
+	})
+}
+
+func TestExportHCL(t *testing.T) {
+	state, err := sqlschema.NewState(sqlschema.WithSchemaName("public")).
+		AddTable("books").
+		Column("id", "bigint").PK().
+		Column("author_id", "bigint").NotNull().References("authors", "id").
+		Column("title", "character varying(100)").Default("'untitled'").
+		Column("tags", "text[]").
+		Column("mood", "mood").
+		Check("length(title) > 0").
+		AddTable("authors").
+		Column("id", "bigint").PK().
+		Column("email", "varchar(320)").NotNull().Unique().
+		Column("rating", "numeric(3,1)").Default("0").
+		Column("created_at", "timestamp with time zone").NotNull().Default("now()").
+		Build()
+	require.NoError(t, err)
+	state.Enums["mood"] = []string{"happy", "sad"}
+	state.Indexes = []sqlschema.Index{
+		{Name: "books_title_idx", TableName: "books", Columns: []sqlschema.IndexColumn{{Expression: "lower(title)"}}},
+		{Name: "books_tags_idx", TableName: "books", Columns: sqlschema.NewIndexColumns("tags"), Method: "gin"},
+	}
+
+	t.Run("pg", func(t *testing.T) {
+		got := migrate.ExportHCL(pg(t), "public", state)
+		require.Equal(t, `schema "public" {
+}
+
+enum "mood" {
+  schema = schema.public
+  values = ["happy", "sad"]
+}
+
+table "authors" {
+  schema = schema.public
+  column "id" {
+    null = false
+    type = bigint
+  }
+  column "email" {
+    null = false
+    type = varchar(320)
+  }
+  column "rating" {
+    null    = true
+    type    = numeric(3,1)
+    default = 0
+  }
+  column "created_at" {
+    null    = false
+    type    = timestamptz
+    default = sql("now()")
+  }
+  primary_key {
+    columns = [column.id]
+  }
+  index "authors_email_key" {
+    unique  = true
+    columns = [column.email]
+  }
+}
+
+table "books" {
+  schema = schema.public
+  column "id" {
+    null = false
+    type = bigint
+  }
+  column "author_id" {
+    null = false
+    type = bigint
+  }
+  column "title" {
+    null    = true
+    type    = varchar(100)
+    default = "untitled"
+  }
+  column "tags" {
+    null = true
+    type = sql("text[]")
+  }
+  column "mood" {
+    null = true
+    type = enum.mood
+  }
+  primary_key {
+    columns = [column.id]
+  }
+  foreign_key "books_author_id_fkey" {
+    columns     = [column.author_id]
+    ref_columns = [table.authors.column.id]
+    on_update   = NO_ACTION
+    on_delete   = NO_ACTION
+  }
+  index "books_tags_idx" {
+    columns = [column.tags]
+    type    = GIN
+  }
+  index "books_title_idx" {
+    on {
+      expr = "lower(title)"
+    }
+  }
+  check {
+    expr = "length(title) > 0"
+  }
+}
+`, string(got))
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		d := sqlitedialect.New()
+		tables := schema.NewTables(d)
+		tables.Register((*Article)(nil))
+		state, err := sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(d.DefaultSchema())).Inspect(ctx)
+		require.NoError(t, err)
+
+		got := string(migrate.ExportHCL(bun.NewDB(nil, d), d.DefaultSchema(), state))
+		require.Contains(t, got, `table "articles" {`)
+		require.Contains(t, got, "auto_increment = true")
+	})
+}
+
+func TestExportDBML(t *testing.T) {
+	state, err := sqlschema.NewState().
+		AddTable("books").
+		Column("id", "bigint").PK().
+		Column("author_id", "bigint").NotNull().
+		Column("title", "varchar(100)").Default("'Bob''s'").
+		Column("published_at", "timestamp with time zone").Default("now()").
+		ForeignKey([]string{"author_id"}, "authors", "id").
+		AddTable("authors").
+		Column("id", "bigint").PK().
+		Column("email", "varchar(320)").NotNull().Unique().
+		AddTable("audit.tags").
+		Column("book_id", "bigint").PK().
+		Column("name", "text").PK().
+		Build()
+	require.NoError(t, err)
+	authors := state.Tables.Value("authors").(*sqlschema.BaseTable)
+	authors.Comment = "People who write"
+	authors.Columns.Value("email").(*sqlschema.BaseColumn).Comment = "Used to log in"
+	for fk, name := range state.ForeignKeys {
+		delete(state.ForeignKeys, fk)
+		fk.OnDelete = sqlschema.Cascade
+		state.ForeignKeys[fk] = name
+	}
+	state.ForeignKeys[sqlschema.ForeignKey{
+		From: sqlschema.NewColumnReference("audit.tags", "book_id"),
+		To:   sqlschema.NewColumnReference("books", "id"),
+	}] = "tags_book_id_fkey"
+	state.ForeignTables = []sqlschema.ForeignTable{{
+		Schema:  "public",
+		Name:    "remote_orders",
+		Server:  "billing",
+		Columns: []*sqlschema.BaseColumn{{Name: "id", SQLType: "bigint"}},
+		Comment: "Synced nightly",
+	}}
+
+	want := `Table audit.tags {
+  book_id bigint [not null]
+  name text [not null]
+
+  indexes {
+    (book_id, name) [pk]
+  }
+}
+
+Table authors {
+  id bigint [pk, not null]
+  email varchar(320) [not null, unique, note: 'Used to log in']
+
+  Note: 'People who write'
+}
+
+Table books {
+  id bigint [pk, not null]
+  author_id bigint [not null]
+  title varchar(100) [default: 'Bob\'s']
+  published_at "timestamp with time zone" [default: ` + "`now()`" + `]
+}
+
+Table public.remote_orders {
+  id bigint [not null]
+
+  Note: 'Foreign table on server billing. Synced nightly'
+}
+
+Ref books_author_id_fkey: books.author_id > authors.id [delete: cascade]
+Ref tags_book_id_fkey: audit.tags.book_id > books.id
+`
+	got := migrate.ExportDBML(state)
+	require.Equal(t, want, string(got))
+	require.Equal(t, got, migrate.ExportDBML(state), "output is not stable")
+}
+
+// Literature is an enumerated type.
+type Literature string
+
+func (Literature) EnumValues() []string { return []string{"fiction", "poetry"} }
+
+func TestDeterministicState(t *testing.T) {
+	type Author struct {
+		bun.BaseModel `bun:"table:authors"`
+		ID            int64  `bun:",pk"`
+		FirstName     string `bun:",unique:full_name"`
+		LastName      string `bun:",unique:full_name"`
+		Email         string `bun:",unique"`
+		Nickname      string `bun:",unique:nickname"`
+		Mood          Mood   `bun:",type:mood"`
+	}
+
+	type Book struct {
+		bun.BaseModel `bun:"table:books"`
+		ID            int64 `bun:",pk"`
+		AuthorID      int64
+		EditorID      int64
+		Genre         Literature `bun:",type:genre"`
+		Author        *Author    `bun:"rel:belongs-to,join:author_id=id"`
+		Editor        *Author    `bun:"rel:belongs-to,join:editor_id=id"`
+	}
+
+	type Event struct {
+		bun.BaseModel `bun:"table:audit.events"`
+		ID            int64 `bun:",pk"`
+	}
+
+	db := pg(t) // only generates SQL, does not connect to the database
+	d := db.Dialect().(sqlschema.InspectorDialect)
+	render := func() string {
+		tables := schema.NewTables(d)
+		tables.Register((*Event)(nil), (*Book)(nil), (*Author)(nil), (*indexedAccount)(nil), (*Article)(nil))
+		state, err := sqlschema.NewBunModelInspector(tables,
+			sqlschema.WithSchemaName(d.DefaultSchema()),
+			sqlschema.WithSchemas("audit"),
+		).Inspect(ctx)
+		require.NoError(t, err)
+
+		snapshot, err := sqlschema.MarshalDatabase(state)
+		require.NoError(t, err)
+
+		changes, err := migrate.Diff(d, sqlschema.BaseDatabase{
+			Tables:      ordered.NewMap[string, sqlschema.Table](),
+			ForeignKeys: make(map[sqlschema.ForeignKey]string),
+			Enums:       make(map[string][]string),
+		}, state)
+		require.NoError(t, err)
+		m, err := sqlschema.NewMigrator(db, d.DefaultSchema())
+		require.NoError(t, err)
+		statements, err := changes.Statements(m)
+		require.NoError(t, err)
+
+		var b strings.Builder
+		b.WriteString(sqlschema.FormatDatabase(state))
+		b.Write(snapshot)
+		for _, s := range statements {
+			b.WriteString(s.SQL + ";\n")
+		}
+		return b.String()
+	}
+
+	want := render()
+	for i := 0; i < 20; i++ {
+		require.Equal(t, want, render(), "output is not deterministic")
+	}
+}
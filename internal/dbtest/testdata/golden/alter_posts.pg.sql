@@ -0,0 +1,2 @@
+ALTER TABLE "public"."posts" ADD COLUMN "body" text;
+CREATE INDEX "posts_title_idx" ON "public"."posts" ("title");
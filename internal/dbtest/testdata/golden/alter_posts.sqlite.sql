@@ -0,0 +1,2 @@
+ALTER TABLE "main"."posts" ADD COLUMN "body" text;
+CREATE INDEX "main"."posts_title_idx" ON "posts" ("title");
@@ -0,0 +1 @@
+CREATE TABLE "public"."posts" ("id" BIGINT NOT NULL, "title" VARCHAR(100), PRIMARY KEY ("id"));
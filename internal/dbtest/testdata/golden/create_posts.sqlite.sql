@@ -0,0 +1 @@
+CREATE TABLE "main"."posts" ("id" BIGINT NOT NULL, "title" VARCHAR(100), PRIMARY KEY ("id"));
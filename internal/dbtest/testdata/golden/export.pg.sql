@@ -0,0 +1,9 @@
+CREATE SCHEMA IF NOT EXISTS "audit";
+CREATE TABLE "audit"."events" ("id" BIGINT NOT NULL, PRIMARY KEY ("id"));
+CREATE TABLE "authors" ("id" BIGSERIAL NOT NULL, "name" VARCHAR, PRIMARY KEY ("id"));
+CREATE TABLE "books" ("id" BIGSERIAL NOT NULL, "author_id" BIGINT, PRIMARY KEY ("id"));
+CREATE TABLE "indexed_accounts" ("id" BIGINT NOT NULL, "email" VARCHAR, "name" VARCHAR, "deleted_at" TIMESTAMPTZ, PRIMARY KEY ("id"));
+CREATE INDEX "indexed_accounts_active_idx" ON "public"."indexed_accounts" ("name") WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX "indexed_accounts_email_idx" ON "public"."indexed_accounts" ("email");
+CREATE INDEX "indexed_accounts_name_idx" ON "public"."indexed_accounts" ("name");
+ALTER TABLE "public"."books" ADD CONSTRAINT "books_author_id_fkey" FOREIGN KEY ("author_id") REFERENCES "public"."authors" ("id");
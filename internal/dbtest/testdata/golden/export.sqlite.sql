@@ -0,0 +1,6 @@
+CREATE TABLE "main"."authors" ("id" integer PRIMARY KEY AUTOINCREMENT NOT NULL, "name" varchar);
+CREATE TABLE "main"."books" ("id" integer PRIMARY KEY AUTOINCREMENT NOT NULL, "author_id" integer, CONSTRAINT "books_author_id_fkey" FOREIGN KEY ("author_id") REFERENCES "main"."authors" ("id"));
+CREATE TABLE "main"."indexed_accounts" ("id" integer NOT NULL, "email" varchar, "name" varchar, "deleted_at" timestamp, PRIMARY KEY ("id"));
+CREATE INDEX "main"."indexed_accounts_active_idx" ON "indexed_accounts" ("name") WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX "main"."indexed_accounts_email_idx" ON "indexed_accounts" ("email");
+CREATE INDEX "main"."indexed_accounts_name_idx" ON "indexed_accounts" ("name");
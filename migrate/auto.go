@@ -8,8 +8,10 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/feature"
 	"github.com/uptrace/bun/internal"
 	"github.com/uptrace/bun/migrate/sqlschema"
 	"github.com/uptrace/bun/schema"
@@ -35,6 +37,17 @@ func WithExcludeTable(tables ...string) AutoMigratorOption {
 	}
 }
 
+// WithIncludeTable limits the scope of AutoMigrator to the matching tables in the database,
+// e.g. when it only manages a subset of tables in a shared database. Other tables are neither
+// altered nor dropped. Exclusions set with WithExcludeTable still apply to the included tables.
+//
+// Make sure to include the tables for all models added via WithModel, as BunModelInspector ignores this setting.
+func WithIncludeTable(tables ...string) AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.includeTables = append(m.includeTables, tables...)
+	}
+}
+
 // WithSchemaName changes the default database schema to migrate objects in.
 func WithSchemaName(schemaName string) AutoMigratorOption {
 	return func(m *AutoMigrator) {
@@ -42,6 +55,96 @@ func WithSchemaName(schemaName string) AutoMigratorOption {
 	}
 }
 
+// WithSchemas adds schemas to migrate together with the default schema, e.g. when tables
+// in an "app" schema reference tables in a "shared" schema. Tables outside of the default schema
+// are identified by their qualified names, and models should set the schema in the table name,
+// e.g. `bun:"table:shared.users"`.
+func WithSchemas(schemas ...string) AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.schemas = append(m.schemas, schemas...)
+	}
+}
+
+// WithViews adds views to the desired schema state. If any views are registered,
+// AutoMigrator also drops the views in the database that are not. See sqlschema.WithViews.
+func WithViews(views ...sqlschema.View) AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.views = append(m.views, views...)
+	}
+}
+
+// WithSequences adds standalone sequences to the desired schema state. If any sequences are registered,
+// AutoMigrator also drops the standalone sequences in the database that are not. See sqlschema.WithSequences.
+func WithSequences(sequences ...sqlschema.Sequence) AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.sequences = append(m.sequences, sequences...)
+	}
+}
+
+// WithDomains adds domain types to the desired schema state. If any domains are registered,
+// AutoMigrator also drops the domains in the database that are not. See sqlschema.WithDomains.
+func WithDomains(domains ...sqlschema.Domain) AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.domains = append(m.domains, domains...)
+	}
+}
+
+// WithCompositeTypes adds composite types to the desired schema state. If any types are registered,
+// AutoMigrator also drops the composite types in the database that are not. See sqlschema.WithCompositeTypes.
+func WithCompositeTypes(types ...sqlschema.CompositeType) AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.compositeTypes = append(m.compositeTypes, types...)
+	}
+}
+
+// WithExtensions adds the extensions the schema requires to the desired schema state.
+// Missing extensions are created before any other changes. See sqlschema.WithExtensions.
+func WithExtensions(extensions ...sqlschema.Extension) AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.extensions = append(m.extensions, extensions...)
+	}
+}
+
+// WithTriggers adds triggers to the desired schema state. If any triggers are registered,
+// AutoMigrator also drops the triggers in the database that are not. See sqlschema.WithTriggers.
+func WithTriggers(triggers ...sqlschema.Trigger) AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.triggers = append(m.triggers, triggers...)
+	}
+}
+
+// WithPolicies adds row-level security policies to the desired schema state. If any policies are registered,
+// AutoMigrator also drops the policies in the database that are not. See sqlschema.WithPolicies.
+func WithPolicies(policies ...sqlschema.Policy) AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.policies = append(m.policies, policies...)
+	}
+}
+
+// WithGrants adds privileges on the tables to the desired schema state. If any grants are registered,
+// AutoMigrator inspects the privileges in the database and revokes the ones that are not. See sqlschema.WithGrants.
+func WithGrants(grants ...sqlschema.Grant) AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.grants = append(m.grants, grants...)
+	}
+}
+
+// WithTypeOverrides replaces the SQL types of the matching model fields in the desired schema state,
+// e.g. to always map uuid.UUID to "uuid". See sqlschema.WithTypeOverrides.
+func WithTypeOverrides(overrides ...sqlschema.TypeOverride) AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.typeOverrides = append(m.typeOverrides, overrides...)
+	}
+}
+
+// WithInspectConcurrency lets AutoMigrator run up to n inspection queries at the same time.
+// See sqlschema.WithConcurrency.
+func WithInspectConcurrency(n int) AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.inspectConcurrency = n
+	}
+}
+
 // WithTableNameAuto overrides default migrations table name.
 func WithTableNameAuto(table string) AutoMigratorOption {
 	return func(m *AutoMigrator) {
@@ -66,6 +169,90 @@ func WithMarkAppliedOnSuccessAuto(enabled bool) AutoMigratorOption {
 	}
 }
 
+// WithTypeEquivalence registers additional rules for comparing column types, e.g. to treat
+// a custom domain as equivalent to its underlying type. Rules are consulted in order before
+// falling back to the dialect's CompareType, and any rule returning true makes the types equivalent.
+func WithTypeEquivalence(rules ...CompareTypeFunc) AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.cmpTypeRules = append(m.cmpTypeRules, rules...)
+	}
+}
+
+// WithSafeMode refuses to apply or write migrations for destructive changes, such as dropping tables or columns
+// and narrowing column types, which are reported with a DestructiveChangesError instead. Migrations that only
+// add to the schema are applied as usual. DryRun is not affected, so it can be used to review the refused changes.
+func WithSafeMode() AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.safeMode = true
+	}
+}
+
+// OperationHook runs custom logic around an operation applied by AutoMigrator.Apply,
+// e.g. to backfill a new column, in the same transaction as its statement.
+// Returning an error aborts the migration and rolls back the transaction.
+type OperationHook func(ctx context.Context, tx bun.Tx, op Operation) error
+
+// BeforeOperation registers a hook which Apply calls for each operation of type T, e.g. *migrate.DropTableOp,
+// right before executing its statement. Use Operation as T to run it for every operation.
+// Hooks for the same operation are called in the order they were registered.
+func BeforeOperation[T Operation](hook func(ctx context.Context, tx bun.Tx, op T) error) AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.beforeHooks = append(m.beforeHooks, typedHook(hook))
+	}
+}
+
+// AfterOperation registers a hook which Apply calls for each operation of type T, e.g. *migrate.AddColumnOp,
+// right after its statement succeeds and before the next statement is executed, see BeforeOperation.
+func AfterOperation[T Operation](hook func(ctx context.Context, tx bun.Tx, op T) error) AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.afterHooks = append(m.afterHooks, typedHook(hook))
+	}
+}
+
+func typedHook[T Operation](hook func(context.Context, bun.Tx, T) error) OperationHook {
+	return func(ctx context.Context, tx bun.Tx, op Operation) error {
+		if op, ok := op.(T); ok {
+			return hook(ctx, tx, op)
+		}
+		return nil
+	}
+}
+
+// WithConcurrentIndexes creates and drops indexes CONCURRENTLY, without blocking writes to the tables, in Postgres.
+// Such statements cannot be executed in a transaction, so it should not be used with CreateTxSQLMigrations,
+// and Apply needs WithTransaction(false).
+func WithConcurrentIndexes() AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.diffOpts = append(m.diffOpts, withConcurrentIndexes(true))
+	}
+}
+
+// WithTransaction controls whether Apply executes all statements in a single transaction, which is the default.
+// Disable it for the statements which cannot run in a transaction, e.g. CREATE INDEX CONCURRENTLY,
+// or for dialects without transactional DDL. Hooks are then called in a separate transaction for each operation.
+func WithTransaction(enabled bool) AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.withoutTx = !enabled
+	}
+}
+
+// WithRenameDetection controls whether AutoMigrator guesses renamed columns by looking for a dropped column
+// with the same definition as the added one. It is enabled by default. When disabled, only the columns
+// with a "rename_from" tag option are renamed, and the rest are dropped and re-added.
+func WithRenameDetection(enabled bool) AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.diffOpts = append(m.diffOpts, withDetectRenamedColumns(enabled))
+	}
+}
+
+// WithTargetInspector replaces bun models as the source of the desired schema state,
+// e.g. with a sqlschema.YAMLInspector. Models passed with WithModel are ignored in this case.
+func WithTargetInspector(inspector sqlschema.Inspector) AutoMigratorOption {
+	return func(m *AutoMigrator) {
+		m.modelInspector = inspector
+	}
+}
+
 // WithMigrationsDirectoryAuto overrides the default directory for migration files.
 func WithMigrationsDirectoryAuto(directory string) AutoMigratorOption {
 	return func(m *AutoMigrator) {
@@ -97,10 +284,15 @@ func WithMigrationsDirectoryAuto(directory string) AutoMigratorOption {
 //     data type, make sure the data con be auto-casted to the new type.
 //   - Due to how the schema-state diff is calculated, it is not possible to rename a table and
 //     modify any of its columns' _data type_ in a single run. This will cause the AutoMigrator
-//     to drop and re-create the table under a different name; it is better to apply this change in 2 steps.
+//     to drop and re-create the table under a different name; it is better to apply this change in 2 steps,
+//     or declare the previous name with the "rename_from" option, e.g. `bun:"table:customers,rename_from:clients"`.
 //     Renaming a table and renaming its columns at the same time is possible.
 //   - Renaming table/column to an existing name, i.e. like this [A->B] [B->C], is not possible due to how
 //     AutoMigrator distinguishes "rename" and "unchanged" columns.
+//   - A column is only assumed to be renamed if exactly one dropped column has the same definition.
+//     Use the "rename_from" tag option, e.g. `bun:"email,rename_from:login"`, to rename columns explicitly.
+//   - Tables which inherit from another table with the "inherits" option, e.g. `bun:"table:cats,inherits:pets"`,
+//     only manage the columns that the parent table does not define. Changing the parents of an existing table is not migrated.
 //
 // Dialect must implement both sqlschema.Inspector and sqlschema.Migrator to be used with AutoMigrator.
 type AutoMigrator struct {
@@ -121,12 +313,61 @@ type AutoMigrator struct {
 	// schemaName is the database schema considered for migration.
 	schemaName string
 
+	// schemas are migrated together with schemaName.
+	schemas []string
+
+	// views are registered with the model inspector.
+	views []sqlschema.View
+
+	// sequences are registered with the model inspector.
+	sequences []sqlschema.Sequence
+
+	// triggers are registered with the model inspector.
+	triggers []sqlschema.Trigger
+
+	// policies are registered with the model inspector.
+	policies []sqlschema.Policy
+
+	// grants are registered with the model inspector.
+	grants []sqlschema.Grant
+
+	// domains are registered with the model inspector.
+	domains []sqlschema.Domain
+
+	// compositeTypes are registered with the model inspector.
+	compositeTypes []sqlschema.CompositeType
+
+	// extensions are registered with the model inspector.
+	extensions []sqlschema.Extension
+
+	// typeOverrides are registered with the model inspector.
+	typeOverrides []sqlschema.TypeOverride
+
+	// inspectConcurrency limits the number of concurrent queries during database inspection.
+	inspectConcurrency int
+
 	// includeModels define the migration scope.
 	includeModels []interface{}
 
+	// includeTables limit database inspection to the matching tables.
+	includeTables []string
+
 	// excludeTables are excluded from database inspection.
 	excludeTables []string
 
+	// cmpTypeRules are consulted before the dialect's CompareType.
+	cmpTypeRules []CompareTypeFunc
+
+	// safeMode refuses to apply destructive changes, see WithSafeMode.
+	safeMode bool
+
+	// beforeHooks and afterHooks are called by Apply around each statement, see BeforeOperation.
+	beforeHooks []OperationHook
+	afterHooks  []OperationHook
+
+	// withoutTx makes Apply execute the statements outside of a transaction, see WithTransaction.
+	withoutTx bool
+
 	// diffOpts are passed to detector constructor.
 	diffOpts []diffOption
 
@@ -150,12 +391,26 @@ func NewAutoMigrator(db *bun.DB, opts ...AutoMigratorOption) (*AutoMigrator, err
 	}
 	am.excludeTables = append(am.excludeTables, am.table, am.locksTable)
 
-	dbInspector, err := sqlschema.NewInspector(db, sqlschema.WithSchemaName(am.schemaName), sqlschema.WithExcludeTables(am.excludeTables...))
+	inspectorOpts := []sqlschema.InspectorOption{
+		sqlschema.WithSchemaName(am.schemaName),
+		sqlschema.WithSchemas(am.schemas...),
+		sqlschema.WithConcurrency(am.inspectConcurrency),
+		sqlschema.WithIncludeTables(am.includeTables...),
+		sqlschema.WithExcludeTables(am.excludeTables...),
+	}
+	if len(am.grants) > 0 {
+		inspectorOpts = append(inspectorOpts, sqlschema.WithIncludeGrants())
+	}
+	dbInspector, err := sqlschema.NewInspector(db, inspectorOpts...)
 	if err != nil {
 		return nil, err
 	}
 	am.dbInspector = dbInspector
-	am.diffOpts = append(am.diffOpts, withCompareTypeFunc(db.Dialect().(sqlschema.InspectorDialect).CompareType))
+	inspectorDialect := db.Dialect().(sqlschema.InspectorDialect)
+	am.diffOpts = append(am.diffOpts,
+		withCompareTypeFunc(anyCompareType(append(am.cmpTypeRules, inspectorDialect.CompareType)...)),
+		withDialectDefaults(inspectorDialect),
+	)
 
 	dbMigrator, err := sqlschema.NewMigrator(db, am.schemaName)
 	if err != nil {
@@ -163,9 +418,23 @@ func NewAutoMigrator(db *bun.DB, opts ...AutoMigratorOption) (*AutoMigrator, err
 	}
 	am.dbMigrator = dbMigrator
 
-	tables := schema.NewTables(db.Dialect())
-	tables.Register(am.includeModels...)
-	am.modelInspector = sqlschema.NewBunModelInspector(tables, sqlschema.WithSchemaName(am.schemaName))
+	if am.modelInspector == nil {
+		tables := schema.NewTables(db.Dialect())
+		tables.Register(am.includeModels...)
+		am.modelInspector = sqlschema.NewBunModelInspector(tables,
+			sqlschema.WithSchemaName(am.schemaName),
+			sqlschema.WithSchemas(am.schemas...),
+			sqlschema.WithViews(am.views...),
+			sqlschema.WithSequences(am.sequences...),
+			sqlschema.WithTriggers(am.triggers...),
+			sqlschema.WithPolicies(am.policies...),
+			sqlschema.WithGrants(am.grants...),
+			sqlschema.WithDomains(am.domains...),
+			sqlschema.WithCompositeTypes(am.compositeTypes...),
+			sqlschema.WithExtensions(am.extensions...),
+			sqlschema.WithTypeOverrides(am.typeOverrides...),
+		)
+	}
 
 	return am, nil
 }
@@ -183,7 +452,10 @@ func (am *AutoMigrator) plan(ctx context.Context) (*changeset, error) {
 		return nil, err
 	}
 
-	changes := diff(got, want, am.diffOpts...)
+	changes, err := diff(got, want, am.diffOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("plan migrations: %w", err)
+	}
 	if err := changes.ResolveDependencies(); err != nil {
 		return nil, fmt.Errorf("plan migrations: %w", err)
 	}
@@ -214,6 +486,150 @@ func (am *AutoMigrator) Migrate(ctx context.Context, opts ...MigrationOption) (*
 	return group, nil
 }
 
+// Apply brings the database to the desired state without creating migration files. It executes the statements
+// for the detected changes in a single transaction, which is rolled back if any of them fails, and records
+// the migration in the migrations table. Applying the same models again does nothing, since there are no changes.
+// The executed statements are returned in order, e.g. for logging.
+//
+// Dialects which commit DDL statements implicitly, like MySQL, cannot roll back a failed migration, so Apply refuses
+// to run for them unless the transaction is disabled with WithTransaction(false). Without a transaction, a failed
+// statement leaves the previous ones applied: Apply returns them together with the error, and calling Apply again
+// resumes the migration, since the changes are detected by inspecting the database.
+//
+// Operation hooks are only called by Apply, since the statements in migration files are executed as plain SQL.
+func (am *AutoMigrator) Apply(ctx context.Context) ([]Statement, error) {
+	if !am.withoutTx && !am.db.HasFeature(feature.TransactionalDDL) {
+		return nil, fmt.Errorf("auto apply: %s implicitly commits DDL statements, "+
+			"which cannot be rolled back; use WithTransaction(false) to apply them one by one", am.db.Dialect().Name())
+	}
+
+	changes, err := am.plan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("auto apply: %w", err)
+	}
+	if err := am.checkSafeMode(changes); err != nil {
+		return nil, fmt.Errorf("auto apply: %w", err)
+	}
+
+	statements, err := (&Changeset{Operations: changes.operations}).Statements(am.dbMigrator)
+	if err != nil {
+		return nil, fmt.Errorf("auto apply: %w", err)
+	}
+	if len(statements) == 0 {
+		return nil, nil
+	}
+
+	migrator := NewMigrator(am.db, NewMigrations(am.migrationsOpts...), am.migratorOpts...)
+	if err := migrator.Init(ctx); err != nil {
+		return nil, fmt.Errorf("auto apply: %w", err)
+	}
+	applied, err := migrator.AppliedMigrations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("auto apply: %w", err)
+	}
+
+	name, _ := genMigrationName(am.schemaName + "_auto")
+	migration := &Migration{Name: name, GroupID: applied.LastGroupID() + 1}
+	record := func(ctx context.Context, db bun.IDB) error {
+		_, err := db.NewInsert().Model(migration).ModelTableExpr(migrator.table).Exec(ctx)
+		return err
+	}
+
+	if am.withoutTx {
+		for i, stmt := range statements {
+			if err := am.execStatement(ctx, stmt); err != nil {
+				return statements[:i], fmt.Errorf("auto apply: %w (applied %d of %d statements)", err, i, len(statements))
+			}
+		}
+		if err := record(ctx, am.db); err != nil {
+			return statements, fmt.Errorf("auto apply: %w", err)
+		}
+		return statements, nil
+	}
+
+	if err := am.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		for _, stmt := range statements {
+			if err := runHooks(ctx, tx, am.beforeHooks, stmt.Operation); err != nil {
+				return fmt.Errorf("before %T: %w", stmt.Operation, err)
+			}
+			if _, err := tx.ExecContext(ctx, stmt.SQL); err != nil {
+				return fmt.Errorf("%T: %w", stmt.Operation, err)
+			}
+			if err := runHooks(ctx, tx, am.afterHooks, stmt.Operation); err != nil {
+				return fmt.Errorf("after %T: %w", stmt.Operation, err)
+			}
+		}
+		return record(ctx, tx)
+	}); err != nil {
+		return nil, fmt.Errorf("auto apply: %w", err)
+	}
+	return statements, nil
+}
+
+// execStatement executes the statement outside of a transaction, see WithTransaction.
+// Its hooks still receive a transaction of their own, which is committed before the statement is executed.
+func (am *AutoMigrator) execStatement(ctx context.Context, stmt Statement) error {
+	if err := am.runHooksInTx(ctx, am.beforeHooks, stmt.Operation); err != nil {
+		return fmt.Errorf("before %T: %w", stmt.Operation, err)
+	}
+	if _, err := am.db.ExecContext(ctx, stmt.SQL); err != nil {
+		return fmt.Errorf("%T: %w", stmt.Operation, err)
+	}
+	if err := am.runHooksInTx(ctx, am.afterHooks, stmt.Operation); err != nil {
+		return fmt.Errorf("after %T: %w", stmt.Operation, err)
+	}
+	return nil
+}
+
+func (am *AutoMigrator) runHooksInTx(ctx context.Context, hooks []OperationHook, op Operation) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+	return am.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return runHooks(ctx, tx, hooks, op)
+	})
+}
+
+func runHooks(ctx context.Context, tx bun.Tx, hooks []OperationHook, op Operation) error {
+	for _, hook := range hooks {
+		if err := hook(ctx, tx, op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DryRun detects the changes required to bring the database to the desired state and writes their SQL to w,
+// one statement per line, without applying them or creating any migration files. Destructive statements,
+// which drop tables or columns or convert column values lossily, are preceded by a "-- DESTRUCTIVE" comment.
+//
+// Statements are listed in the order they would be executed and are returned for further inspection.
+// The output is the same for the same pair of schemas, so it can be reviewed or compared with the previous run.
+func (am *AutoMigrator) DryRun(ctx context.Context, w io.Writer) ([]Statement, error) {
+	changes, err := am.plan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("dry run: %w", err)
+	}
+
+	statements, err := (&Changeset{Operations: changes.operations}).Statements(am.dbMigrator)
+	if err != nil {
+		return nil, fmt.Errorf("dry run: %w", err)
+	}
+
+	var b []byte
+	for _, stmt := range statements {
+		if stmt.Destructive {
+			b = append(b, "-- DESTRUCTIVE\n"...)
+		}
+		b = append(b, stmt.SQL...)
+		b = append(b, ";\n"...)
+	}
+	if _, err := w.Write(b); err != nil {
+		return nil, fmt.Errorf("dry run: %w", err)
+	}
+	return statements, nil
+}
+
 // CreateSQLMigration writes required changes to a new migration file.
 // Use migrate.Migrator to apply the generated migrations.
 func (am *AutoMigrator) CreateSQLMigrations(ctx context.Context) ([]*MigrationFile, error) {
@@ -238,6 +654,41 @@ func (am *AutoMigrator) CreateTxSQLMigrations(ctx context.Context) ([]*Migration
 // Should not be returned to the user -- return a nil-error instead.
 var errNothingToMigrate = errors.New("nothing to migrate")
 
+// DestructiveChangesError lists the destructive changes which AutoMigrator refused to apply in safe mode.
+type DestructiveChangesError struct {
+	Statements []Statement
+}
+
+func (e *DestructiveChangesError) Error() string {
+	sqls := make([]string, len(e.Statements))
+	for i, stmt := range e.Statements {
+		sqls[i] = stmt.SQL
+	}
+	return fmt.Sprintf("refusing destructive changes in safe mode: %s", strings.Join(sqls, "; "))
+}
+
+// checkSafeMode returns a DestructiveChangesError in safe mode if any of the changes is destructive.
+func (am *AutoMigrator) checkSafeMode(changes *changeset) error {
+	if !am.safeMode {
+		return nil
+	}
+	var destructive []Statement
+	for _, op := range changes.operations {
+		if !IsDestructive(op) {
+			continue
+		}
+		b, err := am.dbMigrator.AppendSQL(nil, op)
+		if err != nil {
+			return err
+		}
+		destructive = append(destructive, Statement{Operation: op, SQL: string(b), Destructive: true})
+	}
+	if len(destructive) > 0 {
+		return &DestructiveChangesError{Statements: destructive}
+	}
+	return nil
+}
+
 func (am *AutoMigrator) createSQLMigrations(ctx context.Context, transactional bool) (*Migrations, []*MigrationFile, error) {
 	changes, err := am.plan(ctx)
 	if err != nil {
@@ -247,6 +698,9 @@ func (am *AutoMigrator) createSQLMigrations(ctx context.Context, transactional b
 	if changes.Len() == 0 {
 		return nil, nil, errNothingToMigrate
 	}
+	if err := am.checkSafeMode(changes); err != nil {
+		return nil, nil, fmt.Errorf("create sql migrations: %w", err)
+	}
 
 	name, _ := genMigrationName(am.schemaName + "_auto")
 	migrations := NewMigrations(am.migrationsOpts...)
@@ -311,14 +765,33 @@ func (c *changeset) Func(m sqlschema.Migrator) MigrationFunc {
 }
 
 // GetReverse returns a new changeset with each operation in it "reversed" and in reverse order.
+// Reverting a destructive operation restores the schema, but not the data, which is noted in a comment.
 func (c *changeset) GetReverse() *changeset {
 	var reverse changeset
 	for i := len(c.operations) - 1; i >= 0; i-- {
-		reverse.Add(c.operations[i].GetReverse())
+		op := c.operations[i]
+		if note, ok := dataLossNote(op); ok {
+			reverse.Add(&note)
+		}
+		reverse.Add(op.GetReverse())
 	}
 	return &reverse
 }
 
+// dataLossNote explains which data cannot be restored when reverting a destructive operation.
+func dataLossNote(op Operation) (comment, bool) {
+	switch op := op.(type) {
+	case *DropTableOp:
+		if op.Table == nil {
+			return "", false // GetReverse already explains why the table cannot be restored
+		}
+		return comment(fmt.Sprintf("WARNING: rows dropped with table %q cannot be restored", op.TableName)), true
+	case *DropColumnOp:
+		return comment(fmt.Sprintf("WARNING: values dropped with column %q.%q cannot be restored", op.TableName, op.ColumnName)), true
+	}
+	return "", false
+}
+
 // Up is syntactic sugar.
 func (c *changeset) Up(m sqlschema.Migrator) MigrationFunc {
 	return c.Func(m)
@@ -398,9 +871,11 @@ func (c *changeset) ResolveDependencies() error {
 	var nextOp Operation
 	var visit func(op Operation) error
 
+	// next picks the last unvisited operation. Because visited operations are prepended to the list,
+	// independent operations keep their original order and the result is the same on every run.
 	next := func() bool {
-		for op, s := range status {
-			if s == unvisited {
+		for i := len(c.operations) - 1; i >= 0; i-- {
+			if op := c.operations[i]; status[op] == unvisited {
 				nextOp = op
 				return true
 			}
@@ -422,9 +897,7 @@ func (c *changeset) ResolveDependencies() error {
 		status[op] = current
 
 		for _, another := range c.operations {
-			if dop, hasDeps := another.(interface {
-				DependsOn(Operation) bool
-			}); another == op || !hasDeps || !dop.DependsOn(op) {
+			if another == op || !dependsOn(another, op) {
 				continue
 			}
 			if err := visit(another); err != nil {
@@ -448,3 +921,16 @@ func (c *changeset) ResolveDependencies() error {
 	c.operations = resolved
 	return nil
 }
+
+// dependsOn checks if op must be applied after another. Extensions may provide the types, functions,
+// and operators used by any other object, so every operation depends on CreateExtensionOp.
+func dependsOn(op, another Operation) bool {
+	if _, isExt := another.(*CreateExtensionOp); isExt {
+		_, opIsExt := op.(*CreateExtensionOp)
+		return !opIsExt
+	}
+	dop, hasDeps := op.(interface {
+		DependsOn(Operation) bool
+	})
+	return hasDeps && dop.DependsOn(another)
+}
@@ -0,0 +1,220 @@
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/uptrace/bun/migrate/sqlschema"
+	"github.com/uptrace/bun/schema"
+)
+
+// ExportDBML converts the schema state into DBML, the markup read by dbdiagram.io and other ERD tools.
+//
+// Each table lists its columns with their types and settings, e.g. [pk, not null], followed by
+// the composite primary keys and unique constraints, and the table comment as a note.
+// Column comments become column notes. Foreign keys are declared as refs after all tables.
+// Tables outside of the default schema keep their qualified name, e.g. audit.events.
+// Foreign tables, see sqlschema.WithIncludeForeignTables, follow the other tables with the server in their note.
+//
+// Tables and refs are sorted by name, so that the output is stable and can be committed and diffed.
+func ExportDBML(state sqlschema.Database) []byte {
+	var buf bytes.Buffer
+
+	keys := state.GetTables().Keys()
+	slices.Sort(keys)
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		writeDBMLTable(&buf, key, state.GetTables().Value(key))
+	}
+
+	foreign := slices.Clone(state.GetForeignTables())
+	slices.SortFunc(foreign, func(a, b sqlschema.ForeignTable) int {
+		return strings.Compare(a.Schema+"."+a.Name, b.Schema+"."+b.Name)
+	})
+	for i, ft := range foreign {
+		if i > 0 || len(keys) > 0 {
+			buf.WriteByte('\n')
+		}
+		table := ft.Table()
+		table.Comment = strings.TrimSpace(fmt.Sprintf("Foreign table on server %s. %s", ft.Server, ft.Comment))
+		writeDBMLTable(&buf, ft.Schema+"."+ft.Name, table)
+	}
+
+	type ref struct {
+		name string
+		fk   sqlschema.ForeignKey
+	}
+	var refs []ref
+	for fk, name := range state.GetForeignKeys() {
+		refs = append(refs, ref{name: name, fk: fk})
+	}
+	slices.SortFunc(refs, func(a, b ref) int {
+		if c := strings.Compare(a.name, b.name); c != 0 {
+			return c
+		}
+		return strings.Compare(dbmlColumnRef(a.fk.From), dbmlColumnRef(b.fk.From))
+	})
+	if len(refs) > 0 && len(keys) > 0 {
+		buf.WriteByte('\n')
+	}
+	for _, ref := range refs {
+		buf.WriteString("Ref")
+		if ref.name != "" {
+			buf.WriteString(" " + dbmlIdent(ref.name))
+		}
+		fmt.Fprintf(&buf, ": %s > %s", dbmlColumnRef(ref.fk.From), dbmlColumnRef(ref.fk.To))
+
+		var settings []string
+		if ref.fk.OnDelete != sqlschema.NoAction {
+			settings = append(settings, "delete: "+strings.ToLower(ref.fk.OnDelete.String()))
+		}
+		if ref.fk.OnUpdate != sqlschema.NoAction {
+			settings = append(settings, "update: "+strings.ToLower(ref.fk.OnUpdate.String()))
+		}
+		buf.WriteString(dbmlSettings(settings))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+func writeDBMLTable(buf *bytes.Buffer, key string, table sqlschema.Table) {
+	fmt.Fprintf(buf, "Table %s {\n", dbmlTableName(key))
+
+	var pk []string
+	if table.GetPrimaryKey() != nil {
+		pk = table.GetPrimaryKey().Columns.Split()
+	}
+	unique := make(map[string]bool)
+	var composite [][]string
+	for _, u := range table.GetUniqueConstraints() {
+		if columns := u.Columns.Split(); len(columns) == 1 {
+			unique[columns[0]] = true
+		} else {
+			composite = append(composite, columns)
+		}
+	}
+
+	for _, col := range table.GetColumns().Values() {
+		var settings []string
+		if len(pk) == 1 && pk[0] == col.GetName() {
+			settings = append(settings, "pk")
+		}
+		if col.GetIsAutoIncrement() || col.GetIsIdentity() {
+			settings = append(settings, "increment")
+		}
+		if !col.GetIsNullable() {
+			settings = append(settings, "not null")
+		}
+		if unique[col.GetName()] {
+			settings = append(settings, "unique")
+		}
+		if def := col.GetDefaultValue(); def != "" {
+			settings = append(settings, "default: "+dbmlDefault(def))
+		}
+		if comment := col.GetComment(); comment != "" {
+			settings = append(settings, "note: "+dbmlString(comment))
+		}
+		fmt.Fprintf(buf, "  %s %s%s\n", dbmlIdent(col.GetName()), dbmlType(col), dbmlSettings(settings))
+	}
+
+	if len(pk) > 1 || len(composite) > 0 {
+		buf.WriteString("\n  indexes {\n")
+		if len(pk) > 1 {
+			fmt.Fprintf(buf, "    %s [pk]\n", dbmlColumns(pk))
+		}
+		for _, columns := range composite {
+			fmt.Fprintf(buf, "    %s [unique]\n", dbmlColumns(columns))
+		}
+		buf.WriteString("  }\n")
+	}
+
+	if comment := table.GetComment(); comment != "" {
+		fmt.Fprintf(buf, "\n  Note: %s\n", dbmlString(comment))
+	}
+	buf.WriteString("}\n")
+}
+
+// dbmlType returns the column's SQL type in lower case, quoted if it is not a plain name, e.g. "timestamp with time zone".
+func dbmlType(col sqlschema.Column) string {
+	typ := &sqlschema.BaseColumn{
+		SQLType:          strings.ToLower(sqlschema.NormalizeType(col.GetSQLType())),
+		VarcharLen:       col.GetVarcharLen(),
+		NumericPrecision: col.GetNumericPrecision(),
+		NumericScale:     col.GetNumericScale(),
+		ArrayDims:        col.GetArrayDims(),
+	}
+	b, _ := typ.AppendQuery(schema.NewNopFormatter(), nil)
+	if s := string(b); dbmlTypeRe.MatchString(s) {
+		return s
+	}
+	return strconv.Quote(string(b))
+}
+
+var (
+	dbmlTypeRe  = regexp.MustCompile(`^\w+(\(\d+(,\d+)?\))?$`)
+	dbmlIdentRe = regexp.MustCompile(`^\w+$`)
+)
+
+// dbmlDefault returns the default value as a DBML number, boolean, or string literal,
+// or as an expression in backticks, e.g. `now()`. See sqlschema.IsDefaultExpr.
+func dbmlDefault(def string) string {
+	if _, err := strconv.ParseFloat(def, 64); err == nil {
+		return def
+	}
+	switch lower := strings.ToLower(def); {
+	case lower == "true" || lower == "false" || lower == "null":
+		return lower
+	case sqlschema.IsDefaultExpr(def):
+		return "`" + def + "`"
+	}
+	return dbmlString(defaultLiteral(def))
+}
+
+func dbmlTableName(key string) string {
+	if schemaName, tableName := sqlschema.SplitTableKey(key); schemaName != "" {
+		return dbmlIdent(schemaName) + "." + dbmlIdent(tableName)
+	}
+	return dbmlIdent(key)
+}
+
+func dbmlColumnRef(ref sqlschema.ColumnReference) string {
+	if columns := ref.Column.Split(); len(columns) > 1 {
+		return dbmlTableName(ref.TableName) + "." + dbmlColumns(columns)
+	}
+	return dbmlTableName(ref.TableName) + "." + dbmlIdent(string(ref.Column))
+}
+
+func dbmlColumns(columns []string) string {
+	parts := make([]string, len(columns))
+	for i, col := range columns {
+		parts[i] = dbmlIdent(col)
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+func dbmlIdent(name string) string {
+	if dbmlIdentRe.MatchString(name) {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `\"`) + `"`
+}
+
+// dbmlString quotes the string with single quotes, as DBML notes and literals expect.
+func dbmlString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+func dbmlSettings(settings []string) string {
+	if len(settings) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(settings, ", ") + "]"
+}
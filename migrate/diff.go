@@ -1,110 +1,1245 @@
 package migrate
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/internal/ordered"
 	"github.com/uptrace/bun/migrate/sqlschema"
+	"github.com/uptrace/bun/schema"
 )
 
+// Changeset lists the operations that bring the current database schema to the target state,
+// in the order they should be applied. Each change is a typed Operation, e.g. *AddColumnOp,
+// which the caller can inspect with a type switch.
+type Changeset struct {
+	Operations []Operation
+}
+
+// Diff compares the current database schema to the target state, typically produced by
+// a database Inspector and BunModelInspector respectively. Column types are compared with
+// the dialect's CompareType, so that type aliases are not reported as changes.
+// Additional rules are consulted first, see WithTypeEquivalence.
+// Default values are compared in the canonical form if the dialect implements sqlschema.DefaultNormalizer.
+//
+// Diff may modify the passed database schemas, so they should not be re-used.
+func Diff(dialect sqlschema.InspectorDialect, current, target sqlschema.Database, rules ...CompareTypeFunc) (*Changeset, error) {
+	cmpType := anyCompareType(append(slices.Clone(rules), dialect.CompareType)...)
+	changes, err := diff(current, target, withCompareTypeFunc(cmpType), withDialectDefaults(dialect))
+	if err != nil {
+		return nil, fmt.Errorf("diff: %w", err)
+	}
+	if err := changes.ResolveDependencies(); err != nil {
+		return nil, fmt.Errorf("diff: %w", err)
+	}
+	return &Changeset{Operations: changes.operations}, nil
+}
+
+// Statement is the SQL generated for a single operation in the Changeset.
+type Statement struct {
+	Operation Operation
+	SQL       string
+
+	// Destructive statements, such as DROP TABLE, DROP COLUMN, and lossy type changes, may lose data that cannot be recovered
+	// by reverting the migration, see IsDestructive.
+	Destructive bool
+}
+
+// Statements generates SQL for each operation in the changeset, quoted according to the Migrator's dialect.
+// Statements are returned separately and in order, so that the caller may wrap them in a transaction
+// or split them into several migration files.
+func (c *Changeset) Statements(m sqlschema.Migrator) ([]Statement, error) {
+	statements := make([]Statement, 0, len(c.Operations))
+	for _, op := range c.Operations {
+		if _, isComment := op.(*comment); isComment {
+			continue
+		}
+
+		b, err := m.AppendSQL(nil, op)
+		if err != nil {
+			return nil, fmt.Errorf("generate statements: %w", err)
+		}
+		statements = append(statements, Statement{
+			Operation:   op,
+			SQL:         string(b),
+			Destructive: IsDestructive(op),
+		})
+	}
+	return statements, nil
+}
+
+// Export generates the DDL script which recreates the schema state from scratch in an empty database,
+// e.g. to bootstrap a database from bun models or to snapshot an inspected one, like `pg_dump --schema-only`.
+// Schemas are created first, if the dialect supports them, followed by the extensions, types, sequences,
+// tables, constraints, indexes, and the other objects in the order of their dependencies.
+// Tables are created before the tables that reference them and foreign keys are added once all tables exist.
+// Dialects which declare foreign keys in CREATE TABLE, see sqlschema.ForeignKeyInliner, cannot add them afterwards,
+// so the references which form a cycle are declared inline as well, before the referenced table is created.
+// Tables outside of schemaName must be keyed by their qualified name, see sqlschema.InspectorConfig.TableKey.
+//
+// The script is the same for the same state, so it can be compared with a snapshot. The state may be modified,
+// like in Diff, so it should not be re-used.
+func Export(db *bun.DB, schemaName string, state sqlschema.Database, opts ...ExportOption) ([]string, error) {
+	var cfg exportConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	up, _, err := export(db, schemaName, state, cfg)
+	return up, err
+}
+
+// ExportOption configures Export.
+type ExportOption func(*exportConfig)
+
+type exportConfig struct {
+	ifNotExists bool
+}
+
+// WithIfNotExists makes the script safe to run repeatedly, e.g. to seed fresh environments:
+// tables, indexes, and sequences are created with IF NOT EXISTS, while the types and foreign keys,
+// which Postgres cannot create conditionally, are skipped if they exist already.
+// Objects which exist are left as they are, even if their definition differs from the state,
+// so use Diff to migrate a database which may have drifted.
+func WithIfNotExists() ExportOption {
+	return func(cfg *exportConfig) {
+		cfg.ifNotExists = true
+	}
+}
+
+// export generates the script which creates the state, see Export, and the one which drops it again.
+// The latter reverts the former statement by statement in reverse order, except that the schemas
+// and extensions are kept, and that foreign keys are dropped with their tables if they are declared inline.
+func export(db *bun.DB, schemaName string, state sqlschema.Database, cfg exportConfig) (up, down []string, _ error) {
+	dialect, ok := db.Dialect().(sqlschema.InspectorDialect)
+	if !ok {
+		return nil, nil, fmt.Errorf("export: %s does not implement sqlschema.InspectorDialect", db.Dialect().Name())
+	}
+	m, err := sqlschema.NewMigrator(db, schemaName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("export: %w", err)
+	}
+
+	empty := sqlschema.BaseDatabase{
+		Tables:      ordered.NewMap[string, sqlschema.Table](),
+		ForeignKeys: make(map[sqlschema.ForeignKey]string),
+		Enums:       make(map[string][]string),
+	}
+	// Tables are created after the tables they reference, see sqlschema.CreationOrder, and indexes are sorted by name,
+	// so that the script does not depend on the order they were inspected in.
+	tables := state.GetTables()
+	order, _ := sqlschema.CreationOrder(state)
+	values := make([]sqlschema.Table, len(order))
+	for i, key := range order {
+		values[i] = tables.Value(key)
+	}
+	tables.Clear()
+	for i, key := range order {
+		tables.Store(key, values[i])
+	}
+	slices.SortStableFunc(state.GetIndexes(), func(a, b sqlschema.Index) int {
+		if c := strings.Compare(a.TableName, b.TableName); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	schemas := exportedSchemas(schemaName, state)
+	changes, err := Diff(dialect, empty, state)
+	if err != nil {
+		return nil, nil, fmt.Errorf("export: %w", err)
+	}
+
+	if creator, ok := db.Dialect().(sqlschema.SchemaCreator); ok {
+		for _, name := range schemas {
+			up = append(up, string(creator.AppendCreateSchema(db.Formatter(), nil, name)))
+		}
+	}
+
+	// Dialects which declare foreign keys in CREATE TABLE cannot add them to the created tables afterwards.
+	inliner, ok := db.Dialect().(sqlschema.ForeignKeyInliner)
+	inline := ok && inliner.InlineForeignKeys()
+	for _, op := range changes.Operations {
+		if cfg.ifNotExists {
+			setIfNotExists(op)
+		}
+		switch op := op.(type) {
+		case *comment:
+			continue
+		case *AddForeignKeyOp:
+			if inline {
+				continue
+			}
+		case *CreateTableOp:
+			if inline {
+				statements, err := createTableStatements(db, schemaName, state, op.TableName, op.IfNotExists)
+				if err != nil {
+					return nil, nil, fmt.Errorf("export: %w", err)
+				}
+				up = append(up, statements[0].SQL)
+				if down, err = prependReverse(down, m, op); err != nil {
+					return nil, nil, fmt.Errorf("export: %w", err)
+				}
+				continue
+			}
+		}
+		b, err := m.AppendSQL(nil, op)
+		if err != nil {
+			return nil, nil, fmt.Errorf("export: %w", err)
+		}
+		up = append(up, string(b))
+		if down, err = prependReverse(down, m, op); err != nil {
+			return nil, nil, fmt.Errorf("export: %w", err)
+		}
+	}
+	return up, down, nil
+}
+
+// setIfNotExists marks the operations which create objects to skip the objects that exist already.
+func setIfNotExists(op Operation) {
+	switch op := op.(type) {
+	case *CreateTableOp:
+		op.IfNotExists = true
+	case *CreateIndexOp:
+		op.IfNotExists = true
+	case *CreateEnumOp:
+		op.IfNotExists = true
+	case *CreateDomainOp:
+		op.IfNotExists = true
+	case *CreateCompositeTypeOp:
+		op.IfNotExists = true
+	case *CreateSequenceOp:
+		op.IfNotExists = true
+	case *AddForeignKeyOp:
+		op.IfNotExists = true
+	}
+}
+
+// prependReverse adds the statement which reverts op to the beginning of the script.
+// Operations which are not reverted, e.g. CreateExtensionOp, are skipped.
+func prependReverse(script []string, m sqlschema.Migrator, op Operation) ([]string, error) {
+	reverse := op.GetReverse()
+	if _, isComment := reverse.(*comment); isComment {
+		return script, nil
+	}
+	b, err := m.AppendSQL(nil, reverse)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Insert(script, 0, string(b)), nil
+}
+
+// exportedSchemas returns the sorted names of the schemas other than schemaName which contain any objects in the state.
+func exportedSchemas(schemaName string, state sqlschema.Database) []string {
+	var schemas []string
+	add := func(name string) {
+		if name != "" && name != schemaName && !slices.Contains(schemas, name) {
+			schemas = append(schemas, name)
+		}
+	}
+	for _, t := range state.GetTables().Values() {
+		add(t.GetSchema())
+	}
+	for _, v := range state.GetViews() {
+		add(v.Schema)
+	}
+	for _, s := range state.GetSequences() {
+		add(s.Schema)
+	}
+	for _, d := range state.GetDomains() {
+		add(d.Schema)
+	}
+	for _, ct := range state.GetCompositeTypes() {
+		add(ct.Schema)
+	}
+	for _, ext := range state.GetExtensions() {
+		add(ext.Schema)
+	}
+	slices.Sort(schemas)
+	return schemas
+}
+
+// CreateTableStatements generates the statements which create the table from its definition in the state,
+// e.g. to bootstrap a new database from bun models. The table's foreign keys are added with separate
+// ALTER TABLE statements, unless the dialect declares them in CREATE TABLE, see sqlschema.ForeignKeyInliner.
+// Tables outside of schemaName must be looked up by their qualified name, see sqlschema.InspectorConfig.TableKey.
+func CreateTableStatements(db *bun.DB, schemaName string, state sqlschema.Database, tableName string) ([]Statement, error) {
+	return createTableStatements(db, schemaName, state, tableName, false)
+}
+
+func createTableStatements(db *bun.DB, schemaName string, state sqlschema.Database, tableName string, ifNotExists bool) ([]Statement, error) {
+	table, ok := state.GetTables().Load(tableName)
+	if !ok {
+		return nil, fmt.Errorf("generate statements: table %q is not defined", tableName)
+	}
+	fks := make(map[sqlschema.ForeignKey]string)
+	for fk, name := range state.GetForeignKeys() {
+		if fk.From.TableName == tableName {
+			fks[fk] = name
+		}
+	}
+	tableSchema := table.GetSchema()
+	if tableSchema == "" {
+		tableSchema = schemaName
+	}
+
+	base := sqlschema.NewBaseMigrator(db)
+	create := &CreateTableOp{TableName: tableName, Table: table, IfNotExists: ifNotExists}
+	var opts []sqlschema.CreateTableOption
+	if ifNotExists {
+		opts = append(opts, sqlschema.WithIfNotExists())
+	}
+	if inliner, ok := db.Dialect().(sqlschema.ForeignKeyInliner); ok && inliner.InlineForeignKeys() {
+		b, err := base.AppendCreateTableWithForeignKeys(nil, tableSchema, table, fks, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("generate statements: %w", err)
+		}
+		return []Statement{{Operation: create, SQL: string(b)}}, nil
+	}
+
+	b, err := base.AppendCreateTableDefinition(nil, tableSchema, table, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("generate statements: %w", err)
+	}
+	statements := []Statement{{Operation: create, SQL: string(b)}}
+	for _, fk := range sqlschema.SortedForeignKeys(fks) {
+		op := &AddForeignKeyOp{ForeignKey: fk, ConstraintName: fks[fk]}
+		b := base.AppendAddForeignKey(nil, tableSchema, fk, fks[fk])
+		statements = append(statements, Statement{Operation: op, SQL: string(b)})
+	}
+	return statements, nil
+}
+
+// DropTablesOption configures DropTableStatements.
+type DropTablesOption func(*dropTablesConfig)
+
+type dropTablesConfig struct {
+	cascade bool
+}
+
+// WithDropCascade appends CASCADE to DROP TABLE statements if the dialect supports it,
+// so that the objects which depend on the tables, e.g. views, are dropped too.
+func WithDropCascade() DropTablesOption {
+	return func(cfg *dropTablesConfig) {
+		cfg.cascade = true
+	}
+}
+
+// DropTableStatements generates the statements which drop all tables in the state, e.g. to tear down a schema.
+// Tables are dropped before the tables they reference, so that no foreign key is left with a missing table.
+// Tables which reference each other in a cycle cannot be ordered, so the cycle is broken by dropping the
+// foreign keys which reference one of the tables first, unless the tables are dropped with CASCADE.
+// Tables are otherwise dropped in the order of the state.
+func DropTableStatements(db *bun.DB, schemaName string, state sqlschema.Database, opts ...DropTablesOption) ([]Statement, error) {
+	var cfg dropTablesConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	tables := state.GetTables()
+	fks := state.GetForeignKeys()
+
+	// referencedBy maps the tables to the other tables in the state whose foreign keys reference them.
+	referencedBy := make(map[string]map[string]bool)
+	for fk := range fks {
+		from, to := fk.From.TableName, fk.To.TableName
+		if _, ok := tables.Load(from); !ok || from == to {
+			continue
+		}
+		if referencedBy[to] == nil {
+			referencedBy[to] = make(map[string]bool)
+		}
+		referencedBy[to][from] = true
+	}
+
+	var statements []Statement
+	dropped := make(map[string]bool, tables.Len())
+	canDrop := func(name string) bool {
+		for ref := range referencedBy[name] {
+			if !dropped[ref] {
+				return false
+			}
+		}
+		return true
+	}
+	dropTable := func(name string) error {
+		table := tables.Value(name)
+		tableSchema := table.GetSchema()
+		if tableSchema == "" {
+			tableSchema = schemaName
+		}
+		q := db.NewDropTable().TableExpr("?.?", bun.Ident(tableSchema), bun.Ident(table.GetName()))
+		if cfg.cascade {
+			q = q.Cascade()
+		}
+		b, err := q.AppendQuery(db.Formatter(), nil)
+		if err != nil {
+			return err
+		}
+		dropped[name] = true
+		statements = append(statements, Statement{
+			Operation:   &DropTableOp{TableName: name, Table: table},
+			SQL:         string(b),
+			Destructive: true,
+		})
+		return nil
+	}
+
+	remaining := tables.Keys()
+	for len(remaining) > 0 {
+		progress := false
+		for _, name := range remaining {
+			if !canDrop(name) {
+				continue
+			}
+			if err := dropTable(name); err != nil {
+				return nil, fmt.Errorf("generate statements: %w", err)
+			}
+			progress = true
+		}
+		remaining = slices.DeleteFunc(remaining, func(name string) bool { return dropped[name] })
+		if progress || len(remaining) == 0 {
+			continue
+		}
+
+		// The remaining tables reference each other in a cycle, which is broken at the first of them.
+		first := remaining[0]
+		if cfg.cascade {
+			if err := dropTable(first); err != nil {
+				return nil, fmt.Errorf("generate statements: %w", err)
+			}
+			remaining = remaining[1:]
+			continue
+		}
+		m, err := sqlschema.NewMigrator(db, schemaName)
+		if err != nil {
+			return nil, fmt.Errorf("generate statements: %w", err)
+		}
+		for _, fk := range sqlschema.SortedForeignKeys(fks) {
+			from, to := fk.From.TableName, fk.To.TableName
+			if to != first || !referencedBy[to][from] || dropped[from] {
+				continue
+			}
+			op := &DropForeignKeyOp{ForeignKey: fk, ConstraintName: fks[fk]}
+			b, err := m.AppendSQL(nil, op)
+			if err != nil {
+				return nil, fmt.Errorf("generate statements: %w", err)
+			}
+			statements = append(statements, Statement{Operation: op, SQL: string(b)})
+		}
+		delete(referencedBy, first)
+	}
+	return statements, nil
+}
+
+// IsDestructive checks if applying the operation loses data that cannot be recovered by reverting it:
+// dropping tables, columns, and attributes of composite types, or converting column values lossily.
+// Dropping indexes, constraints, and foreign keys is not destructive, since they can be re-created from the data.
+func IsDestructive(op Operation) bool {
+	switch op := op.(type) {
+	case *DropTableOp, *DropColumnOp, *DropCompositeAttributeOp:
+		return true
+	case *ChangeColumnTypeOp:
+		return sqlschema.IsLossyConversion(op.From, op.To)
+	}
+	return false
+}
+
 // changeset is a set of changes to the database schema definition.
 type changeset struct {
 	operations []Operation
 }
 
-// Add new operations to the changeset.
-func (c *changeset) Add(op ...Operation) {
-	c.operations = append(c.operations, op...)
+// Add new operations to the changeset.
+func (c *changeset) Add(op ...Operation) {
+	c.operations = append(c.operations, op...)
+}
+
+// diff calculates the diff between the current database schema and the target state.
+// The changeset is not sorted -- the caller should resolve dependencies before applying the changes.
+func diff(got, want sqlschema.Database, opts ...diffOption) (*changeset, error) {
+	d := newDetector(got, want, opts...)
+	return d.detectChanges()
+}
+
+func (d *detector) detectChanges() (*changeset, error) {
+	currentTables := d.current.GetTables()
+	targetTables := d.target.GetTables()
+
+	d.detectExtensionChanges()
+	d.detectEnumChanges()
+	d.detectViewChanges()
+	d.detectSequenceChanges()
+	d.detectTriggerChanges()
+	d.detectPolicyChanges()
+	d.detectDomainChanges()
+	d.detectCompositeTypeChanges()
+
+	// Partitions are created and dropped together with their parent table, so they are not diffed
+	// unless the target state defines them too.
+	for _, name := range currentTables.Keys() {
+		if d.partitions[name] {
+			currentTables.Delete(name)
+		}
+	}
+
+	// Collect explicit renames, ignoring the ones that refer to non-existent tables
+	// or to tables that still have a model.
+	explicit := make(map[string]string)
+	for _, wantPair := range targetTables.Pairs() {
+		bunTable, ok := wantPair.Value.(*sqlschema.BunTable)
+		if !ok || bunTable.RenamedFrom == "" {
+			continue
+		}
+		_, oldExists := currentTables.Load(bunTable.RenamedFrom)
+		_, oldKept := targetTables.Load(bunTable.RenamedFrom)
+		_, newExists := currentTables.Load(wantPair.Key)
+		if oldExists && !oldKept && !newExists {
+			explicit[wantPair.Key] = bunTable.RenamedFrom
+		}
+	}
+
+	for _, wantPair := range targetTables.Pairs() {
+		wantName, wantTable := wantPair.Key, wantPair.Value
+		// A table with this name exists in the database. We assume that schema objects won't
+		// be renamed to an already existing name, nor do we support such cases.
+		// Simply check if the table definition has changed.
+		if haveTable, ok := currentTables.Load(wantName); ok {
+			d.detectColumnChanges(wantName, haveTable, wantTable, true)
+			d.detectConstraintChanges(wantName, haveTable, wantTable)
+			d.detectTableCommentChange(wantName, haveTable.GetComment(), wantTable.GetComment())
+			d.detectRowSecurityChange(wantName, haveTable.GetRowSecurity(), wantTable.GetRowSecurity())
+			d.detectTablespaceChange(wantName, haveTable.GetTablespace(), wantTable.GetTablespace())
+			continue
+		}
+
+		// The table was renamed with the "rename_from" tag option, so its columns may have changed too.
+		if haveName, ok := explicit[wantName]; ok {
+			haveTable := currentTables.Value(haveName)
+			d.renameTable(haveName, wantName)
+			d.detectColumnChanges(wantName, haveTable, wantTable, true)
+			d.detectConstraintChanges(wantName, haveTable, wantTable)
+			d.detectTableCommentChange(wantName, haveTable.GetComment(), wantTable.GetComment())
+			d.detectRowSecurityChange(wantName, haveTable.GetRowSecurity(), wantTable.GetRowSecurity())
+			d.detectTablespaceChange(wantName, haveTable.GetTablespace(), wantTable.GetTablespace())
+			currentTables.Delete(haveName)
+			continue
+		}
+
+		// Find renamed tables. We assume that renamed tables have the same signature.
+		if haveName, ok := d.findRenamedTable(currentTables, targetTables, explicit, wantName, wantTable); ok {
+			haveTable := currentTables.Value(haveName)
+			d.renameTable(haveName, wantName)
+
+			// Find renamed columns, if any, and check if constraints (PK, UNIQUE) have been updated.
+			// We need not check wantTable any further.
+			d.detectColumnChanges(wantName, haveTable, wantTable, false)
+			d.detectConstraintChanges(wantName, haveTable, wantTable)
+			d.detectTableCommentChange(wantName, haveTable.GetComment(), wantTable.GetComment())
+			d.detectRowSecurityChange(wantName, haveTable.GetRowSecurity(), wantTable.GetRowSecurity())
+			d.detectTablespaceChange(wantName, haveTable.GetTablespace(), wantTable.GetTablespace())
+			currentTables.Delete(haveName)
+			continue
+		}
+
+		// If wantTable does not exist in the database and was not renamed
+		// then we need to create this table in the database.
+		create := &CreateTableOp{
+			TableName: wantName,
+			Table:     wantTable,
+		}
+		// Models which inherit from another table include the inherited columns, so such tables are created from their definition.
+		if bunTable, ok := wantTable.(*sqlschema.BunTable); ok && !bunTable.TypeOverridden && len(bunTable.Inherits) == 0 {
+			create.Model = bunTable.Model
+		}
+		d.changes.Add(create)
+		for _, excl := range wantTable.GetExclusions() {
+			d.changes.Add(&AddExclusionConstraintOp{TableName: wantName, Exclusion: excl})
+		}
+		d.detectTableCommentChange(wantName, "", wantTable.GetComment())
+		d.detectRowSecurityChange(wantName, sqlschema.RowSecurity{}, wantTable.GetRowSecurity())
+		for _, col := range wantTable.GetColumns().Pairs() {
+			d.detectCommentChange(wantName, col.Key, "", col.Value.GetComment())
+			d.detectStorageChange(wantName, col.Key, "", col.Value.GetStorage())
+		}
+	}
+
+	// Drop any remaining "current" tables which do not have a model.
+	dropped := make(map[string]bool)
+	for _, tPair := range currentTables.Pairs() {
+		name, table := tPair.Key, tPair.Value
+		if _, keep := targetTables.Load(name); !keep {
+			d.changes.Add(&DropTableOp{
+				TableName: name,
+				Table:     table,
+			})
+			dropped[name] = true
+		}
+	}
+
+	d.detectIndexChanges(dropped)
+	d.detectGrantChanges(dropped)
+
+	targetFKs := d.target.GetForeignKeys()
+	currentFKs := d.refMap.Deref()
+
+	// Iterate over foreign keys in a stable order to produce the same changeset for the same schemas.
+	for _, fk := range sqlschema.SortedForeignKeys(targetFKs) {
+		if _, ok := currentFKs[fk]; !ok {
+			d.changes.Add(&AddForeignKeyOp{
+				ForeignKey:     fk,
+				ConstraintName: targetFKs[fk], // dialects apply their naming convention if the name is empty
+			})
+		}
+	}
+
+	for _, fk := range sqlschema.SortedForeignKeys(currentFKs) {
+		// Foreign keys on the partitions are inherited from the parent table.
+		if _, ok := targetFKs[fk]; !ok && !d.partitions[fk.From.TableName] {
+			d.changes.Add(&DropForeignKeyOp{
+				ConstraintName: currentFKs[fk],
+				ForeignKey:     fk,
+			})
+		}
+	}
+
+	if err := d.recreateReferencingKeys(currentFKs, targetFKs); err != nil {
+		return nil, err
+	}
+	d.completeTableRebuilds()
+	return &d.changes, nil
+}
+
+// completeTableRebuilds records the indexes and triggers which must be created again if the column changes
+// rebuild the table, leaving out the ones that the changeset drops, together with the foreign keys
+// of the tables which reference it. See ChangeColumnTypeOp.Indexes.
+func (d *detector) completeTableRebuilds() {
+	type triggerKey struct{ table, name string }
+	droppedIndexes := make(map[string]bool)
+	droppedTriggers := make(map[triggerKey]bool)
+	for _, op := range d.changes.operations {
+		switch op := op.(type) {
+		case *DropIndexOp:
+			droppedIndexes[op.Index.Name] = true
+		case *DropTriggerOp:
+			droppedTriggers[triggerKey{op.Trigger.TableName, op.Trigger.Name}] = true
+		}
+	}
+
+	// Indexes, triggers and foreign keys in the current state refer to the tables by their old names.
+	isTable := func(name, tableName string) bool {
+		return name == tableName || d.renamedTables[name] == tableName
+	}
+	indexesOf := func(tableName string) (indexes []sqlschema.Index) {
+		for _, idx := range d.current.GetIndexes() {
+			if isTable(idx.TableName, tableName) && !droppedIndexes[idx.Name] {
+				idx.TableName = tableName
+				indexes = append(indexes, idx)
+			}
+		}
+		return indexes
+	}
+	triggersOf := func(tableName string) (triggers []sqlschema.Trigger) {
+		for _, t := range d.current.GetTriggers() {
+			if isTable(t.TableName, tableName) && !droppedTriggers[triggerKey{t.TableName, t.Name}] {
+				t.TableName = tableName
+				triggers = append(triggers, t)
+			}
+		}
+		return triggers
+	}
+	referencing := func(tableName string) map[sqlschema.ForeignKey]string {
+		fks := make(map[sqlschema.ForeignKey]string)
+		for fk, name := range d.current.GetForeignKeys() {
+			if isTable(fk.To.TableName, tableName) {
+				fks[fk] = name
+			}
+		}
+		return fks
+	}
+
+	for _, op := range d.changes.operations {
+		switch op := op.(type) {
+		case *ChangeColumnTypeOp:
+			op.Indexes, op.Triggers, op.ReferencedBy = indexesOf(op.TableName), triggersOf(op.TableName), referencing(op.TableName)
+		case *ChangeColumnDefaultOp:
+			op.Indexes, op.Triggers, op.ReferencedBy = indexesOf(op.TableName), triggersOf(op.TableName), referencing(op.TableName)
+		case *ChangeColumnNullabilityOp:
+			op.Indexes, op.Triggers, op.ReferencedBy = indexesOf(op.TableName), triggersOf(op.TableName), referencing(op.TableName)
+		}
+	}
+}
+
+// recreateReferencingKeys drops the foreign keys that reference a primary key which is dropped or changed,
+// since the database would refuse to drop it, and adds them back once the new key is in place.
+// The foreign keys which are kept in the target state must still reference the new primary key
+// or a unique constraint, otherwise the change cannot be applied and an error is returned.
+func (d *detector) recreateReferencingKeys(currentFKs, targetFKs map[sqlschema.ForeignKey]string) error {
+	for _, op := range slices.Clone(d.changes.operations) {
+		var tableName string
+		var pk sqlschema.PrimaryKey
+		switch op := op.(type) {
+		case *DropPrimaryKeyOp:
+			tableName, pk = op.TableName, op.PrimaryKey
+		case *ChangePrimaryKeyOp:
+			tableName, pk = op.TableName, op.Old
+		default:
+			continue
+		}
+
+		for _, fk := range sqlschema.SortedForeignKeys(currentFKs) {
+			if _, kept := targetFKs[fk]; !kept || !referencesKey(fk, tableName, pk.Columns) {
+				continue
+			}
+			if !d.isTargetKey(tableName, fk.To.Column) {
+				return fmt.Errorf("cannot drop primary key (%s) of table %q: foreign key %s references it, "+
+					"drop the foreign key or add a unique constraint on (%[1]s)", pk.Columns, tableName, fk)
+			}
+			d.changes.Add(
+				&DropForeignKeyOp{ForeignKey: fk, ConstraintName: currentFKs[fk]},
+				&AddForeignKeyOp{ForeignKey: fk, ConstraintName: currentFKs[fk]},
+			)
+		}
+	}
+	return nil
+}
+
+// isTargetKey checks if the columns form the primary key or a unique constraint of the table in the target state.
+func (d *detector) isTargetKey(tableName string, columns sqlschema.Columns) bool {
+	table, ok := d.target.GetTables().Load(tableName)
+	if !ok {
+		return false
+	}
+	if pk := table.GetPrimaryKey(); pk != nil && sameColumns(pk.Columns, columns) {
+		return true
+	}
+	for _, u := range table.GetUniqueConstraints() {
+		if sameColumns(u.Columns, columns) {
+			return true
+		}
+	}
+	return false
+}
+
+// renameTable adds an operation to rename the table and tracks the new name in the foreign keys and indexes.
+func (d *detector) renameTable(oldName, newName string) {
+	d.changes.Add(&RenameTableOp{
+		TableName: oldName,
+		NewName:   newName,
+	})
+	d.refMap.RenameTable(oldName, newName)
+	d.renamedTables[oldName] = newName
+}
+
+// detectIndexChanges creates new indexes and drops the ones that are no longer defined. Indexes are matched
+// by their definition, so the indexes of renamed tables are kept. An index whose definition has changed
+// is dropped and created again under its name. Indexes of the dropped tables and of the partitions are dropped with them.
+//
+// Indexes are only migrated if the target state defines at least one of them,
+// so that indexes managed outside of bun are not dropped.
+func (d *detector) detectIndexChanges(droppedTables map[string]bool) {
+	if len(d.target.GetIndexes()) == 0 {
+		return
+	}
+
+	var current []sqlschema.Index
+	for _, idx := range d.current.GetIndexes() {
+		if droppedTables[idx.TableName] || d.partitions[idx.TableName] {
+			continue
+		}
+		if newName, ok := d.renamedTables[idx.TableName]; ok {
+			idx.TableName = newName
+		}
+		current = append(current, idx)
+	}
+
+	kept := make([]bool, len(current))
+	moved := make(map[int]sqlschema.Index)
+	var created []sqlschema.Index
+nextIndex:
+	for _, want := range d.target.GetIndexes() {
+		for i, have := range current {
+			if !kept[i] && have.Equals(want) {
+				kept[i] = true
+				if want.Tablespace != "" {
+					moved[i] = want
+				}
+				continue nextIndex
+			}
+		}
+		created = append(created, want)
+	}
+
+	for i, have := range current {
+		if !kept[i] {
+			d.changes.Add(&DropIndexOp{Index: have, Concurrently: d.concurrentIndexes})
+		}
+	}
+	for i, have := range current {
+		if want, ok := moved[i]; ok && !equalTablespaces(have.Tablespace, want.Tablespace) {
+			d.changes.Add(&ChangeIndexTablespaceOp{Index: have, From: have.Tablespace, To: want.Tablespace})
+		}
+	}
+	for _, want := range created {
+		d.changes.Add(&CreateIndexOp{Index: want, Concurrently: d.concurrentIndexes})
+	}
+}
+
+// findRenamedTable looks for a table in the database that could have been renamed to wantName.
+// Like with columns, a rename is only assumed if the match is unambiguous: exactly one of the dropped tables
+// has the same signature as wantTable, and it does not match any other of the created tables.
+func (d *detector) findRenamedTable(currentTables, targetTables *ordered.Map[string, sqlschema.Table], explicit map[string]string, wantName string, wantTable sqlschema.Table) (string, bool) {
+	renamedFrom := make(map[string]bool, len(explicit))
+	for _, oldName := range explicit {
+		renamedFrom[oldName] = true
+	}
+
+	var dropped []string
+	for _, havePair := range currentTables.Pairs() {
+		if _, exists := targetTables.Load(havePair.Key); exists || renamedFrom[havePair.Key] || !d.canRename(havePair.Value, wantTable) {
+			continue
+		}
+		dropped = append(dropped, havePair.Key)
+	}
+	if len(dropped) != 1 {
+		return "", false
+	}
+	haveName := dropped[0]
+	haveTable := currentTables.Value(haveName)
+
+	var created []string
+	for _, wantPair := range targetTables.Pairs() {
+		if _, exists := currentTables.Load(wantPair.Key); exists || explicit[wantPair.Key] != "" || !d.canRename(haveTable, wantPair.Value) {
+			continue
+		}
+		created = append(created, wantPair.Key)
+	}
+	if len(created) != 1 || created[0] != wantName {
+		return "", false
+	}
+	return haveName, true
+}
+
+// detectEnumChanges creates new enumerated types and adds values to the existing ones.
+// Enums that are no longer used by any of the target tables are dropped.
+func (d *detector) detectEnumChanges() {
+	currentEnums := d.current.GetEnums()
+	targetEnums := d.target.GetEnums()
+
+	for _, name := range sortedKeys(targetEnums) {
+		values := targetEnums[name]
+		have, ok := currentEnums[name]
+		if !ok {
+			d.changes.Add(&CreateEnumOp{TypeName: name, Values: values})
+			continue
+		}
+		have = slices.Clone(have)
+
+		for i, value := range values {
+			if slices.Contains(have, value) {
+				continue
+			}
+			add := &AddEnumValueOp{TypeName: name, Value: value}
+			if i > 0 {
+				add.After = values[i-1]
+			} else if len(have) > 0 {
+				add.Before = have[0]
+			}
+			d.changes.Add(add)
+			have = slices.Insert(have, slices.Index(have, add.After)+1, value)
+		}
+
+		for _, value := range have {
+			if !slices.Contains(values, value) {
+				c := comment(fmt.Sprintf("WARNING: value %q was removed from enum %q in the model, but it cannot be dropped automatically", value, name))
+				d.changes.Add(&c)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(currentEnums) {
+		if _, keep := targetEnums[name]; keep || d.usesType(d.target, name) {
+			continue
+		}
+		d.changes.Add(&DropEnumOp{TypeName: name, Values: currentEnums[name]})
+	}
+}
+
+// sortedKeys returns the keys of the map in ascending order, so that the changes do not
+// depend on the map's iteration order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// detectViewChanges creates new views and drops the ones that are no longer defined.
+// Views whose definition has changed are dropped and created again, and the materialized views
+// that should be populated, but are not, are refreshed.
+//
+// Views are only migrated if the target state defines at least one of them,
+// so that views managed outside of bun are not dropped.
+func (d *detector) detectViewChanges() {
+	if len(d.target.GetViews()) == 0 {
+		return
+	}
+	type viewKey struct{ schema, name string }
+	current := make(map[viewKey]sqlschema.View)
+	for _, v := range d.current.GetViews() {
+		current[viewKey{v.Schema, v.Name}] = v
+	}
+	target := make(map[viewKey]bool)
+
+	for _, want := range d.target.GetViews() {
+		key := viewKey{want.Schema, want.Name}
+		target[key] = true
+
+		if have, ok := current[key]; ok {
+			if have.Equals(want) {
+				if want.Materialized && want.Populated && !have.Populated {
+					d.changes.Add(&RefreshViewOp{View: want})
+				}
+				continue
+			}
+			d.changes.Add(&DropViewOp{View: have})
+		}
+		d.changes.Add(&CreateViewOp{View: want})
+	}
+
+	for _, have := range d.current.GetViews() {
+		if !target[viewKey{have.Schema, have.Name}] {
+			d.changes.Add(&DropViewOp{View: have})
+		}
+	}
+}
+
+// detectSequenceChanges creates new sequences, alters the ones whose parameters have changed,
+// and drops the standalone sequences that are no longer defined. Sequences owned by a column
+// are dropped together with it.
+//
+// Like views, sequences are only migrated if the target state defines at least one of them,
+// because the sequences of serial and identity columns are not part of the bun models.
+func (d *detector) detectSequenceChanges() {
+	if len(d.target.GetSequences()) == 0 {
+		return
+	}
+	type sequenceKey struct{ schema, name string }
+	current := make(map[sequenceKey]sqlschema.Sequence)
+	for _, s := range d.current.GetSequences() {
+		current[sequenceKey{s.Schema, s.Name}] = s
+	}
+	target := make(map[sequenceKey]bool)
+
+	for _, want := range d.target.GetSequences() {
+		key := sequenceKey{want.Schema, want.Name}
+		target[key] = true
+
+		have, ok := current[key]
+		switch {
+		case !ok:
+			d.changes.Add(&CreateSequenceOp{Sequence: want})
+		case !have.Equals(want):
+			d.changes.Add(&AlterSequenceOp{From: have, To: want})
+		}
+	}
+
+	for _, have := range d.current.GetSequences() {
+		if !target[sequenceKey{have.Schema, have.Name}] && !have.IsOwned() {
+			d.changes.Add(&DropSequenceOp{Sequence: have})
+		}
+	}
+}
+
+// detectExtensionChanges installs the required extensions which are missing from the database.
+// Extensions are never dropped, since the target state only lists the ones the schema needs.
+func (d *detector) detectExtensionChanges() {
+	for _, want := range d.target.GetExtensions() {
+		if !slices.ContainsFunc(d.current.GetExtensions(), func(have sqlschema.Extension) bool {
+			return have.Name == want.Name
+		}) {
+			d.changes.Add(&CreateExtensionOp{Extension: want})
+		}
+	}
+}
+
+// detectDomainChanges creates new domains and drops the ones that are neither defined nor used in the target state.
+// Domains cannot be changed without re-creating the columns that use them, so changed domains are only reported.
+// Like views, domains are only migrated if the target state defines at least one of them.
+func (d *detector) detectDomainChanges() {
+	if len(d.target.GetDomains()) == 0 {
+		return
+	}
+	type domainKey struct{ schema, name string }
+	current := make(map[domainKey]sqlschema.Domain)
+	for _, dom := range d.current.GetDomains() {
+		current[domainKey{dom.Schema, dom.Name}] = dom
+	}
+	target := make(map[domainKey]bool)
+
+	for _, want := range d.target.GetDomains() {
+		key := domainKey{want.Schema, want.Name}
+		target[key] = true
+
+		have, ok := current[key]
+		switch {
+		case !ok:
+			d.changes.Add(&CreateDomainOp{Domain: want})
+		case !have.Equals(want):
+			c := comment(fmt.Sprintf("WARNING: domain %q was changed in the model, but it cannot be altered automatically", want.Name))
+			d.changes.Add(&c)
+		}
+	}
+
+	for _, have := range d.current.GetDomains() {
+		if !target[domainKey{have.Schema, have.Name}] && !d.usesType(d.target, have.Name) {
+			d.changes.Add(&DropDomainOp{Domain: have})
+		}
+	}
 }
 
-// diff calculates the diff between the current database schema and the target state.
-// The changeset is not sorted -- the caller should resolve dependencies before applying the changes.
-func diff(got, want sqlschema.Database, opts ...diffOption) *changeset {
-	d := newDetector(got, want, opts...)
-	return d.detectChanges()
-}
+// detectCompositeTypeChanges creates new composite types, adds and drops the attributes of the existing ones,
+// and drops the types that are neither defined nor used in the target state. Attributes whose type
+// has changed are only reported. Like views, composite types are only migrated if the target state
+// defines at least one of them.
+func (d *detector) detectCompositeTypeChanges() {
+	if len(d.target.GetCompositeTypes()) == 0 {
+		return
+	}
+	type typeKey struct{ schema, name string }
+	current := make(map[typeKey]sqlschema.CompositeType)
+	for _, ct := range d.current.GetCompositeTypes() {
+		current[typeKey{ct.Schema, ct.Name}] = ct
+	}
+	target := make(map[typeKey]bool)
 
-func (d *detector) detectChanges() *changeset {
-	currentTables := d.current.GetTables()
-	targetTables := d.target.GetTables()
+	for _, want := range d.target.GetCompositeTypes() {
+		key := typeKey{want.Schema, want.Name}
+		target[key] = true
 
-RenameCreate:
-	for _, wantPair := range targetTables.Pairs() {
-		wantName, wantTable := wantPair.Key, wantPair.Value
-		// A table with this name exists in the database. We assume that schema objects won't
-		// be renamed to an already existing name, nor do we support such cases.
-		// Simply check if the table definition has changed.
-		if haveTable, ok := currentTables.Load(wantName); ok {
-			d.detectColumnChanges(haveTable, wantTable, true)
-			d.detectConstraintChanges(haveTable, wantTable)
+		have, ok := current[key]
+		if !ok {
+			d.changes.Add(&CreateCompositeTypeOp{Type: want})
 			continue
 		}
 
-		// Find all renamed tables. We assume that renamed tables have the same signature.
-		for _, havePair := range currentTables.Pairs() {
-			haveName, haveTable := havePair.Key, havePair.Value
-			if _, exists := targetTables.Load(haveName); !exists && d.canRename(haveTable, wantTable) {
-				d.changes.Add(&RenameTableOp{
-					TableName: haveTable.GetName(),
-					NewName:   wantName,
-				})
-				d.refMap.RenameTable(haveTable.GetName(), wantName)
+		for _, attr := range want.Attributes {
+			haveAttr, ok := have.Attribute(attr.Name)
+			switch {
+			case !ok:
+				d.changes.Add(&AddCompositeAttributeOp{Type: want, Attribute: attr})
+			case !haveAttr.Equals(attr):
+				c := comment(fmt.Sprintf("WARNING: attribute %q of type %q was changed in the model, but it cannot be altered automatically", attr.Name, want.Name))
+				d.changes.Add(&c)
+			}
+		}
+		for _, attr := range have.Attributes {
+			if _, keep := want.Attribute(attr.Name); !keep {
+				d.changes.Add(&DropCompositeAttributeOp{Type: have, Attribute: attr})
+			}
+		}
+	}
+
+	for _, have := range d.current.GetCompositeTypes() {
+		if !target[typeKey{have.Schema, have.Name}] && !d.usesType(d.target, have.Name) {
+			d.changes.Add(&DropCompositeTypeOp{Type: have})
+		}
+	}
+}
+
+// detectTriggerChanges creates new triggers and drops the ones that are no longer defined.
+// Triggers whose definition has changed are dropped and created again.
+//
+// Triggers are only migrated if the target state defines at least one of them,
+// so that triggers managed outside of bun are not dropped.
+func (d *detector) detectTriggerChanges() {
+	if len(d.target.GetTriggers()) == 0 {
+		return
+	}
+	type triggerKey struct{ table, name string }
+	current := make(map[triggerKey]sqlschema.Trigger)
+	for _, t := range d.current.GetTriggers() {
+		current[triggerKey{t.TableName, t.Name}] = t
+	}
+	target := make(map[triggerKey]bool)
+
+	for _, want := range d.target.GetTriggers() {
+		key := triggerKey{want.TableName, want.Name}
+		target[key] = true
 
-				// Find renamed columns, if any, and check if constraints (PK, UNIQUE) have been updated.
-				// We need not check wantTable any further.
-				d.detectColumnChanges(haveTable, wantTable, false)
-				d.detectConstraintChanges(haveTable, wantTable)
-				currentTables.Delete(haveName)
-				continue RenameCreate
+		if have, ok := current[key]; ok {
+			if have.Equals(want) {
+				continue
 			}
+			d.changes.Add(&DropTriggerOp{Trigger: have})
 		}
+		d.changes.Add(&CreateTriggerOp{Trigger: want})
+	}
 
-		// If wantTable does not exist in the database and was not renamed
-		// then we need to create this table in the database.
-		additional := wantTable.(*sqlschema.BunTable)
-		d.changes.Add(&CreateTableOp{
-			TableName: wantTable.GetName(),
-			Model:     additional.Model,
-		})
+	for _, have := range d.current.GetTriggers() {
+		if !target[triggerKey{have.TableName, have.Name}] && !d.partitions[have.TableName] {
+			d.changes.Add(&DropTriggerOp{Trigger: have})
+		}
 	}
+}
 
-	// Drop any remaining "current" tables which do not have a model.
-	for _, tPair := range currentTables.Pairs() {
-		name, table := tPair.Key, tPair.Value
-		if _, keep := targetTables.Load(name); !keep {
-			d.changes.Add(&DropTableOp{
-				TableName: table.GetName(),
-			})
+// detectPolicyChanges creates new row-level security policies and drops the ones that are no longer defined.
+// Policies which only differ in their roles or expressions are altered in place, the rest are dropped and created again.
+//
+// As with triggers, policies are only migrated if the target state defines at least one of them.
+func (d *detector) detectPolicyChanges() {
+	if len(d.target.GetPolicies()) == 0 {
+		return
+	}
+	type policyKey struct{ table, name string }
+	current := make(map[policyKey]sqlschema.Policy)
+	for _, p := range d.current.GetPolicies() {
+		current[policyKey{p.TableName, p.Name}] = p
+	}
+	target := make(map[policyKey]bool)
+
+	for _, want := range d.target.GetPolicies() {
+		key := policyKey{want.TableName, want.Name}
+		target[key] = true
+
+		if have, ok := current[key]; ok {
+			if have.Equals(want) {
+				continue
+			}
+			if canAlterPolicy(have, want) {
+				d.changes.Add(&AlterPolicyOp{From: have, To: want})
+				continue
+			}
+			d.changes.Add(&DropPolicyOp{Policy: have})
 		}
+		d.changes.Add(&CreatePolicyOp{Policy: want})
 	}
 
-	targetFKs := d.target.GetForeignKeys()
-	currentFKs := d.refMap.Deref()
+	for _, have := range d.current.GetPolicies() {
+		if !target[policyKey{have.TableName, have.Name}] && !d.partitions[have.TableName] {
+			d.changes.Add(&DropPolicyOp{Policy: have})
+		}
+	}
+}
 
-	for fk := range targetFKs {
-		if _, ok := currentFKs[fk]; !ok {
-			d.changes.Add(&AddForeignKeyOp{
-				ForeignKey:     fk,
-				ConstraintName: "", // leave empty to let each dialect apply their convention
-			})
+// canAlterPolicy reports whether ALTER POLICY can turn one policy into the other.
+// It cannot change the command or the kind of the policy, nor remove its expressions.
+func canAlterPolicy(from, to sqlschema.Policy) bool {
+	return from.Restrictive == to.Restrictive &&
+		from.NormalizedCommand() == to.NormalizedCommand() &&
+		(from.Using == "" || to.Using != "") &&
+		(from.WithCheck == "" || to.WithCheck != "")
+}
+
+// detectGrantChanges grants the privileges that the target state defines and revokes the ones it does not.
+// The grants on the dropped tables are dropped with them.
+//
+// Grants are only migrated if the target state defines at least one of them.
+func (d *detector) detectGrantChanges(dropped map[string]bool) {
+	if len(d.target.GetGrants()) == 0 {
+		return
+	}
+	current := make(map[sqlschema.Grant]bool)
+	for _, g := range normalizeGrants(d.current.GetGrants()) {
+		current[g] = true
+	}
+	target := make(map[sqlschema.Grant]bool)
+	for _, g := range normalizeGrants(d.target.GetGrants()) {
+		target[g] = true
+		if !current[g] {
+			d.changes.Add(&GrantOp{Grant: g})
+		}
+	}
+	for _, g := range normalizeGrants(d.current.GetGrants()) {
+		if !target[g] && !dropped[g.TableName] && !d.partitions[g.TableName] {
+			d.changes.Add(&RevokeOp{Grant: g})
 		}
 	}
+}
 
-	for fk, name := range currentFKs {
-		if _, ok := targetFKs[fk]; !ok {
-			d.changes.Add(&DropForeignKeyOp{
-				ConstraintName: name,
-				ForeignKey:     fk,
-			})
+// normalizeGrants expands ALL privileges and removes the duplicates, keeping the order of the grants.
+func normalizeGrants(grants []sqlschema.Grant) []sqlschema.Grant {
+	seen := make(map[sqlschema.Grant]bool)
+	var normalized []sqlschema.Grant
+	for _, grant := range grants {
+		for _, g := range grant.Expand() {
+			if strings.EqualFold(g.Grantee, "public") {
+				g.Grantee = "PUBLIC"
+			}
+			if !seen[g] {
+				seen[g] = true
+				normalized = append(normalized, g)
+			}
 		}
 	}
+	return normalized
+}
+
+// detectTablespaceChange moves the table to the tablespace the target state defines for it.
+// Tables without a tablespace in the target state are left where they are.
+func (d *detector) detectTablespaceChange(tableName string, from, to string) {
+	if to == "" || equalTablespaces(from, to) {
+		return
+	}
+	d.changes.Add(&ChangeTablespaceOp{
+		TableName: tableName,
+		From:      from,
+		To:        to,
+	})
+}
+
+// equalTablespaces compares tablespace names case-insensitively, as unquoted names are folded to lower case.
+func equalTablespaces(ts1, ts2 string) bool {
+	return strings.EqualFold(ts1, ts2)
+}
+
+// detectRowSecurityChange enables or disables row-level security for the table.
+// Row-level security is left as is in the tables that do not enable it, unless the target state defines any policies.
+func (d *detector) detectRowSecurityChange(tableName string, from, to sqlschema.RowSecurity) {
+	if from == to || (to == sqlschema.RowSecurity{} && len(d.target.GetPolicies()) == 0) {
+		return
+	}
+	d.changes.Add(&ChangeRowSecurityOp{
+		TableName: tableName,
+		From:      from,
+		To:        to,
+	})
+}
 
-	return &d.changes
+// usesType checks if any column in the database has this SQL type.
+func (d *detector) usesType(db sqlschema.Database, typ string) bool {
+	for _, t := range db.GetTables().Values() {
+		for _, c := range t.GetColumns().Values() {
+			if strings.EqualFold(c.GetSQLType(), typ) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // detechColumnChanges finds renamed columns and, if checkType == true, columns with changed type.
-func (d *detector) detectColumnChanges(current, target sqlschema.Table, checkType bool) {
+func (d *detector) detectColumnChanges(tableName string, current, target sqlschema.Table, checkType bool) {
 	currentColumns := current.GetColumns()
 	targetColumns := target.GetColumns()
 
-ChangeRename:
+	// Collect explicit renames, ignoring the ones that refer to non-existent columns
+	// or to columns that are still present in the model.
+	explicit := make(map[string]string)
+	if bunTable, ok := target.(*sqlschema.BunTable); ok {
+		for newName, oldName := range bunTable.RenamedColumns {
+			_, oldExists := currentColumns.Load(oldName)
+			_, oldKept := targetColumns.Load(oldName)
+			_, newExists := currentColumns.Load(newName)
+			if oldExists && !oldKept && !newExists {
+				explicit[newName] = oldName
+			}
+		}
+	}
+
 	for _, tPair := range targetColumns.Pairs() {
 		tName, tCol := tPair.Key, tPair.Value
 
@@ -112,44 +1247,40 @@ ChangeRename:
 		// Still, we should not delete(columns, thisColumn), because later we will need to
 		// check that we do not try to rename a column to an already a name that already exists.
 		if cCol, ok := currentColumns.Load(tName); ok {
-			if checkType && !d.equalColumns(cCol, tCol) {
-				d.changes.Add(&ChangeColumnTypeOp{
-					TableName: target.GetName(),
-					Column:    tName,
-					From:      cCol,
-					To:        d.makeTargetColDef(cCol, tCol),
-				})
+			if checkType {
+				d.detectColumnDefinitionChange(tableName, current, target, tName, cCol, tCol)
 			}
+			d.detectCommentChange(tableName, tName, cCol.GetComment(), tCol.GetComment())
+			d.detectStorageChange(tableName, tName, cCol.GetStorage(), tCol.GetStorage())
 			continue
 		}
 
 		// Column tName does not exist in the database -- it's been either renamed or added.
 		// Find renamed columns first.
-		for _, cPair := range currentColumns.Pairs() {
-			cName, cCol := cPair.Key, cPair.Value
-			// Cannot rename if a column with this name already exists or the types differ.
-			if _, exists := targetColumns.Load(cName); exists || !d.equalColumns(tCol, cCol) {
-				continue
+		if cName, ok := explicit[tName]; ok {
+			cCol := currentColumns.Value(cName)
+			d.renameColumn(tableName, current, target, cName, tName, cCol, tCol)
+			if checkType {
+				d.detectColumnDefinitionChange(tableName, current, target, tName, cCol, tCol)
 			}
-			d.changes.Add(&RenameColumnOp{
-				TableName: target.GetName(),
-				OldName:   cName,
-				NewName:   tName,
-			})
-			d.refMap.RenameColumn(target.GetName(), cName, tName)
-			currentColumns.Delete(cName) // no need to check this column again
-
-			// Update primary key definition to avoid superficially recreating the constraint.
-			current.GetPrimaryKey().Columns.Replace(cName, tName)
-
-			continue ChangeRename
+			continue
+		}
+		if cName, ok := d.findRenamedColumn(currentColumns, targetColumns, explicit, tName, tCol); ok {
+			d.renameColumn(tableName, current, target, cName, tName, currentColumns.Value(cName), tCol)
+			continue
 		}
 
-		d.changes.Add(&AddColumnOp{
-			TableName:  target.GetName(),
+		add := &AddColumnOp{
+			TableName:  tableName,
 			ColumnName: tName,
 			Column:     tCol,
-		})
+		}
+		if bunTable, ok := target.(*sqlschema.BunTable); ok && add.RequiresBackfill() {
+			add.Backfill = bunTable.Backfills[tName]
+		}
+		d.changes.Add(add)
+		d.detectCommentChange(tableName, tName, "", tCol.GetComment())
+		d.detectStorageChange(tableName, tName, "", tCol.GetStorage())
 	}
 
 	// Drop columns which do not exist in the target schema and were not renamed.
@@ -157,7 +1288,7 @@ ChangeRename:
 		cName, cCol := cPair.Key, cPair.Value
 		if _, keep := targetColumns.Load(cName); !keep {
 			d.changes.Add(&DropColumnOp{
-				TableName:  target.GetName(),
+				TableName:  tableName,
 				ColumnName: cName,
 				Column:     cCol,
 			})
@@ -165,7 +1296,158 @@ ChangeRename:
 	}
 }
 
-func (d *detector) detectConstraintChanges(current, target sqlschema.Table) {
+// detectColumnDefinitionChange compares the definitions of an existing column. Changes that only touch
+// the default value or nullability get their own operations, anything else changes the column type.
+func (d *detector) detectColumnDefinitionChange(tableName string, current, target sqlschema.Table, colName string, cCol, tCol sqlschema.Column) {
+	if !d.equalColumnsExceptDefaults(cCol, tCol, sqlschema.IgnoreNullability()) {
+		d.changeColumnType(tableName, current, target, colName, cCol, tCol)
+		// Changing the type resets the storage mode, so a storage mode that is kept must be set again.
+		if strings.EqualFold(cCol.GetStorage(), tCol.GetStorage()) {
+			d.detectStorageChange(tableName, colName, "", tCol.GetStorage())
+		}
+		return
+	}
+	if !d.equalDefaults(cCol.GetDefaultValue(), tCol.GetDefaultValue()) {
+		d.changeColumnDefault(tableName, current, colName, cCol, tCol)
+	}
+	if cCol.GetIsNullable() != tCol.GetIsNullable() && cCol.GetGeneratedExpr() == "" {
+		d.changeColumnNullability(tableName, current, target, colName, tCol)
+	}
+}
+
+// renameColumn adds an operation to rename the column and updates the current state to reflect the new name.
+func (d *detector) renameColumn(tableName string, current, target sqlschema.Table, oldName, newName string, cCol, tCol sqlschema.Column) {
+	d.changes.Add(&RenameColumnOp{
+		TableName: tableName,
+		OldName:   oldName,
+		NewName:   newName,
+	})
+	d.refMap.RenameColumn(tableName, oldName, newName)
+
+	// Keep the column in its place under the new name, so that the operations which change its definition,
+	// or rebuild the table, find it there. The target has a column with this name, so it is not checked again.
+	columns := current.GetColumns()
+	pairs := columns.Pairs()
+	columns.Clear()
+	for _, pair := range pairs {
+		if pair.Key == oldName {
+			pair.Key, pair.Value = newName, renamedColumn(cCol, newName)
+		}
+		columns.Store(pair.Key, pair.Value)
+	}
+
+	// Update primary key definition to avoid superficially recreating the constraint.
+	current.GetPrimaryKey().Columns.Replace(oldName, newName)
+
+	// Indexes follow the renamed column, so they need not be recreated either.
+	for i, idx := range d.current.GetIndexes() {
+		if idx.TableName != tableName && d.renamedTables[idx.TableName] != tableName {
+			continue
+		}
+		idxColumns := slices.Clone(idx.Columns)
+		for j := range idxColumns {
+			if idxColumns[j].Name == oldName {
+				idxColumns[j].Name = newName
+			}
+		}
+		d.current.GetIndexes()[i].Columns = idxColumns
+	}
+
+	d.detectCommentChange(tableName, newName, cCol.GetComment(), tCol.GetComment())
+	d.detectStorageChange(tableName, newName, cCol.GetStorage(), tCol.GetStorage())
+}
+
+// renamedColumn returns a copy of the column with the new name. Columns other than BaseColumn are returned as is.
+func renamedColumn(col sqlschema.Column, name string) sqlschema.Column {
+	if base, ok := col.(*sqlschema.BaseColumn); ok {
+		renamed := *base
+		renamed.Name = name
+		return &renamed
+	}
+	return col
+}
+
+// findRenamedColumn looks for a column in the database that could have been renamed to tName.
+// A rename is only assumed if the match is unambiguous, i.e. exactly one of the dropped columns
+// has the same definition as tCol, and it does not match any other of the added columns.
+// Otherwise, the columns are dropped and added, which is the safer outcome of the two.
+func (d *detector) findRenamedColumn(currentColumns, targetColumns *ordered.Map[string, sqlschema.Column], explicit map[string]string, tName string, tCol sqlschema.Column) (string, bool) {
+	if !d.detectRenamedColumns {
+		return "", false
+	}
+
+	renamedFrom := make(map[string]bool, len(explicit))
+	for _, oldName := range explicit {
+		renamedFrom[oldName] = true
+	}
+
+	// Cannot rename if a column with this name already exists or the types differ.
+	var dropped []string
+	for _, cPair := range currentColumns.Pairs() {
+		if _, exists := targetColumns.Load(cPair.Key); exists || renamedFrom[cPair.Key] || !d.equalColumns(tCol, cPair.Value) {
+			continue
+		}
+		dropped = append(dropped, cPair.Key)
+	}
+	if len(dropped) != 1 {
+		return "", false
+	}
+	cName := dropped[0]
+	cCol := currentColumns.Value(cName)
+
+	var added []string
+	for _, tPair := range targetColumns.Pairs() {
+		if _, exists := currentColumns.Load(tPair.Key); exists || explicit[tPair.Key] != "" || !d.equalColumns(tPair.Value, cCol) {
+			continue
+		}
+		added = append(added, tPair.Key)
+	}
+	if len(added) != 1 || added[0] != tName {
+		return "", false
+	}
+	return cName, true
+}
+
+// detectCommentChange adds an operation to change column comment if it has been modified.
+func (d *detector) detectCommentChange(tableName, column string, from, to string) {
+	if sqlschema.EqualComments(from, to) {
+		return
+	}
+	d.changes.Add(&ChangeColumnCommentOp{
+		TableName: tableName,
+		Column:    column,
+		From:      from,
+		To:        to,
+	})
+}
+
+// detectStorageChange adds an operation to change the column's storage mode if the target state sets one.
+// Columns without a storage mode in the target state keep the one they have.
+func (d *detector) detectStorageChange(tableName, column string, from, to string) {
+	if to == "" || strings.EqualFold(from, to) {
+		return
+	}
+	d.changes.Add(&ChangeColumnStorageOp{
+		TableName: tableName,
+		Column:    column,
+		From:      from,
+		To:        to,
+	})
+}
+
+// detectTableCommentChange adds an operation to change table comment if it has been modified.
+func (d *detector) detectTableCommentChange(tableName string, from, to string) {
+	if sqlschema.EqualComments(from, to) {
+		return
+	}
+	d.changes.Add(&ChangeTableCommentOp{
+		TableName: tableName,
+		From:      from,
+		To:        to,
+	})
+}
+
+func (d *detector) detectConstraintChanges(tableName string, current, target sqlschema.Table) {
 Add:
 	for _, want := range target.GetUniqueConstraints() {
 		for _, got := range current.GetUniqueConstraints() {
@@ -174,7 +1456,7 @@ Add:
 			}
 		}
 		d.changes.Add(&AddUniqueConstraintOp{
-			TableName: target.GetName(),
+			TableName: tableName,
 			Unique:    want,
 		})
 	}
@@ -188,11 +1470,63 @@ Drop:
 		}
 
 		d.changes.Add(&DropUniqueConstraintOp{
-			TableName: target.GetName(),
+			TableName: tableName,
 			Unique:    got,
 		})
 	}
 
+AddCheck:
+	for _, want := range target.GetChecks() {
+		for _, got := range current.GetChecks() {
+			if got.Equals(want) {
+				continue AddCheck
+			}
+		}
+		d.changes.Add(&AddCheckConstraintOp{
+			TableName: tableName,
+			Check:     want,
+		})
+	}
+
+DropCheck:
+	for _, got := range current.GetChecks() {
+		for _, want := range target.GetChecks() {
+			if got.Equals(want) {
+				continue DropCheck
+			}
+		}
+		d.changes.Add(&DropCheckConstraintOp{
+			TableName: tableName,
+			Check:     got,
+		})
+	}
+
+AddExclusion:
+	for _, want := range target.GetExclusions() {
+		for _, got := range current.GetExclusions() {
+			if got.Equals(want) {
+				continue AddExclusion
+			}
+		}
+		d.changes.Add(&AddExclusionConstraintOp{
+			TableName: tableName,
+			Exclusion: want,
+		})
+	}
+
+DropExclusion:
+	for _, got := range current.GetExclusions() {
+		for _, want := range target.GetExclusions() {
+			if got.Equals(want) {
+				continue DropExclusion
+			}
+		}
+		d.changes.Add(&DropExclusionConstraintOp{
+			TableName: tableName,
+			Exclusion: got,
+		})
+	}
+
 	targetPK := target.GetPrimaryKey()
 	currentPK := current.GetPrimaryKey()
 
@@ -203,17 +1537,17 @@ Drop:
 	switch {
 	case targetPK == nil && currentPK != nil:
 		d.changes.Add(&DropPrimaryKeyOp{
-			TableName:  target.GetName(),
+			TableName:  tableName,
 			PrimaryKey: *currentPK,
 		})
 	case currentPK == nil && targetPK != nil:
 		d.changes.Add(&AddPrimaryKeyOp{
-			TableName:  target.GetName(),
+			TableName:  tableName,
 			PrimaryKey: *targetPK,
 		})
 	case targetPK.Columns != currentPK.Columns:
 		d.changes.Add(&ChangePrimaryKeyOp{
-			TableName: target.GetName(),
+			TableName: tableName,
 			Old:       *currentPK,
 			New:       *targetPK,
 		})
@@ -223,18 +1557,34 @@ Drop:
 func newDetector(got, want sqlschema.Database, opts ...diffOption) *detector {
 	cfg := &detectorConfig{
 		cmpType: func(c1, c2 sqlschema.Column) bool {
-			return c1.GetSQLType() == c2.GetSQLType() && c1.GetVarcharLen() == c2.GetVarcharLen()
+			return c1.GetSQLType() == c2.GetSQLType() && c1.GetVarcharLen() == c2.GetVarcharLen() &&
+				c1.GetNumericPrecision() == c2.GetNumericPrecision() && c1.GetNumericScale() == c2.GetNumericScale() &&
+				c1.GetArrayDims() == c2.GetArrayDims()
 		},
+		detectRenamedColumns: true,
 	}
 	for _, opt := range opts {
 		opt(cfg)
 	}
 
+	// Partitions which the target state does not define are managed through their parent table.
+	partitions := make(map[string]bool)
+	for _, pair := range got.GetTables().Pairs() {
+		if _, defined := want.GetTables().Load(pair.Key); !defined && pair.Value.GetPartitionOf() != "" {
+			partitions[pair.Key] = true
+		}
+	}
+
 	return &detector{
-		current: got,
-		target:  want,
-		refMap:  newRefMap(got.GetForeignKeys()),
-		cmpType: cfg.cmpType,
+		current:              got,
+		partitions:           partitions,
+		target:               want,
+		refMap:               newRefMap(got.GetForeignKeys()),
+		renamedTables:        make(map[string]string),
+		cmpType:              cfg.cmpType,
+		normalizeDefault:     cfg.normalizeDefault,
+		detectRenamedColumns: cfg.detectRenamedColumns,
+		concurrentIndexes:    cfg.concurrentIndexes,
 	}
 }
 
@@ -246,9 +1596,33 @@ func withCompareTypeFunc(f CompareTypeFunc) diffOption {
 	}
 }
 
+// withDialectDefaults uses the dialect's DefaultNormalizer, if it implements one, to compare default values.
+func withDialectDefaults(dialect sqlschema.InspectorDialect) diffOption {
+	return func(cfg *detectorConfig) {
+		if n, ok := dialect.(sqlschema.DefaultNormalizer); ok {
+			cfg.normalizeDefault = n.NormalizeDefault
+		}
+	}
+}
+
+func withDetectRenamedColumns(enabled bool) diffOption {
+	return func(cfg *detectorConfig) {
+		cfg.detectRenamedColumns = enabled
+	}
+}
+
+func withConcurrentIndexes(enabled bool) diffOption {
+	return func(cfg *detectorConfig) {
+		cfg.concurrentIndexes = enabled
+	}
+}
+
 // detectorConfig controls how differences in the model states are resolved.
 type detectorConfig struct {
-	cmpType CompareTypeFunc
+	cmpType              CompareTypeFunc
+	normalizeDefault     func(string) string
+	detectRenamedColumns bool
+	concurrentIndexes    bool
 }
 
 // detector may modify the passed database schemas, so it isn't safe to re-use them.
@@ -262,24 +1636,138 @@ type detector struct {
 	changes changeset
 	refMap  refMap
 
+	// partitions of the current tables are excluded from the diff, see newDetector.
+	partitions map[string]bool
+
 	// cmpType determines column type equivalence.
 	// Default is direct comparison with '==' operator, which is inaccurate
 	// due to the existence of dialect-specific type aliases. The caller
 	// should pass a concrete InspectorDialect.EquuivalentType for robust comparison.
 	cmpType CompareTypeFunc
+
+	// normalizeDefault canonicalizes default expressions before they are compared,
+	// see sqlschema.DefaultNormalizer. Nil means defaults must match exactly.
+	normalizeDefault func(string) string
+
+	// renamedTables maps the old names of the renamed tables to the new ones.
+	renamedTables map[string]string
+
+	// concurrentIndexes creates and drops indexes without locking the tables, see WithConcurrentIndexes.
+	concurrentIndexes bool
+
+	// detectRenamedColumns enables guessing renamed columns from their definitions.
+	// Columns renamed explicitly with the "rename_from" tag option are always detected.
+	detectRenamedColumns bool
 }
 
-// canRename checks if t1 can be renamed to t2.
+// canRename checks if t1 can be renamed to t2. Besides having the same columns,
+// the tables must declare the same number of constraints to avoid renaming unrelated tables that happen to look alike.
 func (d detector) canRename(t1, t2 sqlschema.Table) bool {
-	return t1.GetSchema() == t2.GetSchema() && equalSignatures(t1, t2, d.equalColumns)
+	return t1.GetSchema() == t2.GetSchema() &&
+		equalSignatures(t1, t2, d.equalColumns) &&
+		sameConstraintCount(t1, t2)
+}
+
+// sameConstraintCount compares the number of PRIMARY KEY columns, UNIQUE and CHECK constraints in both tables.
+func sameConstraintCount(t1, t2 sqlschema.Table) bool {
+	pkLen := func(t sqlschema.Table) int {
+		if pk := t.GetPrimaryKey(); pk != nil {
+			return len(pk.Columns.Split())
+		}
+		return 0
+	}
+	return pkLen(t1) == pkLen(t2) &&
+		len(t1.GetUniqueConstraints()) == len(t2.GetUniqueConstraints()) &&
+		len(t1.GetChecks()) == len(t2.GetChecks())
 }
 
+// equalColumns checks if the column definitions require no changes. Comments are changed
+// with separate operations and are not part of the definition.
 func (d detector) equalColumns(col1, col2 sqlschema.Column) bool {
-	return d.cmpType(col1, col2) &&
-		col1.GetDefaultValue() == col2.GetDefaultValue() &&
-		col1.GetIsNullable() == col2.GetIsNullable() &&
-		col1.GetIsAutoIncrement() == col2.GetIsAutoIncrement() &&
-		col1.GetIsIdentity() == col2.GetIsIdentity()
+	return sqlschema.EqualColumns(col1, col2, d.equalOptions()...)
+}
+
+// equalColumnsExceptDefaults is like equalColumns, but the default values may differ, see ChangeColumnDefaultOp.
+func (d detector) equalColumnsExceptDefaults(col1, col2 sqlschema.Column, opts ...sqlschema.EqualOption) bool {
+	return sqlschema.EqualColumns(col1, col2, append(append(d.equalOptions(), sqlschema.IgnoreDefaults()), opts...)...)
+}
+
+func (d detector) equalOptions() []sqlschema.EqualOption {
+	return []sqlschema.EqualOption{
+		sqlschema.WithTypeComparison(d.cmpType),
+		sqlschema.WithDefaultNormalization(d.normalizeDefault),
+		sqlschema.IgnoreComments(),
+		sqlschema.IgnoreCollation(),
+	}
+}
+
+// equalDefaults checks if the default values are the same in their canonical form, see sqlschema.DefaultNormalizer,
+// so that e.g. CURRENT_TIMESTAMP and now() are not reported as a change in PostgreSQL.
+func (d detector) equalDefaults(def1, def2 string) bool {
+	if def1 == def2 {
+		return true
+	}
+	if d.normalizeDefault == nil || def1 == "" || def2 == "" {
+		return false
+	}
+	return d.normalizeDefault(def1) == d.normalizeDefault(def2)
+}
+
+// changeColumnDefault sets a new default value for column colName, whose definition is otherwise unchanged.
+func (d *detector) changeColumnDefault(tableName string, current sqlschema.Table, colName string, cCol, tCol sqlschema.Column) {
+	op := &ChangeColumnDefaultOp{
+		TableName:   tableName,
+		Column:      colName,
+		From:        cCol.GetDefaultValue(),
+		To:          tCol.GetDefaultValue(),
+		Table:       current,
+		ForeignKeys: d.foreignKeysFrom(current),
+	}
+	d.changes.Add(op)
+}
+
+// changeColumnType alters the definition of column colName, which keeps its existing values.
+// The current definition of the table is recorded for the dialects which rebuild the table to alter it.
+func (d *detector) changeColumnType(tableName string, current, target sqlschema.Table, colName string, cCol, tCol sqlschema.Column) {
+	op := &ChangeColumnTypeOp{
+		TableName:   tableName,
+		Column:      colName,
+		From:        cCol,
+		To:          d.makeTargetColDef(cCol, tCol),
+		Table:       current,
+		ForeignKeys: d.foreignKeysFrom(current),
+	}
+	if bunTable, ok := target.(*sqlschema.BunTable); ok {
+		op.Using = bunTable.UsingExprs[colName]
+	}
+	d.changes.Add(op)
+}
+
+// changeColumnNullability makes column colName nullable or NOT NULL, as defined in the target table.
+// The NULL values are replaced with the column's backfill value, if the model declares one.
+func (d *detector) changeColumnNullability(tableName string, current, target sqlschema.Table, colName string, tCol sqlschema.Column) {
+	op := &ChangeColumnNullabilityOp{
+		TableName:   tableName,
+		Column:      colName,
+		Nullable:    tCol.GetIsNullable(),
+		Table:       current,
+		ForeignKeys: d.foreignKeysFrom(current),
+	}
+	if bunTable, ok := target.(*sqlschema.BunTable); ok && !op.Nullable {
+		op.Backfill = bunTable.Backfills[colName]
+	}
+	d.changes.Add(op)
+}
+
+// foreignKeysFrom returns the foreign keys which the table defines in the current state.
+func (d *detector) foreignKeysFrom(table sqlschema.Table) map[sqlschema.ForeignKey]string {
+	fks := make(map[sqlschema.ForeignKey]string)
+	for fk, name := range d.current.GetForeignKeys() {
+		if fk.From.TableName == table.GetName() {
+			fks[fk] = name
+		}
+	}
+	return fks
 }
 
 func (d detector) makeTargetColDef(current, target sqlschema.Column) sqlschema.Column {
@@ -291,9 +1779,17 @@ func (d detector) makeTargetColDef(current, target sqlschema.Column) sqlschema.C
 			IsNullable:      target.GetIsNullable(),
 			IsAutoIncrement: target.GetIsAutoIncrement(),
 			IsIdentity:      target.GetIsIdentity(),
+			IdentityOptions: target.GetIdentityOptions(),
+			Comment:         target.GetComment(),
+			Collation:       target.GetCollation(),
+			GeneratedExpr:   target.GetGeneratedExpr(),
+			GeneratedStored: target.GetGeneratedStored(),
 
-			SQLType:    current.GetSQLType(),
-			VarcharLen: current.GetVarcharLen(),
+			SQLType:          current.GetSQLType(),
+			VarcharLen:       current.GetVarcharLen(),
+			NumericPrecision: current.GetNumericPrecision(),
+			NumericScale:     current.GetNumericScale(),
+			ArrayDims:        current.GetArrayDims(),
 		}
 	}
 	return target
@@ -301,6 +1797,67 @@ func (d detector) makeTargetColDef(current, target sqlschema.Column) sqlschema.C
 
 type CompareTypeFunc func(sqlschema.Column, sqlschema.Column) bool
 
+// TypeAlias returns a CompareTypeFunc which treats SQL types a and b as equivalent, e.g. TypeAlias("money", "numeric(19,4)").
+// Types are matched against the full type of the column, including its length or precision,
+// case-insensitively and ignoring whitespace.
+func TypeAlias(a, b string) CompareTypeFunc {
+	a, b = normalizeType(a), normalizeType(b)
+	return func(col1, col2 sqlschema.Column) bool {
+		typ1, typ2 := fullType(col1), fullType(col2)
+		return (typ1 == a && typ2 == b) || (typ1 == b && typ2 == a)
+	}
+}
+
+// DomainBaseType returns a CompareTypeFunc which resolves the domains used by the columns to their base types,
+// so that a column of domain type is equivalent to a column of the domain's base type. Pass it to
+// WithTypeEquivalence to let the models use the base type, e.g. for domains created outside of bun.
+func DomainBaseType(domains ...sqlschema.Domain) CompareTypeFunc {
+	baseTypes := make(map[string]string, len(domains))
+	for _, dom := range domains {
+		baseTypes[strings.ToLower(dom.Name)] = normalizeType(dom.DataType)
+	}
+	resolve := func(col sqlschema.Column) (string, bool) {
+		if typ, ok := baseTypes[strings.ToLower(col.GetSQLType())]; ok {
+			return typ, true
+		}
+		return fullType(col), false
+	}
+	return func(col1, col2 sqlschema.Column) bool {
+		typ1, ok1 := resolve(col1)
+		typ2, ok2 := resolve(col2)
+		return (ok1 || ok2) && typ1 == typ2
+	}
+}
+
+// anyCompareType combines several CompareTypeFuncs, so that the types are equivalent if any of them returns true.
+func anyCompareType(fns ...CompareTypeFunc) CompareTypeFunc {
+	return func(col1, col2 sqlschema.Column) bool {
+		for _, eq := range fns {
+			if eq(col1, col2) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// fullType returns the normalized SQL type of the column with its modifiers, e.g. numeric(19,4).
+func fullType(col sqlschema.Column) string {
+	typ := &sqlschema.BaseColumn{
+		SQLType:          col.GetSQLType(),
+		VarcharLen:       col.GetVarcharLen(),
+		NumericPrecision: col.GetNumericPrecision(),
+		NumericScale:     col.GetNumericScale(),
+		ArrayDims:        col.GetArrayDims(),
+	}
+	b, _ := typ.AppendQuery(schema.NewNopFormatter(), nil)
+	return normalizeType(string(b))
+}
+
+func normalizeType(typ string) string {
+	return strings.ToLower(strings.Join(strings.Fields(typ), ""))
+}
+
 // equalSignatures determines if two tables have the same "signature".
 func equalSignatures(t1, t2 sqlschema.Table, eq CompareTypeFunc) bool {
 	sig1 := newSignature(t1, eq)
@@ -407,10 +1964,11 @@ func (rm refMap) RenameColumn(tableName string, column, newName string) {
 }
 
 // Deref returns copies of ForeignKey values to a map.
+// Keys are canonicalized, because renaming a column may change the order of column pairs.
 func (rm refMap) Deref() map[sqlschema.ForeignKey]string {
 	out := make(map[sqlschema.ForeignKey]string)
 	for fk, name := range rm {
-		out[*fk] = name
+		out[fk.Canonical()] = name
 	}
 	return out
 }
@@ -0,0 +1,375 @@
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/migrate/sqlschema"
+	"github.com/uptrace/bun/schema"
+)
+
+// ExportHCL converts the schema state into the Atlas HCL schema syntax, so that the bun models
+// can serve as the source of truth for the projects which apply their migrations with Atlas.
+//
+// The document declares the schemas, enums, and tables with their columns, primary keys, foreign keys,
+// unique constraints, indexes, and checks. Column types are written as the Atlas driver for
+// the db's dialect expects them, e.g. "character varying" becomes varchar in PostgreSQL.
+// Types and default values that have no HCL counterpart are wrapped in sql("...").
+// Objects are sorted by name, so the output does not depend on the order they were inspected in.
+func ExportHCL(db *bun.DB, schemaName string, state sqlschema.Database) []byte {
+	x := hclExporter{
+		dialect:    db.Dialect().Name(),
+		schemaName: schemaName,
+		enums:      state.GetEnums(),
+	}
+
+	var doc []*hclBlock
+	schemas := append([]string{schemaName}, exportedSchemas(schemaName, state)...)
+	for _, name := range schemas {
+		doc = append(doc, &hclBlock{typ: "schema", labels: []string{name}})
+	}
+
+	for _, name := range sortedKeys(x.enums) {
+		enum := &hclBlock{typ: "enum", labels: []string{name}}
+		enum.attr("schema", "schema."+schemaName)
+		enum.attr("values", hclList(x.enums[name], hclString))
+		doc = append(doc, enum)
+	}
+
+	fks := make(map[string][]sqlschema.ForeignKey)
+	names := make(map[sqlschema.ForeignKey]string)
+	for fk, name := range state.GetForeignKeys() {
+		fks[fk.From.TableName] = append(fks[fk.From.TableName], fk)
+		names[fk] = name
+	}
+	indexes := make(map[string][]sqlschema.Index)
+	for _, idx := range state.GetIndexes() {
+		indexes[idx.TableName] = append(indexes[idx.TableName], idx)
+	}
+
+	keys := state.GetTables().Keys()
+	slices.Sort(keys)
+	for _, key := range keys {
+		table := state.GetTables().Value(key)
+		tableFKs := fks[key]
+		slices.SortFunc(tableFKs, func(a, b sqlschema.ForeignKey) int {
+			return strings.Compare(names[a], names[b])
+		})
+		tableIndexes := indexes[key]
+		slices.SortFunc(tableIndexes, func(a, b sqlschema.Index) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+
+		block := x.table(key, table)
+		for _, fk := range tableFKs {
+			block.blocks = append(block.blocks, x.foreignKey(names[fk], fk))
+		}
+		for _, u := range table.GetUniqueConstraints() {
+			block.blocks = append(block.blocks, x.unique(table, u))
+		}
+		for _, idx := range tableIndexes {
+			block.blocks = append(block.blocks, x.index(idx))
+		}
+		for _, check := range table.GetChecks() {
+			c := block.block("check")
+			if check.Name != "" {
+				c.labels = []string{check.Name}
+			}
+			c.attr("expr", hclString(check.Expression))
+		}
+		doc = append(doc, block)
+	}
+
+	var buf bytes.Buffer
+	for i, block := range doc {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		block.write(&buf, 0)
+	}
+	return buf.Bytes()
+}
+
+type hclExporter struct {
+	dialect    dialect.Name
+	schemaName string
+	enums      map[string][]string
+}
+
+func (x hclExporter) table(key string, table sqlschema.Table) *hclBlock {
+	schemaName := table.GetSchema()
+	if schemaName == "" {
+		schemaName = x.schemaName
+	}
+	block := &hclBlock{typ: "table", labels: x.tableLabels(key)}
+	block.attr("schema", "schema."+schemaName)
+	if comment := table.GetComment(); comment != "" {
+		block.attr("comment", hclString(comment))
+	}
+
+	for _, col := range table.GetColumns().Values() {
+		c := block.block("column", col.GetName())
+		c.attr("null", strconv.FormatBool(col.GetIsNullable()))
+		c.attr("type", x.columnType(col))
+		if def := col.GetDefaultValue(); def != "" {
+			c.attr("default", hclValue(def))
+		}
+		if col.GetIsAutoIncrement() && x.dialect != dialect.PG {
+			c.attr("auto_increment", "true")
+		}
+		if collation := col.GetCollation(); collation != "" {
+			c.attr("collate", hclString(collation))
+		}
+		if comment := col.GetComment(); comment != "" {
+			c.attr("comment", hclString(comment))
+		}
+		if col.GetIsIdentity() {
+			opts := col.GetIdentityOptions()
+			identity := c.block("identity")
+			identity.attr("generated", strings.ReplaceAll(opts.GetGeneration(), " ", "_"))
+			if opts.Start != 0 {
+				identity.attr("start", strconv.FormatInt(opts.Start, 10))
+			}
+			if opts.Increment != 0 {
+				identity.attr("increment", strconv.FormatInt(opts.Increment, 10))
+			}
+		}
+		if expr := col.GetGeneratedExpr(); expr != "" {
+			as := c.block("as")
+			as.attr("expr", hclString(expr))
+			if col.GetGeneratedStored() {
+				as.attr("type", "STORED")
+			} else {
+				as.attr("type", "VIRTUAL")
+			}
+		}
+	}
+
+	if pk := table.GetPrimaryKey(); pk != nil {
+		block.block("primary_key").attr("columns", hclList(pk.Columns.Split(), hclColumn))
+	}
+	return block
+}
+
+func (x hclExporter) foreignKey(name string, fk sqlschema.ForeignKey) *hclBlock {
+	block := &hclBlock{typ: "foreign_key", labels: []string{name}}
+	block.attr("columns", hclList(fk.From.Column.Split(), hclColumn))
+	ref := "table." + strings.Join(x.tableLabels(fk.To.TableName), ".") + "."
+	block.attr("ref_columns", hclList(fk.To.Column.Split(), func(col string) string {
+		return ref + hclColumn(col)
+	}))
+	block.attr("on_update", hclAction(fk.OnUpdate))
+	block.attr("on_delete", hclAction(fk.OnDelete))
+	return block
+}
+
+func (x hclExporter) unique(table sqlschema.Table, u sqlschema.Unique) *hclBlock {
+	name := u.Name
+	if name == "" {
+		name = table.GetName() + "_" + strings.Join(u.Columns.Split(), "_") + "_key"
+	}
+	block := &hclBlock{typ: "index", labels: []string{name}}
+	block.attr("unique", "true")
+	block.attr("columns", hclList(u.Columns.Split(), hclColumn))
+	return block
+}
+
+func (x hclExporter) index(idx sqlschema.Index) *hclBlock {
+	block := &hclBlock{typ: "index", labels: []string{idx.Name}}
+	if idx.Unique {
+		block.attr("unique", "true")
+	}
+	var partBlocks bool
+	var columns []string
+	for _, col := range idx.Columns {
+		partBlocks = partBlocks || col.Expression != "" || col.OpClass != ""
+		columns = append(columns, col.Name)
+	}
+	if !partBlocks {
+		block.attr("columns", hclList(columns, hclColumn))
+	}
+	if idx.Method != "" && !strings.EqualFold(idx.Method, "btree") {
+		block.attr("type", strings.ToUpper(idx.Method))
+	}
+	if idx.Where != "" {
+		block.attr("where", hclString(idx.Where))
+	}
+	if len(idx.Include) > 0 {
+		block.attr("include", hclList(idx.Include, hclColumn))
+	}
+	for _, param := range idx.NormalizedStorageParameters() {
+		// Atlas only knows the number of pages summarized by BRIN ranges, under a slightly different name.
+		if value, ok := strings.CutPrefix(param, "pages_per_range="); ok {
+			block.attr("page_per_range", value)
+		}
+	}
+	if partBlocks {
+		// Atlas declares expression key parts and operator classes in separate blocks, which must then describe all key parts.
+		for _, col := range idx.Columns {
+			on := block.block("on")
+			if col.Expression != "" {
+				on.attr("expr", hclString(col.Expression))
+			} else {
+				on.attr("column", hclColumn(col.Name))
+			}
+			if col.OpClass != "" {
+				on.attr("ops", col.NormalizedOpClass())
+			}
+		}
+	}
+	return block
+}
+
+// tableLabels returns the labels of the table block. Tables outside of the default schema
+// are qualified with the schema name, e.g. table "audit" "events", to tell them apart.
+func (x hclExporter) tableLabels(key string) []string {
+	schemaName, tableName := sqlschema.SplitTableKey(key)
+	if schemaName != "" && schemaName != x.schemaName {
+		return []string{schemaName, tableName}
+	}
+	return []string{tableName}
+}
+
+var hclTypeRe = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\(\d+(,\d+)?\))?$`)
+
+// columnType returns the type of the column in the notation of the Atlas driver, e.g. varchar(100) or enum.mood.
+func (x hclExporter) columnType(col sqlschema.Column) string {
+	if _, ok := x.enums[col.GetSQLType()]; ok && col.GetArrayDims() == 0 {
+		return "enum." + col.GetSQLType()
+	}
+
+	sqlType := strings.ToLower(sqlschema.NormalizeType(col.GetSQLType()))
+	if x.dialect == dialect.PG {
+		if alias, ok := pgHCLTypes[sqlType]; ok {
+			sqlType = alias
+		}
+		if serial, ok := pgSerialTypes[sqlType]; ok && col.GetIsAutoIncrement() {
+			sqlType = serial
+		}
+	}
+	typ := &sqlschema.BaseColumn{
+		SQLType:          sqlType,
+		VarcharLen:       col.GetVarcharLen(),
+		NumericPrecision: col.GetNumericPrecision(),
+		NumericScale:     col.GetNumericScale(),
+		ArrayDims:        col.GetArrayDims(),
+	}
+	b, _ := typ.AppendQuery(schema.NewNopFormatter(), nil)
+	if s := string(b); hclTypeRe.MatchString(s) {
+		return s
+	}
+	return fmt.Sprintf("sql(%s)", hclString(string(b)))
+}
+
+// pgHCLTypes maps the type names reported by PostgreSQL to the names used by the Atlas driver.
+var pgHCLTypes = map[string]string{
+	"character varying":           "varchar",
+	"character":                   "char",
+	"bit varying":                 "varbit",
+	"double precision":            "double_precision",
+	"timestamp with time zone":    "timestamptz",
+	"timestamp without time zone": "timestamp",
+	"time with time zone":         "timetz",
+	"time without time zone":      "time",
+	"int":                         "integer",
+	"int2":                        "smallint",
+	"int4":                        "integer",
+	"int8":                        "bigint",
+	"bool":                        "boolean",
+	"float4":                      "real",
+	"float8":                      "double_precision",
+}
+
+var pgSerialTypes = map[string]string{
+	"smallint": "smallserial",
+	"integer":  "serial",
+	"bigint":   "bigserial",
+}
+
+// hclValue returns the default value as an HCL number, boolean, or string if it is one and as an SQL expression otherwise.
+func hclValue(def string) string {
+	if _, err := strconv.ParseFloat(def, 64); err == nil {
+		return def
+	}
+	switch lower := strings.ToLower(def); {
+	case lower == "true" || lower == "false":
+		return lower
+	case sqlschema.IsDefaultExpr(def):
+		return fmt.Sprintf("sql(%s)", hclString(def))
+	}
+	return hclString(defaultLiteral(def))
+}
+
+// defaultLiteral unescapes the quotes in the string literal, which inspectors keep doubled, e.g. Bob”s.
+func defaultLiteral(def string) string {
+	return strings.ReplaceAll(def, "''", "'")
+}
+
+func hclAction(a sqlschema.ReferentialAction) string {
+	return strings.ReplaceAll(a.String(), " ", "_")
+}
+
+func hclColumn(name string) string {
+	return "column." + name
+}
+
+// hclString quotes the string, escaping the template sequences which HCL would otherwise interpolate.
+func hclString(s string) string {
+	s = strconv.Quote(s)
+	s = strings.ReplaceAll(s, "${", "$${")
+	return strings.ReplaceAll(s, "%{", "%%{")
+}
+
+func hclList(items []string, format func(string) string) string {
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = format(item)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// hclBlock is a block of the HCL document, e.g. table "users" { ... }, with its attributes and nested blocks.
+type hclBlock struct {
+	typ    string
+	labels []string
+	attrs  [][2]string
+	blocks []*hclBlock
+}
+
+func (b *hclBlock) attr(name, value string) {
+	b.attrs = append(b.attrs, [2]string{name, value})
+}
+
+func (b *hclBlock) block(typ string, labels ...string) *hclBlock {
+	child := &hclBlock{typ: typ, labels: labels}
+	b.blocks = append(b.blocks, child)
+	return child
+}
+
+// write formats the block the way "atlas schema fmt" does, aligning the equals signs of the attributes.
+func (b *hclBlock) write(buf *bytes.Buffer, depth int) {
+	indent := strings.Repeat("  ", depth)
+	buf.WriteString(indent + b.typ)
+	for _, label := range b.labels {
+		buf.WriteString(" " + hclString(label))
+	}
+	buf.WriteString(" {\n")
+
+	var width int
+	for _, attr := range b.attrs {
+		width = max(width, len(attr[0]))
+	}
+	for _, attr := range b.attrs {
+		fmt.Fprintf(buf, "%s  %-*s = %s\n", indent, width, attr[0], attr[1])
+	}
+	for _, child := range b.blocks {
+		child.write(buf, depth+1)
+	}
+	buf.WriteString(indent + "}\n")
+}
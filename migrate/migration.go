@@ -23,6 +23,9 @@ type Migration struct {
 	GroupID    int64
 	MigratedAt time.Time `bun:",notnull,nullzero,default:current_timestamp"`
 
+	// Replaces lists the names of the migrations squashed into this one, see Migrator.Squash.
+	Replaces []string `bun:"-"`
+
 	Up   MigrationFunc `bun:"-"`
 	Down MigrationFunc `bun:"-"`
 }
@@ -66,6 +69,9 @@ func Exec(ctx context.Context, db *bun.DB, f io.Reader, isTx bool) error {
 				query = query[:0]
 				continue
 			}
+			if bytes.HasPrefix(b, []byte(replacesDirective)) {
+				continue // read by Migrations.Discover
+			}
 			return fmt.Errorf("bun: unknown directive: %q", b)
 		}
 
@@ -101,7 +101,8 @@ func (m *Migrations) Discover(fsys fs.FS) error {
 
 		if strings.HasSuffix(path, ".up.sql") {
 			migration.Up = migrationFunc
-			return nil
+			migration.Replaces, err = readReplaces(fsys, path)
+			return err
 		}
 		if strings.HasSuffix(path, ".down.sql") {
 			migration.Down = migrationFunc
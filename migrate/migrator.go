@@ -78,12 +78,12 @@ func (m *Migrator) MigrationsWithStatus(ctx context.Context) (MigrationSlice, er
 	return sorted, err
 }
 
-func (m *Migrator) migrationsWithStatus(ctx context.Context) (MigrationSlice, int64, error) {
+func (m *Migrator) migrationsWithStatus(ctx context.Context) (MigrationSlice, MigrationSlice, error) {
 	sorted := m.migrations.Sorted()
 
 	applied, err := m.AppliedMigrations(ctx)
 	if err != nil {
-		return nil, 0, err
+		return nil, nil, err
 	}
 
 	appliedMap := migrationMap(applied)
@@ -96,7 +96,7 @@ func (m *Migrator) migrationsWithStatus(ctx context.Context) (MigrationSlice, in
 		}
 	}
 
-	return sorted, applied.LastGroupID(), nil
+	return sorted, applied, nil
 }
 
 func (m *Migrator) Init(ctx context.Context) error {
@@ -136,6 +136,7 @@ func (m *Migrator) Reset(ctx context.Context) error {
 }
 
 // Migrate runs unapplied migrations. If a migration fails, migrate immediately exits.
+// Migrations replaced by a squashed baseline are not run again, see Squash.
 func (m *Migrator) Migrate(ctx context.Context, opts ...MigrationOption) (*MigrationGroup, error) {
 	cfg := newMigrationConfig(opts)
 
@@ -143,17 +144,20 @@ func (m *Migrator) Migrate(ctx context.Context, opts ...MigrationOption) (*Migra
 		return nil, err
 	}
 
-	migrations, lastGroupID, err := m.migrationsWithStatus(ctx)
+	migrations, applied, err := m.migrationsWithStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err = pendingMigrations(migrations, applied)
 	if err != nil {
 		return nil, err
 	}
-	migrations = migrations.Unapplied()
 
 	group := new(MigrationGroup)
 	if len(migrations) == 0 {
 		return group, nil
 	}
-	group.ID = lastGroupID + 1
+	group.ID = applied.LastGroupID() + 1
 
 	for i := range migrations {
 		migration := &migrations[i]
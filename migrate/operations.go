@@ -2,6 +2,8 @@ package migrate
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/uptrace/bun/migrate/sqlschema"
 )
@@ -27,12 +29,20 @@ type Operation interface {
 
 // CreateTableOp creates a new table in the schema.
 //
-// It does not report dependency on any other migration and may be executed first.
+// It only depends on the enum types being created or altered in the same changeset,
+// because its columns may use them, and may otherwise be executed first.
 // Make sure the dialect does not include FOREIGN KEY constraints in the CREATE TABLE
 // statement, as those may potentially reference not-yet-existing columns/tables.
+//
+// Tables which are not derived from a bun model are created from their Table definition.
 type CreateTableOp struct {
 	TableName string
 	Model     interface{}
+	Table     sqlschema.Table
+
+	// IfNotExists skips creating the table if one with the same name exists already, see WithIfNotExists.
+	// Diff never sets it, as a table that exists with a different definition would go unnoticed.
+	IfNotExists bool
 }
 
 var _ Operation = (*CreateTableOp)(nil)
@@ -41,21 +51,54 @@ func (op *CreateTableOp) GetReverse() Operation {
 	return &DropTableOp{TableName: op.TableName}
 }
 
-// DropTableOp drops a database table. This operation is not reversible.
+func (op *CreateTableOp) DependsOn(another Operation) bool {
+	switch another := another.(type) {
+	case *CreateEnumOp, *AddEnumValueOp, *CreateDomainOp, *CreateCompositeTypeOp:
+		return true
+	case *CreateSequenceOp:
+		return another.Sequence.OwnedBy.TableName != op.TableName
+	case *CreateTableOp:
+		return op.Table != nil && slices.Contains(op.Table.GetInherits(), another.TableName)
+	}
+	return false
+}
+
+// DropTableOp drops a database table. The data in the table is lost, but the table itself
+// can be re-created on revert if its definition is known.
 type DropTableOp struct {
 	TableName string
+
+	// Table is the definition of the dropped table, if available.
+	Table sqlschema.Table
 }
 
 var _ Operation = (*DropTableOp)(nil)
 
 func (op *DropTableOp) DependsOn(another Operation) bool {
-	drop, ok := another.(*DropForeignKeyOp)
-	return ok && drop.ForeignKey.DependsOnTable(op.TableName)
+	switch drop := another.(type) {
+	case *DropForeignKeyOp:
+		return drop.ForeignKey.DependsOnTable(op.TableName)
+	case *DropViewOp:
+		return true
+	case *DropTriggerOp:
+		return drop.Trigger.TableName == op.TableName
+	case *DropPolicyOp:
+		return drop.Policy.TableName == op.TableName
+	case *RevokeOp:
+		return drop.Grant.TableName == op.TableName
+	case *DropTableOp:
+		// Parent tables cannot be dropped while other tables inherit from them.
+		return drop.Table != nil && slices.Contains(drop.Table.GetInherits(), op.TableName)
+	}
+	return false
 }
 
-// GetReverse for a DropTable returns a no-op migration. Logically, CreateTable is the reverse,
-// but DropTable does not have the table's definition to create one.
+// GetReverse for a DropTable re-creates the table from its definition. If the definition is not available,
+// it returns a no-op migration with an explanatory note instead.
 func (op *DropTableOp) GetReverse() Operation {
+	if op.Table != nil {
+		return &CreateTableOp{TableName: op.TableName, Table: op.Table}
+	}
 	c := comment(fmt.Sprintf("WARNING: \"DROP TABLE %s\" cannot be reversed automatically because table definition is not available", op.TableName))
 	return &c
 }
@@ -75,6 +118,18 @@ func (op *RenameTableOp) GetReverse() Operation {
 	}
 }
 
+func (op *RenameTableOp) DependsOn(another Operation) bool {
+	switch drop := another.(type) {
+	case *DropTriggerOp:
+		return drop.Trigger.TableName == op.TableName
+	case *DropPolicyOp:
+		return drop.Policy.TableName == op.TableName
+	case *RevokeOp:
+		return drop.Grant.TableName == op.TableName
+	}
+	return false
+}
+
 // RenameColumnOp renames a column in the table. If the changeset includes a rename operation
 // for the column's table, it should be executed first.
 type RenameColumnOp struct {
@@ -94,8 +149,13 @@ func (op *RenameColumnOp) GetReverse() Operation {
 }
 
 func (op *RenameColumnOp) DependsOn(another Operation) bool {
-	rename, ok := another.(*RenameTableOp)
-	return ok && op.TableName == rename.NewName
+	switch another := another.(type) {
+	case *RenameTableOp:
+		return op.TableName == another.NewName
+	case *RevokeOp:
+		return op.TableName == another.Grant.TableName && op.OldName == another.Grant.Column
+	}
+	return false
 }
 
 // AddColumnOp adds a new column to the table.
@@ -103,6 +163,17 @@ type AddColumnOp struct {
 	TableName  string
 	ColumnName string
 	Column     sqlschema.Column
+
+	// Backfill is the value assigned to existing rows when a NOT NULL column without a default is added.
+	Backfill string
+}
+
+// RequiresBackfill reports whether existing rows need a value for the new column,
+// i.e. the column is NOT NULL and its value is neither defaulted nor generated.
+func (op *AddColumnOp) RequiresBackfill() bool {
+	col := op.Column
+	return !col.GetIsNullable() && col.GetDefaultValue() == "" && col.GetGeneratedExpr() == "" &&
+		!col.GetIsIdentity() && !col.GetIsAutoIncrement()
 }
 
 var _ Operation = (*AddColumnOp)(nil)
@@ -115,12 +186,22 @@ func (op *AddColumnOp) GetReverse() Operation {
 	}
 }
 
+func (op *AddColumnOp) DependsOn(another Operation) bool {
+	switch another := another.(type) {
+	case *RenameTableOp:
+		return op.TableName == another.NewName
+	case *CreateSequenceOp:
+		return another.Sequence.OwnedBy != sqlschema.NewColumnReference(op.TableName, op.ColumnName)
+	}
+	return dependsOnType(op.Column, another)
+}
+
 // DropColumnOp drop a column from the table.
 //
 // While some dialects allow DROP CASCADE to drop dependent constraints,
 // explicit handling on constraints is preferred for transparency and debugging.
-// DropColumnOp depends on DropForeignKeyOp, DropPrimaryKeyOp, and ChangePrimaryKeyOp
-// if any of the constraints is defined on this table.
+// DropColumnOp depends on DropForeignKeyOp, DropPrimaryKeyOp, ChangePrimaryKeyOp, DropCheckConstraintOp,
+// and DropExclusionConstraintOp if any of the constraints is defined on this table.
 type DropColumnOp struct {
 	TableName  string
 	ColumnName string
@@ -145,6 +226,20 @@ func (op *DropColumnOp) DependsOn(another Operation) bool {
 		return op.TableName == drop.TableName && drop.PrimaryKey.Columns.Contains(op.ColumnName)
 	case *ChangePrimaryKeyOp:
 		return op.TableName == drop.TableName && drop.Old.Columns.Contains(op.ColumnName)
+	case *DropCheckConstraintOp:
+		return op.TableName == drop.TableName
+	case *DropExclusionConstraintOp:
+		return op.TableName == drop.TableName
+	case *DropIndexOp:
+		return op.TableName == drop.Index.TableName
+	case *RenameTableOp:
+		return op.TableName == drop.NewName
+	case *DropViewOp:
+		return true
+	case *DropPolicyOp:
+		return op.TableName == drop.Policy.TableName
+	case *RevokeOp:
+		return op.TableName == drop.Grant.TableName && op.ColumnName == drop.Grant.Column
 	}
 	return false
 }
@@ -153,6 +248,9 @@ func (op *DropColumnOp) DependsOn(another Operation) bool {
 type AddForeignKeyOp struct {
 	ForeignKey     sqlschema.ForeignKey
 	ConstraintName string
+
+	// IfNotExists skips the constraint if the table has one with the same name.
+	IfNotExists bool
 }
 
 var _ Operation = (*AddForeignKeyOp)(nil)
@@ -167,6 +265,15 @@ func (op *AddForeignKeyOp) DependsOn(another Operation) bool {
 		return op.ForeignKey.DependsOnTable(another.TableName) || op.ForeignKey.DependsOnTable(another.NewName)
 	case *CreateTableOp:
 		return op.ForeignKey.DependsOnTable(another.TableName)
+	case *DropForeignKeyOp:
+		// A constraint with new referential actions replaces the old one, which may have the same name.
+		return op.ForeignKey.From == another.ForeignKey.From
+	case *AddPrimaryKeyOp:
+		return op.ForeignKey.To.TableName == another.TableName
+	case *ChangePrimaryKeyOp:
+		return op.ForeignKey.To.TableName == another.TableName
+	case *AddUniqueConstraintOp:
+		return op.ForeignKey.To.TableName == another.TableName
 	}
 	return false
 }
@@ -249,21 +356,292 @@ func (op *DropUniqueConstraintOp) GetReverse() Operation {
 	}
 }
 
+// AddCheckConstraintOp adds a new CHECK constraint to the table.
+type AddCheckConstraintOp struct {
+	TableName string
+	Check     sqlschema.Check
+}
+
+var _ Operation = (*AddCheckConstraintOp)(nil)
+
+func (op *AddCheckConstraintOp) GetReverse() Operation {
+	return &DropCheckConstraintOp{
+		TableName: op.TableName,
+		Check:     op.Check,
+	}
+}
+
+// DependsOn reports dependency on any new column in the table,
+// because the columns referenced in the check expression are not known.
+func (op *AddCheckConstraintOp) DependsOn(another Operation) bool {
+	switch another := another.(type) {
+	case *AddColumnOp:
+		return op.TableName == another.TableName
+	case *RenameTableOp:
+		return op.TableName == another.NewName
+	case *DropCheckConstraintOp:
+		return op.TableName == another.TableName && op.Check.Name != "" && op.Check.Name == another.Check.Name
+	}
+	return false
+}
+
+// DropCheckConstraintOp drops a CHECK constraint.
+type DropCheckConstraintOp struct {
+	TableName string
+	Check     sqlschema.Check
+}
+
+var _ Operation = (*DropCheckConstraintOp)(nil)
+
+func (op *DropCheckConstraintOp) DependsOn(another Operation) bool {
+	if rename, ok := another.(*RenameTableOp); ok {
+		return op.TableName == rename.NewName
+	}
+	return false
+}
+
+func (op *DropCheckConstraintOp) GetReverse() Operation {
+	return &AddCheckConstraintOp{
+		TableName: op.TableName,
+		Check:     op.Check,
+	}
+}
+
+// AddExclusionConstraintOp adds a new EXCLUDE constraint to the table.
+// Exclusion constraints are not part of CREATE TABLE statements, so they are added to new tables too.
+type AddExclusionConstraintOp struct {
+	TableName string
+	Exclusion sqlschema.ExclusionConstraint
+}
+
+var _ Operation = (*AddExclusionConstraintOp)(nil)
+
+func (op *AddExclusionConstraintOp) GetReverse() Operation {
+	return &DropExclusionConstraintOp{
+		TableName: op.TableName,
+		Exclusion: op.Exclusion,
+	}
+}
+
+// DependsOn reports dependency on the table and on any new column in it, like AddCheckConstraintOp does.
+func (op *AddExclusionConstraintOp) DependsOn(another Operation) bool {
+	switch another := another.(type) {
+	case *CreateTableOp:
+		return op.TableName == another.TableName
+	case *AddColumnOp:
+		return op.TableName == another.TableName
+	case *RenameTableOp:
+		return op.TableName == another.NewName
+	case *DropExclusionConstraintOp:
+		return op.TableName == another.TableName && op.Exclusion.Name != "" && op.Exclusion.Name == another.Exclusion.Name
+	}
+	return false
+}
+
+// DropExclusionConstraintOp drops an EXCLUDE constraint.
+type DropExclusionConstraintOp struct {
+	TableName string
+	Exclusion sqlschema.ExclusionConstraint
+}
+
+var _ Operation = (*DropExclusionConstraintOp)(nil)
+
+func (op *DropExclusionConstraintOp) DependsOn(another Operation) bool {
+	if rename, ok := another.(*RenameTableOp); ok {
+		return op.TableName == rename.NewName
+	}
+	return false
+}
+
+func (op *DropExclusionConstraintOp) GetReverse() Operation {
+	return &AddExclusionConstraintOp{
+		TableName: op.TableName,
+		Exclusion: op.Exclusion,
+	}
+}
+
 // ChangeColumnTypeOp set a new data type for the column.
-// The two types should be such that the data can be auto-casted from one to another.
-// E.g. reducing VARCHAR lenght is not possible in most dialects.
-// AutoMigrator does not enforce or validate these rules.
+// Existing values are converted with the Using expression, or with a cast the dialect derives from the types.
+// Conversions that may lose data are reported as destructive, see sqlschema.IsLossyConversion.
 type ChangeColumnTypeOp struct {
 	TableName string
 	Column    string
 	From      sqlschema.Column
 	To        sqlschema.Column
+
+	// Using converts the existing values to the new type, e.g. "amount::integer",
+	// as declared with the "using" tag option.
+	Using string
+
+	// Table and ForeignKeys hold the current definition of the table for dialects
+	// which cannot alter columns in place and rebuild the table instead.
+	// Indexes and Triggers are created again on the rebuilt table, and ReferencedBy
+	// holds the foreign keys of other tables which reference it.
+	Table        sqlschema.Table
+	ForeignKeys  map[sqlschema.ForeignKey]string
+	Indexes      []sqlschema.Index
+	Triggers     []sqlschema.Trigger
+	ReferencedBy map[sqlschema.ForeignKey]string
 }
 
 var _ Operation = (*ChangeColumnTypeOp)(nil)
 
+// GetReverse does not keep the Using expression, which only converts the values in one direction.
 func (op *ChangeColumnTypeOp) GetReverse() Operation {
 	return &ChangeColumnTypeOp{
+		TableName:    op.TableName,
+		Column:       op.Column,
+		From:         op.To,
+		To:           op.From,
+		Table:        op.Table,
+		ForeignKeys:  op.ForeignKeys,
+		Indexes:      op.Indexes,
+		Triggers:     op.Triggers,
+		ReferencedBy: op.ReferencedBy,
+	}
+}
+
+func (op *ChangeColumnTypeOp) DependsOn(another Operation) bool {
+	switch rename := another.(type) {
+	case *RenameTableOp:
+		return op.TableName == rename.NewName
+	case *RenameColumnOp:
+		return op.TableName == rename.TableName && op.Column == rename.NewName
+	case *DropViewOp:
+		return true
+	case *DropIndexOp, *DropTriggerOp:
+		// Indexes and triggers are created again if the table is rebuilt, so the dropped ones must be gone by then.
+		return true
+	case *DropPrimaryKeyOp:
+		// Primary key columns cannot be made nullable.
+		return op.TableName == rename.TableName && op.To.GetIsNullable() && rename.PrimaryKey.Columns.Contains(op.Column)
+	case *ChangePrimaryKeyOp:
+		return op.TableName == rename.TableName && op.To.GetIsNullable() && rename.Old.Columns.Contains(op.Column)
+	}
+	return dependsOnType(op.To, another)
+}
+
+// ChangeColumnDefaultOp sets a new default value for the column. An empty default removes it.
+//
+// Only the default is changed, so this operation is used when the rest of the column definition is the same;
+// otherwise ChangeColumnTypeOp sets the new default along with the other changes.
+type ChangeColumnDefaultOp struct {
+	TableName string
+	Column    string
+	From      string
+	To        string
+
+	// Table and ForeignKeys hold the current definition of the table for dialects
+	// which cannot alter columns in place and rebuild the table instead.
+	// Indexes and Triggers are created again on the rebuilt table, and ReferencedBy
+	// holds the foreign keys of other tables which reference it.
+	Table        sqlschema.Table
+	ForeignKeys  map[sqlschema.ForeignKey]string
+	Indexes      []sqlschema.Index
+	Triggers     []sqlschema.Trigger
+	ReferencedBy map[sqlschema.ForeignKey]string
+}
+
+var _ Operation = (*ChangeColumnDefaultOp)(nil)
+
+func (op *ChangeColumnDefaultOp) GetReverse() Operation {
+	return &ChangeColumnDefaultOp{
+		TableName:    op.TableName,
+		Column:       op.Column,
+		From:         op.To,
+		To:           op.From,
+		Table:        op.Table,
+		ForeignKeys:  op.ForeignKeys,
+		Indexes:      op.Indexes,
+		Triggers:     op.Triggers,
+		ReferencedBy: op.ReferencedBy,
+	}
+}
+
+func (op *ChangeColumnDefaultOp) DependsOn(another Operation) bool {
+	switch another := another.(type) {
+	case *RenameTableOp:
+		return op.TableName == another.NewName
+	case *RenameColumnOp:
+		return op.TableName == another.TableName && op.Column == another.NewName
+	case *DropIndexOp, *DropTriggerOp:
+		return true
+	}
+	return false
+}
+
+// ChangeColumnNullabilityOp makes the column nullable or NOT NULL. Like ChangeColumnDefaultOp,
+// it is used when the rest of the column definition is the same.
+//
+// Existing rows which are NULL prevent the column from being made NOT NULL. They are updated
+// with the Backfill value first, or the database rejects the change if no value is set.
+type ChangeColumnNullabilityOp struct {
+	TableName string
+	Column    string
+	Nullable  bool
+
+	// Backfill is the value assigned to the NULL values when the column is made NOT NULL.
+	Backfill string
+
+	// Table and ForeignKeys hold the current definition of the table for dialects
+	// which cannot alter columns in place and rebuild the table instead.
+	// Indexes and Triggers are created again on the rebuilt table, and ReferencedBy
+	// holds the foreign keys of other tables which reference it.
+	Table        sqlschema.Table
+	ForeignKeys  map[sqlschema.ForeignKey]string
+	Indexes      []sqlschema.Index
+	Triggers     []sqlschema.Trigger
+	ReferencedBy map[sqlschema.ForeignKey]string
+}
+
+var _ Operation = (*ChangeColumnNullabilityOp)(nil)
+
+// GetReverse does not keep the Backfill value, as the column is made nullable again.
+func (op *ChangeColumnNullabilityOp) GetReverse() Operation {
+	return &ChangeColumnNullabilityOp{
+		TableName:    op.TableName,
+		Column:       op.Column,
+		Nullable:     !op.Nullable,
+		Table:        op.Table,
+		ForeignKeys:  op.ForeignKeys,
+		Indexes:      op.Indexes,
+		Triggers:     op.Triggers,
+		ReferencedBy: op.ReferencedBy,
+	}
+}
+
+func (op *ChangeColumnNullabilityOp) DependsOn(another Operation) bool {
+	switch another := another.(type) {
+	case *RenameTableOp:
+		return op.TableName == another.NewName
+	case *RenameColumnOp:
+		return op.TableName == another.TableName && op.Column == another.NewName
+	case *DropIndexOp, *DropTriggerOp:
+		return true
+	case *DropPrimaryKeyOp:
+		// Primary key columns cannot be made nullable.
+		return op.TableName == another.TableName && op.Nullable && another.PrimaryKey.Columns.Contains(op.Column)
+	case *ChangePrimaryKeyOp:
+		return op.TableName == another.TableName && op.Nullable && another.Old.Columns.Contains(op.Column)
+	}
+	return false
+}
+
+// ChangeColumnCommentOp sets a new comment on the column. An empty comment removes it.
+//
+// Comments are not part of the column definition in most dialects and are applied with
+// a separate statement, so this change is detected independently of ChangeColumnTypeOp.
+type ChangeColumnCommentOp struct {
+	TableName string
+	Column    string
+	From      string
+	To        string
+}
+
+var _ Operation = (*ChangeColumnCommentOp)(nil)
+
+func (op *ChangeColumnCommentOp) GetReverse() Operation {
+	return &ChangeColumnCommentOp{
 		TableName: op.TableName,
 		Column:    op.Column,
 		From:      op.To,
@@ -271,7 +649,130 @@ func (op *ChangeColumnTypeOp) GetReverse() Operation {
 	}
 }
 
+func (op *ChangeColumnCommentOp) DependsOn(another Operation) bool {
+	switch another := another.(type) {
+	case *CreateTableOp:
+		return op.TableName == another.TableName
+	case *AddColumnOp:
+		return op.TableName == another.TableName && op.Column == another.ColumnName
+	case *RenameTableOp:
+		return op.TableName == another.NewName
+	case *RenameColumnOp:
+		return op.TableName == another.TableName && op.Column == another.NewName
+	}
+	return false
+}
+
+// ChangeColumnStorageOp sets the storage mode of the column, e.g. EXTERNAL to store large values
+// out of line without compressing them. An empty storage mode stands for the type's default.
+type ChangeColumnStorageOp struct {
+	TableName string
+	Column    string
+	From      string
+	To        string
+}
+
+var _ Operation = (*ChangeColumnStorageOp)(nil)
+
+func (op *ChangeColumnStorageOp) GetReverse() Operation {
+	return &ChangeColumnStorageOp{
+		TableName: op.TableName,
+		Column:    op.Column,
+		From:      op.To,
+		To:        op.From,
+	}
+}
+
+func (op *ChangeColumnStorageOp) DependsOn(another Operation) bool {
+	switch another := another.(type) {
+	case *CreateTableOp:
+		return op.TableName == another.TableName
+	case *AddColumnOp:
+		return op.TableName == another.TableName && op.Column == another.ColumnName
+	case *ChangeColumnTypeOp:
+		// Changing the type resets the storage mode to the default of the new type.
+		return op.TableName == another.TableName && op.Column == another.Column
+	case *RenameTableOp:
+		return op.TableName == another.NewName
+	case *RenameColumnOp:
+		return op.TableName == another.TableName && op.Column == another.NewName
+	}
+	return false
+}
+
+// ChangeTableCommentOp sets a new comment on the table. An empty comment removes it.
+type ChangeTableCommentOp struct {
+	TableName string
+	From      string
+	To        string
+}
+
+var _ Operation = (*ChangeTableCommentOp)(nil)
+
+func (op *ChangeTableCommentOp) GetReverse() Operation {
+	return &ChangeTableCommentOp{
+		TableName: op.TableName,
+		From:      op.To,
+		To:        op.From,
+	}
+}
+
+func (op *ChangeTableCommentOp) DependsOn(another Operation) bool {
+	switch another := another.(type) {
+	case *CreateTableOp:
+		return op.TableName == another.TableName
+	case *RenameTableOp:
+		return op.TableName == another.NewName
+	}
+	return false
+}
+
+// ChangeTablespaceOp moves the table to another tablespace. An empty tablespace stands for the database's default one.
+type ChangeTablespaceOp struct {
+	TableName string
+	From      string
+	To        string
+}
+
+var _ Operation = (*ChangeTablespaceOp)(nil)
+
+func (op *ChangeTablespaceOp) GetReverse() Operation {
+	return &ChangeTablespaceOp{
+		TableName: op.TableName,
+		From:      op.To,
+		To:        op.From,
+	}
+}
+
+func (op *ChangeTablespaceOp) DependsOn(another Operation) bool {
+	rename, ok := another.(*RenameTableOp)
+	return ok && op.TableName == rename.NewName
+}
+
+// ChangeIndexTablespaceOp moves the index to another tablespace without recreating it.
+type ChangeIndexTablespaceOp struct {
+	Index sqlschema.Index
+	From  string
+	To    string
+}
+
+var _ Operation = (*ChangeIndexTablespaceOp)(nil)
+
+func (op *ChangeIndexTablespaceOp) GetReverse() Operation {
+	return &ChangeIndexTablespaceOp{
+		Index: op.Index,
+		From:  op.To,
+		To:    op.From,
+	}
+}
+
+func (op *ChangeIndexTablespaceOp) DependsOn(another Operation) bool {
+	rename, ok := another.(*RenameTableOp)
+	return ok && op.Index.TableName == rename.NewName
+}
+
 // DropPrimaryKeyOp drops the table's PRIMARY KEY.
+// It depends on DropForeignKeyOp for the foreign keys that reference the primary key columns.
 type DropPrimaryKeyOp struct {
 	TableName  string
 	PrimaryKey sqlschema.PrimaryKey
@@ -286,6 +787,16 @@ func (op *DropPrimaryKeyOp) GetReverse() Operation {
 	}
 }
 
+func (op *DropPrimaryKeyOp) DependsOn(another Operation) bool {
+	switch another := another.(type) {
+	case *RenameTableOp:
+		return op.TableName == another.NewName
+	case *DropForeignKeyOp:
+		return referencesKey(another.ForeignKey, op.TableName, op.PrimaryKey.Columns)
+	}
+	return false
+}
+
 // AddPrimaryKeyOp adds a new PRIMARY KEY to the table.
 type AddPrimaryKeyOp struct {
 	TableName  string
@@ -305,11 +816,17 @@ func (op *AddPrimaryKeyOp) DependsOn(another Operation) bool {
 	switch another := another.(type) {
 	case *AddColumnOp:
 		return op.TableName == another.TableName && op.PrimaryKey.Columns.Contains(another.ColumnName)
+	case *RenameColumnOp:
+		return op.TableName == another.TableName && op.PrimaryKey.Columns.Contains(another.NewName)
+	case *RenameTableOp:
+		return op.TableName == another.NewName
 	}
 	return false
 }
 
-// ChangePrimaryKeyOp changes the PRIMARY KEY of the table.
+// ChangePrimaryKeyOp changes the PRIMARY KEY of the table, e.g. adds, removes, or reorders its columns.
+// Like DropPrimaryKeyOp, it depends on DropForeignKeyOp for the foreign keys that reference the old key,
+// and like AddPrimaryKeyOp, on AddColumnOp and RenameColumnOp for the columns of the new one.
 type ChangePrimaryKeyOp struct {
 	TableName string
 	Old       sqlschema.PrimaryKey
@@ -326,6 +843,604 @@ func (op *ChangePrimaryKeyOp) GetReverse() Operation {
 	}
 }
 
+func (op *ChangePrimaryKeyOp) DependsOn(another Operation) bool {
+	switch another := another.(type) {
+	case *RenameTableOp:
+		return op.TableName == another.NewName
+	case *AddColumnOp:
+		return op.TableName == another.TableName && op.New.Columns.Contains(another.ColumnName)
+	case *RenameColumnOp:
+		return op.TableName == another.TableName && op.New.Columns.Contains(another.NewName)
+	case *DropForeignKeyOp:
+		return referencesKey(another.ForeignKey, op.TableName, op.Old.Columns)
+	}
+	return false
+}
+
+// referencesKey checks if the foreign key references exactly the key columns of the table, in any order.
+func referencesKey(fk sqlschema.ForeignKey, tableName string, key sqlschema.Columns) bool {
+	return fk.To.TableName == tableName && sameColumns(fk.To.Column, key)
+}
+
+func sameColumns(a, b sqlschema.Columns) bool {
+	x, y := a.Split(), b.Split()
+	slices.Sort(x)
+	slices.Sort(y)
+	return slices.Equal(x, y)
+}
+
+// CreateEnumOp creates a new enumerated type with the values listed in their sort order.
+type CreateEnumOp struct {
+	TypeName string
+	Values   []string
+
+	// IfNotExists skips creating the type if it exists already, even with different values.
+	IfNotExists bool
+}
+
+var _ Operation = (*CreateEnumOp)(nil)
+
+func (op *CreateEnumOp) GetReverse() Operation {
+	return &DropEnumOp{
+		TypeName: op.TypeName,
+		Values:   op.Values,
+	}
+}
+
+// DropEnumOp drops an enumerated type. It depends on DropTableOp, as well as
+// DropColumnOp and ChangeColumnTypeOp for the columns that use this type.
+type DropEnumOp struct {
+	TypeName string
+	Values   []string
+}
+
+var _ Operation = (*DropEnumOp)(nil)
+
+func (op *DropEnumOp) GetReverse() Operation {
+	return &CreateEnumOp{
+		TypeName: op.TypeName,
+		Values:   op.Values,
+	}
+}
+
+func (op *DropEnumOp) DependsOn(another Operation) bool {
+	switch drop := another.(type) {
+	case *DropTableOp:
+		return true
+	case *DropColumnOp:
+		return strings.EqualFold(drop.Column.GetSQLType(), op.TypeName)
+	case *ChangeColumnTypeOp:
+		return strings.EqualFold(drop.From.GetSQLType(), op.TypeName)
+	}
+	return false
+}
+
+// AddEnumValueOp adds a new value to an existing enumerated type.
+// The value is placed before or after another existing value, if either is set, and at the end otherwise.
+//
+// Adding a value is a safe operation: it does not rewrite the table or affect existing data.
+// It cannot be reversed however, because most dialects do not support removing values from an enum.
+type AddEnumValueOp struct {
+	TypeName string
+	Value    string
+	Before   string
+	After    string
+}
+
+var _ Operation = (*AddEnumValueOp)(nil)
+
+func (op *AddEnumValueOp) GetReverse() Operation {
+	c := comment(fmt.Sprintf("WARNING: value %q cannot be removed from enum %q automatically", op.Value, op.TypeName))
+	return &c
+}
+
+// dependsOnType checks if the operation creates or alters the enumerated type or the domain used by the column.
+func dependsOnType(col sqlschema.Column, another Operation) bool {
+	if col == nil {
+		return false
+	}
+	switch typ := another.(type) {
+	case *CreateEnumOp:
+		return strings.EqualFold(col.GetSQLType(), typ.TypeName)
+	case *AddEnumValueOp:
+		return strings.EqualFold(col.GetSQLType(), typ.TypeName)
+	case *CreateDomainOp:
+		return strings.EqualFold(col.GetSQLType(), typ.Domain.Name)
+	case *CreateCompositeTypeOp:
+		return strings.EqualFold(col.GetSQLType(), typ.Type.Name)
+	}
+	return false
+}
+
+// CreateViewOp creates a new view. Views are not parsed to find the tables they select from,
+// so it depends on every other operation except for the ones on views and is executed last.
+type CreateViewOp struct {
+	View sqlschema.View
+}
+
+var _ Operation = (*CreateViewOp)(nil)
+
+func (op *CreateViewOp) GetReverse() Operation {
+	return &DropViewOp{View: op.View}
+}
+
+func (op *CreateViewOp) DependsOn(another Operation) bool {
+	switch another.(type) {
+	case *CreateViewOp, *DropViewOp, *RefreshViewOp:
+		return false
+	}
+	return true
+}
+
+// DropViewOp drops a view. Views may reference any of the tables, so DropTableOp,
+// DropColumnOp, and ChangeColumnTypeOp depend on it.
+//
+// Views whose definition has changed are replaced with a DropViewOp and CreateViewOp pair,
+// which, unlike CREATE OR REPLACE VIEW, allows removing columns from the view.
+type DropViewOp struct {
+	View sqlschema.View
+}
+
+var _ Operation = (*DropViewOp)(nil)
+
+func (op *DropViewOp) GetReverse() Operation {
+	return &CreateViewOp{View: op.View}
+}
+
+// RefreshViewOp replaces the contents of a materialized view with the current result of its query.
+// It is executed after all other operations, including the creation of the views it may select from.
+type RefreshViewOp struct {
+	View sqlschema.View
+}
+
+var _ Operation = (*RefreshViewOp)(nil)
+
+// GetReverse returns a no-op, as the previous contents of the view cannot be restored.
+func (op *RefreshViewOp) GetReverse() Operation {
+	c := comment(fmt.Sprintf("WARNING: previous contents of materialized view %q cannot be restored", op.View.Name))
+	return &c
+}
+
+func (op *RefreshViewOp) DependsOn(another Operation) bool {
+	_, isRefresh := another.(*RefreshViewOp)
+	return !isRefresh
+}
+
+// CreateSequenceOp creates a new sequence. New tables and columns may use it in their default values,
+// so they are created after the sequence, unless the sequence is owned by them.
+type CreateSequenceOp struct {
+	Sequence sqlschema.Sequence
+
+	// IfNotExists skips creating the sequence if it exists already.
+	IfNotExists bool
+}
+
+var _ Operation = (*CreateSequenceOp)(nil)
+
+func (op *CreateSequenceOp) GetReverse() Operation {
+	return &DropSequenceOp{Sequence: op.Sequence}
+}
+
+func (op *CreateSequenceOp) DependsOn(another Operation) bool {
+	return ownsSequence(another, op.Sequence)
+}
+
+// AlterSequenceOp changes the parameters or the owner of an existing sequence.
+type AlterSequenceOp struct {
+	From sqlschema.Sequence
+	To   sqlschema.Sequence
+}
+
+var _ Operation = (*AlterSequenceOp)(nil)
+
+func (op *AlterSequenceOp) GetReverse() Operation {
+	return &AlterSequenceOp{From: op.To, To: op.From}
+}
+
+func (op *AlterSequenceOp) DependsOn(another Operation) bool {
+	return ownsSequence(another, op.To)
+}
+
+// DropSequenceOp drops a standalone sequence. Column defaults may reference the sequence,
+// so it depends on DropTableOp, DropColumnOp, and ChangeColumnTypeOp.
+type DropSequenceOp struct {
+	Sequence sqlschema.Sequence
+}
+
+var _ Operation = (*DropSequenceOp)(nil)
+
+func (op *DropSequenceOp) GetReverse() Operation {
+	return &CreateSequenceOp{Sequence: op.Sequence}
+}
+
+func (op *DropSequenceOp) DependsOn(another Operation) bool {
+	switch another.(type) {
+	case *DropTableOp, *DropColumnOp, *ChangeColumnTypeOp:
+		return true
+	}
+	return false
+}
+
+// CreateDomainOp creates a new domain type. Tables and columns which use the domain are created after it.
+type CreateDomainOp struct {
+	Domain sqlschema.Domain
+
+	// IfNotExists skips creating the domain if a type with the same name exists already.
+	IfNotExists bool
+}
+
+var _ Operation = (*CreateDomainOp)(nil)
+
+func (op *CreateDomainOp) GetReverse() Operation {
+	return &DropDomainOp{Domain: op.Domain}
+}
+
+// DropDomainOp drops a domain type. It depends on DropTableOp, as well as
+// DropColumnOp and ChangeColumnTypeOp for the columns that use the domain.
+type DropDomainOp struct {
+	Domain sqlschema.Domain
+}
+
+var _ Operation = (*DropDomainOp)(nil)
+
+func (op *DropDomainOp) GetReverse() Operation {
+	return &CreateDomainOp{Domain: op.Domain}
+}
+
+func (op *DropDomainOp) DependsOn(another Operation) bool {
+	switch drop := another.(type) {
+	case *DropTableOp:
+		return true
+	case *DropColumnOp:
+		return drop.Column != nil && strings.EqualFold(drop.Column.GetSQLType(), op.Domain.Name)
+	case *ChangeColumnTypeOp:
+		return strings.EqualFold(drop.From.GetSQLType(), op.Domain.Name)
+	}
+	return false
+}
+
+// CreateCompositeTypeOp creates a new composite type. Tables and columns which use the type are created after it,
+// and the type itself is created after the enums, domains, and other composite types used by its attributes.
+type CreateCompositeTypeOp struct {
+	Type sqlschema.CompositeType
+
+	// IfNotExists skips creating the type if it exists already.
+	IfNotExists bool
+}
+
+var _ Operation = (*CreateCompositeTypeOp)(nil)
+
+func (op *CreateCompositeTypeOp) GetReverse() Operation {
+	return &DropCompositeTypeOp{Type: op.Type}
+}
+
+func (op *CreateCompositeTypeOp) DependsOn(another Operation) bool {
+	for _, attr := range op.Type.Attributes {
+		if dependsOnType(&sqlschema.BaseColumn{SQLType: attr.DataType}, another) {
+			return true
+		}
+	}
+	return false
+}
+
+// DropCompositeTypeOp drops a composite type. It depends on DropTableOp, as well as
+// DropColumnOp and ChangeColumnTypeOp for the columns that use the type.
+type DropCompositeTypeOp struct {
+	Type sqlschema.CompositeType
+}
+
+var _ Operation = (*DropCompositeTypeOp)(nil)
+
+func (op *DropCompositeTypeOp) GetReverse() Operation {
+	return &CreateCompositeTypeOp{Type: op.Type}
+}
+
+func (op *DropCompositeTypeOp) DependsOn(another Operation) bool {
+	switch drop := another.(type) {
+	case *DropTableOp:
+		return true
+	case *DropColumnOp:
+		return drop.Column != nil && strings.EqualFold(drop.Column.GetSQLType(), op.Type.Name)
+	case *ChangeColumnTypeOp:
+		return strings.EqualFold(drop.From.GetSQLType(), op.Type.Name)
+	case *DropCompositeAttributeOp:
+		return drop.Type.Schema == op.Type.Schema && drop.Type.Name == op.Type.Name
+	}
+	return false
+}
+
+// AddCompositeAttributeOp adds a new attribute to an existing composite type.
+type AddCompositeAttributeOp struct {
+	Type      sqlschema.CompositeType
+	Attribute sqlschema.CompositeAttribute
+}
+
+var _ Operation = (*AddCompositeAttributeOp)(nil)
+
+func (op *AddCompositeAttributeOp) GetReverse() Operation {
+	return &DropCompositeAttributeOp{Type: op.Type, Attribute: op.Attribute}
+}
+
+func (op *AddCompositeAttributeOp) DependsOn(another Operation) bool {
+	return dependsOnType(&sqlschema.BaseColumn{SQLType: op.Attribute.DataType}, another)
+}
+
+// DropCompositeAttributeOp drops an attribute from a composite type. The attribute's values are lost
+// in every column of this type.
+type DropCompositeAttributeOp struct {
+	Type      sqlschema.CompositeType
+	Attribute sqlschema.CompositeAttribute
+}
+
+var _ Operation = (*DropCompositeAttributeOp)(nil)
+
+func (op *DropCompositeAttributeOp) GetReverse() Operation {
+	return &AddCompositeAttributeOp{Type: op.Type, Attribute: op.Attribute}
+}
+
+// CreateExtensionOp installs an extension, unless it is already installed. Other operations may use
+// the objects it provides, so they all depend on it, see ResolveDependencies.
+type CreateExtensionOp struct {
+	Extension sqlschema.Extension
+}
+
+var _ Operation = (*CreateExtensionOp)(nil)
+
+func (op *CreateExtensionOp) GetReverse() Operation {
+	c := comment(fmt.Sprintf("WARNING: extension %q is not dropped automatically, as other schemas may use it", op.Extension.Name))
+	return &c
+}
+
+// CreateIndexOp creates a secondary index. It depends on the operations which create, rebuild, or alter the table,
+// and on the DropIndexOp which frees the index name when a changed index is re-created.
+type CreateIndexOp struct {
+	Index sqlschema.Index
+
+	// Concurrently builds the index without blocking writes to the table, in dialects that support it.
+	// Such statements cannot be executed in a transaction.
+	Concurrently bool
+
+	// IfNotExists skips building the index if the schema has a relation with the same name.
+	IfNotExists bool
+}
+
+var _ Operation = (*CreateIndexOp)(nil)
+
+func (op *CreateIndexOp) GetReverse() Operation {
+	return &DropIndexOp{Index: op.Index, Concurrently: op.Concurrently}
+}
+
+func (op *CreateIndexOp) DependsOn(another Operation) bool {
+	tableName := op.Index.TableName
+	switch another := another.(type) {
+	case *CreateTableOp:
+		return another.TableName == tableName
+	case *RenameTableOp:
+		return another.NewName == tableName
+	case *AddColumnOp:
+		return another.TableName == tableName
+	case *RenameColumnOp:
+		return another.TableName == tableName
+	case *ChangeColumnTypeOp:
+		return another.TableName == tableName
+	case *ChangeColumnDefaultOp:
+		return another.TableName == tableName
+	case *ChangeColumnNullabilityOp:
+		return another.TableName == tableName
+	case *DropIndexOp:
+		return sameIndexName(op.Index, another.Index)
+	}
+	return false
+}
+
+// DropIndexOp drops a secondary index by its name. DropColumnOp for the columns of the index's table depends on it.
+// Indexes whose definition has changed are replaced with a DropIndexOp and CreateIndexOp pair.
+type DropIndexOp struct {
+	Index sqlschema.Index
+
+	// Concurrently drops the index without blocking access to the table, in dialects that support it.
+	// Such statements cannot be executed in a transaction.
+	Concurrently bool
+}
+
+var _ Operation = (*DropIndexOp)(nil)
+
+func (op *DropIndexOp) GetReverse() Operation {
+	return &CreateIndexOp{Index: op.Index, Concurrently: op.Concurrently}
+}
+
+// sameIndexName checks if the indexes have the same name in the same schema, which index names are unique in.
+func sameIndexName(idx1, idx2 sqlschema.Index) bool {
+	schema1, _ := sqlschema.SplitTableKey(idx1.TableName)
+	schema2, _ := sqlschema.SplitTableKey(idx2.TableName)
+	return schema1 == schema2 && idx1.Name == idx2.Name
+}
+
+// CreateTriggerOp creates a new trigger. It depends on the operations which create the table or its columns,
+// including a RenameTableOp that gives the table its new name, and on the column changes which may rebuild it.
+// The functions called by the triggers are not managed and must exist in the database.
+type CreateTriggerOp struct {
+	Trigger sqlschema.Trigger
+}
+
+var _ Operation = (*CreateTriggerOp)(nil)
+
+func (op *CreateTriggerOp) GetReverse() Operation {
+	return &DropTriggerOp{Trigger: op.Trigger}
+}
+
+func (op *CreateTriggerOp) DependsOn(another Operation) bool {
+	switch another := another.(type) {
+	case *CreateTableOp:
+		return another.TableName == op.Trigger.TableName
+	case *AddColumnOp:
+		return another.TableName == op.Trigger.TableName
+	case *RenameTableOp:
+		return another.NewName == op.Trigger.TableName
+	case *ChangeColumnTypeOp:
+		return another.TableName == op.Trigger.TableName
+	case *ChangeColumnDefaultOp:
+		return another.TableName == op.Trigger.TableName
+	case *ChangeColumnNullabilityOp:
+		return another.TableName == op.Trigger.TableName
+	case *DropTriggerOp:
+		return another.Trigger.TableName == op.Trigger.TableName && another.Trigger.Name == op.Trigger.Name
+	}
+	return false
+}
+
+// DropTriggerOp drops a trigger. DropTableOp and RenameTableOp for the trigger's table depend on it.
+// Triggers whose definition has changed are replaced with a DropTriggerOp and CreateTriggerOp pair.
+type DropTriggerOp struct {
+	Trigger sqlschema.Trigger
+}
+
+var _ Operation = (*DropTriggerOp)(nil)
+
+func (op *DropTriggerOp) GetReverse() Operation {
+	return &CreateTriggerOp{Trigger: op.Trigger}
+}
+
+// CreatePolicyOp creates a row-level security policy. Like CreateTriggerOp, it depends on
+// the operations which create the table or its columns, as the policy's expressions may refer to them.
+type CreatePolicyOp struct {
+	Policy sqlschema.Policy
+}
+
+var _ Operation = (*CreatePolicyOp)(nil)
+
+func (op *CreatePolicyOp) GetReverse() Operation {
+	return &DropPolicyOp{Policy: op.Policy}
+}
+
+func (op *CreatePolicyOp) DependsOn(another Operation) bool {
+	switch another := another.(type) {
+	case *CreateTableOp:
+		return another.TableName == op.Policy.TableName
+	case *AddColumnOp:
+		return another.TableName == op.Policy.TableName
+	case *RenameTableOp:
+		return another.NewName == op.Policy.TableName
+	case *DropPolicyOp:
+		return another.Policy.TableName == op.Policy.TableName && another.Policy.Name == op.Policy.Name
+	}
+	return false
+}
+
+// DropPolicyOp drops a row-level security policy.
+// DropTableOp, RenameTableOp, and DropColumnOp for the policy's table depend on it.
+type DropPolicyOp struct {
+	Policy sqlschema.Policy
+}
+
+var _ Operation = (*DropPolicyOp)(nil)
+
+func (op *DropPolicyOp) GetReverse() Operation {
+	return &CreatePolicyOp{Policy: op.Policy}
+}
+
+// AlterPolicyOp changes the roles or the expressions of an existing policy.
+// Policies whose command or kind (permissive or restrictive) has changed cannot be altered
+// and are replaced with a DropPolicyOp and CreatePolicyOp pair instead.
+type AlterPolicyOp struct {
+	From sqlschema.Policy
+	To   sqlschema.Policy
+}
+
+var _ Operation = (*AlterPolicyOp)(nil)
+
+func (op *AlterPolicyOp) GetReverse() Operation {
+	return &AlterPolicyOp{From: op.To, To: op.From}
+}
+
+func (op *AlterPolicyOp) DependsOn(another Operation) bool {
+	switch another := another.(type) {
+	case *AddColumnOp:
+		return another.TableName == op.To.TableName
+	case *RenameTableOp:
+		return another.NewName == op.To.TableName
+	}
+	return false
+}
+
+// ChangeRowSecurityOp enables or disables row-level security for the table, and whether it applies to the table owner.
+type ChangeRowSecurityOp struct {
+	TableName string
+	From      sqlschema.RowSecurity
+	To        sqlschema.RowSecurity
+}
+
+var _ Operation = (*ChangeRowSecurityOp)(nil)
+
+func (op *ChangeRowSecurityOp) GetReverse() Operation {
+	return &ChangeRowSecurityOp{TableName: op.TableName, From: op.To, To: op.From}
+}
+
+func (op *ChangeRowSecurityOp) DependsOn(another Operation) bool {
+	switch another := another.(type) {
+	case *CreateTableOp:
+		return another.TableName == op.TableName
+	case *RenameTableOp:
+		return another.NewName == op.TableName
+	}
+	return false
+}
+
+// GrantOp gives the privilege on the table or its column to the role.
+// It depends on the operations which create, rename, or add the table or the column.
+type GrantOp struct {
+	Grant sqlschema.Grant
+}
+
+var _ Operation = (*GrantOp)(nil)
+
+func (op *GrantOp) GetReverse() Operation {
+	return &RevokeOp{Grant: op.Grant}
+}
+
+func (op *GrantOp) DependsOn(another Operation) bool {
+	switch another := another.(type) {
+	case *CreateTableOp:
+		return another.TableName == op.Grant.TableName
+	case *RenameTableOp:
+		return another.NewName == op.Grant.TableName
+	case *AddColumnOp:
+		return another.TableName == op.Grant.TableName && another.ColumnName == op.Grant.Column
+	case *RenameColumnOp:
+		return another.TableName == op.Grant.TableName && another.NewName == op.Grant.Column
+	case *RevokeOp:
+		// Revoking a table privilege also revokes it from the columns, so it must not follow the column grant.
+		return another.Grant.TableName == op.Grant.TableName && another.Grant.Grantee == op.Grant.Grantee
+	}
+	return false
+}
+
+// RevokeOp takes the privilege on the table or its column away from the role.
+// The operations which drop or rename the table or the column depend on it.
+type RevokeOp struct {
+	Grant sqlschema.Grant
+}
+
+var _ Operation = (*RevokeOp)(nil)
+
+func (op *RevokeOp) GetReverse() Operation {
+	return &GrantOp{Grant: op.Grant}
+}
+
+// ownsSequence checks if the operation creates the table or the column which the sequence belongs to.
+func ownsSequence(op Operation, seq sqlschema.Sequence) bool {
+	if !seq.IsOwned() {
+		return false
+	}
+	switch op := op.(type) {
+	case *CreateTableOp:
+		return op.TableName == seq.OwnedBy.TableName
+	case *AddColumnOp:
+		return sqlschema.NewColumnReference(op.TableName, op.ColumnName) == seq.OwnedBy
+	}
+	return false
+}
+
 // comment denotes an Operation that cannot be executed.
 //
 // Operations, which cannot be reversed due to current technical limitations,
@@ -0,0 +1,243 @@
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/uptrace/bun/migrate/sqlschema"
+	"github.com/uptrace/bun/schema"
+)
+
+// Preview detects the changes required to bring the database to the desired state and describes them
+// in plain words, e.g. "add column age bigint not null default 0", for a quick review in a terminal.
+// Unlike DryRun, it does not generate any SQL, so it also works for changes the dialect cannot apply.
+//
+// Lines are listed in the order the changes would be executed. Consecutive changes to the same table
+// are grouped under a "<table>:" line and indented, so a table may appear more than once if its changes
+// are interleaved with others. Destructive changes are marked with a "[destructive]" suffix.
+func (am *AutoMigrator) Preview(ctx context.Context) ([]string, error) {
+	changes, err := am.plan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("preview: %w", err)
+	}
+
+	var lines []string
+	var group string
+	for _, op := range changes.operations {
+		table, text := describe(op)
+		if IsDestructive(op) {
+			text += " [destructive]"
+		}
+
+		if table == "" {
+			group = ""
+			lines = append(lines, text)
+			continue
+		}
+		if table != group {
+			group = table
+			lines = append(lines, table+":")
+		}
+		lines = append(lines, "  "+text)
+	}
+	return lines, nil
+}
+
+// describe returns the name of the table the operation changes, if any, and a short description of the change.
+func describe(op Operation) (table, text string) {
+	switch op := op.(type) {
+	case *CreateTableOp:
+		return op.TableName, "create table"
+	case *DropTableOp:
+		return op.TableName, "drop table"
+	case *RenameTableOp:
+		return op.TableName, fmt.Sprintf("rename table to %s", op.NewName)
+	case *RenameColumnOp:
+		return op.TableName, fmt.Sprintf("rename column %s to %s", op.OldName, op.NewName)
+	case *AddColumnOp:
+		text := fmt.Sprintf("add column %s %s", op.ColumnName, describeColumn(op.Column))
+		if op.Backfill != "" {
+			text += fmt.Sprintf(", backfill with %s", op.Backfill)
+		}
+		return op.TableName, text
+	case *DropColumnOp:
+		return op.TableName, fmt.Sprintf("drop column %s", op.ColumnName)
+	case *ChangeColumnTypeOp:
+		return op.TableName, fmt.Sprintf("change column %s from %s to %s",
+			op.Column, describeColumn(op.From), describeColumn(op.To))
+	case *ChangeColumnDefaultOp:
+		if op.To == "" {
+			return op.TableName, fmt.Sprintf("drop default on column %s", op.Column)
+		}
+		return op.TableName, fmt.Sprintf("set default on column %s to %s", op.Column, op.To)
+	case *ChangeColumnNullabilityOp:
+		if op.Nullable {
+			return op.TableName, fmt.Sprintf("drop not null on column %s", op.Column)
+		}
+		if op.Backfill != "" {
+			return op.TableName, fmt.Sprintf("set not null on column %s, backfill with %s", op.Column, op.Backfill)
+		}
+		return op.TableName, fmt.Sprintf("set not null on column %s", op.Column)
+	case *ChangeColumnCommentOp:
+		return op.TableName, fmt.Sprintf("change comment on column %s to %q", op.Column, op.To)
+	case *ChangeTableCommentOp:
+		return op.TableName, fmt.Sprintf("change table comment to %q", op.To)
+	case *AddForeignKeyOp:
+		return op.TableName(), fmt.Sprintf("add foreign key (%s) references %s (%s)",
+			op.ForeignKey.From.Column, op.ForeignKey.To.TableName, op.ForeignKey.To.Column)
+	case *DropForeignKeyOp:
+		return op.TableName(), fmt.Sprintf("drop foreign key %s", op.ConstraintName)
+	case *AddUniqueConstraintOp:
+		return op.TableName, fmt.Sprintf("add unique constraint on (%s)", op.Unique.Columns)
+	case *DropUniqueConstraintOp:
+		return op.TableName, fmt.Sprintf("drop unique constraint %s", op.Unique.Name)
+	case *AddCheckConstraintOp:
+		return op.TableName, fmt.Sprintf("add check constraint (%s)", op.Check.Expression)
+	case *DropCheckConstraintOp:
+		return op.TableName, fmt.Sprintf("drop check constraint %s", op.Check.Name)
+	case *AddExclusionConstraintOp:
+		return op.TableName, fmt.Sprintf("add exclusion constraint %s using %s", op.Exclusion.Name, op.Exclusion.NormalizedMethod())
+	case *DropExclusionConstraintOp:
+		return op.TableName, fmt.Sprintf("drop exclusion constraint %s", op.Exclusion.Name)
+	case *AddPrimaryKeyOp:
+		return op.TableName, fmt.Sprintf("add primary key (%s)", op.PrimaryKey.Columns)
+	case *DropPrimaryKeyOp:
+		return op.TableName, "drop primary key"
+	case *ChangePrimaryKeyOp:
+		return op.TableName, fmt.Sprintf("change primary key from (%s) to (%s)", op.Old.Columns, op.New.Columns)
+	case *CreateIndexOp:
+		return op.Index.TableName, fmt.Sprintf("create %s %s on (%s)", indexKind(op.Index), op.Index.Name, indexColumns(op.Index))
+	case *DropIndexOp:
+		return op.Index.TableName, fmt.Sprintf("drop %s %s", indexKind(op.Index), op.Index.Name)
+	case *CreateTriggerOp:
+		return op.Trigger.TableName, fmt.Sprintf("create trigger %s", op.Trigger.Name)
+	case *DropTriggerOp:
+		return op.Trigger.TableName, fmt.Sprintf("drop trigger %s", op.Trigger.Name)
+	case *CreatePolicyOp:
+		return op.Policy.TableName, fmt.Sprintf("create policy %s", op.Policy.Name)
+	case *DropPolicyOp:
+		return op.Policy.TableName, fmt.Sprintf("drop policy %s", op.Policy.Name)
+	case *AlterPolicyOp:
+		return op.To.TableName, fmt.Sprintf("alter policy %s", op.To.Name)
+	case *ChangeRowSecurityOp:
+		return op.TableName, fmt.Sprintf("%s row level security", rowSecurityVerb(op.To))
+	case *ChangeColumnStorageOp:
+		return op.TableName, fmt.Sprintf("set storage of %s to %s", op.Column, strings.ToLower(cmp.Or(op.To, "default")))
+	case *ChangeTablespaceOp:
+		return op.TableName, fmt.Sprintf("move to tablespace %s", cmp.Or(op.To, "default"))
+	case *ChangeIndexTablespaceOp:
+		return op.Index.TableName, fmt.Sprintf("move index %s to tablespace %s", op.Index.Name, cmp.Or(op.To, "default"))
+	case *GrantOp:
+		return op.Grant.TableName, fmt.Sprintf("grant %s to %s", grantPrivilege(op.Grant), op.Grant.Grantee)
+	case *RevokeOp:
+		return op.Grant.TableName, fmt.Sprintf("revoke %s from %s", grantPrivilege(op.Grant), op.Grant.Grantee)
+	case *CreateEnumOp:
+		return "", fmt.Sprintf("create enum %s (%s)", op.TypeName, strings.Join(op.Values, ", "))
+	case *DropEnumOp:
+		return "", fmt.Sprintf("drop enum %s", op.TypeName)
+	case *AddEnumValueOp:
+		return "", fmt.Sprintf("add value %q to enum %s", op.Value, op.TypeName)
+	case *CreateViewOp:
+		return "", fmt.Sprintf("create %s %s", viewKind(op.View), op.View.Name)
+	case *DropViewOp:
+		return "", fmt.Sprintf("drop %s %s", viewKind(op.View), op.View.Name)
+	case *RefreshViewOp:
+		return "", fmt.Sprintf("refresh %s %s", viewKind(op.View), op.View.Name)
+	case *CreateSequenceOp:
+		return "", fmt.Sprintf("create sequence %s", op.Sequence.Name)
+	case *AlterSequenceOp:
+		return "", fmt.Sprintf("alter sequence %s", op.To.Name)
+	case *DropSequenceOp:
+		return "", fmt.Sprintf("drop sequence %s", op.Sequence.Name)
+	case *CreateDomainOp:
+		return "", fmt.Sprintf("create domain %s %s", op.Domain.Name, strings.ToLower(op.Domain.DataType))
+	case *DropDomainOp:
+		return "", fmt.Sprintf("drop domain %s", op.Domain.Name)
+	case *CreateCompositeTypeOp:
+		return "", fmt.Sprintf("create type %s", op.Type.Name)
+	case *DropCompositeTypeOp:
+		return "", fmt.Sprintf("drop type %s", op.Type.Name)
+	case *AddCompositeAttributeOp:
+		return "", fmt.Sprintf("add attribute %s %s to type %s",
+			op.Attribute.Name, strings.ToLower(op.Attribute.DataType), op.Type.Name)
+	case *DropCompositeAttributeOp:
+		return "", fmt.Sprintf("drop attribute %s from type %s", op.Attribute.Name, op.Type.Name)
+	case *CreateExtensionOp:
+		return "", fmt.Sprintf("create extension %s", op.Extension.Name)
+	case *comment:
+		return "", strings.ToLower(string(*op)[:1]) + string(*op)[1:]
+	}
+	return "", fmt.Sprintf("%T", op)
+}
+
+// describeColumn returns the column's type in lower case followed by its constraints, e.g. "bigint not null default 0".
+func describeColumn(col sqlschema.Column) string {
+	typ := &sqlschema.BaseColumn{
+		SQLType:          col.GetSQLType(),
+		VarcharLen:       col.GetVarcharLen(),
+		NumericPrecision: col.GetNumericPrecision(),
+		NumericScale:     col.GetNumericScale(),
+		ArrayDims:        col.GetArrayDims(),
+	}
+	b, _ := typ.AppendQuery(schema.NewNopFormatter(), nil)
+
+	parts := []string{strings.ToLower(string(b))}
+	if !col.GetIsNullable() {
+		parts = append(parts, "not null")
+	}
+	if def := col.GetDefaultValue(); def != "" {
+		parts = append(parts, "default "+def)
+	}
+	if col.GetIsIdentity() {
+		parts = append(parts, "identity")
+	}
+	if expr := col.GetGeneratedExpr(); expr != "" {
+		parts = append(parts, fmt.Sprintf("generated as (%s)", expr))
+	}
+	return strings.Join(parts, " ")
+}
+
+func indexKind(idx sqlschema.Index) string {
+	if idx.Unique {
+		return "unique index"
+	}
+	return "index"
+}
+
+func indexColumns(idx sqlschema.Index) string {
+	parts := make([]string, len(idx.Columns))
+	for i, col := range idx.Columns {
+		if col.Expression != "" {
+			parts[i] = col.Expression
+		} else {
+			parts[i] = col.Name
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func viewKind(v sqlschema.View) string {
+	if v.Materialized {
+		return "materialized view"
+	}
+	return "view"
+}
+
+func rowSecurityVerb(rs sqlschema.RowSecurity) string {
+	switch {
+	case rs.Forced:
+		return "force"
+	case rs.Enabled:
+		return "enable"
+	}
+	return "disable"
+}
+
+func grantPrivilege(g sqlschema.Grant) string {
+	if g.Column != "" {
+		return fmt.Sprintf("%s (%s)", strings.ToLower(g.Privilege), g.Column)
+	}
+	return strings.ToLower(g.Privilege)
+}
@@ -0,0 +1,239 @@
+package sqlschema
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/uptrace/bun/internal/ordered"
+)
+
+// StateBuilder creates the schema state in memory, e.g. for tests:
+//
+//	state, err := sqlschema.NewState().
+//		AddTable("users").
+//		Column("id", "bigint").PK().
+//		Column("email", "varchar(320)").NotNull().Unique().
+//		AddTable("posts").
+//		Column("id", "bigint").PK().
+//		Column("author_id", "bigint").NotNull().References("users", "id").
+//		Build()
+//
+// Columns keep the order in which they are declared. Foreign keys are validated in Build,
+// so they may reference the tables declared further down.
+type StateBuilder struct {
+	schemaName string
+	tables     *ordered.Map[string, *BaseTable]
+	fks        []builderForeignKey
+	errs       []error
+}
+
+type builderForeignKey struct {
+	fk   ForeignKey
+	name string
+}
+
+// NewState creates an empty schema state. Tables are created in SchemaName, see WithSchemaName.
+func NewState(options ...InspectorOption) *StateBuilder {
+	var cfg InspectorConfig
+	ApplyInspectorOptions(&cfg, options...)
+	return &StateBuilder{
+		schemaName: cfg.SchemaName,
+		tables:     ordered.NewMap[string, *BaseTable](),
+	}
+}
+
+// AddTable adds a table without columns to the state and returns a builder to define them.
+func (sb *StateBuilder) AddTable(name string) *TableBuilder {
+	table := &BaseTable{
+		Schema:  sb.schemaName,
+		Name:    name,
+		Columns: ordered.NewMap[string, Column](),
+	}
+	switch _, exists := sb.tables.Load(name); {
+	case name == "":
+		sb.errorf("table name is required")
+	case exists:
+		sb.errorf("duplicate table %q", name)
+	default:
+		sb.tables.Store(name, table)
+	}
+	return &TableBuilder{sb: sb, table: table}
+}
+
+// Build validates the foreign keys and returns the state.
+// All errors in the definition are reported together.
+func (sb *StateBuilder) Build() (BaseDatabase, error) {
+	state := BaseDatabase{
+		Tables:      ordered.NewMap[string, Table](),
+		ForeignKeys: make(map[ForeignKey]string),
+		Enums:       make(map[string][]string),
+	}
+	for _, table := range sb.tables.Values() {
+		state.Tables.Store(table.Name, table)
+	}
+
+	errs := sb.errs
+	for _, fk := range sb.fks {
+		target, ok := sb.tables.Load(fk.fk.To.TableName)
+		if !ok {
+			errs = append(errs, fmt.Errorf("foreign key %s references unknown table %q", fk.name, fk.fk.To.TableName))
+			continue
+		}
+		if err := checkBuilderColumns(target, fk.fk.To.Column.Split()); err != nil {
+			errs = append(errs, fmt.Errorf("foreign key %s: %w", fk.name, err))
+			continue
+		}
+		state.ForeignKeys[fk.fk.Canonical()] = fk.name
+	}
+	if err := errors.Join(errs...); err != nil {
+		return BaseDatabase{}, fmt.Errorf("build state: %w", err)
+	}
+	return state, nil
+}
+
+func (sb *StateBuilder) errorf(format string, args ...interface{}) {
+	sb.errs = append(sb.errs, fmt.Errorf(format, args...))
+}
+
+// TableBuilder defines the columns and constraints of a table. Column modifiers,
+// such as NotNull or PK, apply to the column declared last.
+type TableBuilder struct {
+	sb     *StateBuilder
+	table  *BaseTable
+	column *BaseColumn
+}
+
+// Column adds a column of the SQL type, e.g. "varchar(100)", to the table. Columns are nullable by default.
+func (tb *TableBuilder) Column(name, sqlType string) *TableBuilder {
+	col, err := parseSQLType(sqlType)
+	col.Name = name
+	col.IsNullable = true
+	tb.column = &col
+
+	switch _, exists := tb.table.Columns.Load(name); {
+	case name == "":
+		tb.sb.errorf("column name in table %q is required", tb.table.Name)
+	case exists:
+		tb.sb.errorf("duplicate column %q in table %q", name, tb.table.Name)
+	case sqlType == "":
+		tb.sb.errorf("column %q in table %q must have a type", name, tb.table.Name)
+	case err != nil:
+		tb.sb.errorf("column %q in table %q: %w", name, tb.table.Name, err)
+	default:
+		tb.table.Columns.Store(name, tb.column)
+	}
+	return tb
+}
+
+// NotNull makes the last column non-nullable.
+func (tb *TableBuilder) NotNull() *TableBuilder {
+	if col := tb.lastColumn("NotNull"); col != nil {
+		col.IsNullable = false
+	}
+	return tb
+}
+
+// Default sets the default value of the last column, e.g. "0" or "'active'".
+func (tb *TableBuilder) Default(value string) *TableBuilder {
+	if col := tb.lastColumn("Default"); col != nil {
+		col.DefaultValue = exprOrLiteral(value)
+	}
+	return tb
+}
+
+// PK adds the last column to the table's primary key and makes it non-nullable.
+// Calling it for several columns creates a composite primary key.
+func (tb *TableBuilder) PK() *TableBuilder {
+	col := tb.lastColumn("PK")
+	if col == nil {
+		return tb
+	}
+	col.IsNullable = false
+	if tb.table.PrimaryKey == nil {
+		tb.table.PrimaryKey = &PrimaryKey{Columns: NewColumns(col.Name)}
+	} else {
+		tb.table.PrimaryKey.Columns = NewColumns(append(tb.table.PrimaryKey.Columns.Split(), col.Name)...)
+	}
+	return tb
+}
+
+// Unique adds a unique constraint on the last column.
+func (tb *TableBuilder) Unique() *TableBuilder {
+	if col := tb.lastColumn("Unique"); col != nil {
+		tb.table.UniqueConstraints = append(tb.table.UniqueConstraints, Unique{Columns: NewColumns(col.Name)})
+	}
+	return tb
+}
+
+// References adds a foreign key from the last column to the column of another table.
+func (tb *TableBuilder) References(table, column string) *TableBuilder {
+	if col := tb.lastColumn("References"); col != nil {
+		tb.ForeignKey([]string{col.Name}, table, column)
+	}
+	return tb
+}
+
+// UniqueConstraint adds a unique constraint on several columns of the table.
+func (tb *TableBuilder) UniqueConstraint(columns ...string) *TableBuilder {
+	if err := checkBuilderColumns(tb.table, columns); err != nil {
+		tb.sb.errorf("unique constraint: %w", err)
+		return tb
+	}
+	tb.table.UniqueConstraints = append(tb.table.UniqueConstraints, Unique{Columns: NewColumns(columns...)})
+	return tb
+}
+
+// Check adds a check constraint with the expression to the table.
+func (tb *TableBuilder) Check(expr string) *TableBuilder {
+	tb.table.Checks = append(tb.table.Checks, Check{Expression: expr})
+	return tb
+}
+
+// ForeignKey adds a foreign key from the columns of the table to the columns of another one,
+// which may be declared later. The constraint receives the default name, e.g. "posts_author_id_fkey".
+func (tb *TableBuilder) ForeignKey(columns []string, table string, references ...string) *TableBuilder {
+	name := defaultForeignKeyName(tb.table.Name, columns)
+	if err := checkBuilderColumns(tb.table, columns); err != nil {
+		tb.sb.errorf("foreign key %s: %w", name, err)
+		return tb
+	}
+	if len(columns) == 0 || len(references) != len(columns) {
+		tb.sb.errorf("foreign key %s references %d columns, want %d", name, len(references), len(columns))
+		return tb
+	}
+	tb.sb.fks = append(tb.sb.fks, builderForeignKey{
+		fk: ForeignKey{
+			From: NewColumnReference(tb.table.Name, columns...),
+			To:   NewColumnReference(table, references...),
+		},
+		name: name,
+	})
+	return tb
+}
+
+// AddTable finishes the current table and adds another one to the state, see StateBuilder.AddTable.
+func (tb *TableBuilder) AddTable(name string) *TableBuilder {
+	return tb.sb.AddTable(name)
+}
+
+// Build returns the state with all declared tables, see StateBuilder.Build.
+func (tb *TableBuilder) Build() (BaseDatabase, error) {
+	return tb.sb.Build()
+}
+
+func (tb *TableBuilder) lastColumn(modifier string) *BaseColumn {
+	if tb.column == nil {
+		tb.sb.errorf("%s in table %q must follow a column", modifier, tb.table.Name)
+	}
+	return tb.column
+}
+
+// checkBuilderColumns reports the first column which does not exist in the table.
+func checkBuilderColumns(table Table, columns []string) error {
+	for _, name := range columns {
+		if _, ok := table.GetColumns().Load(name); !ok {
+			return fmt.Errorf("unknown column %q in table %q", name, table.GetName())
+		}
+	}
+	return nil
+}
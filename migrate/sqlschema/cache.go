@@ -0,0 +1,78 @@
+package sqlschema
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Fingerprinter is implemented by database inspectors which can detect schema changes
+// without inspecting the schema, e.g. by reading a version counter from the system catalog.
+type Fingerprinter interface {
+	// Fingerprint returns a value which changes whenever any of the inspected tables is modified.
+	Fingerprint(ctx context.Context) (string, error)
+}
+
+// CachingInspector returns the result of the last inspection for as long as the schema fingerprint
+// stays the same, which saves a full inspection when the database is inspected repeatedly, e.g. in a dev loop.
+//
+// The cached state is copied before it is returned, so callers may modify it freely.
+type CachingInspector struct {
+	inspector     Inspector
+	fingerprinter Fingerprinter
+
+	mu          sync.Mutex
+	fingerprint string
+	snapshot    []byte
+}
+
+var _ Inspector = (*CachingInspector)(nil)
+
+// NewCachingInspector wraps an inspector created with NewInspector. It returns an error
+// if the dialect's inspector does not implement Fingerprinter.
+func NewCachingInspector(in Inspector) (*CachingInspector, error) {
+	if wrapped, ok := in.(*inspector); ok {
+		in = wrapped.Inspector
+	}
+	fingerprinter, ok := in.(Fingerprinter)
+	if !ok {
+		return nil, fmt.Errorf("sqlschema: %T does not support schema fingerprints", in)
+	}
+	return &CachingInspector{
+		inspector:     in,
+		fingerprinter: fingerprinter,
+	}, nil
+}
+
+func (ci *CachingInspector) Inspect(ctx context.Context) (Database, error) {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+
+	// Take the fingerprint before inspecting the schema: if it changes in between,
+	// the next call will see a different fingerprint and inspect the schema again.
+	fingerprint, err := ci.fingerprinter.Fingerprint(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ci.snapshot != nil && fingerprint == ci.fingerprint {
+		return UnmarshalDatabase(ci.snapshot)
+	}
+
+	state, err := ci.inspector.Inspect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := MarshalDatabase(state)
+	if err != nil {
+		return nil, err
+	}
+	ci.fingerprint, ci.snapshot = fingerprint, snapshot
+	return state, nil
+}
+
+// Reset discards the cached state, so that the next call to Inspect inspects the database.
+func (ci *CachingInspector) Reset() {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	ci.fingerprint, ci.snapshot = "", nil
+}
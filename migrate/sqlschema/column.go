@@ -2,6 +2,7 @@ package sqlschema
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/uptrace/bun/schema"
 )
@@ -10,28 +11,197 @@ type Column interface {
 	GetName() string
 	GetSQLType() string
 	GetVarcharLen() int
+	GetNumericPrecision() int
+	GetNumericScale() int
+	GetArrayDims() int
 	GetDefaultValue() string
 	GetIsNullable() bool
 	GetIsAutoIncrement() bool
 	GetIsIdentity() bool
+	GetIdentityOptions() IdentityOptions
+	GetComment() string
+	GetCollation() string
+	GetGeneratedExpr() string
+	GetGeneratedStored() bool
+	GetSequence() string
+	GetStorage() string
 	AppendQuery(schema.Formatter, []byte) ([]byte, error)
 }
 
 var _ Column = (*BaseColumn)(nil)
 
+// VarcharLenMax is the VarcharLen of character and binary types declared with (MAX) length, e.g. NVARCHAR(MAX).
+const VarcharLenMax = -1
+
+// NormalizeType returns the SQL type in upper case with runs of whitespace collapsed to a single space,
+// so that spelling variations of multi-word types, e.g. "double  precision", compare equal.
+func NormalizeType(typ string) string {
+	return strings.ToUpper(collapseSpaces(typ))
+}
+
+// collapseSpaces trims the string and replaces each run of whitespace in it with a single space.
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// TypeFamily groups the data types which store the same kind of values, e.g. SMALLINT and BIGINT.
+type TypeFamily string
+
+const (
+	FamilyUnknown   TypeFamily = ""
+	FamilyBoolean   TypeFamily = "boolean"
+	FamilyInteger   TypeFamily = "integer"
+	FamilyDecimal   TypeFamily = "decimal"
+	FamilyFloat     TypeFamily = "float"
+	FamilyString    TypeFamily = "string"
+	FamilyBinary    TypeFamily = "binary"
+	FamilyDate      TypeFamily = "date"
+	FamilyTime      TypeFamily = "time"
+	FamilyTimestamp TypeFamily = "timestamp"
+	FamilyJSON      TypeFamily = "json"
+	FamilyUUID      TypeFamily = "uuid"
+)
+
+// typeSizes holds the storage size in bytes of integer and floating point types,
+// which is used to tell if values of one type fit into the other.
+var typeSizes = map[string]int{
+	"TINYINT": 1, "SMALLINT": 2, "INT2": 2, "SMALLSERIAL": 2, "MEDIUMINT": 3,
+	"INTEGER": 4, "INT": 4, "INT4": 4, "SERIAL": 4, "BIGINT": 8, "INT8": 8, "BIGSERIAL": 8,
+	"REAL": 4, "FLOAT4": 4, "DOUBLE PRECISION": 8, "DOUBLE": 8, "FLOAT8": 8, "FLOAT": 8,
+}
+
+// integerDigits is the number of decimal digits needed to represent any value of an integer type of the given size.
+var integerDigits = map[int]int{1: 3, 2: 5, 3: 8, 4: 10, 8: 19}
+
+// GetTypeFamily returns the family of the SQL type, or FamilyUnknown for types
+// that are specific to a dialect, such as enums and user-defined types.
+func GetTypeFamily(sqlType string) TypeFamily {
+	switch typ := NormalizeType(sqlType); typ {
+	case "BOOLEAN", "BOOL", "BIT":
+		return FamilyBoolean
+	case "TINYINT", "SMALLINT", "INT2", "SMALLSERIAL", "MEDIUMINT", "INTEGER", "INT", "INT4", "SERIAL",
+		"BIGINT", "INT8", "BIGSERIAL":
+		return FamilyInteger
+	case "NUMERIC", "DECIMAL", "MONEY":
+		return FamilyDecimal
+	case "REAL", "FLOAT4", "DOUBLE PRECISION", "DOUBLE", "FLOAT8", "FLOAT":
+		return FamilyFloat
+	case "CHAR", "CHARACTER", "NCHAR", "VARCHAR", "CHARACTER VARYING", "NVARCHAR", "TEXT", "NTEXT",
+		"TINYTEXT", "MEDIUMTEXT", "LONGTEXT", "CITEXT":
+		return FamilyString
+	case "BYTEA", "BLOB", "BINARY", "VARBINARY", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB":
+		return FamilyBinary
+	case "DATE":
+		return FamilyDate
+	case "TIME", "TIME WITHOUT TIME ZONE", "TIME WITH TIME ZONE", "TIMETZ":
+		return FamilyTime
+	case "TIMESTAMP", "TIMESTAMP WITHOUT TIME ZONE", "TIMESTAMP WITH TIME ZONE", "TIMESTAMPTZ",
+		"DATETIME", "DATETIME2", "DATETIMEOFFSET", "SMALLDATETIME":
+		return FamilyTimestamp
+	case "JSON", "JSONB":
+		return FamilyJSON
+	case "UUID", "UNIQUEIDENTIFIER":
+		return FamilyUUID
+	}
+	return FamilyUnknown
+}
+
+// IsLossyConversion reports whether converting the values of column from to the type of column to
+// may truncate them, lose precision, or fail for some of the values, e.g. BIGINT to INTEGER
+// or TEXT to INTEGER. Widening conversions and conversions to unbounded character types are not lossy.
+// Types of unknown families are only converted losslessly to the same type.
+func IsLossyConversion(from, to Column) bool {
+	if from.GetArrayDims() != to.GetArrayDims() {
+		return true
+	}
+
+	typ1, typ2 := NormalizeType(from.GetSQLType()), NormalizeType(to.GetSQLType())
+	fam1, fam2 := GetTypeFamily(typ1), GetTypeFamily(typ2)
+	switch {
+	case fam1 == FamilyString && fam2 == FamilyString, fam1 == FamilyBinary && fam2 == FamilyBinary:
+		return shorter(to.GetVarcharLen(), from.GetVarcharLen())
+	case fam2 == FamilyString:
+		// Values of other types are represented as text, which is long enough unless its length is limited.
+		return to.GetVarcharLen() != 0 && to.GetVarcharLen() != VarcharLenMax
+	case fam1 == FamilyInteger && fam2 == FamilyInteger, fam1 == FamilyFloat && fam2 == FamilyFloat:
+		return typeSizes[typ2] < typeSizes[typ1]
+	case fam1 == FamilyDecimal && fam2 == FamilyDecimal:
+		return shorter(to.GetNumericPrecision(), from.GetNumericPrecision()) ||
+			to.GetNumericScale() < from.GetNumericScale()
+	case fam1 == FamilyInteger && fam2 == FamilyDecimal:
+		return to.GetNumericPrecision() != 0 && to.GetNumericPrecision()-to.GetNumericScale() < integerDigits[typeSizes[typ1]]
+	case fam1 == FamilyInteger && fam2 == FamilyFloat:
+		return typeSizes[typ1] >= typeSizes[typ2]
+	case fam1 == FamilyDate && fam2 == FamilyTimestamp:
+		return false
+	case fam1 != FamilyUnknown && fam1 == fam2:
+		return false
+	}
+	return typ1 != typ2
+}
+
+// shorter checks if length limit l1 is stricter than l2, where zero means no limit.
+func shorter(l1, l2 int) bool {
+	if l1 == VarcharLenMax || l1 == 0 {
+		return false
+	}
+	return l2 == 0 || l2 == VarcharLenMax || l1 < l2
+}
+
+// Generation types of identity columns.
+const (
+	IdentityByDefault = "BY DEFAULT"
+	IdentityAlways    = "ALWAYS"
+)
+
+// IdentityOptions define how the values of an identity column are generated.
+type IdentityOptions struct {
+	// Generation is IdentityAlways or IdentityByDefault. Empty generation means IdentityByDefault.
+	Generation string
+
+	// Start and Increment are the options of the column's sequence. Zero means the database default.
+	Start     int64
+	Increment int64
+}
+
+// GetGeneration returns the generation type, defaulting to IdentityByDefault.
+func (o IdentityOptions) GetGeneration() string {
+	if o.Generation == "" {
+		return IdentityByDefault
+	}
+	return strings.ToUpper(o.Generation)
+}
+
+// Equals checks that identity columns generate their values the same way.
+// Sequence options set to zero in either of them are not compared, as their values are chosen by the database.
+func (o IdentityOptions) Equals(other IdentityOptions) bool {
+	return o.GetGeneration() == other.GetGeneration() &&
+		equalOrDefault(o.Start, other.Start) && equalOrDefault(o.Increment, other.Increment)
+}
+
 // BaseColumn is a base column definition that stores various attributes of a column.
 //
 // Dialects and only dialects can use it to implement the Column interface.
 // Other packages must use the Column interface.
 type BaseColumn struct {
-	Name            string
-	SQLType         string
-	VarcharLen      int
-	DefaultValue    string
-	IsNullable      bool
-	IsAutoIncrement bool
-	IsIdentity      bool
-	// TODO: add Precision and Cardinality for timestamps/bit-strings/floats and arrays respectively.
+	Name             string
+	SQLType          string
+	VarcharLen       int
+	NumericPrecision int
+	NumericScale     int
+	ArrayDims        int
+	DefaultValue     string
+	IsNullable       bool
+	IsAutoIncrement  bool
+	IsIdentity       bool
+	IdentityOptions  IdentityOptions
+	Comment          string
+	Collation        string
+	GeneratedExpr    string
+	GeneratedStored  bool
+	Sequence         string
+	Storage          string
+	// TODO: add Precision for timestamps and times, e.g. TIMESTAMP(3).
 }
 
 func (cd BaseColumn) GetName() string {
@@ -46,6 +216,23 @@ func (cd BaseColumn) GetVarcharLen() int {
 	return cd.VarcharLen
 }
 
+// GetNumericPrecision returns the total number of significant digits in a NUMERIC/DECIMAL column.
+// Zero precision means that the column was declared without one.
+func (cd BaseColumn) GetNumericPrecision() int {
+	return cd.NumericPrecision
+}
+
+// GetNumericScale returns the number of digits after the decimal point in a NUMERIC/DECIMAL column.
+func (cd BaseColumn) GetNumericScale() int {
+	return cd.NumericScale
+}
+
+// GetArrayDims returns the number of array dimensions, e.g. 2 for "text[][]".
+// SQLType of an array column is the type of its elements.
+func (cd BaseColumn) GetArrayDims() int {
+	return cd.ArrayDims
+}
+
 func (cd BaseColumn) GetDefaultValue() string {
 	return cd.DefaultValue
 }
@@ -62,14 +249,190 @@ func (cd BaseColumn) GetIsIdentity() bool {
 	return cd.IsIdentity
 }
 
+// GetIdentityOptions returns the generation type and sequence options of an identity column.
+func (cd BaseColumn) GetIdentityOptions() IdentityOptions {
+	return cd.IdentityOptions
+}
+
+func (cd BaseColumn) GetComment() string {
+	return cd.Comment
+}
+
+// GetCollation returns column's collation. Empty collation means "database default".
+func (cd BaseColumn) GetCollation() string {
+	return cd.Collation
+}
+
+// GetGeneratedExpr returns the expression for a generated (computed) column, or an empty string for regular columns.
+func (cd BaseColumn) GetGeneratedExpr() string {
+	return cd.GeneratedExpr
+}
+
+// GetGeneratedStored returns true if the generated column's values are computed on write and stored in the table,
+// rather than computed on read.
+func (cd BaseColumn) GetGeneratedStored() bool {
+	return cd.GeneratedStored
+}
+
+// GetSequence returns the name of the sequence that generates the values of an auto-increment or identity column.
+// It is only reported by the database inspectors, and is empty if the column does not own a sequence.
+func (cd BaseColumn) GetSequence() string {
+	return cd.Sequence
+}
+
+// GetStorage returns the storage mode of the column, i.e. "PLAIN", "EXTERNAL", "EXTENDED", or "MAIN".
+// Empty storage means the default storage of the column's type, which database inspectors also report as empty.
+func (cd BaseColumn) GetStorage() string {
+	return cd.Storage
+}
+
+// Equal checks that two columns have the same definition, see EqualColumns.
+func (cd BaseColumn) Equal(other Column, options ...EqualOption) bool {
+	return EqualColumns(&cd, other, options...)
+}
+
+// EqualColumns checks that two columns have the same definition: type, nullability, default value,
+// auto-increment and identity settings, generated expression, comment, and collation.
+// Column names are not compared. Generated columns cannot have a default value and their nullability
+// is determined by the expression, so neither is compared if either column is generated.
+// Comments are compared with EqualComments, the same way the migrations detect comment changes.
+//
+// By default, types must be spelled the same, see NormalizeType. Pass the dialect's CompareType
+// with WithTypeComparison to treat type aliases, e.g. "int8" and "bigint", as the same type.
+func EqualColumns(col1, col2 Column, options ...EqualOption) bool {
+	cfg := equalConfig{compareType: equalTypes}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	if !cfg.compareType(col1, col2) ||
+		col1.GetIsAutoIncrement() != col2.GetIsAutoIncrement() ||
+		col1.GetIsIdentity() != col2.GetIsIdentity() ||
+		col1.GetIsIdentity() && !col1.GetIdentityOptions().Equals(col2.GetIdentityOptions()) {
+		return false
+	}
+	if !cfg.ignoreComments && !EqualComments(col1.GetComment(), col2.GetComment()) {
+		return false
+	}
+	if !cfg.ignoreCollation && !strings.EqualFold(col1.GetCollation(), col2.GetCollation()) {
+		return false
+	}
+
+	if col1.GetGeneratedExpr() != "" || col2.GetGeneratedExpr() != "" {
+		return NormalizeExpr(col1.GetGeneratedExpr()) == NormalizeExpr(col2.GetGeneratedExpr()) &&
+			col1.GetGeneratedStored() == col2.GetGeneratedStored()
+	}
+	return (cfg.ignoreDefaults || cfg.equalDefaults(col1.GetDefaultValue(), col2.GetDefaultValue())) &&
+		(cfg.ignoreNullability || col1.GetIsNullable() == col2.GetIsNullable())
+}
+
+// EqualComments compares two comments ignoring the differences in whitespace.
+func EqualComments(c1, c2 string) bool {
+	return strings.Join(strings.Fields(c1), " ") == strings.Join(strings.Fields(c2), " ")
+}
+
+// EqualOption changes which attributes of the columns are compared in EqualColumns and how.
+type EqualOption func(*equalConfig)
+
+type equalConfig struct {
+	compareType       func(Column, Column) bool
+	normalizeDefault  func(string) string
+	ignoreDefaults    bool
+	ignoreNullability bool
+	ignoreComments    bool
+	ignoreCollation   bool
+}
+
+// WithTypeComparison compares the column types with the function, typically InspectorDialect.CompareType.
+func WithTypeComparison(compareType func(Column, Column) bool) EqualOption {
+	return func(cfg *equalConfig) {
+		cfg.compareType = compareType
+	}
+}
+
+// WithDefaultNormalization makes the default values equal if they are the same after normalization,
+// e.g. with DefaultNormalizer.NormalizeDefault, even if they are spelled differently.
+// A nil function leaves the comparison unchanged.
+func WithDefaultNormalization(normalize func(string) string) EqualOption {
+	return func(cfg *equalConfig) {
+		cfg.normalizeDefault = normalize
+	}
+}
+
+// IgnoreDefaults makes the columns equal regardless of their default values.
+func IgnoreDefaults() EqualOption {
+	return func(cfg *equalConfig) {
+		cfg.ignoreDefaults = true
+	}
+}
+
+// IgnoreNullability makes the columns equal regardless of whether they are nullable.
+func IgnoreNullability() EqualOption {
+	return func(cfg *equalConfig) {
+		cfg.ignoreNullability = true
+	}
+}
+
+// IgnoreComments makes the columns equal regardless of their comments.
+func IgnoreComments() EqualOption {
+	return func(cfg *equalConfig) {
+		cfg.ignoreComments = true
+	}
+}
+
+// IgnoreCollation makes the columns equal regardless of their collation.
+func IgnoreCollation() EqualOption {
+	return func(cfg *equalConfig) {
+		cfg.ignoreCollation = true
+	}
+}
+
+func (cfg equalConfig) equalDefaults(def1, def2 string) bool {
+	if def1 == def2 {
+		return true
+	}
+	if cfg.normalizeDefault == nil || def1 == "" || def2 == "" {
+		return false
+	}
+	return cfg.normalizeDefault(def1) == cfg.normalizeDefault(def2)
+}
+
+// equalTypes checks that the columns have the same type, length, precision, and array dimensions.
+func equalTypes(col1, col2 Column) bool {
+	return NormalizeType(col1.GetSQLType()) == NormalizeType(col2.GetSQLType()) &&
+		col1.GetVarcharLen() == col2.GetVarcharLen() &&
+		col1.GetNumericPrecision() == col2.GetNumericPrecision() &&
+		col1.GetNumericScale() == col2.GetNumericScale() &&
+		col1.GetArrayDims() == col2.GetArrayDims()
+}
+
 // AppendQuery appends full SQL data type.
+// The length modifier of types like TIMESTAMP WITH TIME ZONE goes before the time zone clause.
 func (c *BaseColumn) AppendQuery(fmter schema.Formatter, b []byte) (_ []byte, err error) {
-	b = append(b, c.SQLType...)
-	if c.VarcharLen == 0 {
-		return b, nil
+	typ, suffix := c.SQLType, ""
+	if i := strings.Index(strings.ToUpper(typ), " WITH"); i != -1 {
+		typ, suffix = typ[:i], typ[i:]
+	}
+	b = append(b, typ...)
+	switch {
+	case c.NumericPrecision != 0:
+		b = append(b, "("...)
+		b = append(b, fmt.Sprint(c.NumericPrecision)...)
+		if c.NumericScale != 0 {
+			b = append(b, ","...)
+			b = append(b, fmt.Sprint(c.NumericScale)...)
+		}
+		b = append(b, ")"...)
+	case c.VarcharLen == VarcharLenMax:
+		b = append(b, "(MAX)"...)
+	case c.VarcharLen != 0:
+		b = append(b, "("...)
+		b = append(b, fmt.Sprint(c.VarcharLen)...)
+		b = append(b, ")"...)
+	}
+	b = append(b, suffix...)
+	for i := 0; i < c.ArrayDims; i++ {
+		b = append(b, "[]"...)
 	}
-	b = append(b, "("...)
-	b = append(b, fmt.Sprint(c.VarcharLen)...)
-	b = append(b, ")"...)
 	return b, nil
 }
@@ -1,6 +1,7 @@
 package sqlschema
 
 import (
+	"cmp"
 	"slices"
 	"strings"
 
@@ -11,6 +12,20 @@ import (
 type Database interface {
 	GetTables() *ordered.Map[string, Table]
 	GetForeignKeys() map[ForeignKey]string
+	GetEnums() map[string][]string
+	GetIndexes() []Index
+	GetViews() []View
+	GetSequences() []Sequence
+	GetTriggers() []Trigger
+	GetDomains() []Domain
+	GetCompositeTypes() []CompositeType
+	GetExtensions() []Extension
+	GetForeignTables() []ForeignTable
+	GetPolicies() []Policy
+	GetGrants() []Grant
+
+	// Table looks up the table by its schema and name, see BaseDatabase.Table.
+	Table(schemaName, name string) (Table, bool)
 }
 
 var _ Database = (*BaseDatabase)(nil)
@@ -22,19 +37,202 @@ var _ Database = (*BaseDatabase)(nil)
 type BaseDatabase struct {
 	Tables      *ordered.Map[string, Table]
 	ForeignKeys map[ForeignKey]string
+
+	// Enums maps the names of the enumerated types to their values in the sort order.
+	Enums map[string][]string
+
+	// Indexes lists secondary indexes on all tables. Indexes that back PRIMARY KEY and UNIQUE constraints
+	// are not included, as those are managed through the constraints.
+	Indexes []Index
+
+	// Views defined in the inspected schemas.
+	Views []View
+
+	// Sequences defined in the inspected schemas, including the ones that back serial and identity columns.
+	Sequences []Sequence
+
+	// Triggers defined on the inspected tables. Internal triggers, e.g. the ones that enforce foreign keys, are not included.
+	Triggers []Trigger
+
+	// Domains are user-defined types based on another type, which may add constraints to it.
+	Domains []Domain
+
+	// CompositeTypes are user-defined row types, which columns can use to store structured values.
+	CompositeTypes []CompositeType
+
+	// Extensions installed in the database, e.g. citext or pgcrypto.
+	Extensions []Extension
+
+	// ForeignTables are only inspected with WithIncludeForeignTables, see ForeignTable.
+	ForeignTables []ForeignTable
+
+	// Policies are the row-level security policies defined on the inspected tables.
+	Policies []Policy
+
+	// Grants are only inspected with WithIncludeGrants, see Grant.
+	Grants []Grant
 }
 
 func (ds BaseDatabase) GetTables() *ordered.Map[string, Table] {
 	return ds.Tables
 }
 
+// Table looks up the table by its schema and name. Tables in the default schema are stored
+// under their plain name (see InspectorConfig.TableKey), so they are found both with an empty schemaName
+// and with the name of the schema they belong to. Unlike GetTables().Load, the caller does not
+// need to know which schema is the default one.
+func (ds BaseDatabase) Table(schemaName, name string) (Table, bool) {
+	return lookupTable(ds.Tables, schemaName, name)
+}
+
+func lookupTable(tables *ordered.Map[string, Table], schemaName, name string) (Table, bool) {
+	if tables == nil {
+		return nil, false
+	}
+	if schemaName != "" {
+		if t, ok := tables.Load(schemaName + "." + name); ok {
+			return t, true
+		}
+	}
+	t, ok := tables.Load(name)
+	if !ok || (schemaName != "" && t.GetSchema() != "" && t.GetSchema() != schemaName) {
+		return nil, false
+	}
+	return t, true
+}
+
 func (ds BaseDatabase) GetForeignKeys() map[ForeignKey]string {
 	return ds.ForeignKeys
 }
 
+func (ds BaseDatabase) GetEnums() map[string][]string {
+	return ds.Enums
+}
+
+func (ds BaseDatabase) GetIndexes() []Index {
+	return ds.Indexes
+}
+
+func (ds BaseDatabase) GetViews() []View {
+	return ds.Views
+}
+
+func (ds BaseDatabase) GetSequences() []Sequence {
+	return ds.Sequences
+}
+
+func (ds BaseDatabase) GetTriggers() []Trigger {
+	return ds.Triggers
+}
+
+func (ds BaseDatabase) GetDomains() []Domain {
+	return ds.Domains
+}
+
+func (ds BaseDatabase) GetCompositeTypes() []CompositeType {
+	return ds.CompositeTypes
+}
+
+func (ds BaseDatabase) GetExtensions() []Extension {
+	return ds.Extensions
+}
+
+func (ds BaseDatabase) GetForeignTables() []ForeignTable {
+	return ds.ForeignTables
+}
+
+func (ds BaseDatabase) GetPolicies() []Policy {
+	return ds.Policies
+}
+
+func (ds BaseDatabase) GetGrants() []Grant {
+	return ds.Grants
+}
+
+// EnumType is implemented by Go types that represent an enumerated type in the database.
+// The name of the enum is the SQL type set with the "type:" option in the field's tag, e.g.:
+//
+//	type Mood string
+//
+//	func (Mood) EnumValues() []string { return []string{"sad", "ok", "happy"} }
+//
+//	type Person struct {
+//		Mood Mood `bun:",type:mood"`
+//	}
+type EnumType interface {
+	EnumValues() []string
+}
+
 type ForeignKey struct {
 	From ColumnReference
 	To   ColumnReference
+
+	// OnDelete and OnUpdate are the referential actions performed when the referenced row is deleted or updated.
+	OnDelete ReferentialAction
+	OnUpdate ReferentialAction
+
+	// Match defines how NULL values in a multi-column foreign key are matched against the referenced columns.
+	Match MatchType
+
+	// Deferrable constraints may be checked at the end of the transaction rather than after each statement.
+	// InitiallyDeferred constraints are checked at the end of the transaction by default.
+	Deferrable        bool
+	InitiallyDeferred bool
+}
+
+// ReferentialAction is an action performed on the referencing rows, when the referenced row is deleted or updated.
+// The default action is NO ACTION, which is represented by an empty string to keep ForeignKey values comparable.
+type ReferentialAction string
+
+const (
+	NoAction   ReferentialAction = ""
+	Restrict   ReferentialAction = "RESTRICT"
+	Cascade    ReferentialAction = "CASCADE"
+	SetNull    ReferentialAction = "SET NULL"
+	SetDefault ReferentialAction = "SET DEFAULT"
+)
+
+// NewReferentialAction parses the action's name, e.g. "cascade" or "NO ACTION", into a ReferentialAction.
+func NewReferentialAction(s string) ReferentialAction {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "NO ACTION" {
+		return NoAction
+	}
+	return ReferentialAction(s)
+}
+
+// MatchType of a foreign key. The default type is MATCH SIMPLE, which is represented by an empty string.
+type MatchType string
+
+const (
+	MatchSimple  MatchType = ""
+	MatchFull    MatchType = "FULL"
+	MatchPartial MatchType = "PARTIAL"
+)
+
+// NewMatchType parses the name of the match type, e.g. "full" or "SIMPLE", into a MatchType.
+func NewMatchType(s string) MatchType {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "SIMPLE" {
+		return MatchSimple
+	}
+	return MatchType(s)
+}
+
+// String returns the SQL name of the match type.
+func (m MatchType) String() string {
+	if m == MatchSimple {
+		return "SIMPLE"
+	}
+	return string(m)
+}
+
+// String returns the SQL name of the action.
+func (a ReferentialAction) String() string {
+	if a == NoAction {
+		return "NO ACTION"
+	}
+	return string(a)
 }
 
 func NewColumnReference(tableName string, columns ...string) ColumnReference {
@@ -44,6 +242,195 @@ func NewColumnReference(tableName string, columns ...string) ColumnReference {
 	}
 }
 
+// String returns a readable form of the foreign key for logs and test failures,
+// e.g. "orders(user_id) -> users(id) ON DELETE CASCADE". Default options are omitted.
+func (fk ForeignKey) String() string {
+	var b strings.Builder
+	b.WriteString(fk.From.String())
+	b.WriteString(" -> ")
+	b.WriteString(fk.To.String())
+	if fk.OnDelete != NoAction {
+		b.WriteString(" ON DELETE " + fk.OnDelete.String())
+	}
+	if fk.OnUpdate != NoAction {
+		b.WriteString(" ON UPDATE " + fk.OnUpdate.String())
+	}
+	if fk.Match != MatchSimple {
+		b.WriteString(" MATCH " + fk.Match.String())
+	}
+	if fk.Deferrable {
+		b.WriteString(" DEFERRABLE")
+	}
+	if fk.InitiallyDeferred {
+		b.WriteString(" INITIALLY DEFERRED")
+	}
+	return b.String()
+}
+
+// Canonical returns a copy of the foreign key with its column pairs sorted by the referencing column.
+// Composite foreign keys which list their columns in a different order, but map them to the same
+// referenced columns, have identical canonical forms and can be compared with ==.
+func (fk ForeignKey) Canonical() ForeignKey {
+	from, to := fk.From.Column.Split(), fk.To.Column.Split()
+	if len(from) != len(to) {
+		return fk
+	}
+
+	pairs := make([][2]string, len(from))
+	for i := range from {
+		pairs[i] = [2]string{from[i], to[i]}
+	}
+	slices.SortStableFunc(pairs, func(a, b [2]string) int {
+		return strings.Compare(a[0], b[0])
+	})
+	for i, p := range pairs {
+		from[i], to[i] = p[0], p[1]
+	}
+
+	fk.From.Column = NewColumns(from...)
+	fk.To.Column = NewColumns(to...)
+	return fk
+}
+
+// Compare orders foreign keys by the referencing and then by the referenced columns,
+// so that a set of foreign keys can be listed in a stable order.
+func (fk ForeignKey) Compare(other ForeignKey) int {
+	return cmp.Or(
+		strings.Compare(fk.From.TableName, other.From.TableName),
+		strings.Compare(string(fk.From.Column), string(other.From.Column)),
+		strings.Compare(fk.To.TableName, other.To.TableName),
+		strings.Compare(string(fk.To.Column), string(other.To.Column)),
+		strings.Compare(string(fk.OnDelete), string(other.OnDelete)),
+		strings.Compare(string(fk.OnUpdate), string(other.OnUpdate)),
+		strings.Compare(string(fk.Match), string(other.Match)),
+		compareBool(fk.Deferrable, other.Deferrable),
+		compareBool(fk.InitiallyDeferred, other.InitiallyDeferred),
+	)
+}
+
+// SortedForeignKeys returns the foreign keys in the order defined by ForeignKey.Compare.
+func SortedForeignKeys(fks map[ForeignKey]string) []ForeignKey {
+	sorted := make([]ForeignKey, 0, len(fks))
+	for fk := range fks {
+		sorted = append(sorted, fk)
+	}
+	slices.SortFunc(sorted, ForeignKey.Compare)
+	return sorted
+}
+
+// CreationOrder returns the table keys in the order in which the tables can be created with their foreign keys:
+// the referenced tables come before the ones that reference them, and independent tables are sorted by name.
+// Parent tables, see BaseTable.Inherits, come before the tables that inherit from them.
+// Foreign keys which form a cycle, i.e. connect tables that reference each other directly or indirectly,
+// cannot be satisfied by any order. They are returned separately, sorted, and must be added after
+// all of the tables are created, e.g. with ALTER TABLE ADD CONSTRAINT. Self-references are not deferred,
+// since a table can reference itself in CREATE TABLE. References to tables outside of the state are ignored.
+func CreationOrder(db Database) (tables []string, deferred []ForeignKey) {
+	keys := db.GetTables().Keys()
+	slices.Sort(keys)
+	exists := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		exists[key] = true
+	}
+
+	// Parent tables are created before the tables that inherit from them, like the referenced ones.
+	refs := make(map[string][]string, len(keys))
+	for _, key := range keys {
+		for _, parent := range db.GetTables().Value(key).GetInherits() {
+			if exists[parent] {
+				refs[key] = append(refs[key], parent)
+			}
+		}
+	}
+	for _, fk := range SortedForeignKeys(db.GetForeignKeys()) {
+		from, to := fk.From.TableName, fk.To.TableName
+		if from != to && exists[from] && exists[to] && !slices.Contains(refs[from], to) {
+			refs[from] = append(refs[from], to)
+		}
+	}
+
+	component := stronglyConnected(keys, refs)
+	for _, fk := range SortedForeignKeys(db.GetForeignKeys()) {
+		from, to := fk.From.TableName, fk.To.TableName
+		if from != to && exists[from] && exists[to] && component[from] == component[to] {
+			deferred = append(deferred, fk)
+		}
+	}
+
+	// Emit the first table, by name, whose referenced tables are already created, except those in the same cycle.
+	created := make(map[string]bool, len(keys))
+	for len(tables) < len(keys) {
+		for _, key := range keys {
+			if created[key] || slices.ContainsFunc(refs[key], func(ref string) bool {
+				return !created[ref] && component[ref] != component[key]
+			}) {
+				continue
+			}
+			created[key] = true
+			tables = append(tables, key)
+			break
+		}
+	}
+	return tables, deferred
+}
+
+// stronglyConnected assigns each table to its strongly connected component in the reference graph,
+// so that two tables are in the same component if and only if they reference each other, see CreationOrder.
+func stronglyConnected(keys []string, refs map[string][]string) map[string]int {
+	var (
+		index     = make(map[string]int, len(keys))
+		lowlink   = make(map[string]int, len(keys))
+		onStack   = make(map[string]bool, len(keys))
+		stack     []string
+		component = make(map[string]int, len(keys))
+		visit     func(key string)
+	)
+	visit = func(key string) {
+		index[key] = len(index)
+		lowlink[key] = index[key]
+		stack = append(stack, key)
+		onStack[key] = true
+
+		for _, ref := range refs[key] {
+			if _, visited := index[ref]; !visited {
+				visit(ref)
+				lowlink[key] = min(lowlink[key], lowlink[ref])
+			} else if onStack[ref] {
+				lowlink[key] = min(lowlink[key], index[ref])
+			}
+		}
+
+		if lowlink[key] == index[key] {
+			id := len(component)
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component[top] = id
+				if top == key {
+					break
+				}
+			}
+		}
+	}
+	for _, key := range keys {
+		if _, visited := index[key]; !visited {
+			visit(key)
+		}
+	}
+	return component
+}
+
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	}
+	return -1
+}
+
 func (fk ForeignKey) DependsOnTable(tableName string) bool {
 	return fk.From.TableName == tableName || fk.To.TableName == tableName
 }
@@ -58,8 +445,8 @@ func (fk ForeignKey) DependsOnColumn(tableName string, column string) bool {
 type Columns string
 
 // NewColumns creates a composite column from a slice of column names.
+// The order of the columns is preserved, as it is significant for constraints and indexes.
 func NewColumns(columns ...string) Columns {
-	slices.Sort(columns)
 	return Columns(strings.Join(columns, ","))
 }
 
@@ -67,8 +454,16 @@ func (c *Columns) String() string {
 	return string(*c)
 }
 
+// AppendQuery appends the column names separated by commas, each quoted as an identifier of the dialect,
+// so that mixed-case names and reserved words, e.g. "order" or `select`, can be used in constraints.
 func (c *Columns) AppendQuery(fmter schema.Formatter, b []byte) ([]byte, error) {
-	return schema.Safe(*c).AppendQuery(fmter, b)
+	for i, name := range c.Split() {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = fmter.AppendName(b, name)
+	}
+	return b, nil
 }
 
 // Split returns a slice of column names that make up the composite.
@@ -121,7 +516,550 @@ func (u Unique) Equals(other Unique) bool {
 	return u.Columns == other.Columns
 }
 
+// DefaultIndexMethod is the access method used for indexes that do not specify one.
+const DefaultIndexMethod = "btree"
+
+// Index represents a secondary index defined on 1 or more columns or expressions of a table.
+type Index struct {
+	Name      string
+	TableName string
+	Columns   []IndexColumn
+	Unique    bool
+
+	// Where is the predicate of a partial index. Empty for indexes that cover all rows.
+	Where string
+
+	// Include lists non-key columns stored in a covering index, e.g. "CREATE INDEX ... INCLUDE (a, b)".
+	Include []string
+
+	// Method is the index access method, e.g. "btree", "hash", or "gin". Empty method means "btree".
+	// Methods are compared case-insensitively, so "BTREE", "HASH", and "FULLTEXT" reported by MySQL are also valid.
+	Method string
+
+	// Tablespace the index is stored in. Empty tablespace means the database's default one.
+	// It is not compared by Equals, as moving an index to another tablespace does not require recreating it.
+	Tablespace string
+
+	// StorageParameters are set with "CREATE INDEX ... WITH (...)" in the "name=value" form of pg_class.reloptions,
+	// e.g. "pages_per_range=32" for a BRIN index or "fillfactor=70" for a B-tree.
+	StorageParameters []string
+}
+
+// Equals checks that two indexes are defined on the same columns of the same table in the same order.
+// Index names are not compared, so that renamed indexes are not recreated.
+// Partial indexes are only equal if their predicates are; an index whose predicate has changed must be recreated.
+// The same goes for indexes that use different access methods, include different non-key columns,
+// or set different storage parameters, which, like pages_per_range of BRIN indexes, often cannot be altered in place.
+func (i Index) Equals(other Index) bool {
+	return i.TableName == other.TableName && i.Unique == other.Unique &&
+		slices.EqualFunc(i.Columns, other.Columns, IndexColumn.Equals) &&
+		slices.Equal(i.Include, other.Include) &&
+		NormalizeExpr(i.Where) == NormalizeExpr(other.Where) &&
+		i.GetMethod() == other.GetMethod() &&
+		slices.Equal(i.NormalizedStorageParameters(), other.NormalizedStorageParameters())
+}
+
+// NormalizedStorageParameters returns the storage parameters sorted by name, with lowercased names
+// and no whitespace around "=", e.g. "pages_per_range = 32" becomes "pages_per_range=32".
+func (i Index) NormalizedStorageParameters() []string {
+	if len(i.StorageParameters) == 0 {
+		return nil
+	}
+	params := make([]string, len(i.StorageParameters))
+	for k, param := range i.StorageParameters {
+		name, value, _ := strings.Cut(param, "=")
+		params[k] = strings.ToLower(strings.TrimSpace(name)) + "=" + strings.TrimSpace(value)
+	}
+	slices.Sort(params)
+	return params
+}
+
+// GetMethod returns the lowercased name of the index access method, defaulting to "btree".
+func (i Index) GetMethod() string {
+	if i.Method == "" {
+		return DefaultIndexMethod
+	}
+	return strings.ToLower(i.Method)
+}
+
+// IndexColumn is a part of the index key, which is either a table column or an expression, e.g. "lower(email)".
+type IndexColumn struct {
+	Name       string
+	Expression string
+
+	// OpClass is the operator class of the key part, e.g. "jsonb_path_ops" for a GIN index on a jsonb column.
+	// Empty OpClass means the default operator class of the column type, which inspectors never report.
+	// Operator classes are compared case-insensitively and without the schema, so "public.gin_trgm_ops" equals "gin_trgm_ops".
+	OpClass string
+}
+
+// NewIndexColumns creates index key parts from a list of column names.
+func NewIndexColumns(columns ...string) []IndexColumn {
+	parts := make([]IndexColumn, 0, len(columns))
+	for _, name := range columns {
+		parts = append(parts, IndexColumn{Name: name})
+	}
+	return parts
+}
+
+// Equals checks that two index parts reference the same column or compute the same expression
+// with the same operator class.
+func (c IndexColumn) Equals(other IndexColumn) bool {
+	return c.Name == other.Name && NormalizeExpr(c.Expression) == NormalizeExpr(other.Expression) &&
+		c.NormalizedOpClass() == other.NormalizedOpClass()
+}
+
+// NormalizedOpClass returns the lowercased operator class without its schema.
+func (c IndexColumn) NormalizedOpClass() string {
+	opClass := strings.ToLower(c.OpClass)
+	if i := strings.LastIndexByte(opClass, '.'); i >= 0 {
+		return opClass[i+1:]
+	}
+	return opClass
+}
+
+// String returns the column name or the expression of the index part.
+func (c IndexColumn) String() string {
+	if c.Expression != "" {
+		return c.Expression
+	}
+	return c.Name
+}
+
+// IndexDefiner is implemented by models which declare indexes that cannot be expressed with
+// the "index" and "unique_index" tag options, e.g. partial indexes:
+//
+//	func (*User) Indexes() []sqlschema.Index {
+//		return []sqlschema.Index{
+//			{Name: "active_users", Columns: sqlschema.NewIndexColumns("email"), Where: "deleted_at IS NULL"},
+//			{Name: "users_email_lower_idx", Columns: []sqlschema.IndexColumn{{Expression: "lower(email)"}}},
+//			{Name: "users_settings_idx", Columns: []sqlschema.IndexColumn{{Name: "settings", OpClass: "jsonb_path_ops"}}, Method: "gin"},
+//		}
+//	}
+//
+// TableName defaults to the model's table. Unnamed indexes follow the naming convention "table_column1_column2_idx",
+// where expressions are represented by "expr".
+type IndexDefiner interface {
+	Indexes() []Index
+}
+
+// ExclusionDefiner is implemented by models which declare exclusion constraints, e.g.:
+//
+//	func (*Booking) Exclusions() []sqlschema.ExclusionConstraint {
+//		return []sqlschema.ExclusionConstraint{{
+//			Method:   "gist",
+//			Elements: []sqlschema.ExclusionElement{{Expression: "room", Operator: "="}, {Expression: "during", Operator: "&&"}},
+//		}}
+//	}
+//
+// Unnamed constraints follow the naming convention "table_column1_column2_excl", where expressions are represented by "expr".
+type ExclusionDefiner interface {
+	Exclusions() []ExclusionConstraint
+}
+
+// View is a named query stored in the database.
+type View struct {
+	// Schema of the view. Views registered with WithViews are placed in the inspector's SchemaName by default.
+	Schema     string
+	Name       string
+	Definition string
+
+	// Materialized views store the result of the query, which is only updated when the view is refreshed.
+	Materialized bool
+
+	// Populated reports whether a materialized view has been refreshed and can be queried.
+	// Registered materialized views are created WITH NO DATA unless Populated is set,
+	// in which case the views that are not populated in the database are refreshed.
+	Populated bool
+}
+
+// Equals checks that two views select the same data. Definitions are compared with NormalizeExpr,
+// but databases may rewrite the query significantly when they store the view, e.g. PostgreSQL qualifies
+// column names with their table. Define views the way the database reports them to avoid replacing them
+// on every migration. Whether a materialized view is populated is not compared.
+func (v View) Equals(other View) bool {
+	return v.Schema == other.Schema && v.Name == other.Name && v.Materialized == other.Materialized &&
+		normalizeViewDefinition(v.Definition) == normalizeViewDefinition(other.Definition)
+}
+
+// normalizeViewDefinition strips the trailing semicolon, which some databases keep in the stored query.
+func normalizeViewDefinition(def string) string {
+	return NormalizeExpr(strings.TrimSuffix(strings.TrimSpace(def), ";"))
+}
+
+// ForeignTable is a table whose rows are stored on a remote server and accessed through a foreign-data wrapper,
+// e.g. postgres_fdw. Foreign tables are owned by the wrapper rather than by the application, so they are kept
+// apart from the other tables and are never created, altered, or dropped by migrations.
+type ForeignTable struct {
+	Schema string
+	Name   string
+
+	// Server is the name of the foreign server which stores the rows.
+	Server string
+
+	// Options are passed to the foreign-data wrapper in the "name=value" form, e.g. "table_name=orders".
+	Options []string
+
+	Columns []*BaseColumn
+	Comment string
+}
+
+// Table returns the columns and the comment of the foreign table as a table definition,
+// e.g. to export it together with the other tables.
+func (ft ForeignTable) Table() *BaseTable {
+	columns := ordered.NewMap[string, Column]()
+	for _, col := range ft.Columns {
+		columns.Store(col.Name, col)
+	}
+	return &BaseTable{
+		Schema:  ft.Schema,
+		Name:    ft.Name,
+		Columns: columns,
+		Comment: ft.Comment,
+	}
+}
+
+// Sequence is a generator of integer values, which are typically used as default values of ID columns.
+type Sequence struct {
+	// Schema of the sequence. Sequences registered with WithSequences are placed in the inspector's SchemaName by default.
+	Schema string
+	Name   string
+
+	// Start, Increment, MinValue, and MaxValue are the parameters of the sequence.
+	// Zero values stand for the database defaults.
+	Start     int64
+	Increment int64
+	MinValue  int64
+	MaxValue  int64
+
+	// OwnedBy is the column the sequence belongs to, e.g. a serial or an identity column.
+	// Owned sequences are dropped together with their column. TableName is empty for standalone sequences.
+	OwnedBy ColumnReference
+}
+
+// Equals checks that two sequences have the same parameters and owner.
+// Parameters set to zero in either of the sequences are not compared, as their values
+// are chosen by the database. Sequence names are compared by the caller.
+func (s Sequence) Equals(other Sequence) bool {
+	return equalOrDefault(s.Start, other.Start) && equalOrDefault(s.Increment, other.Increment) &&
+		equalOrDefault(s.MinValue, other.MinValue) && equalOrDefault(s.MaxValue, other.MaxValue) &&
+		s.OwnedBy == other.OwnedBy
+}
+
+// IsOwned reports whether the sequence belongs to a column.
+func (s Sequence) IsOwned() bool {
+	return s.OwnedBy.TableName != ""
+}
+
+func equalOrDefault(a, b int64) bool {
+	return a == 0 || b == 0 || a == b
+}
+
+// Domain is a data type with optional constraints, which columns can use in place of its base type, e.g.:
+//
+//	CREATE DOMAIN email AS text CHECK (VALUE ~ '^.+@.+$')
+//
+// Columns refer to the domain by its Name in their SQL type.
+type Domain struct {
+	// Schema of the domain. Domains registered with WithDomains are placed in the inspector's SchemaName by default.
+	Schema string
+	Name   string
+
+	// DataType is the base type of the domain, including its modifiers, e.g. varchar(255).
+	// Database inspectors report it in the canonical form, e.g. PostgreSQL reports varchar(255)
+	// as character varying(255), so define the domains the same way to avoid reporting them as changed.
+	DataType string
+
+	NotNull bool
+	Default string
+
+	// Checks constrain the values of the domain. Their expressions refer to the value as VALUE.
+	Checks []Check
+}
+
+// Equals checks that two domains have the same base type and constraints.
+// Domain names are compared by the caller and the order of CHECK constraints does not matter.
+func (d Domain) Equals(other Domain) bool {
+	if normalizeDataType(d.DataType) != normalizeDataType(other.DataType) ||
+		d.NotNull != other.NotNull || NormalizeExpr(d.Default) != NormalizeExpr(other.Default) ||
+		len(d.Checks) != len(other.Checks) {
+		return false
+	}
+	for _, c := range d.Checks {
+		if !slices.ContainsFunc(other.Checks, c.Equals) {
+			return false
+		}
+	}
+	return true
+}
+
+// Extension is a package of types, functions, and operators installed in the database, e.g. uuid-ossp.
+type Extension struct {
+	Name string
+
+	// Schema the extension's objects are installed in. Empty schema means the database's default.
+	Schema string
+
+	// Version is the installed version reported by the database inspectors. For the required extensions,
+	// it is the version to install, empty meaning the default version.
+	Version string
+}
+
+// CompositeType is a row type with named attributes, e.g.:
+//
+//	CREATE TYPE address AS (street text, city text)
+//
+// Columns refer to the composite type by its Name in their SQL type.
+type CompositeType struct {
+	// Schema of the type. Types registered with WithCompositeTypes are placed in the inspector's SchemaName by default.
+	Schema string
+	Name   string
+
+	// Attributes of the type in their declared order.
+	Attributes []CompositeAttribute
+}
+
+// Attribute returns the attribute with the given name.
+func (ct CompositeType) Attribute(name string) (CompositeAttribute, bool) {
+	for _, attr := range ct.Attributes {
+		if attr.Name == name {
+			return attr, true
+		}
+	}
+	return CompositeAttribute{}, false
+}
+
+// CompositeAttribute is a field of a composite type.
+type CompositeAttribute struct {
+	Name string
+
+	// DataType of the attribute, including its modifiers. Like domain types, data types are
+	// reported by the database in their canonical form, e.g. character varying(255).
+	DataType string
+}
+
+// Equals checks that both attributes have the same type. Attribute names are compared by the caller.
+func (a CompositeAttribute) Equals(other CompositeAttribute) bool {
+	return normalizeDataType(a.DataType) == normalizeDataType(other.DataType)
+}
+
+func normalizeDataType(typ string) string {
+	return strings.ToLower(strings.Join(strings.Fields(typ), ""))
+}
+
+// Trigger runs a statement or a function when rows of the table are modified.
+type Trigger struct {
+	Name      string
+	TableName string
+
+	// Timing is one of "BEFORE", "AFTER", or "INSTEAD OF".
+	Timing string
+
+	// Events which fire the trigger: "INSERT", "UPDATE", "DELETE", or "TRUNCATE".
+	Events []string
+
+	// ForEachRow triggers fire once for every modified row, rather than once per statement.
+	ForEachRow bool
+
+	// Body is the action performed by the trigger, e.g. "EXECUTE FUNCTION set_updated_at()" in PostgreSQL,
+	// or the "BEGIN ... END" block in MySQL and SQLite.
+	Body string
+}
+
+// Equals checks that two triggers on the same table fire on the same events and perform the same action.
+// Bodies are compared with NormalizeExpr. Trigger names are compared by the caller.
+func (t Trigger) Equals(other Trigger) bool {
+	return t.TableName == other.TableName && t.ForEachRow == other.ForEachRow &&
+		strings.EqualFold(collapseSpaces(t.Timing), collapseSpaces(other.Timing)) &&
+		slices.Equal(t.normalizedEvents(), other.normalizedEvents()) &&
+		NormalizeExpr(t.Body) == NormalizeExpr(other.Body)
+}
+
+// normalizedEvents returns the upper-cased events in a stable order, as it is not significant.
+func (t Trigger) normalizedEvents() []string {
+	events := make([]string, len(t.Events))
+	for i, e := range t.Events {
+		events[i] = strings.ToUpper(strings.TrimSpace(e))
+	}
+	slices.Sort(events)
+	return events
+}
+
+// Policy is a row-level security policy, which limits the rows of the table that the commands can access.
+// Policies only apply to the tables with row-level security enabled, see RowSecurity.
+type Policy struct {
+	Name      string
+	TableName string
+
+	// Restrictive policies must all pass, while at least one of the permissive policies must pass.
+	// Policies are permissive unless Restrictive is set.
+	Restrictive bool
+
+	// Command is "ALL", "SELECT", "INSERT", "UPDATE", or "DELETE". An empty command stands for ALL.
+	Command string
+
+	// Roles the policy applies to. No roles stand for PUBLIC, i.e. all roles.
+	Roles []string
+
+	// Using filters the existing rows that the command can access,
+	// and WithCheck validates the rows that the command adds or updates.
+	Using     string
+	WithCheck string
+}
+
+// Equals checks that two policies apply to the same commands and roles and check the same conditions.
+// Expressions are compared with NormalizeExpr. Policy names are compared by the caller.
+func (p Policy) Equals(other Policy) bool {
+	return p.TableName == other.TableName && p.Restrictive == other.Restrictive &&
+		p.NormalizedCommand() == other.NormalizedCommand() &&
+		slices.Equal(p.NormalizedRoles(), other.NormalizedRoles()) &&
+		NormalizeExpr(p.Using) == NormalizeExpr(other.Using) &&
+		NormalizeExpr(p.WithCheck) == NormalizeExpr(other.WithCheck)
+}
+
+// NormalizedCommand returns the upper-cased command, or "ALL" if it is empty.
+func (p Policy) NormalizedCommand() string {
+	if cmd := strings.ToUpper(strings.TrimSpace(p.Command)); cmd != "" {
+		return cmd
+	}
+	return "ALL"
+}
+
+// NormalizedRoles returns the roles in a stable order, as it is not significant, or nil for PUBLIC.
+func (p Policy) NormalizedRoles() []string {
+	var roles []string
+	for _, role := range p.Roles {
+		if !strings.EqualFold(role, "public") {
+			roles = append(roles, role)
+		}
+	}
+	slices.Sort(roles)
+	return roles
+}
+
+// RowSecurity is the row-level security mode of a table.
+type RowSecurity struct {
+	// Enabled applies the policies to the table. Rows that no policy allows are not accessible,
+	// except to the owner of the table and to the roles that bypass row-level security.
+	Enabled bool
+
+	// Forced applies the policies to the owner of the table too.
+	Forced bool
+}
+
+// Grant is a privilege on a table, or on one of its columns, given to a role.
+// The grants of the table owner and of the system roles are not part of the schema state.
+type Grant struct {
+	TableName string
+
+	// Column limits the privilege to this column. The privilege applies to the whole table if it is empty.
+	Column string
+
+	// Grantee is the name of the role, or PUBLIC for all roles.
+	Grantee string
+
+	// Privilege is one of SELECT, INSERT, UPDATE, DELETE, TRUNCATE, REFERENCES, or TRIGGER.
+	// ALL is expanded to every privilege that applies to the table or the column, see Expand.
+	Privilege string
+}
+
+var (
+	tablePrivileges  = []string{"SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER"}
+	columnPrivileges = []string{"SELECT", "INSERT", "UPDATE", "REFERENCES"}
+)
+
+// Expand returns the grant with an upper-cased privilege, or one grant per privilege if it is ALL [PRIVILEGES].
+func (g Grant) Expand() []Grant {
+	g.Privilege = strings.ToUpper(collapseSpaces(g.Privilege))
+	if g.Privilege != "ALL" && g.Privilege != "ALL PRIVILEGES" {
+		return []Grant{g}
+	}
+	privileges := tablePrivileges
+	if g.Column != "" {
+		privileges = columnPrivileges
+	}
+	grants := make([]Grant, len(privileges))
+	for i, p := range privileges {
+		grants[i] = g
+		grants[i].Privilege = p
+	}
+	return grants
+}
+
+type Check struct {
+	Name       string
+	Expression string
+}
+
+// Equals checks that two CHECK constraints enforce the same condition, assuming both are defined for the same table.
+// Constraint names are not compared, because unnamed checks are assigned a name by the database when they are created.
+func (c Check) Equals(other Check) bool {
+	return NormalizeExpr(c.Expression) == NormalizeExpr(other.Expression)
+}
+
+// ExclusionConstraint guarantees that no two rows conflict, i.e. that for any two rows at least one of
+// the elements compared with its operator is false, e.g. EXCLUDE USING gist (room WITH =, during WITH &&)
+// prevents overlapping bookings of the same room. Only PostgreSQL supports exclusion constraints.
+type ExclusionConstraint struct {
+	Name string
+
+	// Method is the index access method that enforces the constraint, e.g. "gist". Empty method stands for btree.
+	Method string
+
+	Elements []ExclusionElement
+
+	// Where is the predicate of a partial constraint, which only applies to the rows that satisfy it.
+	Where string
+}
+
+// ExclusionElement is a column or an expression compared with the operator, e.g. "during" WITH "&&".
+type ExclusionElement struct {
+	Expression string
+	Operator   string
+}
+
+// Equals checks that two exclusion constraints prevent the same conflicts, assuming both are defined for the same table.
+// Like for checks, constraint names are not compared. Neither is the order of the elements,
+// as the rows conflict only if all of the elements do. Operators may be qualified, e.g. OPERATOR(pg_catalog.=).
+func (c ExclusionConstraint) Equals(other ExclusionConstraint) bool {
+	return c.NormalizedMethod() == other.NormalizedMethod() &&
+		slices.Equal(c.normalizedElements(), other.normalizedElements()) &&
+		NormalizeExpr(c.Where) == NormalizeExpr(other.Where)
+}
+
+// NormalizedMethod returns the lower-cased access method, or "btree" if it is empty.
+func (c ExclusionConstraint) NormalizedMethod() string {
+	if method := strings.ToLower(strings.TrimSpace(c.Method)); method != "" {
+		return method
+	}
+	return "btree"
+}
+
+func (c ExclusionConstraint) normalizedElements() []string {
+	elements := make([]string, len(c.Elements))
+	for i, el := range c.Elements {
+		elements[i] = NormalizeExpr(el.Expression) + " " + NormalizeOperator(el.Operator)
+	}
+	slices.Sort(elements)
+	return elements
+}
+
+// NormalizeOperator strips the OPERATOR() syntax and the pg_catalog schema from the operator,
+// e.g. "OPERATOR(pg_catalog.&&)" becomes "&&".
+func NormalizeOperator(op string) string {
+	op = strings.TrimSpace(op)
+	if inner, ok := strings.CutPrefix(strings.ToUpper(op), "OPERATOR("); ok && strings.HasSuffix(inner, ")") {
+		op = strings.TrimSpace(op[len("OPERATOR(") : len(op)-1])
+	}
+	return strings.TrimPrefix(op, "pg_catalog.")
+}
+
 type ColumnReference struct {
 	TableName string
 	Column    Columns
 }
+
+// String returns the table followed by the referenced columns, e.g. "public.orders(user_id)".
+func (r ColumnReference) String() string {
+	return r.TableName + "(" + strings.Join(r.Column.Split(), ", ") + ")"
+}
@@ -0,0 +1,112 @@
+package sqlschema
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// regexpPrefix marks table patterns that are regular expressions rather than globs.
+const regexpPrefix = "re:"
+
+// TableFilter matches tables against the patterns passed to WithIncludeTables and WithExcludeTables,
+// and their schemas against WithExcludeSchemas. Dialect inspectors should use it to skip excluded tables
+// before fetching their columns.
+type TableFilter struct {
+	include *tablePatterns // nil if all tables are included
+	exclude *tablePatterns
+	schemas map[string]bool
+}
+
+// NewTableFilter compiles the table patterns in the config and reports the first invalid one.
+// Dialects pass the names of their system schemas, which are always excluded.
+func NewTableFilter(cfg InspectorConfig, systemSchemas ...string) (*TableFilter, error) {
+	var f TableFilter
+	var err error
+	if len(cfg.IncludeTables) > 0 {
+		if f.include, err = newTablePatterns(cfg.IncludeTables); err != nil {
+			return nil, fmt.Errorf("include tables: %w", err)
+		}
+	}
+	if f.exclude, err = newTablePatterns(cfg.ExcludeTables); err != nil {
+		return nil, fmt.Errorf("exclude tables: %w", err)
+	}
+
+	f.schemas = make(map[string]bool, len(systemSchemas)+len(cfg.ExcludeSchemas))
+	for _, s := range systemSchemas {
+		f.schemas[strings.ToLower(s)] = true
+	}
+	for _, s := range cfg.ExcludeSchemas {
+		f.schemas[strings.ToLower(s)] = true
+	}
+	return &f, nil
+}
+
+// SchemaExcluded reports whether all tables in the schema should be skipped.
+func (f *TableFilter) SchemaExcluded(schemaName string) bool {
+	return f.schemas[strings.ToLower(schemaName)]
+}
+
+// Excluded reports whether the table should be skipped. A table is skipped if its schema is excluded,
+// if it matches any of the exclude patterns, or if include patterns are given and the table does not match any of them.
+func (f *TableFilter) Excluded(schemaName, tableName string) bool {
+	if f.SchemaExcluded(schemaName) || f.exclude.match(schemaName, tableName) {
+		return true
+	}
+	return f.include != nil && !f.include.match(schemaName, tableName)
+}
+
+// tablePatterns is a set of table names, glob patterns, and regular expressions.
+type tablePatterns struct {
+	names   map[string]bool
+	globs   []string
+	regexps []*regexp.Regexp
+}
+
+func newTablePatterns(patterns []string) (*tablePatterns, error) {
+	tp := &tablePatterns{names: make(map[string]bool)}
+	for _, p := range patterns {
+		switch {
+		case strings.HasPrefix(p, regexpPrefix):
+			re, err := regexp.Compile(strings.TrimPrefix(p, regexpPrefix))
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+			}
+			tp.regexps = append(tp.regexps, re)
+		case strings.ContainsAny(p, "*?["):
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+			}
+			tp.globs = append(tp.globs, p)
+		default:
+			tp.names[p] = true
+		}
+	}
+	return tp, nil
+}
+
+// match checks the table name and its schema-qualified name against all patterns.
+func (tp *tablePatterns) match(schemaName, tableName string) bool {
+	fqn := tableName
+	if schemaName != "" {
+		fqn = schemaName + "." + tableName
+	}
+	if tp.names[tableName] || tp.names[fqn] {
+		return true
+	}
+	for _, glob := range tp.globs {
+		if ok, _ := path.Match(glob, tableName); ok {
+			return true
+		}
+		if ok, _ := path.Match(glob, fqn); ok {
+			return true
+		}
+	}
+	for _, re := range tp.regexps {
+		if re.MatchString(tableName) || re.MatchString(fqn) {
+			return true
+		}
+	}
+	return false
+}
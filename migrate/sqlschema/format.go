@@ -0,0 +1,262 @@
+package sqlschema
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/uptrace/bun/schema"
+)
+
+// FormatDatabase renders the database schema in a readable form for logs and debugging, e.g.:
+//
+//	table books
+//	  column id bigint not null identity by default
+//	  column author_id bigint not null
+//	  primary key books_pkey (id)
+//	foreign key books_author_id_fkey: books(author_id) -> authors(id) ON DELETE CASCADE
+//	index books_author_id_idx on books (author_id)
+//
+// Foreign tables follow the other tables and are always qualified with their schema.
+// Row-level security policies and grants are listed after the indexes.
+// Tables, foreign keys, indexes, and enums are sorted, so the output does not depend on the order
+// they were inspected in, while columns keep the order in which they are defined in the table.
+// Unlike MarshalDatabase, the output is meant for humans and cannot be decoded.
+func FormatDatabase(db Database) string {
+	var b strings.Builder
+
+	keys := db.GetTables().Keys()
+	slices.Sort(keys)
+	for _, key := range keys {
+		formatTable(&b, key, db.GetTables().Value(key))
+	}
+
+	foreign := slices.Clone(db.GetForeignTables())
+	slices.SortFunc(foreign, func(a, b ForeignTable) int {
+		return strings.Compare(a.Schema+"."+a.Name, b.Schema+"."+b.Name)
+	})
+	for _, ft := range foreign {
+		formatForeignTable(&b, ft)
+	}
+
+	fks := db.GetForeignKeys()
+	for _, fk := range SortedForeignKeys(fks) {
+		if name := fks[fk]; name != "" {
+			fmt.Fprintf(&b, "foreign key %s: %s\n", name, fk)
+		} else {
+			fmt.Fprintf(&b, "foreign key %s\n", fk)
+		}
+	}
+
+	indexes := slices.Clone(db.GetIndexes())
+	slices.SortFunc(indexes, func(a, b Index) int {
+		if c := strings.Compare(a.TableName, b.TableName); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	for _, idx := range indexes {
+		formatIndex(&b, idx)
+	}
+
+	policies := slices.Clone(db.GetPolicies())
+	slices.SortFunc(policies, func(a, b Policy) int {
+		if c := strings.Compare(a.TableName, b.TableName); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	for _, p := range policies {
+		formatPolicy(&b, p)
+	}
+
+	var grants []string
+	for _, g := range db.GetGrants() {
+		on := g.TableName
+		if g.Column != "" {
+			on += "(" + g.Column + ")"
+		}
+		grants = append(grants, fmt.Sprintf("grant %s on %s to %s\n", strings.ToLower(g.Privilege), on, g.Grantee))
+	}
+	slices.Sort(grants)
+	for _, grant := range grants {
+		b.WriteString(grant)
+	}
+
+	enums := make([]string, 0, len(db.GetEnums()))
+	for name := range db.GetEnums() {
+		enums = append(enums, name)
+	}
+	slices.Sort(enums)
+	for _, name := range enums {
+		fmt.Fprintf(&b, "enum %s (%s)\n", name, strings.Join(db.GetEnums()[name], ", "))
+	}
+	return b.String()
+}
+
+// String returns the schema in a readable form, see FormatDatabase.
+func (ds BaseDatabase) String() string {
+	return FormatDatabase(ds)
+}
+
+func formatTable(b *strings.Builder, key string, t Table) {
+	fmt.Fprintf(b, "table %s\n", key)
+
+	for _, col := range t.GetColumns().Values() {
+		fmt.Fprintf(b, "  column %s %s\n", col.GetName(), formatColumn(col))
+	}
+	if pk := t.GetPrimaryKey(); pk != nil {
+		fmt.Fprintf(b, "  primary key %s\n", formatConstraint(pk.Name, pk.Columns.Split()))
+	}
+	for _, u := range t.GetUniqueConstraints() {
+		fmt.Fprintf(b, "  unique %s\n", formatConstraint(u.Name, u.Columns.Split()))
+	}
+	for _, check := range t.GetChecks() {
+		fmt.Fprintf(b, "  check %s\n", formatConstraint(check.Name, []string{check.Expression}))
+	}
+	for _, excl := range t.GetExclusions() {
+		elements := make([]string, len(excl.Elements))
+		for i, el := range excl.Elements {
+			elements[i] = el.Expression + " with " + el.Operator
+		}
+		fmt.Fprintf(b, "  exclude %s using %s", formatConstraint(excl.Name, elements), excl.NormalizedMethod())
+		if excl.Where != "" {
+			fmt.Fprintf(b, " where (%s)", excl.Where)
+		}
+		b.WriteByte('\n')
+	}
+	if p := t.GetPartitioning(); p != nil {
+		fmt.Fprintf(b, "  partition by %s (%s)\n", strings.ToLower(p.Strategy), p.Key)
+	}
+	if parent := t.GetPartitionOf(); parent != "" {
+		fmt.Fprintf(b, "  partition of %s\n", parent)
+	}
+	if parents := t.GetInherits(); len(parents) > 0 {
+		fmt.Fprintf(b, "  inherits %s\n", strings.Join(parents, ", "))
+	}
+	if ts := t.GetTablespace(); ts != "" {
+		fmt.Fprintf(b, "  tablespace %s\n", ts)
+	}
+	if rs := t.GetRowSecurity(); rs.Forced {
+		b.WriteString("  row level security forced\n")
+	} else if rs.Enabled {
+		b.WriteString("  row level security enabled\n")
+	}
+	if comment := t.GetComment(); comment != "" {
+		fmt.Fprintf(b, "  comment %s\n", strconv.Quote(comment))
+	}
+}
+
+func formatForeignTable(b *strings.Builder, ft ForeignTable) {
+	fmt.Fprintf(b, "foreign table %s.%s server %s\n", ft.Schema, ft.Name, ft.Server)
+	for _, col := range ft.Columns {
+		fmt.Fprintf(b, "  column %s %s\n", col.Name, formatColumn(col))
+	}
+	if len(ft.Options) > 0 {
+		fmt.Fprintf(b, "  options (%s)\n", strings.Join(ft.Options, ", "))
+	}
+	if ft.Comment != "" {
+		fmt.Fprintf(b, "  comment %s\n", strconv.Quote(ft.Comment))
+	}
+}
+
+// formatColumn returns the column's type followed by its flags, e.g. "varchar(100) not null default 'x'".
+func formatColumn(col Column) string {
+	typ := &BaseColumn{
+		SQLType:          col.GetSQLType(),
+		VarcharLen:       col.GetVarcharLen(),
+		NumericPrecision: col.GetNumericPrecision(),
+		NumericScale:     col.GetNumericScale(),
+		ArrayDims:        col.GetArrayDims(),
+	}
+	sqlType, _ := typ.AppendQuery(schema.NewNopFormatter(), nil)
+
+	parts := []string{strings.ToLower(string(sqlType))}
+	if !col.GetIsNullable() {
+		parts = append(parts, "not null")
+	}
+	if def := col.GetDefaultValue(); def != "" {
+		parts = append(parts, "default "+def)
+	}
+	if col.GetIsAutoIncrement() {
+		parts = append(parts, "autoincrement")
+	}
+	if col.GetIsIdentity() {
+		parts = append(parts, "identity "+strings.ToLower(col.GetIdentityOptions().GetGeneration()))
+	}
+	if expr := col.GetGeneratedExpr(); expr != "" {
+		if col.GetGeneratedStored() {
+			parts = append(parts, fmt.Sprintf("generated (%s) stored", expr))
+		} else {
+			parts = append(parts, fmt.Sprintf("generated (%s)", expr))
+		}
+	}
+	if collation := col.GetCollation(); collation != "" {
+		parts = append(parts, "collate "+collation)
+	}
+	if storage := col.GetStorage(); storage != "" {
+		parts = append(parts, "storage "+strings.ToLower(storage))
+	}
+	if comment := col.GetComment(); comment != "" {
+		parts = append(parts, "comment "+strconv.Quote(comment))
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatIndex(b *strings.Builder, idx Index) {
+	kind := "index"
+	if idx.Unique {
+		kind = "unique index"
+	}
+	parts := make([]string, len(idx.Columns))
+	for i, col := range idx.Columns {
+		parts[i] = cmp.Or(col.Expression, col.Name)
+		if col.OpClass != "" {
+			parts[i] += " " + col.NormalizedOpClass()
+		}
+	}
+	fmt.Fprintf(b, "%s %s on %s (%s)", kind, idx.Name, idx.TableName, strings.Join(parts, ", "))
+	if idx.Method != "" {
+		b.WriteString(" using " + strings.ToLower(idx.Method))
+	}
+	if len(idx.Include) > 0 {
+		fmt.Fprintf(b, " include (%s)", strings.Join(idx.Include, ", "))
+	}
+	if params := idx.NormalizedStorageParameters(); len(params) > 0 {
+		fmt.Fprintf(b, " with (%s)", strings.Join(params, ", "))
+	}
+	if idx.Tablespace != "" {
+		b.WriteString(" tablespace " + idx.Tablespace)
+	}
+	if idx.Where != "" {
+		b.WriteString(" where " + idx.Where)
+	}
+	b.WriteByte('\n')
+}
+
+func formatPolicy(b *strings.Builder, p Policy) {
+	fmt.Fprintf(b, "policy %s on %s for %s", p.Name, p.TableName, strings.ToLower(p.NormalizedCommand()))
+	if p.Restrictive {
+		b.WriteString(" restrictive")
+	}
+	if roles := p.NormalizedRoles(); len(roles) > 0 {
+		b.WriteString(" to " + strings.Join(roles, ", "))
+	}
+	if p.Using != "" {
+		fmt.Fprintf(b, " using (%s)", p.Using)
+	}
+	if p.WithCheck != "" {
+		fmt.Fprintf(b, " with check (%s)", p.WithCheck)
+	}
+	b.WriteByte('\n')
+}
+
+func formatConstraint(name string, columns []string) string {
+	list := "(" + strings.Join(columns, ", ") + ")"
+	if name == "" {
+		return list
+	}
+	return name + " " + list
+}
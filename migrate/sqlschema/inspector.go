@@ -1,10 +1,17 @@
 package sqlschema
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/internal/ordered"
@@ -27,6 +34,23 @@ type InspectorDialect interface {
 	CompareType(Column, Column) bool
 }
 
+// DefaultNormalizer is implemented by dialects whose databases report column defaults
+// in a different form than they were declared in, e.g. PostgreSQL adds type casts to literals.
+type DefaultNormalizer interface {
+	// NormalizeDefault returns the canonical form of a default value, as reported by the inspectors:
+	// string literals are unquoted and expressions are lowercased outside of the literals they contain.
+	// The result is only used to check if two defaults are equivalent and is never executed.
+	NormalizeDefault(expr string) string
+}
+
+// TypeExtensions is implemented by dialects whose column types may be provided by extensions,
+// e.g. citext in PostgreSQL. BunModelInspector adds the extensions the models need to the schema state,
+// so that they are installed before the tables which use them.
+type TypeExtensions interface {
+	// TypeExtension returns the name of the extension which provides the SQL type, or an empty string.
+	TypeExtension(sqlType string) string
+}
+
 // InspectorConfig controls the scope of migration by limiting the objects Inspector should return.
 // Inspectors SHOULD use the configuration directly instead of copying it, or MAY choose to embed it,
 // to make sure options are always applied correctly.
@@ -34,8 +58,73 @@ type InspectorConfig struct {
 	// SchemaName limits inspection to tables in a particular schema.
 	SchemaName string
 
-	// ExcludeTables from inspection.
+	// Schemas are inspected together with SchemaName. Tables outside of SchemaName
+	// are keyed by their qualified name, e.g. "shared.users".
+	Schemas []string
+
+	// Concurrency is the maximum number of inspection queries running at the same time.
+	// Queries are run one by one if it is 0 or 1.
+	Concurrency int
+
+	// IncludeTables limits inspection to the matching tables. All tables are included if empty.
+	IncludeTables []string
+
+	// ExcludeTables from inspection. Excluded tables are skipped even if they are included.
 	ExcludeTables []string
+
+	// ExcludeSchemas from inspection, in addition to the dialect's system schemas.
+	// Tables in these schemas are skipped, as well as the foreign keys that reference them.
+	ExcludeSchemas []string
+
+	// Views are added to the schema state by BunModelInspector, see WithViews.
+	// Database inspectors read the views from the database instead.
+	Views []View
+
+	// Sequences are added to the schema state by BunModelInspector, see WithSequences.
+	Sequences []Sequence
+
+	// Triggers are added to the schema state by BunModelInspector, see WithTriggers.
+	Triggers []Trigger
+
+	// Policies are added to the schema state by BunModelInspector, see WithPolicies.
+	Policies []Policy
+
+	// Grants are added to the schema state by BunModelInspector, see WithGrants.
+	// Database inspectors only read them if IncludeGrants is set.
+	Grants []Grant
+
+	// Domains are added to the schema state by BunModelInspector, see WithDomains.
+	Domains []Domain
+
+	// CompositeTypes are added to the schema state by BunModelInspector, see WithCompositeTypes.
+	CompositeTypes []CompositeType
+
+	// Extensions are added to the schema state by BunModelInspector, see WithExtensions.
+	Extensions []Extension
+
+	// TypeOverrides replace the SQL types BunModelInspector derives from the models, see WithTypeOverrides.
+	TypeOverrides []TypeOverride
+
+	// IncludeForeignTables adds the foreign tables to the schema state, see WithIncludeForeignTables.
+	IncludeForeignTables bool
+
+	// IncludeGrants adds the privileges on the inspected tables to the schema state, see WithIncludeGrants.
+	IncludeGrants bool
+}
+
+// TypeOverride maps model fields to a SQL type regardless of their "type" tag or the dialect's default.
+// A field is matched either by its Go type or by the table and column name.
+type TypeOverride struct {
+	// GoType matches the fields of this type, or a pointer to it, e.g. reflect.TypeOf(uuid.UUID{}).
+	GoType reflect.Type
+
+	// Table and Column match a single field, e.g. {Table: "users", Column: "id"}.
+	// Like in foreign keys, Table must be qualified with its schema if it is not in SchemaName.
+	Table  string
+	Column string
+
+	// SQLType is the full type of the column with an optional length, e.g. "uuid" or "varchar(36)".
+	SQLType string
 }
 
 // Inspector reads schema state.
@@ -49,7 +138,269 @@ func WithSchemaName(schemaName string) InspectorOption {
 	}
 }
 
+// WithConcurrency lets the inspector run up to n of its queries at the same time,
+// which reduces inspection time over a high-latency connection. The number of queries
+// in flight never exceeds the maximum number of open connections set for bun.DB.
+func WithConcurrency(n int) InspectorOption {
+	return func(cfg *InspectorConfig) {
+		cfg.Concurrency = n
+	}
+}
+
+// RunQueries calls each of the query functions, running up to Concurrency of them at the same time.
+// The functions must be independent of each other and should store their results in separate variables,
+// so that the results do not depend on the order in which the queries complete.
+// As soon as one of the queries fails, the context passed to the others is cancelled.
+// If several queries fail, the error of the first one in the argument list is returned.
+// No more queries are started once ctx is done, in which case ctx.Err() is returned.
+func (cfg InspectorConfig) RunQueries(ctx context.Context, db *bun.DB, queries ...func(context.Context) error) error {
+	limit := min(cfg.Concurrency, len(queries))
+	if maxConns := db.Stats().MaxOpenConnections; maxConns > 0 {
+		limit = min(limit, maxConns)
+	}
+	if limit <= 1 {
+		for _, query := range queries {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := query(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, len(queries))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, query := range queries {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			errs[i] = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if errs[i] = query(ctx); errs[i] != nil {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Prefer the error that caused the cancellation over the ones caused by it.
+	var first error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if first == nil {
+			first = err
+		}
+		if !errors.Is(err, context.Canceled) {
+			return err
+		}
+	}
+	return first
+}
+
+// WithSchemas inspects tables in additional schemas in the same pass, so that foreign keys
+// between tables in different schemas can be resolved. Like WithExcludeTables, it works in append-only mode.
+func WithSchemas(schemas ...string) InspectorOption {
+	return func(cfg *InspectorConfig) {
+		cfg.Schemas = append(cfg.Schemas, schemas...)
+	}
+}
+
+// WithViews registers the views the schema should have, which bun models cannot describe.
+// Views without a schema are placed in SchemaName. Like WithSchemas, it works in append-only mode.
+//
+//	sqlschema.NewBunModelInspector(tables, sqlschema.WithViews(sqlschema.View{
+//		Name:       "active_users",
+//		Definition: "SELECT id, email FROM users WHERE deleted_at IS NULL",
+//	}))
+func WithViews(views ...View) InspectorOption {
+	return func(cfg *InspectorConfig) {
+		cfg.Views = append(cfg.Views, views...)
+	}
+}
+
+// WithSequences registers standalone sequences the schema should have. Sequences without a schema
+// are placed in SchemaName. Sequences of serial and identity columns are created with their columns
+// and need not be registered. Like WithSchemas, it works in append-only mode.
+func WithSequences(sequences ...Sequence) InspectorOption {
+	return func(cfg *InspectorConfig) {
+		cfg.Sequences = append(cfg.Sequences, sequences...)
+	}
+}
+
+// WithDomains registers the domain types the schema should have. Domains without a schema
+// are placed in SchemaName. Like WithSchemas, it works in append-only mode.
+func WithDomains(domains ...Domain) InspectorOption {
+	return func(cfg *InspectorConfig) {
+		cfg.Domains = append(cfg.Domains, domains...)
+	}
+}
+
+// WithCompositeTypes registers the composite types the schema should have. Types without a schema
+// are placed in SchemaName. Like WithSchemas, it works in append-only mode.
+func WithCompositeTypes(types ...CompositeType) InspectorOption {
+	return func(cfg *InspectorConfig) {
+		cfg.CompositeTypes = append(cfg.CompositeTypes, types...)
+	}
+}
+
+// WithExtensions declares the extensions the schema requires. Unlike other objects,
+// extensions are never dropped, as they are shared by all schemas in the database.
+// Like WithSchemas, it works in append-only mode.
+func WithExtensions(extensions ...Extension) InspectorOption {
+	return func(cfg *InspectorConfig) {
+		cfg.Extensions = append(cfg.Extensions, extensions...)
+	}
+}
+
+// WithTypeOverrides replaces the SQL types of the matching model fields. Overrides for a particular column
+// take precedence over the ones for a Go type. Like WithSchemas, it works in append-only mode.
+//
+//	sqlschema.NewBunModelInspector(tables, sqlschema.WithTypeOverrides(
+//		sqlschema.TypeOverride{GoType: reflect.TypeOf(uuid.UUID{}), SQLType: "uuid"},
+//		sqlschema.TypeOverride{Table: "users", Column: "email", SQLType: "varchar(320)"},
+//	))
+func WithTypeOverrides(overrides ...TypeOverride) InspectorOption {
+	return func(cfg *InspectorConfig) {
+		cfg.TypeOverrides = append(cfg.TypeOverrides, overrides...)
+	}
+}
+
+// WithTriggers registers the triggers the schema should have. Like table names in foreign keys,
+// the trigger's TableName must be qualified with its schema if the table is not in SchemaName.
+// Like WithSchemas, it works in append-only mode.
+//
+//	sqlschema.NewBunModelInspector(tables, sqlschema.WithTriggers(sqlschema.Trigger{
+//		Name:       "users_updated_at",
+//		TableName:  "users",
+//		Timing:     "BEFORE",
+//		Events:     []string{"UPDATE"},
+//		ForEachRow: true,
+//		Body:       "EXECUTE FUNCTION set_updated_at()",
+//	}))
+func WithTriggers(triggers ...Trigger) InspectorOption {
+	return func(cfg *InspectorConfig) {
+		cfg.Triggers = append(cfg.Triggers, triggers...)
+	}
+}
+
+// WithPolicies registers the row-level security policies the schema should have. Like in WithTriggers,
+// the policy's TableName must be qualified with its schema if the table is not in SchemaName.
+// Row-level security must be enabled on the tables for their policies to apply, e.g. `bun:"table:documents,rls"`.
+// Like WithSchemas, it works in append-only mode.
+//
+//	sqlschema.NewBunModelInspector(tables, sqlschema.WithPolicies(sqlschema.Policy{
+//		Name:      "documents_owner",
+//		TableName: "documents",
+//		Using:     "owner_id = current_setting('app.user_id')::bigint",
+//	}))
+func WithPolicies(policies ...Policy) InspectorOption {
+	return func(cfg *InspectorConfig) {
+		cfg.Policies = append(cfg.Policies, policies...)
+	}
+}
+
+// WithGrants registers the privileges the roles should have on the tables. Like in WithTriggers,
+// the grant's TableName must be qualified with its schema if the table is not in SchemaName.
+// Like WithSchemas, it works in append-only mode.
+//
+//	sqlschema.NewBunModelInspector(tables, sqlschema.WithGrants(
+//		sqlschema.Grant{TableName: "documents", Grantee: "reader", Privilege: "SELECT"},
+//		sqlschema.Grant{TableName: "documents", Column: "title", Grantee: "editor", Privilege: "UPDATE"},
+//	))
+func WithGrants(grants ...Grant) InspectorOption {
+	return func(cfg *InspectorConfig) {
+		cfg.Grants = append(cfg.Grants, grants...)
+	}
+}
+
+// WithIncludeGrants makes database inspectors read the privileges granted on the inspected tables and their columns.
+// Many deployments manage access control separately, so grants are not inspected by default.
+func WithIncludeGrants() InspectorOption {
+	return func(cfg *InspectorConfig) {
+		cfg.IncludeGrants = true
+	}
+}
+
+// InspectedSchemas lists SchemaName followed by the additional Schemas, without duplicates.
+func (cfg InspectorConfig) InspectedSchemas() []string {
+	schemas := []string{cfg.SchemaName}
+	for _, s := range cfg.Schemas {
+		if !slices.Contains(schemas, s) {
+			schemas = append(schemas, s)
+		}
+	}
+	return schemas
+}
+
+// TableKey returns the name under which the table is stored in Database.GetTables()
+// and referenced by foreign keys and indexes. Tables in SchemaName keep their plain name,
+// while tables in other schemas are qualified with the schema name.
+func (cfg InspectorConfig) TableKey(schemaName, tableName string) string {
+	if schemaName == "" || schemaName == cfg.SchemaName {
+		return tableName
+	}
+	return schemaName + "." + tableName
+}
+
+// SplitTableKey splits the key created with TableKey into the schema and table name.
+// The schema is empty for tables in the default schema.
+func SplitTableKey(key string) (schemaName, tableName string) {
+	if i := strings.IndexByte(key, '.'); i != -1 {
+		return key[:i], key[i+1:]
+	}
+	return "", key
+}
+
+// WithExcludeSchemas skips all tables in the schemas, e.g. a schema managed by another application.
+// System schemas, like "pg_catalog" and "information_schema", are always excluded.
+// Schema names are case-insensitive. Like WithExcludeTables, it works in append-only mode.
+func WithExcludeSchemas(schemas ...string) InspectorOption {
+	return func(cfg *InspectorConfig) {
+		cfg.ExcludeSchemas = append(cfg.ExcludeSchemas, schemas...)
+	}
+}
+
+// WithIncludeTables restricts inspection to the tables matching one of the entries, which follow
+// the same format as in WithExcludeTables. Tables which are also excluded are not inspected.
+// Like WithExcludeTables, it works in append-only mode.
+func WithIncludeTables(tables ...string) InspectorOption {
+	return func(cfg *InspectorConfig) {
+		cfg.IncludeTables = append(cfg.IncludeTables, tables...)
+	}
+}
+
+// WithIncludeForeignTables adds the foreign tables in the inspected schemas to the schema state,
+// e.g. to document them with ExportDBML. They are skipped by default, as migrations do not manage them.
+// Foreign tables are filtered like the other tables, see WithExcludeTables.
+func WithIncludeForeignTables() InspectorOption {
+	return func(cfg *InspectorConfig) {
+		cfg.IncludeForeignTables = true
+	}
+}
+
 // WithExcludeTables works in append-only mode, i.e. tables cannot be re-included.
+//
+// Each entry is either a table name, a glob pattern like "temp_*", or a regular expression prefixed with "re:",
+// e.g. "re:^django_(session|migrations)$". Entries are matched against both the table name and its
+// schema-qualified name, so that "audit.*" excludes all tables in the "audit" schema.
 func WithExcludeTables(tables ...string) InspectorOption {
 	return func(cfg *InspectorConfig) {
 		cfg.ExcludeTables = append(cfg.ExcludeTables, tables...)
@@ -101,34 +452,95 @@ func (bmi *BunModelInspector) Inspect(ctx context.Context) (Database, error) {
 	state := BunModelSchema{
 		BaseDatabase: BaseDatabase{
 			ForeignKeys: make(map[ForeignKey]string),
+			Enums:       make(map[string][]string),
 		},
 		Tables: ordered.NewMap[string, Table](),
 	}
-	for _, t := range bmi.tables.All() {
-		if t.Schema != bmi.SchemaName {
+	// The models are registered in a sync.Map, so they are sorted to inspect them in the same order every time.
+	tables := bmi.tables.All()
+	slices.SortFunc(tables, func(a, b *schema.Table) int {
+		return cmp.Or(strings.Compare(a.Schema, b.Schema), strings.Compare(a.Name, b.Name))
+	})
+	// modelColumns and modelChecks hold all columns and checks of the models, including the inherited ones.
+	modelColumns := make(map[string][]string)
+	modelChecks := make(map[string][]Check)
+	for _, t := range tables {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !slices.Contains(bmi.InspectedSchemas(), t.Schema) {
 			continue
 		}
 
+		tableName := strings.TrimPrefix(t.Name, t.Schema+".")
+		key := bmi.TableKey(t.Schema, tableName)
+
 		columns := ordered.NewMap[string, Column]()
+		var typeOverridden bool
+		renamedColumns := make(map[string]string)
+		backfills := make(map[string]string)
+		usingExprs := make(map[string]string)
 		for _, f := range t.Fields {
+			if oldName, ok := f.Tag.Option("rename_from"); ok && oldName != "" {
+				renamedColumns[f.Name] = oldName
+			}
+			if value, ok := f.Tag.Option("backfill"); ok && value != "" {
+				backfills[f.Name] = value
+			}
+			if expr, ok := f.Tag.Option("using"); ok && expr != "" {
+				usingExprs[f.Name] = expr
+			}
 
-			sqlType, length, err := parseLen(f.CreateTableSQLType)
+			sqlType := f.CreateTableSQLType
+			if override, ok := bmi.typeOverride(key, f); ok {
+				sqlType, typeOverridden = override, true
+			}
+			typ, err := parseSQLType(sqlType)
 			if err != nil {
-				return nil, fmt.Errorf("parse length in %q: %w", f.CreateTableSQLType, err)
+				return nil, err
+			}
+			if values, ok := enumValues(f.IndirectType); ok {
+				state.Enums[typ.SQLType] = values
+			}
+			comment, _ := f.Tag.Option("comment")
+			collation, _ := f.Tag.Option("collate")
+			generated, _ := f.Tag.Option("generated")
+			storage, _ := f.Tag.Option("storage")
+			var identity IdentityOptions
+			if f.Identity {
+				identity = IdentityOptions{Generation: IdentityByDefault, Start: f.IdentityStart, Increment: f.IdentityIncrement}
+				if f.IdentityAlways {
+					identity.Generation = IdentityAlways
+				}
 			}
 			columns.Store(f.Name, &BaseColumn{
-				Name:            f.Name,
-				SQLType:         strings.ToLower(sqlType), // TODO(dyma): maybe this is not necessary after Column.Eq()
-				VarcharLen:      length,
-				DefaultValue:    exprOrLiteral(f.SQLDefault),
-				IsNullable:      !f.NotNull,
-				IsAutoIncrement: f.AutoIncrement,
-				IsIdentity:      f.Identity,
+				Name:             f.Name,
+				SQLType:          typ.SQLType,
+				VarcharLen:       typ.VarcharLen,
+				NumericPrecision: typ.NumericPrecision,
+				NumericScale:     typ.NumericScale,
+				ArrayDims:        typ.ArrayDims,
+				DefaultValue:     exprOrLiteral(f.SQLDefault),
+				IsNullable:       !f.NotNull,
+				IsAutoIncrement:  f.AutoIncrement,
+				IsIdentity:       f.Identity,
+				IdentityOptions:  identity,
+				Comment:          comment,
+				Collation:        normalizeCollation(collation),
+				GeneratedExpr:    generated,
+				GeneratedStored:  generated != "" && f.Tag.HasOption("stored"),
+				Storage:          strings.ToUpper(storage),
 			})
 		}
 
 		var unique []Unique
-		for name, group := range t.Unique {
+		uniqueNames := make([]string, 0, len(t.Unique))
+		for name := range t.Unique {
+			uniqueNames = append(uniqueNames, name)
+		}
+		slices.Sort(uniqueNames)
+		for _, name := range uniqueNames {
+			group := t.Unique[name]
 			// Create a separate unique index for single-column unique constraints
 			//  let each dialect apply the default naming convention.
 			if name == "" {
@@ -146,6 +558,13 @@ func (bmi *BunModelInspector) Inspect(ctx context.Context) (Database, error) {
 			unique = append(unique, Unique{Name: name, Columns: NewColumns(columns...)})
 		}
 
+		var checks []Check
+		for _, f := range t.Fields {
+			for _, expr := range f.Tag.Options["check"] {
+				checks = append(checks, Check{Expression: expr})
+			}
+		}
+
 		var pk *PrimaryKey
 		if len(t.PKs) > 0 {
 			var columns []string
@@ -161,16 +580,41 @@ func (bmi *BunModelInspector) Inspect(ctx context.Context) (Database, error) {
 		// 	type Model struct { bun.BaseModel `bun:"table:favourite.books` }
 		// produces
 		// 	schema.Table{ Schema: "favourite", Name: "favourite.books" }
-		tableName := strings.TrimPrefix(t.Name, t.Schema+".")
-		state.Tables.Store(tableName, &BunTable{
+		state.Indexes = append(state.Indexes, modelIndexes(t, key, tableName)...)
+
+		var parents []string
+		for _, parent := range t.Inherits {
+			parentSchema, parentName := SplitTableKey(parent)
+			parents = append(parents, bmi.TableKey(cmp.Or(parentSchema, t.Schema), parentName))
+		}
+		modelColumns[key] = columns.Keys()
+		modelChecks[key] = checks
+
+		var renamedFrom string
+		if t.RenamedFrom != "" {
+			renamedFrom = bmi.TableKey(t.Schema, strings.TrimPrefix(t.RenamedFrom, t.Schema+"."))
+		}
+		state.Tables.Store(key, &BunTable{
 			BaseTable: BaseTable{
 				Schema:            t.Schema,
 				Name:              tableName,
 				Columns:           columns,
 				UniqueConstraints: unique,
 				PrimaryKey:        pk,
+				Checks:            checks,
+				Exclusions:        modelExclusions(t, tableName),
+				Inherits:          parents,
+				RowSecurity:       RowSecurity{Enabled: t.RowSecurity, Forced: t.ForceRowSecurity},
+				Tablespace:        t.Tablespace,
+				Comment:           t.Comment,
 			},
-			Model: t.ZeroIface,
+			Model:          t.ZeroIface,
+			ModelName:      t.Type.PkgPath() + "." + t.Type.Name(),
+			RenamedFrom:    renamedFrom,
+			RenamedColumns: renamedColumns,
+			Backfills:      backfills,
+			UsingExprs:     usingExprs,
+			TypeOverridden: typeOverridden,
 		})
 
 		for _, rel := range t.Relations {
@@ -181,6 +625,7 @@ func (bmi *BunModelInspector) Inspect(ctx context.Context) (Database, error) {
 				continue
 			}
 
+			// BasePKs and JoinPKs are position-aligned: the i-th base field references the i-th join field.
 			var fromCols, toCols []string
 			for _, f := range rel.BasePKs {
 				fromCols = append(fromCols, f.Name)
@@ -190,36 +635,678 @@ func (bmi *BunModelInspector) Inspect(ctx context.Context) (Database, error) {
 			}
 
 			target := rel.JoinTable
-			state.ForeignKeys[ForeignKey{
-				From: NewColumnReference(t.Name, fromCols...),
-				To:   NewColumnReference(target.Name, toCols...),
-			}] = ""
+			matchType, _ := rel.Field.Tag.Option("match")
+			fk := ForeignKey{
+				From:     NewColumnReference(key, fromCols...),
+				To:       NewColumnReference(bmi.TableKey(target.Schema, strings.TrimPrefix(target.Name, target.Schema+".")), toCols...),
+				OnDelete: NewReferentialAction(strings.TrimPrefix(rel.OnDelete, "ON DELETE ")),
+				OnUpdate: NewReferentialAction(strings.TrimPrefix(rel.OnUpdate, "ON UPDATE ")),
+
+				Match:             NewMatchType(matchType),
+				Deferrable:        rel.Field.Tag.HasOption("deferrable") || rel.Field.Tag.HasOption("initially_deferred"),
+				InitiallyDeferred: rel.Field.Tag.HasOption("initially_deferred"),
+			}
+			state.ForeignKeys[fk.Canonical()] = defaultForeignKeyName(tableName, fromCols)
+		}
+	}
+
+	// Models usually embed the struct of the model they inherit from, but PostgreSQL creates the inherited
+	// columns and checks with the parent table, so the table itself only defines the rest of them.
+	for _, table := range state.Tables.Values() {
+		bunTable := table.(*BunTable)
+		for _, parent := range bunTable.Inherits {
+			for _, name := range modelColumns[parent] {
+				bunTable.Columns.Delete(name)
+			}
+			bunTable.Checks = slices.DeleteFunc(bunTable.Checks, func(check Check) bool {
+				return slices.Contains(modelChecks[parent], check)
+			})
+		}
+	}
+
+	for _, v := range bmi.Views {
+		if v.Schema == "" {
+			v.Schema = bmi.SchemaName
+		}
+		if slices.Contains(bmi.InspectedSchemas(), v.Schema) {
+			state.Views = append(state.Views, v)
+		}
+	}
+
+	state.Triggers = append(state.Triggers, bmi.Triggers...)
+	state.Policies = append(state.Policies, bmi.Policies...)
+	state.Grants = append(state.Grants, bmi.Grants...)
+	state.Extensions = append(state.Extensions, bmi.Extensions...)
+	if resolver, ok := bmi.tables.Dialect().(TypeExtensions); ok {
+		state.Extensions = appendTypeExtensions(state.Extensions, state.Tables, resolver)
+	}
+
+	for _, s := range bmi.Sequences {
+		if s.Schema == "" {
+			s.Schema = bmi.SchemaName
+		}
+		if slices.Contains(bmi.InspectedSchemas(), s.Schema) {
+			state.Sequences = append(state.Sequences, s)
+		}
+	}
+
+	for _, dom := range bmi.Domains {
+		if dom.Schema == "" {
+			dom.Schema = bmi.SchemaName
+		}
+		if slices.Contains(bmi.InspectedSchemas(), dom.Schema) {
+			state.Domains = append(state.Domains, dom)
+		}
+	}
+
+	for _, ct := range bmi.CompositeTypes {
+		if ct.Schema == "" {
+			ct.Schema = bmi.SchemaName
+		}
+		if slices.Contains(bmi.InspectedSchemas(), ct.Schema) {
+			state.CompositeTypes = append(state.CompositeTypes, ct)
 		}
 	}
 	return state, nil
 }
 
-func parseLen(typ string) (string, int, error) {
+// parseTypeArgs splits the type into its name and the integer arguments of its modifier,
+// e.g. "numeric(10,2)" -> ("numeric", [10 2]). The modifier is not necessarily at the end of the type,
+// e.g. for timestamp(3) with time zone, in which case the rest of the type is kept.
+// The "MAX" length of character and binary types is reported as VarcharLenMax.
+func parseTypeArgs(typ string) (string, []int, error) {
 	paren := strings.Index(typ, "(")
-	if paren == -1 {
-		return typ, 0, nil
+	closing := strings.Index(typ, ")")
+	if paren == -1 && closing == -1 {
+		return typ, nil, nil
+	}
+	if paren == -1 || closing < paren {
+		return typ, nil, fmt.Errorf("unbalanced parentheses")
+	}
+
+	name := strings.TrimSpace(typ[:paren]) + typ[closing+1:]
+	if strings.EqualFold(strings.TrimSpace(typ[paren+1:closing]), "max") {
+		return name, []int{VarcharLenMax}, nil
 	}
-	length, err := strconv.Atoi(typ[paren+1 : len(typ)-1])
+
+	var args []int
+	for _, arg := range strings.Split(typ[paren+1:closing], ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(arg))
+		if err != nil {
+			return typ, nil, fmt.Errorf("invalid type modifier %q", typ[paren:closing+1])
+		}
+		args = append(args, n)
+	}
+	return name, args, nil
+}
+
+// typeOverride returns the SQL type which replaces the type of the field in the table, if any.
+func (bmi *BunModelInspector) typeOverride(tableKey string, f *schema.Field) (string, bool) {
+	var byType string
+	for _, override := range bmi.TypeOverrides {
+		switch {
+		case override.Table != "" || override.Column != "":
+			if override.Table == tableKey && override.Column == f.Name {
+				return override.SQLType, true
+			}
+		case override.GoType != nil && byType == "":
+			if override.GoType == f.IndirectType || override.GoType == f.StructField.Type {
+				byType = override.SQLType
+			}
+		}
+	}
+	return byType, byType != ""
+}
+
+// appendTypeExtensions adds the extensions which provide the column types to the declared ones,
+// unless they are declared already, e.g. with a specific schema or version.
+func appendTypeExtensions(extensions []Extension, tables *ordered.Map[string, Table], resolver TypeExtensions) []Extension {
+	for _, t := range tables.Values() {
+		for _, col := range t.GetColumns().Values() {
+			name := resolver.TypeExtension(col.GetSQLType())
+			if name == "" || slices.ContainsFunc(extensions, func(ext Extension) bool { return ext.Name == name }) {
+				continue
+			}
+			extensions = append(extensions, Extension{Name: name})
+		}
+	}
+	return extensions
+}
+
+// modelIndexes collects indexes declared with "index" and "unique_index" tag options,
+// followed by the ones returned from the model's Indexes() method if it implements IndexDefiner.
+// Note, that "unique_index" creates a unique index, while "unique" declares a UNIQUE constraint.
+// Fields that specify the same index name are grouped into one multi-column index in the order of declaration.
+// Unnamed indexes are always single-column and follow the Postgres naming convention "table_column_idx".
+// Indexes reference the table by its key, while their names only include the table name.
+func modelIndexes(t *schema.Table, key, tableName string) []Index {
+	var indexes []Index
+	byName := make(map[string]int)
+	for _, f := range t.Fields {
+		for _, opt := range []string{"index", "unique_index"} {
+			for _, name := range f.Tag.Options[opt] {
+				if name == "" {
+					name = tableName + "_" + f.Name + "_idx"
+				}
+				if i, ok := byName[name]; ok {
+					indexes[i].Columns = append(indexes[i].Columns, IndexColumn{Name: f.Name})
+					continue
+				}
+				byName[name] = len(indexes)
+				indexes = append(indexes, Index{
+					Name:      name,
+					TableName: key,
+					Columns:   NewIndexColumns(f.Name),
+					Unique:    opt == "unique_index",
+				})
+			}
+		}
+	}
+
+	if definer, ok := t.ZeroIface.(IndexDefiner); ok {
+		for _, idx := range definer.Indexes() {
+			if idx.TableName == "" {
+				idx.TableName = key
+			}
+			if idx.Name == "" {
+				idx.Name = defaultIndexName(idx)
+			}
+			indexes = append(indexes, idx)
+		}
+	}
+	return indexes
+}
+
+// modelExclusions returns the exclusion constraints from the model's Exclusions() method if it implements ExclusionDefiner.
+func modelExclusions(t *schema.Table, tableName string) []ExclusionConstraint {
+	definer, ok := t.ZeroIface.(ExclusionDefiner)
+	if !ok {
+		return nil
+	}
+	var exclusions []ExclusionConstraint
+	for _, excl := range definer.Exclusions() {
+		if excl.Name == "" {
+			parts := []string{tableName}
+			for _, el := range excl.Elements {
+				if isFoldedIdent(el.Expression) {
+					parts = append(parts, el.Expression)
+				} else {
+					parts = append(parts, "expr")
+				}
+			}
+			excl.Name = strings.Join(append(parts, "excl"), "_")
+		}
+		exclusions = append(exclusions, excl)
+	}
+	return exclusions
+}
+
+// defaultForeignKeyName creates a name like "table_column1_column2_fkey" for the foreign key,
+// which is the name Postgres would give to an unnamed constraint.
+func defaultForeignKeyName(tableName string, columns []string) string {
+	return tableName + "_" + strings.Join(columns, "_") + "_fkey"
+}
+
+// defaultIndexName creates a name like "table_column1_column2_idx" for the index.
+func defaultIndexName(idx Index) string {
+	_, tableName := SplitTableKey(idx.TableName)
+	parts := []string{tableName}
+	for _, c := range idx.Columns {
+		if c.Expression != "" {
+			parts = append(parts, "expr")
+			continue
+		}
+		parts = append(parts, c.Name)
+	}
+	return strings.Join(append(parts, "idx"), "_")
+}
+
+var enumType = reflect.TypeFor[EnumType]()
+
+// enumValues returns the values of the enumerated type if typ, or the type of its elements, implements EnumType.
+func enumValues(typ reflect.Type) ([]string, bool) {
+	for typ != nil {
+		if typ.Kind() != reflect.Ptr {
+			switch {
+			case typ.Implements(enumType):
+				return reflect.Zero(typ).Interface().(EnumType).EnumValues(), true
+			case reflect.PointerTo(typ).Implements(enumType):
+				return reflect.New(typ).Interface().(EnumType).EnumValues(), true
+			}
+		}
+		switch typ.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Ptr:
+			typ = typ.Elem()
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// ParseSQLType splits the full SQL type, e.g. "VARCHAR(100)[]", into the fields of a column,
+// so that inspectors which read the types from SQL text report them like BunModelInspector does.
+func ParseSQLType(typ string) (BaseColumn, error) {
+	return parseSQLType(typ)
+}
+
+// parseSQLType splits the full SQL type into the type name, which is converted to lowercase,
+// its modifiers and array dimensions, e.g. "VARCHAR(100)[]" -> {SQLType: "varchar", VarcharLen: 100, ArrayDims: 1}.
+func parseSQLType(typ string) (BaseColumn, error) {
+	elemType, dims := parseArrayDims(typ)
+
+	sqlType, args, err := parseTypeArgs(elemType)
 	if err != nil {
-		return typ, 0, err
+		return BaseColumn{}, fmt.Errorf("parse length in %q: %w", typ, err)
+	}
+	col := BaseColumn{
+		SQLType:   strings.ToLower(collapseSpaces(sqlType)), // TODO(dyma): maybe this is not necessary after Column.Eq()
+		ArrayDims: dims,
 	}
-	return typ[:paren], length, nil
+
+	switch {
+	case len(args) == 0:
+	case isNumericType(sqlType):
+		if len(args) > 2 || slices.Contains(args, VarcharLenMax) {
+			return BaseColumn{}, fmt.Errorf("parse length in %q: numeric types only accept precision and scale", typ)
+		}
+		col.NumericPrecision = args[0]
+		if len(args) == 2 {
+			col.NumericScale = args[1]
+		}
+	case len(args) == 1:
+		col.VarcharLen = args[0]
+	default:
+		// Column has no fields for other multi-argument modifiers, e.g. MySQL's DOUBLE(10,2),
+		// so they remain part of the type and are compared as such.
+		col.SQLType = strings.ToLower(collapseSpaces(elemType))
+	}
+	return col, nil
+}
+
+// parseArrayDims strips array brackets from the type and returns the type of array elements
+// together with the number of dimensions, e.g. "text[][]" -> ("text", 2) and "int[3]" -> ("int", 1).
+func parseArrayDims(typ string) (string, int) {
+	var dims int
+	typ = strings.TrimSpace(typ)
+	for strings.HasSuffix(typ, "]") {
+		open := strings.LastIndex(typ, "[")
+		if open == -1 {
+			break
+		}
+		typ = strings.TrimSpace(typ[:open])
+		dims++
+	}
+	return typ, dims
 }
 
-// exprOrLiteral converts string to lowercase, if it does not contain a string literal 'lit'
-// and trims the surrounding '' otherwise.
+// isNumericType checks if the type is an exact numeric type, which accepts precision and scale modifiers.
+func isNumericType(typ string) bool {
+	if paren := strings.Index(typ, "("); paren != -1 {
+		typ = typ[:paren]
+	}
+	typ = strings.TrimSpace(typ)
+	return strings.EqualFold(typ, "numeric") || strings.EqualFold(typ, "decimal")
+}
+
+// exprOrLiteral trims the surrounding ” if the string is a literal 'lit' and lowercases it
+// outside of string literals otherwise, e.g. "NEXTVAL('Seq')" becomes "nextval('Seq')".
 // Use it to ensure that user-defined default values in the models are always comparable
 // to those returned by the database inspector, regardless of the case convention in individual drivers.
 func exprOrLiteral(s string) string {
 	if strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") {
 		return strings.Trim(s, "'")
 	}
-	return strings.ToLower(s)
+	return LowerExpr(s)
+}
+
+// normalizeCollation trims quotes from the collation name and treats "default" collation as an empty one,
+// which is how most database inspectors report columns that use the database's default collation.
+func normalizeCollation(s string) string {
+	s = strings.Trim(s, `"`)
+	if strings.EqualFold(s, "default") {
+		return ""
+	}
+	return s
+}
+
+// NormalizeExpr converts an SQL expression to a canonical form suitable for comparison.
+// The result is not a valid SQL expression and should only be used to check if two expressions are the same.
+// Outside of string literals, the expression is lowercased and stripped of type casts and redundant parentheses,
+// as databases often add them when they store expressions, e.g. "lower(email)" becomes "lower((email)::text)".
+// Parentheses that change the grouping are kept, so "(a OR b) AND c" and "a OR (b AND c)" stay different.
+// Whitespace is collapsed to a single space between words and dropped around operators and punctuation.
+// Quoted identifiers keep their case, as "userID" and userid are different columns,
+// but lose the quotes they do not need, so "email" and email are the same.
+func NormalizeExpr(s string) string {
+	var b strings.Builder
+	var inLiteral, space bool
+	write := func(s string) {
+		if space && b.Len() > 0 {
+			last, _ := utf8.DecodeLastRuneInString(b.String())
+			first, _ := utf8.DecodeRuneInString(s)
+			if isExprWordRune(last) && isExprWordRune(first) {
+				b.WriteByte(' ')
+			}
+		}
+		space = false
+		b.WriteString(s)
+	}
+
+	rs := []rune(s)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case r == '\'':
+			inLiteral = !inLiteral
+		case inLiteral:
+			b.WriteRune(r)
+			continue
+		case r == '"':
+			ident, next := readQuotedIdent(rs, i)
+			if isFoldedIdent(ident) {
+				write(ident)
+			} else {
+				write(string(rs[i:next]))
+			}
+			i = next - 1
+			continue
+		case r == ':' && i+1 < len(rs) && rs[i+1] == ':':
+			i = skipTypeCast(rs, i+2) - 1
+			continue
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		default:
+			r = unicode.ToLower(r)
+		}
+		write(string(r))
+	}
+	return stripRedundantParens(b.String())
+}
+
+// isExprWordRune reports whether the rune is part of a word, an identifier, or a literal in a normalized expression.
+func isExprWordRune(r rune) bool {
+	return r == '_' || r == '$' || r == '\'' || r == '"' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// stripRedundantParens removes the parentheses from a normalized expression that do not change its meaning:
+// those around the whole expression, around a single operand, e.g. "lower((email))",
+// and around the operands of AND and OR that bind tighter than their neighbors, e.g. "(a > 0) AND (b > 0)".
+func stripRedundantParens(s string) string {
+	rs := []rune(s)
+	for {
+		open, close := findRedundantParens(rs)
+		if open == -1 {
+			return string(rs)
+		}
+		rs = slices.Concat(rs[:open], unparen(rs, open), rs[open+1:close], unparen(rs, close), rs[close+1:])
+	}
+}
+
+// unparen returns what replaces the parenthesis at rs[i]: a space if it separates two words, e.g. "not(a)".
+func unparen(rs []rune, i int) []rune {
+	if i > 0 && i+1 < len(rs) && isExprWordRune(rs[i-1]) && isExprWordRune(rs[i+1]) {
+		return []rune{' '}
+	}
+	return nil
+}
+
+// findRedundantParens returns the positions of the first pair of redundant parentheses, or -1 if there are none.
+func findRedundantParens(rs []rune) (open, close int) {
+	var stack []int
+	var inLiteral, inIdent bool
+	for i, r := range rs {
+		switch {
+		case r == '\'' && !inIdent:
+			inLiteral = !inLiteral
+		case r == '"' && !inLiteral:
+			inIdent = !inIdent
+		case inLiteral || inIdent:
+		case r == '(':
+			stack = append(stack, i)
+		case r == ')' && len(stack) > 0:
+			open := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if isRedundantGroup(rs, open, i) {
+				return open, i
+			}
+		}
+	}
+	return -1, -1
+}
+
+// isRedundantGroup reports whether the parentheses at rs[open] and rs[close] can be removed,
+// judging by the operators at the top level of the group and the tokens on either side of it.
+func isRedundantGroup(rs []rune, open, close int) bool {
+	inner := rs[open+1 : close]
+	if len(inner) == 0 {
+		return false
+	}
+
+	var depth int
+	var inLiteral, inIdent, hasOr bool
+	var words []string
+	atom := true
+	for i := 0; i < len(inner); i++ {
+		r := inner[i]
+		switch {
+		case r == '\'' && !inIdent:
+			inLiteral = !inLiteral
+		case r == '"' && !inLiteral:
+			inIdent = !inIdent
+		case inLiteral || inIdent:
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			depth--
+		case depth > 0:
+		case r == ',':
+			return false // a row constructor or an argument list
+		case isExprWordRune(r):
+			j := i
+			for j < len(inner) && isExprWordRune(inner[j]) && inner[j] != '\'' && inner[j] != '"' {
+				j++
+			}
+			words = append(words, string(inner[i:j]))
+			i = j - 1
+		case r != '.':
+			atom = false
+		}
+	}
+	for i, word := range words {
+		switch {
+		case i == 0 && (word == "select" || word == "values" || word == "with"):
+			return false // a subquery
+		case word == "or":
+			hasOr = true
+		}
+	}
+	if len(words) > 1 {
+		atom = false
+	}
+
+	before, after := wordBefore(rs, open), wordAfter(rs, close)
+	if atom {
+		if open > 0 && isExprWordRune(rs[open-1]) && before != "and" && before != "or" && before != "not" {
+			return false // a function call or a keyword, e.g. "in (1)"
+		}
+		return close+1 == len(rs) || rs[close+1] != '.' && rs[close+1] != '['
+	}
+
+	// OR binds looser than any other operator, so its operands need the parentheses next to anything but OR.
+	allowed := func(word string) bool {
+		return word == "or" || !hasOr && word == "and"
+	}
+	switch {
+	case open == 0 || rs[open-1] == '(' || rs[open-1] == ',':
+	case !allowed(before):
+		return false
+	}
+	switch {
+	case close+1 == len(rs) || rs[close+1] == ')' || rs[close+1] == ',':
+	case !allowed(after):
+		return false
+	}
+	return true
+}
+
+// wordBefore returns the word that ends right before rs[i], if any.
+func wordBefore(rs []rune, i int) string {
+	j := i
+	for j > 0 && isExprWordRune(rs[j-1]) {
+		j--
+	}
+	return string(rs[j:i])
+}
+
+// wordAfter returns the word that starts right after rs[i], if any.
+func wordAfter(rs []rune, i int) string {
+	j := i + 1
+	for j < len(rs) && isExprWordRune(rs[j]) {
+		j++
+	}
+	return string(rs[i+1 : j])
+}
+
+// IsDefaultExpr reports whether the default value is an SQL expression rather than a string literal.
+// Inspectors strip the quotes from string literals, so expressions are told apart
+// by the parentheses of function calls and by SQL keywords, e.g. current_timestamp.
+func IsDefaultExpr(def string) bool {
+	return strings.ContainsRune(def, '(') || slices.Contains(sqlKeywordDefaults, strings.ToLower(def))
+}
+
+var sqlKeywordDefaults = []string{
+	"null", "current_timestamp", "current_date", "current_time", "localtimestamp", "localtime", "current_user",
+}
+
+// AppendDefault appends the default value of a column as an SQL expression. String literals, which inspectors report
+// without the quotes, are quoted again, while numbers, booleans, expressions, and quoted literals are appended as is.
+func AppendDefault(b []byte, def string) []byte {
+	if _, err := strconv.ParseFloat(def, 64); err == nil || IsDefaultExpr(def) || strings.HasPrefix(def, "'") {
+		return append(b, def...)
+	}
+	switch strings.ToLower(def) {
+	case "true", "false":
+		return append(b, def...)
+	}
+	b = append(b, '\'')
+	b = append(b, def...)
+	return append(b, '\'')
+}
+
+// LowerExpr converts an SQL expression to lowercase, preserving the contents of string literals
+// and quoted identifiers, e.g. "userID".
+func LowerExpr(s string) string {
+	var b strings.Builder
+	var inLiteral bool
+	rs := []rune(s)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case r == '\'':
+			inLiteral = !inLiteral
+		case inLiteral:
+		case r == '"':
+			_, next := readQuotedIdent(rs, i)
+			b.WriteString(string(rs[i:next]))
+			i = next - 1
+			continue
+		default:
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// readQuotedIdent reads the identifier in double quotes which starts at rs[start].
+// Doubled quotes inside are unescaped. It returns the identifier and the offset after the closing quote.
+func readQuotedIdent(rs []rune, start int) (ident string, next int) {
+	var b strings.Builder
+	for i := start + 1; i < len(rs); i++ {
+		if rs[i] != '"' {
+			b.WriteRune(rs[i])
+			continue
+		}
+		if i+1 < len(rs) && rs[i+1] == '"' {
+			b.WriteRune('"')
+			i++
+			continue
+		}
+		return b.String(), i + 1
+	}
+	return b.String(), len(rs)
+}
+
+// isFoldedIdent reports whether the identifier means the same with and without quotes,
+// i.e. it is in lower case and does not need to be quoted.
+func isFoldedIdent(ident string) bool {
+	if ident == "" {
+		return false
+	}
+	for i, r := range ident {
+		if r != '_' && !unicode.IsLower(r) && (i == 0 || !unicode.IsDigit(r) && r != '$') {
+			return false
+		}
+	}
+	return true
+}
+
+// StripTypeCasts removes PostgreSQL-style type casts outside of string literals,
+// e.g. "nextval('users_id_seq'::regclass)" becomes "nextval('users_id_seq')".
+func StripTypeCasts(s string) string {
+	var b strings.Builder
+	var inLiteral bool
+	rs := []rune(s)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case r == '\'':
+			inLiteral = !inLiteral
+		case inLiteral:
+		case r == ':' && i+1 < len(rs) && rs[i+1] == ':':
+			i = skipTypeCast(rs, i+2) - 1
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// skipTypeCast returns the position after the type name that starts at rs[i], including
+// multi-word types like "character varying", type modifiers "(10,2)" and array brackets.
+func skipTypeCast(rs []rune, i int) int {
+	isIdent := func(r rune) bool {
+		return r == '_' || r == '"' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	word := func(i int) int {
+		for i < len(rs) && isIdent(rs[i]) {
+			i++
+		}
+		return i
+	}
+
+	i = word(i)
+	for i < len(rs) && rs[i] == ' ' {
+		next := word(i + 1)
+		switch strings.ToLower(string(rs[i+1 : next])) {
+		case "varying", "precision", "with", "without", "time", "zone":
+			i = next
+			continue
+		}
+		break
+	}
+	if i < len(rs) && rs[i] == '(' {
+		for i < len(rs) && rs[i] != ')' {
+			i++
+		}
+		i++
+	}
+	for i+1 < len(rs) && rs[i] == '[' && rs[i+1] == ']' {
+		i += 2
+	}
+	return i
 }
 
 // BunModelSchema is the schema state derived from bun table models.
@@ -233,10 +1320,44 @@ func (ms BunModelSchema) GetTables() *ordered.Map[string, Table] {
 	return ms.Tables
 }
 
+func (ms BunModelSchema) Table(schemaName, name string) (Table, bool) {
+	return lookupTable(ms.Tables, schemaName, name)
+}
+
+// String returns the schema in a readable form, see FormatDatabase.
+func (ms BunModelSchema) String() string {
+	return FormatDatabase(ms)
+}
+
 // BunTable provides additional table metadata that is only accessible from scanning bun models.
 type BunTable struct {
 	BaseTable
 
 	// Model stores the zero interface to the underlying Go struct.
 	Model interface{}
+
+	// ModelName is the fully qualified name of the model's Go type, e.g. "example.com/app/models.User".
+	// Unlike Model, it is preserved in JSON snapshots.
+	ModelName string
+
+	// RenamedFrom is the previous name of the table, as declared with the "rename_from" tag option,
+	// e.g. `bun:"table:customers,rename_from:clients"`.
+	RenamedFrom string
+
+	// RenamedColumns maps new column names to their previous names,
+	// as declared with the "rename_from" tag option, e.g. `bun:"email,rename_from:login"`.
+	RenamedColumns map[string]string
+
+	// Backfills maps column names to the values that existing rows receive when the column is added
+	// or made NOT NULL, in place of NULL, as declared with the "backfill" tag option, e.g. `bun:"status,notnull,backfill:'active'"`.
+	// Unlike a default, the backfill value is not kept in the column definition.
+	Backfills map[string]string
+
+	// UsingExprs maps column names to the expressions which convert their values when the column type changes,
+	// as declared with the "using" tag option, e.g. `bun:"amount,type:integer,using:round(amount)::integer"`.
+	UsingExprs map[string]string
+
+	// TypeOverridden is true if the type of any column was replaced, see WithTypeOverrides.
+	// Such tables are created from their definition, as the model does not reflect the overridden types.
+	TypeOverridden bool
 }
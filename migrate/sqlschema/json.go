@@ -0,0 +1,223 @@
+package sqlschema
+
+import (
+	"encoding/json"
+
+	"github.com/uptrace/bun/internal/ordered"
+)
+
+// MarshalDatabase encodes the database schema as a JSON snapshot, which can be stored and later
+// decoded with UnmarshalDatabase to compare it with another schema without connecting to the database.
+//
+// Tables and columns keep their original order, while foreign keys are sorted, so that
+// encoding the same schema always produces the same snapshot. Bun models are identified
+// by the name of their Go type, as the type itself cannot be serialized.
+func MarshalDatabase(db Database) ([]byte, error) {
+	var snapshot databaseSnapshot
+
+	for _, pair := range db.GetTables().Pairs() {
+		t := pair.Value
+		ts := tableSnapshot{
+			Schema:            t.GetSchema(),
+			Name:              t.GetName(),
+			PrimaryKey:        t.GetPrimaryKey(),
+			UniqueConstraints: t.GetUniqueConstraints(),
+			Checks:            t.GetChecks(),
+			Exclusions:        t.GetExclusions(),
+			Partitioning:      t.GetPartitioning(),
+			PartitionOf:       t.GetPartitionOf(),
+			Inherits:          t.GetInherits(),
+			Tablespace:        t.GetTablespace(),
+			Comment:           t.GetComment(),
+		}
+		if pair.Key != ts.Name {
+			ts.Key = pair.Key
+		}
+		if rs := t.GetRowSecurity(); rs != (RowSecurity{}) {
+			ts.RowSecurity = &rs
+		}
+		if bunTable, ok := t.(*BunTable); ok {
+			ts.Model = bunTable.ModelName
+			ts.IsModel = true
+			ts.RenamedFrom = bunTable.RenamedFrom
+			ts.RenamedColumns = bunTable.RenamedColumns
+			ts.Backfills = bunTable.Backfills
+			ts.UsingExprs = bunTable.UsingExprs
+		}
+		for _, col := range t.GetColumns().Values() {
+			ts.Columns = append(ts.Columns, toBaseColumn(col))
+		}
+		snapshot.Tables = append(snapshot.Tables, ts)
+	}
+
+	if fks := db.GetForeignKeys(); fks != nil {
+		snapshot.ForeignKeys = make([]foreignKeySnapshot, 0, len(fks))
+		for _, fk := range SortedForeignKeys(fks) {
+			snapshot.ForeignKeys = append(snapshot.ForeignKeys, foreignKeySnapshot{ForeignKey: fk, Name: fks[fk]})
+		}
+	}
+
+	snapshot.Enums = db.GetEnums()
+	snapshot.Indexes = db.GetIndexes()
+	snapshot.Views = db.GetViews()
+	snapshot.Sequences = db.GetSequences()
+	snapshot.Triggers = db.GetTriggers()
+	snapshot.Domains = db.GetDomains()
+	snapshot.CompositeTypes = db.GetCompositeTypes()
+	snapshot.Extensions = db.GetExtensions()
+	snapshot.ForeignTables = db.GetForeignTables()
+	snapshot.Policies = db.GetPolicies()
+	snapshot.Grants = db.GetGrants()
+
+	return json.MarshalIndent(snapshot, "", "  ")
+}
+
+// UnmarshalDatabase decodes a JSON snapshot created with MarshalDatabase.
+// Tables derived from bun models are decoded as *BunTable with a nil Model,
+// so the resulting schema cannot be used to create these tables.
+func UnmarshalDatabase(b []byte) (Database, error) {
+	var snapshot databaseSnapshot
+	if err := json.Unmarshal(b, &snapshot); err != nil {
+		return nil, err
+	}
+
+	db := BaseDatabase{
+		Tables:    ordered.NewMap[string, Table](),
+		Enums:     snapshot.Enums,
+		Indexes:   snapshot.Indexes,
+		Views:     snapshot.Views,
+		Sequences: snapshot.Sequences,
+		Triggers:  snapshot.Triggers,
+		Domains:   snapshot.Domains,
+
+		CompositeTypes: snapshot.CompositeTypes,
+		Extensions:     snapshot.Extensions,
+		ForeignTables:  snapshot.ForeignTables,
+		Policies:       snapshot.Policies,
+		Grants:         snapshot.Grants,
+	}
+
+	for _, ts := range snapshot.Tables {
+		columns := ordered.NewMap[string, Column]()
+		for _, col := range ts.Columns {
+			columns.Store(col.Name, col)
+		}
+
+		key := ts.Name
+		if ts.Key != "" {
+			key = ts.Key
+		}
+		table := BaseTable{
+			Schema:            ts.Schema,
+			Name:              ts.Name,
+			Columns:           columns,
+			PrimaryKey:        ts.PrimaryKey,
+			UniqueConstraints: ts.UniqueConstraints,
+			Checks:            ts.Checks,
+			Exclusions:        ts.Exclusions,
+			Partitioning:      ts.Partitioning,
+			PartitionOf:       ts.PartitionOf,
+			Inherits:          ts.Inherits,
+			Tablespace:        ts.Tablespace,
+			Comment:           ts.Comment,
+		}
+		if ts.RowSecurity != nil {
+			table.RowSecurity = *ts.RowSecurity
+		}
+		if ts.IsModel {
+			db.Tables.Store(key, &BunTable{
+				BaseTable:      table,
+				ModelName:      ts.Model,
+				RenamedFrom:    ts.RenamedFrom,
+				RenamedColumns: ts.RenamedColumns,
+				Backfills:      ts.Backfills,
+				UsingExprs:     ts.UsingExprs,
+			})
+		} else {
+			db.Tables.Store(key, &table)
+		}
+	}
+
+	if snapshot.ForeignKeys != nil {
+		db.ForeignKeys = make(map[ForeignKey]string, len(snapshot.ForeignKeys))
+		for _, fk := range snapshot.ForeignKeys {
+			db.ForeignKeys[fk.ForeignKey] = fk.Name
+		}
+	}
+	return db, nil
+}
+
+// toBaseColumn copies the column definition, unless it already is a *BaseColumn.
+func toBaseColumn(col Column) *BaseColumn {
+	if base, ok := col.(*BaseColumn); ok {
+		return base
+	}
+	return &BaseColumn{
+		Name:             col.GetName(),
+		SQLType:          col.GetSQLType(),
+		VarcharLen:       col.GetVarcharLen(),
+		NumericPrecision: col.GetNumericPrecision(),
+		NumericScale:     col.GetNumericScale(),
+		ArrayDims:        col.GetArrayDims(),
+		DefaultValue:     col.GetDefaultValue(),
+		IsNullable:       col.GetIsNullable(),
+		IsAutoIncrement:  col.GetIsAutoIncrement(),
+		IsIdentity:       col.GetIsIdentity(),
+		IdentityOptions:  col.GetIdentityOptions(),
+		Comment:          col.GetComment(),
+		Collation:        col.GetCollation(),
+		GeneratedExpr:    col.GetGeneratedExpr(),
+		GeneratedStored:  col.GetGeneratedStored(),
+		Sequence:         col.GetSequence(),
+		Storage:          col.GetStorage(),
+	}
+}
+
+type databaseSnapshot struct {
+	Tables      []tableSnapshot
+	ForeignKeys []foreignKeySnapshot
+	Enums       map[string][]string
+	Indexes     []Index
+	Views       []View
+	Sequences   []Sequence
+	Triggers    []Trigger
+	Domains     []Domain `json:",omitempty"`
+
+	CompositeTypes []CompositeType `json:",omitempty"`
+	Extensions     []Extension     `json:",omitempty"`
+	ForeignTables  []ForeignTable  `json:",omitempty"`
+	Policies       []Policy        `json:",omitempty"`
+	Grants         []Grant         `json:",omitempty"`
+}
+
+type tableSnapshot struct {
+	Schema string
+	Name   string
+	// Key is only set for tables stored under their qualified name, see InspectorConfig.TableKey.
+	Key string `json:",omitempty"`
+
+	// IsModel is set for tables derived from bun models, and Model is the name of the model's Go type.
+	IsModel        bool              `json:",omitempty"`
+	Model          string            `json:",omitempty"`
+	RenamedFrom    string            `json:",omitempty"`
+	RenamedColumns map[string]string `json:",omitempty"`
+	Backfills      map[string]string `json:",omitempty"`
+	UsingExprs     map[string]string `json:",omitempty"`
+
+	Columns           []*BaseColumn
+	PrimaryKey        *PrimaryKey
+	UniqueConstraints []Unique
+	Checks            []Check
+	Exclusions        []ExclusionConstraint `json:",omitempty"`
+	Partitioning      *Partitioning         `json:",omitempty"`
+	PartitionOf       string                `json:",omitempty"`
+	Inherits          []string              `json:",omitempty"`
+	RowSecurity       *RowSecurity          `json:",omitempty"`
+	Tablespace        string                `json:",omitempty"`
+	Comment           string                `json:",omitempty"`
+}
+
+type foreignKeySnapshot struct {
+	ForeignKey
+	Name string
+}
@@ -1,7 +1,10 @@
 package sqlschema
 
 import (
+	"bytes"
+	"cmp"
 	"fmt"
+	"slices"
 
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/schema"
@@ -16,6 +19,27 @@ type Migrator interface {
 	AppendSQL(b []byte, operation interface{}) ([]byte, error)
 }
 
+// ColumnSequenceAppender is implemented by dialects which declare identity and auto-increment columns
+// in their own syntax. Without it, such columns are created as plain columns of their SQL type.
+type ColumnSequenceAppender interface {
+	// AppendColumnSequence appends the SQL type of the identity or auto-increment column together with
+	// the clause that generates its values, e.g. "bigserial" or "bigint GENERATED BY DEFAULT AS IDENTITY".
+	// If the clause declares the column as the PRIMARY KEY, e.g. "INTEGER PRIMARY KEY AUTOINCREMENT" in SQLite,
+	// the table's PRIMARY KEY constraint is not appended.
+	AppendColumnSequence(fmter schema.Formatter, b []byte, col Column) ([]byte, error)
+}
+
+// ForeignKeyInliner is implemented by dialects which cannot add foreign keys to an existing table,
+// so the foreign keys must be declared in the CREATE TABLE statement.
+type ForeignKeyInliner interface {
+	InlineForeignKeys() bool
+}
+
+// SchemaCreator is implemented by dialects which support creating schemas, i.e. namespaces for tables and types.
+type SchemaCreator interface {
+	AppendCreateSchema(fmter schema.Formatter, b []byte, schemaName string) []byte
+}
+
 // migrator is a dialect-agnostic wrapper for sqlschema.MigratorDialect.
 type migrator struct {
 	Migrator
@@ -40,8 +64,234 @@ func NewBaseMigrator(db *bun.DB) *BaseMigrator {
 	return &BaseMigrator{db: db}
 }
 
-func (m *BaseMigrator) AppendCreateTable(b []byte, model interface{}) ([]byte, error) {
-	return m.db.NewCreateTable().Model(model).AppendQuery(m.db.Formatter(), b)
+// CreateTableOption configures the CREATE TABLE statements appended by BaseMigrator.
+type CreateTableOption func(*createTableConfig)
+
+type createTableConfig struct {
+	ifNotExists bool
+}
+
+// WithIfNotExists creates the table with CREATE TABLE IF NOT EXISTS, which does nothing if a table
+// with the same name exists already, regardless of its definition.
+func WithIfNotExists() CreateTableOption {
+	return func(cfg *createTableConfig) {
+		cfg.ifNotExists = true
+	}
+}
+
+func (m *BaseMigrator) AppendCreateTable(b []byte, model interface{}, opts ...CreateTableOption) ([]byte, error) {
+	var cfg createTableConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	q := m.db.NewCreateTable().Model(model)
+	if cfg.ifNotExists {
+		q = q.IfNotExists()
+	}
+	return q.AppendQuery(m.db.Formatter(), b)
+}
+
+// AppendCreateTableDefinition creates a table from its definition rather than from a bun model.
+// Columns are created in their ordinal order with their types, collations, nullability, defaults,
+// and generated values, followed by the table's PRIMARY KEY, UNIQUE and CHECK constraints,
+// and by the INHERITS clause if the table inherits from other tables.
+// Like for bun models, foreign keys must be added separately, see AppendCreateTableWithForeignKeys.
+func (m *BaseMigrator) AppendCreateTableDefinition(b []byte, schemaName string, table Table, opts ...CreateTableOption) (_ []byte, err error) {
+	return m.AppendCreateTableWithForeignKeys(b, schemaName, table, nil, opts...)
+}
+
+// AppendCreateTableWithForeignKeys creates a table from its definition and declares the foreign keys
+// in the CREATE TABLE statement. The foreign keys map to their constraint names, like in Database.GetForeignKeys.
+// Unnamed constraints are named by the database. Constraints are appended in a stable order,
+// so the same definition always produces the same statement.
+func (m *BaseMigrator) AppendCreateTableWithForeignKeys(b []byte, schemaName string, table Table, fks map[ForeignKey]string, opts ...CreateTableOption) (_ []byte, err error) {
+	var cfg createTableConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	fmter := m.db.Formatter()
+
+	b = append(b, "CREATE TABLE "...)
+	if cfg.ifNotExists {
+		b = append(b, "IF NOT EXISTS "...)
+	}
+	b = fmter.AppendQuery(b, "?.?", bun.Ident(schemaName), bun.Ident(table.GetName()))
+	b = append(b, " ("...)
+
+	var inlinePK bool
+	for i, col := range table.GetColumns().Values() {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = fmter.AppendName(b, col.GetName())
+		b = append(b, " "...)
+		start := len(b)
+		if b, err = m.appendColumnDefinition(fmter, b, col); err != nil {
+			return nil, err
+		}
+		inlinePK = inlinePK || bytes.Contains(b[start:], []byte("PRIMARY KEY"))
+	}
+
+	if pk := table.GetPrimaryKey(); pk != nil && !inlinePK {
+		b = append(b, ", "...)
+		if pk.Name != "" {
+			b = fmter.AppendQuery(b, "CONSTRAINT ? ", bun.Ident(pk.Name))
+		}
+		b = append(b, "PRIMARY KEY ("...)
+		b = appendColumnNames(fmter, b, pk.Columns)
+		b = append(b, ")"...)
+	}
+
+	uniques := slices.Clone(table.GetUniqueConstraints())
+	slices.SortStableFunc(uniques, func(u1, u2 Unique) int {
+		return cmp.Or(cmp.Compare(u1.Name, u2.Name), cmp.Compare(u1.Columns.String(), u2.Columns.String()))
+	})
+	for _, unique := range uniques {
+		b = append(b, ", "...)
+		if unique.Name != "" {
+			b = fmter.AppendQuery(b, "CONSTRAINT ? ", bun.Ident(unique.Name))
+		}
+		b = append(b, "UNIQUE ("...)
+		b = appendColumnNames(fmter, b, unique.Columns)
+		b = append(b, ")"...)
+	}
+	for _, check := range table.GetChecks() {
+		b = append(b, ", "...)
+		if check.Name != "" {
+			b = fmter.AppendQuery(b, "CONSTRAINT ? ", bun.Ident(check.Name))
+		}
+		b = append(b, "CHECK ("...)
+		b = append(b, check.Expression...)
+		b = append(b, ")"...)
+	}
+
+	for _, fk := range SortedForeignKeys(fks) {
+		b = append(b, ", "...)
+		if name := fks[fk]; name != "" {
+			b = fmter.AppendQuery(b, "CONSTRAINT ? ", bun.Ident(name))
+		}
+		b = m.appendForeignKey(fmter, b, schemaName, fk)
+	}
+
+	b = append(b, ")"...)
+	if parents := table.GetInherits(); len(parents) > 0 {
+		b = append(b, " INHERITS ("...)
+		for i, parent := range parents {
+			if i > 0 {
+				b = append(b, ", "...)
+			}
+			parentSchema, parentName := SplitTableKey(parent)
+			if parentSchema == "" {
+				parentSchema = schemaName
+			}
+			b = fmter.AppendQuery(b, "?.?", bun.Ident(parentSchema), bun.Ident(parentName))
+		}
+		b = append(b, ")"...)
+	}
+	if p := table.GetPartitioning(); p != nil {
+		b = append(b, " PARTITION BY "...)
+		b = append(b, p.Strategy...)
+		b = append(b, " ("...)
+		b = append(b, p.Key...)
+		b = append(b, ")"...)
+	}
+	return b, nil
+}
+
+// appendColumnDefinition appends the column's type and constraints, leaving out the ones that
+// do not apply to generated columns.
+func (m *BaseMigrator) appendColumnDefinition(fmter schema.Formatter, b []byte, col Column) (_ []byte, err error) {
+	seq, hasSeq := m.db.Dialect().(ColumnSequenceAppender)
+	if hasSeq && (col.GetIsIdentity() || col.GetIsAutoIncrement()) {
+		b, err = seq.AppendColumnSequence(fmter, b, col)
+	} else {
+		b, err = col.AppendQuery(fmter, b)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if collation := col.GetCollation(); collation != "" {
+		b = append(b, " COLLATE "...)
+		b = fmter.AppendIdent(b, collation)
+	}
+	if expr := col.GetGeneratedExpr(); expr != "" {
+		b = append(b, " GENERATED ALWAYS AS ("...)
+		b = append(b, expr...)
+		b = append(b, ")"...)
+		if col.GetGeneratedStored() {
+			b = append(b, " STORED"...)
+		}
+		return b, nil
+	}
+	if !col.GetIsNullable() {
+		b = append(b, " NOT NULL"...)
+	}
+	if col.GetDefaultValue() != "" {
+		b = append(b, " DEFAULT "...)
+		b = AppendDefault(b, col.GetDefaultValue())
+	}
+	return b, nil
+}
+
+// AppendAddForeignKey appends ALTER TABLE statement which adds the foreign key to the table in schemaName.
+// Unnamed constraints are named by the database.
+func (m *BaseMigrator) AppendAddForeignKey(b []byte, schemaName string, fk ForeignKey, name string) []byte {
+	fmter := m.db.Formatter()
+
+	_, tableName := SplitTableKey(fk.From.TableName)
+	b = fmter.AppendQuery(b, "ALTER TABLE ?.? ADD ", bun.Ident(schemaName), bun.Ident(tableName))
+	if name != "" {
+		b = fmter.AppendQuery(b, "CONSTRAINT ? ", bun.Ident(name))
+	}
+	return m.appendForeignKey(fmter, b, schemaName, fk)
+}
+
+// appendForeignKey appends the FOREIGN KEY constraint without its name. The referenced table
+// is qualified with schemaName, unless its key includes the schema, see InspectorConfig.TableKey.
+func (m *BaseMigrator) appendForeignKey(fmter schema.Formatter, b []byte, schemaName string, fk ForeignKey) []byte {
+	b = append(b, "FOREIGN KEY ("...)
+	b = appendColumnNames(fmter, b, fk.From.Column)
+	b = append(b, ") REFERENCES "...)
+
+	refSchema, refTable := SplitTableKey(fk.To.TableName)
+	if refSchema == "" {
+		refSchema = schemaName
+	}
+	b = fmter.AppendQuery(b, "?.? (", bun.Ident(refSchema), bun.Ident(refTable))
+	b = appendColumnNames(fmter, b, fk.To.Column)
+	b = append(b, ")"...)
+
+	if fk.Match != MatchSimple {
+		b = append(b, " MATCH "...)
+		b = append(b, fk.Match.String()...)
+	}
+	if fk.OnDelete != NoAction {
+		b = append(b, " ON DELETE "...)
+		b = append(b, fk.OnDelete.String()...)
+	}
+	if fk.OnUpdate != NoAction {
+		b = append(b, " ON UPDATE "...)
+		b = append(b, fk.OnUpdate.String()...)
+	}
+	if fk.Deferrable {
+		b = append(b, " DEFERRABLE"...)
+		if fk.InitiallyDeferred {
+			b = append(b, " INITIALLY DEFERRED"...)
+		}
+	}
+	return b
+}
+
+// appendColumnNames appends a comma-separated list of column names quoted by the dialect.
+func appendColumnNames(fmter schema.Formatter, b []byte, columns Columns) []byte {
+	for i, name := range columns.Split() {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = fmter.AppendName(b, name)
+	}
+	return b
 }
 
 func (m *BaseMigrator) AppendDropTable(b []byte, schemaName, tableName string) ([]byte, error) {
@@ -0,0 +1,160 @@
+// Package sqlschematest helps test the SQL that dialects generate for schema changes
+// by comparing it to golden files checked in next to the tests.
+//
+// Golden files are stored in testdata/<name>.<dialect>.sql, one statement per line.
+// Run the tests with the -update-golden flag to create or update them:
+//
+//	go test . -run TestMigrations -update-golden
+package sqlschematest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/migrate"
+	"github.com/uptrace/bun/migrate/sqlschema"
+)
+
+var update = flag.Bool("update-golden", false, "update golden files with the generated SQL")
+
+// GoldenDir is the directory where golden files are stored, relative to the package under test.
+var GoldenDir = "testdata"
+
+// AssertChangeset generates SQL for the changeset in the dialect of db and compares it to the golden file.
+// The statements are generated for tables in the dialect's default schema.
+func AssertChangeset(tb testing.TB, db *bun.DB, changes *migrate.Changeset, name string) {
+	tb.Helper()
+
+	m, err := sqlschema.NewMigrator(db, db.Dialect().DefaultSchema())
+	if err != nil {
+		tb.Fatal(err)
+	}
+	statements, err := changes.Statements(m)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	AssertStatements(tb, db, statements, name)
+}
+
+// AssertState generates the statements which create all tables in the state, in their order,
+// in the dialect of db and compares them to the golden file. See migrate.CreateTableStatements.
+func AssertState(tb testing.TB, db *bun.DB, state sqlschema.Database, name string) {
+	tb.Helper()
+
+	var statements []migrate.Statement
+	for _, tableName := range state.GetTables().Keys() {
+		stmts, err := migrate.CreateTableStatements(db, db.Dialect().DefaultSchema(), state, tableName)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		statements = append(statements, stmts...)
+	}
+	AssertStatements(tb, db, statements, name)
+}
+
+// AssertStatements compares the SQL of the statements to the golden file for the dialect of db.
+func AssertStatements(tb testing.TB, db *bun.DB, statements []migrate.Statement, name string) {
+	tb.Helper()
+
+	sqls := make([]string, len(statements))
+	for i, stmt := range statements {
+		sqls[i] = stmt.SQL
+	}
+	AssertGolden(tb, name+"."+db.Dialect().Name().String(), sqls...)
+}
+
+// AssertGolden compares the SQL statements to the ones in GoldenDir/<name>.sql, or writes them there
+// if the -update-golden flag is set. Statements are compared with insignificant whitespace collapsed,
+// so they may be split or indented differently in the golden file.
+func AssertGolden(tb testing.TB, name string, sqls ...string) {
+	tb.Helper()
+
+	got := format(sqls)
+	path := filepath.Join(GoldenDir, name+".sql")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			tb.Fatal(err)
+		}
+		return
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("read golden file: %v (run with -update-golden to create it)", err)
+	}
+	if want := string(b); Normalize(want) != Normalize(got) {
+		tb.Errorf("generated SQL does not match %s (run with -update-golden to update it)\n--- want\n%s\n--- got\n%s",
+			path, want, got)
+	}
+}
+
+// format writes each statement on a separate line terminated with a semicolon.
+func format(sqls []string) string {
+	var b strings.Builder
+	for _, sql := range sqls {
+		b.WriteString(collapseSpaces(sql, false))
+		b.WriteString(";\n")
+	}
+	return b.String()
+}
+
+// Normalize collapses insignificant whitespace in SQL and removes "--" comment lines,
+// so that the same statements compare equal regardless of their layout.
+// Whitespace inside quoted strings and identifiers is preserved.
+func Normalize(sql string) string {
+	var lines []string
+	for _, line := range strings.Split(sql, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return collapseSpaces(strings.Join(lines, "\n"), true)
+}
+
+// collapseSpaces replaces each run of whitespace outside of quotes with a single space.
+// If trimPunct is set, whitespace around punctuation is removed altogether, e.g. "( id )" -> "(id)".
+func collapseSpaces(sql string, trimPunct bool) string {
+	var b strings.Builder
+	var quote rune
+	space := false
+	for _, r := range strings.TrimSpace(sql) {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			space = true
+			continue
+		}
+		if space {
+			if !trimPunct || !isPunct(r) && !isPunct(lastRune(b.String())) {
+				b.WriteByte(' ')
+			}
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isPunct(r rune) bool {
+	return strings.ContainsRune("(),;", r)
+}
+
+func lastRune(s string) rune {
+	if s == "" {
+		return 0
+	}
+	return rune(s[len(s)-1])
+}
@@ -10,6 +10,14 @@ type Table interface {
 	GetColumns() *ordered.Map[string, Column]
 	GetPrimaryKey() *PrimaryKey
 	GetUniqueConstraints() []Unique
+	GetChecks() []Check
+	GetExclusions() []ExclusionConstraint
+	GetPartitioning() *Partitioning
+	GetPartitionOf() string
+	GetInherits() []string
+	GetRowSecurity() RowSecurity
+	GetTablespace() string
+	GetComment() string
 }
 
 var _ Table = (*BaseTable)(nil)
@@ -31,6 +39,41 @@ type BaseTable struct {
 
 	// UniqueConstraints defined on the table.
 	UniqueConstraints []Unique
+
+	// Checks are CHECK constraints defined on the table.
+	// Column-level checks are stored here too, as most dialects do not distinguish between the two.
+	Checks []Check
+
+	// Exclusions are EXCLUDE constraints defined on the table.
+	Exclusions []ExclusionConstraint
+
+	// Partitioning is set for partitioned tables, whose rows are stored in their partitions.
+	Partitioning *Partitioning
+
+	// PartitionOf is the key of the partitioned table, if this table is one of its partitions.
+	PartitionOf string
+
+	// Inherits lists the keys of the parent tables in PostgreSQL table inheritance, e.g. CREATE TABLE cats () INHERITS (pets).
+	// Columns only list the ones defined by the table itself, the inherited columns belong to the parents.
+	Inherits []string
+
+	// RowSecurity controls whether the row-level security policies apply to the table, see Policy.
+	RowSecurity RowSecurity
+
+	// Tablespace the table is stored in. Empty tablespace means the database's default one.
+	Tablespace string
+
+	// Comment on the table. Empty comment means the table has none.
+	Comment string
+}
+
+// Partitioning describes how a partitioned table distributes its rows among the partitions.
+type Partitioning struct {
+	// Strategy is one of "RANGE", "LIST", or "HASH".
+	Strategy string
+
+	// Key lists the partition key columns or expressions, e.g. "created_at" or "lower(region)".
+	Key string
 }
 
 // PrimaryKey represents a primary key constraint defined on 1 or more columns.
@@ -58,3 +101,38 @@ func (td *BaseTable) GetPrimaryKey() *PrimaryKey {
 func (td *BaseTable) GetUniqueConstraints() []Unique {
 	return td.UniqueConstraints
 }
+
+func (td *BaseTable) GetChecks() []Check {
+	return td.Checks
+}
+
+func (td *BaseTable) GetExclusions() []ExclusionConstraint {
+	return td.Exclusions
+}
+
+// GetPartitioning returns the partitioning scheme of the table, or nil if the table is not partitioned.
+func (td *BaseTable) GetPartitioning() *Partitioning {
+	return td.Partitioning
+}
+
+// GetPartitionOf returns the key of the parent table for the partitions and an empty string for regular tables.
+func (td *BaseTable) GetPartitionOf() string {
+	return td.PartitionOf
+}
+
+// GetInherits returns the keys of the parent tables, or nil if the table does not inherit from any.
+func (td *BaseTable) GetInherits() []string {
+	return td.Inherits
+}
+
+func (td *BaseTable) GetRowSecurity() RowSecurity {
+	return td.RowSecurity
+}
+
+func (td *BaseTable) GetTablespace() string {
+	return td.Tablespace
+}
+
+func (td *BaseTable) GetComment() string {
+	return td.Comment
+}
@@ -0,0 +1,263 @@
+package sqlschema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/uptrace/bun/internal/ordered"
+)
+
+// YAMLInspector creates the desired schema state from a declarative YAML file.
+// It can be used in place of BunModelInspector for projects which do not describe their schema with bun models:
+//
+//	tables:
+//	  - name: authors
+//	    columns:
+//	      - name: id
+//	        type: bigint
+//	        nullable: false
+//	      - name: email
+//	        type: varchar(100)
+//	        default: "'anonymous'"
+//	    primary_key: [id]
+//	    unique: [[email]]
+//	  - name: books
+//	    columns:
+//	      - {name: id, type: bigint, nullable: false}
+//	      - {name: author_id, type: bigint}
+//	    primary_key: [id]
+//	    foreign_keys:
+//	      - columns: [author_id]
+//	        references: {table: authors, columns: [id]}
+//	        on_delete: cascade
+//
+// Columns are nullable unless declared otherwise. Tables are created in the schema set with WithSchemaName.
+type YAMLInspector struct {
+	InspectorConfig
+	fsys fs.FS
+	name string
+}
+
+var _ Inspector = (*YAMLInspector)(nil)
+
+// NewYAMLInspector creates an inspector that reads the schema definition from the named file in fsys.
+func NewYAMLInspector(fsys fs.FS, name string, options ...InspectorOption) *YAMLInspector {
+	yi := &YAMLInspector{
+		fsys: fsys,
+		name: name,
+	}
+	ApplyInspectorOptions(&yi.InspectorConfig, options...)
+	return yi
+}
+
+func (yi *YAMLInspector) Inspect(ctx context.Context) (Database, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f, err := yi.fsys.Open(yi.name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return yi.load(f)
+}
+
+func (yi *YAMLInspector) load(r io.Reader) (Database, error) {
+	var file yamlSchema
+
+	if err := yaml.NewDecoder(r).Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", yi.name, err)
+	}
+
+	state := BaseDatabase{
+		Tables:      ordered.NewMap[string, Table](),
+		ForeignKeys: make(map[ForeignKey]string),
+		Enums:       make(map[string][]string),
+	}
+
+	// Collect all tables first, so that foreign keys can reference tables declared further down.
+	for _, t := range file.Tables {
+		if t.Name == "" {
+			return nil, yi.errorf(t.line, "table name is required")
+		}
+		if _, ok := state.Tables.Load(t.Name); ok {
+			return nil, yi.errorf(t.line, "duplicate table %q", t.Name)
+		}
+
+		table, err := yi.table(t)
+		if err != nil {
+			return nil, err
+		}
+		state.Tables.Store(t.Name, table)
+	}
+
+	for _, t := range file.Tables {
+		for _, fk := range t.ForeignKeys {
+			if len(fk.Columns) == 0 {
+				return nil, yi.errorf(fk.line, "foreign key in table %q must have columns", t.Name)
+			}
+			if err := yi.checkColumns(fk.line, state.Tables.Value(t.Name), fk.Columns); err != nil {
+				return nil, err
+			}
+
+			if fk.References.Table == "" {
+				return nil, yi.errorf(fk.line, "foreign key in table %q must reference a table", t.Name)
+			}
+			target, ok := state.Tables.Load(fk.References.Table)
+			if !ok {
+				return nil, yi.errorf(fk.References.line, "foreign key in table %q references unknown table %q", t.Name, fk.References.Table)
+			}
+			if len(fk.References.Columns) != len(fk.Columns) {
+				return nil, yi.errorf(fk.References.line, "foreign key in table %q references %d columns, want %d",
+					t.Name, len(fk.References.Columns), len(fk.Columns))
+			}
+			if err := yi.checkColumns(fk.References.line, target, fk.References.Columns); err != nil {
+				return nil, err
+			}
+
+			name := fk.Name
+			if name == "" {
+				name = defaultForeignKeyName(t.Name, fk.Columns)
+			}
+			state.ForeignKeys[ForeignKey{
+				From:     NewColumnReference(t.Name, fk.Columns...),
+				To:       NewColumnReference(fk.References.Table, fk.References.Columns...),
+				OnDelete: NewReferentialAction(fk.OnDelete),
+				OnUpdate: NewReferentialAction(fk.OnUpdate),
+			}.Canonical()] = name
+		}
+	}
+	return state, nil
+}
+
+func (yi *YAMLInspector) table(t yamlTable) (*BaseTable, error) {
+	columns := ordered.NewMap[string, Column]()
+	for _, c := range t.Columns {
+		if c.Name == "" {
+			return nil, yi.errorf(c.line, "column name in table %q is required", t.Name)
+		}
+		if c.Type == "" {
+			return nil, yi.errorf(c.line, "column %q in table %q must have a type", c.Name, t.Name)
+		}
+		if _, ok := columns.Load(c.Name); ok {
+			return nil, yi.errorf(c.line, "duplicate column %q in table %q", c.Name, t.Name)
+		}
+
+		col, err := parseSQLType(c.Type)
+		if err != nil {
+			return nil, yi.errorf(c.line, "column %q in table %q: %v", c.Name, t.Name, err)
+		}
+		col.Name = c.Name
+		col.IsNullable = c.Nullable == nil || *c.Nullable
+		col.DefaultValue = exprOrLiteral(c.Default)
+		columns.Store(c.Name, &col)
+	}
+
+	table := &BaseTable{
+		Schema:  yi.SchemaName,
+		Name:    t.Name,
+		Columns: columns,
+	}
+
+	if len(t.PrimaryKey) > 0 {
+		if err := yi.checkColumns(t.line, table, t.PrimaryKey); err != nil {
+			return nil, err
+		}
+		table.PrimaryKey = &PrimaryKey{Columns: NewColumns(t.PrimaryKey...)}
+	}
+	for _, unique := range t.Unique {
+		if err := yi.checkColumns(t.line, table, unique); err != nil {
+			return nil, err
+		}
+		table.UniqueConstraints = append(table.UniqueConstraints, Unique{Columns: NewColumns(unique...)})
+	}
+	for _, expr := range t.Checks {
+		table.Checks = append(table.Checks, Check{Expression: expr})
+	}
+	return table, nil
+}
+
+// checkColumns reports the first column which does not exist in the table.
+func (yi *YAMLInspector) checkColumns(line int, table Table, columns []string) error {
+	for _, name := range columns {
+		if _, ok := table.GetColumns().Load(name); !ok {
+			return yi.errorf(line, "unknown column %q in table %q", name, table.GetName())
+		}
+	}
+	return nil
+}
+
+func (yi *YAMLInspector) errorf(line int, format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s", yi.name, line, fmt.Sprintf(format, args...))
+}
+
+type yamlSchema struct {
+	Tables []yamlTable `yaml:"tables"`
+}
+
+type yamlTable struct {
+	Name        string           `yaml:"name"`
+	Columns     []yamlColumn     `yaml:"columns"`
+	PrimaryKey  []string         `yaml:"primary_key"`
+	Unique      [][]string       `yaml:"unique"`
+	Checks      []string         `yaml:"checks"`
+	ForeignKeys []yamlForeignKey `yaml:"foreign_keys"`
+
+	line int
+}
+
+func (t *yamlTable) UnmarshalYAML(node *yaml.Node) error {
+	type plain yamlTable
+	t.line = node.Line
+	return node.Decode((*plain)(t))
+}
+
+type yamlColumn struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"`
+	Nullable *bool  `yaml:"nullable"`
+	Default  string `yaml:"default"`
+
+	line int
+}
+
+func (c *yamlColumn) UnmarshalYAML(node *yaml.Node) error {
+	type plain yamlColumn
+	c.line = node.Line
+	return node.Decode((*plain)(c))
+}
+
+type yamlForeignKey struct {
+	Name       string              `yaml:"name"`
+	Columns    []string            `yaml:"columns"`
+	References yamlColumnReference `yaml:"references"`
+	OnDelete   string              `yaml:"on_delete"`
+	OnUpdate   string              `yaml:"on_update"`
+
+	line int
+}
+
+func (fk *yamlForeignKey) UnmarshalYAML(node *yaml.Node) error {
+	type plain yamlForeignKey
+	fk.line = node.Line
+	return node.Decode((*plain)(fk))
+}
+
+type yamlColumnReference struct {
+	Table   string   `yaml:"table"`
+	Columns []string `yaml:"columns"`
+
+	line int
+}
+
+func (ref *yamlColumnReference) UnmarshalYAML(node *yaml.Node) error {
+	type plain yamlColumnReference
+	ref.line = node.Line
+	return node.Decode((*plain)(ref))
+}
@@ -0,0 +1,157 @@
+package migrate
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/uptrace/bun/migrate/sqlschema"
+)
+
+// replacesDirective lists the migrations replaced by a squashed one in its up.sql file, e.g.
+//
+//	--bun:replaces 20240101120000 20240102093000
+const replacesDirective = "replaces "
+
+// Squash writes a baseline migration, which creates the schema state from scratch, to replace all registered
+// migrations, so that fresh databases are set up with a single migration instead of replaying the history.
+// The up.sql file is generated by Export and the down.sql file drops the created objects again.
+// The names of the replaced migrations are recorded in the up.sql file, see Migration.Replaces,
+// and the replaced migration files can be deleted once the baseline is committed.
+//
+// Migrate only executes the baseline in a database which has none of the replaced migrations applied.
+// A database which is already past the squashed point only records the baseline as applied,
+// and one in the middle of the replaced migrations applies the remaining ones first, which must still exist.
+func (m *Migrator) Squash(ctx context.Context, name string, state sqlschema.Database) ([]*MigrationFile, error) {
+	replaces := squashedMigrations(m.migrations.ms)
+	if len(replaces) == 0 {
+		return nil, errors.New("migrate: there are no migrations to squash")
+	}
+
+	name, err := genMigrationName(name)
+	if err != nil {
+		return nil, err
+	}
+	up, down, err := export(m.db, m.db.Dialect().DefaultSchema(), state, exportConfig{})
+	if err != nil {
+		return nil, fmt.Errorf("squash: %w", err)
+	}
+
+	header := "--bun:" + replacesDirective + strings.Join(replaces, " ") + "\n"
+	upFile, err := m.writeSQL(name+".up.sql", header, up)
+	if err != nil {
+		return nil, fmt.Errorf("squash: %w", err)
+	}
+	downFile, err := m.writeSQL(name+".down.sql", "", down)
+	if err != nil {
+		return nil, fmt.Errorf("squash: %w", err)
+	}
+	return []*MigrationFile{upFile, downFile}, nil
+}
+
+func (m *Migrator) writeSQL(fname, header string, script []string) (*MigrationFile, error) {
+	var b strings.Builder
+	b.WriteString(header)
+	for _, stmt := range script {
+		b.WriteString(stmt)
+		b.WriteString(";\n")
+	}
+	content := b.String()
+
+	fpath := filepath.Join(m.migrations.getDirectory(), fname)
+	if err := os.WriteFile(fpath, []byte(content), 0o644); err != nil {
+		return nil, err
+	}
+	return &MigrationFile{Name: fname, Path: fpath, Content: content}, nil
+}
+
+// squashedMigrations returns the sorted names of the migrations and of the ones they replace in turn,
+// so that a database which was set up from an earlier baseline is recognized as well.
+func squashedMigrations(ms MigrationSlice) []string {
+	var names []string
+	for _, migration := range ms {
+		names = append(names, migration.Name)
+		names = append(names, migration.Replaces...)
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// readReplaces reads the names of the replaced migrations from the directives in the SQL migration file.
+func readReplaces(fsys fs.FS, path string) ([]string, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	const prefix = "--bun:" + replacesDirective
+	var replaces []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, prefix) {
+			replaces = append(replaces, strings.Fields(line[len(prefix):])...)
+		}
+	}
+	return replaces, scanner.Err()
+}
+
+// pendingMigrations returns the unapplied migrations which Migrate must run, in ascending order.
+//
+// The migrations replaced by a baseline are left out if the baseline is applied, or if none of them are,
+// in which case the baseline creates the schema. If the newest replaced migration is applied, the database
+// is past the squashed point and the baseline is only marked as applied. Otherwise, the database is in
+// the middle of the replaced migrations, which are applied before marking the baseline, if they still exist.
+func pendingMigrations(sorted, applied MigrationSlice) (MigrationSlice, error) {
+	existing := migrationMap(sorted)
+	isApplied := migrationMap(applied)
+	skip := make(map[string]bool)
+	adopt := make(map[string]bool)
+
+	for _, baseline := range sorted {
+		if len(baseline.Replaces) == 0 {
+			continue
+		}
+		// lastApplied is the newest replaced migration in the database. The older ones are either
+		// applied as well or were replaced by an earlier baseline.
+		var lastApplied string
+		for _, name := range baseline.Replaces {
+			if isApplied[name] != nil {
+				lastApplied = max(lastApplied, name)
+			}
+		}
+
+		if !baseline.IsApplied() && lastApplied != "" {
+			adopt[baseline.Name] = true
+		}
+		for _, name := range baseline.Replaces {
+			if baseline.IsApplied() || lastApplied == "" || name < lastApplied {
+				skip[name] = true
+				continue
+			}
+			if isApplied[name] == nil && existing[name] == nil {
+				return nil, fmt.Errorf(
+					"migrate: database is in the middle of the migrations replaced by %s, but %s is missing",
+					baseline.Name, name)
+			}
+		}
+	}
+
+	var pending MigrationSlice
+	for _, m := range sorted {
+		if m.IsApplied() || skip[m.Name] {
+			continue
+		}
+		if adopt[m.Name] {
+			m.Up = nil
+		}
+		pending = append(pending, m)
+	}
+	return pending, nil
+}
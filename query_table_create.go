@@ -177,7 +177,34 @@ func (q *CreateTableQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []by
 
 		b = append(b, field.SQLName...)
 		b = append(b, " "...)
+
+		// MSSQL computed columns have neither a type nor a default: "col AS (expr) [PERSISTED [NOT NULL]]".
+		if expr, ok := field.Tag.Option("generated"); ok && q.db.dialect.Name() == dialect.MSSQL {
+			b = append(b, "AS ("...)
+			b = append(b, expr...)
+			b = append(b, ")"...)
+			if field.Tag.HasOption("stored") {
+				b = append(b, " PERSISTED"...)
+				if field.NotNull {
+					b = append(b, " NOT NULL"...)
+				}
+			}
+			continue
+		}
+
 		b = q.appendSQLType(b, field)
+		if collation, ok := field.Tag.Option("collate"); ok {
+			b = append(b, " COLLATE "...)
+			b = fmter.AppendIdent(b, collation)
+		}
+		if expr, ok := field.Tag.Option("generated"); ok {
+			b = append(b, " GENERATED ALWAYS AS ("...)
+			b = append(b, expr...)
+			b = append(b, ")"...)
+			if field.Tag.HasOption("stored") {
+				b = append(b, " STORED"...)
+			}
+		}
 		if field.NotNull && q.db.dialect.Name() != dialect.Oracle {
 			b = append(b, " NOT NULL"...)
 		}
@@ -213,6 +240,7 @@ func (q *CreateTableQuery) AppendQuery(fmter schema.Formatter, b []byte) (_ []by
 		b = q.appendPKConstraint(b, q.table.PKs)
 	}
 	b = q.appendUniqueConstraints(fmter, b)
+	b = q.appendCheckConstraints(b)
 
 	if q.fksFromRel {
 		b, err = q.appendFKConstraintsRel(fmter, b)
@@ -307,6 +335,18 @@ func (q *CreateTableQuery) appendUniqueConstraint(
 	return b
 }
 
+// appendCheckConstraints appends a CHECK clause for each `bun:",check:expr"` declared on the model's fields.
+func (q *CreateTableQuery) appendCheckConstraints(b []byte) []byte {
+	for _, field := range q.table.Fields {
+		for _, expr := range field.Tag.Options["check"] {
+			b = append(b, ", CHECK ("...)
+			b = append(b, expr...)
+			b = append(b, ")"...)
+		}
+	}
+	return b
+}
+
 // appendFKConstraintsRel appends a FOREIGN KEY clause for each of the model's existing relations.
 func (q *CreateTableQuery) appendFKConstraintsRel(fmter schema.Formatter, b []byte) (_ []byte, err error) {
 	for _, rel := range q.tableModel.Table().Relations {
@@ -35,6 +35,15 @@ type Field struct {
 	AutoIncrement bool
 	Identity      bool
 
+	// IdentityAlways is set for identity columns declared with "identity:always", whose values
+	// are always generated by the database. Other identity columns are GENERATED BY DEFAULT.
+	IdentityAlways bool
+
+	// IdentityStart and IdentityIncrement are the options of the identity column's sequence,
+	// declared with "identity_start" and "identity_increment". Zero means the database default.
+	IdentityStart     int64
+	IdentityIncrement int64
+
 	Append AppenderFunc
 	Scan   ScannerFunc
 	IsZero IsZeroerFunc
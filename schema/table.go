@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -53,6 +54,28 @@ type Table struct {
 	Alias             string
 	SQLAlias          Safe
 
+	// RenamedFrom is the previous name of the table, as declared with the "rename_from" tag option.
+	// It is only used by the auto-migrator.
+	RenamedFrom string
+
+	// Comment is the table comment declared with the "comment" tag option, e.g. `bun:"table:users,comment:Registered users"`.
+	// It is only used by the auto-migrator.
+	Comment string
+
+	// Inherits lists the parent tables declared with the "inherits" tag option, e.g. `bun:"table:cats,inherits:pets"`.
+	// The option may be repeated for several parents. It is only used by the auto-migrator.
+	Inherits []string
+
+	// RowSecurity enables row-level security on the table, as declared with the "rls" tag option, e.g. `bun:"table:documents,rls"`.
+	// ForceRowSecurity is set with "rls:force" to apply the policies to the owner of the table too.
+	// They are only used by the auto-migrator.
+	RowSecurity      bool
+	ForceRowSecurity bool
+
+	// Tablespace is the tablespace declared with the "tablespace" tag option, e.g. `bun:"table:events,tablespace:archive"`.
+	// It is only used by the auto-migrator.
+	Tablespace string
+
 	allFields  []*Field // all fields including scanonly
 	Fields     []*Field // PKs + DataFields
 	PKs        []*Field
@@ -446,6 +469,38 @@ func (t *Table) processBaseModelField(f reflect.StructField) {
 		t.Alias = s
 		t.SQLAlias = t.quoteIdent(s)
 	}
+
+	if s, ok := tag.Option("rename_from"); ok {
+		t.RenamedFrom = s
+	}
+
+	if s, ok := tag.Option("comment"); ok {
+		t.Comment = s
+	}
+
+	t.Inherits = tag.Options["inherits"]
+
+	if s, ok := tag.Option("rls"); ok {
+		t.RowSecurity = true
+		t.ForceRowSecurity = s == "force"
+	}
+
+	if s, ok := tag.Option("tablespace"); ok {
+		t.Tablespace = s
+	}
+}
+
+// intOption parses the value of an integer tag option, returning 0 if the option is not set.
+func (t *Table) intOption(field *Field, name string) int64 {
+	s, ok := field.Tag.Option(name)
+	if !ok {
+		return 0
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		panic(fmt.Errorf("bun: %s.%s: %s option must be an integer, got %q", t.TypeName, field.GoName, name, s))
+	}
+	return n
 }
 
 // schemaFromTagName splits the bun.BaseModel tag name into schema and table name
@@ -505,9 +560,12 @@ func (t *Table) newField(sf reflect.StructField, tag tagparser.Tag) *Field {
 		field.AutoIncrement = true
 		field.NullZero = true
 	}
-	if tag.HasOption("identity") {
+	if v, ok := tag.Option("identity"); ok {
 		field.Identity = true
+		field.IdentityAlways = strings.EqualFold(v, "always")
 	}
+	field.IdentityStart = t.intOption(field, "identity_start")
+	field.IdentityIncrement = t.intOption(field, "identity_increment")
 
 	if v, ok := tag.Options["unique"]; ok {
 		t.addUnique(field, "", v)
@@ -949,7 +1007,7 @@ func (t *Table) quoteIdent(s string) Safe {
 
 func isKnownTableOption(name string) bool {
 	switch name {
-	case "table", "alias", "select":
+	case "table", "alias", "select", "rename_from", "comment", "inherits", "rls", "tablespace":
 		return true
 	}
 	return false
@@ -970,6 +1028,17 @@ func isKnownFieldOption(name string) bool {
 		"nullzero",
 		"default",
 		"unique",
+		"index",
+		"unique_index",
+		"check",
+		"comment",
+		"collate",
+		"storage",
+		"generated",
+		"stored",
+		"rename_from",
+		"backfill",
+		"using",
 		"soft_delete",
 		"scanonly",
 		"skipupdate",
@@ -981,9 +1050,14 @@ func isKnownFieldOption(name string) bool {
 		"join_on",
 		"on_update",
 		"on_delete",
+		"match",
+		"deferrable",
+		"initially_deferred",
 		"m2m",
 		"polymorphic",
-		"identity":
+		"identity",
+		"identity_start",
+		"identity_increment":
 		return true
 	}
 	return false
@@ -25,6 +25,11 @@ func NewTables(dialect Dialect) *Tables {
 	}
 }
 
+// Dialect returns the dialect the tables are registered for.
+func (t *Tables) Dialect() Dialect {
+	return t.dialect
+}
+
 func (t *Tables) Register(models ...interface{}) {
 	for _, model := range models {
 		_ = t.Get(reflect.TypeOf(model).Elem())